@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stlalpha/vision3/internal/ban"
+)
+
+// openBanList opens data/bans.json under dataDir or exits.
+func openBanList(dataDir string) *ban.List {
+	l, err := ban.Open(filepath.Join(dataDir, ban.FileName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return l
+}
+
+// splitTarget takes a leading positional argument off args so it can come
+// before the flags, as in "helper bans add 192.0.2.1 --for 7d".
+func splitTarget(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+func cmdBansList(args []string) {
+	fs := flag.NewFlagSet("bans list", flag.ExitOnError)
+	dataDir := fs.String("data", "data", "Data directory")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper bans list [options]\n\n")
+		fmt.Fprintf(os.Stderr, "List active IP bans.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	bans := openBanList(*dataDir).Bans()
+	if len(bans) == 0 {
+		fmt.Println("No IP bans.")
+		return
+	}
+	fmt.Printf("%-5s  %-24s  %-16s  %-16s  %-12s  %s\n", "ID", "TARGET", "ADDED", "EXPIRES", "BY", "REASON")
+	for _, b := range bans {
+		expires := "never"
+		if b.Expires != nil {
+			expires = b.Expires.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%-5d  %-24s  %-16s  %-16s  %-12s  %s\n",
+			b.ID, b.Target, b.Added.Format("2006-01-02 15:04"), expires, b.AddedBy, b.Reason)
+	}
+}
+
+func cmdBansAdd(args []string) {
+	target, args := splitTarget(args)
+	fs := flag.NewFlagSet("bans add", flag.ExitOnError)
+	dataDir := fs.String("data", "data", "Data directory")
+	length := fs.String("for", "", "Ban length, e.g. 30m, 12h, 7d, 2w (default: permanent)")
+	reason := fs.String("reason", "", "Why the address is banned")
+	by := fs.String("by", "helper", "Who added the ban")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper bans add <ip|cidr> [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Ban an IP address or range. A running BBS applies it to the next connection.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper bans add 192.0.2.10 --reason \"abusive\"\n")
+		fmt.Fprintf(os.Stderr, "  helper bans add 198.51.100.0/24 --for 7d --reason \"scanner\"\n")
+	}
+	fs.Parse(args)
+	if target == "" {
+		target = fs.Arg(0)
+	}
+	if target == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	d, err := ban.ParseDuration(*length)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	b, err := openBanList(*dataDir).Add(target, *reason, *by, d)
+	if errors.Is(err, ban.ErrExists) {
+		fmt.Fprintf(os.Stderr, "Error: %s is already banned (ban %d)\n", b.Target, b.ID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	until := "permanently"
+	if b.Expires != nil {
+		until = "until " + b.Expires.Format("2006-01-02 15:04")
+	}
+	fmt.Printf("Banned %s %s (ban %d).\n", b.Target, until, b.ID)
+}
+
+func cmdBansRemove(args []string) {
+	target, args := splitTarget(args)
+	fs := flag.NewFlagSet("bans remove", flag.ExitOnError)
+	dataDir := fs.String("data", "data", "Data directory")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper bans remove <id|ip|cidr> [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Lift a ban, by its ID or the banned address or range.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if target == "" {
+		target = fs.Arg(0)
+	}
+	if target == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	list := openBanList(*dataDir)
+	id, err := strconv.Atoi(target)
+	if err != nil {
+		canon, _, perr := ban.ParseTarget(target)
+		if perr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", perr)
+			os.Exit(1)
+		}
+		for _, b := range list.Bans() {
+			if b.Target == canon {
+				id = b.ID
+			}
+		}
+	}
+	b, err := list.Remove(id)
+	if errors.Is(err, ban.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "Error: no ban matches %s\n", target)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Lifted ban %d on %s.\n", b.ID, b.Target)
+}
+
+func cmdBansPrune(args []string) {
+	fs := flag.NewFlagSet("bans prune", flag.ExitOnError)
+	dataDir := fs.String("data", "data", "Data directory")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper bans prune [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Remove expired bans from the ban list.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	n, err := openBanList(*dataDir).Prune()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %d expired ban(s).\n", n)
+}
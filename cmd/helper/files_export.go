@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/file"
+)
+
+// encodeCP437 converts UTF-8 text to CP437 for DOS-era readers. Characters
+// with no CP437 equivalent become '?'.
+func encodeCP437(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		default:
+			if b, ok := ansi.UnicodeToCP437[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// writeListFile writes a generated list to path, CP437-encoded unless utf8
+// is set.
+func writeListFile(path string, buf *bytes.Buffer, utf8 bool) error {
+	data := buf.Bytes()
+	if !utf8 {
+		data = encodeCP437(buf.String())
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func cmdFilesExport(args []string) {
+	fs := flag.NewFlagSet("files export", flag.ExitOnError)
+	areaTag := fs.String("area", "", "Specific file area tag (omit for all areas)")
+	dataDir := fs.String("data", "data", "Data directory")
+	configDir := fs.String("config", "configs", "Config directory")
+	outDir := fs.String("out", "", "Write lists under DIR/<TAG>/ instead of into each area directory")
+	format := fs.String("format", "filesbbs", "Per-area list format: filesbbs or descript")
+	allFiles := fs.String("allfiles", "", "Combined listing path (default: ALLFILES.TXT in --out or <data>/files)")
+	noAllFiles := fs.Bool("no-allfiles", false, "Do not write the combined listing")
+	utf8 := fs.Bool("utf8", false, "Write UTF-8 instead of CP437")
+	dryRun := fs.Bool("dry-run", false, "Show what would be written without writing it")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper files export [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Write a FILES.BBS (or DESCRIPT.ION) for each file area and a combined\n")
+		fmt.Fprintf(os.Stderr, "ALLFILES.TXT listing every area.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper files export\n")
+		fmt.Fprintf(os.Stderr, "  helper files export --area UTILS --no-allfiles\n")
+		fmt.Fprintf(os.Stderr, "  helper files export --out /srv/filebone --format descript\n")
+	}
+	fs.Parse(args)
+
+	listName := "FILES.BBS"
+	writeList := file.WriteFilesBBS
+	switch strings.ToLower(*format) {
+	case string(file.ListFilesBBS):
+	case string(file.ListDescriptIon):
+		listName, writeList = "DESCRIPT.ION", file.WriteDescriptIon
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (want filesbbs or descript)\n", *format)
+		os.Exit(1)
+	}
+
+	areas, err := loadFileAreas(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading file areas: %v\n", err)
+		os.Exit(1)
+	}
+	if *areaTag != "" {
+		area := findAreaByTag(areas, *areaTag)
+		if area == nil {
+			fmt.Fprintf(os.Stderr, "Error: file area %q not found\n", *areaTag)
+			os.Exit(1)
+		}
+		areas = []file.FileArea{*area}
+	}
+	sort.Slice(areas, func(i, j int) bool { return areas[i].ID < areas[j].ID })
+
+	var sections []file.AreaFiles
+	written, errCount := 0, 0
+	for _, area := range areas {
+		areaDir := filepath.Join(*dataDir, "files", area.Path)
+		records, err := loadMetadata(areaDir)
+		if err != nil {
+			fmt.Printf("  ERR   %-12s %v\n", area.Tag, err)
+			errCount++
+			continue
+		}
+		records = file.ListableRecords(records)
+		sections = append(sections, file.AreaFiles{Area: area, Files: records})
+
+		target := filepath.Join(areaDir, listName)
+		if *outDir != "" {
+			target = filepath.Join(*outDir, area.Tag, listName)
+		} else if area.Offline || area.Removable {
+			fmt.Printf("  SKIP  %-12s offline or removable media; use --out\n", area.Tag)
+			continue
+		} else if len(records) == 0 {
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("  LIST  %-12s %5d files -> %s\n", area.Tag, len(records), target)
+			written++
+			continue
+		}
+		var buf bytes.Buffer
+		if err := writeList(&buf, records); err == nil {
+			err = writeListFile(target, &buf, *utf8)
+		}
+		if err != nil {
+			fmt.Printf("  ERR   %-12s %v\n", area.Tag, err)
+			errCount++
+			continue
+		}
+		fmt.Printf("  LIST  %-12s %5d files -> %s\n", area.Tag, len(records), target)
+		written++
+	}
+
+	if !*noAllFiles && len(sections) > 0 {
+		target := *allFiles
+		if target == "" {
+			base := filepath.Join(*dataDir, "files")
+			if *outDir != "" {
+				base = *outDir
+			}
+			target = filepath.Join(base, "ALLFILES.TXT")
+		}
+
+		boardName := "ViSiON/3 BBS"
+		if cfg, err := config.LoadServerConfig(*configDir); err == nil && cfg.BoardName != "" {
+			boardName = cfg.BoardName
+		}
+		title := fmt.Sprintf("%s - All Files (%s)", boardName, time.Now().Format("01-02-06"))
+
+		total := 0
+		for _, sec := range sections {
+			total += len(sec.Files)
+		}
+		var buf bytes.Buffer
+		err := file.WriteAllFiles(&buf, title, sections)
+		if err == nil && !*dryRun {
+			err = writeListFile(target, &buf, *utf8)
+		}
+		if err != nil {
+			fmt.Printf("  ERR   %-12s %v\n", "ALLFILES", err)
+			errCount++
+		} else {
+			fmt.Printf("  ALL   %-12s %5d files -> %s\n", fmt.Sprintf("%d areas", len(sections)), total, target)
+		}
+	}
+
+	fmt.Printf("\nSummary: %d area lists written, %d errors\n", written, errCount)
+	if *dryRun {
+		fmt.Println("(dry run — no files were written)")
+	}
+}
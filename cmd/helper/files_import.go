@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -33,6 +34,10 @@ func cmdFilesImport(args []string) {
 	dryRun := fs.Bool("dry-run", false, "Show what would be imported without making changes")
 	noDIZ := fs.Bool("no-diz", false, "Skip FILE_ID.DIZ extraction from archives")
 	preserveDates := fs.Bool("preserve-dates", false, "Use file modification time as upload date")
+	allowDupes := fs.Bool("allow-dupes", false, "Import files even if identical contents already exist in any area")
+	listPath := fs.String("list", "", "FILES.BBS or DESCRIPT.ION to take descriptions from (default: one found in --dir)")
+	listFormat := fs.String("list-format", "auto", "Description list format: auto, filesbbs or descript")
+	noList := fs.Bool("no-list", false, "Ignore any FILES.BBS or DESCRIPT.ION in the source directory")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: helper files import [options]\n\n")
@@ -43,6 +48,7 @@ func cmdFilesImport(args []string) {
 		fmt.Fprintf(os.Stderr, "  helper files import --dir /mnt/cdrom/files --area GENERAL\n")
 		fmt.Fprintf(os.Stderr, "  helper files import --dir ~/staging --area UTILS --preserve-dates --dry-run\n")
 		fmt.Fprintf(os.Stderr, "  helper files import --dir /tmp/incoming --area UPLOADS --move --uploader Admin\n")
+		fmt.Fprintf(os.Stderr, "  helper files import --dir /mnt/old/utils --area UTILS --list /mnt/old/utils/FILES.BBS\n")
 	}
 	fs.Parse(args)
 
@@ -118,6 +124,23 @@ func cmdFilesImport(args []string) {
 		return
 	}
 
+	if *listPath == "" && !*noList {
+		*listPath = findDescriptionList(entries)
+		if *listPath != "" {
+			*listPath = filepath.Join(*dir, *listPath)
+		}
+	}
+	var listed map[string]file.ListEntry
+	if *listPath != "" {
+		listed, err = loadDescriptionList(*listPath, *listFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading description list: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	index := loadFileIndex(*dataDir, areas)
+
 	arcCfg, err := archiver.LoadConfig(*configDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to load archiver config from %s: %v (using defaults)\n", *configDir, err)
@@ -128,6 +151,9 @@ func cmdFilesImport(args []string) {
 	fmt.Printf("Source:    %s\n", *dir)
 	fmt.Printf("Uploader:  %s\n", *uploader)
 	fmt.Printf("Files:     %d candidates\n", len(candidates))
+	if *listPath != "" {
+		fmt.Printf("List:      %s (%d entries)\n", *listPath, len(listed))
+	}
 	if *dryRun {
 		fmt.Println("Mode:      DRY RUN")
 	} else if *moveFiles {
@@ -144,6 +170,9 @@ func cmdFilesImport(args []string) {
 		name := entry.Name()
 		srcPath := filepath.Join(*dir, name)
 
+		listedEntry, listedOK := listed[strings.ToUpper(name)]
+		delete(listed, strings.ToUpper(name))
+
 		if existingNames[strings.ToUpper(name)] {
 			fmt.Printf("  SKIP  %-40s (duplicate)\n", name)
 			stats.skipped++
@@ -157,13 +186,35 @@ func cmdFilesImport(args []string) {
 			continue
 		}
 
+		hashes, err := file.HashFile(srcPath)
+		if err != nil {
+			fmt.Printf("  ERR   %-40s %v\n", name, err)
+			stats.errors++
+			continue
+		}
+		if loc, dup := index.duplicateOf(hashes.SHA256); dup && !*allowDupes {
+			fmt.Printf("  SKIP  %-40s (identical to %s)\n", name, loc)
+			stats.skipped++
+			continue
+		}
+		if loc := index.similarName(name); loc != "" {
+			fmt.Printf("  WARN  %-40s similar name to %s\n", name, loc)
+		}
+
 		uploadTime := time.Now()
 		if *preserveDates {
 			uploadTime = info.ModTime()
+			if listedOK && !listedEntry.Date.IsZero() {
+				uploadTime = listedEntry.Date
+			}
+		}
+		if listedOK && listedEntry.Size > 0 && listedEntry.Size != info.Size() {
+			fmt.Printf("  WARN  %-40s listed as %d bytes, file is %d\n", name, listedEntry.Size, info.Size())
 		}
 
-		var description string
-		if !*noDIZ && arcCfg.IsSupported(name) {
+		// A curated description from the list wins over FILE_ID.DIZ.
+		description := listedEntry.Description
+		if description == "" && !*noDIZ && arcCfg.IsSupported(name) {
 			diz, dizErr := ziplab.ExtractDIZFromArchive(srcPath, *configDir)
 			if dizErr != nil {
 				fmt.Printf("  WARN  %-40s DIZ extraction failed: %v\n", name, dizErr)
@@ -179,9 +230,14 @@ func cmdFilesImport(args []string) {
 				if len(firstLine) > 50 {
 					firstLine = firstLine[:47] + "..."
 				}
-				dizNote = fmt.Sprintf(" [DIZ: %s]", firstLine)
+				source := "DIZ"
+				if listedEntry.Description != "" {
+					source = "LIST"
+				}
+				dizNote = fmt.Sprintf(" [%s: %s]", source, firstLine)
 			}
 			fmt.Printf("  ADD   %-40s %10s%s\n", name, formatSize(info.Size()), dizNote)
+			index.add(area.Tag, file.FileRecord{Filename: name, SHA256: hashes.SHA256})
 			stats.imported++
 			continue
 		}
@@ -206,12 +262,17 @@ func cmdFilesImport(args []string) {
 			Size:        info.Size(),
 			UploadedAt:  uploadTime,
 			UploadedBy:  *uploader,
+			SHA256:      hashes.SHA256,
+			CRC32:       hashes.CRC32,
 		}
 		newRecords = append(newRecords, record)
 		existingNames[strings.ToUpper(name)] = true
+		index.add(area.Tag, record)
 
 		dizTag := ""
-		if description != "" {
+		if listedEntry.Description != "" {
+			dizTag = " +LIST"
+		} else if description != "" {
 			dizTag = " +DIZ"
 		}
 		fmt.Printf("  OK    %-40s %10s%s\n", name, formatSize(info.Size()), dizTag)
@@ -226,18 +287,80 @@ func cmdFilesImport(args []string) {
 		}
 	}
 
+	if len(listed) > 0 {
+		var missing []string
+		for _, e := range listed {
+			missing = append(missing, e.Filename)
+		}
+		sort.Strings(missing)
+		for _, name := range missing {
+			fmt.Printf("  MISS  %-40s (listed, not in source directory)\n", name)
+		}
+	}
+
 	fmt.Printf("\nSummary: %d imported, %d skipped, %d errors\n", stats.imported, stats.skipped, stats.errors)
 	if *dryRun {
 		fmt.Println("(dry run — no files were modified)")
 	}
 }
 
+// findDescriptionList returns the name of a FILES.BBS or DESCRIPT.ION among
+// entries, preferring FILES.BBS, or "" if there is neither.
+func findDescriptionList(entries []os.DirEntry) string {
+	found := ""
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToUpper(e.Name()) {
+		case "FILES.BBS":
+			return e.Name()
+		case "DESCRIPT.ION":
+			found = e.Name()
+		}
+	}
+	return found
+}
+
+// loadDescriptionList parses a FILES.BBS or DESCRIPT.ION, keyed by
+// uppercase filename. The first entry for a name wins.
+func loadDescriptionList(path, format string) (map[string]file.ListEntry, error) {
+	var lf file.ListFormat
+	switch strings.ToLower(format) {
+	case "", "auto":
+		lf = file.DetectListFormat(path)
+	case string(file.ListFilesBBS), string(file.ListDescriptIon):
+		lf = file.ListFormat(strings.ToLower(format))
+	default:
+		return nil, fmt.Errorf("unknown list format %q (want auto, filesbbs or descript)", format)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := file.ParseFileList(f, lf)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	listed := make(map[string]file.ListEntry, len(entries))
+	for _, e := range entries {
+		key := strings.ToUpper(e.Filename)
+		if _, dup := listed[key]; !dup {
+			listed[key] = e
+		}
+	}
+	return listed, nil
+}
+
 func shouldSkipFile(name string) bool {
 	lower := strings.ToLower(name)
 	if strings.HasPrefix(name, ".") {
 		return true
 	}
-	skipNames := []string{"files.bbs", "metadata.json", "thumbs.db", ".ds_store", "desktop.ini"}
+	skipNames := []string{"files.bbs", "descript.ion", "metadata.json", "thumbs.db", ".ds_store", "desktop.ini"}
 	for _, skip := range skipNames {
 		if lower == skip {
 			return true
@@ -17,6 +17,7 @@ func TestShouldSkipFile(t *testing.T) {
 		{"FILES.BBS", true},
 		{"files.bbs", true},
 		{"metadata.json", true},
+		{"DESCRIPT.ION", true},
 		{".hidden", true},
 		{".DS_Store", true},
 		{"Thumbs.db", true},
@@ -33,6 +34,49 @@ func TestShouldSkipFile(t *testing.T) {
 	}
 }
 
+func TestLoadDescriptionList(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"descript.ion": "b.zip From DESCRIPT.ION\n",
+		"Files.Bbs":    "A.ZIP  First\r\n       continued\r\nA.ZIP  Second copy\r\n",
+		"A.ZIP":        "a",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := findDescriptionList(entries)
+	if name != "Files.Bbs" {
+		t.Fatalf("findDescriptionList = %q, want Files.Bbs", name)
+	}
+
+	listed, err := loadDescriptionList(filepath.Join(dir, name), "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := listed["A.ZIP"].Description; got != "First\ncontinued" {
+		t.Errorf("A.ZIP description = %q, want first entry with continuation", got)
+	}
+
+	listed, err = loadDescriptionList(filepath.Join(dir, "descript.ion"), "auto")
+	if err != nil || listed["B.ZIP"].Description != "From DESCRIPT.ION" {
+		t.Errorf("DESCRIPT.ION = %+v, %v", listed, err)
+	}
+	if _, err := loadDescriptionList(filepath.Join(dir, name), "bogus"); err == nil {
+		t.Error("expected error for unknown list format")
+	}
+}
+
+func TestEncodeCP437(t *testing.T) {
+	if got := encodeCP437("ok ░▓ ☃"); string(got) != "ok \xb0\xb2 ?" {
+		t.Errorf("encodeCP437 = %q", got)
+	}
+}
+
 func TestFindAreaByTag(t *testing.T) {
 	areas := []file.FileArea{
 		{ID: 1, Tag: "GENERAL", Name: "General Files"},
@@ -188,3 +232,57 @@ func TestMoveFile(t *testing.T) {
 		t.Error("source file should not exist after move")
 	}
 }
+
+func TestFileIndex_DuplicatesAndSimilarNames(t *testing.T) {
+	dataDir := t.TempDir()
+	areas := []file.FileArea{
+		{ID: 1, Tag: "UTILS", Path: "utils"},
+		{ID: 2, Tag: "GAMES", Path: "games"},
+	}
+	gamesDir := filepath.Join(dataDir, "files", "games")
+	os.MkdirAll(gamesDir, 0755)
+	if err := saveMetadata(gamesDir, []file.FileRecord{
+		{Filename: "DOOM.ZIP", SHA256: "abc123"},
+		{Filename: "NOHASH.ZIP"},
+	}); err != nil {
+		t.Fatalf("saveMetadata: %v", err)
+	}
+
+	ix := loadFileIndex(dataDir, areas)
+
+	if loc, ok := ix.duplicateOf("ABC123"); !ok || loc != "GAMES/DOOM.ZIP" {
+		t.Errorf("duplicateOf = %q, %v; want GAMES/DOOM.ZIP, true", loc, ok)
+	}
+	if _, ok := ix.duplicateOf("FFFF"); ok {
+		t.Error("unexpected duplicate for unknown hash")
+	}
+	if loc := ix.similarName("doom.arj"); loc != "GAMES/DOOM.ZIP" {
+		t.Errorf("similarName = %q, want GAMES/DOOM.ZIP", loc)
+	}
+
+	ix.add("UTILS", file.FileRecord{Filename: "NEW.ZIP", SHA256: "def456"})
+	if loc, ok := ix.duplicateOf("DEF456"); !ok || loc != "UTILS/NEW.ZIP" {
+		t.Errorf("duplicateOf after add = %q, %v", loc, ok)
+	}
+}
+
+func TestFindDuplicateContent(t *testing.T) {
+	dataDir := t.TempDir()
+	areas := []file.FileArea{
+		{ID: 1, Tag: "UTILS", Path: "utils"},
+		{ID: 2, Tag: "GAMES", Path: "games"},
+	}
+	for _, a := range areas {
+		dir := filepath.Join(dataDir, "files", a.Path)
+		os.MkdirAll(dir, 0755)
+		saveMetadata(dir, []file.FileRecord{{Filename: "SAME.ZIP", SHA256: "AAAA"}, {Filename: a.Tag + ".ZIP", SHA256: a.Tag}})
+	}
+
+	dups := findDuplicateContent(dataDir, areas)
+	if len(dups) != 1 {
+		t.Fatalf("expected 1 duplicate group, got %d: %v", len(dups), dups)
+	}
+	if len(dups[0]) != 2 || dups[0][0] != "GAMES/SAME.ZIP" || dups[0][1] != "UTILS/SAME.ZIP" {
+		t.Errorf("unexpected group: %v", dups[0])
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/file"
+)
+
+func cmdFilesPurgePartials(args []string) {
+	fs := flag.NewFlagSet("files purgepartials", flag.ExitOnError)
+	dataDir := fs.String("data", "data", "Data directory")
+	configDir := fs.String("config", "configs", "Config directory")
+	days := fs.Int("days", -1, "Retention days override (default: read from config.json)")
+	dryRun := fs.Bool("dry-run", false, "Show what would be purged without making changes")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper files purgepartials [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Delete interrupted uploads kept for resuming once they are older\n")
+		fmt.Fprintf(os.Stderr, "than the retention period. Run it with the BBS stopped; while the BBS\n")
+		fmt.Fprintf(os.Stderr, "runs, use the purge_partials internal event instead.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper files purgepartials\n")
+		fmt.Fprintf(os.Stderr, "  helper files purgepartials --days 3 --dry-run\n")
+	}
+	fs.Parse(args)
+
+	retentionDays := *days
+	if retentionDays < 0 {
+		cfg, err := config.LoadServerConfig(*configDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		retentionDays = cfg.PartialRetentionDays
+	}
+
+	if retentionDays < 0 {
+		fmt.Println("Retention days is -1 (never purge). Nothing to do.")
+		return
+	}
+
+	purged, err := file.PurgeStalePartials(filepath.Join(*dataDir, "files"), time.Duration(retentionDays)*24*time.Hour, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error purging partial uploads: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(purged) == 0 {
+		fmt.Printf("No partial uploads eligible for purge (retention: %d days).\n", retentionDays)
+		return
+	}
+
+	verb := "Purged"
+	if *dryRun {
+		verb = "Dry run: would purge"
+	}
+	fmt.Printf("%s %d partial upload(s) (retention: %d days):\n\n", verb, len(purged), retentionDays)
+	for _, p := range purged {
+		fmt.Printf("  %-20s  %-30s  %10d bytes  saved %s\n", p.Handle, p.Filename, p.Size, p.SavedAt.Format("2006-01-02"))
+	}
+}
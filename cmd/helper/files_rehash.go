@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stlalpha/vision3/internal/file"
+)
+
+// fileIndex is the helper's offline equivalent of FileManager's hash index.
+// It maps content hashes and filenames across all areas to an "AREA/FILENAME"
+// location string for duplicate reporting.
+type fileIndex struct {
+	bySHA256 map[string]string
+	names    []indexedName
+}
+
+type indexedName struct {
+	location string
+	filename string
+}
+
+// loadFileIndex reads metadata for every area and indexes the records.
+// Areas whose metadata cannot be read are reported and skipped.
+func loadFileIndex(dataDir string, areas []file.FileArea) *fileIndex {
+	ix := &fileIndex{bySHA256: make(map[string]string)}
+	for _, area := range areas {
+		records, err := loadMetadata(filepath.Join(dataDir, "files", area.Path))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load metadata for %s: %v\n", area.Tag, err)
+			continue
+		}
+		for _, rec := range records {
+			ix.add(area.Tag, rec)
+		}
+	}
+	return ix
+}
+
+// add indexes a record. The first record seen for a given hash wins.
+func (ix *fileIndex) add(areaTag string, rec file.FileRecord) {
+	loc := areaTag + "/" + rec.Filename
+	if rec.SHA256 != "" {
+		key := strings.ToUpper(rec.SHA256)
+		if _, exists := ix.bySHA256[key]; !exists {
+			ix.bySHA256[key] = loc
+		}
+	}
+	ix.names = append(ix.names, indexedName{location: loc, filename: rec.Filename})
+}
+
+// duplicateOf returns the location of a file with identical contents, if any.
+func (ix *fileIndex) duplicateOf(sha string) (string, bool) {
+	loc, ok := ix.bySHA256[strings.ToUpper(sha)]
+	return loc, ok
+}
+
+// similarName returns the location of a file whose name differs from name
+// only by case, punctuation, or extension, or "" if there is none.
+func (ix *fileIndex) similarName(name string) string {
+	for _, n := range ix.names {
+		if file.IsNearDuplicateName(name, n.filename) {
+			return n.location
+		}
+	}
+	return ""
+}
+
+func cmdFilesRehash(args []string) {
+	fs := flag.NewFlagSet("files rehash", flag.ExitOnError)
+	areaTag := fs.String("area", "", "Specific file area tag (omit for all areas)")
+	dataDir := fs.String("data", "data", "Data directory")
+	configDir := fs.String("config", "configs", "Config directory")
+	force := fs.Bool("force", false, "Recompute hashes even for records that already have them")
+	dryRun := fs.Bool("dry-run", false, "Show what would be updated without making changes")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper files rehash [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Compute SHA-256 and CRC-32 hashes for existing file records and\n")
+		fmt.Fprintf(os.Stderr, "report files with identical contents across areas.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper files rehash\n")
+		fmt.Fprintf(os.Stderr, "  helper files rehash --area GENERAL --force\n")
+	}
+	fs.Parse(args)
+
+	areas, err := loadFileAreas(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading file areas: %v\n", err)
+		os.Exit(1)
+	}
+
+	targets := areas
+	if *areaTag != "" {
+		area := findAreaByTag(areas, *areaTag)
+		if area == nil {
+			fmt.Fprintf(os.Stderr, "Error: file area %q not found\n", *areaTag)
+			os.Exit(1)
+		}
+		targets = []file.FileArea{*area}
+	}
+
+	totalScanned := 0
+	totalUpdated := 0
+	totalMissing := 0
+
+	for _, area := range targets {
+		areaDir := filepath.Join(*dataDir, "files", area.Path)
+		records, err := loadMetadata(areaDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading metadata for %s: %v\n", area.Tag, err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		fmt.Printf("Area: %s (%s) — %d files\n", area.Name, area.Tag, len(records))
+		updated := 0
+
+		for i := range records {
+			rec := &records[i]
+			totalScanned++
+
+			if rec.SHA256 != "" && rec.CRC32 != "" && !*force {
+				continue
+			}
+
+			filePath := filepath.Join(areaDir, filepath.Base(rec.Filename))
+			hashes, err := file.HashFile(filePath)
+			if err != nil {
+				if os.IsNotExist(err) {
+					fmt.Printf("  MISS  %-40s (file not found on disk)\n", rec.Filename)
+					totalMissing++
+				} else {
+					fmt.Printf("  ERR   %-40s %v\n", rec.Filename, err)
+				}
+				continue
+			}
+
+			if hashes.SHA256 == rec.SHA256 && hashes.CRC32 == rec.CRC32 {
+				continue
+			}
+
+			fmt.Printf("  UPD   %-40s CRC %s SHA %s...\n", rec.Filename, hashes.CRC32, hashes.SHA256[:16])
+			if !*dryRun {
+				rec.SHA256 = hashes.SHA256
+				rec.CRC32 = hashes.CRC32
+			}
+			updated++
+		}
+
+		if !*dryRun && updated > 0 {
+			if err := saveMetadata(areaDir, records); err != nil {
+				fmt.Fprintf(os.Stderr, "  Error saving metadata for %s: %v\n", area.Tag, err)
+				continue
+			}
+		}
+		totalUpdated += updated
+	}
+
+	fmt.Printf("\nSummary: scanned %d files, hashed %d, %d missing\n", totalScanned, totalUpdated, totalMissing)
+	if *dryRun {
+		fmt.Println("(dry run — no files were modified)")
+		return
+	}
+
+	dups := findDuplicateContent(*dataDir, areas)
+	if len(dups) == 0 {
+		return
+	}
+	fmt.Printf("\nIdentical contents found in %d group(s):\n", len(dups))
+	for _, group := range dups {
+		fmt.Printf("  DUP   %s\n", strings.Join(group, ", "))
+	}
+}
+
+// findDuplicateContent groups hashed records across all areas by SHA-256 and
+// returns the location lists for hashes shared by more than one record.
+func findDuplicateContent(dataDir string, areas []file.FileArea) [][]string {
+	groups := make(map[string][]string)
+	for _, area := range areas {
+		records, err := loadMetadata(filepath.Join(dataDir, "files", area.Path))
+		if err != nil {
+			continue
+		}
+		for _, rec := range records {
+			if rec.SHA256 == "" {
+				continue
+			}
+			key := strings.ToUpper(rec.SHA256)
+			groups[key] = append(groups[key], area.Tag+"/"+rec.Filename)
+		}
+	}
+
+	var dups [][]string
+	for _, locs := range groups {
+		if len(locs) > 1 {
+			sort.Strings(locs)
+			dups = append(dups, locs)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i][0] < dups[j][0] })
+	return dups
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/stlalpha/vision3/internal/file"
+)
+
+// verifyResult tallies one run of files verify.
+type verifyResult struct {
+	checked, missing, resized, back, changed int
+}
+
+// verifyRecords checks each record against the files in areaDir, printing
+// what it finds. With markOffline, missing files are flagged offline and
+// offline records whose files are back are flagged online again. Returns
+// true if any record was changed.
+func verifyRecords(areaDir string, records []file.FileRecord, markOffline bool, res *verifyResult) bool {
+	changed := false
+	for i := range records {
+		rec := &records[i]
+		res.checked++
+
+		fi, err := os.Stat(filepath.Join(areaDir, filepath.Base(rec.Filename)))
+		if err != nil {
+			if rec.Offline {
+				continue // Already known to be offline
+			}
+			if os.IsNotExist(err) {
+				fmt.Printf("  MISS  %-40s (file not found on disk)\n", rec.Filename)
+			} else {
+				fmt.Printf("  ERR   %-40s %v\n", rec.Filename, err)
+			}
+			res.missing++
+			if markOffline {
+				rec.Offline = true
+				changed = true
+				res.changed++
+			}
+			continue
+		}
+
+		if rec.Offline {
+			fmt.Printf("  BACK  %-40s (marked offline, file is present)\n", rec.Filename)
+			res.back++
+			if markOffline {
+				rec.Offline = false
+				changed = true
+				res.changed++
+			}
+		}
+		if fi.Size() != rec.Size {
+			fmt.Printf("  SIZE  %-40s recorded %d, on disk %d\n", rec.Filename, rec.Size, fi.Size())
+			res.resized++
+		}
+	}
+	return changed
+}
+
+func cmdFilesVerify(args []string) {
+	fs := flag.NewFlagSet("files verify", flag.ExitOnError)
+	areaTag := fs.String("area", "", "Specific file area tag (omit for all areas)")
+	dataDir := fs.String("data", "data", "Data directory")
+	configDir := fs.String("config", "configs", "Config directory")
+	markOffline := fs.Bool("mark-offline", false, "Mark records with missing files offline (and present ones back online)")
+	dryRun := fs.Bool("dry-run", false, "With --mark-offline, show what would change without saving")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper files verify [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Check every file record against the disk and report files that are\n")
+		fmt.Fprintf(os.Stderr, "missing or whose size no longer matches the record.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper files verify\n")
+		fmt.Fprintf(os.Stderr, "  helper files verify --area CDROM --mark-offline\n")
+	}
+	fs.Parse(args)
+
+	areas, err := loadFileAreas(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading file areas: %v\n", err)
+		os.Exit(1)
+	}
+
+	targets := areas
+	if *areaTag != "" {
+		area := findAreaByTag(areas, *areaTag)
+		if area == nil {
+			fmt.Fprintf(os.Stderr, "Error: file area %q not found\n", *areaTag)
+			os.Exit(1)
+		}
+		targets = []file.FileArea{*area}
+	}
+
+	var res verifyResult
+	for _, area := range targets {
+		if area.Offline {
+			fmt.Printf("Area: %s (%s) — offline, skipped\n", area.Name, area.Tag)
+			continue
+		}
+		areaDir := filepath.Join(*dataDir, "files", area.Path)
+		records, err := loadMetadata(areaDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading metadata for %s: %v\n", area.Tag, err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		fmt.Printf("Area: %s (%s) — %d files\n", area.Name, area.Tag, len(records))
+		if verifyRecords(areaDir, records, *markOffline, &res) && !*dryRun {
+			if err := saveMetadata(areaDir, records); err != nil {
+				fmt.Fprintf(os.Stderr, "  Error saving metadata for %s: %v\n", area.Tag, err)
+			}
+		}
+	}
+
+	fmt.Printf("\nSummary: checked %d files, %d missing, %d size changed, %d back online\n", res.checked, res.missing, res.resized, res.back)
+	if *markOffline {
+		if *dryRun {
+			fmt.Printf("(dry run — %d record(s) would be updated)\n", res.changed)
+		} else {
+			fmt.Printf("Updated %d record(s).\n", res.changed)
+		}
+	}
+}
@@ -94,6 +94,8 @@ func main() {
 		cmdUsers(os.Args[2:])
 	case "files":
 		cmdFiles(os.Args[2:])
+	case "bans":
+		cmdBans(os.Args[2:])
 	default:
 		printUsage(fmt.Sprintf("Unknown command: %s", cmd))
 		os.Exit(1)
@@ -118,10 +120,22 @@ func printUsage(errMsg string) {
 	fmt.Fprintf(w, "  %sUser Commands:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpcmd("USERS PURGE", "Permanently remove soft-deleted users past retention"))
 	fmt.Fprintln(w, helpcmd("USERS LIST", "List user accounts"))
+	fmt.Fprintln(w, helpcmd("USERS POINTS", "Show the file point charge/credit ledger"))
+	fmt.Fprintln(w, helpcmd("USERS EXPIRE", "Move expired accounts down to their expire-to level"))
+	fmt.Fprintln(w, helpcmd("USERS SETEXPIRY", "Set, renew or clear an account's expiry date"))
+	fmt.Fprintln(w, helpcmd("USERS RESETCODE", "Issue a one-time password reset code"))
+	fmt.Fprintln(w, helpcmd("USERS MUSTCHANGE", "Make a user change their password at next login"))
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %sFile Commands:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpcmd("FILES IMPORT", "Bulk import files from a directory into a file area"))
 	fmt.Fprintln(w, helpcmd("FILES REEXTRACTDIZ", "Re-extract FILE_ID.DIZ and update descriptions"))
+	fmt.Fprintln(w, helpcmd("FILES REHASH", "Backfill content hashes and report duplicates"))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %sBan Commands:%s\n", clrBold, clrReset)
+	fmt.Fprintln(w, helpcmd("BANS LIST", "List active IP bans"))
+	fmt.Fprintln(w, helpcmd("BANS ADD", "Ban an IP address or CIDR range"))
+	fmt.Fprintln(w, helpcmd("BANS REMOVE", "Lift a ban by ID or address"))
+	fmt.Fprintln(w, helpcmd("BANS PRUNE", "Remove expired bans from the list"))
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %sGlobal Options:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpopt("--config DIR", "Config directory (default: configs)"))
@@ -143,14 +157,27 @@ func printUsersHelp(errMsg string) {
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %sUser Subcommands:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpcmd("PURGE", "Permanently remove soft-deleted users past retention"))
-	fmt.Fprintln(w, helpcmd("LIST", "List user accounts (optionally filtered to deleted)"))
+	fmt.Fprintln(w, helpcmd("LIST", "List user accounts (optionally deleted or expiring)"))
+	fmt.Fprintln(w, helpcmd("POINTS", "Show the file point charge/credit ledger"))
+	fmt.Fprintln(w, helpcmd("EXPIRE", "Move expired accounts down to their expire-to level"))
+	fmt.Fprintln(w, helpcmd("SETEXPIRY", "Set, renew or clear an account's expiry date"))
+	fmt.Fprintln(w, helpcmd("RESETCODE", "Issue a one-time password reset code"))
+	fmt.Fprintln(w, helpcmd("MUSTCHANGE", "Make a user change their password at next login"))
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %sOptions:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpopt("--config DIR", "Config directory (default: configs)"))
 	fmt.Fprintln(w, helpopt("--data DIR", "Data directory (default: data/users)"))
-	fmt.Fprintln(w, helpopt("--days N", "Retention days override (purge)"))
+	fmt.Fprintln(w, helpopt("--days N", "Retention days override (purge); renew by N days (setexpiry)"))
 	fmt.Fprintln(w, helpopt("--dry-run", "Show what would happen without making changes"))
 	fmt.Fprintln(w, helpopt("--deleted", "Show only soft-deleted accounts (list)"))
+	fmt.Fprintln(w, helpopt("--expiring", "Show only accounts with an expiry date (list)"))
+	fmt.Fprintln(w, helpopt("--within N", "Only accounts expiring in N days (list --expiring)"))
+	fmt.Fprintln(w, helpopt("--no-notice", "Skip the private notice to expired users (expire)"))
+	fmt.Fprintln(w, helpopt("--date YYYY-MM-DD", "Expiry date (setexpiry)"))
+	fmt.Fprintln(w, helpopt("--to LEVEL", "Level the account drops to on expiry (setexpiry)"))
+	fmt.Fprintln(w, helpopt("--clear", "Remove the expiry (setexpiry); clear the flag (mustchange)"))
+	fmt.Fprintln(w, helpopt("--user HANDLE", "Only show entries for this handle (points); account to change"))
+	fmt.Fprintln(w, helpopt("--limit N", "Show only the most recent N entries (points)"))
 	fmt.Fprintln(w)
 }
 
@@ -166,6 +193,16 @@ func cmdUsers(args []string) {
 		cmdUsersPurge(args[1:])
 	case "list":
 		cmdUsersList(args[1:])
+	case "points":
+		cmdUsersPoints(args[1:])
+	case "expire":
+		cmdUsersExpire(args[1:])
+	case "setexpiry":
+		cmdUsersSetExpiry(args[1:])
+	case "resetcode":
+		cmdUsersResetCode(args[1:])
+	case "mustchange":
+		cmdUsersMustChange(args[1:])
 	case "help", "--help", "-h":
 		printUsersHelp("")
 	default:
@@ -174,6 +211,56 @@ func cmdUsers(args []string) {
 	}
 }
 
+// --- bans command group ---
+
+func printBansHelp(errMsg string) {
+	w := os.Stderr
+	printHeader()
+	fmt.Fprintln(w)
+	if errMsg != "" {
+		fmt.Fprintln(w, bullet(errMsg))
+	}
+	fmt.Fprintln(w, bullet("Required Format: helper bans <subcommand> [options]"))
+	fmt.Fprintln(w, bullet("Valid Subcommands Are As Follows..."))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %sBan Subcommands:%s\n", clrBold, clrReset)
+	fmt.Fprintln(w, helpcmd("LIST", "List active IP bans"))
+	fmt.Fprintln(w, helpcmd("ADD <ip|cidr>", "Ban an IP address or CIDR range"))
+	fmt.Fprintln(w, helpcmd("REMOVE <id|ip|cidr>", "Lift a ban"))
+	fmt.Fprintln(w, helpcmd("PRUNE", "Remove expired bans from the list"))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %sOptions:%s\n", clrBold, clrReset)
+	fmt.Fprintln(w, helpopt("--data DIR", "Data directory (default: data)"))
+	fmt.Fprintln(w, helpopt("--for LENGTH", "Ban length: 30m, 12h, 7d, 2w (add; default permanent)"))
+	fmt.Fprintln(w, helpopt("--reason TEXT", "Why the address is banned (add)"))
+	fmt.Fprintln(w, helpopt("--by NAME", "Who added the ban (add; default helper)"))
+	fmt.Fprintln(w)
+}
+
+func cmdBans(args []string) {
+	if len(args) < 1 {
+		printBansHelp("")
+		os.Exit(1)
+	}
+
+	sub := args[0]
+	switch sub {
+	case "list":
+		cmdBansList(args[1:])
+	case "add":
+		cmdBansAdd(args[1:])
+	case "remove", "rm":
+		cmdBansRemove(args[1:])
+	case "prune":
+		cmdBansPrune(args[1:])
+	case "help", "--help", "-h":
+		printBansHelp("")
+	default:
+		printBansHelp(fmt.Sprintf("Unknown subcommand: %s", sub))
+		os.Exit(1)
+	}
+}
+
 // --- files command group ---
 
 func printFilesHelp(errMsg string) {
@@ -188,7 +275,11 @@ func printFilesHelp(errMsg string) {
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %sFile Subcommands:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpcmd("IMPORT", "Bulk import files from a directory into a file area"))
+	fmt.Fprintln(w, helpcmd("EXPORT", "Write FILES.BBS per area and a combined ALLFILES.TXT"))
 	fmt.Fprintln(w, helpcmd("REEXTRACTDIZ", "Re-extract FILE_ID.DIZ and update descriptions"))
+	fmt.Fprintln(w, helpcmd("REHASH", "Backfill content hashes and report duplicates"))
+	fmt.Fprintln(w, helpcmd("PURGEPARTIALS", "Delete interrupted uploads past their retention"))
+	fmt.Fprintln(w, helpcmd("VERIFY", "Report missing or resized files; optionally mark them offline"))
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %sImport Options:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpopt("--dir DIR", "Source directory containing files (required)"))
@@ -197,8 +288,29 @@ func printFilesHelp(errMsg string) {
 	fmt.Fprintln(w, helpopt("--move", "Move files instead of copying"))
 	fmt.Fprintln(w, helpopt("--preserve-dates", "Use file modification time as upload date"))
 	fmt.Fprintln(w, helpopt("--no-diz", "Skip FILE_ID.DIZ extraction from archives"))
+	fmt.Fprintln(w, helpopt("--allow-dupes", "Import even if identical contents already exist"))
+	fmt.Fprintln(w, helpopt("--list FILE", "FILES.BBS or DESCRIPT.ION to take descriptions from"))
+	fmt.Fprintln(w, helpopt("--list-format FMT", "auto, filesbbs or descript (default: auto)"))
+	fmt.Fprintln(w, helpopt("--no-list", "Ignore any FILES.BBS or DESCRIPT.ION in --dir"))
 	fmt.Fprintln(w, helpopt("--dry-run", "Show what would happen without making changes"))
 	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %sExport Options:%s\n", clrBold, clrReset)
+	fmt.Fprintln(w, helpopt("--area TAG", "Export one file area (default: all)"))
+	fmt.Fprintln(w, helpopt("--out DIR", "Write DIR/<TAG>/FILES.BBS instead of into area dirs"))
+	fmt.Fprintln(w, helpopt("--format FMT", "filesbbs or descript (default: filesbbs)"))
+	fmt.Fprintln(w, helpopt("--allfiles FILE", "Combined listing path (default: ALLFILES.TXT)"))
+	fmt.Fprintln(w, helpopt("--no-allfiles", "Skip the combined listing"))
+	fmt.Fprintln(w, helpopt("--utf8", "Write UTF-8 instead of CP437"))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %sVerify Options:%s\n", clrBold, clrReset)
+	fmt.Fprintln(w, helpopt("--area TAG", "Check one file area (default: all)"))
+	fmt.Fprintln(w, helpopt("--mark-offline", "Mark missing files offline, present ones back online"))
+	fmt.Fprintln(w, helpopt("--dry-run", "Show what --mark-offline would change"))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %sPurgePartials Options:%s\n", clrBold, clrReset)
+	fmt.Fprintln(w, helpopt("--days N", "Retention days override (default: config.json)"))
+	fmt.Fprintln(w, helpopt("--dry-run", "Show what would be purged without making changes"))
+	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %sGlobal Options:%s\n", clrBold, clrReset)
 	fmt.Fprintln(w, helpopt("--config DIR", "Config directory (default: configs)"))
 	fmt.Fprintln(w, helpopt("--data DIR", "Data directory (default: data)"))
@@ -215,8 +327,16 @@ func cmdFiles(args []string) {
 	switch sub {
 	case "import":
 		cmdFilesImport(args[1:])
+	case "export":
+		cmdFilesExport(args[1:])
 	case "reextractdiz":
 		cmdFilesReextractDIZ(args[1:])
+	case "rehash":
+		cmdFilesRehash(args[1:])
+	case "purgepartials":
+		cmdFilesPurgePartials(args[1:])
+	case "verify":
+		cmdFilesVerify(args[1:])
 	case "help", "--help", "-h":
 		printFilesHelp("")
 	default:
@@ -265,6 +385,7 @@ func cmdUsersPurge(args []string) {
 		fmt.Fprintf(os.Stderr, "Error loading users: %v\n", err)
 		os.Exit(1)
 	}
+	defer um.Close()
 
 	if *dryRun {
 		// Show eligible users without purging
@@ -307,6 +428,8 @@ func cmdUsersList(args []string) {
 	dataDir := fs.String("data", "data/users", "User data directory")
 	configDir := fs.String("config", "configs", "Config directory")
 	deletedOnly := fs.Bool("deleted", false, "Show only soft-deleted accounts")
+	expiring := fs.Bool("expiring", false, "Show only accounts with an expiry date, soonest first")
+	within := fs.Int("within", 0, "With --expiring, only accounts expiring in the next N days (0 = all)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: helper users list [options]\n\n")
@@ -321,6 +444,7 @@ func cmdUsersList(args []string) {
 		fmt.Fprintf(os.Stderr, "Error loading users: %v\n", err)
 		os.Exit(1)
 	}
+	defer um.Close()
 
 	// Load retention days for the "days remaining" column
 	retentionDays := -1
@@ -331,6 +455,12 @@ func cmdUsersList(args []string) {
 	all := um.GetAllUsers()
 	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
 
+	if *expiring {
+		now := time.Now()
+		printExpiringUsers(expiringUsers(all, now, *within), now)
+		return
+	}
+
 	if *deletedOnly {
 		var deleted []*user.User
 		for _, u := range all {
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/message"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func cmdUsersExpire(args []string) {
+	fs := flag.NewFlagSet("users expire", flag.ExitOnError)
+	configDir := fs.String("config", "configs", "Config directory")
+	dataDir := fs.String("data", "data/users", "User data directory")
+	mailDir := fs.String("mail-data", "data", "Data directory holding the message bases (for notices)")
+	noNotice := fs.Bool("no-notice", false, "Do not send expired users a private notice")
+	dryRun := fs.Bool("dry-run", false, "Show what would expire without making changes")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper users expire [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Move accounts past their expiry date down to their expire-to level\n")
+		fmt.Fprintf(os.Stderr, "and send each user a private notice.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper users expire\n")
+		fmt.Fprintf(os.Stderr, "  helper users expire --dry-run\n")
+	}
+	fs.Parse(args)
+
+	cfg, err := config.LoadServerConfig(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	levels, err := config.LoadSecurityLevels(*configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading security levels: %v\n", err)
+		os.Exit(1)
+	}
+
+	um, err := user.NewUserManager(*dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading users: %v\n", err)
+		os.Exit(1)
+	}
+	defer um.Close()
+
+	now := time.Now()
+	if *dryRun {
+		var due []*user.User
+		for _, u := range um.GetAllUsers() {
+			if !u.DeletedUser && u.Expired(now) {
+				due = append(due, u)
+			}
+		}
+		if len(due) == 0 {
+			fmt.Println("Dry run: no accounts have expired.")
+			return
+		}
+		sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+		fmt.Printf("Dry run: %d account(s) would expire:\n\n", len(due))
+		fmt.Printf("  %-6s  %-20s  %-12s  %s\n", "ID", "Handle", "Expired On", "Level")
+		fmt.Println("  " + strings.Repeat("-", 56))
+		for _, u := range due {
+			fmt.Printf("  %-6d  %-20s  %-12s  %d -> %d\n",
+				u.ID, u.Handle, u.ExpiresAt.Format("2006-01-02"), u.AccessLevel, u.ExpireToLevel)
+		}
+		return
+	}
+
+	expired, err := um.ExpireAccounts(levels, now)
+	if err != nil {
+		// The accounts that were saved are still reported and mailed.
+		fmt.Fprintf(os.Stderr, "Error expiring accounts: %v\n", err)
+		if len(expired) == 0 {
+			os.Exit(1)
+		}
+	}
+	if len(expired) == 0 {
+		fmt.Println("No accounts have expired.")
+		return
+	}
+
+	fmt.Printf("Expired %d account(s):\n\n", len(expired))
+	for _, r := range expired {
+		fmt.Printf("  #%-4d  %-20s  expired %s  level %d -> %d\n",
+			r.ID, r.Handle, r.ExpiredAt.Format("2006-01-02"), r.FromLevel, r.ToLevel)
+	}
+
+	if !*noNotice {
+		sendExpiryNotices(cfg, levels, *configDir, *mailDir, expired)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// sendExpiryNotices mails each expired user a private notice from the
+// SysOp. Failures are reported but do not undo the expiry.
+func sendExpiryNotices(cfg config.ServerConfig, levels []config.SecurityLevel, configDir, mailDir string, expired []user.ExpiryResult) {
+	msgMgr, err := message.NewMessageManager(mailDir, configDir, cfg.BoardName, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no notices sent: %v\n", err)
+		return
+	}
+	defer msgMgr.Close()
+
+	privmailArea, ok := msgMgr.GetAreaByTag("PRIVMAIL")
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Warning: PRIVMAIL area not found; no notices sent.")
+		return
+	}
+	from := cfg.SysOpName
+	if from == "" {
+		from = "SysOp"
+	}
+
+	loc := config.LoadTimezone(cfg.Timezone)
+	sent := 0
+	for _, r := range expired {
+		subject, body := r.Notice(levels, loc)
+		if _, err := msgMgr.AddPrivateMessage(privmailArea.ID, from, r.Handle, subject, body, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to notify %s: %v\n", r.Handle, err)
+			continue
+		}
+		sent++
+	}
+	fmt.Printf("\nSent %d expiry notice(s).\n", sent)
+}
+
+// expiringUsers returns the accounts that have an expiry date, soonest
+// first. A positive within keeps only those expiring in the next within
+// days; already expired accounts are always kept.
+func expiringUsers(users []*user.User, now time.Time, within int) []*user.User {
+	var result []*user.User
+	for _, u := range users {
+		if u.DeletedUser {
+			continue
+		}
+		days, ok := u.DaysUntilExpiry(now)
+		if !ok || (within > 0 && days > within) {
+			continue
+		}
+		result = append(result, u)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ExpiresAt.Before(*result[j].ExpiresAt) })
+	return result
+}
+
+// printExpiringUsers prints the expiring accounts with the days left and
+// the level each will drop to.
+func printExpiringUsers(users []*user.User, now time.Time) {
+	if len(users) == 0 {
+		fmt.Println("No accounts are due to expire.")
+		return
+	}
+	fmt.Printf("%-6s  %-20s  %-20s  %-5s  %-5s  %-12s  %s\n", "ID", "Username", "Handle", "Level", "To", "Expires On", "Days Left")
+	fmt.Println(strings.Repeat("-", 86))
+	for _, u := range users {
+		left := "expired"
+		if days, _ := u.DaysUntilExpiry(now); days > 0 {
+			left = fmt.Sprintf("%d", days)
+		}
+		fmt.Printf("%-6d  %-20s  %-20s  %-5d  %-5d  %-12s  %s\n",
+			u.ID, u.Username, u.Handle, u.AccessLevel, u.ExpireToLevel, u.ExpiresAt.Format("2006-01-02"), left)
+	}
+	fmt.Printf("\nTotal: %d account(s)\n", len(users))
+}
+
+func cmdUsersSetExpiry(args []string) {
+	fs := flag.NewFlagSet("users setexpiry", flag.ExitOnError)
+	configDir := fs.String("config", "configs", "Config directory")
+	dataDir := fs.String("data", "data/users", "User data directory")
+	handle := fs.String("user", "", "Handle of the account to change (required)")
+	date := fs.String("date", "", "Expiry date YYYY-MM-DD (the account drops at the start of that day)")
+	days := fs.Int("days", 0, "Expire N days from the later of today and the current expiry (renewal)")
+	toLevel := fs.Int("to", -1, "Access level the account drops to on expiry (required for a new expiry)")
+	clearExpiry := fs.Bool("clear", false, "Remove the expiry; the account never expires")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper users setexpiry --user HANDLE [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Set, renew or clear the expiry date of an account.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper users setexpiry --user Felonius --date 2027-01-01 --to 20\n")
+		fmt.Fprintf(os.Stderr, "  helper users setexpiry --user Felonius --days 365\n")
+		fmt.Fprintf(os.Stderr, "  helper users setexpiry --user Felonius --clear\n")
+	}
+	fs.Parse(args)
+
+	if *handle == "" || (*date == "" && *days <= 0 && !*clearExpiry) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	um, err := user.NewUserManager(*dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading users: %v\n", err)
+		os.Exit(1)
+	}
+	defer um.Close()
+	u, ok := um.GetUserByHandle(*handle)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "No user with handle %q.\n", *handle)
+		os.Exit(1)
+	}
+
+	if *clearExpiry {
+		u.ExpiresAt = nil
+		u.ExpireToLevel = 0
+	} else {
+		if u.ExpiresAt == nil && *toLevel < 0 {
+			fmt.Fprintf(os.Stderr, "%s has no expiry yet; give the level to drop to with --to.\n", u.Handle)
+			os.Exit(1)
+		}
+		loc := time.Local
+		if cfg, cfgErr := config.LoadServerConfig(*configDir); cfgErr == nil {
+			loc = config.LoadTimezone(cfg.Timezone)
+		}
+		expiresAt, err := newExpiry(u, *date, *days, time.Now(), loc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		u.ExpiresAt = &expiresAt
+		if *toLevel >= 0 {
+			u.ExpireToLevel = *toLevel
+		}
+	}
+
+	if err := um.UpdateUser(u); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving user: %v\n", err)
+		os.Exit(1)
+	}
+	if u.ExpiresAt == nil {
+		fmt.Printf("%s no longer expires.\n", u.Handle)
+		return
+	}
+	fmt.Printf("%s expires %s and then drops from level %d to %d.\n",
+		u.Handle, u.ExpiresAt.Format("2006-01-02"), u.AccessLevel, u.ExpireToLevel)
+}
+
+// newExpiry returns the expiry for u from either date (YYYY-MM-DD, midnight
+// in loc) or days added to the later of now and u's current expiry.
+func newExpiry(u *user.User, date string, days int, now time.Time, loc *time.Location) (time.Time, error) {
+	if date != "" {
+		t, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --date %q (want YYYY-MM-DD)", date)
+		}
+		return t, nil
+	}
+	from := now
+	if u.ExpiresAt != nil && u.ExpiresAt.After(now) {
+		from = *u.ExpiresAt
+	}
+	return from.AddDate(0, 0, days), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestExpiringUsers(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(days int) *time.Time { ts := now.AddDate(0, 0, days); return &ts }
+	users := []*user.User{
+		{ID: 1, Handle: "Later", ExpiresAt: at(40)},
+		{ID: 2, Handle: "Soon", ExpiresAt: at(3)},
+		{ID: 3, Handle: "Never"},
+		{ID: 4, Handle: "Lapsed", ExpiresAt: at(-2)},
+		{ID: 5, Handle: "Gone", ExpiresAt: at(1), DeletedUser: true},
+	}
+
+	got := expiringUsers(users, now, 0)
+	if len(got) != 3 || got[0].Handle != "Lapsed" || got[1].Handle != "Soon" || got[2].Handle != "Later" {
+		t.Errorf("all expiring = %v", handles(got))
+	}
+	got = expiringUsers(users, now, 7)
+	if len(got) != 2 || got[0].Handle != "Lapsed" || got[1].Handle != "Soon" {
+		t.Errorf("expiring within 7 days = %v", handles(got))
+	}
+}
+
+func TestNewExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := newExpiry(&user.User{}, "2027-01-01", 0, now, time.UTC)
+	if err != nil || !got.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("by date = %v, %v", got, err)
+	}
+	if _, err := newExpiry(&user.User{}, "01/01/2027", 0, now, time.UTC); err == nil {
+		t.Error("accepted a malformed date")
+	}
+
+	// Renewing before expiry extends from the current expiry date
+	current := now.AddDate(0, 0, 10)
+	got, _ = newExpiry(&user.User{ExpiresAt: &current}, "", 30, now, time.UTC)
+	if !got.Equal(now.AddDate(0, 0, 40)) {
+		t.Errorf("renewal = %v, want %v", got, now.AddDate(0, 0, 40))
+	}
+
+	// A lapsed account renews from today
+	lapsed := now.AddDate(0, 0, -5)
+	got, _ = newExpiry(&user.User{ExpiresAt: &lapsed}, "", 30, now, time.UTC)
+	if !got.Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("lapsed renewal = %v, want %v", got, now.AddDate(0, 0, 30))
+	}
+}
+
+func handles(users []*user.User) []string {
+	var out []string
+	for _, u := range users {
+		out = append(out, u.Handle)
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func cmdUsersResetCode(args []string) {
+	fs := flag.NewFlagSet("users resetcode", flag.ExitOnError)
+	configDir := fs.String("config", "configs", "Config directory")
+	dataDir := fs.String("data", "data/users", "User data directory")
+	handle := fs.String("user", "", "Handle of the account (required)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper users resetcode --user HANDLE [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Issue a one-time code the user logs in with instead of their password.\n")
+		fmt.Fprintf(os.Stderr, "They must choose a new password straight after.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *handle == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	um, err := user.NewUserManager(*dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading users: %v\n", err)
+		os.Exit(1)
+	}
+	defer um.Close()
+	if cfg, cfgErr := config.LoadServerConfig(*configDir); cfgErr == nil {
+		um.SetPasswordPolicy(user.PasswordPolicyFromConfig(cfg))
+	}
+	u, ok := um.GetUserByHandle(*handle)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "No user with handle %q.\n", *handle)
+		os.Exit(1)
+	}
+
+	code, expires, err := um.IssueResetCode(u.Username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Reset code for %s: %s\n", u.Handle, code)
+	fmt.Printf("It works once, until %s.\n", expires.Format("2006-01-02 15:04"))
+}
+
+func cmdUsersMustChange(args []string) {
+	fs := flag.NewFlagSet("users mustchange", flag.ExitOnError)
+	dataDir := fs.String("data", "data/users", "User data directory")
+	handle := fs.String("user", "", "Handle of the account (required)")
+	clearFlag := fs.Bool("clear", false, "Clear the flag instead of setting it")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper users mustchange --user HANDLE [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Make a user choose a new password at their next login.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *handle == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	um, err := user.NewUserManager(*dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading users: %v\n", err)
+		os.Exit(1)
+	}
+	defer um.Close()
+	u, ok := um.GetUserByHandle(*handle)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "No user with handle %q.\n", *handle)
+		os.Exit(1)
+	}
+
+	if err := um.SetMustChangePassword(u.Username, !*clearFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving user: %v\n", err)
+		os.Exit(1)
+	}
+	if *clearFlag {
+		fmt.Printf("%s no longer has to change their password.\n", u.Handle)
+		return
+	}
+	fmt.Printf("%s must change their password at their next login.\n", u.Handle)
+}
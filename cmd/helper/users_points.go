@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func cmdUsersPoints(args []string) {
+	fs := flag.NewFlagSet("users points", flag.ExitOnError)
+	dataDir := fs.String("data", "data/users", "User data directory")
+	handle := fs.String("user", "", "Only show entries for this handle")
+	limit := fs.Int("limit", 50, "Show only the most recent N entries (0 = all)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: helper users points [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Show the file point ledger: every download charge and upload credit.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  helper users points\n")
+		fmt.Fprintf(os.Stderr, "  helper users points --user Felonius --limit 0\n")
+	}
+	fs.Parse(args)
+
+	entries, err := user.LoadFilePointsLog(*dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file point ledger: %v\n", err)
+		os.Exit(1)
+	}
+
+	entries = filterPointsLog(entries, *handle, *limit)
+	if len(entries) == 0 {
+		fmt.Println("No file point activity recorded.")
+		return
+	}
+
+	fmt.Printf("%-16s  %-16s  %-8s  %6s  %7s  %s\n", "WHEN", "HANDLE", "REASON", "DELTA", "BALANCE", "FILE")
+	for _, e := range entries {
+		where := e.Filename
+		if e.AreaTag != "" {
+			where = e.AreaTag + "/" + e.Filename
+		}
+		if e.Notes != "" {
+			where = strings.TrimSpace(where + " " + e.Notes)
+		}
+		fmt.Printf("%-16s  %-16s  %-8s  %+6d  %7d  %s\n",
+			e.Timestamp.Format("2006-01-02 15:04"), e.Handle, e.Reason, e.Delta, e.Balance, where)
+	}
+}
+
+// filterPointsLog keeps entries for handle (case-insensitive, "" = all) and
+// trims to the most recent limit entries (0 = no limit).
+func filterPointsLog(entries []user.FilePointsLog, handle string, limit int) []user.FilePointsLog {
+	if handle != "" {
+		var kept []user.FilePointsLog
+		for _, e := range entries {
+			if strings.EqualFold(e.Handle, handle) {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
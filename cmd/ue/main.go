@@ -4,10 +4,12 @@
 //
 // Usage:
 //
-//	./ue [--data path/to/users/directory]
+//	./ue [--data path/to/users/directory] [--config path/to/configs]
 //
 // If no --data flag is provided, it looks for data/users/users.json
-// relative to the current working directory.
+// relative to the current working directory. Security level profiles and
+// infoforms are read from levels.json and infoforms.json in the --config
+// directory (default: configs/).
 package main
 
 import (
@@ -18,11 +20,14 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
 	"github.com/stlalpha/vision3/internal/usereditor"
 )
 
 func main() {
 	dataPath := flag.String("data", "", "Path to users directory (default: data/users/)")
+	configPath := flag.String("config", "configs", "Path to configuration directory (for levels.json and infoforms.json)")
 	flag.Parse()
 
 	// Resolve data path
@@ -45,8 +50,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Security level profiles are optional; without them level changes
+	// only change the level.
+	levels, err := config.LoadSecurityLevels(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Infoforms are also optional; without them answers are shown by
+	// question ID.
+	forms, err := config.LoadInfoForms(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Passwords set in the editor follow the BBS password policy. On a
+	// missing or unreadable config.json the defaults apply.
+	serverConfig, err := config.LoadServerConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	// Create the editor model
-	model, err := usereditor.New(usersFile)
+	model, err := usereditor.New(usersFile, levels, forms, user.PasswordPolicyFromConfig(serverConfig))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing editor: %v\n", err)
 		os.Exit(1)
@@ -139,6 +139,10 @@ func (cw *ConfigWatcher) handleConfigChange(path string) {
 		cw.reloadDoors()
 	case "login.json":
 		cw.reloadLoginSequence()
+	case "levels.json":
+		cw.reloadSecurityLevels()
+	case "infoforms.json":
+		cw.reloadInfoForms()
 	case "strings.json":
 		cw.reloadStrings()
 	case "theme.json":
@@ -189,6 +193,34 @@ func (cw *ConfigWatcher) reloadLoginSequence() {
 	log.Printf("INFO: login.json reloaded successfully (%d steps)", len(newSequence))
 }
 
+// reloadSecurityLevels reloads the security level profiles.
+func (cw *ConfigWatcher) reloadSecurityLevels() {
+	log.Printf("INFO: Reloading levels.json...")
+
+	newLevels, err := config.LoadSecurityLevels(cw.rootConfigPath)
+	if err != nil {
+		log.Printf("ERROR: Failed to reload levels.json: %v", err)
+		return
+	}
+
+	cw.menuExecutor.SetSecurityLevels(newLevels)
+	log.Printf("INFO: levels.json reloaded successfully (%d levels defined)", len(newLevels))
+}
+
+// reloadInfoForms reloads the infoforms.
+func (cw *ConfigWatcher) reloadInfoForms() {
+	log.Printf("INFO: Reloading infoforms.json...")
+
+	newForms, err := config.LoadInfoForms(cw.rootConfigPath)
+	if err != nil {
+		log.Printf("ERROR: Failed to reload infoforms.json: %v", err)
+		return
+	}
+
+	cw.menuExecutor.SetInfoForms(newForms)
+	log.Printf("INFO: infoforms.json reloaded successfully (%d forms defined)", len(newForms))
+}
+
 // reloadStrings reloads the strings configuration.
 func (cw *ConfigWatcher) reloadStrings() {
 	log.Printf("INFO: Reloading strings.json...")
@@ -242,10 +274,17 @@ func (cw *ConfigWatcher) reloadServerConfig() {
 	// Also update MenuExecutor's ServerCfg
 	cw.menuExecutor.SetServerConfig(newServerConfig)
 
+	// Auto-ban thresholds apply to the next connection
+	if connectionTracker != nil {
+		connectionTracker.SetAutoBanLimits(newServerConfig)
+	}
+
 	// Update UserManager's new user level
 	if cw.userMgr != nil {
 		cw.userMgr.SetNewUserLevel(newServerConfig.NewUserLevel)
 		log.Printf("INFO: Updated new user level to %d", newServerConfig.NewUserLevel)
+		cw.userMgr.SetPasswordPolicy(user.PasswordPolicyFromConfig(newServerConfig))
+		cw.userMgr.SetTOTPRequiredLevel(newServerConfig.TOTPRequiredLevel)
 	}
 
 	log.Printf("INFO: config.json reloaded successfully")
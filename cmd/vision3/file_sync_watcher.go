@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/menu"
+)
+
+// FileAreaWatcher watches the directories of file areas with sync enabled
+// and has the menu executor bring their records up to date when files are
+// added, changed or removed.
+type FileAreaWatcher struct {
+	mu           sync.Mutex
+	watcher      *fsnotify.Watcher
+	watcherDone  chan bool
+	menuExecutor *menu.MenuExecutor
+	areas        map[string]file.FileArea // Watched directory to its area
+	timers       map[int]*time.Timer      // Pending sync per area ID
+}
+
+// fileSyncDebounce is how long after the last change in a directory the
+// area is synced, so a batch of copies is handled in one pass.
+const fileSyncDebounce = 2 * time.Second
+
+// NewFileAreaWatcher starts watching every file area with sync enabled and
+// queues an initial sync of each to pick up changes made while the BBS was
+// down. Returns nil if no area has sync enabled.
+func NewFileAreaWatcher(fileMgr *file.FileManager, menuExecutor *menu.MenuExecutor) (*FileAreaWatcher, error) {
+	var synced []file.FileArea
+	for _, area := range fileMgr.ListAreas() {
+		if area.Sync && !area.Offline {
+			synced = append(synced, area)
+		}
+	}
+	if len(synced) == 0 {
+		return nil, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	fw := &FileAreaWatcher{
+		watcher:      watcher,
+		watcherDone:  make(chan bool),
+		menuExecutor: menuExecutor,
+		areas:        make(map[string]file.FileArea),
+		timers:       make(map[int]*time.Timer),
+	}
+
+	for _, area := range synced {
+		dir, err := fileMgr.GetAreaUploadPath(area.ID)
+		if err != nil {
+			log.Printf("WARN: File sync %s: %v", area.Tag, err)
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("WARN: File sync %s: Failed to watch %s: %v", area.Tag, dir, err)
+			continue
+		}
+		fw.areas[dir] = area
+		fw.schedule(area.ID, fileSyncDebounce)
+		log.Printf("INFO: Watching %s for file area %s (sync enabled)", dir, area.Tag)
+	}
+
+	go fw.watchLoop(watcher)
+
+	return fw, nil
+}
+
+// Stop stops the watcher and cancels any pending syncs.
+func (fw *FileAreaWatcher) Stop() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.watcher == nil {
+		return
+	}
+
+	select {
+	case <-fw.watcherDone:
+		// already closed
+	default:
+		close(fw.watcherDone)
+	}
+	for id, t := range fw.timers {
+		t.Stop()
+		delete(fw.timers, id)
+	}
+
+	fw.watcher.Close()
+	fw.watcher = nil
+	log.Printf("INFO: File area watcher stopped")
+}
+
+// watchLoop turns file system events into debounced area syncs.
+func (fw *FileAreaWatcher) watchLoop(w *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			area, watched := fw.areas[filepath.Dir(event.Name)]
+			if !watched || area.SyncIgnored(filepath.Base(event.Name)) {
+				continue
+			}
+			fw.schedule(area.ID, fileSyncDebounce)
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("ERROR: File area watcher error: %v", err)
+
+		case <-fw.watcherDone:
+			return
+		}
+	}
+}
+
+// schedule (re)starts the sync timer for an area.
+func (fw *FileAreaWatcher) schedule(areaID int, after time.Duration) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if t, ok := fw.timers[areaID]; ok {
+		t.Stop()
+	}
+	fw.timers[areaID] = time.AfterFunc(after, func() { fw.runSync(areaID) })
+}
+
+// runSync syncs an area, checking back later if new files were still being
+// written.
+func (fw *FileAreaWatcher) runSync(areaID int) {
+	fw.mu.Lock()
+	stopped := fw.watcher == nil
+	delete(fw.timers, areaID)
+	fw.mu.Unlock()
+	if stopped {
+		return
+	}
+
+	pending, err := fw.menuExecutor.SyncFileArea(areaID)
+	if err != nil {
+		log.Printf("ERROR: File sync of area %d failed: %v", areaID, err)
+	}
+	if pending {
+		fw.schedule(areaID, fileSyncDebounce)
+	}
+}
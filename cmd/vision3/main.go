@@ -25,9 +25,11 @@ import (
 
 	// Local packages (Update paths)
 	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/ban"
 	"github.com/stlalpha/vision3/internal/chat"
 	"github.com/stlalpha/vision3/internal/conference"
 	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/editor"
 	"github.com/stlalpha/vision3/internal/file"
 	"github.com/stlalpha/vision3/internal/menu"
 	"github.com/stlalpha/vision3/internal/message"
@@ -120,6 +122,10 @@ type ConnectionTracker struct {
 	lockoutMinutes      int
 	watcher             *fsnotify.Watcher // File system watcher for auto-reload
 	watcherDone         chan bool         // Signal to stop watcher
+	bans                *ban.List         // Dynamic IP bans (nil = disabled)
+	floods              *ban.Counter      // Connections per IP for flood detection
+	autoBanMinutes      int
+	maxTelnetGarbage    int
 }
 
 // NewConnectionTracker creates a new connection tracker
@@ -135,6 +141,7 @@ func NewConnectionTracker(maxNodes, maxConnectionsPerIP, maxFailedLogins, lockou
 		allowlistPath:       allowlistPath,
 		maxFailedLogins:     maxFailedLogins,
 		lockoutMinutes:      lockoutMinutes,
+		floods:              ban.NewCounter(0, 0),
 	}
 
 	// Load initial IP lists
@@ -268,6 +275,13 @@ func (ct *ConnectionTracker) canAcceptLocked(remoteAddr net.Addr) (bool, string)
 		return false, "IP address is blocked"
 	}
 
+	// Check dynamic bans
+	if ct.bans != nil {
+		if _, banned := ct.bans.Check(ip); banned {
+			return false, "IP address is banned"
+		}
+	}
+
 	// Check max nodes limit
 	if ct.maxNodes > 0 && ct.totalConnections >= ct.maxNodes {
 		return false, "maximum nodes reached"
@@ -411,12 +425,8 @@ func (ct *ConnectionTracker) RecordFailedLoginAttempt(ip string) bool {
 		tracker.LockedUntil = time.Now().Add(time.Duration(ct.lockoutMinutes) * time.Minute)
 		log.Printf("SECURITY: IP %s locked out after %d failed login attempts. Locked until %s",
 			ip, tracker.Attempts, tracker.LockedUntil.Format(time.RFC3339))
-		// Persist to blocklist file outside the lock to avoid deadlock
-		go func() {
-			if err := ct.AppendToBlocklist(ip); err != nil {
-				log.Printf("ERROR: Failed to persist blocked IP %s to blocklist: %v", ip, err)
-			}
-		}()
+		// Ban outside the lock; the ban list does its own file I/O
+		go ct.autoBan(ip, "too many failed logins")
 		return true
 	}
 
@@ -425,58 +435,108 @@ func (ct *ConnectionTracker) RecordFailedLoginAttempt(ip string) bool {
 	return false
 }
 
-// AppendToBlocklist appends an IP to the blocklist file and updates the in-memory list immediately.
-// If blocklistPath is not configured, this is a no-op.
-func (ct *ConnectionTracker) AppendToBlocklist(ip string) error {
-	if ct.blocklistPath == "" {
-		return nil
-	}
+// SetBans enables dynamic IP bans from list.
+func (ct *ConnectionTracker) SetBans(list *ban.List) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.bans = list
+}
 
-	parsedIP := net.ParseIP(ip)
-	if parsedIP == nil {
-		return fmt.Errorf("invalid IP address: %s", ip)
-	}
-	normalizedIP := parsedIP.String()
+// SetAutoBanLimits applies the automatic ban thresholds from cfg. It is
+// called at startup and whenever config.json is reloaded.
+func (ct *ConnectionTracker) SetAutoBanLimits(cfg config.ServerConfig) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.autoBanMinutes = cfg.AutoBanMinutes
+	ct.maxTelnetGarbage = cfg.MaxTelnetGarbage
+	ct.floods.SetLimit(cfg.FloodConnections, time.Duration(cfg.FloodSeconds)*time.Second)
+}
+
+// AllowConn is called for every new telnet and SSH connection before any
+// protocol handshake. It refuses blocked and banned addresses and bans an
+// address that connects too often within the flood window. Connections
+// from an address stop counting once it logs in; see
+// ClearFailedLoginAttempts.
+func (ct *ConnectionTracker) AllowConn(remoteAddr net.Addr) bool {
+	ip := extractIP(remoteAddr)
 
-	// Check if already in blocklist
 	ct.mu.Lock()
-	alreadyBlocked := ct.blocklist != nil && ct.blocklist.Contains(normalizedIP)
+	if ct.allowlist != nil && ct.allowlist.Contains(ip) {
+		ct.mu.Unlock()
+		return true
+	}
+	if ct.blocklist != nil && ct.blocklist.Contains(ip) {
+		ct.mu.Unlock()
+		log.Printf("INFO: Dropping connection from blocked IP %s", ip)
+		return false
+	}
+	bans := ct.bans
 	ct.mu.Unlock()
 
-	if alreadyBlocked {
-		return nil
+	if bans != nil {
+		if b, banned := bans.Check(ip); banned {
+			log.Printf("INFO: Dropping connection from banned IP %s (ban #%d: %s)", ip, b.ID, b.Reason)
+			return false
+		}
 	}
-
-	// Append to file
-	f, err := os.OpenFile(ct.blocklistPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open blocklist file: %w", err)
+	if ct.floods.Hit(ip, time.Now()) {
+		ct.autoBan(ip, "connection flood")
+		return false
 	}
-	defer f.Close()
+	return true
+}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	line := fmt.Sprintf("%s # auto-blocked %s: too many failed logins\n", normalizedIP, timestamp)
-	if _, err := f.WriteString(line); err != nil {
-		return fmt.Errorf("failed to write to blocklist file: %w", err)
-	}
+// CheckTelnetNegotiation bans an address whose telnet negotiation held
+// more than maxTelnetGarbage bytes of non-telnet data, typically a scanner
+// speaking HTTP or SSH to the telnet port.
+func (ct *ConnectionTracker) CheckTelnetNegotiation(remoteAddr net.Addr, garbage int) bool {
+	ip := extractIP(remoteAddr)
 
-	// Update in-memory list immediately — don't wait for fsnotify debounce
 	ct.mu.Lock()
-	if ct.blocklist == nil {
-		ct.blocklist = &IPList{
-			ips:      make(map[string]bool),
-			networks: make([]*net.IPNet, 0),
-		}
+	limit := ct.maxTelnetGarbage
+	allowed := ct.allowlist != nil && ct.allowlist.Contains(ip)
+	ct.mu.Unlock()
+
+	if allowed || limit <= 0 || garbage < limit {
+		return true
 	}
-	ct.blocklist.ips[normalizedIP] = true
+	ct.autoBan(ip, fmt.Sprintf("telnet negotiation garbage (%d bytes)", garbage))
+	return false
+}
+
+// autoBan bans ip for autoBanMinutes. Allowlisted addresses are never
+// banned. It must be called without ct.mu held.
+func (ct *ConnectionTracker) autoBan(ip, reason string) {
+	ct.mu.Lock()
+	bans := ct.bans
+	minutes := ct.autoBanMinutes
+	allowed := ct.allowlist != nil && ct.allowlist.Contains(ip)
 	ct.mu.Unlock()
 
-	log.Printf("SECURITY: IP %s permanently added to blocklist %s", normalizedIP, ct.blocklistPath)
-	return nil
+	if bans == nil || allowed {
+		return
+	}
+	b, err := bans.Add(ip, reason, ban.AutoBanBy, time.Duration(minutes)*time.Minute)
+	if errors.Is(err, ban.ErrExists) {
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to ban IP %s: %v", ip, err)
+		return
+	}
+	until := "permanently"
+	if b.Expires != nil {
+		until = "until " + b.Expires.Format(time.RFC3339)
+	}
+	log.Printf("SECURITY: IP %s banned %s: %s", ip, until, reason)
 }
 
 // ClearFailedLoginAttempts clears the failed login counter for an IP on successful authentication.
+// The IP's connection flood count is cleared too, so a user whose client
+// opens many connections (scp, SFTP) is not banned for it.
 func (ct *ConnectionTracker) ClearFailedLoginAttempts(ip string) {
+	ct.floods.Forget(ip)
+
 	ct.mu.Lock()
 	defer ct.mu.Unlock()
 
@@ -881,17 +941,30 @@ func sessionHandler(s ssh.Session) {
 				ConnectTime:    startTime,
 				DisconnectTime: disconnectTime,
 				Duration:       duration,
-				UploadedMB:     0.0,
-				DownloadedMB:   0.0,
-				Actions:        "",
 				BaudRate:       "38400",
 			}
 			if bbsSession != nil {
+				totals := bbsSession.TransferTotals()
+				callRec.UploadedMB = user.BytesToMB(totals.UploadedBytes)
+				callRec.DownloadedMB = user.BytesToMB(totals.DownloadedBytes)
+				callRec.Uploads = totals.Uploads
+				callRec.Downloads = totals.Downloads
+				callRec.Actions = totals.Actions()
 				bbsSession.Mutex.RLock()
 				callRec.Invisible = bbsSession.Invisible
 				bbsSession.Mutex.RUnlock()
 			}
 			userMgr.AddCallRecord(callRec)
+			if err := userMgr.LogActivity(user.ActivityRecord{
+				Timestamp: disconnectTime,
+				UserID:    callRec.UserID,
+				Handle:    callRec.Handle,
+				Kind:      user.ActivityCall,
+				Duration:  duration,
+				Invisible: callRec.Invisible,
+			}); err != nil {
+				log.Printf("WARN: Node %d: Failed to write activity log: %v", nodeID, err)
+			}
 		} else {
 			log.Printf("DEBUG: Node %d: No authenticated user found, skipping call record.", nodeID)
 		}
@@ -1062,19 +1135,34 @@ func sessionHandler(s ssh.Session) {
 	currentMenuName := "LOGIN"               // Start with LOGIN
 	autoRunLog := make(types.AutoRunTracker) // Initialize tracker for this session
 
-	// Check if user is already authenticated via SSH
+	// Check if user is already authenticated via SSH (registered public key
+	// or BBS password given to the SSH client)
 	sshUsername := s.User()
 	if sshUsername != "" {
 		log.Printf("Node %d: SSH user '%s' detected, attempting auto-login", nodeID, sshUsername)
 		// Try to load the user from the database
+		verifiedName, keyFingerprint := verifiedSSHUser(s)
+		if keyFingerprint != "" {
+			// Recorded before loading the user so the copy saved below has it
+			if err := userMgr.TouchSSHKey(verifiedName, keyFingerprint); err != nil {
+				log.Printf("WARN: Node %d: Failed to record SSH key use for %s: %v", nodeID, verifiedName, err)
+			}
+		}
 		sshUser, found := userMgr.GetUser(sshUsername)
-		if found && sshUser != nil {
-			// User exists in database, authenticate them automatically
+		if found && sshUser != nil && verifiedName != "" && !sshUser.DeletedUser && !menuExecutor.TOTPRequired(sshUser) {
+			// Identity proven during SSH auth, authenticate them automatically.
+			// Accounts that must still set up two-factor login go through the
+			// normal login, which makes them enrol.
 			authenticatedUser = sshUser
+			connectionTracker.ClearFailedLoginAttempts(extractIP(s.RemoteAddr()))
 			bbsSession.Mutex.Lock()
 			bbsSession.User = authenticatedUser
 			bbsSession.Mutex.Unlock()
-			log.Printf("Node %d: SSH auto-login successful for user '%s' (Handle: %s)", nodeID, sshUsername, sshUser.Handle)
+			if keyFingerprint != "" {
+				log.Printf("Node %d: SSH auto-login successful for user '%s' (Handle: %s) with key %s", nodeID, sshUsername, sshUser.Handle, keyFingerprint)
+			} else {
+				log.Printf("Node %d: SSH auto-login successful for user '%s' (Handle: %s)", nodeID, sshUsername, sshUser.Handle)
+			}
 
 			// Mark user as online
 			userMgr.MarkUserOnline(authenticatedUser.ID)
@@ -1118,6 +1206,8 @@ func sessionHandler(s ssh.Session) {
 			}
 
 			currentMenuName = "MAIN" // Will be overridden by login sequence result
+		} else if found && sshUser != nil {
+			log.Printf("Node %d: SSH user '%s' not verified by key or password, sending to PDMATRIX screen", nodeID, sshUsername)
 		} else {
 			log.Printf("Node %d: SSH user '%s' not found in BBS database, sending to PDMATRIX screen", nodeID, sshUsername)
 			// User not in database, send to PDMATRIX like telnet users
@@ -1208,6 +1298,20 @@ func sessionHandler(s ssh.Session) {
 	}
 	log.Printf("Node %d: Entering main loop for authenticated user: %s", nodeID, authenticatedUser.Handle)
 
+	// Drop expired accounts to their expire-to level (or warn those about to
+	// expire) before the level's limits are applied
+	menuExecutor.CheckAccountExpiry(s, terminal, userMgr, authenticatedUser, int(nodeID), effectiveMode)
+
+	// Count the call and start its time clock; callers out of calls or
+	// daily time go no further
+	if !menuExecutor.AdmitDailyCall(s, terminal, userMgr, authenticatedUser, int(nodeID), effectiveMode) {
+		return
+	}
+	if !menuExecutor.StartSessionClock(s, terminal, authenticatedUser, int(nodeID), effectiveMode) {
+		return
+	}
+	defer menuExecutor.EndSessionClock(s, userMgr, authenticatedUser, int(nodeID))
+
 	// --- Invisible Login Prompt (users at or above invisibleLevel) ---
 	cfg := menuExecutor.GetServerConfig()
 	invLevel := cfg.InvisibleLevel
@@ -1446,7 +1550,9 @@ func sessionHandler(s ssh.Session) {
 	for {
 		if currentMenuName == "" || currentMenuName == "LOGOFF" {
 			log.Printf("Node %d: User %s selected Logoff or reached end state.", nodeID, authenticatedUser.Handle)
-			fmt.Fprintln(terminal, "\r\nLogging off...")
+			if !menuExecutor.AnnounceTimeUp(s, terminal, int(nodeID), effectiveMode) {
+				fmt.Fprintln(terminal, "\r\nLogging off...")
+			}
 			// Add any cleanup tasks before closing the session
 			break // Exit the loop
 		}
@@ -1466,6 +1572,10 @@ func sessionHandler(s ssh.Session) {
 		// Pass "" for currentAreaName for now (TODO: Pass actual session area name)
 		nextMenuName, _, execErr := menuExecutor.Run(s, terminal, userMgr, authenticatedUser, currentMenuName, int(nodeID), sessionStartTime, autoRunLog, effectiveMode, "", int(termWidth.Load()), int(termHeight.Load()))
 		if execErr != nil {
+			if errors.Is(execErr, editor.ErrTimeLimit) {
+				currentMenuName = "LOGOFF"
+				continue
+			}
 			log.Printf("Node %d: Error executing menu '%s': %v", nodeID, currentMenuName, execErr)
 			fmt.Fprintf(terminal, "\r\nSystem error during menu execution: %v\r\n", execErr)
 			// Logoff on error?
@@ -1568,6 +1678,16 @@ func main() {
 	)
 	defer connectionTracker.StopWatching() // Ensure file watcher is stopped on shutdown
 
+	// Dynamic IP bans, shared with `helper bans` through data/bans.json
+	// Refuse to start without it, or automatic bans would be dropped.
+	banList, err := ban.Open(filepath.Join(dataPath, ban.FileName))
+	if err != nil {
+		log.Fatalf("Failed to load IP bans: %v (fix or remove the file to start)", err)
+	}
+	connectionTracker.SetBans(banList)
+	log.Printf("INFO: Loaded %d IP ban(s) from %s", len(banList.Bans()), banList.Path())
+	connectionTracker.SetAutoBanLimits(serverConfig)
+
 	log.Printf("INFO: Connection security configured - Max Nodes: %d, Max Connections Per IP: %d, Max Failed Logins: %d, Lockout: %d min",
 		serverConfig.MaxNodes, serverConfig.MaxConnectionsPerIP, serverConfig.MaxFailedLogins, serverConfig.LockoutMinutes)
 
@@ -1629,8 +1749,11 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize user manager: %v", err)
 	}
+	defer userMgr.Close()
 	// Set the new user level from config
 	userMgr.SetNewUserLevel(serverConfig.NewUserLevel)
+	userMgr.SetPasswordPolicy(user.PasswordPolicyFromConfig(serverConfig))
+	userMgr.SetTOTPRequiredLevel(serverConfig.TOTPRequiredLevel)
 
 	// Initialize MessageManager (areas config from configs/, message data from data/)
 	messageMgr, err = message.NewMessageManager(dataPath, rootConfigPath, serverConfig.BoardName, networkTearlines)
@@ -1676,6 +1799,21 @@ func main() {
 
 	// Initialize MenuExecutor with new paths, loaded theme, server config, message manager, and connection tracker
 	menuExecutor = menu.NewExecutor(menuSetPath, rootConfigPath, rootAssetsPath, oneliners, loadedDoors, loadedStrings, loadedTheme, serverConfig, messageMgr, fileMgr, confMgr, connectionTracker, loginSequence, sessionRegistry, chatRoom, loadedProtocols)
+	menuExecutor.Bans = banList
+
+	// Load security level profiles (optional)
+	if levels, err := config.LoadSecurityLevels(rootConfigPath); err != nil {
+		log.Printf("WARN: Failed to load levels.json: %v. Security level profiles disabled.", err)
+	} else {
+		menuExecutor.SetSecurityLevels(levels)
+	}
+
+	// Load infoforms (optional)
+	if forms, err := config.LoadInfoForms(rootConfigPath); err != nil {
+		log.Printf("WARN: Failed to load infoforms.json: %v. Infoforms disabled.", err)
+	} else {
+		menuExecutor.SetInfoForms(forms)
+	}
 
 	// Initialize configuration file watcher for hot reload
 	var serverConfigMu sync.RWMutex
@@ -1684,7 +1822,15 @@ func main() {
 		log.Printf("WARN: Failed to start config file watcher: %v. Hot reload disabled.", err)
 	} else {
 		defer configWatcher.Stop()
-		log.Printf("INFO: Configuration hot reload enabled for doors.json, login.json, strings.json, theme.json, server.json")
+		log.Printf("INFO: Configuration hot reload enabled for doors.json, login.json, levels.json, infoforms.json, strings.json, theme.json, server.json")
+	}
+
+	// Watch file area directories with sync enabled
+	fileAreaWatcher, err := NewFileAreaWatcher(fileMgr, menuExecutor)
+	if err != nil {
+		log.Printf("WARN: Failed to start file area watcher: %v. Directory sync disabled.", err)
+	} else if fileAreaWatcher != nil {
+		defer fileAreaWatcher.Stop()
 	}
 
 	if ftnErr == nil && len(ftnConfig.Networks) > 0 {
@@ -1705,6 +1851,12 @@ func main() {
 	if eventsConfig.Enabled {
 		historyPath := filepath.Join(dataPath, "logs", "event_history.json")
 		eventScheduler = scheduler.NewScheduler(eventsConfig, historyPath)
+		eventScheduler.RegisterInternal(config.InternalExpireAccounts, func(ctx context.Context) (string, error) {
+			return menuExecutor.ExpireAccounts(userMgr)
+		})
+		eventScheduler.RegisterInternal(config.InternalPurgePartials, func(ctx context.Context) (string, error) {
+			return menuExecutor.PurgePartials()
+		})
 		schedulerCtx, schedulerCancel = context.WithCancel(context.Background())
 		defer func() {
 			if schedulerCancel != nil {
@@ -1756,9 +1908,11 @@ func main() {
 		log.Printf("INFO: Configuring telnet server on %s:%d...", telnetHost, telnetPort)
 
 		telnetSrv, telnetErr := telnetserver.NewServer(telnetserver.Config{
-			Port:           telnetPort,
-			Host:           telnetHost,
-			SessionHandler: telnetSessionHandler,
+			Port:             telnetPort,
+			Host:             telnetHost,
+			SessionHandler:   telnetSessionHandler,
+			AllowConn:        connectionTracker.AllowConn,
+			CheckNegotiation: connectionTracker.CheckTelnetNegotiation,
 		})
 		if telnetErr != nil {
 			log.Fatalf("FATAL: Failed to create telnet server: %v", telnetErr)
@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gliderlabs/ssh"
 	"github.com/stlalpha/vision3/internal/sshserver"
+	"github.com/stlalpha/vision3/internal/user"
 	gossh "golang.org/x/crypto/ssh"
 )
 
@@ -21,17 +23,49 @@ func startSSHServer(hostKeyPath, sshHost string, sshPort int, legacyAlgorithms b
 		Port:                sshPort,
 		LegacySSHAlgorithms: legacyAlgorithms,
 		SessionHandler:      sshSessionHandler,
+		SubsystemHandlers:   map[string]ssh.SubsystemHandler{"sftp": sftpSubsystemHandler},
 		Version:             "Vision3",
+		AllowConn:           connectionTracker.AllowConn,
 		// BBS handles its own login flow — accept all SSH auth methods.
 		// The PasswordHandler and KeyboardInteractiveHandler both return true
 		// so any SSH client (SyncTERM, NetRunner, OpenSSH, etc.) can connect
-		// regardless of which auth method it prefers.
+		// regardless of which auth method it prefers. A password that matches
+		// the BBS account is remembered so the session can skip the BBS login
+		// and SFTP and scp can trust it.
 		PasswordHandler: func(ctx ssh.Context, password string) bool {
 			log.Printf("DEBUG: SSH password auth from user=%q addr=%s", ctx.User(), ctx.RemoteAddr())
+			recordFileAccessLogin(ctx, password, nil)
 			return true
 		},
 		KeyboardInteractiveHandler: func(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool {
 			log.Printf("DEBUG: SSH keyboard-interactive auth from user=%q addr=%s", ctx.User(), ctx.RemoteAddr())
+			clearVerifiedLogin(ctx)
+			// Only existing accounts are asked for a password; new callers
+			// still go straight to the BBS.
+			if _, found := userMgr.GetUser(ctx.User()); found {
+				answers, err := challenger(ctx.User(), "", []string{"Password: "}, []bool{false})
+				if err == nil && len(answers) == 1 {
+					recordFileAccessLogin(ctx, answers[0], func() (string, bool) {
+						codes, err := challenger(ctx.User(), "", []string{"Verification code: "}, []bool{true})
+						if err != nil || len(codes) != 1 {
+							return "", false
+						}
+						return codes[0], true
+					})
+				}
+			}
+			return true
+		},
+		// Only keys registered to the account are accepted, so clients
+		// offering other keys fall back to password or keyboard-interactive.
+		PublicKeyHandler: func(ctx ssh.Context, key ssh.PublicKey) bool {
+			u, ok := userMgr.CheckSSHKey(ctx.User(), key)
+			if !ok {
+				return false
+			}
+			log.Printf("DEBUG: SSH public key %s accepted for user=%q addr=%s", gossh.FingerprintSHA256(key), ctx.User(), ctx.RemoteAddr())
+			ctx.SetValue(fileAccessUserKey{}, u.Username)
+			ctx.SetValue(sshKeyFingerprintKey{}, gossh.FingerprintSHA256(key))
 			return true
 		},
 	})
@@ -71,6 +105,158 @@ func sshSessionHandler(sess ssh.Session) {
 	// Connection is registered; ensure it's removed when done
 	defer connectionTracker.RemoveConnection(wrapped.RemoteAddr())
 
+	// Legacy scp runs as a command instead of the sftp subsystem.
+	if cmd := sess.Command(); len(cmd) > 0 && cmd[0] == "scp" {
+		scpCommandHandler(wrapped, cmd[1:])
+		return
+	}
+
 	// Call the existing session handler with the wrapped session
 	sessionHandler(wrapped)
 }
+
+// fileAccessUserKey holds the username whose BBS password or registered
+// public key was verified during SSH authentication.
+type fileAccessUserKey struct{}
+
+// sshKeyFingerprintKey holds the fingerprint of the public key that was
+// accepted during SSH authentication.
+type sshKeyFingerprintKey struct{}
+
+// clearVerifiedLogin forgets a user recorded by the PublicKeyHandler. The
+// handler runs when a client merely offers a key, before it proves it holds
+// the private key, so if the client goes on to try another method the key
+// was never verified.
+func clearVerifiedLogin(ctx ssh.Context) {
+	ctx.SetValue(fileAccessUserKey{}, "")
+	ctx.SetValue(sshKeyFingerprintKey{}, "")
+}
+
+// recordFileAccessLogin remembers the connecting user if password matches
+// their BBS account. Accounts with two-factor login also need a code from
+// askCode; when there is no way to ask (plain password auth) the login is
+// left unverified and the BBS asks for the code itself. Wrong passwords and
+// codes count toward the IP lockout like failed BBS logins, and a locked-out
+// IP is not checked at all.
+func recordFileAccessLogin(ctx ssh.Context, password string, askCode func() (string, bool)) {
+	clearVerifiedLogin(ctx)
+	ip := extractIP(ctx.RemoteAddr())
+	if locked, lockedUntil, _ := connectionTracker.IsIPLockedOut(ip); locked {
+		log.Printf("SECURITY: SSH password for user=%q from locked IP %s not checked (locked until %s)",
+			ctx.User(), ip, lockedUntil.Format(time.RFC3339))
+		return
+	}
+	u, ok := userMgr.CheckPassword(ctx.User(), password)
+	if !ok {
+		if _, exists := userMgr.GetUser(ctx.User()); exists && password != "" {
+			log.Printf("WARN: SSH password for user=%q from %s was wrong", ctx.User(), ip)
+			connectionTracker.RecordFailedLoginAttempt(ip)
+		}
+		return
+	}
+	if menuExecutor.TOTPRequired(u) {
+		return
+	}
+	if u.TOTPEnabled() {
+		if askCode == nil {
+			return
+		}
+		code, answered := askCode()
+		if !answered {
+			return
+		}
+		if _, _, valid := userMgr.CheckSecondFactor(u.Username, code); !valid {
+			log.Printf("WARN: SSH two-factor code for user=%q from %s was wrong", ctx.User(), ctx.RemoteAddr())
+			connectionTracker.RecordFailedLoginAttempt(ip)
+			return
+		}
+	}
+	ctx.SetValue(fileAccessUserKey{}, u.Username)
+}
+
+// verifiedSSHUser returns the username proven during SSH authentication by
+// password or public key, and the fingerprint of the key if one was used.
+// It is empty for telnet sessions and for SSH logins that were not verified.
+func verifiedSSHUser(s ssh.Session) (username, fingerprint string) {
+	ctx := s.Context()
+	if ctx == nil || s.User() == "" {
+		return "", ""
+	}
+	username, _ = ctx.Value(fileAccessUserKey{}).(string)
+	if username == "" || !strings.EqualFold(username, s.User()) {
+		return "", ""
+	}
+	fingerprint, _ = ctx.Value(sshKeyFingerprintKey{}).(string)
+	return username, fingerprint
+}
+
+// fileAccessUser returns the verified BBS user for an SFTP or scp session,
+// writing the reason to stderr when access is refused.
+func fileAccessUser(sess ssh.Session) *user.User {
+	if !menuExecutor.GetServerConfig().SFTPEnabled {
+		fmt.Fprint(sess.Stderr(), "SFTP and scp are disabled on this BBS.\r\n")
+		return nil
+	}
+	username, _ := verifiedSSHUser(sess)
+	if username == "" {
+		log.Printf("INFO: Refusing file access for %q from %s: password not verified", sess.User(), sess.RemoteAddr())
+		fmt.Fprint(sess.Stderr(), "File access requires your BBS username and password.\r\n")
+		return nil
+	}
+	u, ok := userMgr.GetUser(username)
+	if !ok || u.DeletedUser {
+		fmt.Fprint(sess.Stderr(), "Account not available.\r\n")
+		return nil
+	}
+	if menuExecutor.TOTPRequired(u) {
+		fmt.Fprint(sess.Stderr(), "Log in to the BBS to set up two-factor login first.\r\n")
+		return nil
+	}
+	if userMgr.NeedsPasswordChange(u) {
+		log.Printf("INFO: Refusing file access for %s from %s: password change required", u.Handle, sess.RemoteAddr())
+		fmt.Fprint(sess.Stderr(), "Your password must be changed. Log in to the BBS first.\r\n")
+		return nil
+	}
+	connectionTracker.ClearFailedLoginAttempts(extractIP(sess.RemoteAddr()))
+	return u
+}
+
+// sftpSubsystemHandler serves the file areas over SFTP.
+func sftpSubsystemHandler(sess ssh.Session) {
+	u := fileAccessUser(sess)
+	if u == nil {
+		sess.Exit(1)
+		return
+	}
+	canAccept, reason := connectionTracker.TryAccept(sess.RemoteAddr())
+	if !canAccept {
+		log.Printf("INFO: Rejecting SFTP connection from %s: %s", sess.RemoteAddr(), reason)
+		fmt.Fprintf(sess.Stderr(), "Connection rejected: %s\r\n", reason)
+		sess.Exit(1)
+		return
+	}
+	defer connectionTracker.RemoveConnection(sess.RemoteAddr())
+
+	if err := menuExecutor.ServeSFTP(sess, userMgr, u); err != nil {
+		log.Printf("WARN: SFTP session for %s ended with error: %v", u.Handle, err)
+		sess.Exit(1)
+		return
+	}
+	sess.Exit(0)
+}
+
+// scpCommandHandler runs a legacy "scp -f" or "scp -t" command.
+func scpCommandHandler(sess ssh.Session, args []string) {
+	u := fileAccessUser(sess)
+	if u == nil {
+		sess.Exit(1)
+		return
+	}
+	log.Printf("INFO: SCP: %s running scp %v from %s", u.Handle, args, sess.RemoteAddr())
+	if err := menuExecutor.ServeSCP(sess, userMgr, u, args); err != nil {
+		log.Printf("WARN: SCP for %s: %v", u.Handle, err)
+		sess.Exit(1)
+		return
+	}
+	sess.Exit(0)
+}
@@ -0,0 +1,382 @@
+// Package ban keeps the list of banned IP addresses and ranges. The list is
+// a JSON file shared by the BBS and the helper tool; each side picks up the
+// other's changes the next time it looks at the list. Changes are made under
+// a lock on a .lock file next to the list, so one side never overwrites a
+// ban the other has just added.
+package ban
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the ban list's file name in the data directory.
+const FileName = "bans.json"
+
+// AutoBanBy is the AddedBy of bans the BBS adds on its own.
+const AutoBanBy = "auto"
+
+var (
+	ErrExists   = errors.New("target is already banned")
+	ErrNotFound = errors.New("ban not found")
+)
+
+// Ban is one banned IP address or CIDR range.
+type Ban struct {
+	ID      int        `json:"id"`
+	Target  string     `json:"target"` // IP address or CIDR range
+	Reason  string     `json:"reason,omitempty"`
+	AddedBy string     `json:"addedBy"`
+	Added   time.Time  `json:"added"`
+	Expires *time.Time `json:"expires,omitempty"` // nil = never
+}
+
+// Expired reports whether the ban has run out at now.
+func (b Ban) Expired(now time.Time) bool {
+	return b.Expires != nil && !now.Before(*b.Expires)
+}
+
+// listFile is the layout of bans.json. NextID is kept in the file so the
+// ID of a ban that was removed or ran out is never handed out again. Older
+// files are a bare array of bans.
+type listFile struct {
+	NextID int   `json:"nextId"`
+	Bans   []Ban `json:"bans"`
+}
+
+// List is the ban list backed by a JSON file.
+type List struct {
+	mu      sync.Mutex
+	path    string
+	bans    []Ban
+	nets    []*net.IPNet // parsed Target of each ban, same order as bans
+	nextID  int          // ID for the next ban added
+	modTime time.Time    // file state at the last load or save
+	size    int64
+}
+
+// Open loads the ban list at path. A missing file is an empty list.
+func Open(path string) (*List, error) {
+	l := &List{path: path}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the ban list's file path.
+func (l *List) Path() string {
+	return l.path
+}
+
+// ParseTarget parses an IP address or CIDR range and returns it in
+// canonical form. A bare address becomes a single-host range.
+func ParseTarget(s string) (string, *net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid CIDR range %q", s)
+		}
+		return n.String(), n, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "", nil, fmt.Errorf("invalid IP address %q", s)
+	}
+	bits := 128
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits = v4, 32
+	}
+	return ip.String(), &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// ParseDuration parses a ban length such as 30m, 12h, 7d or 2w. An empty
+// string, "0" or "never" means a permanent ban and returns 0.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "0", "never", "perm", "permanent":
+		return 0, nil
+	}
+	unit := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if u, ok := unit[s[len(s)-1]]; ok {
+		if n, err := strconv.Atoi(s[:len(s)-1]); err == nil && n > 0 {
+			return time.Duration(n) * u, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid ban length %q (use e.g. 30m, 12h, 7d, 2w or never)", s)
+}
+
+// Check returns the active ban covering ip, if any. The list is reloaded
+// first if the file has changed.
+func (l *List) Check(ip string) (Ban, bool) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return Ban{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refresh()
+
+	now := time.Now()
+	for i, b := range l.bans {
+		if !b.Expired(now) && l.nets[i].Contains(addr) {
+			return b, true
+		}
+	}
+	return Ban{}, false
+}
+
+// Bans returns the active bans, oldest first.
+func (l *List) Bans() []Ban {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refresh()
+
+	now := time.Now()
+	var active []Ban
+	for _, b := range l.bans {
+		if !b.Expired(now) {
+			active = append(active, b)
+		}
+	}
+	return active
+}
+
+// Add bans target (an IP address or CIDR range) for d, or for good if d is
+// zero, and saves the list. It returns ErrExists if target already has an
+// active ban.
+func (l *List) Add(target, reason, addedBy string, d time.Duration) (Ban, error) {
+	canon, n, err := ParseTarget(target)
+	if err != nil {
+		return Ban{}, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := l.lock()
+	if err != nil {
+		return Ban{}, err
+	}
+	defer unlock()
+	if err := l.load(); err != nil {
+		return Ban{}, err
+	}
+
+	now := time.Now()
+	for _, b := range l.bans {
+		if b.Target == canon && !b.Expired(now) {
+			return b, ErrExists
+		}
+	}
+	b := Ban{
+		ID:      l.nextID,
+		Target:  canon,
+		Reason:  strings.TrimSpace(reason),
+		AddedBy: addedBy,
+		Added:   now,
+	}
+	if d > 0 {
+		expires := now.Add(d)
+		b.Expires = &expires
+	}
+	l.bans = append(l.bans, b)
+	l.nets = append(l.nets, n)
+	l.nextID++
+	if err := l.save(); err != nil {
+		return Ban{}, err
+	}
+	return b, nil
+}
+
+// Remove lifts ban id and saves the list.
+func (l *List) Remove(id int) (Ban, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := l.lock()
+	if err != nil {
+		return Ban{}, err
+	}
+	defer unlock()
+	if err := l.load(); err != nil {
+		return Ban{}, err
+	}
+	for i, b := range l.bans {
+		if b.ID == id {
+			l.bans = append(l.bans[:i], l.bans[i+1:]...)
+			l.nets = append(l.nets[:i], l.nets[i+1:]...)
+			return b, l.save()
+		}
+	}
+	return Ban{}, ErrNotFound
+}
+
+// Prune drops expired bans from the file and returns how many there were.
+func (l *List) Prune() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := l.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if err := l.load(); err != nil {
+		return 0, err
+	}
+	before := len(l.bans)
+	if err := l.save(); err != nil {
+		return 0, err
+	}
+	return before - len(l.bans), nil
+}
+
+// lock takes the lock other processes changing the list also take, waiting
+// for them to finish. The returned function releases it.
+func (l *List) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create ban list directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ban list lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock ban list: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// refresh reloads the list if the file changed since it was last read or
+// written. Errors keep the list already in memory.
+func (l *List) refresh() {
+	fi, err := os.Stat(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && len(l.bans) > 0 {
+			l.bans, l.nets = nil, nil
+			l.modTime, l.size = time.Time{}, 0
+		}
+		return
+	}
+	if fi.ModTime().Equal(l.modTime) && fi.Size() == l.size {
+		return
+	}
+	l.load()
+}
+
+// load reads the file into memory, replacing the current list.
+func (l *List) load() error {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		l.bans, l.nets = nil, nil
+		l.nextID = 1
+		l.modTime, l.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read ban list %s: %w", l.path, err)
+	}
+	var f listFile
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &f.Bans)
+	} else if trimmed != "" {
+		err = json.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse ban list %s: %w", l.path, err)
+	}
+
+	// A hand-edited or older file may lack the counter or lag behind it.
+	l.nextID = max(f.NextID, 1)
+	for _, b := range f.Bans {
+		l.nextID = max(l.nextID, b.ID+1)
+	}
+	l.bans = l.bans[:0]
+	for _, b := range f.Bans {
+		canon, _, err := ParseTarget(b.Target)
+		if err != nil {
+			continue // skip entries edited into something unparseable
+		}
+		b.Target = canon
+		l.bans = append(l.bans, b)
+	}
+	sort.SliceStable(l.bans, func(i, j int) bool { return l.bans[i].ID < l.bans[j].ID })
+	l.reparse()
+	l.stat()
+	return nil
+}
+
+// reparse rebuilds nets to match bans.
+func (l *List) reparse() {
+	l.nets = l.nets[:0]
+	for _, b := range l.bans {
+		_, n, _ := ParseTarget(b.Target)
+		l.nets = append(l.nets, n)
+	}
+}
+
+// save drops expired bans and writes the list, replacing the file in one
+// step so a reader never sees it half written. Callers hold the lock.
+func (l *List) save() error {
+	now := time.Now()
+	kept := l.bans[:0]
+	for _, b := range l.bans {
+		if !b.Expired(now) {
+			kept = append(kept, b)
+		}
+	}
+	l.bans = kept
+	l.reparse()
+
+	bans := l.bans
+	if bans == nil {
+		bans = []Ban{}
+	}
+	data, err := json.MarshalIndent(listFile{NextID: l.nextID, Bans: bans}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ban list: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create ban list directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write ban list: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write ban list: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace ban list: %w", err)
+	}
+	l.stat()
+	return nil
+}
+
+// stat records the file's current state for refresh.
+func (l *List) stat() {
+	if fi, err := os.Stat(l.path); err == nil {
+		l.modTime, l.size = fi.ModTime(), fi.Size()
+	}
+}
@@ -0,0 +1,215 @@
+package ban
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestList_AddCheckRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if _, err := l.Add("203.0.113.7", "spam", "SysOp", 0); err != nil {
+		t.Fatalf("Add IP: %v", err)
+	}
+	r, err := l.Add("198.51.100.0/24", "scanner", "SysOp", time.Hour)
+	if err != nil {
+		t.Fatalf("Add CIDR: %v", err)
+	}
+	if r.Expires == nil {
+		t.Error("expected an expiry on a timed ban")
+	}
+	if _, err := l.Add("203.0.113.7", "again", "SysOp", 0); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate ban: got %v, want ErrExists", err)
+	}
+	if _, err := l.Add("not-an-ip", "", "SysOp", 0); err == nil {
+		t.Error("expected an error for a bad target")
+	}
+
+	for ip, want := range map[string]bool{
+		"203.0.113.7":    true,
+		"203.0.113.8":    false,
+		"198.51.100.200": true,
+		"198.51.101.1":   false,
+	} {
+		if _, got := l.Check(ip); got != want {
+			t.Errorf("Check(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if _, err := l.Remove(r.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, banned := l.Check("198.51.100.200"); banned {
+		t.Error("range still banned after Remove")
+	}
+	if _, err := l.Remove(r.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Remove: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestList_ExpiryAndPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	past := time.Now().Add(-time.Minute)
+	os.WriteFile(path, []byte(`[
+  {"id": 1, "target": "192.0.2.1", "addedBy": "auto", "added": "2026-01-01T00:00:00Z", "expires": "`+past.Format(time.RFC3339)+`"},
+  {"id": 2, "target": "192.0.2.2", "addedBy": "SysOp", "added": "2026-01-01T00:00:00Z"}
+]`), 0644)
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, banned := l.Check("192.0.2.1"); banned {
+		t.Error("expired ban still applies")
+	}
+	if got := len(l.Bans()); got != 1 {
+		t.Errorf("Bans() = %d active, want 1", got)
+	}
+	// An expired ban doesn't block a new one for the same address.
+	if _, err := l.Add("192.0.2.1", "back again", AutoBanBy, time.Hour); err != nil {
+		t.Errorf("re-ban after expiry: %v", err)
+	}
+	if n, err := l.Prune(); err != nil || n != 0 {
+		t.Errorf("Prune = %d, %v; want 0 (Add already dropped the expired ban)", n, err)
+	}
+}
+
+func TestList_PicksUpOtherWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	bbs, _ := Open(path)
+	helper, _ := Open(path)
+
+	if _, err := helper.Add("192.0.2.50", "from helper", "helper", 0); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, banned := bbs.Check("192.0.2.50"); !banned {
+		t.Error("ban added by another writer not seen")
+	}
+	// A write from the BBS keeps the other writer's ban.
+	if _, err := bbs.Add("192.0.2.51", "", AutoBanBy, 0); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if got := len(helper.Bans()); got != 2 {
+		t.Errorf("helper sees %d bans, want 2", got)
+	}
+}
+
+// TestList_ConcurrentWriters has two lists on the same file, as the BBS and
+// the helper would, add bans at the same time. None may be lost.
+func TestList_ConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	bbs, _ := Open(path)
+	helper, _ := Open(path)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i, l := range []*List{bbs, helper} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n; j++ {
+				if _, err := l.Add(fmt.Sprintf("192.0.%d.%d", i, j+1), "", "SysOp", 0); err != nil {
+					t.Errorf("Add: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	other, _ := Open(path)
+	if got := len(other.Bans()); got != 2*n {
+		t.Errorf("got %d bans, want %d", got, 2*n)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(matches) > 0 {
+		t.Errorf("temp files left behind: %v", matches)
+	}
+}
+
+func TestList_IDsNotReused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	l, _ := Open(path)
+
+	first, _ := l.Add("192.0.2.1", "", "SysOp", 0)
+	second, _ := l.Add("192.0.2.2", "", "SysOp", 0)
+	if _, err := l.Remove(second.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	// The counter is in the file, so a fresh reader doesn't reuse it either.
+	other, _ := Open(path)
+	third, err := other.Add("192.0.2.3", "", "SysOp", 0)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if third.ID <= second.ID || third.ID == first.ID {
+		t.Errorf("new ban got ID %d after %d was removed", third.ID, second.ID)
+	}
+
+	// An older file without the counter continues after its highest ID.
+	os.WriteFile(path, []byte(`[{"id": 7, "target": "192.0.2.7", "addedBy": "SysOp", "added": "2026-01-01T00:00:00Z"}]`), 0644)
+	if b, err := l.Add("192.0.2.8", "", "SysOp", 0); err != nil || b.ID != 8 {
+		t.Errorf("Add on old file = %d, %v; want ID 8", b.ID, err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"":      0,
+		"never": 0,
+		"30m":   30 * time.Minute,
+		"12h":   12 * time.Hour,
+		"7d":    7 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+	} {
+		if got, err := ParseDuration(in); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"x", "5", "-3d", "d"} {
+		if _, err := ParseDuration(bad); err == nil {
+			t.Errorf("ParseDuration(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestCounter(t *testing.T) {
+	c := NewCounter(3, time.Minute)
+	now := time.Now()
+	if c.Hit("a", now) || c.Hit("a", now.Add(time.Second)) {
+		t.Fatal("tripped early")
+	}
+	if c.Hit("b", now) {
+		t.Fatal("addresses share a count")
+	}
+	if !c.Hit("a", now.Add(2*time.Second)) {
+		t.Fatal("did not trip at the limit")
+	}
+	// Hits outside the window don't count.
+	c.Hit("c", now)
+	c.Hit("c", now.Add(time.Second))
+	if c.Hit("c", now.Add(2*time.Minute)) {
+		t.Error("old hits counted")
+	}
+
+	// Forgotten hits don't count.
+	c.Hit("d", now)
+	c.Hit("d", now.Add(time.Second))
+	c.Forget("d")
+	if c.Hit("d", now.Add(2*time.Second)) {
+		t.Error("forgotten hits counted")
+	}
+
+	if NewCounter(0, time.Minute).Hit("a", now) {
+		t.Error("a zero limit tripped")
+	}
+}
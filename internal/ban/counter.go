@@ -0,0 +1,69 @@
+package ban
+
+import (
+	"sync"
+	"time"
+)
+
+// Counter counts offences per IP address within a sliding window, to spot
+// addresses that should be banned automatically.
+type Counter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+// NewCounter returns a counter that trips when an address commits limit
+// offences within window. A limit of 0 never trips.
+func NewCounter(limit int, window time.Duration) *Counter {
+	return &Counter{limit: limit, window: window, hits: make(map[string][]time.Time)}
+}
+
+// SetLimit changes the threshold. Offences already counted are kept.
+func (c *Counter) SetLimit(limit int, window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit, c.window = limit, window
+}
+
+// Hit records an offence by ip at now and reports whether ip has reached
+// the limit. Once tripped the address starts counting from zero again.
+func (c *Counter) Hit(ip string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limit <= 0 {
+		return false
+	}
+
+	cutoff := now.Add(-c.window)
+	recent := c.hits[ip][:0]
+	for _, t := range c.hits[ip] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	if len(recent) >= c.limit {
+		delete(c.hits, ip)
+		return true
+	}
+	c.hits[ip] = recent
+
+	// Forget addresses that have gone quiet so the map doesn't grow forever.
+	if len(c.hits) > 1024 {
+		for k, ts := range c.hits {
+			if !ts[len(ts)-1].After(cutoff) {
+				delete(c.hits, k)
+			}
+		}
+	}
+	return false
+}
+
+// Forget drops the offences counted for ip.
+func (c *Counter) Forget(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.hits, ip)
+}
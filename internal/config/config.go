@@ -51,6 +51,7 @@ type StringsConfig struct {
 	SysOpIsOut              string `json:"sysOpIsOut"`
 	HeaderStr               string `json:"headerStr"`
 	InfoformPrompt          string `json:"infoformPrompt"`
+	FeedbackPrompt          string `json:"feedbackPrompt"`
 	NewInfoFormPrompt       string `json:"newInfoFormPrompt"`
 	UserNotFound            string `json:"userNotFound"`
 	DesignNewPrompt         string `json:"designNewPrompt"`
@@ -227,6 +228,8 @@ type StringsConfig struct {
 	NewUserLocationPrompt   string `json:"newUserLocationPrompt"`
 	NewUserPasswordTooShort string `json:"newUserPasswordTooShort"`
 	NewUserPasswordMismatch string `json:"newUserPasswordMismatch"`
+	PasswordReused          string `json:"passwordReused"`
+	PasswordChangeRequired  string `json:"passwordChangeRequired"`
 	NewUserInvalidRealName  string `json:"newUserInvalidRealName"`
 	NewUserTooManyAttempts  string `json:"newUserTooManyAttempts"`
 	NewUserAccountCreated   string `json:"newUserAccountCreated"`
@@ -727,8 +730,19 @@ type ServerConfig struct {
 	IPAllowlistPath     string `json:"ipAllowlistPath"`
 	MaxFailedLogins     int    `json:"maxFailedLogins"`
 	LockoutMinutes      int    `json:"lockoutMinutes"`
+	AutoBanMinutes      int    `json:"autoBanMinutes"`   // length of automatic IP bans; 0 = permanent
+	FloodConnections    int    `json:"floodConnections"` // connections from one IP within floodSeconds that trigger a ban; 0 = off
+	FloodSeconds        int    `json:"floodSeconds"`
+	MaxTelnetGarbage    int    `json:"maxTelnetGarbage"` // non-telnet bytes during negotiation that trigger a ban; 0 = off
+	TOTPRequiredLevel   int    `json:"totpRequiredLevel"` // users at or above this level must use two-factor login; 0 = optional for everyone
+	PasswordMinLength   int    `json:"passwordMinLength"`  // shortest password accepted at signup and change
+	PasswordHistory     int    `json:"passwordHistory"`    // recent passwords a user may not reuse; 0 = no check
+	PasswordMaxAgeDays  int    `json:"passwordMaxAgeDays"` // days before a password must be changed; 0 = never
+	PasswordHashCost    int    `json:"passwordHashCost"`   // bcrypt cost; lower-cost hashes are upgraded at login
+	PasswordResetHours  int    `json:"passwordResetHours"` // how long a sysop-issued reset code stays valid
 	FileListingMode     string `json:"fileListingMode"`
 	LegacySSHAlgorithms bool   `json:"legacySSHAlgorithms"`
+	SFTPEnabled         bool   `json:"sftpEnabled"` // SFTP and scp access to the file areas on the SSH port
 	AllowNewUsers       bool   `json:"allowNewUsers"`
 
 	// Idle timeout (0 = disabled). Applied across the entire app; any input loop
@@ -739,10 +753,23 @@ type ServerConfig struct {
 	// When exceeded, the transfer process is killed and the session returns to the BBS.
 	TransferTimeoutMinutes int `json:"transferTimeoutMinutes"`
 
+	// Time limits. Each user's timeLimit (per call) and dailyTimeLimit are
+	// enforced by the session loop; users at coSysOpLevel and above are exempt.
+	UploadTimeCredit int             `json:"uploadTimeCredit"` // percent of upload time given back to the caller (0 = none)
+	TimeBankLimits   []TimeBankLimit `json:"timeBankLimits"`   // most minutes each level may bank; empty = no time bank
+
 	// Number of days to retain soft-deleted user accounts before they are eligible
 	// for permanent purge. 0 = purge immediately; -1 = never purge automatically.
 	DeletedUserRetentionDays int `json:"deletedUserRetentionDays"`
 
+	// Number of days to keep interrupted uploads for resuming before the
+	// partial upload purge deletes them. -1 = never purge automatically.
+	PartialRetentionDays int `json:"partialRetentionDays"`
+
+	// Days before an account's expiresAt that the user is warned at login.
+	// 0 = no warning.
+	ExpiryWarningDays int `json:"expiryWarningDays"`
+
 	// New User Voting (NUV) — community-based new user approval (V2 NUV system).
 	UseNUV      bool `json:"useNuv"`      // enable NUV system
 	AutoAddNUV  bool `json:"autoAddNuv"`  // automatically add new registrants to NUV queue
@@ -752,6 +779,31 @@ type ServerConfig struct {
 	NUVValidate bool `json:"nuvValidate"` // auto-validate user when yes threshold reached
 	NUVKill     bool `json:"nuvKill"`     // auto-delete user when no threshold reached
 	NUVLevel    int  `json:"nuvLevel"`    // access level assigned on NUV auto-validation
+
+	// File point economy and download ratios. Per-area costs live in file_areas.json.
+	FilePointsEnabled  bool `json:"filePointsEnabled"`  // charge area download costs and grant upload credit
+	UploadKBPerPoint   int  `json:"uploadKbPerPoint"`   // uploads earn one point per N KB (0 = no upload credit)
+	DownloadRatio      int  `json:"downloadRatio"`      // downloads allowed per upload (0 = no ratio enforced)
+	RatioFreeDownloads int  `json:"ratioFreeDownloads"` // downloads allowed before the ratio applies
+	RatioExemptLevel   int  `json:"ratioExemptLevel"`   // users at or above this level skip points and ratio; 0 = use coSysOpLevel
+}
+
+// TimeBankLimit caps the time bank for users at or above MinLevel.
+type TimeBankLimit struct {
+	MinLevel   int `json:"minLevel"`
+	MaxMinutes int `json:"maxMinutes"`
+}
+
+// TimeBankMax returns the most minutes a user at level may keep in the time
+// bank: the limit with the highest MinLevel the user reaches, or 0 if none.
+func (c ServerConfig) TimeBankMax(level int) int {
+	best, maxMinutes := -1, 0
+	for _, l := range c.TimeBankLimits {
+		if level >= l.MinLevel && l.MinLevel > best {
+			best, maxMinutes = l.MinLevel, l.MaxMinutes
+		}
+	}
+	return maxMinutes
 }
 
 // EventConfig defines a scheduled event configuration
@@ -768,8 +820,17 @@ type EventConfig struct {
 	EnvironmentVars   map[string]string `json:"environment_vars,omitempty"`
 	RunAfter          string            `json:"run_after,omitempty"`           // Event ID to run after
 	DelayAfterSeconds int               `json:"delay_after_seconds,omitempty"` // Delay after RunAfter completes
+	Internal          string            `json:"internal,omitempty"`            // Built-in job run inside the BBS instead of Command
 }
 
+// InternalExpireAccounts is the built-in event job that downgrades
+// expired accounts and mails each caller a notice.
+const InternalExpireAccounts = "expire_accounts"
+
+// InternalPurgePartials is the built-in event job that deletes partial
+// uploads older than partialRetentionDays.
+const InternalPurgePartials = "purge_partials"
+
 // EventsConfig is the root configuration for the event scheduler
 type EventsConfig struct {
 	Enabled             bool          `json:"enabled"`
@@ -802,11 +863,24 @@ func LoadServerConfig(configPath string) (ServerConfig, error) {
 		MaxConnectionsPerIP:       3,
 		MaxFailedLogins:           5,
 		LockoutMinutes:            30,
+		AutoBanMinutes:            1440,
+		FloodConnections:          0,
+		FloodSeconds:              60,
+		MaxTelnetGarbage:          0,
+		PasswordMinLength:         6,
+		PasswordHistory:           3,
+		PasswordMaxAgeDays:        0,
+		PasswordHashCost:          10,
+		PasswordResetHours:        24,
 		AllowNewUsers:             true,
 		SessionIdleTimeoutMinutes: 5,
 		TransferTimeoutMinutes:    10,
+		UploadTimeCredit:          100,
 		LegacySSHAlgorithms:       true,
+		SFTPEnabled:               false,
 		DeletedUserRetentionDays:  30,
+		PartialRetentionDays:      7,
+		ExpiryWarningDays:         7,
 		UseNUV:                    false,
 		AutoAddNUV:                false,
 		NUVUseLevel:               25,
@@ -815,6 +889,10 @@ func LoadServerConfig(configPath string) (ServerConfig, error) {
 		NUVValidate:               true,
 		NUVKill:                   false,
 		NUVLevel:                  25,
+		FilePointsEnabled:         false,
+		UploadKBPerPoint:          100,
+		DownloadRatio:             0,
+		RatioFreeDownloads:        10,
 	}
 
 	data, err := os.ReadFile(filePath)
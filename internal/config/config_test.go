@@ -567,3 +567,132 @@ func TestLoadStrings_NewUsersClosedStr(t *testing.T) {
 		t.Errorf("expected custom NewUsersClosedStr, got %q", result.NewUsersClosedStr)
 	}
 }
+
+func TestTimeBankMax(t *testing.T) {
+	cfg := ServerConfig{TimeBankLimits: []TimeBankLimit{
+		{MinLevel: 50, MaxMinutes: 180},
+		{MinLevel: 10, MaxMinutes: 60},
+	}}
+	tests := map[int]int{5: 0, 10: 60, 49: 60, 50: 180, 255: 180}
+	for level, want := range tests {
+		if got := cfg.TimeBankMax(level); got != want {
+			t.Errorf("TimeBankMax(%d) = %d, want %d", level, got, want)
+		}
+	}
+	if got := (ServerConfig{}).TimeBankMax(255); got != 0 {
+		t.Errorf("no limits: TimeBankMax = %d, want 0", got)
+	}
+}
+
+func TestSecurityLevels_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	levels := []SecurityLevel{
+		{Level: 20, Name: "Regular", TimeLimit: 60, CallsPerDay: 5},
+		{Level: 10, Name: "New User", TimeLimit: 30, Flags: "N"},
+	}
+	if err := SaveSecurityLevels(tmpDir, levels); err != nil {
+		t.Fatalf("SaveSecurityLevels: %v", err)
+	}
+	result, err := LoadSecurityLevels(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadSecurityLevels: %v", err)
+	}
+	if len(result) != 2 || result[0].Level != 10 || result[1].Level != 20 {
+		t.Fatalf("expected levels sorted 10, 20; got %+v", result)
+	}
+	if p, ok := FindSecurityLevel(result, 20); !ok || p.CallsPerDay != 5 {
+		t.Errorf("FindSecurityLevel(20) = %+v, %v", p, ok)
+	}
+	if _, ok := FindSecurityLevel(result, 15); ok {
+		t.Error("found a profile for undefined level 15")
+	}
+}
+
+func TestLoadSecurityLevels_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	if result, err := LoadSecurityLevels(tmpDir); err != nil || result != nil {
+		t.Fatalf("missing file: got %v, %v; want nil, nil", result, err)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "levels.json"), []byte(`[{"level": 10}, {"level": 10}]`), 0644)
+	if _, err := LoadSecurityLevels(tmpDir); err == nil {
+		t.Error("expected error for duplicate level")
+	}
+	os.WriteFile(filepath.Join(tmpDir, "levels.json"), []byte(`[{"level": 300}]`), 0644)
+	if _, err := LoadSecurityLevels(tmpDir); err == nil {
+		t.Error("expected error for level out of range")
+	}
+}
+
+func TestLoadInfoForms(t *testing.T) {
+	tmpDir := t.TempDir()
+	if result, err := LoadInfoForms(tmpDir); err != nil || result != nil {
+		t.Fatalf("missing file: got %v, %v; want nil, nil", result, err)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "infoforms.json"), []byte(`[
+		{"id": 2, "name": "Systems", "questions": [{"id": "os", "text": "Your OS?", "type": "choice", "choices": ["DOS", "Amiga"]}]},
+		{"id": 1, "name": "About You", "newUser": true, "questions": [{"id": "age", "text": "Age?"}]}
+	]`), 0644)
+	forms, err := LoadInfoForms(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadInfoForms: %v", err)
+	}
+	if len(forms) != 2 || forms[0].ID != 1 || forms[1].ID != 2 {
+		t.Fatalf("expected forms sorted 1, 2; got %+v", forms)
+	}
+	if forms[0].Questions[0].Type != InfoQuestionText {
+		t.Errorf("default question type = %q, want text", forms[0].Questions[0].Type)
+	}
+	if f, ok := FindInfoForm(forms, 2); !ok || f.Name != "Systems" {
+		t.Errorf("FindInfoForm(2) = %+v, %v", f, ok)
+	}
+
+	invalid := map[string]string{
+		"duplicate id":   `[{"id": 1, "questions": [{"id": "a"}]}, {"id": 1, "questions": [{"id": "b"}]}]`,
+		"zero id":        `[{"id": 0, "questions": [{"id": "a"}]}]`,
+		"no questions":   `[{"id": 1}]`,
+		"duplicate q id": `[{"id": 1, "questions": [{"id": "a"}, {"id": "a"}]}]`,
+		"one choice":     `[{"id": 1, "questions": [{"id": "a", "type": "choice", "choices": ["x"]}]}]`,
+		"bad pattern":    `[{"id": 1, "questions": [{"id": "a", "pattern": "("}]}]`,
+		"unknown type":   `[{"id": 1, "questions": [{"id": "a", "type": "essay"}]}]`,
+	}
+	for name, data := range invalid {
+		os.WriteFile(filepath.Join(tmpDir, "infoforms.json"), []byte(data), 0644)
+		if _, err := LoadInfoForms(tmpDir); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestInfoQuestionCheckAnswer(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       InfoQuestion
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"optional empty", InfoQuestion{Type: InfoQuestionText}, "  ", "", false},
+		{"required empty", InfoQuestion{Type: InfoQuestionText, Required: true}, "", "", true},
+		{"text trimmed", InfoQuestion{Type: InfoQuestionText}, " hi ", "hi", false},
+		{"too short", InfoQuestion{Type: InfoQuestionText, MinLength: 3}, "ab", "", true},
+		{"too long", InfoQuestion{Type: InfoQuestionText, MaxLength: 3}, "abcd", "", true},
+		{"pattern ok", InfoQuestion{Type: InfoQuestionText, Pattern: `^\d+$`}, "42", "42", false},
+		{"pattern bad", InfoQuestion{Type: InfoQuestionText, Pattern: `^\d+$`, Invalid: "numbers only"}, "x", "", true},
+		{"choice", InfoQuestion{Type: InfoQuestionChoice, Choices: []string{"DOS", "Amiga"}}, "2", "Amiga", false},
+		{"choice out of range", InfoQuestion{Type: InfoQuestionChoice, Choices: []string{"DOS", "Amiga"}}, "3", "", true},
+		{"yes", InfoQuestion{Type: InfoQuestionYesNo}, "y", "Yes", false},
+		{"no", InfoQuestion{Type: InfoQuestionYesNo}, "NO", "No", false},
+		{"yesno other", InfoQuestion{Type: InfoQuestionYesNo}, "maybe", "", true},
+	}
+	for _, tc := range tests {
+		got, err := tc.q.CheckAnswer(tc.input)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("%s: CheckAnswer(%q) = %q, %v; want %q, err %v", tc.name, tc.input, got, err, tc.want, tc.wantErr)
+		}
+	}
+	if _, err := (InfoQuestion{Type: InfoQuestionText, Pattern: `^\d+$`, Invalid: "numbers only"}).CheckAnswer("x"); err == nil || err.Error() != "numbers only" {
+		t.Errorf("pattern error = %v, want the invalid message", err)
+	}
+}
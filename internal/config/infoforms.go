@@ -0,0 +1,194 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Infoform question types.
+const (
+	InfoQuestionText   = "text"   // free text, checked against minLength/maxLength/pattern
+	InfoQuestionChoice = "choice" // one of choices, picked by number
+	InfoQuestionYesNo  = "yesno"  // Yes or No
+)
+
+// InfoForm is a sysop-defined questionnaire from infoforms.json (V2
+// infoforms). Forms marked newUser are asked during NEWUSER; all forms can
+// be answered or re-answered from RUN:INFOFORMS. Raising Version asks users
+// who answered an older version to answer again.
+type InfoForm struct {
+	ID          int            `json:"id"`          // form number shown to users
+	Name        string         `json:"name"`        // title shown in the form list
+	Description string         `json:"description"` // shown before the first question
+	Version     int            `json:"version"`     // bump after changing the questions
+	NewUser     bool           `json:"newUser"`     // ask during new user signup
+	Required    bool           `json:"required"`    // must be answered (at signup, and by CHECKINFOFORMS after a change)
+	Questions   []InfoQuestion `json:"questions"`
+}
+
+// InfoQuestion is one question of an infoform. Answers are stored under
+// the question's ID, so IDs should not change once users have answered.
+type InfoQuestion struct {
+	ID        string   `json:"id"`
+	Text      string   `json:"text"`
+	Type      string   `json:"type"`                // text, choice or yesno (default text)
+	Choices   []string `json:"choices,omitempty"`   // choice: the options
+	Required  bool     `json:"required"`            // an empty answer is refused
+	MinLength int      `json:"minLength,omitempty"` // text: shortest answer (0 = any)
+	MaxLength int      `json:"maxLength,omitempty"` // text: longest answer (0 = 60)
+	Pattern   string   `json:"pattern,omitempty"`   // text: regular expression the answer must match
+	Invalid   string   `json:"invalid,omitempty"`   // text: shown when pattern does not match
+}
+
+// defaultInfoAnswerLength is the longest text answer when maxLength is unset.
+const defaultInfoAnswerLength = 60
+
+// LoadInfoForms loads the infoforms from infoforms.json, sorted by ID. A
+// missing file is not an error; there are then no forms.
+func LoadInfoForms(configPath string) ([]InfoForm, error) {
+	filePath := filepath.Join(configPath, "infoforms.json")
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("INFO: infoforms.json not found at %s. No infoforms.", filePath)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read infoforms file %s: %w", filePath, err)
+	}
+
+	var forms []InfoForm
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return nil, fmt.Errorf("failed to parse infoforms JSON from %s: %w", filePath, err)
+	}
+
+	seen := make(map[int]bool, len(forms))
+	for i := range forms {
+		f := &forms[i]
+		if f.ID <= 0 {
+			return nil, fmt.Errorf("infoforms.json: form %q needs an id above 0", f.Name)
+		}
+		if seen[f.ID] {
+			return nil, fmt.Errorf("infoforms.json: form id %d defined more than once", f.ID)
+		}
+		seen[f.ID] = true
+		if err := f.validate(); err != nil {
+			return nil, fmt.Errorf("infoforms.json: form %d: %w", f.ID, err)
+		}
+	}
+	sort.Slice(forms, func(i, j int) bool { return forms[i].ID < forms[j].ID })
+
+	log.Printf("INFO: Loaded %d infoform(s)", len(forms))
+	return forms, nil
+}
+
+// validate checks the form's questions and fills in the default type.
+func (f *InfoForm) validate() error {
+	if len(f.Questions) == 0 {
+		return fmt.Errorf("no questions")
+	}
+	ids := make(map[string]bool, len(f.Questions))
+	for i := range f.Questions {
+		q := &f.Questions[i]
+		if q.ID == "" {
+			return fmt.Errorf("question %d has no id", i+1)
+		}
+		if ids[q.ID] {
+			return fmt.Errorf("question id %q used more than once", q.ID)
+		}
+		ids[q.ID] = true
+		if q.Type == "" {
+			q.Type = InfoQuestionText
+		}
+		switch q.Type {
+		case InfoQuestionText:
+			if q.Pattern != "" {
+				if _, err := regexp.Compile(q.Pattern); err != nil {
+					return fmt.Errorf("question %q: bad pattern: %w", q.ID, err)
+				}
+			}
+			if q.MaxLength > 0 && q.MinLength > q.MaxLength {
+				return fmt.Errorf("question %q: minLength is above maxLength", q.ID)
+			}
+		case InfoQuestionChoice:
+			if len(q.Choices) < 2 {
+				return fmt.Errorf("question %q: a choice question needs at least 2 choices", q.ID)
+			}
+		case InfoQuestionYesNo:
+		default:
+			return fmt.Errorf("question %q: unknown type %q", q.ID, q.Type)
+		}
+	}
+	return nil
+}
+
+// FindInfoForm returns the form with id.
+func FindInfoForm(forms []InfoForm, id int) (InfoForm, bool) {
+	for _, f := range forms {
+		if f.ID == id {
+			return f, true
+		}
+	}
+	return InfoForm{}, false
+}
+
+// MaxAnswerLength returns the longest answer the question accepts.
+func (q InfoQuestion) MaxAnswerLength() int {
+	if q.MaxLength > 0 {
+		return q.MaxLength
+	}
+	return defaultInfoAnswerLength
+}
+
+// CheckAnswer validates input as an answer to q and returns the answer to
+// store: trimmed text, the chosen option's text (input is its number), or
+// "Yes"/"No". An empty input is accepted as "" unless q is required.
+func (q InfoQuestion) CheckAnswer(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		if q.Required {
+			return "", fmt.Errorf("an answer is required")
+		}
+		return "", nil
+	}
+
+	switch q.Type {
+	case InfoQuestionChoice:
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(q.Choices) {
+			return "", fmt.Errorf("pick a number from 1 to %d", len(q.Choices))
+		}
+		return q.Choices[n-1], nil
+	case InfoQuestionYesNo:
+		switch strings.ToUpper(input) {
+		case "Y", "YES":
+			return "Yes", nil
+		case "N", "NO":
+			return "No", nil
+		}
+		return "", fmt.Errorf("answer yes or no")
+	}
+
+	if len(input) < q.MinLength {
+		return "", fmt.Errorf("at least %d characters, please", q.MinLength)
+	}
+	if len(input) > q.MaxAnswerLength() {
+		return "", fmt.Errorf("no more than %d characters, please", q.MaxAnswerLength())
+	}
+	if q.Pattern != "" {
+		if re, err := regexp.Compile(q.Pattern); err == nil && !re.MatchString(input) {
+			if q.Invalid != "" {
+				return "", errors.New(q.Invalid)
+			}
+			return "", fmt.Errorf("that answer is not in the expected format")
+		}
+	}
+	return input, nil
+}
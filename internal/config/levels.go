@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SecurityLevel is the profile of one access level, from levels.json.
+// Moving a user to the level (validation, NUV, the user editor) copies its
+// time limits and default flags onto the user and grants its signup points.
+// CallsPerDay, DownloadRatio and MaxPrivateMail are read from the profile of
+// the user's current level whenever they are checked.
+type SecurityLevel struct {
+	Level          int    `json:"level"`
+	Name           string `json:"name"`
+	TimeLimit      int    `json:"timeLimit"`      // minutes per call (0 = no limit)
+	DailyTime      int    `json:"dailyTime"`      // minutes per day across all calls (0 = no limit)
+	CallsPerDay    int    `json:"callsPerDay"`    // logons per day (0 = no limit)
+	DownloadRatio  int    `json:"downloadRatio"`  // downloads allowed per upload (0 = use downloadRatio from config.json)
+	SignupPoints   int    `json:"signupPoints"`   // file points granted on reaching the level
+	MaxPrivateMail int    `json:"maxPrivateMail"` // private mails that can be sent per day (0 = no limit)
+	Flags          string `json:"flags"`          // flags added on reaching the level
+}
+
+// LoadSecurityLevels loads the security level profiles from levels.json,
+// sorted by level. A missing file is not an error; levels then have no
+// profiles and users keep whatever limits they already have.
+func LoadSecurityLevels(configPath string) ([]SecurityLevel, error) {
+	filePath := filepath.Join(configPath, "levels.json")
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("INFO: levels.json not found at %s. No security level profiles.", filePath)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read levels file %s: %w", filePath, err)
+	}
+
+	var levels []SecurityLevel
+	if err := json.Unmarshal(data, &levels); err != nil {
+		return nil, fmt.Errorf("failed to parse levels JSON from %s: %w", filePath, err)
+	}
+
+	seen := make(map[int]bool, len(levels))
+	for _, l := range levels {
+		if l.Level < 0 || l.Level > 255 {
+			return nil, fmt.Errorf("levels.json: level %d out of range 0-255", l.Level)
+		}
+		if seen[l.Level] {
+			return nil, fmt.Errorf("levels.json: level %d defined more than once", l.Level)
+		}
+		seen[l.Level] = true
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+
+	log.Printf("INFO: Loaded %d security level profile(s)", len(levels))
+	return levels, nil
+}
+
+// SaveSecurityLevels writes the security level profiles to levels.json.
+func SaveSecurityLevels(configPath string, levels []SecurityLevel) error {
+	if levels == nil {
+		levels = []SecurityLevel{}
+	}
+	filePath := filepath.Join(configPath, "levels.json")
+	data, err := json.MarshalIndent(levels, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal levels: %w", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write levels file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// FindSecurityLevel returns the profile defined for exactly level.
+func FindSecurityLevel(levels []SecurityLevel, level int) (SecurityLevel, bool) {
+	for _, l := range levels {
+		if l.Level == level {
+			return l, true
+		}
+	}
+	return SecurityLevel{}, false
+}
@@ -32,6 +32,8 @@ func (m *Model) buildRecordFields() []fieldDef {
 		return m.fieldsArchiver()
 	case "login":
 		return m.fieldsLogin()
+	case "level":
+		return m.fieldsLevel()
 	}
 	return nil
 }
@@ -240,6 +242,80 @@ func (m *Model) fieldsFileArea() []fieldDef {
 				return m.buildConferenceLookupItems()
 			},
 		},
+		{
+			Label: "DL Cost", Help: "Flat file points charged per download", Type: ftInteger, Col: 3, Row: 9, Width: 5, Min: 0, Max: 99999,
+			Get: func() string { return strconv.Itoa(a.DownloadCost) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				a.DownloadCost = n
+				return nil
+			},
+		},
+		{
+			Label: "DL KB/Point", Help: "Extra point charged per N KB downloaded (0=flat cost only)", Type: ftInteger, Col: 3, Row: 10, Width: 5, Min: 0, Max: 99999,
+			Get: func() string { return strconv.Itoa(a.DownloadKBPerPoint) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				a.DownloadKBPerPoint = n
+				return nil
+			},
+		},
+		{
+			Label: "UL Multiplier", Help: "Scales upload credit earned in this area (0 or blank=1.0)", Type: ftString, Col: 3, Row: 11, Width: 6,
+			Get: func() string {
+				if a.UploadMultiplier == 0 {
+					return ""
+				}
+				return strconv.FormatFloat(a.UploadMultiplier, 'f', -1, 64)
+			},
+			Set: func(val string) error {
+				val = strings.TrimSpace(val)
+				if val == "" {
+					a.UploadMultiplier = 0
+					return nil
+				}
+				f, err := strconv.ParseFloat(val, 64)
+				if err != nil {
+					return err
+				}
+				if f < 0 {
+					return fmt.Errorf("multiplier must not be negative")
+				}
+				a.UploadMultiplier = f
+				return nil
+			},
+		},
+		{
+			Label: "Validate ULs", Help: "Hold new uploads until a sysop validates them", Type: ftYesNo, Col: 3, Row: 12, Width: 1,
+			Get: func() string { return boolToYN(a.RequireValidation) },
+			Set: func(val string) error { a.RequireValidation = ynToBool(val); return nil },
+		},
+		{
+			Label: "Offline", Help: "Media not available: files stay listed, downloads become offline requests", Type: ftYesNo, Col: 3, Row: 13, Width: 1,
+			Get: func() string { return boolToYN(a.Offline) },
+			Set: func(val string) error { a.Offline = ynToBool(val); return nil },
+		},
+		{
+			Label: "Removable", Help: "CD-ROM or removable media: missing files become offline requests", Type: ftYesNo, Col: 3, Row: 14, Width: 1,
+			Get: func() string { return boolToYN(a.Removable) },
+			Set: func(val string) error { a.Removable = ynToBool(val); return nil },
+		},
+		{
+			Label: "Dir Sync", Help: "Watch the directory: add new files, update changed, mark removed offline", Type: ftYesNo, Col: 3, Row: 15, Width: 1,
+			Get: func() string { return boolToYN(a.Sync) },
+			Set: func(val string) error { a.Sync = ynToBool(val); return nil },
+		},
+		{
+			Label: "Sync Ignore", Help: "Comma-separated filename patterns sync never adds (e.g. *.part, *.!ut)", Type: ftString, Col: 3, Row: 16, Width: 45,
+			Get: func() string { return sliceToCSV(a.SyncIgnore) },
+			Set: func(val string) error { a.SyncIgnore = csvToSlice(val); return nil },
+		},
 	}
 }
 
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/stlalpha/vision3/internal/config"
 )
 
 // sanitizeEventID converts a display name into a valid event ID.
@@ -152,5 +154,29 @@ func (m *Model) fieldsEvent() []fieldDef {
 				return nil
 			},
 		},
+		{
+			Label: "Internal Job", Help: "Built-in job run inside the BBS instead of Command (press Enter to select)", Type: ftLookup, Col: 3, Row: 10, Width: 20,
+			Get: func() string {
+				if e.Internal == "" {
+					return "(none)"
+				}
+				return e.Internal
+			},
+			Set: func(val string) error {
+				if val == "(none)" {
+					e.Internal = ""
+				} else {
+					e.Internal = val
+				}
+				return nil
+			},
+			LookupItems: func() []LookupItem {
+				return []LookupItem{
+					{Value: "(none)", Display: "(none) - Run Command"},
+					{Value: config.InternalExpireAccounts, Display: config.InternalExpireAccounts + " - Expire accounts and mail notices"},
+					{Value: config.InternalPurgePartials, Display: config.InternalPurgePartials + " - Delete stale partial uploads"},
+				}
+			},
+		},
 	}
 }
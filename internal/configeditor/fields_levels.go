@@ -0,0 +1,68 @@
+package configeditor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// levelIntField returns an integer field bound to one setting of a security
+// level profile.
+func levelIntField(label, help string, row, maxVal int, v *int) fieldDef {
+	return fieldDef{
+		Label: label, Help: help, Type: ftInteger, Col: 3, Row: row, Width: 6, Min: 0, Max: maxVal,
+		Get: func() string { return strconv.Itoa(*v) },
+		Set: func(val string) error {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return err
+			}
+			*v = n
+			return nil
+		},
+	}
+}
+
+// fieldsLevel returns fields for editing a security level profile.
+func (m *Model) fieldsLevel() []fieldDef {
+	idx := m.recordEditIdx
+	if idx < 0 || idx >= len(m.configs.Levels) {
+		return nil
+	}
+	l := &m.configs.Levels[idx]
+	return []fieldDef{
+		{
+			Label: "Level", Help: "Access level this profile defines (0-255)", Type: ftInteger, Col: 3, Row: 1, Width: 3, Min: 0, Max: 255,
+			Get: func() string { return strconv.Itoa(l.Level) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				for i, other := range m.configs.Levels {
+					if i != idx && other.Level == n {
+						return fmt.Errorf("level %d already has a profile (%s)", n, other.Name)
+					}
+				}
+				l.Level = n
+				return nil
+			},
+		},
+		{
+			Label: "Name", Help: "Display name for this level (e.g. Validated User)", Type: ftString, Col: 3, Row: 2, Width: 30,
+			Get: func() string { return l.Name },
+			Set: func(val string) error { l.Name = val; return nil },
+		},
+		levelIntField("Time/Call", "Minutes allowed per call (0=no limit)", 3, 1440, &l.TimeLimit),
+		levelIntField("Time/Day", "Minutes allowed per day across all calls (0=no limit)", 4, 1440, &l.DailyTime),
+		levelIntField("Calls/Day", "Logons allowed per day (0=no limit)", 5, 999, &l.CallsPerDay),
+		levelIntField("DL Ratio", "Downloads allowed per upload (0=use File Points & Ratios setting)", 6, 999, &l.DownloadRatio),
+		levelIntField("Signup Points", "File points granted when a user is moved to this level", 7, 99999, &l.SignupPoints),
+		levelIntField("Mail/Day", "Private mails that can be sent per day (0=no limit)", 8, 999, &l.MaxPrivateMail),
+		{
+			Label: "Flags", Help: "Flags added to users moved to this level (e.g. AB)", Type: ftString, Col: 3, Row: 9, Width: 26,
+			Get: func() string { return l.Flags },
+			Set: func(val string) error { l.Flags = strings.ToUpper(strings.TrimSpace(val)); return nil },
+		},
+	}
+}
@@ -127,6 +127,11 @@ func (m *Model) fieldsProtocol() []fieldDef {
 			Get: func() string { return p.ConnectionType },
 			Set: func(val string) error { p.ConnectionType = val; return nil },
 		},
+		{
+			Label: "Built-in", Help: "Engine: zmodem, xmodem, xmodem-1k, ymodem, ymodem-g; blank=external", Type: ftString, Col: 3, Row: 12, Width: 10,
+			Get: func() string { return p.Builtin },
+			Set: func(val string) error { p.Builtin = strings.ToLower(strings.TrimSpace(val)); return nil },
+		},
 	}
 }
 
@@ -92,6 +92,10 @@ func (m *Model) buildSysFields(screen int) []fieldDef {
 		return sysFieldsIPLists(cfg)
 	case 6:
 		return sysFieldsNUV(cfg)
+	case 7:
+		return sysFieldsEconomy(cfg)
+	case 8:
+		return sysFieldsPasswords(cfg)
 	}
 	return nil
 }
@@ -169,6 +173,11 @@ func sysFieldsNetwork(cfg *config.ServerConfig) []fieldDef {
 			Get: func() string { return boolToYN(cfg.LegacySSHAlgorithms) },
 			Set: func(val string) error { cfg.LegacySSHAlgorithms = ynToBool(val); return nil },
 		},
+		{
+			Label: "SFTP/SCP", Help: "Allow SFTP and scp access to file areas over SSH", Type: ftYesNo, Col: 3, Row: 5, Width: 1,
+			Get: func() string { return boolToYN(cfg.SFTPEnabled) },
+			Set: func(val string) error { cfg.SFTPEnabled = ynToBool(val); return nil },
+		},
 		{
 			Label: "Telnet Enabled", Help: "Enable Telnet server", Type: ftYesNo, Col: 3, Row: 6, Width: 1,
 			Get: func() string { return boolToYN(cfg.TelnetEnabled) },
@@ -269,6 +278,18 @@ func sysFieldsLimits(cfg *config.ServerConfig) []fieldDef {
 				return nil
 			},
 		},
+		{
+			Label: "UL Time Credit", Help: "Percent of upload time given back to the caller (0=none)", Type: ftInteger, Col: 3, Row: 7, Width: 5, Min: 0, Max: 1000,
+			Get: func() string { return strconv.Itoa(cfg.UploadTimeCredit) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.UploadTimeCredit = n
+				return nil
+			},
+		},
 	}
 }
 
@@ -359,6 +380,18 @@ func sysFieldsLevels(cfg *config.ServerConfig) []fieldDef {
 				return nil
 			},
 		},
+		{
+			Label: "2FA Level", Help: "Users at or above this level must use two-factor login (0=optional)", Type: ftInteger, Col: 3, Row: 8, Width: 3, Min: 0, Max: 255,
+			Get: func() string { return strconv.Itoa(cfg.TOTPRequiredLevel) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.TOTPRequiredLevel = n
+				return nil
+			},
+		},
 	}
 }
 
@@ -393,10 +426,34 @@ func sysFieldsDefaults(cfg *config.ServerConfig) []fieldDef {
 				return nil
 			},
 		},
+		{
+			Label: "Partial Days", Help: "Days to keep interrupted uploads for resuming (-1=forever)", Type: ftInteger, Col: 3, Row: 4, Width: 5, Min: -1, Max: 9999,
+			Get: func() string { return strconv.Itoa(cfg.PartialRetentionDays) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.PartialRetentionDays = n
+				return nil
+			},
+		},
+		{
+			Label: "Expiry Warn Days", Help: "Days before account expiry to warn users at login (0=no warning)", Type: ftInteger, Col: 3, Row: 5, Width: 5, Min: 0, Max: 365,
+			Get: func() string { return strconv.Itoa(cfg.ExpiryWarningDays) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.ExpiryWarningDays = n
+				return nil
+			},
+		},
 	}
 }
 
-// sysFieldsIPLists returns fields for IP Blocklist/Allowlist sub-screen.
+// sysFieldsIPLists returns fields for the IP filter and auto-ban sub-screen.
 func sysFieldsIPLists(cfg *config.ServerConfig) []fieldDef {
 	return []fieldDef{
 		{
@@ -409,6 +466,120 @@ func sysFieldsIPLists(cfg *config.ServerConfig) []fieldDef {
 			Get: func() string { return cfg.IPAllowlistPath },
 			Set: func(val string) error { cfg.IPAllowlistPath = val; return nil },
 		},
+		{
+			Label: "Auto-Ban Mins", Help: "Length of automatic IP bans in minutes (0=permanent)", Type: ftInteger, Col: 3, Row: 3, Width: 5, Min: 0, Max: 99999,
+			Get: func() string { return strconv.Itoa(cfg.AutoBanMinutes) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.AutoBanMinutes = n
+				return nil
+			},
+		},
+		{
+			Label: "Flood Conns", Help: "Connections from one IP that trigger a ban (0=off)", Type: ftInteger, Col: 3, Row: 4, Width: 5, Min: 0, Max: 999,
+			Get: func() string { return strconv.Itoa(cfg.FloodConnections) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.FloodConnections = n
+				return nil
+			},
+		},
+		{
+			Label: "Flood Seconds", Help: "Window for counting flood connections", Type: ftInteger, Col: 3, Row: 5, Width: 5, Min: 1, Max: 3600,
+			Get: func() string { return strconv.Itoa(cfg.FloodSeconds) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.FloodSeconds = n
+				return nil
+			},
+		},
+		{
+			Label: "Telnet Garbage", Help: "Junk bytes in telnet negotiation that trigger a ban (0=off)", Type: ftInteger, Col: 3, Row: 6, Width: 5, Min: 0, Max: 9999,
+			Get: func() string { return strconv.Itoa(cfg.MaxTelnetGarbage) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.MaxTelnetGarbage = n
+				return nil
+			},
+		},
+	}
+}
+
+// sysFieldsPasswords returns fields for the password policy sub-screen.
+func sysFieldsPasswords(cfg *config.ServerConfig) []fieldDef {
+	return []fieldDef{
+		{
+			Label: "Min Length", Help: "Shortest password accepted at signup and change", Type: ftInteger, Col: 3, Row: 1, Width: 3, Min: 3, Max: 72,
+			Get: func() string { return strconv.Itoa(cfg.PasswordMinLength) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.PasswordMinLength = n
+				return nil
+			},
+		},
+		{
+			Label: "History", Help: "Recent passwords a user may not reuse (0=no check)", Type: ftInteger, Col: 3, Row: 2, Width: 3, Min: 0, Max: 24,
+			Get: func() string { return strconv.Itoa(cfg.PasswordHistory) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.PasswordHistory = n
+				return nil
+			},
+		},
+		{
+			Label: "Max Age Days", Help: "Days before a password must be changed (0=never)", Type: ftInteger, Col: 3, Row: 3, Width: 5, Min: 0, Max: 9999,
+			Get: func() string { return strconv.Itoa(cfg.PasswordMaxAgeDays) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.PasswordMaxAgeDays = n
+				return nil
+			},
+		},
+		{
+			Label: "Hash Cost", Help: "bcrypt cost; older hashes are upgraded at login (10=default)", Type: ftInteger, Col: 3, Row: 4, Width: 3, Min: 4, Max: 31,
+			Get: func() string { return strconv.Itoa(cfg.PasswordHashCost) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.PasswordHashCost = n
+				return nil
+			},
+		},
+		{
+			Label: "Reset Code Hrs", Help: "Hours a sysop-issued password reset code stays valid", Type: ftInteger, Col: 3, Row: 5, Width: 5, Min: 1, Max: 720,
+			Get: func() string { return strconv.Itoa(cfg.PasswordResetHours) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.PasswordResetHours = n
+				return nil
+			},
+		},
 	}
 }
 
@@ -485,3 +656,62 @@ func sysFieldsNUV(cfg *config.ServerConfig) []fieldDef {
 		},
 	}
 }
+
+// sysFieldsEconomy returns fields for the File Points & Ratios sub-screen.
+func sysFieldsEconomy(cfg *config.ServerConfig) []fieldDef {
+	return []fieldDef{
+		{
+			Label: "File Points", Help: "Charge area download costs and grant upload credit", Type: ftYesNo, Col: 3, Row: 1, Width: 1,
+			Get: func() string { return boolToYN(cfg.FilePointsEnabled) },
+			Set: func(val string) error { cfg.FilePointsEnabled = ynToBool(val); return nil },
+		},
+		{
+			Label: "Upload KB/Point", Help: "Uploads earn one point per N KB (0=no upload credit)", Type: ftInteger, Col: 3, Row: 2, Width: 5, Min: 0, Max: 99999,
+			Get: func() string { return strconv.Itoa(cfg.UploadKBPerPoint) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.UploadKBPerPoint = n
+				return nil
+			},
+		},
+		{
+			Label: "Download Ratio", Help: "Downloads allowed per upload (0=no ratio)", Type: ftInteger, Col: 3, Row: 3, Width: 5, Min: 0, Max: 9999,
+			Get: func() string { return strconv.Itoa(cfg.DownloadRatio) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.DownloadRatio = n
+				return nil
+			},
+		},
+		{
+			Label: "Free Downloads", Help: "Downloads allowed before the ratio applies", Type: ftInteger, Col: 3, Row: 4, Width: 5, Min: 0, Max: 9999,
+			Get: func() string { return strconv.Itoa(cfg.RatioFreeDownloads) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.RatioFreeDownloads = n
+				return nil
+			},
+		},
+		{
+			Label: "Exempt Level", Help: "Users at or above this level skip points and ratio (0=CoSysOp level)", Type: ftInteger, Col: 3, Row: 5, Width: 3, Min: 0, Max: 255,
+			Get: func() string { return strconv.Itoa(cfg.RatioExemptLevel) },
+			Set: func(val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				cfg.RatioExemptLevel = n
+				return nil
+			},
+		},
+	}
+}
@@ -28,6 +28,7 @@ type allConfigs struct {
 	Protocols   []transfer.ProtocolConfig
 	Archivers   archiver.Config
 	LoginSeq    []config.LoginItem
+	Levels      []config.SecurityLevel
 }
 
 // loadAllConfigs loads all configuration files from the given directory.
@@ -101,6 +102,12 @@ func loadAllConfigs(configPath string) (allConfigs, error) {
 		ac.LoginSeq = nil
 	}
 
+	// Security levels
+	ac.Levels, err = config.LoadSecurityLevels(configPath)
+	if err != nil {
+		return ac, fmt.Errorf("loading levels: %w", err)
+	}
+
 	return ac, nil
 }
 
@@ -226,6 +233,12 @@ func saveLoginSeq(configPath string, items []config.LoginItem) error {
 	return saveJSONSlice(configPath, "login.json", items)
 }
 
+// saveLevels writes security level profiles back to disk, sorted by level.
+func saveLevels(configPath string, levels []config.SecurityLevel) error {
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+	return config.SaveSecurityLevels(configPath, levels)
+}
+
 // sortMsgAreasByConference sorts message areas by conference display position,
 // then by area position within each conference. Areas whose conference is not
 // found sort to the end.
@@ -122,6 +122,7 @@ func New(configPath string) (Model, error) {
 		{"9", "Transfer Protocols"},
 		{"A", "Archivers"},
 		{"B", "Login Sequence"},
+		{"C", "Security Levels"},
 		{"Q", "Quit Program"},
 	}
 
@@ -131,8 +132,10 @@ func New(configPath string) (Model, error) {
 		{"Connection Limits"},
 		{"Access Levels"},
 		{"Default Settings"},
-		{"IP Blocklist/Allowlist"},
+		{"IP Filters & Auto-Bans"},
 		{"New User Voting (NUV)"},
+		{"File Points & Ratios"},
+		{"Passwords"},
 	}
 
 	return Model{
@@ -231,7 +234,7 @@ func (m Model) updateTopMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) selectTopMenuItem() (Model, tea.Cmd) {
 	recordTypes := []string{
 		"", "msgarea", "filearea", "conference", "door",
-		"event", "ftn", "ftnlink", "protocol", "archiver", "login",
+		"event", "ftn", "ftnlink", "protocol", "archiver", "login", "level",
 	}
 
 	switch m.topCursor {
@@ -239,7 +242,7 @@ func (m Model) selectTopMenuItem() (Model, tea.Cmd) {
 		m.mode = modeSysConfigMenu
 		m.sysMenuCursor = 0
 		return m, nil
-	case 11: // Quit
+	case 12: // Quit
 		return m.tryExit()
 	default:
 		// Items 1-9 are record list editors
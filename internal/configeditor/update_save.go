@@ -58,6 +58,10 @@ func (m *Model) saveAll() {
 		m.message = fmt.Sprintf("SAVE ERROR: %v", err)
 		return
 	}
+	if err := saveLevels(m.configPath, m.configs.Levels); err != nil {
+		m.message = fmt.Sprintf("SAVE ERROR: %v", err)
+		return
+	}
 
 	m.dirty = false
 	m.message = "All configurations saved successfully"
@@ -87,6 +91,8 @@ func (m Model) recordCount() int {
 		return len(m.configs.Archivers.Archivers)
 	case "login":
 		return len(m.configs.LoginSeq)
+	case "level":
+		return len(m.configs.Levels)
 	}
 	return 0
 }
@@ -211,6 +217,22 @@ func (m *Model) insertRecord() {
 		m.configs.LoginSeq = append(m.configs.LoginSeq, config.LoginItem{
 			Command: "DISPLAYFILE",
 		})
+	case "level":
+		// Use the lowest level that has no profile yet
+		used := make(map[int]bool, len(m.configs.Levels))
+		for _, l := range m.configs.Levels {
+			used[l.Level] = true
+		}
+		for n := 0; n <= 255; n++ {
+			if !used[n] {
+				m.configs.Levels = append(m.configs.Levels, config.SecurityLevel{
+					Level: n,
+					Name:  fmt.Sprintf("Level %d", n),
+				})
+				return
+			}
+		}
+		m.message = "Every level already has a profile"
 	}
 }
 
@@ -263,6 +285,10 @@ func (m *Model) deleteRecord() {
 		if idx >= 0 && idx < len(m.configs.LoginSeq) {
 			m.configs.LoginSeq = append(m.configs.LoginSeq[:idx], m.configs.LoginSeq[idx+1:]...)
 		}
+	case "level":
+		if idx >= 0 && idx < len(m.configs.Levels) {
+			m.configs.Levels = append(m.configs.Levels[:idx], m.configs.Levels[idx+1:]...)
+		}
 	}
 
 	total := m.recordCount()
@@ -166,6 +166,8 @@ func (m Model) recordTypeTitle() string {
 		return "Archivers"
 	case "login":
 		return "Login Sequence"
+	case "level":
+		return "Security Levels"
 	}
 	return "Records"
 }
@@ -193,6 +195,8 @@ func (m Model) recordColumnHeader(boxW int) string {
 		return "  ID     Name                  Ext      Enabled"
 	case "login":
 		return "  #  Command          Data"
+	case "level":
+		return "  Lvl  Name                       Call  Day  Calls  Ratio  Flags"
 	}
 	return ""
 }
@@ -253,7 +257,11 @@ func (m Model) renderRecordRow(idx, boxW int) string {
 	case "protocol":
 		if idx < len(m.configs.Protocols) {
 			p := m.configs.Protocols[idx]
-			content = fmt.Sprintf("  %-6s  %-30s %s", padRight(p.Key, 6), padRight(p.Name, 30), p.SendCmd)
+			engine := p.SendCmd
+			if p.Builtin != "" {
+				engine = "(built-in " + p.Builtin + ")"
+			}
+			content = fmt.Sprintf("  %-6s  %-30s %s", padRight(p.Key, 6), padRight(p.Name, 30), engine)
 		}
 	case "archiver":
 		if idx < len(m.configs.Archivers.Archivers) {
@@ -265,6 +273,11 @@ func (m Model) renderRecordRow(idx, boxW int) string {
 			l := m.configs.LoginSeq[idx]
 			content = fmt.Sprintf(" %3d  %-16s %s", idx+1, padRight(l.Command, 16), l.Data)
 		}
+	case "level":
+		if idx < len(m.configs.Levels) {
+			l := m.configs.Levels[idx]
+			content = fmt.Sprintf("  %3d  %-26s %4d %4d  %5d  %5d  %s", l.Level, padRight(l.Name, 26), l.TimeLimit, l.DailyTime, l.CallsPerDay, l.DownloadRatio, l.Flags)
+		}
 	}
 
 	if content == "" {
@@ -241,6 +241,11 @@ func (m Model) recordEditHeader() string {
 		if m.recordEditIdx < len(m.configs.LoginSeq) {
 			return fmt.Sprintf("Step %d", m.recordEditIdx+1)
 		}
+	case "level":
+		if m.recordEditIdx < len(m.configs.Levels) {
+			l := m.configs.Levels[m.recordEditIdx]
+			return fmt.Sprintf("%s  (Level %d)", l.Name, l.Level)
+		}
 	}
 	return "Edit Record"
 }
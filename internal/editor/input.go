@@ -2,6 +2,7 @@ package editor
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"sync"
 	"sync/atomic"
@@ -14,6 +15,12 @@ import (
 // disconnects without false-positive matches on sequence parsing.
 var ErrIdleTimeout = errors.New("idle timeout")
 
+// ErrTimeLimit is returned when the session deadline set by
+// SetSessionDeadline passes while waiting for input. It wraps ErrIdleTimeout
+// so every input loop that logs off idle callers also logs off callers whose
+// time is up.
+var ErrTimeLimit = fmt.Errorf("time limit reached: %w", ErrIdleTimeout)
+
 // Special key codes for editor commands (using WordStar-style control characters)
 const (
 	// WordStar navigation commands
@@ -80,6 +87,11 @@ type InputHandler struct {
 	// SetSessionIdleTimeout; read on every key-wait.
 	idleNs int64 // atomic
 
+	// deadlineNs is the session deadline as Unix nanoseconds (0 = none).
+	// A read still waiting when it passes returns ErrTimeLimit. Set via
+	// SetSessionDeadline.
+	deadlineNs int64 // atomic
+
 	// escTimeoutNs overrides the inter-byte ESC disambiguation window
 	// (default 500 ms). 0 means use the default. Set via SetEscTimeout.
 	escTimeoutNs int64 // atomic
@@ -96,6 +108,27 @@ func (ih *InputHandler) SetSessionIdleTimeout(d time.Duration) {
 	atomic.StoreInt64(&ih.idleNs, d.Nanoseconds())
 }
 
+// SetSessionDeadline sets the time at which reads stop waiting for input
+// and return ErrTimeLimit, such as when a caller's time runs out. Pass the
+// zero time to clear it. Thread-safe.
+func (ih *InputHandler) SetSessionDeadline(t time.Time) {
+	var ns int64
+	if !t.IsZero() {
+		ns = t.UnixNano()
+	}
+	atomic.StoreInt64(&ih.deadlineNs, ns)
+}
+
+// untilDeadline returns the time left before the session deadline and
+// whether one is set.
+func (ih *InputHandler) untilDeadline() (time.Duration, bool) {
+	ns := atomic.LoadInt64(&ih.deadlineNs)
+	if ns == 0 {
+		return 0, false
+	}
+	return time.Until(time.Unix(0, ns)), true
+}
+
 // SetEscTimeout overrides the ESC disambiguation window used in ReadKey.
 // Pass 0 to restore the default (500 ms). Thread-safe.
 func (ih *InputHandler) SetEscTimeout(d time.Duration) {
@@ -203,18 +236,29 @@ func (ih *InputHandler) Read(p []byte) (int, error) {
 
 // readByte reads a single byte, blocking until one is available.
 // If a session idle timeout is set (via SetSessionIdleTimeout) and no byte
-// arrives within that window, ErrIdleTimeout is returned.
+// arrives within that window, ErrIdleTimeout is returned. If the session
+// deadline (SetSessionDeadline) passes first, ErrTimeLimit is returned.
 func (ih *InputHandler) readByte() (byte, error) {
 	if len(ih.unreadBuf) > 0 {
 		b := ih.unreadBuf[0]
 		ih.unreadBuf = ih.unreadBuf[1:]
 		return b, nil
 	}
-	if t := ih.sessionIdleTimeout(); t > 0 {
+	t := ih.sessionIdleTimeout()
+	timeoutErr := ErrIdleTimeout
+	if left, ok := ih.untilDeadline(); ok {
+		if left <= 0 {
+			return 0, ErrTimeLimit
+		}
+		if t == 0 || left < t {
+			t, timeoutErr = left, ErrTimeLimit
+		}
+	}
+	if t > 0 {
 		b, err := ih.readByteWithTimeout(t)
 		if err != nil {
 			if isTimeoutError(err) {
-				return 0, ErrIdleTimeout
+				return 0, timeoutErr
 			}
 			return 0, err
 		}
@@ -329,11 +373,21 @@ func (ih *InputHandler) ReadKey() (int, error) {
 // (0, ErrIdleTimeout). Inter-byte timeouts for escape-sequence parsing are
 // unaffected. This is the extensible primitive for idle-disconnect logic.
 func (ih *InputHandler) ReadKeyWithTimeout(idleTimeout time.Duration) (int, error) {
-	// Wait for the first byte with the caller's deadline.
+	// Wait for the first byte with the caller's deadline, or the session
+	// deadline if that comes first.
+	timeoutErr := ErrIdleTimeout
+	if left, ok := ih.untilDeadline(); ok {
+		if left <= 0 {
+			return 0, ErrTimeLimit
+		}
+		if left < idleTimeout {
+			idleTimeout, timeoutErr = left, ErrTimeLimit
+		}
+	}
 	first, err := ih.readByteWithTimeout(idleTimeout)
 	if err != nil {
 		if isTimeoutError(err) {
-			return 0, ErrIdleTimeout
+			return 0, timeoutErr
 		}
 		return 0, err
 	}
@@ -0,0 +1,46 @@
+package editor
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSessionDeadline(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	ih := NewInputHandler(r)
+
+	ih.SetSessionDeadline(time.Now().Add(50 * time.Millisecond))
+	start := time.Now()
+	_, err := ih.ReadKey()
+	if !errors.Is(err, ErrTimeLimit) {
+		t.Fatalf("ReadKey err = %v, want ErrTimeLimit", err)
+	}
+	if !errors.Is(err, ErrIdleTimeout) {
+		t.Error("ErrTimeLimit does not match ErrIdleTimeout")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("deadline did not cut the read short")
+	}
+
+	// A shorter idle timeout still reports an idle timeout
+	ih.SetSessionDeadline(time.Now().Add(time.Hour))
+	ih.SetSessionIdleTimeout(20 * time.Millisecond)
+	if _, err := ih.ReadKey(); !errors.Is(err, ErrIdleTimeout) || errors.Is(err, ErrTimeLimit) {
+		t.Errorf("ReadKey err = %v, want ErrIdleTimeout", err)
+	}
+
+	// Input before the deadline is read normally
+	ih.SetSessionIdleTimeout(0)
+	go w.Write([]byte("x"))
+	if key, err := ih.ReadKey(); err != nil || key != 'x' {
+		t.Errorf("ReadKey = %q, %v", key, err)
+	}
+
+	ih.SetSessionDeadline(time.Now().Add(-time.Second))
+	if _, err := ih.ReadKeyWithTimeout(time.Hour); !errors.Is(err, ErrTimeLimit) {
+		t.Errorf("ReadKeyWithTimeout err = %v, want ErrTimeLimit", err)
+	}
+}
@@ -0,0 +1,321 @@
+package file
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+)
+
+// ListFormat names a plain-text file description list.
+type ListFormat string
+
+const (
+	// ListFilesBBS is a FILES.BBS list: the filename, optionally the size
+	// and date, then the description. Indented lines continue the
+	// description of the file above them.
+	ListFilesBBS ListFormat = "filesbbs"
+	// ListDescriptIon is a 4DOS/4NT DESCRIPT.ION list: one line per file,
+	// names containing spaces in double quotes.
+	ListDescriptIon ListFormat = "descript"
+)
+
+// ListEntry is one file read from a description list.
+type ListEntry struct {
+	Filename    string
+	Description string    // Lines joined with "\n"
+	Size        int64     // 0 if the list has no size column
+	Date        time.Time // Zero if the list has no date column
+}
+
+// AreaFiles is one area's section of an ALLFILES listing.
+type AreaFiles struct {
+	Area  FileArea
+	Files []FileRecord
+}
+
+// listDateLayouts are the date columns accepted in FILES.BBS listings.
+var listDateLayouts = []string{"01-02-06", "01/02/06", "01-02-2006", "01/02/2006", "2006-01-02"}
+
+// downloadCounterRegex matches the "[12]" download counter some BBSes put
+// in front of FILES.BBS descriptions.
+var downloadCounterRegex = regexp.MustCompile(`^\[\s*\d+\s*\]\s*`)
+
+// DetectListFormat guesses a list's format from its filename: DESCRIPT.ION
+// is a 4DOS list, anything else is treated as FILES.BBS.
+func DetectListFormat(name string) ListFormat {
+	if strings.EqualFold(filepath.Base(name), "DESCRIPT.ION") {
+		return ListDescriptIon
+	}
+	return ListFilesBBS
+}
+
+// ParseFileList reads a description list. Lines that are not valid UTF-8
+// are decoded as CP437.
+func ParseFileList(r io.Reader, format ListFormat) ([]ListEntry, error) {
+	var entries []ListEntry
+	var current *ListEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		if !utf8.Valid(raw) {
+			raw = ansi.ConvertCP437ToUTF8(raw)
+		}
+		line := strings.TrimRight(strings.ReplaceAll(string(raw), "\x1a", ""), " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if format == ListDescriptIon {
+			if e, ok := parseDescriptIonLine(line); ok {
+				entries = append(entries, e)
+			}
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			if current == nil {
+				continue // Indented text under a header, not a file
+			}
+			text := strings.TrimSpace(line)
+			if text[0] == '|' || text[0] == '+' {
+				text = strings.TrimSpace(text[1:])
+			}
+			if current.Description == "" {
+				current.Description = text
+			} else {
+				current.Description += "\n" + text
+			}
+			continue
+		}
+
+		if !isListFilenameStart(line) {
+			current = nil // Comment, header or separator line
+			continue
+		}
+		entries = append(entries, parseFilesBBSLine(line))
+		current = &entries[len(entries)-1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// isListFilenameStart reports whether a FILES.BBS line starts with a
+// character that can begin a DOS filename. Lines starting with anything
+// else ("-", "=", "*", box drawing) are headers or comments.
+func isListFilenameStart(line string) bool {
+	r, _ := utf8.DecodeRuneInString(line)
+	if r < 0x80 && (r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+		return true
+	}
+	return strings.ContainsRune("_!$%&'{}~^@\"", r)
+}
+
+// parseFilesBBSLine splits the first line of a FILES.BBS entry into the
+// filename, optional size and date columns, and description.
+func parseFilesBBSLine(line string) ListEntry {
+	name, rest, ok := splitListName(line)
+	if !ok {
+		name, rest = splitListField(line)
+	}
+	e := ListEntry{Filename: filepath.Base(name)}
+
+	sizeField, afterSize := splitListField(rest)
+	dateField, afterDate := splitListField(afterSize)
+	if size, ok := parseListSize(sizeField); ok {
+		if date, ok := parseListDate(dateField); ok {
+			e.Size, e.Date, rest = size, date, afterDate
+		}
+	} else if date, ok := parseListDate(sizeField); ok {
+		e.Date, rest = date, afterSize
+	}
+
+	e.Description = downloadCounterRegex.ReplaceAllString(strings.TrimSpace(rest), "")
+	return e
+}
+
+// parseDescriptIonLine parses one DESCRIPT.ION line. Anything from a ^D on
+// is 4DOS extended data and is dropped.
+func parseDescriptIonLine(line string) (ListEntry, bool) {
+	name, rest, ok := splitListName(line)
+	if !ok {
+		return ListEntry{}, false
+	}
+	if i := strings.IndexByte(rest, '\x04'); i >= 0 {
+		rest = rest[:i]
+	}
+	if name == "" {
+		return ListEntry{}, false
+	}
+	return ListEntry{Filename: filepath.Base(name), Description: strings.TrimSpace(rest)}, true
+}
+
+// splitListName splits the filename off the front of a list line. Names
+// containing spaces are in double quotes. ok is false for an unterminated
+// quote.
+func splitListName(line string) (name, rest string, ok bool) {
+	if line[0] != '"' {
+		name, rest = splitListField(line)
+		return name, rest, true
+	}
+	end := strings.IndexByte(line[1:], '"')
+	if end < 0 {
+		return "", "", false
+	}
+	return line[1 : end+1], strings.TrimLeft(line[end+2:], " \t"), true
+}
+
+// listName quotes a filename for a list if it contains spaces.
+func listName(name string) string {
+	if strings.ContainsAny(name, " \t") {
+		return `"` + name + `"`
+	}
+	return name
+}
+
+// splitListField returns the first whitespace-separated field of s and the
+// remainder with leading whitespace removed.
+func splitListField(s string) (string, string) {
+	s = strings.TrimLeft(s, " \t")
+	i := strings.IndexAny(s, " \t")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimLeft(s[i:], " \t")
+}
+
+func parseListSize(s string) (int64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(s, ",", ""), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func parseListDate(s string) (time.Time, bool) {
+	for _, layout := range listDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ListableRecords returns the records that belong in an exported list:
+// validated ones, sorted by filename.
+func ListableRecords(records []FileRecord) []FileRecord {
+	out := make([]FileRecord, 0, len(records))
+	for _, r := range records {
+		if !r.Unvalidated {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToUpper(out[i].Filename) < strings.ToUpper(out[j].Filename)
+	})
+	return out
+}
+
+// descriptionLines returns a description's non-blank lines, right-trimmed.
+func descriptionLines(desc string) []string {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(desc, "\r\n", "\n"), "\n") {
+		if l = strings.TrimRight(l, " \t\r"); strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// WriteFilesBBS writes records as a FILES.BBS list. Extra description lines
+// are indented under the first.
+func WriteFilesBBS(w io.Writer, records []FileRecord) error {
+	bw := bufio.NewWriter(w)
+	indent := strings.Repeat(" ", 14)
+	for _, r := range records {
+		lines := descriptionLines(r.Description)
+		if len(lines) == 0 {
+			fmt.Fprintf(bw, "%s\r\n", listName(r.Filename))
+			continue
+		}
+		fmt.Fprintf(bw, "%-12s  %s\r\n", listName(r.Filename), strings.TrimLeft(lines[0], " \t"))
+		for _, l := range lines[1:] {
+			fmt.Fprintf(bw, "%s%s\r\n", indent, strings.TrimLeft(l, " \t"))
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteDescriptIon writes records as a DESCRIPT.ION list. DESCRIPT.ION has
+// one line per file, so multi-line descriptions are joined with spaces.
+func WriteDescriptIon(w io.Writer, records []FileRecord) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range records {
+		name := listName(r.Filename)
+		lines := descriptionLines(r.Description)
+		for i := range lines {
+			lines[i] = strings.TrimSpace(lines[i])
+		}
+		fmt.Fprintf(bw, "%s %s\r\n", name, strings.Join(lines, " "))
+	}
+	return bw.Flush()
+}
+
+// WriteAllFiles writes a combined listing of several areas in the
+// "filename size date description" layout, with a header line per area.
+// Offline files are marked so callers know to request them.
+func WriteAllFiles(w io.Writer, title string, sections []AreaFiles) error {
+	bw := bufio.NewWriter(w)
+	rule := strings.Repeat("=", 78)
+	fmt.Fprintf(bw, "%s\r\n== %s\r\n%s\r\n", rule, title, rule)
+
+	indent := strings.Repeat(" ", 33)
+	var totalFiles int
+	var totalBytes int64
+	for _, sec := range sections {
+		var areaBytes int64
+		for _, r := range sec.Files {
+			areaBytes += r.Size
+		}
+		fmt.Fprintf(bw, "\r\n-- %s: %s (%d files, %d bytes)\r\n", sec.Area.Tag, sec.Area.Name, len(sec.Files), areaBytes)
+		if sec.Area.Offline {
+			fmt.Fprintf(bw, "-- This area is offline; downloads are by request.\r\n")
+		}
+		fmt.Fprintf(bw, "%s\r\n", strings.Repeat("-", 78))
+		for _, r := range sec.Files {
+			lines := descriptionLines(r.Description)
+			first := ""
+			if len(lines) > 0 {
+				first = strings.TrimLeft(lines[0], " \t")
+			}
+			if r.Offline {
+				first = strings.TrimSpace("(Offline) " + first)
+			}
+			line := fmt.Sprintf("%-12s %9d %s  %s", listName(r.Filename), r.Size, r.UploadedAt.Format("01-02-06"), first)
+			fmt.Fprintf(bw, "%s\r\n", strings.TrimRight(line, " "))
+			for i := 1; i < len(lines); i++ {
+				fmt.Fprintf(bw, "%s%s\r\n", indent, strings.TrimLeft(lines[i], " \t"))
+			}
+		}
+		totalFiles += len(sec.Files)
+		totalBytes += areaBytes
+	}
+
+	fmt.Fprintf(bw, "\r\n%s\r\n== Total: %d files, %d bytes in %d areas\r\n%s\r\n", rule, totalFiles, totalBytes, len(sections), rule)
+	return bw.Flush()
+}
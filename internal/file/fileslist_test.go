@@ -0,0 +1,143 @@
+package file
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFileList_FilesBBS(t *testing.T) {
+	list := "---- Utilities ----\r\n" +
+		"   stray text under the header\r\n" +
+		"PKZ204G.EXE  PKZIP 2.04g compression utility\r\n" +
+		"             | The standard archiver\r\n" +
+		"              for DOS.\r\n" +
+		"LIST90.ZIP    123,456 03-15-95  [12] Vernon Buerg's LIST\r\n" +
+		"QEDIT.ZIP 1995-04-01 Fast text editor\r\n" +
+		"2PLAYER.ZIP  2 player game\r\n" +
+		"\"Long Name.txt\"  Quoted long filename\r\n" +
+		"NODESC.ZIP\r\n" +
+		"ANSI\xb0ART.ZIP  Art with a \xb2 block\r\n"
+
+	entries, err := ParseFileList(strings.NewReader(list), ListFilesBBS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ListEntry{
+		{Filename: "PKZ204G.EXE", Description: "PKZIP 2.04g compression utility\nThe standard archiver\nfor DOS."},
+		{Filename: "LIST90.ZIP", Description: "Vernon Buerg's LIST", Size: 123456, Date: time.Date(1995, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{Filename: "QEDIT.ZIP", Description: "Fast text editor", Date: time.Date(1995, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{Filename: "2PLAYER.ZIP", Description: "2 player game"},
+		{Filename: "Long Name.txt", Description: "Quoted long filename"},
+		{Filename: "NODESC.ZIP"},
+		{Filename: "ANSI░ART.ZIP", Description: "Art with a ▓ block"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestParseFileList_DescriptIon(t *testing.T) {
+	list := "readme.txt Read this first\n" +
+		"\"my file.zip\" Has spaces\n" +
+		"tool.exe Tool with 4DOS data\x04\xc2extra\n" +
+		"\"unterminated.zip broken\n"
+
+	if got := DetectListFormat("/cd/utils/descript.ion"); got != ListDescriptIon {
+		t.Fatalf("DetectListFormat = %q", got)
+	}
+	entries, err := ParseFileList(strings.NewReader(list), ListDescriptIon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ListEntry{
+		{Filename: "readme.txt", Description: "Read this first"},
+		{Filename: "my file.zip", Description: "Has spaces"},
+		{Filename: "tool.exe", Description: "Tool with 4DOS data"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestWriteFilesBBS_RoundTrip(t *testing.T) {
+	records := ListableRecords([]FileRecord{
+		{Filename: "ZED.ZIP", Description: "Last one"},
+		{Filename: "held.zip", Description: "Awaiting validation", Unvalidated: true},
+		{Filename: "alpha.zip", Description: "  First line\n\nSecond line  \n"},
+		{Filename: "My Docs.txt"},
+	})
+	if len(records) != 3 || records[0].Filename != "alpha.zip" || records[2].Filename != "ZED.ZIP" {
+		t.Fatalf("ListableRecords = %+v", records)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFilesBBS(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	wantText := "alpha.zip     First line\r\n" +
+		"              Second line\r\n" +
+		"\"My Docs.txt\"\r\n" +
+		"ZED.ZIP       Last one\r\n"
+	if buf.String() != wantText {
+		t.Fatalf("WriteFilesBBS wrote:\n%q\nwant:\n%q", buf.String(), wantText)
+	}
+
+	entries, err := ParseFileList(&buf, ListFilesBBS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Description != "First line\nSecond line" || entries[1].Filename != "My Docs.txt" {
+		t.Errorf("round trip = %+v", entries)
+	}
+}
+
+func TestWriteAllFiles(t *testing.T) {
+	uploaded := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	sections := []AreaFiles{
+		{Area: FileArea{Tag: "UTILS", Name: "Utilities"}, Files: []FileRecord{
+			{Filename: "PKZ204G.EXE", Size: 202574, UploadedAt: uploaded, Description: "PKZIP\nfor DOS"},
+		}},
+		{Area: FileArea{Tag: "CDROM", Name: "Shareware CD", Offline: true}, Files: []FileRecord{
+			{Filename: "DOOM.ZIP", Size: 2000, UploadedAt: uploaded, Description: "Shareware Doom", Offline: true},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAllFiles(&buf, "Test BBS - All Files", sections); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"== Test BBS - All Files\r\n",
+		"-- UTILS: Utilities (1 files, 202574 bytes)\r\n",
+		"PKZ204G.EXE     202574 10-01-26  PKZIP\r\n" + strings.Repeat(" ", 33) + "for DOS\r\n",
+		"-- This area is offline; downloads are by request.\r\n",
+		"DOOM.ZIP          2000 10-01-26  (Offline) Shareware Doom\r\n",
+		"== Total: 2 files, 204574 bytes in 2 areas\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ALLFILES missing %q in:\n%s", want, out)
+		}
+	}
+
+	// The listing reads back as a FILES.BBS list with size and date columns.
+	entries, err := ParseFileList(strings.NewReader(out), ListFilesBBS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Size != 202574 || entries[0].Description != "PKZIP\nfor DOS" {
+		t.Errorf("parsed ALLFILES = %+v", entries)
+	}
+}
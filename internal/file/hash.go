@@ -0,0 +1,75 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// FileHashes holds the content hashes recorded for a file.
+// SHA256 is used for duplicate detection; CRC32 is kept for FTN/TIC
+// compatibility (TIC files carry an 8-digit hex CRC of the attached file).
+type FileHashes struct {
+	SHA256 string
+	CRC32  string
+}
+
+// HashFile computes the SHA-256 and CRC-32 of the file at path in a single pass.
+// Both hashes are returned as uppercase hex strings.
+func HashFile(path string) (FileHashes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileHashes{}, err
+	}
+	defer f.Close()
+	return HashReader(f)
+}
+
+// HashReader computes the SHA-256 and CRC-32 of everything read from r.
+func HashReader(r io.Reader) (FileHashes, error) {
+	sh := sha256.New()
+	crc := crc32.NewIEEE()
+	if _, err := io.Copy(io.MultiWriter(sh, crc), r); err != nil {
+		return FileHashes{}, fmt.Errorf("hashing: %w", err)
+	}
+	return FileHashes{
+		SHA256: strings.ToUpper(hex.EncodeToString(sh.Sum(nil))),
+		CRC32:  fmt.Sprintf("%08X", crc.Sum32()),
+	}, nil
+}
+
+// NormalizeFilenameStem reduces a filename to a comparison key for
+// near-duplicate detection: the extension is dropped, letters are lowercased,
+// and everything but letters and digits is removed. "Cool_App.ZIP" and
+// "COOL-APP.ARJ" both normalize to "coolapp".
+func NormalizeFilenameStem(name string) string {
+	base := filepath.Base(name)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	var b strings.Builder
+	for _, r := range base {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// IsNearDuplicateName reports whether two filenames differ only by case,
+// punctuation, or extension. Identical names (case-insensitive) are exact
+// duplicates and are not reported here.
+func IsNearDuplicateName(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return false
+	}
+	stemA := NormalizeFilenameStem(a)
+	if stemA == "" {
+		return false
+	}
+	return stemA == NormalizeFilenameStem(b)
+}
@@ -0,0 +1,165 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestHashReader_KnownValues(t *testing.T) {
+	h, err := HashReader(strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.SHA256 != "B94D27B9934D3E08A52E52D7DA7DABFAC484EFE37A5380EE9088F7ACE2EFCDE9" {
+		t.Errorf("SHA256 = %s", h.SHA256)
+	}
+	if h.CRC32 != "0D4A1185" {
+		t.Errorf("CRC32 = %s", h.CRC32)
+	}
+}
+
+func TestHashFile_MatchesHashReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TEST.TXT")
+	os.WriteFile(path, []byte("hello world"), 0644)
+
+	h, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.CRC32 != "0D4A1185" {
+		t.Errorf("CRC32 = %s, want 0D4A1185", h.CRC32)
+	}
+}
+
+func TestIsNearDuplicateName(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"COOLAPP.ZIP", "coolapp.zip", false}, // exact (case-insensitive) duplicate
+		{"COOLAPP.ZIP", "COOLAPP.ARJ", true},
+		{"Cool_App.zip", "COOL-APP.ZIP", true},
+		{"COOLAPP1.ZIP", "COOLAPP2.ZIP", false},
+		{"README", "readme.txt", true},
+		{"___.ZIP", "---.ZIP", false}, // no alphanumerics to compare
+	}
+	for _, tc := range tests {
+		if got := IsNearDuplicateName(tc.a, tc.b); got != tc.want {
+			t.Errorf("IsNearDuplicateName(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestFindFileBySHA256_AcrossAreas(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"},
+		{ID: 2, Tag: "GAMES", Name: "Games", Path: "games"},
+	})
+
+	rec := FileRecord{
+		ID:         uuid.New(),
+		AreaID:     2,
+		Filename:   "DOOM.ZIP",
+		Size:       11,
+		UploadedAt: time.Now(),
+		SHA256:     "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		CRC32:      "0D4A1185",
+	}
+	if err := fm.AddFileRecord(rec); err != nil {
+		t.Fatalf("AddFileRecord: %v", err)
+	}
+
+	found, ok := fm.FindFileBySHA256("B94D27B9934D3E08A52E52D7DA7DABFAC484EFE37A5380EE9088F7ACE2EFCDE9")
+	if !ok {
+		t.Fatal("expected duplicate to be found")
+	}
+	if found.ID != rec.ID {
+		t.Errorf("found ID %s, want %s", found.ID, rec.ID)
+	}
+
+	if err := fm.DeleteFileRecord(rec.ID, false); err != nil {
+		t.Fatalf("DeleteFileRecord: %v", err)
+	}
+	if _, ok := fm.FindFileBySHA256(rec.SHA256); ok {
+		t.Error("expected hash index entry to be removed after delete")
+	}
+}
+
+func TestFindFileBySHA256_IndexedOnLoad(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"},
+	})
+	rec := FileRecord{ID: uuid.New(), AreaID: 1, Filename: "A.ZIP", UploadedAt: time.Now(), SHA256: "ABCDEF"}
+	if err := fm.AddFileRecord(rec); err != nil {
+		t.Fatalf("AddFileRecord: %v", err)
+	}
+
+	// Reload from disk and confirm the index is rebuilt.
+	if err := fm.loadAllFileRecords(); err != nil {
+		t.Fatalf("loadAllFileRecords: %v", err)
+	}
+	if _, ok := fm.FindFileBySHA256("abcdef"); !ok {
+		t.Error("expected hash to be indexed after reload")
+	}
+}
+
+func TestFindFileBySHA256_TracksChanges(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"},
+	})
+	first := FileRecord{ID: uuid.New(), AreaID: 1, Filename: "A.ZIP", UploadedAt: time.Now(), SHA256: "ABCDEF"}
+	second := FileRecord{ID: uuid.New(), AreaID: 1, Filename: "B.ZIP", UploadedAt: time.Now(), SHA256: "abcdef"}
+	fm.AddFileRecord(first)
+	fm.AddFileRecord(second)
+
+	if err := fm.UpdateFileDescription(first.ID, "updated"); err != nil {
+		t.Fatalf("UpdateFileDescription: %v", err)
+	}
+	found, ok := fm.FindFileBySHA256("abcdef")
+	if !ok || found.ID != first.ID || found.Description != "updated" {
+		t.Fatalf("found %+v, want the updated first record", found)
+	}
+
+	if err := fm.DeleteFileRecord(first.ID, false); err != nil {
+		t.Fatalf("DeleteFileRecord: %v", err)
+	}
+	if found, ok := fm.FindFileBySHA256("ABCDEF"); !ok || found.ID != second.ID {
+		t.Errorf("after delete found %v (ok=%v), want the second record", found.ID, ok)
+	}
+
+	fm.UpdateFileRecord(second.ID, func(r *FileRecord) { r.SHA256 = "123456" })
+	if _, ok := fm.FindFileBySHA256("ABCDEF"); ok {
+		t.Error("old hash still indexed after the record's hash changed")
+	}
+	if found, ok := fm.FindFileBySHA256("123456"); !ok || found.ID != second.ID {
+		t.Error("new hash not indexed")
+	}
+
+	fm.IncrementDownloadCount(second.ID)
+	fm.UpdateFileRecord(second.ID, func(r *FileRecord) { r.Filename = "C.ZIP" })
+	if found, _ := fm.FindFileBySHA256("123456"); found.Filename != "C.ZIP" || found.DownloadCount != 1 {
+		t.Errorf("found %s with %d downloads, want the current C.ZIP with 1", found.Filename, found.DownloadCount)
+	}
+}
+
+func TestFindNearDuplicateNames(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"},
+	})
+	for _, name := range []string{"PKZ204G.EXE", "OTHER.ZIP"} {
+		fm.AddFileRecord(FileRecord{ID: uuid.New(), AreaID: 1, Filename: name, UploadedAt: time.Now()})
+	}
+
+	matches := fm.FindNearDuplicateNames("pkz204g.zip")
+	if len(matches) != 1 || matches[0].Filename != "PKZ204G.EXE" {
+		t.Errorf("unexpected matches: %+v", matches)
+	}
+	if len(fm.FindNearDuplicateNames("PKZ204G.EXE")) != 0 {
+		t.Error("exact filename should not be reported as near-duplicate")
+	}
+}
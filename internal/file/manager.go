@@ -2,6 +2,7 @@ package file
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -16,13 +17,19 @@ import (
 
 // FileManager manages file areas and their associated file records.
 type FileManager struct {
-	basePath    string               // Base directory for all file areas (e.g., "data/files")
-	configPath  string               // Path to file_areas.json
-	muAreas     sync.RWMutex         // Mutex for accessing file area definitions
-	muFiles     sync.RWMutex         // Mutex for accessing file records (might need finer-grained locking later)
-	fileAreas   map[int]*FileArea    // Map AreaID to FileArea definition
-	fileTags    map[string]int       // Map Area Tag (uppercase) to AreaID
-	fileRecords map[int][]FileRecord // Map AreaID to a slice of its FileRecords
+	basePath    string                 // Base directory for all file areas (e.g., "data/files")
+	configPath  string                 // Path to file_areas.json
+	muAreas     sync.RWMutex           // Mutex for accessing file area definitions
+	muFiles     sync.RWMutex           // Mutex for accessing file records (might need finer-grained locking later)
+	fileAreas   map[int]*FileArea      // Map AreaID to FileArea definition
+	fileTags    map[string]int         // Map Area Tag (uppercase) to AreaID
+	fileRecords map[int][]FileRecord   // Map AreaID to a slice of its FileRecords
+	hashIndex   map[string][]uuid.UUID // Map uppercase SHA-256 to the IDs of the records with that hash, oldest first
+	muStats     sync.Mutex             // Mutex for the per-area transfer stats
+	areaStats   map[int]*AreaStats     // Map AreaID to transfer stats, loaded on first use
+	muPartials  sync.Mutex             // Mutex for the partial upload indexes
+	muOffline   sync.Mutex             // Mutex for the offline request queue
+	muUploads   sync.Mutex             // Serialises the name check and add of AddUploadedFile and AddExistingFile
 }
 
 // NewFileManager creates and initializes a new FileManager.
@@ -33,6 +40,7 @@ func NewFileManager(baseDataPath, baseConfigPath string) (*FileManager, error) {
 		fileAreas:   make(map[int]*FileArea),
 		fileTags:    make(map[string]int),
 		fileRecords: make(map[int][]FileRecord),
+		hashIndex:   make(map[string][]uuid.UUID),
 	}
 
 	log.Printf("INFO: Loading file areas from: %s", fm.configPath)
@@ -160,6 +168,8 @@ func (fm *FileManager) loadAllFileRecords() error {
 		log.Printf("DEBUG: Loaded %d file records for area %s (ID: %d)", len(records), area.Tag, areaID)
 	}
 
+	fm.reindexHashesLocked()
+
 	log.Printf("INFO: Loaded metadata for %d areas, total %d file records.", len(fm.fileAreas), totalFilesLoaded)
 	if errorsEncountered {
 		return fmt.Errorf("encountered errors while loading file records")
@@ -245,6 +255,27 @@ func (fm *FileManager) GetAreaByID(id int) (*FileArea, bool) {
 	return area, exists // Return pointer directly
 }
 
+// GetFileRecord returns a copy of the file record with the given ID.
+func (fm *FileManager) GetFileRecord(fileID uuid.UUID) (FileRecord, bool) {
+	fm.muFiles.RLock()
+	defer fm.muFiles.RUnlock()
+
+	return fm.fileRecordLocked(fileID)
+}
+
+// fileRecordLocked returns a copy of the file record with the given ID.
+// Caller must hold muFiles.
+func (fm *FileManager) fileRecordLocked(fileID uuid.UUID) (FileRecord, bool) {
+	for _, records := range fm.fileRecords {
+		for _, rec := range records {
+			if rec.ID == fileID {
+				return rec, true
+			}
+		}
+	}
+	return FileRecord{}, false
+}
+
 // GetFilesForArea returns a slice of FileRecord for a given area ID.
 // Returns an empty slice if the area doesn't exist or has no files.
 func (fm *FileManager) GetFilesForArea(areaID int) []FileRecord {
@@ -373,6 +404,7 @@ func (fm *FileManager) AddFileRecord(record FileRecord) error {
 	}
 
 	fm.fileRecords[record.AreaID] = append(fm.fileRecords[record.AreaID], record)
+	fm.indexHashLocked(record)
 
 	// Save changes for this area - release lock during save
 	fm.muFiles.Unlock()
@@ -391,6 +423,79 @@ func (fm *FileManager) AddFileRecord(record FileRecord) error {
 	return nil
 }
 
+// ErrFilenameTaken is returned by AddUploadedFile when the area already has a
+// record or a file on disk with the upload's name.
+var ErrFilenameTaken = errors.New("filename already in use")
+
+// AddUploadedFile moves the file at srcPath into record's area under
+// record.Filename and adds record. The name is checked against the area's
+// records (ignoring case) and claimed on disk with an exclusive create, all
+// under one lock, so concurrent uploads of the same name cannot both land and
+// nothing already at the destination is replaced. On failure any file this
+// call placed is removed.
+func (fm *FileManager) AddUploadedFile(record FileRecord, srcPath string) error {
+	fm.muUploads.Lock()
+	defer fm.muUploads.Unlock()
+
+	if err := fm.checkNameFree(record); err != nil {
+		return err
+	}
+
+	targetDir, err := fm.GetAreaUploadPath(record.AreaID)
+	if err != nil {
+		return err
+	}
+	finalPath := filepath.Join(targetDir, filepath.Base(record.Filename))
+	claim, err := os.OpenFile(finalPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%w: %s is already on disk", ErrFilenameTaken, record.Filename)
+		}
+		return fmt.Errorf("failed to claim %s: %w", finalPath, err)
+	}
+	claim.Close()
+
+	if err := os.Rename(srcPath, finalPath); err != nil {
+		os.Remove(finalPath)
+		return fmt.Errorf("failed to move %s into area: %w", record.Filename, err)
+	}
+	if err := fm.AddFileRecord(record); err != nil {
+		if removeErr := os.Remove(finalPath); removeErr != nil {
+			log.Printf("ERROR: Failed to clean up orphaned file %s: %v", finalPath, removeErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// AddExistingFile adds record for a file already in its area's directory,
+// such as one found by a directory sync. The name is checked against the
+// area's records (ignoring case) under the same lock as AddUploadedFile, so
+// a sync cannot add a second record for a file an upload is adding.
+// Returns ErrFilenameTaken if the area already has a record with the name.
+func (fm *FileManager) AddExistingFile(record FileRecord) error {
+	fm.muUploads.Lock()
+	defer fm.muUploads.Unlock()
+
+	if err := fm.checkNameFree(record); err != nil {
+		return err
+	}
+	return fm.AddFileRecord(record)
+}
+
+// checkNameFree returns ErrFilenameTaken if record's area already has a
+// record with its filename, ignoring case. Caller must hold muUploads.
+func (fm *FileManager) checkNameFree(record FileRecord) error {
+	fm.muFiles.RLock()
+	defer fm.muFiles.RUnlock()
+	for _, existing := range fm.fileRecords[record.AreaID] {
+		if strings.EqualFold(existing.Filename, record.Filename) {
+			return fmt.Errorf("%w: %s", ErrFilenameTaken, record.Filename)
+		}
+	}
+	return nil
+}
+
 // IncrementDownloadCount increments the download count for a file and saves.
 func (fm *FileManager) IncrementDownloadCount(fileID uuid.UUID) error {
 	fm.muFiles.Lock()
@@ -459,8 +564,10 @@ searchLoop:
 		return fmt.Errorf("file record with ID %s not found", fileID)
 	}
 
+	old := fm.fileRecords[foundAreaID][foundIndex]
 	updateFunc(&fm.fileRecords[foundAreaID][foundIndex])
 	filename := fm.fileRecords[foundAreaID][foundIndex].Filename
+	fm.reindexHashLocked(old, fm.fileRecords[foundAreaID][foundIndex])
 
 	fm.muFiles.Unlock()
 	err := fm.saveFileRecords(foundAreaID)
@@ -531,6 +638,7 @@ searchLoop:
 
 	// Remove record from slice and persist.
 	records := fm.fileRecords[foundAreaID]
+	fm.unindexHashLocked(records[foundIndex])
 	fm.fileRecords[foundAreaID] = append(records[:foundIndex], records[foundIndex+1:]...)
 
 	fm.muFiles.Unlock()
@@ -651,8 +759,186 @@ searchLoop:
 	return nil
 }
 
+// RenameFileRecord renames a file on disk and updates its record. The new
+// name must be a plain filename not already used by another record in the area.
+func (fm *FileManager) RenameFileRecord(fileID uuid.UUID, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" || newName != filepath.Base(newName) || newName == "." || newName == ".." || strings.ContainsAny(newName, `/\`) {
+		return fmt.Errorf("invalid filename %q", newName)
+	}
+
+	fm.muFiles.Lock()
+	defer fm.muFiles.Unlock()
+
+	var foundAreaID int = -1
+	var foundIndex int = -1
+
+searchLoop:
+	for areaID, records := range fm.fileRecords {
+		for i := range records {
+			if records[i].ID == fileID {
+				foundAreaID = areaID
+				foundIndex = i
+				break searchLoop
+			}
+		}
+	}
+
+	if foundAreaID == -1 {
+		return fmt.Errorf("file record with ID %s not found", fileID)
+	}
+
+	oldName := fm.fileRecords[foundAreaID][foundIndex].Filename
+	if oldName == newName {
+		return nil
+	}
+	for i, rec := range fm.fileRecords[foundAreaID] {
+		if i != foundIndex && strings.EqualFold(rec.Filename, newName) {
+			return fmt.Errorf("file %q already exists in area %d", newName, foundAreaID)
+		}
+	}
+
+	fm.muAreas.RLock()
+	area, areaExists := fm.fileAreas[foundAreaID]
+	fm.muAreas.RUnlock()
+	if !areaExists {
+		return fmt.Errorf("internal inconsistency: area %d not found", foundAreaID)
+	}
+
+	absBasePath, err := filepath.Abs(fm.basePath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute base path: %w", err)
+	}
+	srcPath := filepath.Join(absBasePath, area.Path, filepath.Base(oldName))
+	dstPath := filepath.Join(absBasePath, area.Path, newName)
+
+	// A case-only rename maps to the same path on case-insensitive filesystems.
+	if !strings.EqualFold(oldName, newName) {
+		if _, err := os.Stat(dstPath); err == nil {
+			return fmt.Errorf("file %q already exists on disk", newName)
+		}
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", srcPath, dstPath, err)
+	}
+
+	fm.fileRecords[foundAreaID][foundIndex].Filename = newName
+
+	fm.muFiles.Unlock()
+	saveErr := fm.saveFileRecords(foundAreaID)
+	fm.muFiles.Lock()
+
+	if saveErr != nil {
+		// Put the file and record back so disk and metadata stay in agreement.
+		if renameBackErr := os.Rename(dstPath, srcPath); renameBackErr != nil {
+			log.Printf("ERROR: Failed to roll back rename after metadata save failure (%s -> %s): %v", dstPath, srcPath, renameBackErr)
+		} else {
+			fm.fileRecords[foundAreaID][foundIndex].Filename = oldName
+		}
+		log.Printf("ERROR: Failed to save file records after renaming %s to %s: %v", oldName, newName, saveErr)
+		return saveErr
+	}
+
+	log.Printf("INFO: Renamed file '%s' to '%s' (ID: %s) in area %d.", oldName, newName, fileID, foundAreaID)
+	return nil
+}
+
+// reindexHashesLocked rebuilds the SHA-256 index from the in-memory records.
+// It runs once at load; later changes keep the index current through
+// indexHashLocked, unindexHashLocked and reindexHashLocked. The index holds
+// only record IDs, so changes that keep the hash need no reindexing.
+// Caller must hold muFiles for writing.
+func (fm *FileManager) reindexHashesLocked() {
+	fm.hashIndex = make(map[string][]uuid.UUID)
+	for _, records := range fm.fileRecords {
+		for i := range records {
+			if key := strings.ToUpper(records[i].SHA256); key != "" && len(fm.hashIndex[key]) > 0 {
+				log.Printf("DEBUG: Duplicate content hash %s for file '%s' (ID: %s)", key, records[i].Filename, records[i].ID)
+			}
+			fm.indexHashLocked(records[i])
+		}
+	}
+}
+
+// indexHashLocked adds r's ID to the SHA-256 index.
+// Caller must hold muFiles for writing.
+func (fm *FileManager) indexHashLocked(r FileRecord) {
+	if key := strings.ToUpper(r.SHA256); key != "" {
+		fm.hashIndex[key] = append(fm.hashIndex[key], r.ID)
+	}
+}
+
+// unindexHashLocked removes r's ID from the SHA-256 index.
+// Caller must hold muFiles for writing.
+func (fm *FileManager) unindexHashLocked(r FileRecord) {
+	key := strings.ToUpper(r.SHA256)
+	ids := fm.hashIndex[key]
+	for i := range ids {
+		if ids[i] == r.ID {
+			ids = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(fm.hashIndex, key)
+	} else {
+		fm.hashIndex[key] = ids
+	}
+}
+
+// reindexHashLocked moves a record's index entry when an update changed its
+// hash from old's to r's.
+// Caller must hold muFiles for writing.
+func (fm *FileManager) reindexHashLocked(old, r FileRecord) {
+	if strings.EqualFold(old.SHA256, r.SHA256) {
+		return
+	}
+	fm.unindexHashLocked(old)
+	fm.indexHashLocked(r)
+}
+
+// FindFileBySHA256 returns the first file record, in any area, whose contents
+// hash to the given SHA-256 (hex, case-insensitive).
+func (fm *FileManager) FindFileBySHA256(sha string) (FileRecord, bool) {
+	if sha == "" {
+		return FileRecord{}, false
+	}
+	fm.muFiles.RLock()
+	defer fm.muFiles.RUnlock()
+
+	ids := fm.hashIndex[strings.ToUpper(sha)]
+	if len(ids) == 0 {
+		return FileRecord{}, false
+	}
+	return fm.fileRecordLocked(ids[0])
+}
+
+// FindNearDuplicateNames returns file records in any area whose filenames
+// differ from filename only by case, punctuation, or extension.
+func (fm *FileManager) FindNearDuplicateNames(filename string) []FileRecord {
+	fm.muFiles.RLock()
+	defer fm.muFiles.RUnlock()
+
+	var matches []FileRecord
+	for _, records := range fm.fileRecords {
+		for i := range records {
+			if IsNearDuplicateName(filename, records[i].Filename) {
+				matches = append(matches, records[i])
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].AreaID != matches[j].AreaID {
+			return matches[i].AreaID < matches[j].AreaID
+		}
+		return matches[i].Filename < matches[j].Filename
+	})
+	return matches
+}
+
 // GetFilePath returns the full, absolute path to a file given its record ID.
-// It checks that the file exists and constructs the path safely.
+// It constructs the path safely. Files on offline media, and files missing
+// from a removable area, return an error wrapping ErrFileOffline.
 func (fm *FileManager) GetFilePath(fileID uuid.UUID) (string, error) {
 	fm.muFiles.RLock() // Need read lock to find the file record
 	defer fm.muFiles.RUnlock()
@@ -703,10 +989,15 @@ searchLoop:
 		return "", fmt.Errorf("constructed file path '%s' is outside base directory '%s'", fullPath, absBasePath)
 	}
 
-	// Optional: Check if file actually exists on disk?
-	// if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-	// 	return "", fmt.Errorf("file '%s' not found on disk at expected path '%s'", safeFilename, fullPath)
-	// }
+	// Files on offline or removable media stay listed while absent.
+	if foundArea.Offline || foundRecord.Offline {
+		return "", fmt.Errorf("%s: %w", safeFilename, ErrFileOffline)
+	}
+	if foundArea.Removable {
+		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+			return "", fmt.Errorf("%s: %w", safeFilename, ErrFileOffline)
+		}
+	}
 
 	return fullPath, nil
 }
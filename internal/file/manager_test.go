@@ -2,9 +2,11 @@ package file
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -415,3 +417,83 @@ func TestGetFilePath_TraversalRejected(t *testing.T) {
 		t.Errorf("path %s escaped base directory %s", path, absBase)
 	}
 }
+
+func TestAddUploadedFile_ClaimsName(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"}})
+	areaDir, err := fm.GetAreaUploadPath(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(areaDir, 0755)
+	upload := func(name, content string) error {
+		src := filepath.Join(t.TempDir(), name)
+		os.WriteFile(src, []byte(content), 0644)
+		return fm.AddUploadedFile(FileRecord{ID: uuid.New(), AreaID: 1, Filename: name, UploadedAt: time.Now()}, src)
+	}
+
+	if err := upload("NEW.ZIP", "new"); err != nil {
+		t.Fatalf("AddUploadedFile: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(areaDir, "NEW.ZIP")); string(data) != "new" {
+		t.Errorf("area file holds %q", data)
+	}
+	if err := upload("new.zip", "again"); !errors.Is(err, ErrFilenameTaken) {
+		t.Errorf("recorded name: err = %v, want ErrFilenameTaken", err)
+	}
+
+	// A file already on disk without a record is left alone.
+	os.WriteFile(filepath.Join(areaDir, "SYNCED.ZIP"), []byte("synced"), 0644)
+	if err := upload("SYNCED.ZIP", "upload"); !errors.Is(err, ErrFilenameTaken) {
+		t.Errorf("file on disk: err = %v, want ErrFilenameTaken", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(areaDir, "SYNCED.ZIP")); string(data) != "synced" {
+		t.Errorf("existing file replaced with %q", data)
+	}
+	if n := len(fm.GetFilesForArea(1)); n != 1 {
+		t.Errorf("area has %d records, want 1", n)
+	}
+}
+
+func TestAddExistingFile_ChecksName(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"}})
+	areaDir, err := fm.GetAreaUploadPath(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(areaDir, 0755)
+	os.WriteFile(filepath.Join(areaDir, "SYNCED.ZIP"), []byte("synced"), 0644)
+
+	add := func(name string) error {
+		return fm.AddExistingFile(FileRecord{ID: uuid.New(), AreaID: 1, Filename: name, UploadedAt: time.Now()})
+	}
+	if err := add("SYNCED.ZIP"); err != nil {
+		t.Fatalf("AddExistingFile: %v", err)
+	}
+	if err := add("synced.zip"); !errors.Is(err, ErrFilenameTaken) {
+		t.Errorf("second add: err = %v, want ErrFilenameTaken", err)
+	}
+
+	// An upload of the same name racing the sync adds only one record.
+	src := filepath.Join(t.TempDir(), "RACE.ZIP")
+	os.WriteFile(src, []byte("race"), 0644)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		fm.AddUploadedFile(FileRecord{ID: uuid.New(), AreaID: 1, Filename: "RACE.ZIP", UploadedAt: time.Now()}, src)
+	}()
+	go func() {
+		defer wg.Done()
+		add("RACE.ZIP")
+	}()
+	wg.Wait()
+	n := 0
+	for _, rec := range fm.GetFilesForArea(1) {
+		if strings.EqualFold(rec.Filename, "RACE.ZIP") {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("RACE.ZIP has %d records, want 1", n)
+	}
+}
@@ -194,6 +194,42 @@ func TestDeleteFileRecord_DeletesFromDisk(t *testing.T) {
 	}
 }
 
+func TestDeleteFileRecord_KeepsFileAndDropsHash(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+	if err := fm.UpdateFileRecord(fileID, func(r *FileRecord) { r.SHA256 = "ABC123" }); err != nil {
+		t.Fatalf("UpdateFileRecord: %v", err)
+	}
+
+	if err := fm.DeleteFileRecord(fileID, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := fm.GetFileRecord(fileID); ok {
+		t.Error("file record still present after delete")
+	}
+	if _, found := fm.FindFileBySHA256("abc123"); found {
+		t.Error("deleted record still in the hash index")
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, "utils", "GAME.ZIP")); err != nil {
+		t.Errorf("file removed from disk without being asked: %v", err)
+	}
+}
+
+func TestDeleteFileRecord_DiskFailureKeepsRecord(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+
+	// A non-empty directory in the file's place cannot be removed.
+	filePath := filepath.Join(fm.basePath, "utils", "GAME.ZIP")
+	os.Remove(filePath)
+	os.MkdirAll(filepath.Join(filePath, "keep"), 0755)
+
+	if err := fm.DeleteFileRecord(fileID, true); err == nil {
+		t.Fatal("expected error when the file cannot be removed")
+	}
+	if _, ok := fm.GetFileRecord(fileID); !ok {
+		t.Error("record removed although the file was not")
+	}
+}
+
 func TestDeleteFileRecord_NotFound(t *testing.T) {
 	fm, _ := setupTestFileManagerForUpdate(t)
 
@@ -288,6 +324,32 @@ func TestMoveFileRecord_MovesRecordAndFile(t *testing.T) {
 	}
 }
 
+func TestMoveFileRecord_KeepsHashIndexAndPath(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+	if err := fm.UpdateFileRecord(fileID, func(r *FileRecord) { r.SHA256 = "ABC123" }); err != nil {
+		t.Fatalf("UpdateFileRecord: %v", err)
+	}
+
+	if err := fm.MoveFileRecord(fileID, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dup, found := fm.FindFileBySHA256("ABC123")
+	if !found || dup.ID != fileID || dup.AreaID != 2 {
+		t.Errorf("FindFileBySHA256 after move = %+v, %v; want the record in area 2", dup, found)
+	}
+	path, err := fm.GetFilePath(fileID)
+	if err != nil {
+		t.Fatalf("GetFilePath: %v", err)
+	}
+	if want, _ := filepath.Abs(filepath.Join(fm.basePath, "games", "GAME.ZIP")); path != want {
+		t.Errorf("GetFilePath = %s, want %s", path, want)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "dummy" {
+		t.Errorf("file at %s: %q, %v", path, data, err)
+	}
+}
+
 func TestMoveFileRecord_InvalidTargetArea(t *testing.T) {
 	fm, fileID := setupTestFileManagerTwoAreas(t)
 
@@ -349,3 +411,100 @@ func TestMoveFileRecord_TargetCollision(t *testing.T) {
 		t.Errorf("collision file content = %q, want \"existing\"", string(data))
 	}
 }
+
+// --- RenameFileRecord tests ---
+
+func TestRenameFileRecord_RenamesRecordAndFile(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+
+	if err := fm.RenameFileRecord(fileID, "GAME2.ZIP"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec, ok := fm.GetFileRecord(fileID)
+	if !ok || rec.Filename != "GAME2.ZIP" {
+		t.Errorf("expected record renamed to GAME2.ZIP, got %+v", rec)
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, "utils", "GAME2.ZIP")); err != nil {
+		t.Errorf("renamed file not on disk: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, "utils", "GAME.ZIP")); !os.IsNotExist(err) {
+		t.Error("old file still exists after rename")
+	}
+}
+
+func TestRenameFileRecord_RejectsBadNames(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+
+	for _, name := range []string{"", "..", "../ESCAPE.ZIP", "sub/FILE.ZIP"} {
+		if err := fm.RenameFileRecord(fileID, name); err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+	}
+}
+
+func TestRenameFileRecord_RejectsExistingName(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+	other := FileRecord{ID: uuid.New(), AreaID: 1, Filename: "OTHER.ZIP"}
+	if err := fm.AddFileRecord(other); err != nil {
+		t.Fatalf("AddFileRecord: %v", err)
+	}
+
+	if err := fm.RenameFileRecord(fileID, "other.zip"); err == nil {
+		t.Error("expected error renaming onto an existing record name")
+	}
+}
+
+func TestRenameFileRecord_ConflictLeavesRecordAndFile(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+
+	// A file on disk with no record also blocks the rename.
+	onDisk := filepath.Join(fm.basePath, "utils", "LOOSE.ZIP")
+	os.WriteFile(onDisk, []byte("loose"), 0644)
+	if err := fm.RenameFileRecord(fileID, "LOOSE.ZIP"); err == nil {
+		t.Fatal("expected error renaming onto a file on disk")
+	}
+	if data, _ := os.ReadFile(onDisk); string(data) != "loose" {
+		t.Errorf("existing file replaced with %q", data)
+	}
+	if rec, _ := fm.GetFileRecord(fileID); rec.Filename != "GAME.ZIP" {
+		t.Errorf("record renamed to %q after a refused rename", rec.Filename)
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, "utils", "GAME.ZIP")); err != nil {
+		t.Errorf("original file gone after a refused rename: %v", err)
+	}
+}
+
+func TestRenameFileRecord_DiskFailureKeepsRecord(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+	os.Remove(filepath.Join(fm.basePath, "utils", "GAME.ZIP"))
+
+	if err := fm.RenameFileRecord(fileID, "GAME2.ZIP"); err == nil {
+		t.Fatal("expected error when the file cannot be renamed on disk")
+	}
+	if rec, _ := fm.GetFileRecord(fileID); rec.Filename != "GAME.ZIP" {
+		t.Errorf("record renamed to %q although the file was not", rec.Filename)
+	}
+}
+
+func TestRenameFileRecord_SaveFailureRollsBack(t *testing.T) {
+	fm, fileID := setupTestFileManagerTwoAreas(t)
+
+	// A directory in place of metadata.json makes the save fail.
+	metadataPath := filepath.Join(fm.basePath, "utils", "metadata.json")
+	os.Remove(metadataPath)
+	os.MkdirAll(filepath.Join(metadataPath, "keep"), 0755)
+
+	if err := fm.RenameFileRecord(fileID, "GAME2.ZIP"); err == nil {
+		t.Fatal("expected error when the metadata cannot be saved")
+	}
+	if rec, _ := fm.GetFileRecord(fileID); rec.Filename != "GAME.ZIP" {
+		t.Errorf("record left as %q after a failed save", rec.Filename)
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, "utils", "GAME.ZIP")); err != nil {
+		t.Errorf("file not renamed back after a failed save: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, "utils", "GAME2.ZIP")); !os.IsNotExist(err) {
+		t.Error("new name left on disk after a failed save")
+	}
+}
@@ -0,0 +1,131 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const offlineRequestsFile = "offline_requests.json" // Queue of downloads waiting on offline media, under the files base path
+
+// ErrFileOffline is returned by GetFilePath when a record's file is on
+// offline or removable media that is not currently available.
+var ErrFileOffline = errors.New("file is offline")
+
+// OfflineRequest is a user's request for a file that was offline when they
+// tried to download it. The sysop fulfils it by bringing the file back.
+type OfflineRequest struct {
+	ID          uuid.UUID `json:"id"`
+	FileID      uuid.UUID `json:"fileId"`
+	AreaID      int       `json:"areaId"`
+	Filename    string    `json:"filename"`
+	UserID      int       `json:"userId"`
+	Handle      string    `json:"handle"`
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+func (fm *FileManager) offlineRequestsPath() string {
+	return filepath.Join(fm.basePath, offlineRequestsFile)
+}
+
+// loadOfflineRequests reads the queue. A missing queue is empty.
+func (fm *FileManager) loadOfflineRequests() ([]OfflineRequest, error) {
+	data, err := os.ReadFile(fm.offlineRequestsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var reqs []OfflineRequest
+	if err := json.Unmarshal(data, &reqs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", offlineRequestsFile, err)
+	}
+	return reqs, nil
+}
+
+func (fm *FileManager) saveOfflineRequests(reqs []OfflineRequest) error {
+	data, err := json.MarshalIndent(reqs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal offline requests: %w", err)
+	}
+	return os.WriteFile(fm.offlineRequestsPath(), data, 0644)
+}
+
+// RequestOfflineFile queues a request by a user for rec. If the user has
+// already asked for the file, their existing request is returned and
+// queued is false.
+func (fm *FileManager) RequestOfflineFile(rec FileRecord, userID int, handle string) (req OfflineRequest, queued bool, err error) {
+	fm.muOffline.Lock()
+	defer fm.muOffline.Unlock()
+
+	reqs, err := fm.loadOfflineRequests()
+	if err != nil {
+		return OfflineRequest{}, false, err
+	}
+	for _, r := range reqs {
+		if r.FileID == rec.ID && r.UserID == userID {
+			return r, false, nil
+		}
+	}
+	req = OfflineRequest{
+		ID:          uuid.New(),
+		FileID:      rec.ID,
+		AreaID:      rec.AreaID,
+		Filename:    rec.Filename,
+		UserID:      userID,
+		Handle:      handle,
+		RequestedAt: time.Now(),
+	}
+	if err := fm.saveOfflineRequests(append(reqs, req)); err != nil {
+		return OfflineRequest{}, false, err
+	}
+	return req, true, nil
+}
+
+// ListOfflineRequests returns every queued request, oldest first.
+func (fm *FileManager) ListOfflineRequests() []OfflineRequest {
+	fm.muOffline.Lock()
+	defer fm.muOffline.Unlock()
+
+	reqs, _ := fm.loadOfflineRequests()
+	sort.SliceStable(reqs, func(i, j int) bool { return reqs[i].RequestedAt.Before(reqs[j].RequestedAt) })
+	return reqs
+}
+
+// RemoveOfflineRequests drops the requests with the given IDs from the
+// queue. Unknown IDs are ignored.
+func (fm *FileManager) RemoveOfflineRequests(ids ...uuid.UUID) error {
+	fm.muOffline.Lock()
+	defer fm.muOffline.Unlock()
+
+	reqs, err := fm.loadOfflineRequests()
+	if err != nil {
+		return err
+	}
+	drop := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+	kept := reqs[:0]
+	for _, r := range reqs {
+		if !drop[r.ID] {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(reqs) {
+		return nil
+	}
+	return fm.saveOfflineRequests(kept)
+}
+
+// SetFileOffline marks a record's file as offline or back online.
+func (fm *FileManager) SetFileOffline(fileID uuid.UUID, offline bool) error {
+	return fm.UpdateFileRecord(fileID, func(r *FileRecord) { r.Offline = offline })
+}
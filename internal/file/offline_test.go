@@ -0,0 +1,74 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetFilePath_OfflineAndRemovable(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "LOCAL", Name: "Local", Path: "local"},
+		{ID: 2, Tag: "CDROM", Name: "CD-ROM", Path: "cdrom", Removable: true},
+		{ID: 3, Tag: "SHELF", Name: "Shelf", Path: "shelf", Offline: true},
+	})
+	add := func(areaID int, name string, offline bool) uuid.UUID {
+		rec := FileRecord{ID: uuid.New(), AreaID: areaID, Filename: name, Offline: offline, UploadedAt: time.Now()}
+		if err := fm.AddFileRecord(rec); err != nil {
+			t.Fatal(err)
+		}
+		return rec.ID
+	}
+	os.MkdirAll(filepath.Join(fm.basePath, "cdrom"), 0755)
+	os.WriteFile(filepath.Join(fm.basePath, "cdrom", "HERE.ZIP"), []byte("x"), 0644)
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		offline bool
+	}{
+		{"missing local file", add(1, "GONE.ZIP", false), false}, // Not offline media; caller finds it missing
+		{"record marked offline", add(1, "SHELVED.ZIP", true), true},
+		{"present on removable", add(2, "HERE.ZIP", false), false},
+		{"missing on removable", add(2, "AWAY.ZIP", false), true},
+		{"offline area", add(3, "ANY.ZIP", false), true},
+	}
+	for _, tt := range tests {
+		_, err := fm.GetFilePath(tt.id)
+		if got := errors.Is(err, ErrFileOffline); got != tt.offline {
+			t.Errorf("%s: offline = %v (err %v), want %v", tt.name, got, err, tt.offline)
+		}
+	}
+}
+
+func TestOfflineRequests_QueueAndRemove(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{{ID: 1, Tag: "CDROM", Name: "CD-ROM", Path: "cdrom", Offline: true}})
+	rec := FileRecord{ID: uuid.New(), AreaID: 1, Filename: "GAME.ZIP"}
+
+	first, queued, err := fm.RequestOfflineFile(rec, 7, "Tester")
+	if err != nil || !queued {
+		t.Fatalf("RequestOfflineFile: queued=%v err=%v", queued, err)
+	}
+	again, queued, err := fm.RequestOfflineFile(rec, 7, "Tester")
+	if err != nil || queued || again.ID != first.ID {
+		t.Errorf("repeat request: %+v queued=%v err=%v", again, queued, err)
+	}
+	if _, queued, _ := fm.RequestOfflineFile(rec, 8, "Other"); !queued {
+		t.Error("second user's request was not queued")
+	}
+
+	reqs := fm.ListOfflineRequests()
+	if len(reqs) != 2 || reqs[0].Handle != "Tester" || reqs[0].Filename != "GAME.ZIP" {
+		t.Fatalf("unexpected requests %+v", reqs)
+	}
+	if err := fm.RemoveOfflineRequests(first.ID, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if reqs := fm.ListOfflineRequests(); len(reqs) != 1 || reqs[0].Handle != "Other" {
+		t.Errorf("unexpected requests after remove %+v", reqs)
+	}
+}
@@ -0,0 +1,397 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	partialsDirName   = ".partials"     // Per-user partial uploads, under the files base path
+	partialsIndexFile = "partials.json" // Index of the partials in one user's directory
+)
+
+// ErrPartialNotFound is returned when a user has no partial upload by the
+// requested name.
+var ErrPartialNotFound = errors.New("partial upload not found")
+
+// PartialUpload describes an interrupted upload kept so that the uploader
+// can resume it or a sysop can promote it to a real file.
+type PartialUpload struct {
+	UserID       int       `json:"userId"`
+	Handle       string    `json:"handle"`
+	AreaID       int       `json:"areaId"` // Area the upload was headed for
+	Filename     string    `json:"filename"`
+	Size         int64     `json:"size"`         // Bytes received so far
+	ExpectedSize int64     `json:"expectedSize"` // Size the sender announced; -1 when unknown
+	Protocol     string    `json:"protocol"`
+	SavedAt      time.Time `json:"savedAt"`
+}
+
+// Percent returns how much of the file arrived, or -1 when the full size
+// is unknown.
+func (p PartialUpload) Percent() int {
+	if p.ExpectedSize <= 0 {
+		return -1
+	}
+	return int(p.Size * 100 / p.ExpectedSize)
+}
+
+// PartialsPath returns the directory holding every user's partial uploads
+// under a files base path such as "data/files".
+func PartialsPath(filesPath string) string {
+	return filepath.Join(filesPath, partialsDirName)
+}
+
+func (fm *FileManager) userPartialsDir(userID int) string {
+	return filepath.Join(PartialsPath(fm.basePath), strconv.Itoa(userID))
+}
+
+// loadPartialIndex reads the partials index in dir. A missing index is empty.
+func loadPartialIndex(dir string) ([]PartialUpload, error) {
+	data, err := os.ReadFile(filepath.Join(dir, partialsIndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var partials []PartialUpload
+	if err := json.Unmarshal(data, &partials); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", partialsIndexFile, err)
+	}
+	return partials, nil
+}
+
+// savePartialIndex writes the partials index in dir, removing the directory
+// once it holds nothing else.
+func savePartialIndex(dir string, partials []PartialUpload) error {
+	indexPath := filepath.Join(dir, partialsIndexFile)
+	if len(partials) == 0 {
+		if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		_ = os.Remove(dir) // fails harmlessly if stray files remain
+		return nil
+	}
+	data, err := json.MarshalIndent(partials, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal partials index: %w", err)
+	}
+	return os.WriteFile(indexPath, data, 0644)
+}
+
+// SavePartial moves the incomplete file at srcPath into the uploader's
+// partials directory, replacing any earlier partial of the same name.
+func (fm *FileManager) SavePartial(p PartialUpload, srcPath string) error {
+	fm.muPartials.Lock()
+	defer fm.muPartials.Unlock()
+
+	name := filepath.Base(p.Filename)
+	if name != p.Filename || name == "." || name == ".." || name == partialsIndexFile {
+		return fmt.Errorf("invalid partial filename %q", p.Filename)
+	}
+	dir := fm.userPartialsDir(p.UserID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	partials, err := loadPartialIndex(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(srcPath, filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("failed to move partial %s: %w", name, err)
+	}
+
+	if p.SavedAt.IsZero() {
+		p.SavedAt = time.Now()
+	}
+	kept := partials[:0]
+	for _, old := range partials {
+		if !strings.EqualFold(old.Filename, name) {
+			kept = append(kept, old)
+			continue
+		}
+		// The new upload supersedes an earlier partial, whatever its case.
+		if old.Filename != name {
+			os.Remove(filepath.Join(dir, old.Filename))
+		}
+	}
+	return savePartialIndex(dir, append(kept, p))
+}
+
+// ListPartials returns a user's partial uploads, oldest first.
+func (fm *FileManager) ListPartials(userID int) []PartialUpload {
+	fm.muPartials.Lock()
+	defer fm.muPartials.Unlock()
+
+	partials, err := loadPartialIndex(fm.userPartialsDir(userID))
+	if err != nil {
+		log.Printf("ERROR: Failed to read partial uploads for user %d: %v", userID, err)
+		return nil
+	}
+	sort.Slice(partials, func(i, j int) bool { return partials[i].SavedAt.Before(partials[j].SavedAt) })
+	return partials
+}
+
+// ListAllPartials returns every user's partial uploads, oldest first.
+func (fm *FileManager) ListAllPartials() []PartialUpload {
+	fm.muPartials.Lock()
+	defer fm.muPartials.Unlock()
+
+	entries, err := os.ReadDir(PartialsPath(fm.basePath))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("ERROR: Failed to read partial uploads: %v", err)
+		}
+		return nil
+	}
+	var all []PartialUpload
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		partials, err := loadPartialIndex(filepath.Join(PartialsPath(fm.basePath), entry.Name()))
+		if err != nil {
+			log.Printf("ERROR: Failed to read partial uploads in %s: %v", entry.Name(), err)
+			continue
+		}
+		all = append(all, partials...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].SavedAt.Before(all[j].SavedAt) })
+	return all
+}
+
+// takePartialLocked drops a partial from its user's index and returns it
+// with the path of its data file. Caller must hold muPartials.
+func (fm *FileManager) takePartialLocked(userID int, name string) (PartialUpload, string, error) {
+	dir := fm.userPartialsDir(userID)
+	partials, err := loadPartialIndex(dir)
+	if err != nil {
+		return PartialUpload{}, "", err
+	}
+	for i, p := range partials {
+		if p.Filename != name {
+			continue
+		}
+		rest := append(partials[:i:i], partials[i+1:]...)
+		if err := savePartialIndex(dir, rest); err != nil {
+			return PartialUpload{}, "", err
+		}
+		return p, filepath.Join(dir, p.Filename), nil
+	}
+	return PartialUpload{}, "", ErrPartialNotFound
+}
+
+// ResumePartial moves a user's partial upload into destDir so a transfer
+// protocol with crash recovery can continue it. The partial leaves the
+// index; if the transfer is interrupted again the caller saves it anew.
+func (fm *FileManager) ResumePartial(userID int, name, destDir string) (PartialUpload, error) {
+	fm.muPartials.Lock()
+	defer fm.muPartials.Unlock()
+
+	p, path, err := fm.takePartialLocked(userID, name)
+	if err != nil {
+		return PartialUpload{}, err
+	}
+	if err := os.Rename(path, filepath.Join(destDir, p.Filename)); err != nil {
+		return PartialUpload{}, fmt.Errorf("failed to move partial %s: %w", p.Filename, err)
+	}
+	_ = os.Remove(fm.userPartialsDir(userID)) // succeeds only once empty
+	return p, nil
+}
+
+// DeletePartial removes a user's partial upload and its data.
+func (fm *FileManager) DeletePartial(userID int, name string) error {
+	fm.muPartials.Lock()
+	defer fm.muPartials.Unlock()
+
+	_, path, err := fm.takePartialLocked(userID, name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = os.Remove(fm.userPartialsDir(userID))
+	return nil
+}
+
+// PromotePartial turns a partial upload into a validated file in areaID,
+// credited to the original uploader. It is refused if the area already has
+// a file of that name or identical contents exist anywhere on the system.
+func (fm *FileManager) PromotePartial(userID int, name string, areaID int, description string) (FileRecord, error) {
+	targetDir, err := fm.GetAreaUploadPath(areaID)
+	if err != nil {
+		return FileRecord{}, err
+	}
+	for _, rec := range fm.GetFilesForArea(areaID) {
+		if strings.EqualFold(rec.Filename, name) {
+			return FileRecord{}, fmt.Errorf("%s already exists in that area", name)
+		}
+	}
+
+	fm.muPartials.Lock()
+	defer fm.muPartials.Unlock()
+
+	dir := fm.userPartialsDir(userID)
+	partials, err := loadPartialIndex(dir)
+	if err != nil {
+		return FileRecord{}, err
+	}
+	var p *PartialUpload
+	for i := range partials {
+		if partials[i].Filename == name {
+			p = &partials[i]
+			break
+		}
+	}
+	if p == nil {
+		return FileRecord{}, ErrPartialNotFound
+	}
+	srcPath := filepath.Join(dir, p.Filename)
+
+	hashes, err := HashFile(srcPath)
+	if err != nil {
+		return FileRecord{}, fmt.Errorf("failed to hash %s: %w", name, err)
+	}
+	if dup, found := fm.FindFileBySHA256(hashes.SHA256); found {
+		return FileRecord{}, fmt.Errorf("%s is identical to %s", name, dup.Filename)
+	}
+	fi, err := os.Stat(srcPath)
+	if err != nil {
+		return FileRecord{}, err
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return FileRecord{}, fmt.Errorf("failed to create %s: %w", targetDir, err)
+	}
+	finalPath := filepath.Join(targetDir, p.Filename)
+	if _, err := os.Stat(finalPath); err == nil {
+		return FileRecord{}, fmt.Errorf("%s is already on disk in that area", name)
+	}
+	if err := os.Rename(srcPath, finalPath); err != nil {
+		return FileRecord{}, fmt.Errorf("failed to move %s: %w", name, err)
+	}
+
+	if description == "" {
+		description = "No description"
+	}
+	record := FileRecord{
+		ID:          uuid.New(),
+		AreaID:      areaID,
+		Filename:    p.Filename,
+		Description: description,
+		Size:        fi.Size(),
+		UploadedAt:  p.SavedAt,
+		UploadedBy:  p.Handle,
+		SHA256:      hashes.SHA256,
+		CRC32:       hashes.CRC32,
+	}
+	if err := fm.AddFileRecord(record); err != nil {
+		if rbErr := os.Rename(finalPath, srcPath); rbErr != nil {
+			log.Printf("ERROR: Failed to restore partial %s after failed promotion: %v", name, rbErr)
+		}
+		return FileRecord{}, err
+	}
+
+	rest := make([]PartialUpload, 0, len(partials)-1)
+	for _, other := range partials {
+		if other.Filename != name {
+			rest = append(rest, other)
+		}
+	}
+	if err := savePartialIndex(dir, rest); err != nil {
+		log.Printf("ERROR: Failed to update partials index for user %d: %v", userID, err)
+	}
+	return record, nil
+}
+
+// PurgeStalePartials runs PurgeStalePartials on the manager's partials
+// under muPartials, so it cannot race a node saving or resuming one.
+func (fm *FileManager) PurgeStalePartials(maxAge time.Duration, dryRun bool) ([]PartialUpload, error) {
+	fm.muPartials.Lock()
+	defer fm.muPartials.Unlock()
+	return PurgeStalePartials(fm.basePath, maxAge, dryRun)
+}
+
+// PurgeStalePartials deletes partial uploads saved more than maxAge ago
+// from every user's directory under filesPath, along with stray files no
+// index mentions. With dryRun nothing is removed. It returns the partials
+// that were (or would be) purged. It takes no lock, so while the BBS is
+// running use the FileManager method (the purge_partials event) instead.
+func PurgeStalePartials(filesPath string, maxAge time.Duration, dryRun bool) ([]PartialUpload, error) {
+	root := PartialsPath(filesPath)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var purged []PartialUpload
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		partials, err := loadPartialIndex(dir)
+		if err != nil {
+			log.Printf("WARN: Skipping partials in %s: %v", dir, err)
+			continue
+		}
+
+		indexed := make(map[string]bool, len(partials))
+		var kept, stale []PartialUpload
+		for _, p := range partials {
+			if _, statErr := os.Stat(filepath.Join(dir, p.Filename)); statErr != nil || p.SavedAt.Before(cutoff) {
+				stale = append(stale, p)
+				continue
+			}
+			indexed[p.Filename] = true
+			kept = append(kept, p)
+		}
+		purged = append(purged, stale...)
+
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			log.Printf("WARN: Failed to read %s: %v", dir, err)
+			continue
+		}
+		var strays []string
+		for _, f := range files {
+			if f.Name() == partialsIndexFile || indexed[f.Name()] {
+				continue
+			}
+			info, err := f.Info()
+			if err != nil || info.ModTime().Before(cutoff) {
+				strays = append(strays, f.Name())
+			}
+		}
+
+		if dryRun {
+			continue
+		}
+		for _, p := range stale {
+			os.Remove(filepath.Join(dir, p.Filename))
+		}
+		for _, name := range strays {
+			os.RemoveAll(filepath.Join(dir, name))
+		}
+		if err := savePartialIndex(dir, kept); err != nil {
+			log.Printf("WARN: Failed to update %s: %v", filepath.Join(dir, partialsIndexFile), err)
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,133 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePartial creates an incoming file of the given contents and saves it
+// as a partial for user 7 in area 1.
+func writePartial(t *testing.T, fm *FileManager, name, contents string, savedAt time.Time) PartialUpload {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(src, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := PartialUpload{UserID: 7, Handle: "Tester", AreaID: 1, Filename: name, Size: int64(len(contents)), ExpectedSize: 100, Protocol: "Zmodem", SavedAt: savedAt}
+	if err := fm.SavePartial(p, src); err != nil {
+		t.Fatalf("SavePartial: %v", err)
+	}
+	return p
+}
+
+func TestSavePartial_ListsAndReplaces(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"}})
+
+	writePartial(t, fm, "GAME.ZIP", "first", time.Time{})
+	writePartial(t, fm, "GAME.ZIP", "second try", time.Time{})
+
+	partials := fm.ListPartials(7)
+	if len(partials) != 1 {
+		t.Fatalf("expected 1 partial, got %+v", partials)
+	}
+	if p := partials[0]; p.Size != 10 || p.SavedAt.IsZero() || p.Percent() != 10 {
+		t.Errorf("unexpected partial %+v", p)
+	}
+	data, err := os.ReadFile(filepath.Join(fm.basePath, ".partials", "7", "GAME.ZIP"))
+	if err != nil || string(data) != "second try" {
+		t.Errorf("partial data = %q, %v", data, err)
+	}
+	if len(fm.ListPartials(8)) != 0 || len(fm.ListAllPartials()) != 1 {
+		t.Error("partials leaked between users")
+	}
+}
+
+func TestResumePartial_MovesFileAndDropsEntry(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"}})
+	writePartial(t, fm, "GAME.ZIP", "half", time.Time{})
+
+	dest := t.TempDir()
+	if _, err := fm.ResumePartial(7, "GAME.ZIP", dest); err != nil {
+		t.Fatalf("ResumePartial: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "GAME.ZIP")); err != nil || string(data) != "half" {
+		t.Errorf("resumed data = %q, %v", data, err)
+	}
+	if len(fm.ListPartials(7)) != 0 {
+		t.Error("resumed partial still listed")
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, ".partials", "7")); !os.IsNotExist(err) {
+		t.Error("empty user partials directory was left behind")
+	}
+	if _, err := fm.ResumePartial(7, "GAME.ZIP", dest); !errors.Is(err, ErrPartialNotFound) {
+		t.Errorf("expected ErrPartialNotFound, got %v", err)
+	}
+}
+
+func TestPromotePartial_AddsValidatedRecord(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"},
+		{ID: 2, Tag: "GAMES", Name: "Games", Path: "games"},
+	})
+	writePartial(t, fm, "GAME.ZIP", "good enough", time.Now().Add(-time.Hour))
+	writePartial(t, fm, "COPY.ZIP", "good enough", time.Time{})
+
+	rec, err := fm.PromotePartial(7, "GAME.ZIP", 2, "")
+	if err != nil {
+		t.Fatalf("PromotePartial: %v", err)
+	}
+	if rec.AreaID != 2 || rec.UploadedBy != "Tester" || rec.Unvalidated || rec.Size != 11 || rec.SHA256 == "" || rec.Description != "No description" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if _, err := os.Stat(filepath.Join(fm.basePath, "games", "GAME.ZIP")); err != nil {
+		t.Errorf("promoted file not in area: %v", err)
+	}
+	if len(fm.GetFilesForArea(2)) != 1 {
+		t.Error("record not added to area")
+	}
+
+	// Identical contents are refused and the partial is kept.
+	if _, err := fm.PromotePartial(7, "COPY.ZIP", 1, "dup"); err == nil {
+		t.Error("expected duplicate contents to be refused")
+	}
+	if partials := fm.ListPartials(7); len(partials) != 1 || partials[0].Filename != "COPY.ZIP" {
+		t.Errorf("unexpected partials after promotion: %+v", partials)
+	}
+}
+
+func TestPurgeStalePartials(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"}})
+	writePartial(t, fm, "OLD.ZIP", "old", time.Now().Add(-10*24*time.Hour))
+	writePartial(t, fm, "NEW.ZIP", "new", time.Now())
+
+	stray := filepath.Join(fm.basePath, ".partials", "7", "STRAY.BIN")
+	if err := os.WriteFile(stray, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	os.Chtimes(stray, old, old)
+
+	purged, err := PurgeStalePartials(fm.basePath, 7*24*time.Hour, true)
+	if err != nil || len(purged) != 1 || purged[0].Filename != "OLD.ZIP" {
+		t.Fatalf("dry run purged %+v, %v", purged, err)
+	}
+	if len(fm.ListPartials(7)) != 2 {
+		t.Error("dry run removed partials")
+	}
+
+	if _, err := fm.PurgeStalePartials(7*24*time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+	partials := fm.ListPartials(7)
+	if len(partials) != 1 || partials[0].Filename != "NEW.ZIP" {
+		t.Errorf("unexpected partials after purge: %+v", partials)
+	}
+	for _, name := range []string{"OLD.ZIP", "STRAY.BIN"} {
+		if _, err := os.Stat(filepath.Join(fm.basePath, ".partials", "7", name)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", name)
+		}
+	}
+}
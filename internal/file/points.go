@@ -0,0 +1,46 @@
+package file
+
+import "math"
+
+// DownloadCostFor returns the file points charged for downloading rec from
+// this area: the flat DownloadCost plus one point per DownloadKBPerPoint KB
+// (rounded up). Records flagged Free always cost nothing.
+func (a *FileArea) DownloadCostFor(rec FileRecord) int {
+	if rec.Free {
+		return 0
+	}
+	cost := a.DownloadCost
+	if a.DownloadKBPerPoint > 0 && rec.Size > 0 {
+		kb := (rec.Size + 1023) / 1024
+		per := int64(a.DownloadKBPerPoint)
+		cost += int((kb + per - 1) / per)
+	}
+	if cost < 0 {
+		return 0
+	}
+	return cost
+}
+
+// UploadCreditFor returns the file points granted for uploading a file of
+// size bytes to this area. The base credit is one point per kbPerPoint KB
+// (rounded up, minimum one point), scaled by the area's UploadMultiplier.
+// A kbPerPoint of zero or less disables upload credit.
+func (a *FileArea) UploadCreditFor(size int64, kbPerPoint int) int {
+	if kbPerPoint <= 0 {
+		return 0
+	}
+	kb := (size + 1023) / 1024
+	per := int64(kbPerPoint)
+	base := (kb + per - 1) / per
+	if base < 1 {
+		base = 1
+	}
+	mult := a.UploadMultiplier
+	if mult == 0 {
+		mult = 1.0
+	}
+	if mult < 0 {
+		return 0
+	}
+	return int(math.Round(float64(base) * mult))
+}
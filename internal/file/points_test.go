@@ -0,0 +1,51 @@
+package file
+
+import "testing"
+
+func TestDownloadCostFor(t *testing.T) {
+	tests := []struct {
+		name string
+		area FileArea
+		rec  FileRecord
+		want int
+	}{
+		{"free area", FileArea{}, FileRecord{Size: 50000}, 0},
+		{"flat only", FileArea{DownloadCost: 3}, FileRecord{Size: 50000}, 3},
+		{"per KB rounds up", FileArea{DownloadKBPerPoint: 100}, FileRecord{Size: 150 * 1024}, 2},
+		{"partial KB counts", FileArea{DownloadKBPerPoint: 1}, FileRecord{Size: 1025}, 2},
+		{"flat plus per KB", FileArea{DownloadCost: 5, DownloadKBPerPoint: 100}, FileRecord{Size: 100 * 1024}, 6},
+		{"free file", FileArea{DownloadCost: 5, DownloadKBPerPoint: 1}, FileRecord{Size: 4096, Free: true}, 0},
+		{"empty file", FileArea{DownloadKBPerPoint: 10}, FileRecord{Size: 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.area.DownloadCostFor(tt.rec); got != tt.want {
+				t.Errorf("DownloadCostFor() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadCreditFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		area       FileArea
+		size       int64
+		kbPerPoint int
+		want       int
+	}{
+		{"disabled", FileArea{}, 500 * 1024, 0, 0},
+		{"minimum one point", FileArea{}, 10, 100, 1},
+		{"rounds up", FileArea{}, 250 * 1024, 100, 3},
+		{"multiplier", FileArea{UploadMultiplier: 2}, 250 * 1024, 100, 6},
+		{"fractional multiplier", FileArea{UploadMultiplier: 0.5}, 300 * 1024, 100, 2},
+		{"negative multiplier", FileArea{UploadMultiplier: -1}, 300 * 1024, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.area.UploadCreditFor(tt.size, tt.kbPerPoint); got != tt.want {
+				t.Errorf("UploadCreditFor() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const areaStatsFile = "area_stats.json" // Per-area transfer totals, under the files base path
+
+// AreaStats holds lifetime transfer totals for a file area.
+type AreaStats struct {
+	Uploads         int       `json:"uploads"`
+	UploadedBytes   int64     `json:"uploadedBytes"`
+	Downloads       int       `json:"downloads"`
+	DownloadedBytes int64     `json:"downloadedBytes"`
+	LastUpload      time.Time `json:"lastUpload,omitempty"`
+	LastDownload    time.Time `json:"lastDownload,omitempty"`
+}
+
+// statsLocked returns the per-area stats, loading them from disk the first
+// time. Caller must hold muStats.
+func (fm *FileManager) statsLocked() map[int]*AreaStats {
+	if fm.areaStats != nil {
+		return fm.areaStats
+	}
+	fm.areaStats = make(map[int]*AreaStats)
+
+	data, err := os.ReadFile(filepath.Join(fm.basePath, areaStatsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("ERROR: Failed to read %s: %v", areaStatsFile, err)
+		}
+		return fm.areaStats
+	}
+	if err := json.Unmarshal(data, &fm.areaStats); err != nil {
+		log.Printf("ERROR: Failed to parse %s: %v", areaStatsFile, err)
+		fm.areaStats = make(map[int]*AreaStats)
+	}
+	return fm.areaStats
+}
+
+// RecordAreaTransfer adds one transferred file of size bytes to an area's
+// totals. upload selects the upload or download side.
+func (fm *FileManager) RecordAreaTransfer(areaID int, upload bool, size int64) error {
+	fm.muStats.Lock()
+	defer fm.muStats.Unlock()
+
+	stats := fm.statsLocked()
+	st, ok := stats[areaID]
+	if !ok {
+		st = &AreaStats{}
+		stats[areaID] = st
+	}
+	if upload {
+		st.Uploads++
+		st.UploadedBytes += size
+		st.LastUpload = time.Now()
+	} else {
+		st.Downloads++
+		st.DownloadedBytes += size
+		st.LastDownload = time.Now()
+	}
+
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal area stats: %w", err)
+	}
+	if err := os.MkdirAll(fm.basePath, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", fm.basePath, err)
+	}
+	if err := os.WriteFile(filepath.Join(fm.basePath, areaStatsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", areaStatsFile, err)
+	}
+	return nil
+}
+
+// GetAreaStats returns the transfer totals for an area. Areas with no
+// transfers yet return zero totals.
+func (fm *FileManager) GetAreaStats(areaID int) AreaStats {
+	fm.muStats.Lock()
+	defer fm.muStats.Unlock()
+
+	if st, ok := fm.statsLocked()[areaID]; ok {
+		return *st
+	}
+	return AreaStats{}
+}
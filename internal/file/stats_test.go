@@ -0,0 +1,33 @@
+package file
+
+import "testing"
+
+func TestRecordAreaTransfer_AccumulatesAndPersists(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"},
+		{ID: 2, Tag: "GAMES", Name: "Games", Path: "games"},
+	})
+
+	if st := fm.GetAreaStats(1); st != (AreaStats{}) {
+		t.Fatalf("expected empty stats, got %+v", st)
+	}
+
+	fm.RecordAreaTransfer(1, true, 1000)
+	fm.RecordAreaTransfer(1, false, 1000)
+	fm.RecordAreaTransfer(1, false, 500)
+	fm.RecordAreaTransfer(2, false, 42)
+
+	st := fm.GetAreaStats(1)
+	if st.Uploads != 1 || st.UploadedBytes != 1000 || st.Downloads != 2 || st.DownloadedBytes != 1500 {
+		t.Errorf("unexpected stats for area 1: %+v", st)
+	}
+	if st.LastUpload.IsZero() || st.LastDownload.IsZero() {
+		t.Error("expected last transfer times to be set")
+	}
+
+	// Drop the cache and confirm the totals come back from disk.
+	fm.areaStats = nil
+	if st := fm.GetAreaStats(2); st.Downloads != 1 || st.DownloadedBytes != 42 {
+		t.Errorf("unexpected stats for area 2 after reload: %+v", st)
+	}
+}
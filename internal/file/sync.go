@@ -0,0 +1,151 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// syncSkipNames are files in an area directory that are never file records.
+var syncSkipNames = map[string]bool{
+	"metadata.json": true,
+	"files.bbs":     true,
+	"descript.ion":  true,
+	"thumbs.db":     true,
+	"desktop.ini":   true,
+}
+
+// AreaDirChanges is the difference between an area directory and its file
+// records, found by ScanAreaDir.
+type AreaDirChanges struct {
+	New      []string     // Files on disk with no record
+	Settling []string     // New files modified too recently to add yet
+	Resized  []FileRecord // Records whose file size differs on disk; Size holds the new size
+	Missing  []FileRecord // Records whose file is gone and that are not yet offline
+	Back     []FileRecord // Offline records whose file is present again; Size holds the size on disk
+	// Files whose names differ only in case from another file in the
+	// directory. File names are unique ignoring case, so none of them is
+	// added; a file already on record keeps its record.
+	Collisions []string
+}
+
+// Empty reports whether the scan found nothing to do.
+func (c AreaDirChanges) Empty() bool {
+	return len(c.New) == 0 && len(c.Settling) == 0 && len(c.Resized) == 0 && len(c.Missing) == 0 && len(c.Back) == 0 && len(c.Collisions) == 0
+}
+
+// SyncIgnored reports whether directory sync leaves name alone in this area:
+// hidden files, temporary files, file lists and metadata, and anything
+// matching one of the area's SyncIgnore patterns (case-insensitive).
+func (a FileArea) SyncIgnored(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(lower, ".tmp") || syncSkipNames[lower] {
+		return true
+	}
+	for _, pattern := range a.SyncIgnore {
+		if ok, _ := path.Match(strings.ToLower(pattern), lower); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanAreaDir compares an area's directory with its records. Files modified
+// within settle of now are reported as Settling rather than New, so files
+// still being copied in are not picked up half-written. Files missing from
+// a removable area are not reported, since GetFilePath already treats them
+// as offline.
+func (fm *FileManager) ScanAreaDir(areaID int, settle time.Duration) (AreaDirChanges, error) {
+	var changes AreaDirChanges
+
+	fm.muAreas.RLock()
+	areaPtr, ok := fm.fileAreas[areaID]
+	var area FileArea
+	if ok {
+		area = *areaPtr
+	}
+	fm.muAreas.RUnlock()
+	if !ok {
+		return changes, fmt.Errorf("file area %d not found", areaID)
+	}
+	if area.Offline {
+		return changes, nil
+	}
+
+	dir := filepath.Join(fm.basePath, area.Path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return changes, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	// Keyed by uppercase name; more than one entry is a case collision,
+	// possible only on a case-sensitive filesystem.
+	onDisk := make(map[string][]os.FileInfo, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || area.SyncIgnored(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		key := strings.ToUpper(entry.Name())
+		onDisk[key] = append(onDisk[key], info)
+	}
+
+	records := fm.GetFilesForArea(areaID)
+	for _, rec := range records {
+		name := filepath.Base(rec.Filename)
+		key := strings.ToUpper(name)
+		infos := onDisk[key]
+		delete(onDisk, key)
+		var info os.FileInfo
+		if len(infos) == 1 {
+			info = infos[0]
+		} else {
+			// The record keeps the file with its exact name; the others
+			// are reported.
+			for _, fi := range infos {
+				if fi.Name() == name {
+					info = fi
+				} else {
+					changes.Collisions = append(changes.Collisions, fi.Name())
+				}
+			}
+		}
+		present := info != nil
+		switch {
+		case !present && !rec.Offline && !area.Removable:
+			changes.Missing = append(changes.Missing, rec)
+		case present && rec.Offline:
+			rec.Size = info.Size()
+			changes.Back = append(changes.Back, rec)
+		case present && info.Size() != rec.Size:
+			rec.Size = info.Size()
+			changes.Resized = append(changes.Resized, rec)
+		}
+	}
+
+	cutoff := time.Now().Add(-settle)
+	for _, infos := range onDisk {
+		if len(infos) > 1 {
+			for _, info := range infos {
+				changes.Collisions = append(changes.Collisions, info.Name())
+			}
+			continue
+		}
+		info := infos[0]
+		if info.ModTime().After(cutoff) {
+			changes.Settling = append(changes.Settling, info.Name())
+		} else {
+			changes.New = append(changes.New, info.Name())
+		}
+	}
+	sort.Strings(changes.New)
+	sort.Strings(changes.Settling)
+	sort.Strings(changes.Collisions)
+	return changes, nil
+}
@@ -0,0 +1,98 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFileArea_SyncIgnored(t *testing.T) {
+	area := FileArea{SyncIgnore: []string{"*.part", "INCOMING*"}}
+	tests := []struct {
+		name   string
+		expect bool
+	}{
+		{"COOL.ZIP", false},
+		{"metadata.json", true},
+		{"FILES.BBS", true},
+		{"metadata.json.tmp", true},
+		{".hidden", true},
+		{"big.iso.PART", true},
+		{"incoming.txt", true},
+	}
+	for _, tc := range tests {
+		if got := area.SyncIgnored(tc.name); got != tc.expect {
+			t.Errorf("SyncIgnored(%q) = %v, want %v", tc.name, got, tc.expect)
+		}
+	}
+}
+
+func TestScanAreaDir(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils", Sync: true}})
+	dir := filepath.Join(fm.basePath, "utils")
+	old := time.Now().Add(-time.Hour)
+	write := func(name, contents string, mod time.Time) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add := func(name string, size int64, offline bool) {
+		t.Helper()
+		if err := fm.AddFileRecord(FileRecord{ID: uuid.New(), AreaID: 1, Filename: name, Size: size, Offline: offline}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("SAME.ZIP", "same", old)
+	add("SAME.ZIP", 4, false)
+	write("GREW.ZIP", "grown", old)
+	add("GREW.ZIP", 2, false)
+	add("GONE.ZIP", 4, false)
+	add("LOST.ZIP", 4, true)
+	write("back.zip", "back", old)
+	add("BACK.ZIP", 4, true)
+	write("NEW.ZIP", "new", old)
+	write("COPYING.ZIP", "half", time.Now())
+	// Names differing only in case, beside a record and on their own.
+	write("same.zip", "other", old)
+	write("DUP.TXT", "one", old)
+	write("dup.txt", "two", old)
+
+	changes, err := fm.ScanAreaDir(1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.New) != 1 || changes.New[0] != "NEW.ZIP" {
+		t.Errorf("New = %v", changes.New)
+	}
+	if len(changes.Settling) != 1 || changes.Settling[0] != "COPYING.ZIP" {
+		t.Errorf("Settling = %v", changes.Settling)
+	}
+	if len(changes.Resized) != 1 || changes.Resized[0].Filename != "GREW.ZIP" || changes.Resized[0].Size != 5 {
+		t.Errorf("Resized = %+v", changes.Resized)
+	}
+	if len(changes.Missing) != 1 || changes.Missing[0].Filename != "GONE.ZIP" {
+		t.Errorf("Missing = %+v", changes.Missing)
+	}
+	if len(changes.Back) != 1 || changes.Back[0].Filename != "BACK.ZIP" {
+		t.Errorf("Back = %+v", changes.Back)
+	}
+	if want := []string{"DUP.TXT", "dup.txt", "same.zip"}; !reflect.DeepEqual(changes.Collisions, want) {
+		t.Errorf("Collisions = %v, want %v", changes.Collisions, want)
+	}
+
+	// Removable media: absent files are expected, so nothing is missing.
+	fm.fileAreas[1].Removable = true
+	if changes, _ := fm.ScanAreaDir(1, time.Minute); len(changes.Missing) != 0 {
+		t.Errorf("Removable area reported missing files: %+v", changes.Missing)
+	}
+}
@@ -8,15 +8,33 @@ import (
 
 // FileArea defines a logical grouping or directory for files.
 type FileArea struct {
-	ID          int    `json:"id"`
-	Tag         string `json:"tag"`  // e.g., "UTILS", "TEXTS" (Unique, uppercase)
-	Name        string `json:"name"` // e.g., "Utility Programs"
-	Description string `json:"description"`
-	Path        string `json:"path"`         // Server filesystem path (relative to a base path, e.g., "utils")
-	ACSList     string `json:"acs_list"`     // ACS to list files in this area
-	ACSUpload   string `json:"acs_upload"`   // ACS to upload to this area
-	ACSDownload  string `json:"acs_download"`              // ACS to download from this area
+	ID           int    `json:"id"`
+	Tag          string `json:"tag"`  // e.g., "UTILS", "TEXTS" (Unique, uppercase)
+	Name         string `json:"name"` // e.g., "Utility Programs"
+	Description  string `json:"description"`
+	Path         string `json:"path"`                    // Server filesystem path (relative to a base path, e.g., "utils")
+	ACSList      string `json:"acs_list"`                // ACS to list files in this area
+	ACSUpload    string `json:"acs_upload"`              // ACS to upload to this area
+	ACSDownload  string `json:"acs_download"`            // ACS to download from this area
 	ConferenceID int    `json:"conference_id,omitempty"` // Conference this area belongs to (0=ungrouped)
+
+	// File point economy (see points.go). All zero = downloads are free.
+	DownloadCost       int     `json:"download_cost,omitempty"`         // Flat points charged per file downloaded
+	DownloadKBPerPoint int     `json:"download_kb_per_point,omitempty"` // Extra point per this many KB (0 = flat only)
+	UploadMultiplier   float64 `json:"upload_multiplier,omitempty"`     // Scales upload credit earned here (0 = 1.0)
+
+	RequireValidation bool `json:"require_validation,omitempty"` // Hold new uploads until a sysop validates them
+
+	// Offline media (see offline.go). Records stay listed while their files
+	// are absent, and downloads become offline requests for the sysop.
+	Offline   bool `json:"offline,omitempty"`   // Media is not available; no file in the area can be downloaded
+	Removable bool `json:"removable,omitempty"` // CD-ROM or other removable media; files may be missing at any time
+
+	// Directory sync (see sync.go). While the BBS runs, files copied into
+	// the area directory are added, resized ones updated and removed ones
+	// marked offline.
+	Sync       bool     `json:"sync,omitempty"`        // Watch the area directory and keep records in step with it
+	SyncIgnore []string `json:"sync_ignore,omitempty"` // Filename patterns (e.g. "*.part") sync never adds
 }
 
 // FileRecord holds metadata about a specific file within a FileArea.
@@ -29,6 +47,11 @@ type FileRecord struct {
 	UploadedAt    time.Time `json:"uploaded_at"`
 	UploadedBy    string    `json:"uploaded_by"` // User Handle
 	DownloadCount int       `json:"download_count"`
+	SHA256        string    `json:"sha256,omitempty"`         // Uppercase hex SHA-256 of the file contents
+	CRC32         string    `json:"crc32,omitempty"`          // Uppercase hex CRC-32 (FTN/TIC compatible)
+	Free          bool      `json:"free,omitempty"`           // Download costs no points and does not count against ratio
+	Unvalidated   bool      `json:"unvalidated,omitempty"`    // Awaiting sysop validation; hidden from other users
+	ZipLabResults string    `json:"ziplab_results,omitempty"` // Per-step ZipLab outcome recorded at upload
+	Offline       bool      `json:"offline,omitempty"`        // File is not on disk; downloads become offline requests
 	// TODO: Add []string Tags for keyword tagging later if needed
-	// TODO: Add hash?
 }
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VisibleTo reports whether r should be listed for the user with the given
+// handle. Unvalidated uploads are only shown to their uploader and to
+// privileged users (sysops), who see everything.
+func (r FileRecord) VisibleTo(handle string, privileged bool) bool {
+	if !r.Unvalidated || privileged {
+		return true
+	}
+	return handle != "" && strings.EqualFold(r.UploadedBy, handle)
+}
+
+// FilterVisible returns the records in records that are visible to handle.
+// The input slice is not modified.
+func FilterVisible(records []FileRecord, handle string, privileged bool) []FileRecord {
+	visible := make([]FileRecord, 0, len(records))
+	for _, r := range records {
+		if r.VisibleTo(handle, privileged) {
+			visible = append(visible, r)
+		}
+	}
+	return visible
+}
+
+// GetVisibleFileCountForArea returns the number of files in an area that
+// handle can see. See FileRecord.VisibleTo.
+func (fm *FileManager) GetVisibleFileCountForArea(areaID int, handle string, privileged bool) (int, error) {
+	fm.muFiles.RLock()
+	defer fm.muFiles.RUnlock()
+
+	count := 0
+	for _, r := range fm.fileRecords[areaID] {
+		if r.VisibleTo(handle, privileged) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetVisibleFilesForAreaPaginated is GetFilesForAreaPaginated restricted to
+// the files handle can see.
+func (fm *FileManager) GetVisibleFilesForAreaPaginated(areaID int, handle string, privileged bool, page int, pageSize int) ([]FileRecord, error) {
+	if privileged {
+		return fm.GetFilesForAreaPaginated(areaID, page, pageSize)
+	}
+	visible := FilterVisible(fm.GetFilesForArea(areaID), handle, privileged)
+
+	if page <= 0 || pageSize <= 0 {
+		return []FileRecord{}, fmt.Errorf("invalid page number or page size")
+	}
+	start := (page - 1) * pageSize
+	if start >= len(visible) {
+		return []FileRecord{}, nil
+	}
+	end := start + pageSize
+	if end > len(visible) {
+		end = len(visible)
+	}
+	return visible[start:end], nil
+}
+
+// ListUnvalidatedFiles returns every file awaiting validation across all
+// areas, oldest upload first.
+func (fm *FileManager) ListUnvalidatedFiles() []FileRecord {
+	fm.muFiles.RLock()
+	var pending []FileRecord
+	for _, records := range fm.fileRecords {
+		for _, r := range records {
+			if r.Unvalidated {
+				pending = append(pending, r)
+			}
+		}
+	}
+	fm.muFiles.RUnlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].UploadedAt.Before(pending[j].UploadedAt)
+	})
+	return pending
+}
@@ -0,0 +1,75 @@
+package file
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFileRecordVisibleTo(t *testing.T) {
+	rec := FileRecord{Filename: "NEW.ZIP", UploadedBy: "Uploader", Unvalidated: true}
+
+	if rec.VisibleTo("someone", false) {
+		t.Error("unvalidated file should be hidden from other users")
+	}
+	if !rec.VisibleTo("UPLOADER", false) {
+		t.Error("unvalidated file should be visible to its uploader")
+	}
+	if !rec.VisibleTo("someone", true) {
+		t.Error("unvalidated file should be visible to sysops")
+	}
+	if rec.VisibleTo("", false) {
+		t.Error("empty handle should not match")
+	}
+
+	rec.Unvalidated = false
+	if !rec.VisibleTo("someone", false) {
+		t.Error("validated file should be visible to everyone")
+	}
+}
+
+func TestVisibleListingAndPendingQueue(t *testing.T) {
+	fm := setupTestFileManager(t, []FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"},
+		{ID: 2, Tag: "GAMES", Name: "Games", Path: "games"},
+	})
+
+	now := time.Now()
+	records := []FileRecord{
+		{ID: uuid.New(), AreaID: 1, Filename: "OLD.ZIP", UploadedBy: "Alice", UploadedAt: now.Add(-2 * time.Hour)},
+		{ID: uuid.New(), AreaID: 1, Filename: "NEW.ZIP", UploadedBy: "Bob", UploadedAt: now, Unvalidated: true},
+		{ID: uuid.New(), AreaID: 2, Filename: "GAME.ZIP", UploadedBy: "Bob", UploadedAt: now.Add(-time.Hour), Unvalidated: true},
+	}
+	for _, r := range records {
+		if err := fm.AddFileRecord(r); err != nil {
+			t.Fatalf("AddFileRecord: %v", err)
+		}
+	}
+
+	if n, _ := fm.GetVisibleFileCountForArea(1, "Alice", false); n != 1 {
+		t.Errorf("Alice sees %d files in UTILS, want 1", n)
+	}
+	if n, _ := fm.GetVisibleFileCountForArea(1, "Bob", false); n != 2 {
+		t.Errorf("Bob sees %d files in UTILS, want 2", n)
+	}
+
+	page, err := fm.GetVisibleFilesForAreaPaginated(1, "Alice", false, 1, 10)
+	if err != nil {
+		t.Fatalf("GetVisibleFilesForAreaPaginated: %v", err)
+	}
+	if len(page) != 1 || page[0].Filename != "OLD.ZIP" {
+		t.Errorf("unexpected page for Alice: %+v", page)
+	}
+	if page, _ := fm.GetVisibleFilesForAreaPaginated(1, "Alice", false, 2, 10); len(page) != 0 {
+		t.Errorf("expected empty second page, got %d records", len(page))
+	}
+
+	pending := fm.ListUnvalidatedFiles()
+	if len(pending) != 2 {
+		t.Fatalf("ListUnvalidatedFiles returned %d records, want 2", len(pending))
+	}
+	if pending[0].Filename != "GAME.ZIP" || pending[1].Filename != "NEW.ZIP" {
+		t.Errorf("pending queue not oldest-first: %s, %s", pending[0].Filename, pending[1].Filename)
+	}
+}
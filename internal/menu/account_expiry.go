@@ -0,0 +1,91 @@
+package menu
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// CheckAccountExpiry runs at logon. An account past its expiry is moved
+// down to its expire-to level straight away, as the nightly expire_accounts
+// event would have done, and the user is told and sent a private notice.
+// An account expiring within expiryWarningDays gets a warning.
+func (e *MenuExecutor) CheckAccountExpiry(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, u *user.User, nodeNumber int, outputMode ansi.OutputMode) {
+	now := time.Now()
+	if u.Expired(now) {
+		levels := e.GetSecurityLevels()
+		res := u.ExpireAccount(levels)
+		if err := userManager.UpdateUser(u); err != nil {
+			log.Printf("ERROR: Node %d: Failed to save expired account %s: %v", nodeNumber, u.Handle, err)
+		} else {
+			log.Printf("INFO: Node %d: Account of %s expired; level %d -> %d", nodeNumber, u.Handle, res.FromLevel, res.ToLevel)
+			subject, body := res.Notice(levels, config.LoadTimezone(e.GetServerConfig().Timezone))
+			e.sendPrivateNotice("", u.Handle, subject, body, nodeNumber)
+		}
+
+		msg := "\r\n|12Your account has expired and your access has been reduced.|07\r\n" +
+			"|07Contact the SysOp to renew it.\r\n"
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		time.Sleep(2 * time.Second)
+		return
+	}
+
+	if msg, ok := e.expiryWarning(u, now); ok {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// ExpireAccounts is the expire_accounts event job. It moves every account
+// past its expiry down to its expire-to level and, once that is saved,
+// mails each user a private notice. Running it in the BBS keeps the change
+// in the live user list, which a separate process cannot do.
+func (e *MenuExecutor) ExpireAccounts(userManager *user.UserMgr) (string, error) {
+	levels := e.GetSecurityLevels()
+	// Accounts that were saved get their notice even if others failed.
+	expired, err := userManager.ExpireAccounts(levels, time.Now())
+	if err != nil {
+		err = fmt.Errorf("expire accounts: %w", err)
+	}
+	if len(expired) == 0 {
+		if err != nil {
+			return "", err
+		}
+		return "No accounts have expired.", nil
+	}
+
+	loc := config.LoadTimezone(e.GetServerConfig().Timezone)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Expired %d account(s):", len(expired))
+	for _, r := range expired {
+		fmt.Fprintf(&sb, " %s (%d -> %d)", r.Handle, r.FromLevel, r.ToLevel)
+		subject, body := r.Notice(levels, loc)
+		e.sendPrivateNotice("", r.Handle, subject, body, 0)
+	}
+	return sb.String(), err
+}
+
+// expiryWarning returns the logon warning for u when their account expires
+// within expiryWarningDays of now.
+func (e *MenuExecutor) expiryWarning(u *user.User, now time.Time) (string, bool) {
+	warnDays := e.GetServerConfig().ExpiryWarningDays
+	days, ok := u.DaysUntilExpiry(now)
+	if !ok || warnDays <= 0 || days > warnDays {
+		return "", false
+	}
+	when := u.ExpiresAt.In(config.LoadTimezone(e.GetServerConfig().Timezone)).Format("2006-01-02")
+	plural := "s"
+	if days == 1 {
+		plural = ""
+	}
+	return fmt.Sprintf("\r\n|14Your account expires in %d day%s (%s). Contact the SysOp to renew it.|07\r\n", days, plural, when), true
+}
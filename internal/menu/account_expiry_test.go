@@ -0,0 +1,67 @@
+package menu
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestExpiryWarning(t *testing.T) {
+	e := &MenuExecutor{ServerCfg: config.ServerConfig{ExpiryWarningDays: 7, Timezone: "UTC"}}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(days int) *time.Time { ts := now.AddDate(0, 0, days); return &ts }
+
+	if _, ok := e.expiryWarning(&user.User{}, now); ok {
+		t.Error("warned an account that never expires")
+	}
+	if _, ok := e.expiryWarning(&user.User{ExpiresAt: at(8)}, now); ok {
+		t.Error("warned 8 days out with a 7 day window")
+	}
+	msg, ok := e.expiryWarning(&user.User{ExpiresAt: at(3)}, now)
+	if !ok || !strings.Contains(msg, "3 days (2026-03-04)") {
+		t.Errorf("warning = %q, %v", msg, ok)
+	}
+	msg, ok = e.expiryWarning(&user.User{ExpiresAt: at(1)}, now)
+	if !ok || !strings.Contains(msg, "1 day (") {
+		t.Errorf("warning = %q, %v", msg, ok)
+	}
+
+	e.ServerCfg.ExpiryWarningDays = 0
+	if _, ok := e.expiryWarning(&user.User{ExpiresAt: at(1)}, now); ok {
+		t.Error("warned with expiryWarningDays 0")
+	}
+}
+
+func TestExpireAccounts_Event(t *testing.T) {
+	um, err := user.NewUserManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &MenuExecutor{ServerCfg: config.ServerConfig{Timezone: "UTC"}}
+
+	out, err := e.ExpireAccounts(um)
+	if err != nil || out != "No accounts have expired." {
+		t.Fatalf("nothing due: %q, %v", out, err)
+	}
+
+	u, _ := um.GetUser("felonius")
+	past := time.Now().Add(-time.Hour)
+	u.ExpiresAt = &past
+	u.ExpireToLevel = 10
+	from := u.AccessLevel
+	if err := um.UpdateUser(u); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = e.ExpireAccounts(um)
+	if err != nil || !strings.Contains(out, "Expired 1 account(s)") {
+		t.Fatalf("expire: %q, %v", out, err)
+	}
+	got, _ := um.GetUser("felonius")
+	if got.AccessLevel != 10 || got.ExpiresAt != nil {
+		t.Errorf("after expiry level=%d (was %d) expiresAt=%v", got.AccessLevel, from, got.ExpiresAt)
+	}
+}
@@ -362,15 +362,12 @@ func evaluateCondition(condition string, u *user.User, s ssh.Session, _ *term.Te
 			log.Printf("WARN: Invalid time left value in ACS condition '%s': %v", condition, err)
 			result = false
 		} else {
-			if u.TimeLimit <= 0 {
-				log.Printf("DEBUG: ACS 'T' check: User has no time limit (TimeLimit=%d), passing.", u.TimeLimit)
+			if left, limited := minutesLeft(s, u, startTime); !limited {
+				log.Printf("DEBUG: ACS 'T' check: User has no time limit, passing.")
 				result = true
 			} else {
-				elapsedSeconds := time.Since(startTime).Seconds()
-				timeLeftSeconds := float64(u.TimeLimit*60) - elapsedSeconds
-				thresholdSeconds := float64(minutesLeftThreshold * 60)
-				result = timeLeftSeconds >= thresholdSeconds
-				log.Printf("DEBUG: ACS 'T' check: Limit=%dm, Elapsed=%.fs, Left=%.fs, Threshold=%ds -> %t", u.TimeLimit, elapsedSeconds, timeLeftSeconds, int(thresholdSeconds), result)
+				result = left >= minutesLeftThreshold
+				log.Printf("DEBUG: ACS 'T' check: Left=%dm, Threshold=%dm -> %t", left, minutesLeftThreshold, result)
 			}
 		}
 	case "W": // Day of Week == value (0=Sun, 1=Mon, ... 6=Sat)
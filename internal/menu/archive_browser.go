@@ -0,0 +1,233 @@
+package menu
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+	"github.com/stlalpha/vision3/internal/util"
+	"github.com/stlalpha/vision3/internal/ziplab"
+)
+
+// textMemberExts are archive member extensions always shown as text.
+var textMemberExts = map[string]bool{
+	".txt": true, ".nfo": true, ".diz": true, ".ans": true, ".asc": true,
+	".doc": true, ".me": true, ".1st": true, ".now": true, ".lst": true,
+	".cfg": true, ".ini": true, ".bat": true, ".md": true, ".log": true,
+}
+
+// isViewableMember reports whether an archive member can be shown with the
+// text viewer. Known text extensions always qualify; anything else does if
+// its first bytes contain no NULs.
+func isViewableMember(name string, head []byte) bool {
+	if textMemberExts[strings.ToLower(filepath.Ext(name))] {
+		return true
+	}
+	return len(head) > 0 && bytes.IndexByte(head, 0) < 0
+}
+
+// browseArchive lists the members of an archive file and lets the user view
+// text members inline (D# downloads a single member instead). ZIP is read
+// natively; other formats are unpacked with the archivers.json commands.
+func (e *MenuExecutor) browseArchive(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, record *file.FileRecord, filePath string, outputMode ansi.OutputMode, nodeNumber int) {
+	zlCfg, err := ziplab.LoadConfig(e.RootConfigPath)
+	if err != nil {
+		log.Printf("WARN: Node %d: Failed to load ZipLab config: %v", nodeNumber, err)
+		zlCfg = ziplab.DefaultConfig()
+	}
+
+	contents, err := ziplab.OpenArchiveContents(filePath, zlCfg)
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to open archive %s: %v", nodeNumber, record.Filename, err)
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|01Error reading archive.|07\r\n")), outputMode)
+		pauseEnter(s, terminal, outputMode, e.LoadedStrings.FilePausePrompt)
+		return
+	}
+	defer contents.Close()
+
+	if len(contents.Members) == 0 {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|01Archive is empty.|07\r\n")), outputMode)
+		pauseEnter(s, terminal, outputMode, e.LoadedStrings.FilePausePrompt)
+		return
+	}
+
+	_, termHeight := getTerminalSize(s)
+	perPage := termHeight - 9
+	if perPage < 5 {
+		perPage = 5
+	}
+	page := 0
+	lastPage := (len(contents.Members) - 1) / perPage
+
+	for {
+		e.renderArchivePage(terminal, record.Filename, contents.Members, page, perPage, outputMode)
+
+		input, err := readLineFromSessionIHAllowAbort(s, terminal)
+		if err != nil {
+			return // ESC, disconnect or idle timeout all leave the browser
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(input))
+
+		switch {
+		case cmd == "Q":
+			return
+		case cmd == "" || cmd == "N":
+			if page < lastPage {
+				page++
+			} else {
+				page = 0
+			}
+		case cmd == "P":
+			if page > 0 {
+				page--
+			}
+		case strings.HasPrefix(cmd, "D"):
+			n, convErr := strconv.Atoi(strings.TrimSpace(cmd[1:]))
+			if convErr != nil || n < 1 || n > len(contents.Members) {
+				e.archiveNotice(terminal, fmt.Sprintf("|01Invalid selection. Enter D1-D%d.|07", len(contents.Members)), outputMode)
+				continue
+			}
+			e.sendArchiveMember(s, terminal, userManager, currentUser, record, contents, n, outputMode, nodeNumber)
+		default:
+			n, convErr := strconv.Atoi(cmd)
+			if convErr != nil || n < 1 || n > len(contents.Members) {
+				e.archiveNotice(terminal, fmt.Sprintf("|01Invalid selection. Enter 1-%d, D#, or Q.|07", len(contents.Members)), outputMode)
+				continue
+			}
+			e.viewArchiveMember(s, terminal, contents, n, outputMode, nodeNumber)
+		}
+	}
+}
+
+// renderArchivePage draws one page of the member listing.
+func (e *MenuExecutor) renderArchivePage(terminal *term.Terminal, filename string, members []ziplab.ArchiveMember, page, perPage int, outputMode ansi.OutputMode) {
+	var b strings.Builder
+	b.WriteString(ansi.ClearScreen())
+	b.WriteString(fmt.Sprintf("|15--- Archive Contents: %s ---|07\r\n\r\n", sanitizeControlChars(filename)))
+	b.WriteString("|14  #   Size       Date       Name|07\r\n")
+	b.WriteString("|08 ---  ---------  ---------- --------------------------------|07\r\n")
+
+	var total int64
+	for _, m := range members {
+		total += m.Size
+	}
+	start := page * perPage
+	end := start + perPage
+	if end > len(members) {
+		end = len(members)
+	}
+	for i := start; i < end; i++ {
+		m := members[i]
+		b.WriteString(fmt.Sprintf("|07 %3d  %9s  %s  |15%s|07\r\n", i+1, util.FormatFileSize(m.Size), m.Modified.Format("01/02/2006"), m.Name))
+	}
+
+	pages := (len(members) + perPage - 1) / perPage
+	b.WriteString(fmt.Sprintf("\r\n|07 %d file(s), %s total  |08(|07page %d of %d|08)\r\n", len(members), util.FormatFileSize(total), page+1, pages))
+	b.WriteString("\r\n|07[|15#|07]=View  [|15D#|07]=Download  [|15N|07/|15P|07]=Page  [|15Q|07]=Quit: |15")
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(b.String())), outputMode)
+}
+
+// archiveNotice shows a short message under the member listing.
+func (e *MenuExecutor) archiveNotice(terminal *term.Terminal, msg string, outputMode ansi.OutputMode) {
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n"+msg+"\r\n")), outputMode)
+	time.Sleep(1 * time.Second)
+}
+
+// viewArchiveMember extracts member n and shows it with the text viewer.
+func (e *MenuExecutor) viewArchiveMember(s ssh.Session, terminal *term.Terminal, contents *ziplab.ArchiveContents, n int, outputMode ansi.OutputMode, nodeNumber int) {
+	m := contents.Members[n-1]
+	path, cleanup, err := contents.ExtractMember(n)
+	if err != nil {
+		log.Printf("WARN: Node %d: Failed to extract %s for viewing: %v", nodeNumber, m.Name, err)
+		e.archiveNotice(terminal, "|01Extraction failed.|07", outputMode)
+		return
+	}
+	defer cleanup()
+
+	head := make([]byte, 1024)
+	if f, openErr := os.Open(path); openErr == nil {
+		nRead, _ := io.ReadFull(f, head)
+		head = head[:nRead]
+		f.Close()
+	}
+	if !isViewableMember(m.Name, head) {
+		e.archiveNotice(terminal, fmt.Sprintf("|01%s is not a text file. Use D%d to download it.|07", m.Name, n), outputMode)
+		return
+	}
+
+	_, termHeight := getTerminalSize(s)
+	displayTextWithPaging(s, terminal, path, m.Name, outputMode, termHeight,
+		e.LoadedStrings.FileViewingHeader, e.LoadedStrings.FileEndOfFile,
+		e.LoadedStrings.FileMorePrompt, e.LoadedStrings.FilePausePrompt,
+		e.LoadedStrings.FileOpenError)
+}
+
+// sendArchiveMember extracts member n and sends it with the protocol the
+// user picks. The download is charged like the parent file, but priced on
+// the member's size, and does not bump the parent's download count.
+func (e *MenuExecutor) sendArchiveMember(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, record *file.FileRecord, contents *ziplab.ArchiveContents, n int, outputMode ansi.OutputMode, nodeNumber int) {
+	if currentUser == nil {
+		return
+	}
+	m := contents.Members[n-1]
+
+	priced := *record
+	priced.Size = m.Size
+	ch := e.downloadChargeFor(priced)
+	ch.filename = record.Filename + "/" + m.Name
+	counted := 1
+	if ch.free {
+		counted = 0
+	}
+	if denyMsg := checkDownloadAllowance(e.downloadConfigFor(currentUser), e.LoadedStrings, currentUser, ch.cost, counted); denyMsg != "" {
+		log.Printf("INFO: Node %d: Member download by %s denied (cost %d, points %d)", nodeNumber, currentUser.Handle, ch.cost, currentUser.FilePoints)
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n"+denyMsg+"|07\r\n")), outputMode)
+		time.Sleep(2 * time.Second)
+		return
+	}
+
+	proto, protoOK, protoErr := e.selectTransferProtocol(s, terminal, outputMode)
+	if protoErr != nil {
+		if !errors.Is(protoErr, io.EOF) {
+			log.Printf("ERROR: Node %d: Protocol selection error: %v", nodeNumber, protoErr)
+			e.archiveNotice(terminal, "|01Error: No transfer protocols configured on this system.|07", outputMode)
+		}
+		return
+	}
+	if !protoOK {
+		return
+	}
+
+	path, cleanup, err := contents.ExtractMember(n)
+	if err != nil {
+		log.Printf("WARN: Node %d: Failed to extract %s for download: %v", nodeNumber, m.Name, err)
+		e.archiveNotice(terminal, "|01Extraction failed.|07", outputMode)
+		return
+	}
+	defer cleanup()
+
+	log.Printf("INFO: Node %d: %s downloading member %s of %s", nodeNumber, currentUser.Handle, m.Name, record.Filename)
+	sent, _ := e.runTransferSend(s, terminal, userManager, currentUser, proto, []string{path}, []uuid.UUID{uuid.Nil}, outputMode, nodeNumber, nil)
+	if sent > 0 && userManager != nil {
+		e.applyDownloadCharge(userManager, currentUser, ch, nodeNumber)
+		if err := userManager.UpdateUser(currentUser); err != nil {
+			log.Printf("ERROR: Node %d: Failed to save user after member download: %v", nodeNumber, err)
+		}
+	}
+	time.Sleep(1 * time.Second)
+}
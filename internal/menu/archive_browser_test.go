@@ -0,0 +1,23 @@
+package menu
+
+import "testing"
+
+func TestIsViewableMember(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want bool
+	}{
+		{"README.TXT", []byte{0x00, 0x01}, true}, // known text extension wins
+		{"FILE_ID.DIZ", nil, true},
+		{"logo.ANS", []byte("\x1b[0m"), true},
+		{"Makefile", []byte("all: build\n"), true},
+		{"PROGRAM.EXE", []byte("MZ\x90\x00\x03"), false},
+		{"EMPTY", nil, false},
+	}
+	for _, tc := range tests {
+		if got := isViewableMember(tc.name, tc.head); got != tc.want {
+			t.Errorf("isViewableMember(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -655,6 +655,7 @@ func runOpenDoor(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userMa
 			doorConfig, upperInput)
 
 		resetSessionIH(s)
+		doorStart := time.Now()
 		cmdErr := executeDoor(ctx)
 		_ = getSessionIH(s)
 
@@ -663,6 +664,7 @@ func runOpenDoor(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userMa
 			doorErrorMessage(ctx, fmt.Sprintf("Error running door '%s': %v", upperInput, cmdErr))
 		} else {
 			log.Printf("INFO: Node %d: Door completed for user %s, door %s", nodeNumber, currentUser.Handle, upperInput)
+			e.logActivity(userManager, currentUser, nodeNumber, user.ActivityRecord{Kind: user.ActivityDoor, Name: doorConfig.Name, Duration: time.Since(doorStart)})
 		}
 
 		return currentUser, "", nil
@@ -30,6 +30,9 @@ func buildDoorCtx(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 	if remainingMinutes < 0 {
 		remainingMinutes = 0
 	}
+	if clock := sessionClock(s); clock != nil && clock.Limited() {
+		remainingMinutes = int(clock.Left(time.Now()) / time.Minute)
+	}
 	timeLeftStr := strconv.Itoa(remainingMinutes)
 	baudStr := "38400"
 	userIDStr := strconv.Itoa(userID)
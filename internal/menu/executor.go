@@ -21,11 +21,10 @@ import (
 	"time"
 	"unicode/utf8"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"github.com/gliderlabs/ssh"
 	"github.com/google/uuid"
 	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/ban"
 	"github.com/stlalpha/vision3/internal/chat"
 	"github.com/stlalpha/vision3/internal/conference"
 	"github.com/stlalpha/vision3/internal/config"
@@ -63,6 +62,9 @@ func getSessionIH(s ssh.Session) *editor.InputHandler {
 		return v.(*editor.InputHandler)
 	}
 	ih := editor.NewInputHandler(s)
+	if clock := sessionClock(s); clock != nil {
+		ih.SetSessionDeadline(clock.Deadline())
+	}
 	sessionInputHandlers.Store(s, ih)
 	return ih
 }
@@ -221,10 +223,13 @@ type MenuExecutor struct {
 	FileMgr         *file.FileManager             // <-- ADDED FIELD: File manager instance
 	ConferenceMgr   *conference.ConferenceManager // Conference grouping manager
 	IPLockoutCheck  IPLockoutChecker              // IP-based authentication lockout checker
+	Bans            *ban.List                     // Dynamic IP bans (nil = disabled)
 	LoginSequence   []config.LoginItem            // Configurable login sequence from login.json
 	SessionRegistry *session.SessionRegistry      // Session registry for who's online
 	ChatRoom        *chat.ChatRoom                // Global teleconference chat room
 	Protocols       []transfer.ProtocolConfig     // Loaded transfer protocol configurations
+	SecurityLevels  []config.SecurityLevel        // Security level profiles from levels.json
+	InfoForms       []config.InfoForm             // Sysop-defined questionnaires from infoforms.json
 	configMu        sync.RWMutex                  // Mutex for thread-safe config updates
 }
 
@@ -288,6 +293,41 @@ func (e *MenuExecutor) GetLoginSequence() []config.LoginItem {
 	return e.LoginSequence
 }
 
+// SetSecurityLevels atomically updates the security level profiles.
+func (e *MenuExecutor) SetSecurityLevels(levels []config.SecurityLevel) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.SecurityLevels = levels
+}
+
+// GetSecurityLevels atomically retrieves all security level profiles.
+func (e *MenuExecutor) GetSecurityLevels() []config.SecurityLevel {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	return e.SecurityLevels
+}
+
+// GetSecurityLevel atomically retrieves the profile defined for level.
+func (e *MenuExecutor) GetSecurityLevel(level int) (config.SecurityLevel, bool) {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	return config.FindSecurityLevel(e.SecurityLevels, level)
+}
+
+// SetInfoForms atomically updates the infoforms.
+func (e *MenuExecutor) SetInfoForms(forms []config.InfoForm) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.InfoForms = forms
+}
+
+// GetInfoForms atomically retrieves the infoforms.
+func (e *MenuExecutor) GetInfoForms() []config.InfoForm {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+	return e.InfoForms
+}
+
 // SetStrings atomically updates the strings configuration.
 func (e *MenuExecutor) SetStrings(strings config.StringsConfig) {
 	e.configMu.Lock()
@@ -369,7 +409,12 @@ func (e *MenuExecutor) isCoSysOpOrAbove(u *user.User) bool {
 // handleIdleTimeout displays TIMEOUT.ANS (if available) or falls back to the
 // idle timeout string, then logs the disconnection. Call this before returning
 // LOGOFF/DISCONNECT whenever ErrIdleTimeout is received from any input loop.
-func (e *MenuExecutor) handleIdleTimeout(terminal *term.Terminal, outputMode ansi.OutputMode, nodeNumber int, termHeight int) {
+func (e *MenuExecutor) handleIdleTimeout(s ssh.Session, terminal *term.Terminal, outputMode ansi.OutputMode, nodeNumber int, termHeight int) {
+	// Running out of call time also surfaces as ErrIdleTimeout; the session
+	// loop announces that itself.
+	if clock := sessionClock(s); clock != nil && clock.Expired(time.Now()) {
+		return
+	}
 	// Try to display TIMEOUT.ANS first.
 	ansPath := filepath.Join(e.MenuSetPath, "ansi", "TIMEOUT.ANS")
 	if rawContent, err := ansi.GetAnsiFileContent(ansPath); err == nil {
@@ -499,6 +544,7 @@ func registerPlaceholderRunnables(registry map[string]RunnableFunc) { // Use loc
 		// Doors read directly from ssh.Session; reset shared InputHandler first
 		// so it does not race and steal door/menu keystrokes.
 		resetSessionIH(s)
+		doorStart := time.Now()
 		cmdErr := executeDoor(ctx)
 		_ = getSessionIH(s)
 
@@ -507,6 +553,7 @@ func registerPlaceholderRunnables(registry map[string]RunnableFunc) { // Use loc
 			doorErrorMessage(ctx, fmt.Sprintf("Error running external program '%s': %v", doorName, cmdErr))
 		} else {
 			log.Printf("INFO: Node %d: Door completed for user %s, door %s", nodeNumber, currentUser.Handle, doorName)
+			e.logActivity(userManager, currentUser, nodeNumber, user.ActivityRecord{Kind: user.ActivityDoor, Name: doorConfig.Name, Duration: time.Since(doorStart)})
 		}
 
 		return nil, "", nil
@@ -521,6 +568,7 @@ func registerAppRunnables(registry map[string]RunnableFunc) { // Use local Runna
 	registry["SHOWSTATS"] = runShowStats
 	registry["SYSTEMSTATS"] = runSystemStats
 	registry["LASTCALLERS"] = runLastCallers
+	registry["TOPTEN"] = runTopTen
 	registry["AUTHENTICATE"] = runAuthenticate
 	registry["ONELINER"] = runOneliners                              // Register new placeholder
 	registry["FULL_LOGIN_SEQUENCE"] = runFullLoginSequence           // Register the new sequence
@@ -567,9 +615,16 @@ func registerAppRunnables(registry map[string]RunnableFunc) { // Use local Runna
 	registry["OPENDOOR"] = runOpenDoor                               // Prompt and open a door
 	registry["DOORINFO"] = runDoorInfo                               // Show door information
 	registry["UPLOADFILE"] = runUploadFile                           // ZMODEM file upload
+	registry["EDITFILERECORD"] = runEditFileRecord                   // SysOp: file record editor
+	registry["VALIDATEFILES"] = runValidateFiles                     // SysOp: upload validation queue
+	registry["TRANSFERLOG"] = runTransferLog                         // SysOp: transfer log and area totals
+	registry["PARTIALUPLOADS"] = runPartialUploads                   // SysOp: interrupted upload queue
+	registry["WANTLIST"] = runWantList                               // Want list / file request board
+	registry["OFFLINEREQUESTS"] = runOfflineRequests                 // SysOp: offline file request queue
 	registry["QWKDOWNLOAD"] = runQWKDownload                         // QWK mail packet download
 	registry["QWKUPLOAD"] = runQWKUpload                             // QWK REP packet upload
 	registry["WHOISONLINE"] = runWhoIsOnline                         // Who's online display
+	registry["TIMEBANK"] = runTimeBank                               // Time bank deposits and withdrawals
 	registry["CFG_HOTKEYS"] = runCfgHotKeys
 	registry["CFG_MOREPROMPTS"] = runCfgMorePrompts
 	registry["CFG_SCREENWIDTH"] = runCfgScreenWidth
@@ -583,6 +638,8 @@ func registerAppRunnables(registry map[string]RunnableFunc) { // Use local Runna
 	registry["CFG_PASSWORD"] = runCfgPassword
 	registry["CFG_FILELISTMODE"] = runCfgFileListMode
 	registry["CFG_AUTOSIG"] = runCfgAutoSig
+	registry["CFG_SSHKEYS"] = runCfgSSHKeys
+	registry["CFG_2FA"] = runCfgTwoFactor
 	registry["CFG_VIEWCONFIG"] = runCfgViewConfig
 	registry["CHAT"] = runChat
 	registry["PAGE"] = runPage
@@ -593,6 +650,12 @@ func registerAppRunnables(registry map[string]RunnableFunc) { // Use local Runna
 	registry["EDITNEWS"] = runEditNews               // SysOp: news management (Add/Delete/Edit/List/View)
 	registry["VOTE"] = runVote                       // Voting booths system
 	registry["VOTEMANDATORY"] = runVoteOnMandatory   // Mandatory voting check (login sequence)
+	registry["INFOFORMS"] = runInfoForms             // Fill in, re-answer or view infoforms
+	registry["CHECKINFOFORMS"] = runCheckInfoForms   // Required infoforms not yet answered (login sequence)
+	registry["LEAVEFEEDBACK"] = runLeaveFeedback     // Write feedback to the sysop
+	registry["READFEEDBACK"] = runReadFeedback       // Sysop feedback reader (reply, forward, delete)
+	registry["CHECKFEEDBACK"] = runCheckFeedback     // Feedback waiting notice for sysops (login sequence)
+	registry["IPBANS"] = runIPBans                   // Sysop IP ban manager (list, add, lift)
 	registry["LISTNUV"] = runNUVList                 // List NUV candidates and vote tallies
 	registry["SCANNUV"] = runNUVScan                 // Vote on pending NUV candidates
 	registry["BBSLIST"] = runBBSList                 // List BBS directory entries
@@ -677,20 +740,16 @@ func runShowStats(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 		"|UN": currentUser.PrivateNote,
 		"|UL": strconv.Itoa(currentUser.AccessLevel),
 		"|FL": strconv.Itoa(currentUser.AccessLevel),
-		"|UK": strconv.Itoa(currentUser.NumUploads),
+		"|UK": strconv.FormatInt(currentUser.UploadedBytes/1024, 10),
 		"|NU": strconv.Itoa(currentUser.NumUploads),
-		"|DK": "0", "|ND": "0", "|TP": "0", "|NM": "0", "|LC": "N/A",
+		"|DK": strconv.FormatInt(currentUser.DownloadedBytes/1024, 10),
+		"|ND": strconv.Itoa(currentUser.NumDownloads),
+		"|TP": "0", "|NM": "0", "|LC": "N/A",
 	}
-	if currentUser.TimeLimit <= 0 {
+	if remainingMinutes, limited := minutesLeft(s, currentUser, sessionStartTime); !limited {
 		placeholders["|TL"] = "Unlimited"
 	} else {
-		elapsedSeconds := time.Since(sessionStartTime).Seconds()
-		totalSeconds := float64(currentUser.TimeLimit * 60)
-		remainingSeconds := totalSeconds - elapsedSeconds
-		if remainingSeconds < 0 {
-			remainingSeconds = 0
-		}
-		placeholders["|TL"] = strconv.Itoa(int(remainingSeconds / 60))
+		placeholders["|TL"] = strconv.Itoa(remainingMinutes)
 	}
 
 	// Branch based on output mode to preserve encoding correctness
@@ -1466,7 +1525,26 @@ func runAuthenticate(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 
 	// Attempt Authentication via UserManager
 	log.Printf("DEBUG: Node %d: Attempting authentication for user: %s from IP: %s", nodeNumber, username, remoteIP)
-	authUser, authenticated := userManager.Authenticate(username, password)
+	authUser, authenticated := userManager.CheckPassword(username, password)
+	usedResetCode := false
+	if !authenticated {
+		// A sysop-issued reset code stands in for the password once. It is
+		// only used up after the second factor and level checks pass.
+		authUser, authenticated = userManager.CheckResetCode(username, password)
+		usedResetCode = authenticated
+	}
+	if authenticated {
+		// Second factor, if the account has one or its level requires one
+		terminalio.WriteProcessedBytes(terminal, []byte(ansi.MoveCursor(errorRow, 1)), outputMode)
+		passed, err := e.checkSecondFactor(s, terminal, userManager, authUser, nodeNumber, outputMode, termWidth, termHeight)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			log.Printf("ERROR: Node %d: Two-factor check failed: %v", nodeNumber, err)
+		}
+		authenticated = passed
+	}
 	if !authenticated {
 		log.Printf("WARN: Node %d: Failed authentication attempt for user: %s from IP: %s", nodeNumber, username, remoteIP)
 
@@ -1508,6 +1586,20 @@ func runAuthenticate(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 		return nil, "", nil // Insufficient level, treat as failed login
 	}
 
+	// Every check passed; use up the reset code, then record the login
+	if usedResetCode {
+		if _, ok := userManager.RedeemResetCode(authUser.Username, password); !ok {
+			log.Printf("WARN: Node %d: Reset code for %s could not be used", nodeNumber, authUser.Username)
+			terminalio.WriteProcessedBytes(terminal, []byte(ansi.MoveCursor(errorRow, 1)), outputMode)
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(e.LoadedStrings.ExecLoginIncorrect)), outputMode)
+			time.Sleep(1 * time.Second)
+			return nil, "", nil
+		}
+	}
+	if recorded, ok := userManager.RecordLogin(authUser.Username); ok {
+		authUser = recorded
+	}
+
 	// Authentication Successful!
 	log.Printf("INFO: Node %d: User '%s' (Handle: %s) authenticated successfully via RUN:AUTHENTICATE", nodeNumber, authUser.Username, authUser.Handle)
 
@@ -2065,7 +2157,7 @@ func (e *MenuExecutor) Run(s ssh.Session, terminal *term.Terminal, userManager *
 								return "LOGOFF", nil, nil
 							}
 							if errors.Is(err, editor.ErrIdleTimeout) {
-								e.handleIdleTimeout(terminal, outputMode, nodeNumber, termHeight)
+								e.handleIdleTimeout(s, terminal, outputMode, nodeNumber, termHeight)
 								return "LOGOFF", nil, nil
 							}
 							log.Printf("ERROR: Failed to read lightbar input for menu %s: %v", currentMenuName, err)
@@ -2155,10 +2247,11 @@ func (e *MenuExecutor) Run(s ssh.Session, terminal *term.Terminal, userManager *
 
 			if !isLightbarMenu || userInput == "" {
 				// Fallback to standard input if lightbar loading failed or no valid selection made
+				e.warnTimeLeft(s, terminal, outputMode)
 				e.deliverPendingPages(terminal, nodeNumber, outputMode)
 				// Display Prompt (Skip if USEPROMPT is false)
 				if menuRec.GetUsePrompt() { // Condition changed: Only check UsePrompt
-					err = e.displayPrompt(terminal, menuRec, currentUser, userManager, nodeNumber, currentMenuName, sessionStartTime, outputMode, currentAreaName) // Pass currentAreaName
+					err = e.displayPrompt(s, terminal, menuRec, currentUser, userManager, nodeNumber, currentMenuName, sessionStartTime, outputMode, currentAreaName) // Pass currentAreaName
 					if err != nil {
 						return "", nil, err // Propagate the error
 					}
@@ -2182,12 +2275,13 @@ func (e *MenuExecutor) Run(s ssh.Session, terminal *term.Terminal, userManager *
 			}
 		} else {
 			// --- Standard Menu Input Handling ---
+			e.warnTimeLeft(s, terminal, outputMode)
 			e.deliverPendingPages(terminal, nodeNumber, outputMode)
 			// Display Prompt (Skip if USEPROMPT is false)
 			log.Printf("DEBUG: Checking prompt display for menu: %s. UsePrompt=%t", currentMenuName, menuRec.GetUsePrompt())
 			if menuRec.GetUsePrompt() { // Condition changed: Only check UsePrompt
 				log.Printf("DEBUG: Calling displayPrompt for menu: %s", currentMenuName)
-				err = e.displayPrompt(terminal, menuRec, currentUser, userManager, nodeNumber, currentMenuName, sessionStartTime, outputMode, currentAreaName) // Pass currentAreaName
+				err = e.displayPrompt(s, terminal, menuRec, currentUser, userManager, nodeNumber, currentMenuName, sessionStartTime, outputMode, currentAreaName) // Pass currentAreaName
 				log.Printf("DEBUG: Returned from displayPrompt for menu: %s. Error: %v", currentMenuName, err)
 				if err != nil {
 					return "", nil, err // Propagate the error
@@ -2205,7 +2299,7 @@ func (e *MenuExecutor) Run(s ssh.Session, terminal *term.Terminal, userManager *
 					return "LOGOFF", nil, nil
 				}
 				if errors.Is(err, editor.ErrIdleTimeout) {
-					e.handleIdleTimeout(terminal, outputMode, nodeNumber, termHeight)
+					e.handleIdleTimeout(s, terminal, outputMode, nodeNumber, termHeight)
 					return "LOGOFF", nil, nil
 				}
 				log.Printf("ERROR: Failed to read input for menu %s: %v", currentMenuName, err)
@@ -2485,7 +2579,26 @@ func (e *MenuExecutor) handleLoginPrompt(s ssh.Session, terminal *term.Terminal,
 
 	// Attempt Authentication via UserManager
 	log.Printf("DEBUG: Node %d: Attempting authentication for user: %s from IP: %s", nodeNumber, username, remoteIP)
-	authUser, authenticated := userManager.Authenticate(username, password)
+	authUser, authenticated := userManager.CheckPassword(username, password)
+	usedResetCode := false
+	if !authenticated {
+		// A sysop-issued reset code stands in for the password once. It is
+		// only used up after the second factor and level checks pass.
+		authUser, authenticated = userManager.CheckResetCode(username, password)
+		usedResetCode = authenticated
+	}
+	if authenticated {
+		// Second factor, if the account has one or its level requires one
+		terminalio.WriteProcessedBytes(terminal, []byte(ansi.MoveCursor(errorRow, 1)), outputMode)
+		passed, err := e.checkSecondFactor(s, terminal, userManager, authUser, nodeNumber, outputMode, termWidth, termHeight)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			log.Printf("ERROR: Node %d: Two-factor check failed: %v", nodeNumber, err)
+		}
+		authenticated = passed
+	}
 	if !authenticated {
 		log.Printf("WARN: Node %d: Failed authentication attempt for user: %s from IP: %s", nodeNumber, username, remoteIP)
 
@@ -2526,6 +2639,20 @@ func (e *MenuExecutor) handleLoginPrompt(s ssh.Session, terminal *term.Terminal,
 		return nil, nil // Insufficient level, treat as failed login
 	}
 
+	// Every check passed; use up the reset code, then record the login
+	if usedResetCode {
+		if _, ok := userManager.RedeemResetCode(authUser.Username, password); !ok {
+			log.Printf("WARN: Node %d: Reset code for %s could not be used", nodeNumber, authUser.Username)
+			terminalio.WriteProcessedBytes(terminal, []byte(ansi.MoveCursor(errorRow, 1)), outputMode)
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(e.LoadedStrings.ExecLoginIncorrect)), outputMode)
+			time.Sleep(1 * time.Second)
+			return nil, nil
+		}
+	}
+	if recorded, ok := userManager.RecordLogin(authUser.Username); ok {
+		authUser = recorded
+	}
+
 	log.Printf("INFO: Node %d: User '%s' (Handle: %s) authenticated successfully via LOGIN prompt", nodeNumber, authUser.Username, authUser.Handle)
 
 	// Clear failed login attempts for this IP
@@ -2610,7 +2737,7 @@ func (e *MenuExecutor) executeCommandAction(action string, s ssh.Session, termin
 					return "LOGOFF", "", nil, nil
 				}
 				if errors.Is(runErr, editor.ErrIdleTimeout) {
-					e.handleIdleTimeout(terminal, outputMode, nodeNumber, termHeight)
+					e.handleIdleTimeout(s, terminal, outputMode, nodeNumber, termHeight)
 					return "LOGOFF", "", nil, nil
 				}
 				log.Printf("ERROR: RUN:%s function failed: %v", runTarget, runErr)
@@ -3062,7 +3189,11 @@ func lastCallerATTokenValue(code string, record user.CallRecord, totalUsers int,
 		return " ", true
 	case "TO":
 		return strconv.Itoa(int(record.Duration.Minutes())), true
-	case "MP", "MR", "DL", "UL", "ES", "FS":
+	case "DL":
+		return strconv.Itoa(record.Downloads), true
+	case "UL":
+		return strconv.Itoa(record.Uploads), true
+	case "MP", "MR", "ES", "FS":
 		return "0", true
 	case "DK":
 		return strconv.Itoa(int(record.DownloadedMB * 1024.0)), true
@@ -3391,7 +3522,7 @@ func (e *MenuExecutor) deliverPendingPages(terminal *term.Terminal, nodeNumber i
 
 // displayPrompt handles rendering the menu prompt, including file includes and placeholder substitution.
 // Added currentAreaName parameter
-func (e *MenuExecutor) displayPrompt(terminal *term.Terminal, menu *MenuRecord, currentUser *user.User, userManager *user.UserMgr, nodeNumber int, currentMenuName string, sessionStartTime time.Time, outputMode ansi.OutputMode, currentAreaName string) error {
+func (e *MenuExecutor) displayPrompt(s ssh.Session, terminal *term.Terminal, menu *MenuRecord, currentUser *user.User, userManager *user.UserMgr, nodeNumber int, currentMenuName string, sessionStartTime time.Time, outputMode ansi.OutputMode, currentAreaName string) error {
 	promptParts := make([]string, 0, 2)
 	if strings.TrimSpace(menu.Prompt1) != "" {
 		promptParts = append(promptParts, menu.Prompt1)
@@ -3488,16 +3619,9 @@ func (e *MenuExecutor) displayPrompt(terminal *term.Terminal, menu *MenuRecord,
 		}
 
 		// Calculate Time Left |TL
-		if currentUser.TimeLimit <= 0 {
+		if remainingMinutes, limited := minutesLeft(s, currentUser, sessionStartTime); !limited {
 			placeholders["|TL"] = "Unlimited"
 		} else {
-			elapsedSeconds := time.Since(sessionStartTime).Seconds()
-			totalSeconds := float64(currentUser.TimeLimit * 60)
-			remainingSeconds := totalSeconds - elapsedSeconds
-			if remainingSeconds < 0 {
-				remainingSeconds = 0
-			}
-			remainingMinutes := int(remainingSeconds / 60)
 			placeholders["|TL"] = strconv.Itoa(remainingMinutes)
 		}
 
@@ -3846,7 +3970,7 @@ func runFastLogin(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 					return nil, "LOGOFF", io.EOF
 				}
 				if errors.Is(readErr, editor.ErrIdleTimeout) {
-					e.handleIdleTimeout(terminal, outputMode, nodeNumber, termHeight)
+					e.handleIdleTimeout(s, terminal, outputMode, nodeNumber, termHeight)
 					return currentUser, "LOGOFF", nil
 				}
 				return currentUser, "", readErr
@@ -3910,7 +4034,7 @@ func runFastLogin(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 				return nil, "LOGOFF", io.EOF
 			}
 			if errors.Is(readErr, editor.ErrIdleTimeout) {
-				e.handleIdleTimeout(terminal, outputMode, nodeNumber, termHeight)
+				e.handleIdleTimeout(s, terminal, outputMode, nodeNumber, termHeight)
 				return currentUser, "LOGOFF", nil
 			}
 			return currentUser, "", readErr
@@ -3951,6 +4075,13 @@ func (e *MenuExecutor) loginPausePrompt(s ssh.Session, terminal *term.Terminal,
 // RunLoginSequence is the exported entry point for running the login sequence from main.go.
 // Returns the next menu name to enter (e.g., "MAIN") or "LOGOFF".
 func (e *MenuExecutor) RunLoginSequence(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, outputMode ansi.OutputMode, termWidth int, termHeight int) (string, error) {
+	// A forced password change comes before anything else
+	if changed, err := e.requirePasswordChange(s, terminal, userManager, currentUser, nodeNumber, outputMode, termWidth, termHeight); !changed {
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Printf("ERROR: Node %d: Password change at login failed: %v", nodeNumber, err)
+		}
+		return "LOGOFF", err
+	}
 	_, nextAction, err := runFullLoginSequence(e, s, terminal, userManager, currentUser, nodeNumber, sessionStartTime, "", outputMode, termWidth, termHeight)
 	if err != nil {
 		return "LOGOFF", err
@@ -3974,19 +4105,21 @@ func runFullLoginSequence(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 	type loginHandler func(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error)
 
 	handlers := map[string]loginHandler{
-		"LASTCALLS":     runLastCallers,
-		"ONELINERS":     runOneliners,
-		"USERSTATS":     runShowStats,
-		"NMAILSCAN":     runNewMailScan,
-		"DISPLAYFILE":   runLoginDisplayFile,
-		"RUNDOOR":       runLoginDoor,
-		"FASTLOGIN":     runFastLogin,
-		"NEWUSERVAL":    runNewUserValidation,
-		"WHOISONLINE":   runLoginWhosOnline,
-		"PRINTNEWS":     runPrintNews,
-		"VOTEMANDATORY": runVoteOnMandatory,
-		"CHECKNUV":      runCheckNUV,
-		"RANDOMRUMOR":   runRandomRumor,
+		"LASTCALLS":      runLastCallers,
+		"ONELINERS":      runOneliners,
+		"USERSTATS":      runShowStats,
+		"NMAILSCAN":      runNewMailScan,
+		"DISPLAYFILE":    runLoginDisplayFile,
+		"RUNDOOR":        runLoginDoor,
+		"FASTLOGIN":      runFastLogin,
+		"NEWUSERVAL":     runNewUserValidation,
+		"WHOISONLINE":    runLoginWhosOnline,
+		"PRINTNEWS":      runPrintNews,
+		"VOTEMANDATORY":  runVoteOnMandatory,
+		"CHECKNUV":       runCheckNUV,
+		"CHECKINFOFORMS": runCheckInfoForms,
+		"CHECKFEEDBACK":  runCheckFeedback,
+		"RANDOMRUMOR":    runRandomRumor,
 	}
 
 	for i, item := range loginSequence {
@@ -4933,6 +5066,73 @@ func adminDate(t time.Time) string {
 	return t.Format("2006-01-02")
 }
 
+// adminExpiry is an account's expiry as staged in the user editor: when it
+// expires (nil = never) and the level it then drops to.
+type adminExpiry struct {
+	at      *time.Time
+	toLevel int
+}
+
+func (x adminExpiry) String() string {
+	if x.at == nil {
+		return "Never"
+	}
+	return fmt.Sprintf("%s to %d", adminDate(*x.at), x.toLevel)
+}
+
+func (x adminExpiry) equal(y adminExpiry) bool {
+	if x.at == nil || y.at == nil {
+		return x.at == nil && y.at == nil
+	}
+	return x.at.Equal(*y.at) && x.toLevel == y.toLevel
+}
+
+// stageAdminExpiry asks for the date u's account expires and the level it
+// then drops to, and stages them as pendingChanges["expires"]. A blank date
+// means it never expires. Returns the status line to show.
+func (e *MenuExecutor) stageAdminExpiry(u *user.User, pendingChanges map[string]interface{}, readFieldInput func(fieldLabel string, currentValue string, maxLen int) (string, error)) string {
+	loc := config.LoadTimezone(e.GetServerConfig().Timezone)
+	current := adminExpiry{u.ExpiresAt, u.ExpireToLevel}
+	dateStr := ""
+	levelStr := ""
+	if u.ExpiresAt != nil {
+		dateStr = u.ExpiresAt.In(loc).Format("2006-01-02")
+		levelStr = strconv.Itoa(u.ExpireToLevel)
+	}
+	newVal, editErr := readFieldInput("Expires (YYYY-MM-DD)", dateStr, 10)
+	if editErr != nil {
+		if editErr.Error() != "cancelled" {
+			return fmt.Sprintf("|01Error: %v|07", editErr)
+		}
+		return ""
+	}
+	staged := adminExpiry{}
+	if newVal = strings.TrimSpace(newVal); newVal != "" {
+		at, parseErr := time.ParseInLocation("2006-01-02", newVal, loc)
+		if parseErr != nil {
+			return "|01Invalid date, use YYYY-MM-DD.|07"
+		}
+		levelVal, levelErr := readFieldInput("Drop To Level", levelStr, 3)
+		if levelErr != nil {
+			if levelErr.Error() != "cancelled" {
+				return fmt.Sprintf("|01Error: %v|07", levelErr)
+			}
+			return ""
+		}
+		level, parseErr := strconv.Atoi(strings.TrimSpace(levelVal))
+		if parseErr != nil || level < 0 {
+			return "|01Invalid number.|07"
+		}
+		staged = adminExpiry{&at, level}
+	}
+	if staged.equal(current) {
+		delete(pendingChanges, "expires")
+		return "|08No change.|07"
+	}
+	pendingChanges["expires"] = staged
+	return "|10Field marked for update.|07"
+}
+
 func adminUserLightbarBrowser(s ssh.Session, terminal *term.Terminal, users []*user.User, title string, instruction string, outputMode ansi.OutputMode, selectOnEnter bool) (*user.User, bool, error) {
 	if len(users) == 0 {
 		return nil, false, nil
@@ -5188,7 +5388,7 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 				deleteLabel = "Un-Delete"
 				deleteColor = "|10" // Green for un-delete
 			}
-			barText = fmt.Sprintf("|08[|15H|08] %s%s  |08[|15P|08] |14Change PW  |08[|150|08] %s%s  |08[|159|08] %s%s  |08[|15Q|08] |11Quit|07", validateColor, validateLabel, banColor, banLabel, deleteColor, deleteLabel)
+			barText = fmt.Sprintf("|08[|15H|08] %s%s  |08[|15P|08] |14Set PW  |08[|15R|08] |14Reset  |08[|150|08] %s%s  |08[|159|08] %s%s  |08[|15Q|08] |11Quit|07", validateColor, validateLabel, banColor, banLabel, deleteColor, deleteLabel)
 		}
 		if err := clearRow(actionRow); err != nil {
 			return err
@@ -5268,6 +5468,13 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 			return fmt.Sprintf("%t", originalValue)
 		}
 
+		getExpiryValue := func(originalValue adminExpiry) string {
+			if val, ok := pendingChanges["expires"]; ok {
+				return fmt.Sprintf("|14*|03%s|07", val.(adminExpiry))
+			}
+			return originalValue.String()
+		}
+
 		// Calculate visual display width (excluding pipe codes)
 		visualWidth := func(s string) int {
 			width := 0
@@ -5300,11 +5507,10 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 		}
 
 		deletedStatus := "No"
-		deletedAtStr := ""
 		if sel.DeletedUser {
 			deletedStatus = "Yes"
 			if sel.DeletedAt != nil {
-				deletedAtStr = adminTime(*sel.DeletedAt)
+				deletedStatus += " " + adminTime(*sel.DeletedAt)
 			}
 		}
 
@@ -5320,10 +5526,10 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 			lineTwoCol("|08[|14B|08]|11 Real Name", getFieldValue("realname", sel.RealName), "|11Uploads", fmt.Sprintf("%d", sel.NumUploads)),
 			lineTwoCol("|08[|14C|08]|11 Phone", getFieldValue("phone", sel.PhoneNumber), "|11FilePoints", fmt.Sprintf("%d", sel.FilePoints)),
 			lineTwoCol("|08[|14D|08]|11 Group/Loc", getFieldValue("grouploc", sel.GroupLocation), "|11Posts", fmt.Sprintf("%d", sel.MessagesPosted)),
-			lineTwoCol("|08[|14E|08]|11 Note", getFieldValue("note", sel.PrivateNote), "|11Created", adminTime(sel.CreatedAt)),
+			lineTwoCol("|08[|14E|08]|11 Note", getFieldValue("note", sel.PrivateNote), "|08[|14I|08]|11 Expires", getExpiryValue(adminExpiry{sel.ExpiresAt, sel.ExpireToLevel})),
 			lineTwoCol("|08[|14F|08]|11 Flags", getFieldValue("flags", sel.Flags), "|11Last Login", adminTime(sel.LastLogin)),
 			lineTwoCol("|08[|14G|08]|11 Level", getIntFieldValue("level", sel.AccessLevel), "|11Deleted", deletedStatus),
-			lineTwoCol("|08[|14H|08]|11 Validated", getBoolFieldValue("validated", sel.Validated), "|11Deleted At", deletedAtStr),
+			lineTwoCol("|08[|14H|08]|11 Validated", getBoolFieldValue("validated", sel.Validated), "|08[|14M|08]|11 Must Chg PW", getBoolFieldValue("mustchange", sel.MustChangePassword)),
 		}
 		for i, line := range lines {
 			if err := writeAt(detailStartRow+i, 1, line); err != nil {
@@ -5509,6 +5715,7 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 						}
 					}
 				}
+				oldLevel := target.AccessLevel
 				if val, ok := pendingChanges["username"]; ok {
 					target.Username = val.(string)
 				}
@@ -5527,8 +5734,9 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 				if val, ok := pendingChanges["flags"]; ok {
 					target.Flags = val.(string)
 				}
+				newLevel := target.AccessLevel
 				if val, ok := pendingChanges["level"]; ok {
-					target.AccessLevel = val.(int)
+					newLevel = val.(int)
 				}
 				if val, ok := pendingChanges["validated"]; ok {
 					target.Validated = val.(bool)
@@ -5541,11 +5749,15 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 						if desiredLevel <= 0 {
 							desiredLevel = 10
 						}
-						if target.AccessLevel < desiredLevel {
-							target.AccessLevel = desiredLevel
+						if newLevel < desiredLevel {
+							newLevel = desiredLevel
 						}
 					}
 				}
+				// setUserLevel needs the old level to take its flags away
+				if newLevel != oldLevel {
+					e.setUserLevel(target, newLevel)
+				}
 				if val, ok := pendingChanges["deleted"]; ok {
 					target.DeletedUser = val.(bool)
 					if target.DeletedUser {
@@ -5557,15 +5769,31 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 					}
 				}
 				if val, ok := pendingChanges["password"]; ok {
-					// Hash the new password using bcrypt
+					// Set the new password under the password policy. A
+					// password set by the sysop is temporary: the user must
+					// change it at their next login unless M says otherwise.
 					newPassword := val.(string)
-					hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-					if hashErr != nil {
-						statusMessage = fmt.Sprintf("|01Failed to hash password: %v|07", hashErr)
+					if pwErr := userManager.PasswordPolicy().SetPassword(target, newPassword); pwErr != nil {
+						statusMessage = fmt.Sprintf("|01Password not set: %v|07", pwErr)
 						refresh = true
 						continue
 					}
-					target.PasswordHash = string(hashedPassword)
+					target.MustChangePassword = true
+				}
+				if val, ok := pendingChanges["mustchange"]; ok {
+					target.MustChangePassword = val.(bool)
+				}
+				if val, ok := pendingChanges["expires"]; ok {
+					x := val.(adminExpiry)
+					target.ExpiresAt = x.at
+					target.ExpireToLevel = x.toLevel
+				}
+				if _, ok := pendingChanges["clear2fa"]; ok {
+					// Lost device: the user logs in with their password
+					// alone and can set two-factor login up again.
+					target.TOTPSecret = ""
+					target.TOTPRecoveryCodes = nil
+					target.TOTPLastStep = 0
 				}
 
 				// Update timestamp for optimistic locking
@@ -5600,6 +5828,13 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 							oldValue = fmt.Sprintf("%t", currentUserData.Validated)
 						case "deleted":
 							oldValue = fmt.Sprintf("%t", currentUserData.DeletedUser)
+						case "mustchange":
+							oldValue = fmt.Sprintf("%t", currentUserData.MustChangePassword)
+						case "expires":
+							oldValue = adminExpiry{currentUserData.ExpiresAt, currentUserData.ExpireToLevel}.String()
+						case "clear2fa":
+							oldValue = "on"
+							newValue = "off"
 						case "password":
 							// Don't log actual password values for security
 							oldValue = "********"
@@ -5808,6 +6043,54 @@ func runAdminListUsers(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 				}
 				refresh = true
 			}
+		case 'm', 'M':
+			// Toggle the forced password change at next login
+			sel := users[selectedIndex]
+			if _, ok := pendingChanges["mustchange"]; ok {
+				delete(pendingChanges, "mustchange")
+				statusMessage = "|08No change.|07"
+			} else {
+				pendingChanges["mustchange"] = !sel.MustChangePassword
+				if !sel.MustChangePassword {
+					statusMessage = "|11Password change at next login marked for update.|07"
+				} else {
+					statusMessage = "|10Clearing forced password change marked for update.|07"
+				}
+			}
+			refresh = true
+		case 'i', 'I':
+			statusMessage = e.stageAdminExpiry(users[selectedIndex], pendingChanges, readFieldInput)
+			refresh = true
+		case 't', 'T':
+			// Turn off two-factor login for a user who lost their device
+			sel := users[selectedIndex]
+			if _, ok := pendingChanges["clear2fa"]; ok {
+				delete(pendingChanges, "clear2fa")
+				statusMessage = "|08No change.|07"
+			} else if !sel.TOTPEnabled() {
+				statusMessage = fmt.Sprintf("|08%s does not use two-factor login.|07", sel.Handle)
+			} else {
+				pendingChanges["clear2fa"] = true
+				statusMessage = "|11Turning off two-factor login marked for update.|07"
+			}
+			refresh = true
+		case 'r', 'R':
+			// Issue a one-time reset code to read to a locked-out user
+			if len(pendingChanges) > 0 {
+				statusMessage = "|11Save or abort pending changes first.|07"
+			} else {
+				sel := users[selectedIndex]
+				code, expires, codeErr := userManager.IssueResetCode(sel.Username)
+				if codeErr != nil {
+					statusMessage = fmt.Sprintf("|01Failed to issue reset code: %v|07", codeErr)
+				} else {
+					logEntry := user.AdminActivityLogEntry(currentUser.Handle, currentUser.ID, sel.ID, sel.Handle, "resetcode", "", "issued")
+					_ = userManager.LogAdminActivity(logEntry)
+					statusMessage = fmt.Sprintf("|10Reset code for %s: |15%s |08(until %s)|07", sel.Handle, code, adminTime(expires))
+					users = sortedUsersByID(userManager.GetAllUsers())
+				}
+			}
+			refresh = true
 		case '0':
 			// Toggle ban user (sets level 0, unvalidated) or unban (restore to regular level)
 			sel := users[selectedIndex]
@@ -6087,7 +6370,7 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 				deleteLabel = "Un-Delete"
 				deleteColor = "|10" // Green for un-delete
 			}
-			barText = fmt.Sprintf("|08[|15H|08] %s%s  |08[|15P|08] |14Change PW  |08[|150|08] %s%s  |08[|159|08] %s%s  |08[|15Q|08] |11Quit|07", validateColor, validateLabel, banColor, banLabel, deleteColor, deleteLabel)
+			barText = fmt.Sprintf("|08[|15H|08] %s%s  |08[|15P|08] |14Set PW  |08[|15R|08] |14Reset  |08[|150|08] %s%s  |08[|159|08] %s%s  |08[|15Q|08] |11Quit|07", validateColor, validateLabel, banColor, banLabel, deleteColor, deleteLabel)
 		}
 		if err := clearRow(actionRow); err != nil {
 			return err
@@ -6165,6 +6448,13 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 			return fmt.Sprintf("%t", originalValue)
 		}
 
+		getExpiryValue := func(originalValue adminExpiry) string {
+			if val, ok := pendingChanges["expires"]; ok {
+				return fmt.Sprintf("|14*|03%s|07", val.(adminExpiry))
+			}
+			return originalValue.String()
+		}
+
 		// Calculate visual display width (excluding pipe codes)
 		visualWidth := func(s string) int {
 			width := 0
@@ -6197,11 +6487,10 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 		}
 
 		deletedStatus := "No"
-		deletedAtStr := ""
 		if sel.DeletedUser {
 			deletedStatus = "Yes"
 			if sel.DeletedAt != nil {
-				deletedAtStr = adminTime(*sel.DeletedAt)
+				deletedStatus += " " + adminTime(*sel.DeletedAt)
 			}
 		}
 
@@ -6217,10 +6506,10 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 			lineTwoCol("|08[|14B|08]|11 Real Name", getFieldValue("realname", sel.RealName), "|11Uploads", fmt.Sprintf("%d", sel.NumUploads)),
 			lineTwoCol("|08[|14C|08]|11 Phone", getFieldValue("phone", sel.PhoneNumber), "|11FilePoints", fmt.Sprintf("%d", sel.FilePoints)),
 			lineTwoCol("|08[|14D|08]|11 Group/Loc", getFieldValue("grouploc", sel.GroupLocation), "|11Posts", fmt.Sprintf("%d", sel.MessagesPosted)),
-			lineTwoCol("|08[|14E|08]|11 Note", getFieldValue("note", sel.PrivateNote), "|11Created", adminTime(sel.CreatedAt)),
+			lineTwoCol("|08[|14E|08]|11 Note", getFieldValue("note", sel.PrivateNote), "|08[|14I|08]|11 Expires", getExpiryValue(adminExpiry{sel.ExpiresAt, sel.ExpireToLevel})),
 			lineTwoCol("|08[|14F|08]|11 Flags", getFieldValue("flags", sel.Flags), "|11Last Login", adminTime(sel.LastLogin)),
 			lineTwoCol("|08[|14G|08]|11 Level", getIntFieldValue("level", sel.AccessLevel), "|11Deleted", deletedStatus),
-			lineTwoCol("|08[|14H|08]|11 Validated", getBoolFieldValue("validated", sel.Validated), "|11Deleted At", deletedAtStr),
+			lineTwoCol("|08[|14H|08]|11 Validated", getBoolFieldValue("validated", sel.Validated), "|08[|14M|08]|11 Must Chg PW", getBoolFieldValue("mustchange", sel.MustChangePassword)),
 		}
 		for i, line := range lines {
 			if err := writeAt(detailStartRow+i, 1, line); err != nil {
@@ -6406,6 +6695,7 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 						}
 					}
 				}
+				oldLevel := target.AccessLevel
 				if val, ok := pendingChanges["username"]; ok {
 					target.Username = val.(string)
 				}
@@ -6424,8 +6714,9 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 				if val, ok := pendingChanges["flags"]; ok {
 					target.Flags = val.(string)
 				}
+				newLevel := target.AccessLevel
 				if val, ok := pendingChanges["level"]; ok {
-					target.AccessLevel = val.(int)
+					newLevel = val.(int)
 				}
 				if val, ok := pendingChanges["validated"]; ok {
 					target.Validated = val.(bool)
@@ -6438,11 +6729,15 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 						if desiredLevel <= 0 {
 							desiredLevel = 10
 						}
-						if target.AccessLevel < desiredLevel {
-							target.AccessLevel = desiredLevel
+						if newLevel < desiredLevel {
+							newLevel = desiredLevel
 						}
 					}
 				}
+				// setUserLevel needs the old level to take its flags away
+				if newLevel != oldLevel {
+					e.setUserLevel(target, newLevel)
+				}
 				if val, ok := pendingChanges["deleted"]; ok {
 					target.DeletedUser = val.(bool)
 					if target.DeletedUser {
@@ -6454,15 +6749,31 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 					}
 				}
 				if val, ok := pendingChanges["password"]; ok {
-					// Hash the new password using bcrypt
+					// Set the new password under the password policy. A
+					// password set by the sysop is temporary: the user must
+					// change it at their next login unless M says otherwise.
 					newPassword := val.(string)
-					hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-					if hashErr != nil {
-						statusMessage = fmt.Sprintf("|01Failed to hash password: %v|07", hashErr)
+					if pwErr := userManager.PasswordPolicy().SetPassword(target, newPassword); pwErr != nil {
+						statusMessage = fmt.Sprintf("|01Password not set: %v|07", pwErr)
 						refresh = true
 						continue
 					}
-					target.PasswordHash = string(hashedPassword)
+					target.MustChangePassword = true
+				}
+				if val, ok := pendingChanges["mustchange"]; ok {
+					target.MustChangePassword = val.(bool)
+				}
+				if val, ok := pendingChanges["expires"]; ok {
+					x := val.(adminExpiry)
+					target.ExpiresAt = x.at
+					target.ExpireToLevel = x.toLevel
+				}
+				if _, ok := pendingChanges["clear2fa"]; ok {
+					// Lost device: the user logs in with their password
+					// alone and can set two-factor login up again.
+					target.TOTPSecret = ""
+					target.TOTPRecoveryCodes = nil
+					target.TOTPLastStep = 0
 				}
 
 				// Update timestamp for optimistic locking
@@ -6497,6 +6808,13 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 							oldValue = fmt.Sprintf("%t", currentUserData.Validated)
 						case "deleted":
 							oldValue = fmt.Sprintf("%t", currentUserData.DeletedUser)
+						case "mustchange":
+							oldValue = fmt.Sprintf("%t", currentUserData.MustChangePassword)
+						case "expires":
+							oldValue = adminExpiry{currentUserData.ExpiresAt, currentUserData.ExpireToLevel}.String()
+						case "clear2fa":
+							oldValue = "on"
+							newValue = "off"
 						case "password":
 							// Don't log actual password values for security
 							oldValue = "********"
@@ -6734,6 +7052,54 @@ func runValidateUser(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 				}
 				refresh = true
 			}
+		case 'm', 'M':
+			// Toggle the forced password change at next login
+			sel := users[selectedIndex]
+			if _, ok := pendingChanges["mustchange"]; ok {
+				delete(pendingChanges, "mustchange")
+				statusMessage = "|08No change.|07"
+			} else {
+				pendingChanges["mustchange"] = !sel.MustChangePassword
+				if !sel.MustChangePassword {
+					statusMessage = "|11Password change at next login marked for update.|07"
+				} else {
+					statusMessage = "|10Clearing forced password change marked for update.|07"
+				}
+			}
+			refresh = true
+		case 'i', 'I':
+			statusMessage = e.stageAdminExpiry(users[selectedIndex], pendingChanges, readFieldInput)
+			refresh = true
+		case 't', 'T':
+			// Turn off two-factor login for a user who lost their device
+			sel := users[selectedIndex]
+			if _, ok := pendingChanges["clear2fa"]; ok {
+				delete(pendingChanges, "clear2fa")
+				statusMessage = "|08No change.|07"
+			} else if !sel.TOTPEnabled() {
+				statusMessage = fmt.Sprintf("|08%s does not use two-factor login.|07", sel.Handle)
+			} else {
+				pendingChanges["clear2fa"] = true
+				statusMessage = "|11Turning off two-factor login marked for update.|07"
+			}
+			refresh = true
+		case 'r', 'R':
+			// Issue a one-time reset code to read to a locked-out user
+			if len(pendingChanges) > 0 {
+				statusMessage = "|11Save or abort pending changes first.|07"
+			} else {
+				sel := users[selectedIndex]
+				code, expires, codeErr := userManager.IssueResetCode(sel.Username)
+				if codeErr != nil {
+					statusMessage = fmt.Sprintf("|01Failed to issue reset code: %v|07", codeErr)
+				} else {
+					logEntry := user.AdminActivityLogEntry(currentUser.Handle, currentUser.ID, sel.ID, sel.Handle, "resetcode", "", "issued")
+					_ = userManager.LogAdminActivity(logEntry)
+					statusMessage = fmt.Sprintf("|10Reset code for %s: |15%s |08(until %s)|07", sel.Handle, code, adminTime(expires))
+					users = sortedUsersByID(userManager.GetAllUsers())
+				}
+			}
+			refresh = true
 		case '0':
 			// Toggle ban user (sets level 0, unvalidated) or unban (restore to regular level)
 			sel := users[selectedIndex]
@@ -7689,6 +8055,7 @@ func runComposeMessageWithIH(e *MenuExecutor, s ssh.Session, ih *editor.InputHan
 	if err := userManager.UpdateUser(currentUser); err != nil {
 		log.Printf("ERROR: Node %d: Failed to update MessagesPosted for user %s: %v", nodeNumber, currentUser.Handle, err)
 	}
+	e.logActivity(userManager, currentUser, nodeNumber, user.ActivityRecord{Kind: user.ActivityPost})
 
 	// 9. Confirmation
 	log.Printf("INFO: Node %d: User %s successfully posted message #%d to area %s", nodeNumber, currentUser.Handle, msgNum, area.Tag)
@@ -8289,10 +8656,12 @@ func (e *MenuExecutor) selectTransferProtocol(s ssh.Session, terminal *term.Term
 
 // runTransferSend executes a protocol send for the given file paths. It handles
 // resetSessionIH/getSessionIH, batch vs one-at-a-time logic, ExecuteSend, error
-// handling (including ErrBinaryNotFound), and IncrementDownloadCount.
+// handling (including ErrBinaryNotFound), IncrementDownloadCount and the
+// transfer log. currentUser's download byte total is updated; the caller saves it.
 // fileIDs must match paths in order (paths[i] corresponds to fileIDs[i]).
+// onSent, if non-nil, is called with the ID of each file sent successfully.
 // Returns successCount and failCount.
-func (e *MenuExecutor) runTransferSend(s ssh.Session, terminal *term.Terminal, proto transfer.ProtocolConfig, paths []string, fileIDs []uuid.UUID, outputMode ansi.OutputMode, nodeNumber int) (successCount, failCount int) {
+func (e *MenuExecutor) runTransferSend(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, proto transfer.ProtocolConfig, paths []string, fileIDs []uuid.UUID, outputMode ansi.OutputMode, nodeNumber int, onSent func(uuid.UUID)) (successCount, failCount int) {
 	if len(paths) == 0 {
 		return 0, 0
 	}
@@ -8308,6 +8677,13 @@ func (e *MenuExecutor) runTransferSend(s ssh.Session, terminal *term.Terminal, p
 		getSessionIH(s)
 	}()
 
+	fileIDAt := func(i int) uuid.UUID {
+		if i < len(fileIDs) {
+			return fileIDs[i]
+		}
+		return uuid.Nil
+	}
+
 	if proto.BatchSend && len(paths) > 1 {
 		// Batch: single transfer session
 		log.Printf("INFO: Node %d: Batch sending %d file(s) via %q: %v", nodeNumber, len(paths), proto.Name, names)
@@ -8316,7 +8692,25 @@ func (e *MenuExecutor) runTransferSend(s ssh.Session, terminal *term.Terminal, p
 
 		ctx, cancel := e.transferContext(s.Context())
 		defer cancel()
+		// Built-in protocols report each file as it completes, so a batch
+		// that fails part-way still credits the files that made it.
+		delivered := make(map[string]bool)
+		ctx = transfer.WithProgress(ctx, func(p transfer.Progress) {
+			if p.Done {
+				delivered[p.Path] = true
+			}
+		})
+		started := time.Now()
 		transferErr := proto.ExecuteSend(ctx, s, paths...)
+		if !errors.Is(transferErr, transfer.ErrBinaryNotFound) {
+			var files []user.TransferFile
+			for i, p := range paths {
+				if transferErr == nil || delivered[p] {
+					files = append(files, e.transferFileFor(p, fileIDAt(i)))
+				}
+			}
+			e.recordTransfer(userManager, currentUser, nodeNumber, user.NewTransferRecord(user.TransferDownload, proto.Name, started, files, transferErr == nil))
+		}
 		if transferErr != nil {
 			log.Printf("ERROR: Node %d: %q batch send failed: %v", nodeNumber, proto.Name, transferErr)
 			terminalio.WriteProcessedBytes(terminal, []byte(ansi.ClearScreen()), outputMode)
@@ -8325,19 +8719,37 @@ func (e *MenuExecutor) runTransferSend(s ssh.Session, terminal *term.Terminal, p
 			} else {
 				terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("|01Transfer failed or was cancelled.\r\n")), outputMode)
 			}
-			return 0, len(paths)
+			if len(delivered) == 0 {
+				return 0, len(paths)
+			}
+			log.Printf("INFO: Node %d: %d of %d file(s) completed before the failure", nodeNumber, len(delivered), len(paths))
+		} else {
+			log.Printf("INFO: Node %d: %q batch send completed successfully.", nodeNumber, proto.Name)
+			terminalio.WriteProcessedBytes(terminal, []byte(ansi.ClearScreen()), outputMode)
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("|07Transfer complete.\r\n")), outputMode)
 		}
-		log.Printf("INFO: Node %d: %q batch send completed successfully.", nodeNumber, proto.Name)
-		terminalio.WriteProcessedBytes(terminal, []byte(ansi.ClearScreen()), outputMode)
-		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("|07Transfer complete.\r\n")), outputMode)
-		for _, id := range fileIDs {
-			if id != uuid.Nil {
-				if err := e.FileMgr.IncrementDownloadCount(id); err != nil {
-					log.Printf("WARN: Node %d: Failed to increment download count for %s: %v", nodeNumber, id, err)
+		sent := len(paths)
+		if transferErr != nil {
+			sent = 0
+		}
+		for i, id := range fileIDs {
+			if transferErr != nil {
+				if i >= len(paths) || !delivered[paths[i]] {
+					continue
 				}
+				sent++
+			}
+			if id == uuid.Nil {
+				continue
+			}
+			if err := e.FileMgr.IncrementDownloadCount(id); err != nil {
+				log.Printf("WARN: Node %d: Failed to increment download count for %s: %v", nodeNumber, id, err)
+			}
+			if onSent != nil {
+				onSent(id)
 			}
 		}
-		return len(paths), 0
+		return sent, len(paths) - sent
 	}
 
 	// One-at-a-time
@@ -8348,8 +8760,16 @@ func (e *MenuExecutor) runTransferSend(s ssh.Session, terminal *term.Terminal, p
 	for i, p := range paths {
 		ctx, cancel := e.transferContext(s.Context())
 		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(fmt.Sprintf("|15[%d/%d]|07 Sending: |14%s|07...", i+1, len(paths), names[i]))), outputMode)
+		started := time.Now()
 		sendErr := proto.ExecuteSend(ctx, s, p)
 		cancel()
+		if !errors.Is(sendErr, transfer.ErrBinaryNotFound) {
+			var files []user.TransferFile
+			if sendErr == nil {
+				files = []user.TransferFile{e.transferFileFor(p, fileIDAt(i))}
+			}
+			e.recordTransfer(userManager, currentUser, nodeNumber, user.NewTransferRecord(user.TransferDownload, proto.Name, started, files, sendErr == nil))
+		}
 		if sendErr != nil {
 			log.Printf("ERROR: Node %d: %q send failed for %s: %v", nodeNumber, proto.Name, names[i], sendErr)
 			if errors.Is(sendErr, transfer.ErrBinaryNotFound) {
@@ -8370,11 +8790,23 @@ func (e *MenuExecutor) runTransferSend(s ssh.Session, terminal *term.Terminal, p
 			if err := e.FileMgr.IncrementDownloadCount(fileIDs[i]); err != nil {
 				log.Printf("WARN: Node %d: Failed to increment download count for %s: %v", nodeNumber, fileIDs[i], err)
 			}
+			if onSent != nil {
+				onSent(fileIDs[i])
+			}
 		}
 	}
 	return successCount, failCount
 }
 
+// fileRecordLocation formats a file record as "AREATAG/FILENAME" for
+// duplicate notices. Falls back to the area ID if the area is unknown.
+func (e *MenuExecutor) fileRecordLocation(rec file.FileRecord) string {
+	if area, ok := e.FileMgr.GetAreaByID(rec.AreaID); ok {
+		return area.Tag + "/" + rec.Filename
+	}
+	return fmt.Sprintf("%d/%s", rec.AreaID, rec.Filename)
+}
+
 // runUploadFile is the RunnableFunc wrapper for UPLOADFILE menu commands.
 func runUploadFile(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
 	if currentUser == nil {
@@ -8434,6 +8866,12 @@ func (e *MenuExecutor) runUploadFiles(
 		time.Sleep(2 * time.Second)
 		return nil
 	}
+	if area.Offline {
+		msg := "\r\n|01This area is offline. Uploads are not accepted.|07\r\n"
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		time.Sleep(2 * time.Second)
+		return nil
+	}
 
 	// 2. Determine target directory
 	targetDir, err := e.FileMgr.GetAreaUploadPath(currentAreaID)
@@ -8464,6 +8902,42 @@ func (e *MenuExecutor) runUploadFiles(
 		return nil // user cancelled
 	}
 
+	// Offer to continue uploads to this area that were cut off last time.
+	resumeNames, err := e.offerPartialResumes(s, terminal, currentUser, currentAreaID, &proto, outputMode, nodeNumber)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+
+	// XMODEM doesn't carry a filename, so ask for one up front.
+	receiveName := ""
+	if proto.NeedsReceiveName() {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|07Filename to upload: |15")), outputMode)
+		nameInput, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return err
+			}
+			return nil
+		}
+		receiveName = strings.TrimSpace(nameInput)
+		if receiveName == "" {
+			return nil
+		}
+		if receiveName != filepath.Base(receiveName) || receiveName == "." || receiveName == ".." || strings.ContainsAny(receiveName, `/\`) {
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|01That is not a valid filename.|07\r\n")), outputMode)
+			time.Sleep(1500 * time.Millisecond)
+			return nil
+		}
+		if existingNames[strings.ToLower(receiveName)] {
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(fmt.Sprintf("\r\n|01%s already exists in this area.|07\r\n", receiveName))), outputMode)
+			time.Sleep(1500 * time.Millisecond)
+			return nil
+		}
+	}
+
 	// 6. Display instructions
 	msg := fmt.Sprintf("\r\n|11Start the %s send in your terminal.|07\r\n|07After transfer, you will be prompted for file descriptions.\r\n\r\n|07Press |15ENTER|07 to begin or |15Q|07 to cancel: ", proto.Name)
 	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
@@ -8487,6 +8961,13 @@ func (e *MenuExecutor) runUploadFiles(
 	}
 	defer os.RemoveAll(incomingDir)
 
+	// Put partials being resumed where the protocol will find them.
+	for _, name := range resumeNames {
+		if _, err := e.FileMgr.ResumePartial(currentUser.ID, name, incomingDir); err != nil {
+			log.Printf("WARN: Node %d: Failed to resume partial upload %s: %v", nodeNumber, name, err)
+		}
+	}
+
 	// 8. Execute protocol receive into temp directory
 	msg = fmt.Sprintf("\r\n|15Starting %s receive...|07\r\n", proto.Name)
 	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
@@ -8494,6 +8975,20 @@ func (e *MenuExecutor) runUploadFiles(
 	resetSessionIH(s)
 	ctx, cancel := e.transferContext(s.Context())
 	defer cancel()
+	// Built-in protocols report which files arrived whole; anything else
+	// they leave behind is a partial from an interrupted transfer.
+	completed := make(map[string]bool)
+	expected := make(map[string]int64)
+	ctx = transfer.WithProgress(ctx, func(p transfer.Progress) {
+		expected[p.File] = p.Size
+		if p.Done {
+			completed[p.File] = true
+		}
+	})
+	if receiveName != "" {
+		ctx = transfer.WithReceiveName(ctx, receiveName)
+	}
+	started := time.Now()
 	transferErr := proto.ExecuteReceive(ctx, s, incomingDir)
 	time.Sleep(250 * time.Millisecond)
 	getSessionIH(s)
@@ -8521,12 +9016,37 @@ func (e *MenuExecutor) runUploadFiles(
 		size int64
 	}
 	var newFiles []newFileInfo
+	var partials []file.PartialUpload
 	for filename, size := range receivedFiles {
+		if proto.Builtin != "" && !completed[filename] {
+			log.Printf("INFO: Node %d: Upload %s incomplete at %d bytes", nodeNumber, filename, size)
+			if p, kept := e.keepPartialUpload(currentUser, currentAreaID, &proto, filepath.Join(incomingDir, filename), filename, size, expected[filename], nodeNumber); kept {
+				partials = append(partials, p)
+			}
+			continue
+		}
+		// A finished upload supersedes any partial of the same name.
+		if err := e.FileMgr.DeletePartial(currentUser.ID, filename); err != nil && !errors.Is(err, file.ErrPartialNotFound) {
+			log.Printf("WARN: Node %d: Failed to remove superseded partial %s: %v", nodeNumber, filename, err)
+		}
 		newFiles = append(newFiles, newFileInfo{name: filename, size: size})
 	}
+	for _, p := range partials {
+		msg := fmt.Sprintf("\r\n%s|07\r\n|07'%s' was kept (%s). Upload it again with crash recovery to finish it.\r\n", e.LoadedStrings.CrashedFile, p.Filename, formatPartialProgress(p))
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+
+	// The transfer is logged once the files have been checked, so only those
+	// added to the area count; its duration is the receive alone.
+	elapsed := time.Since(started)
+	var receivedBytes int64
+	for _, nf := range newFiles {
+		receivedBytes += nf.size
+	}
 
 	if len(newFiles) == 0 {
 		if transferErr != nil {
+			e.recordTransfer(userManager, currentUser, nodeNumber, user.NewTransferRecord(user.TransferUpload, proto.Name, started, nil, false))
 			errMsg := "\r\n|01Transfer receive failed.|07\r\n"
 			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(errMsg)), outputMode)
 		} else {
@@ -8545,8 +9065,14 @@ func (e *MenuExecutor) runUploadFiles(
 	log.Printf("INFO: Node %d: Detected %d new file(s) after upload", nodeNumber, len(newFiles))
 
 	// 9. Process each new file
+	var accepted []user.TransferFile
 	successCount := 0
 	duplicateCount := 0
+	heldCount := 0
+
+	// Areas that require validation hold uploads until a sysop approves them;
+	// sysop uploads are validated immediately.
+	holdForValidation := area.RequireValidation && !e.isCoSysOpOrAbove(currentUser)
 
 	// Load ZipLab config once for all files
 	zlCfg, zlErr := ziplab.LoadConfig(e.RootConfigPath)
@@ -8578,9 +9104,32 @@ func (e *MenuExecutor) runUploadFiles(
 			continue
 		}
 
+		// Check for identical content anywhere on the system before ZipLab runs
+		hashes, hashErr := file.HashFile(incomingPath)
+		if hashErr != nil {
+			log.Printf("WARN: Node %d: Failed to hash %s: %v", nodeNumber, nf.name, hashErr)
+		} else if dup, found := e.FileMgr.FindFileBySHA256(hashes.SHA256); found {
+			log.Printf("WARN: Node %d: Duplicate content rejected: %s matches %s (ID: %s)", nodeNumber, nf.name, e.fileRecordLocation(dup), dup.ID)
+			duplicateCount++
+			os.Remove(incomingPath)
+
+			dupMsg := fmt.Sprintf("\r\n|09'%s' is identical to '%s'. Rejected.|07\r\n", nf.name, e.fileRecordLocation(dup))
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(dupMsg)), outputMode)
+			continue
+		}
+
+		// Similar names are allowed but flagged so the uploader and sysop notice
+		if similar := e.FileMgr.FindNearDuplicateNames(nf.name); len(similar) > 0 {
+			log.Printf("INFO: Node %d: Upload %s has similar filename to %s", nodeNumber, nf.name, e.fileRecordLocation(similar[0]))
+			noteMsg := fmt.Sprintf("\r\n|14Note: '%s' looks similar to existing file '%s'.|07\r\n", nf.name, e.fileRecordLocation(similar[0]))
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(noteMsg)), outputMode)
+		}
+
 		// ZipLab processing for supported archive types (runs on file in incoming dir)
 		var description string
 		filePath := incomingPath
+		pipelineRan := false
+		zipLabResults := ""
 
 		if zlErr == nil && zlCfg.Enabled && zlCfg.RunOnUpload && zlCfg.IsArchiveSupported(nf.name) {
 			log.Printf("INFO: Node %d: Running ZipLab pipeline on %s", nodeNumber, nf.name)
@@ -8601,6 +9150,8 @@ func (e *MenuExecutor) runUploadFiles(
 			} else {
 				result = proc.RunPipeline(filePath, nil)
 			}
+			pipelineRan = true
+			zipLabResults = result.Summary()
 
 			if !result.Success {
 				log.Printf("ERROR: Node %d: ZipLab pipeline failed for %s: %v", nodeNumber, nf.name, result.Error)
@@ -8648,6 +9199,24 @@ func (e *MenuExecutor) runUploadFiles(
 			nf.size = fi.Size()
 		}
 
+		// ZipLab may have rewritten the archive (comments, ad removal), so the
+		// stored hashes must describe the file as it will sit in the area.
+		if pipelineRan || hashErr != nil {
+			if rehashed, err := file.HashFile(incomingPath); err != nil {
+				log.Printf("WARN: Node %d: Failed to hash %s after pipeline: %v", nodeNumber, nf.name, err)
+			} else {
+				hashes, hashErr = rehashed, nil
+				if dup, found := e.FileMgr.FindFileBySHA256(hashes.SHA256); found {
+					log.Printf("WARN: Node %d: Duplicate content rejected after ZipLab: %s matches %s (ID: %s)", nodeNumber, nf.name, e.fileRecordLocation(dup), dup.ID)
+					duplicateCount++
+					os.Remove(incomingPath)
+					dupMsg := fmt.Sprintf("\r\n|09'%s' is identical to '%s'. Rejected.|07\r\n", nf.name, e.fileRecordLocation(dup))
+					terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(dupMsg)), outputMode)
+					continue
+				}
+			}
+		}
+
 		// Create and add FileRecord
 		record := file.FileRecord{
 			ID:            uuid.New(),
@@ -8658,45 +9227,75 @@ func (e *MenuExecutor) runUploadFiles(
 			UploadedAt:    time.Now(),
 			UploadedBy:    currentUser.Handle,
 			DownloadCount: 0,
-		}
-
-		// Move file from incoming to target directory
-		finalPath := filepath.Join(targetDir, nf.name)
-		if moveErr := os.Rename(incomingPath, finalPath); moveErr != nil {
-			log.Printf("ERROR: Node %d: Failed to move %s to area: %v", nodeNumber, nf.name, moveErr)
+			SHA256:        hashes.SHA256,
+			CRC32:         hashes.CRC32,
+			Unvalidated:   holdForValidation,
+			ZipLabResults: zipLabResults,
+		}
+
+		// Move file from incoming to target directory, claiming the name so
+		// another node or an SFTP upload of the same name cannot replace it.
+		if addErr := e.FileMgr.AddUploadedFile(record, incomingPath); addErr != nil {
+			if errors.Is(addErr, file.ErrFilenameTaken) {
+				log.Printf("WARN: Node %d: Duplicate file rejected: %s", nodeNumber, nf.name)
+				duplicateCount++
+				dupMsg := fmt.Sprintf("\r\n|09'%s' already exists in this area. Rejected.|07\r\n", nf.name)
+				terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(dupMsg)), outputMode)
+				continue
+			}
+			log.Printf("ERROR: Node %d: Failed to add %s to area: %v", nodeNumber, nf.name, addErr)
 			errMsg := fmt.Sprintf("\r\n|01Failed to accept '%s'.|07\r\n", nf.name)
 			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(errMsg)), outputMode)
 			continue
 		}
 
-		if addErr := e.FileMgr.AddFileRecord(record); addErr != nil {
-			log.Printf("ERROR: Node %d: Failed to add file record for %s: %v", nodeNumber, nf.name, addErr)
-			errMsg := fmt.Sprintf("\r\n|01Failed to register '%s'.|07\r\n", nf.name)
-			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(errMsg)), outputMode)
-			if removeErr := os.Remove(finalPath); removeErr != nil {
-				log.Printf("ERROR: Node %d: Failed to clean up orphaned file %s: %v", nodeNumber, nf.name, removeErr)
-			}
-			continue
-		}
-
 		log.Printf("INFO: Node %d: Added file record for %s (ID: %s)", nodeNumber, nf.name, record.ID)
 		successCount++
+		accepted = append(accepted, user.TransferFile{Name: nf.name, AreaID: currentAreaID, Size: nf.size})
 		existingNames[strings.ToLower(nf.name)] = true
-	}
 
-	// 9. Update user upload count
-	if successCount > 0 {
-		currentUser.NumUploads += successCount
-		if updateErr := userManager.UpdateUser(currentUser); updateErr != nil {
-			log.Printf("ERROR: Node %d: Failed to update user upload count: %v", nodeNumber, updateErr)
+		// Held uploads earn their upload count and points when validated.
+		if holdForValidation {
+			heldCount++
+			heldMsg := fmt.Sprintf("\r\n|14'%s' will be available once the SysOp validates it.|07\r\n", nf.name)
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(heldMsg)), outputMode)
+			continue
+		}
+		e.fillWants(record, nodeNumber)
+		if area.RequireValidation {
+			autoMsg := "\r\n" + formatFilenameString(e.LoadedStrings.AutoValidatingFile, nf.name) + "|07\r\n"
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(autoMsg)), outputMode)
+		}
+
+		if credit := e.applyUploadCredit(userManager, currentUser, area.Tag, nf.name, area.UploadCreditFor(nf.size, e.GetServerConfig().UploadKBPerPoint), nodeNumber); credit > 0 {
+			worthMsg := "\r\n" + formatPointsString(e.LoadedStrings.FileIsWorth, credit) + "|07\r\n" + formatPointsString(e.LoadedStrings.GrantingUserFP, credit) + "|07\r\n"
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(worthMsg)), outputMode)
 		}
 	}
 
+	// 9. Log the transfer, then update user upload count and byte total (and
+	// any file points credited above)
+	rec := user.NewTransferRecord(user.TransferUpload, proto.Name, time.Now().Add(-elapsed), accepted, transferErr == nil && len(accepted) > 0)
+	e.recordTransfer(userManager, currentUser, nodeNumber, rec)
+	// Time is given back only for the share of the receive that was added,
+	// so resending files the BBS rejects earns nothing.
+	if rec.Success && receivedBytes > 0 && rec.Bytes < receivedBytes {
+		rec.Duration = time.Duration(float64(rec.Duration) * float64(rec.Bytes) / float64(receivedBytes))
+	}
+	e.creditUploadTime(s, terminal, nodeNumber, rec, outputMode)
+	currentUser.NumUploads += successCount - heldCount
+	if updateErr := userManager.UpdateUser(currentUser); updateErr != nil {
+		log.Printf("ERROR: Node %d: Failed to update user upload count: %v", nodeNumber, updateErr)
+	}
+
 	// 10. Display summary
 	summary := fmt.Sprintf("\r\n|15Upload complete.|07 Added: |15%d|07", successCount)
 	if duplicateCount > 0 {
 		summary += fmt.Sprintf("  Rejected (duplicate): |09%d|07", duplicateCount)
 	}
+	if heldCount > 0 {
+		summary += fmt.Sprintf("  Awaiting validation: |14%d|07", heldCount)
+	}
 	summary += "\r\n"
 	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(summary)), outputMode)
 	time.Sleep(2 * time.Second)
@@ -8817,8 +9416,9 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 	log.Printf("DEBUG: Node %d: TermHeight=%d, FixedLines=%d, FilesPerPage=%d", nodeNumber, termHeight, fixedLines, filesPerPage)
 
 	// --- Get Total File Count ---
-	// TODO: Implement GetFileCountForArea in FileManager
-	totalFiles, err := e.FileMgr.GetFileCountForArea(currentAreaID)
+	// Unvalidated uploads are hidden from everyone but their uploader and sysops
+	showUnvalidated := e.isCoSysOpOrAbove(currentUser)
+	totalFiles, err := e.FileMgr.GetVisibleFileCountForArea(currentAreaID, currentUser.Handle, showUnvalidated)
 	if err != nil {
 		log.Printf("ERROR: Node %d: Failed to get file count for area %d: %v", nodeNumber, currentAreaID, err)
 		msg := fmt.Sprintf("\r\n|01Error retrieving file list for area '%s'.|07\r\n", currentAreaTag)
@@ -8842,8 +9442,7 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 
 	// --- Fetch Initial Page ---
 	if totalFiles > 0 {
-		// TODO: Implement GetFilesForAreaPaginated in FileManager
-		filesOnPage, err = e.FileMgr.GetFilesForAreaPaginated(currentAreaID, currentPage, filesPerPage)
+		filesOnPage, err = e.FileMgr.GetVisibleFilesForAreaPaginated(currentAreaID, currentUser.Handle, showUnvalidated, currentPage, filesPerPage)
 		if err != nil {
 			log.Printf("ERROR: Node %d: Failed to get files for area %d, page %d: %v", nodeNumber, currentAreaID, currentPage, err)
 			msg := fmt.Sprintf("\r\n|01Error retrieving file list page for area '%s'.|07\r\n", currentAreaTag)
@@ -9015,7 +9614,7 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 			if currentPage < totalPages {
 				currentPage++
 				// Fetch files for the new page
-				filesOnPage, err = e.FileMgr.GetFilesForAreaPaginated(currentAreaID, currentPage, filesPerPage)
+				filesOnPage, err = e.FileMgr.GetVisibleFilesForAreaPaginated(currentAreaID, currentUser.Handle, showUnvalidated, currentPage, filesPerPage)
 				if err != nil {
 					// Log error and potentially return or break the loop
 					log.Printf("ERROR: Node %d: Failed to get files for page %d: %v", nodeNumber, currentPage, err)
@@ -9032,7 +9631,7 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 			if currentPage > 1 {
 				currentPage--
 				// Fetch files for the new page
-				filesOnPage, err = e.FileMgr.GetFilesForAreaPaginated(currentAreaID, currentPage, filesPerPage)
+				filesOnPage, err = e.FileMgr.GetVisibleFilesForAreaPaginated(currentAreaID, currentUser.Handle, showUnvalidated, currentPage, filesPerPage)
 				if err != nil {
 					// Log error and potentially return or break the loop
 					log.Printf("ERROR: Node %d: Failed to get files for page %d: %v", nodeNumber, currentPage, err)
@@ -9117,7 +9716,18 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 			var resolved []dlEntry
 			var successCount, failCount int
 			for _, fileID := range currentUser.TaggedFileIDs {
+				if e.downloadHeldForValidation(currentUser, fileID) {
+					log.Printf("INFO: Node %d: %s tried to download unvalidated file %s", nodeNumber, currentUser.Handle, fileID)
+					terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n"+e.LoadedStrings.UnvalidatedFile+"|07\r\n")), outputMode)
+					failCount++
+					continue
+				}
 				filePath, pathErr := e.FileMgr.GetFilePath(fileID)
+				if errors.Is(pathErr, file.ErrFileOffline) {
+					e.noticeOfflineDownload(terminal, currentUser, fileID, nodeNumber, outputMode)
+					failCount++
+					continue
+				}
 				if pathErr != nil {
 					log.Printf("ERROR: Node %d: Failed to get path for file ID %s: %v", nodeNumber, fileID, pathErr)
 					failCount++
@@ -9142,16 +9752,28 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 					paths[i] = fe.path
 					fileIDs[i] = fe.id
 				}
-				transferSuccess, transferFail := e.runTransferSend(s, terminal, proto, paths, fileIDs, outputMode, nodeNumber)
+
+				// Ratio and file point check before anything is sent
+				charges, totalCost, counted := e.planDownloadCharges(fileIDs)
+				if denyMsg := checkDownloadAllowance(e.downloadConfigFor(currentUser), e.LoadedStrings, currentUser, totalCost, counted); denyMsg != "" {
+					log.Printf("INFO: Node %d: Download by %s denied (cost %d, points %d, files %d)", nodeNumber, currentUser.Handle, totalCost, currentUser.FilePoints, counted)
+					terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n"+denyMsg+"|07\r\n")), outputMode)
+					time.Sleep(2 * time.Second)
+					continue // Keep tags so the user can unmark files and retry
+				}
+
+				transferSuccess, transferFail := e.runTransferSend(s, terminal, userManager, currentUser, proto, paths, fileIDs, outputMode, nodeNumber, func(id uuid.UUID) {
+					e.applyDownloadCharge(userManager, currentUser, charges[id], nodeNumber)
+				})
 				successCount += transferSuccess
 				failCount += transferFail
 				time.Sleep(1 * time.Second)
 			}
 
-			// 4. Clear tags, update download count, and save user state
+			// 4. Clear tags and save user state (download count and points were
+			// updated per file as each transfer completed)
 			log.Printf("DEBUG: Node %d: Clearing %d tagged file IDs for user %s.", nodeNumber, len(currentUser.TaggedFileIDs), currentUser.Handle)
 			currentUser.TaggedFileIDs = nil // Clear the list
-			currentUser.NumDownloads += successCount
 			if err := userManager.UpdateUser(currentUser); err != nil {
 				log.Printf("ERROR: Node %d: Failed to save user data after download attempt: %v", nodeNumber, err)
 				// Inform user? State might be inconsistent.
@@ -9179,7 +9801,7 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 				currentUser = reloaded
 			}
 			// Refresh file count and page data
-			totalFiles, _ = e.FileMgr.GetFileCountForArea(currentAreaID)
+			totalFiles, _ = e.FileMgr.GetVisibleFileCountForArea(currentAreaID, currentUser.Handle, showUnvalidated)
 			if filesPerPage > 0 {
 				totalPages = (totalFiles + filesPerPage - 1) / filesPerPage
 			}
@@ -9189,7 +9811,7 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 			if currentPage > totalPages {
 				currentPage = totalPages
 			}
-			filesOnPage, _ = e.FileMgr.GetFilesForAreaPaginated(currentAreaID, currentPage, filesPerPage)
+			filesOnPage, _ = e.FileMgr.GetVisibleFilesForAreaPaginated(currentAreaID, currentUser.Handle, showUnvalidated, currentPage, filesPerPage)
 			continue
 		case "V": // View file
 			log.Printf("DEBUG: Node %d: View command entered in file list", nodeNumber)
@@ -9219,20 +9841,7 @@ func runListFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 				continue
 			}
 			fileToView := filesOnPage[viewIndex]
-			if e.FileMgr.IsSupportedArchive(fileToView.Filename) {
-				viewFilePath, pathErr := e.FileMgr.GetFilePath(fileToView.ID)
-				if pathErr != nil {
-					log.Printf("ERROR: Node %d: Failed to get path for file %s: %v", nodeNumber, fileToView.ID, pathErr)
-					terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|01Error locating file.|07\r\n")), outputMode)
-					time.Sleep(1 * time.Second)
-				} else {
-					ctx, cancel := e.transferContext(s.Context())
-					ziplab.RunZipLabView(ctx, s, terminal, viewFilePath, fileToView.Filename, outputMode)
-					cancel()
-				}
-			} else {
-				viewFileByRecord(e, s, terminal, &fileToView, outputMode, termWidth, termHeight)
-			}
+			viewFileByRecord(e, s, terminal, userManager, currentUser, &fileToView, outputMode, nodeNumber, termWidth, termHeight)
 			continue
 		case "A": // Area Change (Placeholder/Not implemented here, handled by menu?)
 			log.Printf("DEBUG: Node %d: Area Change command entered (Handled by menu)", nodeNumber)
@@ -9388,7 +9997,7 @@ func displayFileAreaList(e *MenuExecutor, s ssh.Session, terminal *term.Terminal
 			desc := string(ansi.ReplacePipeCodes([]byte(area.Description)))
 			idStr := strconv.Itoa(area.ID)
 			tag := string(ansi.ReplacePipeCodes([]byte(area.Tag)))
-			fileCount, countErr := e.FileMgr.GetFileCountForArea(area.ID)
+			fileCount, countErr := e.visibleFileCount(area.ID, currentUser)
 			if countErr != nil {
 				log.Printf("WARN: Node %d: Failed getting file count for area %d (%s): %v", nodeNumber, area.ID, area.Tag, countErr)
 				fileCount = 0
@@ -9962,6 +10571,14 @@ func runSendPrivateMail(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 		return nil, "", nil
 	}
 
+	if left, limited := e.privateMailLeft(currentUser); limited && left == 0 {
+		log.Printf("INFO: Node %d: %s has reached the daily private mail limit", nodeNumber, currentUser.Handle)
+		msg := "\r\n|12You have sent all the private mail your level allows today.|07\r\n"
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		time.Sleep(1 * time.Second)
+		return nil, "", nil
+	}
+
 	// Prompt for recipient username
 	terminalio.WriteProcessedBytes(terminal, []byte("\r\n"), outputMode)
 	recipientPrompt := "|07Send private mail to: |15"
@@ -10108,11 +10725,13 @@ func runSendPrivateMail(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 		return nil, "", fmt.Errorf("failed saving private message: %w", err)
 	}
 
-	// Update user message counter
+	// Update user message counters
 	currentUser.MessagesPosted++
+	e.countPrivateMailSent(currentUser)
 	if err := userManager.UpdateUser(currentUser); err != nil {
 		log.Printf("ERROR: Node %d: Failed to update MessagesPosted for user %s: %v", nodeNumber, currentUser.Handle, err)
 	}
+	e.logActivity(userManager, currentUser, nodeNumber, user.ActivityRecord{Kind: user.ActivityPost})
 
 	// Confirmation
 	log.Printf("INFO: Node %d: User %s successfully sent private message #%d to %s", nodeNumber, currentUser.Handle, msgNum, recipientUser.Handle)
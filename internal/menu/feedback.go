@@ -0,0 +1,475 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/jam"
+	"github.com/stlalpha/vision3/internal/message"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// isFeedbackReader reports whether u may read feedback: the sysop level
+// and up.
+func (e *MenuExecutor) isFeedbackReader(u *user.User) bool {
+	return u != nil && u.AccessLevel >= e.GetServerConfig().SysOpLevel
+}
+
+// feedbackThreads returns the feedback left by users, without the sysop
+// replies, oldest first.
+func feedbackThreads(list []*message.DisplayMessage) []*message.DisplayMessage {
+	var threads []*message.DisplayMessage
+	for _, m := range list {
+		if !message.IsFeedbackReply(m) {
+			threads = append(threads, m)
+		}
+	}
+	return threads
+}
+
+// feedbackUnread reports whether the sysop has not read m yet.
+func feedbackUnread(m *message.DisplayMessage) bool {
+	return m.Attributes&jam.MsgRead == 0
+}
+
+// runLeaveFeedback lets a user write feedback to the sysop (V2 F key). It is
+// stored in the feedback base, not private mail.
+func runLeaveFeedback(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return nil, "", nil
+	}
+	if e.MessageMgr == nil {
+		log.Printf("WARN: Node %d: MessageMgr not available for LEAVEFEEDBACK", nodeNumber)
+		return currentUser, "", nil
+	}
+
+	prompt := e.LoadedStrings.LeaveFBStr
+	if prompt == "" {
+		prompt = "|09Send |08[|15Feedback|08]|09 to SysOp's? @"
+	}
+	wv(terminal, "\r\n", outputMode)
+	send, err := e.PromptYesNo(s, terminal, prompt, outputMode, nodeNumber, termWidth, termHeight, true)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, "LOGOFF", io.EOF
+		}
+		return currentUser, "", nil
+	}
+	if !send {
+		wv(terminal, "\r\n", outputMode)
+		return currentUser, "", nil
+	}
+
+	wv(terminal, "\r\n|07Subject: |15", outputMode)
+	subject, err := styledInput(terminal, s, outputMode, 30, "")
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, "LOGOFF", io.EOF
+		}
+		return currentUser, "", nil // ESC abandons the feedback
+	}
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		subject = "Feedback"
+	}
+
+	wv(terminal, ansi.ClearScreen(), outputMode)
+	body, saved, err := editor.RunEditorWithMetadata("", s, s, outputMode, subject, message.FeedbackTo, currentUser.Handle, false, "", "", "", "", false, nil, nil, editor.EditorContext{
+		NodeNumber: nodeNumber,
+		ConfArea:   "Feedback",
+	})
+	if err != nil {
+		log.Printf("ERROR: Node %d: Editor failed for feedback from %s: %v", nodeNumber, currentUser.Handle, err)
+		return currentUser, "", fmt.Errorf("editor error: %w", err)
+	}
+	wv(terminal, ansi.ClearScreen(), outputMode)
+	if !saved || strings.TrimSpace(body) == "" {
+		wv(terminal, "\r\n|07Feedback aborted.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+	if currentUser.AutoSignature != "" {
+		body = body + "\n\n" + currentUser.AutoSignature
+	}
+
+	num, err := e.MessageMgr.AddFeedback(currentUser.Handle, subject, body)
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to save feedback from %s: %v", nodeNumber, currentUser.Handle, err)
+		wv(terminal, "\r\n|12Error saving your feedback!|07\r\n", outputMode)
+		time.Sleep(2 * time.Second)
+		return currentUser, "", nil
+	}
+	log.Printf("INFO: Node %d: %s left feedback #%d (%s)", nodeNumber, currentUser.Handle, num, subject)
+
+	sent := e.LoadedStrings.FeedbackSent
+	if sent == "" {
+		sent = "|01F|09e|15edba|09c|01k |01S|09e|15n|09t|01!"
+	}
+	wv(terminal, "\r\n"+sent+"|07\r\n", outputMode)
+	time.Sleep(1 * time.Second)
+	return currentUser, "", nil
+}
+
+// runCheckFeedback is the CHECKFEEDBACK login item. Sysops are told how
+// much feedback is waiting and offered the feedback reader.
+func runCheckFeedback(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if !e.isFeedbackReader(currentUser) || e.MessageMgr == nil {
+		return currentUser, "", nil
+	}
+	waiting, err := e.MessageMgr.FeedbackWaiting()
+	if err != nil {
+		log.Printf("WARN: Node %d: Failed to count feedback: %v", nodeNumber, err)
+		return currentUser, "", nil
+	}
+	if waiting == 0 {
+		return currentUser, "", nil
+	}
+
+	notice := e.LoadedStrings.HaveFeedback
+	if notice == "" {
+		notice = "|15Feedback Waiting: |13|FB"
+	}
+	wv(terminal, "\r\n"+strings.ReplaceAll(notice, "|FB", strconv.Itoa(waiting))+"|07\r\n", outputMode)
+
+	prompt := e.LoadedStrings.ReadFeedback
+	if prompt == "" {
+		prompt = "|07Read it now? @"
+	}
+	read, err := e.PromptYesNo(s, terminal, prompt, outputMode, nodeNumber, termWidth, termHeight, true)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, "LOGOFF", io.EOF
+		}
+		return currentUser, "", nil
+	}
+	wv(terminal, "\r\n", outputMode)
+	if !read {
+		return currentUser, "", nil
+	}
+	err = e.feedbackReader(s, terminal, userManager, currentUser, nodeNumber, outputMode, termWidth, termHeight)
+	if errors.Is(err, io.EOF) {
+		return nil, "LOGOFF", io.EOF
+	}
+	return currentUser, "", err
+}
+
+// runReadFeedback opens the feedback reader from a menu.
+func runReadFeedback(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return nil, "", nil
+	}
+	if !e.isFeedbackReader(currentUser) {
+		wv(terminal, "\r\n|01Access denied.|07\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+	if e.MessageMgr == nil {
+		return currentUser, "", nil
+	}
+	err := e.feedbackReader(s, terminal, userManager, currentUser, nodeNumber, outputMode, termWidth, termHeight)
+	if errors.Is(err, io.EOF) {
+		return nil, "LOGOFF", io.EOF
+	}
+	return currentUser, "", err
+}
+
+// feedbackReader shows feedback one at a time, starting at the oldest
+// unread, with its replies below it (V2 feedback menu). The sysop can read
+// again, reply, forward, delete, view the sender's infoforms, list or jump
+// to a number. Enter moves to the next feedback.
+func (e *MenuExecutor) feedbackReader(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, sysop *user.User, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) error {
+	load := func() ([]*message.DisplayMessage, error) {
+		list, err := e.MessageMgr.ListFeedback()
+		if err != nil {
+			log.Printf("ERROR: Node %d: Failed to load feedback: %v", nodeNumber, err)
+			wv(terminal, "\r\n|12Error loading feedback.|07\r\n", outputMode)
+			time.Sleep(1 * time.Second)
+		}
+		return feedbackThreads(list), err
+	}
+	threads, err := load()
+	if err != nil {
+		return nil
+	}
+	if len(threads) == 0 {
+		wv(terminal, "\r\n|07No feedback.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	}
+
+	cur := 0
+	for i, m := range threads {
+		if feedbackUnread(m) {
+			cur = i
+			break
+		}
+	}
+	show := true
+
+	for {
+		if cur >= len(threads) {
+			wv(terminal, "\r\n|07No more feedback.\r\n", outputMode)
+			time.Sleep(1 * time.Second)
+			return nil
+		}
+		fb := threads[cur]
+		if show {
+			e.showFeedback(s, terminal, fb, cur+1, len(threads), outputMode, termWidth, termHeight)
+			if feedbackUnread(fb) {
+				if err := e.MessageMgr.MarkFeedbackRead(fb.MsgNum); err != nil {
+					log.Printf("WARN: Node %d: Failed to mark feedback #%d read: %v", nodeNumber, fb.MsgNum, err)
+				}
+				fb.Attributes |= jam.MsgRead
+			}
+		}
+		show = true
+
+		prompt := e.LoadedStrings.FeedbackPrompt
+		if prompt == "" {
+			prompt = "|07Feedback (|15A|07)gain (|15R|07)eply (|15F|07)orward (|15D|07)elete (|15I|07)nfoform (|15L|07)ist (|15#|07) (|15Q|07)uit: "
+		}
+		wv(terminal, "\r\n"+prompt, outputMode)
+		input, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			return err
+		}
+		input = strings.ToUpper(strings.TrimSpace(input))
+
+		switch input {
+		case "":
+			cur++
+		case "Q":
+			return nil
+		case "A":
+			// Show the same feedback again
+		case "R":
+			if err := e.replyFeedback(s, terminal, userManager, sysop, fb, nodeNumber, outputMode); err != nil {
+				return err
+			}
+		case "F":
+			if err := e.forwardFeedback(s, terminal, userManager, sysop, fb, nodeNumber, outputMode); err != nil {
+				return err
+			}
+			show = false
+		case "D":
+			del, err := e.PromptYesNo(s, terminal, fmt.Sprintf("\r\n|07Delete feedback from |15%s|07? @", fb.From), outputMode, nodeNumber, termWidth, termHeight, false)
+			if err != nil {
+				return err
+			}
+			if !del {
+				show = false
+				continue
+			}
+			if err := e.MessageMgr.DeleteFeedback(fb.MsgNum); err != nil {
+				log.Printf("ERROR: Node %d: Failed to delete feedback #%d: %v", nodeNumber, fb.MsgNum, err)
+				wv(terminal, "\r\n|12Error deleting feedback.|07\r\n", outputMode)
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			log.Printf("INFO: Node %d: %s deleted feedback #%d from %s", nodeNumber, sysop.Handle, fb.MsgNum, fb.From)
+			if threads, err = load(); err != nil {
+				return nil
+			}
+			if len(threads) == 0 {
+				wv(terminal, "\r\n|07No more feedback.\r\n", outputMode)
+				time.Sleep(1 * time.Second)
+				return nil
+			}
+			cur = min(cur, len(threads)-1)
+		case "I":
+			e.showSenderInfoForms(s, terminal, userManager, fb.From, outputMode, termWidth, termHeight)
+		case "L":
+			e.listFeedback(s, terminal, threads, outputMode, termWidth, termHeight)
+			show = false
+		default:
+			n, err := strconv.Atoi(input)
+			if err != nil || n < 1 || n > len(threads) {
+				show = false
+				continue
+			}
+			cur = n - 1
+		}
+	}
+}
+
+// showFeedback clears the screen and shows fb, numbered num of total, with
+// the replies sent to it.
+func (e *MenuExecutor) showFeedback(s ssh.Session, terminal *term.Terminal, fb *message.DisplayMessage, num, total int, outputMode ansi.OutputMode, termWidth, termHeight int) {
+	wv(terminal, ansi.ClearScreen(), outputMode)
+	wv(terminal, fmt.Sprintf("|15Feedback |11%d|15 of |11%d\r\n", num, total), outputMode)
+	wv(terminal, fmt.Sprintf("|03From: |11%s  |03Date: |11%s\r\n", fb.From, fb.DateTime.Format("01/02/2006 3:04 PM")), outputMode)
+	wv(terminal, fmt.Sprintf("|03Subj: |11%s\r\n", fb.Subject), outputMode)
+	wv(terminal, "|08"+strings.Repeat("\xc4", 70)+"\r\n", outputMode)
+
+	lines := strings.Split(sanitizeControlChars(fb.Body), "\n")
+	replies, err := e.MessageMgr.FeedbackReplies(fb.MsgNum)
+	if err != nil {
+		log.Printf("WARN: Failed to load replies to feedback #%d: %v", fb.MsgNum, err)
+	}
+	for _, r := range replies {
+		lines = append(lines, "", fmt.Sprintf("|08--- |03Reply from |11%s|03 on |11%s |08---", r.From, r.DateTime.Format("01/02/2006")))
+		lines = append(lines, strings.Split(sanitizeControlChars(r.Body), "\n")...)
+	}
+
+	page := max(termHeight, 24) - 7
+	for i, line := range lines {
+		if i > 0 && i%page == 0 {
+			e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+			wv(terminal, "\r\n", outputMode)
+		}
+		wv(terminal, "|07"+line+"\r\n", outputMode)
+	}
+}
+
+// listFeedback shows one line per feedback.
+func (e *MenuExecutor) listFeedback(s ssh.Session, terminal *term.Terminal, threads []*message.DisplayMessage, outputMode ansi.OutputMode, termWidth, termHeight int) {
+	wv(terminal, ansi.ClearScreen(), outputMode)
+	wv(terminal, fmt.Sprintf("|11%-5s%-6s%-22s%-30s%s\r\n", "#", "", "From", "Subject", "Date"), outputMode)
+	wv(terminal, "|08"+strings.Repeat("\xc4", 75)+"\r\n", outputMode)
+	page := max(termHeight, 24) - 5
+	for i, m := range threads {
+		if i > 0 && i%page == 0 {
+			e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+			wv(terminal, "\r\n", outputMode)
+		}
+		status := "     "
+		if feedbackUnread(m) {
+			status = "|12New  "
+		}
+		wv(terminal, fmt.Sprintf("|09%-5d%s|07%-22s%-30s|03%s\r\n",
+			i+1, status, adminTruncate(m.From, 21), adminTruncate(m.Subject, 29), m.DateTime.Format("01/02/06")), outputMode)
+	}
+}
+
+// replyFeedback writes a reply to fb's sender. The reply goes to their
+// private mail and is threaded under fb in the feedback base.
+func (e *MenuExecutor) replyFeedback(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, sysop *user.User, fb *message.DisplayMessage, nodeNumber int, outputMode ansi.OutputMode) error {
+	privmailArea, hasPrivmail := e.MessageMgr.GetAreaByTag("PRIVMAIL")
+	if _, found := userManager.GetUserByHandle(fb.From); !found || !hasPrivmail {
+		wv(terminal, "\r\n|12The sender can't receive private mail; the reply is only kept with the feedback.|07\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+	}
+
+	subject := generateReplySubject(fb.Subject)
+	quoteLines := strings.Split(fb.Body, "\n")
+	wv(terminal, ansi.ClearScreen(), outputMode)
+	body, saved, err := editor.RunEditorWithMetadata("", s, s, outputMode, subject, fb.From, sysop.Handle, false,
+		fb.From, fb.Subject, fb.DateTime.Format("01/02/2006"), fb.DateTime.Format("3:04 PM"), false, quoteLines, nil,
+		editor.EditorContext{NodeNumber: nodeNumber, ConfArea: "Feedback"})
+	if err != nil {
+		log.Printf("ERROR: Node %d: Editor failed replying to feedback #%d: %v", nodeNumber, fb.MsgNum, err)
+		return fmt.Errorf("editor error: %w", err)
+	}
+	wv(terminal, ansi.ClearScreen(), outputMode)
+	if !saved || strings.TrimSpace(body) == "" {
+		wv(terminal, "\r\n|07Reply aborted.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	}
+	if sysop.AutoSignature != "" {
+		body = body + "\n\n" + sysop.AutoSignature
+	}
+
+	if _, err := e.MessageMgr.AddFeedbackReply(fb.MsgNum, sysop.Handle, fb.From, subject, body); err != nil {
+		log.Printf("ERROR: Node %d: Failed to save reply to feedback #%d: %v", nodeNumber, fb.MsgNum, err)
+		wv(terminal, "\r\n|12Error saving reply!|07\r\n", outputMode)
+		time.Sleep(2 * time.Second)
+		return nil
+	}
+	if _, found := userManager.GetUserByHandle(fb.From); found && hasPrivmail {
+		if _, err := e.MessageMgr.AddPrivateMessage(privmailArea.ID, sysop.Handle, fb.From, subject, body, ""); err != nil {
+			log.Printf("ERROR: Node %d: Failed to mail reply to feedback #%d to %s: %v", nodeNumber, fb.MsgNum, fb.From, err)
+			wv(terminal, "\r\n|12Reply saved, but mailing it failed!|07\r\n", outputMode)
+			time.Sleep(2 * time.Second)
+			return nil
+		}
+	}
+	log.Printf("INFO: Node %d: %s replied to feedback #%d from %s", nodeNumber, sysop.Handle, fb.MsgNum, fb.From)
+	wv(terminal, fmt.Sprintf("\r\n|10Reply sent to %s.|07\r\n", fb.From), outputMode)
+	time.Sleep(1 * time.Second)
+	return nil
+}
+
+// forwardFeedback mails a copy of fb and its replies to another user.
+func (e *MenuExecutor) forwardFeedback(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, sysop *user.User, fb *message.DisplayMessage, nodeNumber int, outputMode ansi.OutputMode) error {
+	privmailArea, ok := e.MessageMgr.GetAreaByTag("PRIVMAIL")
+	if !ok {
+		wv(terminal, "\r\n|01Error: Private mail area not configured.|07\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	}
+
+	wv(terminal, "\r\n|07Forward to: |15", outputMode)
+	handle, err := styledInput(terminal, s, outputMode, 24, "")
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+	handle = strings.TrimSpace(handle)
+	if handle == "" {
+		return nil
+	}
+	to, found := userManager.GetUserByHandle(handle)
+	if !found {
+		to, found = userManager.GetUser(handle)
+	}
+	if !found {
+		wv(terminal, fmt.Sprintf("\r\n|01Error: User '%s' not found.|07\r\n", handle), outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "* Forwarded by %s\n* Feedback from %s on %s\n\n%s", sysop.Handle, fb.From, fb.DateTime.Format("01/02/2006 3:04 PM"), fb.Body)
+	if replies, err := e.MessageMgr.FeedbackReplies(fb.MsgNum); err == nil {
+		for _, r := range replies {
+			fmt.Fprintf(&b, "\n\n* Reply from %s on %s\n\n%s", r.From, r.DateTime.Format("01/02/2006 3:04 PM"), r.Body)
+		}
+	}
+
+	if _, err := e.MessageMgr.AddPrivateMessage(privmailArea.ID, sysop.Handle, to.Handle, "Fwd: "+fb.Subject, b.String(), ""); err != nil {
+		log.Printf("ERROR: Node %d: Failed to forward feedback #%d to %s: %v", nodeNumber, fb.MsgNum, to.Handle, err)
+		wv(terminal, "\r\n|12Error forwarding feedback!|07\r\n", outputMode)
+		time.Sleep(2 * time.Second)
+		return nil
+	}
+	log.Printf("INFO: Node %d: %s forwarded feedback #%d from %s to %s", nodeNumber, sysop.Handle, fb.MsgNum, fb.From, to.Handle)
+	wv(terminal, fmt.Sprintf("\r\n|10Feedback forwarded to %s.|07\r\n", to.Handle), outputMode)
+	time.Sleep(1 * time.Second)
+	return nil
+}
+
+// showSenderInfoForms shows the infoform answers of the user with handle.
+func (e *MenuExecutor) showSenderInfoForms(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, handle string, outputMode ansi.OutputMode, termWidth, termHeight int) {
+	wv(terminal, "\r\n", outputMode)
+	u, found := userManager.GetUserByHandle(handle)
+	if !found {
+		wv(terminal, fmt.Sprintf("|07No account named %s.\r\n", handle), outputMode)
+		e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+		return
+	}
+	shown := 0
+	for _, f := range e.GetInfoForms() {
+		if a, ok := u.InfoFormAnswered(f.ID); ok {
+			writeInfoFormAnswers(terminal, f, a, outputMode)
+			wv(terminal, "\r\n", outputMode)
+			shown++
+		}
+	}
+	if shown == 0 {
+		wv(terminal, fmt.Sprintf("|07%s has not filled in any infoforms.\r\n", u.Handle), outputMode)
+	}
+	e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+}
@@ -20,7 +20,6 @@ import (
 	"github.com/stlalpha/vision3/internal/file"
 	"github.com/stlalpha/vision3/internal/terminalio"
 	"github.com/stlalpha/vision3/internal/user"
-	"github.com/stlalpha/vision3/internal/ziplab"
 )
 
 
@@ -103,7 +102,7 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 	defer terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25h"), outputMode)
 
 	// Fetch all files for the area.
-	allFiles := e.FileMgr.GetFilesForArea(currentAreaID)
+	allFiles := e.visibleFilesForArea(currentAreaID, currentUser)
 
 	selectedIndex := 0
 	topIndex := 0
@@ -903,21 +902,10 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 		case "v":
 			if len(allFiles) > 0 {
 				sel := &allFiles[selectedIndex]
-				filePath, pathErr := e.FileMgr.GetFilePath(sel.ID)
-				if pathErr != nil {
-					log.Printf("ERROR: Node %d: Failed to get path for file %s: %v", nodeNumber, sel.ID, pathErr)
-					continue
-				}
 				// Show cursor for the viewer.
 				_ = terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25h"), outputMode)
-				if e.FileMgr.IsSupportedArchive(sel.Filename) {
-					ctx, cancel := e.transferContext(s.Context())
-					ziplab.RunZipLabView(ctx, s, terminal, filePath, sel.Filename, outputMode)
-					cancel()
-				} else {
-					termWidth, termHeight := getTerminalSize(s)
-					viewFileByRecord(e, s, terminal, sel, outputMode, termWidth, termHeight)
-				}
+				termWidth, termHeight := getTerminalSize(s)
+				viewFileByRecord(e, s, terminal, userManager, currentUser, sel, outputMode, nodeNumber, termWidth, termHeight)
 				// Hide cursor again.
 				_ = terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25l"), outputMode)
 				needFullRedraw = true
@@ -969,7 +957,18 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 			fileIDsToDownload := make([]uuid.UUID, 0, len(currentUser.TaggedFileIDs))
 
 			for _, fileID := range currentUser.TaggedFileIDs {
+				if e.downloadHeldForValidation(currentUser, fileID) {
+					log.Printf("INFO: Node %d: %s tried to download unvalidated file %s", nodeNumber, currentUser.Handle, fileID)
+					_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(e.LoadedStrings.UnvalidatedFile+"|07\r\n")), outputMode)
+					failCount++
+					continue
+				}
 				fp, pathErr := e.FileMgr.GetFilePath(fileID)
+				if errors.Is(pathErr, file.ErrFileOffline) {
+					e.noticeOfflineDownload(terminal, currentUser, fileID, nodeNumber, outputMode)
+					failCount++
+					continue
+				}
 				if pathErr != nil {
 					log.Printf("ERROR: Node %d: Failed to get path for file ID %s: %v", nodeNumber, fileID, pathErr)
 					failCount++
@@ -1003,7 +1002,19 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 				} else if !protoOK {
 					_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|07Download cancelled.|07\r\n")), outputMode)
 				} else {
-					successCount, failCount = e.runTransferSend(s, terminal, proto, filesToDownload, fileIDsToDownload, outputMode, nodeNumber)
+					// Ratio and file point check; on failure keep tags so the user can adjust.
+					charges, totalCost, counted := e.planDownloadCharges(fileIDsToDownload)
+					if denyMsg := checkDownloadAllowance(e.downloadConfigFor(currentUser), e.LoadedStrings, currentUser, totalCost, counted); denyMsg != "" {
+						log.Printf("INFO: Node %d: Download by %s denied (cost %d, points %d, files %d)", nodeNumber, currentUser.Handle, totalCost, currentUser.FilePoints, counted)
+						_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n"+denyMsg+"|07\r\n")), outputMode)
+						time.Sleep(2 * time.Second)
+						_ = terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25l"), outputMode)
+						needFullRedraw = true
+						continue
+					}
+					successCount, failCount = e.runTransferSend(s, terminal, userManager, currentUser, proto, filesToDownload, fileIDsToDownload, outputMode, nodeNumber, func(id uuid.UUID) {
+						e.applyDownloadCharge(userManager, currentUser, charges[id], nodeNumber)
+					})
 					ih = getSessionIH(s)
 				}
 				time.Sleep(1 * time.Second)
@@ -1013,9 +1024,8 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 				failCount = len(currentUser.TaggedFileIDs)
 			}
 
-			// Clear tags, update download count, and save.
+			// Clear tags and save (download count and points were updated per file).
 			currentUser.TaggedFileIDs = nil
-			currentUser.NumDownloads += successCount
 			if saveErr := userManager.UpdateUser(currentUser); saveErr != nil {
 				log.Printf("ERROR: Node %d: Failed to save user data after download: %v", nodeNumber, saveErr)
 			}
@@ -1025,7 +1035,7 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 			time.Sleep(2 * time.Second)
 
 			// Refresh file list.
-			allFiles = e.FileMgr.GetFilesForArea(currentAreaID)
+			allFiles = e.visibleFilesForArea(currentAreaID, currentUser)
 			if selectedIndex >= len(allFiles) && len(allFiles) > 0 {
 				selectedIndex = len(allFiles) - 1
 			}
@@ -1042,30 +1052,33 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 			// so the local ih is now stale — refresh it.
 			ih = getSessionIH(s)
 			// Refresh file list after upload.
-			allFiles = e.FileMgr.GetFilesForArea(currentAreaID)
+			allFiles = e.visibleFilesForArea(currentAreaID, currentUser)
 			if selectedIndex >= len(allFiles) && len(allFiles) > 0 {
 				selectedIndex = len(allFiles) - 1
 			}
 			_ = terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25l"), outputMode)
 			needFullRedraw = true
 
-		case "e": // Edit description (sysop only)
+		case "e": // Edit file record (sysop only)
 			if !isSysop || len(allFiles) == 0 {
 				continue
 			}
 			rec := allFiles[selectedIndex]
-			clearFooter := ansi.MoveCursor(separatorRow, 1) + "\x1b[2K" + ansi.MoveCursor(cmdBarRow, 1) + "\x1b[2K"
-			_ = terminalio.WriteProcessedBytes(terminal, []byte(clearFooter), outputMode)
-			_ = terminalio.WriteProcessedBytes(terminal, []byte(ansi.MoveCursor(cmdBarRow, 1)), outputMode)
-			_ = terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25h"), outputMode)
-			_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("|15New description: |07")), outputMode)
-			newDesc, readErr := readLineFromSessionIHAllowAbort(s, terminal)
+			result, editErr := e.runFileRecordEditor(s, terminal, userManager, currentUser, rec.ID, outputMode, nodeNumber)
 			_ = terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25l"), outputMode)
-			if readErr == nil && newDesc != "" {
-				if updErr := e.FileMgr.UpdateFileDescription(rec.ID, newDesc); updErr != nil {
-					log.Printf("ERROR: Node %d: Failed to update description for %s: %v", nodeNumber, rec.Filename, updErr)
-				} else {
-					allFiles = e.FileMgr.GetFilesForArea(currentAreaID)
+			if editErr != nil {
+				if errors.Is(editErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				log.Printf("ERROR: Node %d: File record editor error: %v", nodeNumber, editErr)
+			}
+			if result == fileEditDeleted {
+				removeTaggedFileID(currentUser, rec.ID)
+			}
+			if result != fileEditUnchanged {
+				allFiles = e.visibleFilesForArea(currentAreaID, currentUser)
+				if selectedIndex >= len(allFiles) && len(allFiles) > 0 {
+					selectedIndex = len(allFiles) - 1
 				}
 			}
 			needFullRedraw = true
@@ -1095,15 +1108,10 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 					log.Printf("ERROR: Node %d: Failed to delete file %s: %v", nodeNumber, rec.Filename, delErr)
 				} else {
 					log.Printf("INFO: Node %d: Sysop deleted file '%s' from area %d.", nodeNumber, rec.Filename, currentAreaID)
+					e.logFileAdminActionNotes(userManager, currentUser, rec, "DELETE_FILE", "Record and file removed from disk")
 					// Remove from user's tag list so stale IDs don't reach batch download.
-					filtered := currentUser.TaggedFileIDs[:0]
-					for _, tid := range currentUser.TaggedFileIDs {
-						if tid != rec.ID {
-							filtered = append(filtered, tid)
-						}
-					}
-					currentUser.TaggedFileIDs = filtered
-					allFiles = e.FileMgr.GetFilesForArea(currentAreaID)
+					removeTaggedFileID(currentUser, rec.ID)
+					allFiles = e.visibleFilesForArea(currentAreaID, currentUser)
 					if selectedIndex >= len(allFiles) && len(allFiles) > 0 {
 						selectedIndex = len(allFiles) - 1
 					}
@@ -1127,20 +1135,8 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 				needFullRedraw = true
 				continue
 			}
-			// Resolve area by ID or tag.
-			var targetAreaID int
-			var targetArea *file.FileArea
-			if n, parseErr := fmt.Sscanf(strings.TrimSpace(areaInput), "%d", &targetAreaID); n == 1 && parseErr == nil {
-				if a, ok := e.FileMgr.GetAreaByID(targetAreaID); ok {
-					targetArea = a
-				}
-			} else {
-				if a, ok := e.FileMgr.GetAreaByTag(strings.TrimSpace(areaInput)); ok {
-					targetArea = a
-					targetAreaID = a.ID
-				}
-			}
-			if targetArea == nil {
+			targetArea, found := e.resolveFileAreaInput(areaInput)
+			if !found {
 				_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|01Area not found.|07\r\n")), outputMode)
 				time.Sleep(1 * time.Second)
 				needFullRedraw = true
@@ -1159,11 +1155,12 @@ func runListFilesLightbar(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 				continue
 			}
 			if proceed {
-				if mvErr := e.FileMgr.MoveFileRecord(rec.ID, targetAreaID); mvErr != nil {
-					log.Printf("ERROR: Node %d: Failed to move file %s to area %d: %v", nodeNumber, rec.Filename, targetAreaID, mvErr)
+				if mvErr := e.FileMgr.MoveFileRecord(rec.ID, targetArea.ID); mvErr != nil {
+					log.Printf("ERROR: Node %d: Failed to move file %s to area %d: %v", nodeNumber, rec.Filename, targetArea.ID, mvErr)
 				} else {
-					log.Printf("INFO: Node %d: Sysop moved file '%s' to area %d (%s).", nodeNumber, rec.Filename, targetAreaID, targetArea.Tag)
-					allFiles = e.FileMgr.GetFilesForArea(currentAreaID)
+					log.Printf("INFO: Node %d: Sysop moved file '%s' to area %d (%s).", nodeNumber, rec.Filename, targetArea.ID, targetArea.Tag)
+					e.logFileAdminAction(userManager, currentUser, rec, "MOVE_FILE", "area", e.fileAreaTag(rec.AreaID), targetArea.Tag)
+					allFiles = e.visibleFilesForArea(currentAreaID, currentUser)
 					if selectedIndex >= len(allFiles) && len(allFiles) > 0 {
 						selectedIndex = len(allFiles) - 1
 					}
@@ -0,0 +1,144 @@
+package menu
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// downloadCharge is the planned cost of a single file in a download batch.
+type downloadCharge struct {
+	areaTag  string
+	filename string
+	cost     int
+	free     bool
+}
+
+// pointsExempt reports whether u skips file point charges and ratio checks.
+func pointsExempt(cfg config.ServerConfig, u *user.User) bool {
+	if u == nil {
+		return false
+	}
+	level := cfg.RatioExemptLevel
+	if level <= 0 {
+		level = cfg.CoSysOpLevel
+	}
+	return u.RatioExempt || (level > 0 && u.AccessLevel >= level)
+}
+
+// planDownloadCharges looks up the cost of each file ID. It returns the
+// per-file charges, the total point cost, and the number of files that count
+// against the download ratio (free files do not).
+func (e *MenuExecutor) planDownloadCharges(fileIDs []uuid.UUID) (map[uuid.UUID]downloadCharge, int, int) {
+	charges := make(map[uuid.UUID]downloadCharge, len(fileIDs))
+	total, counted := 0, 0
+	for _, id := range fileIDs {
+		rec, ok := e.FileMgr.GetFileRecord(id)
+		if !ok {
+			continue
+		}
+		ch := e.downloadChargeFor(rec)
+		charges[id] = ch
+		total += ch.cost
+		if !ch.free {
+			counted++
+		}
+	}
+	return charges, total, counted
+}
+
+// downloadChargeFor returns the charge for downloading rec from its area.
+func (e *MenuExecutor) downloadChargeFor(rec file.FileRecord) downloadCharge {
+	ch := downloadCharge{filename: rec.Filename, free: rec.Free}
+	if area, ok := e.FileMgr.GetAreaByID(rec.AreaID); ok {
+		ch.areaTag = area.Tag
+		ch.cost = area.DownloadCostFor(rec)
+	}
+	return ch
+}
+
+// checkDownloadAllowance decides whether u may start a download costing
+// totalCost points that adds counted files to their download total. It
+// returns an empty string when allowed, otherwise a pipe-coded message
+// telling the user how far short they are.
+func checkDownloadAllowance(cfg config.ServerConfig, strs config.StringsConfig, u *user.User, totalCost, counted int) string {
+	if pointsExempt(cfg, u) {
+		return ""
+	}
+
+	if cfg.DownloadRatio > 0 && counted > 0 {
+		allowed := cfg.RatioFreeDownloads + u.NumUploads*cfg.DownloadRatio
+		if u.NumDownloads+counted > allowed {
+			over := u.NumDownloads + counted - cfg.RatioFreeDownloads
+			needUploads := (over+cfg.DownloadRatio-1)/cfg.DownloadRatio - u.NumUploads
+			return fmt.Sprintf("|09Download ratio exceeded (|15%d|09 download(s) per upload).|07\r\n|07You need to upload |15%d|07 more file(s) first.", cfg.DownloadRatio, needUploads)
+		}
+	}
+
+	if cfg.FilePointsEnabled && totalCost > u.FilePoints {
+		msg := strs.NotEnoughFP
+		if msg == "" {
+			msg = "|09Not enough file points!"
+		}
+		return fmt.Sprintf("%s|07\r\n|07This download costs |15%d|07 point(s). You need |15%d|07 more point(s).", msg, totalCost, totalCost-u.FilePoints)
+	}
+	return ""
+}
+
+// applyDownloadCharge debits u for one completed download and records it in
+// the file point ledger. Free files are not added to the download total.
+// The caller is responsible for persisting u.
+func (e *MenuExecutor) applyDownloadCharge(userManager *user.UserMgr, u *user.User, ch downloadCharge, nodeNumber int) {
+	if !ch.free {
+		u.NumDownloads++
+	}
+	cfg := e.GetServerConfig()
+	if !cfg.FilePointsEnabled || ch.cost <= 0 || pointsExempt(cfg, u) {
+		return
+	}
+	u.FilePoints -= ch.cost
+	if err := userManager.LogFilePoints(user.FilePointsLog{
+		UserID:   u.ID,
+		Handle:   u.Handle,
+		Reason:   user.PointsReasonDownload,
+		Delta:    -ch.cost,
+		Balance:  u.FilePoints,
+		AreaTag:  ch.areaTag,
+		Filename: ch.filename,
+	}); err != nil {
+		log.Printf("WARN: Node %d: Failed to record download charge for %s: %v", nodeNumber, u.Handle, err)
+	}
+}
+
+// applyUploadCredit grants u the points earned for an accepted upload and
+// records the credit. Returns the number of points granted. The caller is
+// responsible for persisting u.
+func (e *MenuExecutor) applyUploadCredit(userManager *user.UserMgr, u *user.User, areaTag, filename string, credit int, nodeNumber int) int {
+	if !e.GetServerConfig().FilePointsEnabled || credit <= 0 {
+		return 0
+	}
+	u.FilePoints += credit
+	if err := userManager.LogFilePoints(user.FilePointsLog{
+		UserID:   u.ID,
+		Handle:   u.Handle,
+		Reason:   user.PointsReasonUpload,
+		Delta:    credit,
+		Balance:  u.FilePoints,
+		AreaTag:  areaTag,
+		Filename: filename,
+	}); err != nil {
+		log.Printf("WARN: Node %d: Failed to record upload credit for %s: %v", nodeNumber, u.Handle, err)
+	}
+	return credit
+}
+
+// formatPointsString substitutes |FP in a strings.json entry with points.
+func formatPointsString(s string, points int) string {
+	return strings.ReplaceAll(s, "|FP", strconv.Itoa(points))
+}
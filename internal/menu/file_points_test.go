@@ -0,0 +1,73 @@
+package menu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestCheckDownloadAllowance_Ratio(t *testing.T) {
+	cfg := config.ServerConfig{CoSysOpLevel: 250, DownloadRatio: 5, RatioFreeDownloads: 10}
+	u := &user.User{AccessLevel: 20, NumUploads: 1, NumDownloads: 14}
+
+	if msg := checkDownloadAllowance(cfg, config.StringsConfig{}, u, 0, 1); msg != "" {
+		t.Errorf("15th download with 1 upload should be allowed, got %q", msg)
+	}
+	msg := checkDownloadAllowance(cfg, config.StringsConfig{}, u, 0, 3)
+	if msg == "" {
+		t.Fatal("expected ratio denial")
+	}
+	if !strings.Contains(msg, "|151|07 more file(s)") {
+		t.Errorf("expected one more upload needed, got %q", msg)
+	}
+	if msg := checkDownloadAllowance(cfg, config.StringsConfig{}, u, 0, 0); msg != "" {
+		t.Errorf("free files should bypass ratio, got %q", msg)
+	}
+}
+
+func TestCheckDownloadAllowance_Points(t *testing.T) {
+	cfg := config.ServerConfig{CoSysOpLevel: 250, FilePointsEnabled: true}
+	strs := config.StringsConfig{NotEnoughFP: "NOPE"}
+	u := &user.User{AccessLevel: 20, FilePoints: 4}
+
+	if msg := checkDownloadAllowance(cfg, strs, u, 4, 1); msg != "" {
+		t.Errorf("exact balance should be allowed, got %q", msg)
+	}
+	msg := checkDownloadAllowance(cfg, strs, u, 7, 1)
+	if !strings.HasPrefix(msg, "NOPE") || !strings.Contains(msg, "|153|07 more point(s)") {
+		t.Errorf("unexpected denial message %q", msg)
+	}
+
+	cfg.FilePointsEnabled = false
+	if msg := checkDownloadAllowance(cfg, strs, u, 7, 1); msg != "" {
+		t.Errorf("points disabled should allow, got %q", msg)
+	}
+}
+
+func TestCheckDownloadAllowance_Exempt(t *testing.T) {
+	cfg := config.ServerConfig{CoSysOpLevel: 250, FilePointsEnabled: true, DownloadRatio: 1}
+
+	flagged := &user.User{AccessLevel: 20, RatioExempt: true, NumDownloads: 100}
+	if msg := checkDownloadAllowance(cfg, config.StringsConfig{}, flagged, 50, 5); msg != "" {
+		t.Errorf("RatioExempt user should be allowed, got %q", msg)
+	}
+
+	cosysop := &user.User{AccessLevel: 250, NumDownloads: 100}
+	if msg := checkDownloadAllowance(cfg, config.StringsConfig{}, cosysop, 50, 5); msg != "" {
+		t.Errorf("CoSysOp should be exempt by default, got %q", msg)
+	}
+
+	cfg.RatioExemptLevel = 100
+	regular := &user.User{AccessLevel: 100, NumDownloads: 100}
+	if msg := checkDownloadAllowance(cfg, config.StringsConfig{}, regular, 50, 5); msg != "" {
+		t.Errorf("user at ratioExemptLevel should be exempt, got %q", msg)
+	}
+}
+
+func TestFormatPointsString(t *testing.T) {
+	if got := formatPointsString("|05Value|07: |14|FP Point(s)", 12); got != "|05Value|07: |1412 Point(s)" {
+		t.Errorf("formatPointsString = %q", got)
+	}
+}
@@ -0,0 +1,427 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+	"github.com/stlalpha/vision3/internal/ziplab"
+)
+
+// fileEditResult reports what the file record editor did to the record.
+type fileEditResult int
+
+const (
+	fileEditUnchanged fileEditResult = iota
+	fileEditUpdated
+	fileEditMoved
+	fileEditDeleted
+)
+
+// fileEditMaxDescLines is the number of description lines shown in the editor.
+const fileEditMaxDescLines = 6
+
+// runEditFileRecord is the RunnableFunc for FILEM's E key. It prompts for a
+// filename in the current area and opens the file record editor on it.
+func runEditFileRecord(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil || !e.isCoSysOpOrAbove(currentUser) {
+		return currentUser, "", nil
+	}
+	if currentUser.CurrentFileAreaID <= 0 {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|01Error: No file area selected.|07\r\n")), outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|15Filename to edit: |07")), outputMode)
+	input, err := readLineFromSessionIHAllowAbort(s, terminal)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, "LOGOFF", io.EOF
+		}
+		return currentUser, "", nil
+	}
+	name := strings.TrimSpace(input)
+	if name == "" {
+		return currentUser, "", nil
+	}
+
+	var target *file.FileRecord
+	for _, rec := range e.FileMgr.GetFilesForArea(currentUser.CurrentFileAreaID) {
+		if strings.EqualFold(rec.Filename, name) {
+			r := rec
+			target = &r
+			break
+		}
+	}
+	if target == nil {
+		msg := fmt.Sprintf("\r\n|01'%s' not found in %s.|07\r\n", name, currentUser.CurrentFileAreaTag)
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+
+	result, err := e.runFileRecordEditor(s, terminal, userManager, currentUser, target.ID, outputMode, nodeNumber)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, "LOGOFF", io.EOF
+		}
+		return currentUser, "", err
+	}
+	if result == fileEditDeleted {
+		removeTaggedFileID(currentUser, target.ID)
+	}
+	return currentUser, "", nil
+}
+
+// runFileRecordEditor shows a full-screen editor for one file record. Every
+// change is applied immediately and written to the admin activity log.
+// Returns when the sysop quits, or after the record is moved or deleted.
+func (e *MenuExecutor) runFileRecordEditor(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, admin *user.User, fileID uuid.UUID, outputMode ansi.OutputMode, nodeNumber int) (fileEditResult, error) {
+	result := fileEditUnchanged
+	ih := getSessionIH(s)
+
+	// Show the cursor for prompts; callers re-hide it as needed.
+	_ = terminalio.WriteProcessedBytes(terminal, []byte("\x1b[?25h"), outputMode)
+
+	for {
+		rec, ok := e.FileMgr.GetFileRecord(fileID)
+		if !ok {
+			return fileEditDeleted, nil
+		}
+		e.renderFileRecordEditor(terminal, rec, outputMode)
+
+		key, err := ih.ReadKey()
+		if err != nil {
+			if errors.Is(err, editor.ErrIdleTimeout) {
+				return result, io.EOF
+			}
+			return result, err
+		}
+		if key == editor.KeyEsc || key == editor.KeyEnter {
+			return result, nil
+		}
+		if key < 32 || key >= 127 {
+			continue
+		}
+
+		switch strings.ToLower(string(rune(key))) {
+		case "q":
+			return result, nil
+
+		case "1": // Filename
+			newName, ok, err := e.promptFileEditField(s, terminal, "New filename", outputMode)
+			if err != nil {
+				return result, err
+			}
+			if !ok || newName == rec.Filename {
+				continue
+			}
+			if renErr := e.FileMgr.RenameFileRecord(rec.ID, newName); renErr != nil {
+				log.Printf("ERROR: Node %d: Failed to rename %s to %s: %v", nodeNumber, rec.Filename, newName, renErr)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Rename failed: %v|07", renErr), outputMode)
+				continue
+			}
+			e.logFileAdminAction(userManager, admin, rec, "EDIT_FILE", "filename", rec.Filename, newName)
+			result = fileEditUpdated
+
+		case "2": // Description
+			newDesc, ok, err := e.promptFileEditField(s, terminal, "New description", outputMode)
+			if err != nil {
+				return result, err
+			}
+			newDesc = sanitizeControlChars(newDesc)
+			if !ok || newDesc == rec.Description {
+				continue
+			}
+			if e.updateFileRecordField(rec, func(r *file.FileRecord) { r.Description = newDesc }, nodeNumber) {
+				e.logFileAdminAction(userManager, admin, rec, "EDIT_FILE", "description", rec.Description, newDesc)
+				result = fileEditUpdated
+			}
+
+		case "3": // Uploader
+			newUploader, ok, err := e.promptFileEditField(s, terminal, "New uploader", outputMode)
+			if err != nil {
+				return result, err
+			}
+			if !ok || newUploader == rec.UploadedBy {
+				continue
+			}
+			if e.updateFileRecordField(rec, func(r *file.FileRecord) { r.UploadedBy = newUploader }, nodeNumber) {
+				e.logFileAdminAction(userManager, admin, rec, "EDIT_FILE", "uploader", rec.UploadedBy, newUploader)
+				result = fileEditUpdated
+			}
+
+		case "4": // Download count
+			input, ok, err := e.promptFileEditField(s, terminal, "New download count", outputMode)
+			if err != nil {
+				return result, err
+			}
+			if !ok {
+				continue
+			}
+			n, convErr := strconv.Atoi(input)
+			if convErr != nil || n < 0 {
+				e.fileEditNotice(terminal, "|01Download count must be a number of zero or more.|07", outputMode)
+				continue
+			}
+			if n == rec.DownloadCount {
+				continue
+			}
+			if e.updateFileRecordField(rec, func(r *file.FileRecord) { r.DownloadCount = n }, nodeNumber) {
+				e.logFileAdminAction(userManager, admin, rec, "EDIT_FILE", "downloads", strconv.Itoa(rec.DownloadCount), strconv.Itoa(n))
+				result = fileEditUpdated
+			}
+
+		case "5": // Free toggle
+			newFree := !rec.Free
+			if e.updateFileRecordField(rec, func(r *file.FileRecord) { r.Free = newFree }, nodeNumber) {
+				e.logFileAdminAction(userManager, admin, rec, "EDIT_FILE", "free", strconv.FormatBool(rec.Free), strconv.FormatBool(newFree))
+				result = fileEditUpdated
+			}
+
+		case "r": // Re-extract FILE_ID.DIZ
+			if e.reextractFileDIZ(terminal, userManager, admin, rec, outputMode, nodeNumber) {
+				result = fileEditUpdated
+			}
+
+		case "m": // Move to another area
+			input, ok, err := e.promptFileEditField(s, terminal, "Move to area (# or tag)", outputMode)
+			if err != nil {
+				return result, err
+			}
+			if !ok {
+				continue
+			}
+			targetArea, found := e.resolveFileAreaInput(input)
+			if !found {
+				e.fileEditNotice(terminal, "|01Area not found.|07", outputMode)
+				continue
+			}
+			termWidth, termHeight := getTerminalSize(s)
+			proceed, promptErr := e.PromptYesNo(s, terminal, fmt.Sprintf("Move %s to %s?", rec.Filename, targetArea.Name), outputMode, nodeNumber, termWidth, termHeight, false)
+			if promptErr != nil {
+				return result, promptErr
+			}
+			if !proceed {
+				continue
+			}
+			if mvErr := e.FileMgr.MoveFileRecord(rec.ID, targetArea.ID); mvErr != nil {
+				log.Printf("ERROR: Node %d: Failed to move file %s to area %d: %v", nodeNumber, rec.Filename, targetArea.ID, mvErr)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Move failed: %v|07", mvErr), outputMode)
+				continue
+			}
+			log.Printf("INFO: Node %d: Sysop moved file '%s' to area %d (%s).", nodeNumber, rec.Filename, targetArea.ID, targetArea.Tag)
+			e.logFileAdminAction(userManager, admin, rec, "MOVE_FILE", "area", e.fileAreaTag(rec.AreaID), targetArea.Tag)
+			return fileEditMoved, nil
+
+		case "d": // Delete
+			termWidth, termHeight := getTerminalSize(s)
+			proceed, promptErr := e.PromptYesNo(s, terminal, fmt.Sprintf("Delete record for %s?", rec.Filename), outputMode, nodeNumber, termWidth, termHeight, false)
+			if promptErr != nil {
+				return result, promptErr
+			}
+			if !proceed {
+				continue
+			}
+			fromDisk, promptErr := e.PromptYesNo(s, terminal, "Also remove the file from disk?", outputMode, nodeNumber, termWidth, termHeight, true)
+			if promptErr != nil {
+				return result, promptErr
+			}
+			if delErr := e.FileMgr.DeleteFileRecord(rec.ID, fromDisk); delErr != nil {
+				log.Printf("ERROR: Node %d: Failed to delete file %s: %v", nodeNumber, rec.Filename, delErr)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Delete failed: %v|07", delErr), outputMode)
+				continue
+			}
+			log.Printf("INFO: Node %d: Sysop deleted file '%s' from area %d (disk: %t).", nodeNumber, rec.Filename, rec.AreaID, fromDisk)
+			notes := "Record only; file left on disk"
+			if fromDisk {
+				notes = "Record and file removed from disk"
+			}
+			e.logFileAdminActionNotes(userManager, admin, rec, "DELETE_FILE", notes)
+			return fileEditDeleted, nil
+		}
+	}
+}
+
+// renderFileRecordEditor draws the editor screen for rec.
+func (e *MenuExecutor) renderFileRecordEditor(terminal *term.Terminal, rec file.FileRecord, outputMode ansi.OutputMode) {
+	var b strings.Builder
+	b.WriteString(ansi.ClearScreen())
+
+	areaLabel := e.fileAreaTag(rec.AreaID)
+	if area, ok := e.FileMgr.GetAreaByID(rec.AreaID); ok {
+		areaLabel = fmt.Sprintf("%s |08(|07%s|08)", area.Tag, area.Name)
+	}
+	b.WriteString(fmt.Sprintf("|09File Record Editor |08- |15%s|07\r\n", areaLabel))
+	b.WriteString("|08" + strings.Repeat("-", 79) + "|07\r\n")
+
+	descLines := formatDIZLines(rec.Description, 60, fileEditMaxDescLines)
+	free := "No"
+	if rec.Free {
+		free = "Yes"
+	}
+
+	b.WriteString(fmt.Sprintf(" |08[|141|08] |11Filename    |08: |15%s\r\n", rec.Filename))
+	if len(descLines) == 0 {
+		b.WriteString(" |08[|142|08] |11Description |08: |07(none)\r\n")
+	}
+	for i, line := range descLines {
+		if i == 0 {
+			b.WriteString(fmt.Sprintf(" |08[|142|08] |11Description |08: |07%s\r\n", line))
+		} else {
+			b.WriteString(fmt.Sprintf("                   |07%s\r\n", line))
+		}
+	}
+	b.WriteString(fmt.Sprintf(" |08[|143|08] |11Uploader    |08: |07%s\r\n", rec.UploadedBy))
+	b.WriteString(fmt.Sprintf(" |08[|144|08] |11Downloads   |08: |07%d\r\n", rec.DownloadCount))
+	b.WriteString(fmt.Sprintf(" |08[|145|08] |11Free        |08: |07%s\r\n", free))
+	b.WriteString(fmt.Sprintf("     |03Size        |08: |07%d bytes\r\n", rec.Size))
+	b.WriteString(fmt.Sprintf("     |03Uploaded    |08: |07%s\r\n", rec.UploadedAt.Format("01/02/2006 15:04")))
+	if rec.CRC32 != "" {
+		b.WriteString(fmt.Sprintf("     |03CRC-32      |08: |07%s\r\n", rec.CRC32))
+	}
+	b.WriteString("\r\n")
+	b.WriteString(" |08[|14M|08] |11Move to another area\r\n")
+	b.WriteString(" |08[|14R|08] |11Re-extract FILE_ID.DIZ\r\n")
+	b.WriteString(" |08[|14D|08] |11Delete record\r\n")
+	b.WriteString(" |08[|14Q|08] |11Quit editor\r\n")
+	b.WriteString("\r\n|15Command: |07")
+
+	_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(b.String())), outputMode)
+}
+
+// promptFileEditField asks for a new field value. ok is false when the sysop
+// pressed ESC or entered nothing. Only EOF is returned as an error.
+func (e *MenuExecutor) promptFileEditField(s ssh.Session, terminal *term.Terminal, label string, outputMode ansi.OutputMode) (string, bool, error) {
+	_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(fmt.Sprintf("\r\n|15%s: |07", label))), outputMode)
+	input, err := readLineFromSessionIHAllowAbort(s, terminal)
+	if err != nil {
+		if errors.Is(err, errInputAborted) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	input = strings.TrimSpace(input)
+	return input, input != "", nil
+}
+
+// fileEditNotice shows a short message below the editor menu.
+func (e *MenuExecutor) fileEditNotice(terminal *term.Terminal, msg string, outputMode ansi.OutputMode) {
+	_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n"+msg+"\r\n")), outputMode)
+	time.Sleep(1500 * time.Millisecond)
+}
+
+// updateFileRecordField applies fn to the record and reports success.
+func (e *MenuExecutor) updateFileRecordField(rec file.FileRecord, fn func(*file.FileRecord), nodeNumber int) bool {
+	if err := e.FileMgr.UpdateFileRecord(rec.ID, fn); err != nil {
+		log.Printf("ERROR: Node %d: Failed to update file record %s: %v", nodeNumber, rec.Filename, err)
+		return false
+	}
+	return true
+}
+
+// reextractFileDIZ replaces the record's description with the FILE_ID.DIZ
+// from its archive. Returns true if the description changed.
+func (e *MenuExecutor) reextractFileDIZ(terminal *term.Terminal, userManager *user.UserMgr, admin *user.User, rec file.FileRecord, outputMode ansi.OutputMode, nodeNumber int) bool {
+	if !e.FileMgr.IsSupportedArchive(rec.Filename) {
+		e.fileEditNotice(terminal, "|01Not a supported archive type.|07", outputMode)
+		return false
+	}
+	filePath, err := e.FileMgr.GetFilePath(rec.ID)
+	if err != nil {
+		e.fileEditNotice(terminal, fmt.Sprintf("|01Cannot locate file: %v|07", err), outputMode)
+		return false
+	}
+	diz, err := ziplab.ExtractDIZFromArchive(filePath, e.RootConfigPath)
+	if err != nil {
+		log.Printf("WARN: Node %d: DIZ extraction failed for %s: %v", nodeNumber, rec.Filename, err)
+		e.fileEditNotice(terminal, fmt.Sprintf("|01DIZ extraction failed: %v|07", err), outputMode)
+		return false
+	}
+	diz = sanitizeControlChars(strings.TrimRight(diz, " \t\r\n"))
+	if diz == "" {
+		e.fileEditNotice(terminal, "|07No FILE_ID.DIZ found in archive.", outputMode)
+		return false
+	}
+	if diz == rec.Description {
+		e.fileEditNotice(terminal, "|07Description already matches FILE_ID.DIZ.", outputMode)
+		return false
+	}
+	if !e.updateFileRecordField(rec, func(r *file.FileRecord) { r.Description = diz }, nodeNumber) {
+		return false
+	}
+	e.logFileAdminAction(userManager, admin, rec, "REEXTRACT_DIZ", "description", rec.Description, diz)
+	return true
+}
+
+// resolveFileAreaInput finds a file area by numeric ID or tag.
+func (e *MenuExecutor) resolveFileAreaInput(input string) (*file.FileArea, bool) {
+	input = strings.TrimSpace(input)
+	if id, err := strconv.Atoi(input); err == nil {
+		return e.FileMgr.GetAreaByID(id)
+	}
+	return e.FileMgr.GetAreaByTag(input)
+}
+
+// fileAreaTag returns the tag for an area ID, or the ID if unknown.
+func (e *MenuExecutor) fileAreaTag(areaID int) string {
+	if area, ok := e.FileMgr.GetAreaByID(areaID); ok {
+		return area.Tag
+	}
+	return strconv.Itoa(areaID)
+}
+
+// logFileAdminAction records a file record change in the admin activity log.
+func (e *MenuExecutor) logFileAdminAction(userManager *user.UserMgr, admin *user.User, rec file.FileRecord, action, field, oldValue, newValue string) {
+	if userManager == nil || admin == nil {
+		return
+	}
+	_ = userManager.LogAdminActivity(user.AdminActivityLog{
+		AdminUsername: admin.Username,
+		AdminID:       admin.ID,
+		TargetFile:    e.fileRecordLocation(rec),
+		Action:        action,
+		FieldName:     field,
+		OldValue:      oldValue,
+		NewValue:      newValue,
+	})
+}
+
+// logFileAdminActionNotes records a file action that has no field change.
+func (e *MenuExecutor) logFileAdminActionNotes(userManager *user.UserMgr, admin *user.User, rec file.FileRecord, action, notes string) {
+	if userManager == nil || admin == nil {
+		return
+	}
+	_ = userManager.LogAdminActivity(user.AdminActivityLog{
+		AdminUsername: admin.Username,
+		AdminID:       admin.ID,
+		TargetFile:    e.fileRecordLocation(rec),
+		Action:        action,
+		Notes:         notes,
+	})
+}
+
+// removeTaggedFileID drops id from the user's batch download tags.
+func removeTaggedFileID(u *user.User, id uuid.UUID) {
+	filtered := u.TaggedFileIDs[:0]
+	for _, tid := range u.TaggedFileIDs {
+		if tid != id {
+			filtered = append(filtered, tid)
+		}
+	}
+	u.TaggedFileIDs = filtered
+}
@@ -0,0 +1,23 @@
+package menu
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestRemoveTaggedFileID(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	u := &user.User{TaggedFileIDs: []uuid.UUID{a, b, c}}
+
+	removeTaggedFileID(u, b)
+	if len(u.TaggedFileIDs) != 2 || u.TaggedFileIDs[0] != a || u.TaggedFileIDs[1] != c {
+		t.Errorf("unexpected tags after removal: %v", u.TaggedFileIDs)
+	}
+
+	removeTaggedFileID(u, uuid.New())
+	if len(u.TaggedFileIDs) != 2 {
+		t.Errorf("removing an untagged ID changed the list: %v", u.TaggedFileIDs)
+	}
+}
@@ -0,0 +1,530 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/user"
+	"github.com/stlalpha/vision3/internal/ziplab"
+)
+
+// remoteFiles is one SFTP or SCP connection's view of the file areas. Each
+// area the user may list appears as a directory named after its tag, and
+// the files the user may see appear inside it. Downloads and uploads go
+// through the same ACS, ratio, point and validation rules as the menus.
+type remoteFiles struct {
+	e       *MenuExecutor
+	um      *user.UserMgr
+	userID  int
+	via     string          // "SFTP" or "SCP", for logs
+	s       ssh.Session     // For ACS checks; nil in tests
+	ctx     context.Context // Done when the connection drops
+	started time.Time
+	mu      sync.Mutex // Serializes updates to the user record
+}
+
+func (e *MenuExecutor) newRemoteFiles(s ssh.Session, um *user.UserMgr, u *user.User, via string) *remoteFiles {
+	ctx := context.Background()
+	if s != nil {
+		ctx = s.Context()
+	}
+	return &remoteFiles{e: e, um: um, userID: u.ID, via: via, s: s, ctx: ctx, started: time.Now()}
+}
+
+// user returns a fresh copy of the connected user, so changes made by an
+// interactive session on another node are not overwritten.
+func (rf *remoteFiles) user() (*user.User, error) {
+	u, ok := rf.um.GetUserByID(rf.userID)
+	if !ok || u.DeletedUser {
+		return nil, fmt.Errorf("%w: account no longer available", sftp.ErrSSHFxPermissionDenied)
+	}
+	return u, nil
+}
+
+func (rf *remoteFiles) allowed(acs string, u *user.User) bool {
+	return checkACS(acs, u, rf.s, nil, rf.started)
+}
+
+// areas returns the areas u may list, in ID order.
+func (rf *remoteFiles) areas(u *user.User) []file.FileArea {
+	var visible []file.FileArea
+	for _, a := range rf.e.FileMgr.ListAreas() {
+		if a.Tag == "" || strings.ContainsAny(a.Tag, `/\`) {
+			continue
+		}
+		if rf.allowed(a.ACSList, u) {
+			visible = append(visible, a)
+		}
+	}
+	return visible
+}
+
+// area finds a listable area by its directory name.
+func (rf *remoteFiles) area(u *user.User, tag string) (file.FileArea, bool) {
+	for _, a := range rf.areas(u) {
+		if strings.EqualFold(a.Tag, tag) {
+			return a, true
+		}
+	}
+	return file.FileArea{}, false
+}
+
+// splitRemotePath splits a client path into an area tag and a filename.
+// Both are empty for the root; name is empty for an area directory.
+func splitRemotePath(p string) (tag, name string, err error) {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return "", "", nil
+	}
+	parts := strings.Split(p, "/")
+	switch len(parts) {
+	case 1:
+		return parts[0], "", nil
+	case 2:
+		return parts[0], parts[1], nil
+	}
+	return "", "", os.ErrNotExist
+}
+
+// record finds a file u may see in area by name.
+func (rf *remoteFiles) record(u *user.User, area file.FileArea, name string) (file.FileRecord, bool) {
+	for _, r := range rf.e.visibleFilesForArea(area.ID, u) {
+		if strings.EqualFold(r.Filename, name) {
+			return r, true
+		}
+	}
+	return file.FileRecord{}, false
+}
+
+// remoteDownload is an open download. It is charged when closed, by the
+// number of distinct bytes the client read (see partialCharge).
+type remoteDownload struct {
+	rf     *remoteFiles
+	f      *os.File
+	rec    file.FileRecord
+	charge downloadCharge
+	mu     sync.Mutex
+	read   byteRanges // Distinct ranges read so far
+	opened time.Time
+}
+
+// byteRanges is a set of byte ranges, kept sorted and merged, so reading
+// the same bytes twice counts them once.
+type byteRanges struct {
+	spans [][2]int64 // [start, end)
+}
+
+// add records the range [start, end).
+func (r *byteRanges) add(start, end int64) {
+	if end <= start {
+		return
+	}
+	merged := make([][2]int64, 0, len(r.spans)+1)
+	i := 0
+	for ; i < len(r.spans) && r.spans[i][1] < start; i++ {
+		merged = append(merged, r.spans[i])
+	}
+	for ; i < len(r.spans) && r.spans[i][0] <= end; i++ {
+		start = min(start, r.spans[i][0])
+		end = max(end, r.spans[i][1])
+	}
+	merged = append(merged, [2]int64{start, end})
+	r.spans = append(merged, r.spans[i:]...)
+}
+
+// total returns the number of bytes covered.
+func (r *byteRanges) total() int64 {
+	var n int64
+	for _, sp := range r.spans {
+		n += sp[1] - sp[0]
+	}
+	return n
+}
+
+// partialCharge scales ch to a download that stopped after received of
+// size bytes. Points are charged for the share received, rounded up. The
+// download counts toward the ratio, like a whole file, once at least half
+// of it was received. counted reports whether it counts.
+func partialCharge(ch downloadCharge, received, size int64) (scaled downloadCharge, counted bool) {
+	scaled = ch
+	if size > 0 && ch.cost > 0 {
+		scaled.cost = int((int64(ch.cost)*received + size - 1) / size)
+	}
+	counted = !ch.free && received*2 >= size
+	scaled.free = !counted
+	return scaled, counted
+}
+
+// openDownload checks that the user may download the file at p and opens it.
+func (rf *remoteFiles) openDownload(p string) (*remoteDownload, error) {
+	u, err := rf.user()
+	if err != nil {
+		return nil, err
+	}
+	tag, name, err := splitRemotePath(p)
+	if err != nil || name == "" {
+		return nil, os.ErrNotExist
+	}
+	area, ok := rf.area(u, tag)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	rec, ok := rf.record(u, area, name)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	if !rf.allowed(area.ACSDownload, u) {
+		return nil, fmt.Errorf("%w: no download access to %s", sftp.ErrSSHFxPermissionDenied, area.Tag)
+	}
+	if rf.e.downloadHeldForValidation(u, rec.ID) {
+		return nil, fmt.Errorf("%w: %s is awaiting validation", sftp.ErrSSHFxPermissionDenied, rec.Filename)
+	}
+
+	ch := rf.e.downloadChargeFor(rec)
+	counted := 1
+	if ch.free {
+		counted = 0
+	}
+	if denyMsg := checkDownloadAllowance(rf.e.downloadConfigFor(u), rf.e.LoadedStrings, u, ch.cost, counted); denyMsg != "" {
+		log.Printf("INFO: %s: Download of %s by %s denied (cost %d, points %d)", rf.via, rec.Filename, u.Handle, ch.cost, u.FilePoints)
+		return nil, fmt.Errorf("%w: %s", sftp.ErrSSHFxPermissionDenied, plainText(denyMsg))
+	}
+
+	filePath, err := rf.e.FileMgr.GetFilePath(rec.ID)
+	if errors.Is(err, file.ErrFileOffline) {
+		name, queued, qErr := rf.e.requestOfflineFile(u, rec.ID, 0)
+		switch {
+		case qErr != nil:
+			return nil, fmt.Errorf("%w: %s is offline", sftp.ErrSSHFxPermissionDenied, rec.Filename)
+		case queued:
+			return nil, fmt.Errorf("%w: %s is offline; a request has been queued", sftp.ErrSSHFxPermissionDenied, name)
+		}
+		return nil, fmt.Errorf("%w: %s is offline; already requested", sftp.ErrSSHFxPermissionDenied, name)
+	}
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("WARN: %s: Failed to open %s for %s: %v", rf.via, filePath, u.Handle, err)
+		return nil, os.ErrNotExist
+	}
+	log.Printf("INFO: %s: %s downloading %s from %s", rf.via, u.Handle, rec.Filename, area.Tag)
+	return &remoteDownload{rf: rf, f: f, rec: rec, charge: ch, opened: time.Now()}, nil
+}
+
+func (d *remoteDownload) ReadAt(p []byte, off int64) (int, error) {
+	n, err := d.f.ReadAt(p, off)
+	d.mu.Lock()
+	d.read.add(off, off+int64(n))
+	d.mu.Unlock()
+	return n, err
+}
+
+// Close closes the file and charges the download. A file read in full is
+// charged as usual; one read in part is charged for what was received.
+func (d *remoteDownload) Close() error {
+	size := d.rec.Size
+	if fi, err := d.f.Stat(); err == nil {
+		size = fi.Size()
+	}
+	d.f.Close()
+	d.mu.Lock()
+	received := d.read.total()
+	d.mu.Unlock()
+	if received >= size {
+		err := d.rf.completeDownload(d.rec, d.charge, true)
+		d.rf.logTransfer(user.TransferDownload, d.opened, []user.TransferFile{{Name: d.rec.Filename, AreaID: d.rec.AreaID, Size: size}}, true)
+		return err
+	}
+
+	ch, counted := partialCharge(d.charge, received, size)
+	if received == 0 || (!counted && ch.cost <= 0) {
+		log.Printf("INFO: %s: Download of %s stopped after %d of %d bytes; not charged", d.rf.via, d.rec.Filename, received, size)
+		d.rf.logTransfer(user.TransferDownload, d.opened, nil, false)
+		return nil
+	}
+	// A counted partial is logged as a download of the bytes received.
+	var files []user.TransferFile
+	if counted {
+		files = []user.TransferFile{{Name: d.rec.Filename, AreaID: d.rec.AreaID, Size: received}}
+	}
+	log.Printf("INFO: %s: Download of %s stopped after %d of %d bytes; charged %d point(s), counted %v", d.rf.via, d.rec.Filename, received, size, ch.cost, counted)
+	err := d.rf.completeDownload(d.rec, ch, counted)
+	d.rf.logTransfer(user.TransferDownload, d.opened, files, false)
+	return err
+}
+
+// logTransfer adds a finished SFTP/scp transfer to the transfer log and the
+// user's byte totals.
+func (rf *remoteFiles) logTransfer(direction string, started time.Time, files []user.TransferFile, success bool) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	u, err := rf.user()
+	if err != nil {
+		return
+	}
+	rf.e.recordTransfer(rf.um, u, 0, user.NewTransferRecord(direction, rf.via, started, files, success))
+	if err := rf.um.UpdateUser(u); err != nil {
+		log.Printf("ERROR: %s: Failed to save transfer totals for %s: %v", rf.via, u.Handle, err)
+	}
+}
+
+// completeDownload debits the user for a download, as the transfer menus
+// do, and credits it to the file's download count if countFile is set.
+func (rf *remoteFiles) completeDownload(rec file.FileRecord, ch downloadCharge, countFile bool) error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if countFile {
+		if err := rf.e.FileMgr.IncrementDownloadCount(rec.ID); err != nil {
+			log.Printf("WARN: %s: Failed to increment download count for %s: %v", rf.via, rec.Filename, err)
+		}
+	}
+	u, err := rf.user()
+	if err != nil {
+		return nil
+	}
+	rf.e.applyDownloadCharge(rf.um, u, ch, 0)
+	if err := rf.um.UpdateUser(u); err != nil {
+		log.Printf("ERROR: %s: Failed to save %s after download: %v", rf.via, u.Handle, err)
+	}
+	return nil
+}
+
+// remoteUpload is an upload in progress. The file is written to a private
+// incoming directory and only added to the area when closed.
+type remoteUpload struct {
+	rf          *remoteFiles
+	f           *os.File
+	area        file.FileArea
+	name        string
+	incomingDir string
+	opened      time.Time
+}
+
+// createUpload checks that the user may upload to the area at p and
+// creates the incoming file.
+func (rf *remoteFiles) createUpload(p string) (*remoteUpload, error) {
+	u, err := rf.user()
+	if err != nil {
+		return nil, err
+	}
+	tag, name, err := splitRemotePath(p)
+	if err != nil || tag == "" || name == "" {
+		return nil, fmt.Errorf("%w: uploads go into an area directory", sftp.ErrSSHFxPermissionDenied)
+	}
+	area, ok := rf.area(u, tag)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	if !rf.allowed(area.ACSUpload, u) {
+		return nil, fmt.Errorf("%w: no upload access to %s", sftp.ErrSSHFxPermissionDenied, area.Tag)
+	}
+	if area.Offline {
+		return nil, fmt.Errorf("%w: %s is offline", sftp.ErrSSHFxPermissionDenied, area.Tag)
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("%w: invalid filename %q", sftp.ErrSSHFxPermissionDenied, name)
+	}
+	if rf.e.areaHasFilename(area.ID, name) {
+		return nil, fmt.Errorf("%w: %s already exists in %s", sftp.ErrSSHFxPermissionDenied, name, area.Tag)
+	}
+
+	targetDir, err := rf.e.FileMgr.GetAreaUploadPath(area.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, err
+	}
+	incomingDir, err := os.MkdirTemp(targetDir, ".incoming-*")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filepath.Join(incomingDir, name))
+	if err != nil {
+		os.RemoveAll(incomingDir)
+		return nil, err
+	}
+	log.Printf("INFO: %s: %s uploading %s to %s", rf.via, u.Handle, name, area.Tag)
+	return &remoteUpload{rf: rf, f: f, area: area, name: name, incomingDir: incomingDir, opened: time.Now()}, nil
+}
+
+func (up *remoteUpload) WriteAt(p []byte, off int64) (int, error) {
+	return up.f.WriteAt(p, off)
+}
+
+func (up *remoteUpload) Write(p []byte) (int, error) {
+	return up.f.Write(p)
+}
+
+// Close finishes the upload. A connection that dropped mid-transfer leaves
+// nothing behind; otherwise the file goes through the upload pipeline and
+// any rejection is reported back to the client.
+func (up *remoteUpload) Close() error {
+	if up.rf.ctx.Err() != nil {
+		up.abort()
+		return nil
+	}
+	defer os.RemoveAll(up.incomingDir)
+	if err := up.f.Close(); err != nil {
+		return err
+	}
+	received := user.TransferFile{Name: up.name, AreaID: up.area.ID}
+	if fi, err := os.Stat(up.f.Name()); err == nil {
+		received.Size = fi.Size()
+	}
+	err := up.rf.acceptUpload(up.area, up.name, up.f.Name())
+	var files []user.TransferFile
+	if err == nil {
+		files = []user.TransferFile{received}
+	}
+	up.rf.logTransfer(user.TransferUpload, up.opened, files, err == nil)
+	return err
+}
+
+// abort discards an upload that did not arrive whole.
+func (up *remoteUpload) abort() {
+	up.f.Close()
+	os.RemoveAll(up.incomingDir)
+	log.Printf("INFO: %s: Upload of %s did not complete; discarded", up.rf.via, up.name)
+	up.rf.logTransfer(user.TransferUpload, up.opened, nil, false)
+}
+
+// acceptUpload runs a received file through the same checks as the upload
+// menu (duplicates, ZipLab, validation) and adds it to area. There is no
+// one to ask for a description, so FILE_ID.DIZ is used when present.
+func (rf *remoteFiles) acceptUpload(area file.FileArea, name, incomingPath string) error {
+	u, err := rf.user()
+	if err != nil {
+		return err
+	}
+	if rf.e.areaHasFilename(area.ID, name) {
+		return fmt.Errorf("%w: %s already exists in %s", sftp.ErrSSHFxPermissionDenied, name, area.Tag)
+	}
+
+	hashes, hashErr := file.HashFile(incomingPath)
+	if hashErr == nil {
+		if dup, found := rf.e.FileMgr.FindFileBySHA256(hashes.SHA256); found {
+			log.Printf("WARN: %s: Duplicate content rejected: %s matches %s (ID: %s)", rf.via, name, rf.e.fileRecordLocation(dup), dup.ID)
+			return fmt.Errorf("%s is identical to %s", name, rf.e.fileRecordLocation(dup))
+		}
+	}
+
+	var description, zipLabResults string
+	pipelineRan := false
+	zlCfg, zlErr := ziplab.LoadConfig(rf.e.RootConfigPath)
+	if zlErr != nil {
+		log.Printf("WARN: %s: Failed to load ZipLab config: %v", rf.via, zlErr)
+	}
+	if zlErr == nil && zlCfg.Enabled && zlCfg.RunOnUpload && zlCfg.IsArchiveSupported(name) {
+		log.Printf("INFO: %s: Running ZipLab pipeline on %s", rf.via, name)
+		proc := ziplab.NewProcessor(zlCfg, filepath.Join(filepath.Dir(rf.e.RootConfigPath), "ziplab"))
+		result := proc.RunPipeline(incomingPath, nil)
+		pipelineRan = true
+		zipLabResults = result.Summary()
+		if !result.Success {
+			log.Printf("ERROR: %s: ZipLab pipeline failed for %s: %v", rf.via, name, result.Error)
+			return fmt.Errorf("%s was rejected by ZipLab", name)
+		}
+		if result.Description != "" {
+			description = sanitizeControlChars(strings.TrimRight(result.Description, " \t\r\n"))
+		}
+	}
+	if description == "" {
+		description = "No description"
+	}
+
+	fi, err := os.Stat(incomingPath)
+	if err != nil {
+		return err
+	}
+	if pipelineRan || hashErr != nil {
+		if hashes, err = file.HashFile(incomingPath); err != nil {
+			log.Printf("WARN: %s: Failed to hash %s after pipeline: %v", rf.via, name, err)
+		} else if dup, found := rf.e.FileMgr.FindFileBySHA256(hashes.SHA256); found {
+			log.Printf("WARN: %s: Duplicate content rejected after ZipLab: %s matches %s (ID: %s)", rf.via, name, rf.e.fileRecordLocation(dup), dup.ID)
+			return fmt.Errorf("%s is identical to %s", name, rf.e.fileRecordLocation(dup))
+		}
+	}
+
+	holdForValidation := area.RequireValidation && !rf.e.isCoSysOpOrAbove(u)
+	record := file.FileRecord{
+		ID:            uuid.New(),
+		AreaID:        area.ID,
+		Filename:      name,
+		Description:   description,
+		Size:          fi.Size(),
+		UploadedAt:    time.Now(),
+		UploadedBy:    u.Handle,
+		SHA256:        hashes.SHA256,
+		CRC32:         hashes.CRC32,
+		Unvalidated:   holdForValidation,
+		ZipLabResults: zipLabResults,
+	}
+
+	if err := rf.e.FileMgr.AddUploadedFile(record, incomingPath); err != nil {
+		if errors.Is(err, file.ErrFilenameTaken) {
+			return fmt.Errorf("%w: %s already exists in %s", sftp.ErrSSHFxPermissionDenied, name, area.Tag)
+		}
+		log.Printf("ERROR: %s: Failed to add %s to area: %v", rf.via, name, err)
+		return fmt.Errorf("failed to accept %s", name)
+	}
+	log.Printf("INFO: %s: Added file record for %s (ID: %s) from %s", rf.via, name, record.ID, u.Handle)
+
+	// Held uploads earn their upload count and points when validated.
+	if holdForValidation {
+		return nil
+	}
+	rf.e.fillWants(record, 0)
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if u, err = rf.user(); err != nil {
+		return nil
+	}
+	rf.e.applyUploadCredit(rf.um, u, area.Tag, name, area.UploadCreditFor(record.Size, rf.e.GetServerConfig().UploadKBPerPoint), 0)
+	u.NumUploads++
+	if err := rf.um.UpdateUser(u); err != nil {
+		log.Printf("ERROR: %s: Failed to update upload count for %s: %v", rf.via, u.Handle, err)
+	}
+	return nil
+}
+
+// areaHasFilename reports whether area already has a record named name.
+func (e *MenuExecutor) areaHasFilename(areaID int, name string) bool {
+	for _, r := range e.FileMgr.GetFilesForArea(areaID) {
+		if strings.EqualFold(r.Filename, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// plainText strips pipe codes and line breaks from a prompt string so it
+// can be sent to a non-terminal client.
+func plainText(s string) string {
+	s = ansi.StripAnsi(string(ansi.ReplacePipeCodes([]byte(s))))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// copyTo writes the first n bytes of the download to w, for SCP, which
+// reads sequentially.
+func (d *remoteDownload) copyTo(w io.Writer, n int64) error {
+	_, err := io.Copy(w, io.NewSectionReader(d, 0, n))
+	return err
+}
@@ -0,0 +1,410 @@
+package menu
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// setupRemoteFiles builds a file manager with an open area, a sysop-only
+// area and a download-only area, plus one user, and returns the remote
+// view for that user.
+func setupRemoteFiles(t *testing.T, cfg config.ServerConfig) (*remoteFiles, string) {
+	t.Helper()
+	areas := []file.FileArea{
+		{ID: 1, Tag: "OPEN", Name: "Open Area", Path: "open"},
+		{ID: 2, Tag: "SYSOP", Name: "Sysop Area", Path: "sysop", ACSList: "s200"},
+		{ID: 3, Tag: "READONLY", Name: "Download Only", Path: "readonly", ACSUpload: "s200"},
+	}
+	fm, filesDir := setupTestFileManagerForViewer(t, areas)
+
+	content := []byte("hello from the bbs\n")
+	if err := os.WriteFile(filepath.Join(filesDir, "open", "HELLO.TXT"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fm.AddFileRecord(file.FileRecord{
+		ID: uuid.New(), AreaID: 1, Filename: "HELLO.TXT", Description: "Greeting",
+		Size: int64(len(content)), UploadedAt: time.Now(), UploadedBy: "SysOp",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	um, err := user.NewUserManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := um.AddUser("tester", "secret", "Tester", "Test User", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.AccessLevel = 10
+	if err := um.UpdateUser(u); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.CoSysOpLevel == 0 {
+		cfg.CoSysOpLevel = 250
+	}
+	e := &MenuExecutor{FileMgr: fm, ServerCfg: cfg, RootConfigPath: t.TempDir()}
+	return e.newRemoteFiles(nil, um, u, "SFTP"), filesDir
+}
+
+// pipeConn joins one direction of each pipe into a connection.
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// sftpClient starts an SFTP server for rf and returns a client talking to it.
+func sftpClient(t *testing.T, rf *remoteFiles) *sftp.Client {
+	t.Helper()
+	serverRead, clientWrite := io.Pipe()
+	clientRead, serverWrite := io.Pipe()
+	go rf.serveSFTP(pipeConn{serverRead, serverWrite})
+
+	client, err := sftp.NewClientPipe(clientRead, clientWrite)
+	if err != nil {
+		t.Fatalf("sftp client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (rf *remoteFiles) testUser(t *testing.T) *user.User {
+	t.Helper()
+	u, err := rf.user()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestSFTP_ListsOnlyPermittedAreas(t *testing.T) {
+	rf, _ := setupRemoteFiles(t, config.ServerConfig{})
+	client := sftpClient(t, rf)
+
+	entries, err := client.ReadDir("/")
+	if err != nil {
+		t.Fatalf("ReadDir(/): %v", err)
+	}
+	var names []string
+	for _, fi := range entries {
+		names = append(names, fi.Name())
+		if !fi.IsDir() {
+			t.Errorf("%s should be a directory", fi.Name())
+		}
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "OPEN,READONLY" {
+		t.Errorf("areas = %v, want OPEN and READONLY only", names)
+	}
+
+	files, err := client.ReadDir("/open")
+	if err != nil || len(files) != 1 || files[0].Name() != "HELLO.TXT" {
+		t.Fatalf("ReadDir(/open) = %v, %v; want HELLO.TXT", files, err)
+	}
+	if _, err := client.ReadDir("/SYSOP"); err == nil {
+		t.Error("sysop-only area should not be listable")
+	}
+}
+
+func TestSFTP_DownloadCountsTowardStats(t *testing.T) {
+	rf, _ := setupRemoteFiles(t, config.ServerConfig{})
+	client := sftpClient(t, rf)
+
+	f, err := client.Open("/OPEN/HELLO.TXT")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || string(data) != "hello from the bbs\n" {
+		t.Fatalf("read %q, %v", data, err)
+	}
+
+	recs := rf.e.FileMgr.GetFilesForArea(1)
+	if recs[0].DownloadCount != 1 {
+		t.Errorf("DownloadCount = %d, want 1", recs[0].DownloadCount)
+	}
+	u := rf.testUser(t)
+	if u.NumDownloads != 1 || u.DownloadedBytes != int64(len(data)) {
+		t.Errorf("NumDownloads = %d, DownloadedBytes = %d; want 1, %d", u.NumDownloads, u.DownloadedBytes, len(data))
+	}
+	if log, _ := rf.um.TransferLog(); len(log) != 1 || log[0].Protocol != "SFTP" || !log[0].Success {
+		t.Errorf("transfer log = %+v", log)
+	}
+}
+
+func TestSFTP_PartialDownloadChargedByBytesRead(t *testing.T) {
+	cfg := config.ServerConfig{DownloadRatio: 1, RatioFreeDownloads: 5}
+	for _, tc := range []struct {
+		name        string
+		off, n      int64
+		wantCounted bool
+	}{
+		{"all but the last byte", 0, 18, true},
+		{"only the last byte", 18, 1, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rf, _ := setupRemoteFiles(t, cfg)
+			client := sftpClient(t, rf)
+
+			f, err := client.Open("/OPEN/HELLO.TXT")
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			buf := make([]byte, tc.n)
+			if _, err := f.ReadAt(buf, tc.off); err != nil && err != io.EOF {
+				t.Fatalf("ReadAt: %v", err)
+			}
+			f.Close()
+
+			u := rf.testUser(t)
+			if got := u.NumDownloads == 1; got != tc.wantCounted {
+				t.Errorf("NumDownloads = %d, counted = %v; want counted %v", u.NumDownloads, got, tc.wantCounted)
+			}
+			recs := rf.e.FileMgr.GetFilesForArea(1)
+			if got := recs[0].DownloadCount == 1; got != tc.wantCounted {
+				t.Errorf("DownloadCount = %d; want counted %v", recs[0].DownloadCount, tc.wantCounted)
+			}
+		})
+	}
+}
+
+func TestByteRanges(t *testing.T) {
+	var r byteRanges
+	r.add(10, 20)
+	r.add(0, 5)
+	r.add(15, 30) // Overlaps the first range
+	r.add(5, 10)  // Joins the two
+	r.add(2, 4)   // Already covered
+	if got := r.total(); got != 30 {
+		t.Errorf("total = %d, want 30", got)
+	}
+	if len(r.spans) != 1 {
+		t.Errorf("spans = %v, want one merged range", r.spans)
+	}
+}
+
+func TestPartialCharge(t *testing.T) {
+	ch := downloadCharge{cost: 10}
+	if got, counted := partialCharge(ch, 1, 100); got.cost != 1 || counted || !got.free {
+		t.Errorf("1%%: cost %d, counted %v", got.cost, counted)
+	}
+	if got, counted := partialCharge(ch, 99, 100); got.cost != 10 || !counted || got.free {
+		t.Errorf("99%%: cost %d, counted %v", got.cost, counted)
+	}
+	if _, counted := partialCharge(downloadCharge{free: true}, 99, 100); counted {
+		t.Error("a free file was counted")
+	}
+}
+
+func TestSFTP_DownloadRespectsRatio(t *testing.T) {
+	rf, _ := setupRemoteFiles(t, config.ServerConfig{DownloadRatio: 1})
+	client := sftpClient(t, rf)
+
+	if _, err := client.Open("/OPEN/HELLO.TXT"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected ratio denial, got %v", err)
+	}
+	if recs := rf.e.FileMgr.GetFilesForArea(1); recs[0].DownloadCount != 0 {
+		t.Errorf("denied download was counted")
+	}
+}
+
+func TestSFTP_UploadAddsRecord(t *testing.T) {
+	rf, filesDir := setupRemoteFiles(t, config.ServerConfig{})
+	client := sftpClient(t, rf)
+
+	f, err := client.Create("/OPEN/NEWFILE.TXT")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.Write([]byte("fresh upload")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var rec *file.FileRecord
+	for _, r := range rf.e.FileMgr.GetFilesForArea(1) {
+		if r.Filename == "NEWFILE.TXT" {
+			rec = &r
+		}
+	}
+	if rec == nil {
+		t.Fatal("upload was not added to the area")
+	}
+	if rec.UploadedBy != "Tester" || rec.Size != 12 || rec.SHA256 == "" {
+		t.Errorf("record = %+v", rec)
+	}
+	if got, _ := os.ReadFile(filepath.Join(filesDir, "open", "NEWFILE.TXT")); string(got) != "fresh upload" {
+		t.Errorf("file on disk = %q", got)
+	}
+	if u := rf.testUser(t); u.NumUploads != 1 || u.UploadedBytes != 12 {
+		t.Errorf("NumUploads = %d, UploadedBytes = %d; want 1, 12", u.NumUploads, u.UploadedBytes)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(filesDir, "open", ".incoming-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("incoming directories left behind: %v", leftovers)
+	}
+}
+
+func TestSFTP_UploadRefusals(t *testing.T) {
+	rf, _ := setupRemoteFiles(t, config.ServerConfig{})
+	client := sftpClient(t, rf)
+
+	for _, p := range []string{"/READONLY/A.TXT", "/ROOT.TXT", "/OPEN/HELLO.TXT", "/OPEN/.hidden", "/SYSOP/A.TXT"} {
+		if f, err := client.Create(p); err == nil {
+			f.Close()
+			t.Errorf("upload to %s should be refused", p)
+		}
+	}
+	if err := client.Remove("/OPEN/HELLO.TXT"); err == nil {
+		t.Error("remove should be refused")
+	}
+	if err := client.Rename("/OPEN/HELLO.TXT", "/OPEN/BYE.TXT"); err == nil {
+		t.Error("rename should be refused")
+	}
+}
+
+func TestSFTP_DuplicateContentRejectedOnClose(t *testing.T) {
+	rf, _ := setupRemoteFiles(t, config.ServerConfig{})
+	hashes, _ := file.HashFile(writeTempFile(t, "hello from the bbs\n"))
+	recs := rf.e.FileMgr.GetFilesForArea(1)
+	rf.e.FileMgr.UpdateFileRecord(recs[0].ID, func(r *file.FileRecord) { r.SHA256 = hashes.SHA256 })
+	client := sftpClient(t, rf)
+
+	f, err := client.Create("/OPEN/COPY.TXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("hello from the bbs\n"))
+	if err := f.Close(); err == nil {
+		t.Fatal("expected duplicate content to be rejected")
+	}
+	if len(rf.e.FileMgr.GetFilesForArea(1)) != 1 {
+		t.Error("duplicate upload was added")
+	}
+	if u := rf.testUser(t); u.UploadedBytes != 0 {
+		t.Errorf("rejected upload credited %d bytes", u.UploadedBytes)
+	}
+	if st := rf.e.FileMgr.GetAreaStats(1); st.Uploads != 0 {
+		t.Errorf("rejected upload counted in area stats: %+v", st)
+	}
+}
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// scpPeer plays the client side of the scp protocol.
+type scpPeer struct {
+	t  *testing.T
+	w  io.WriteCloser
+	br *bufio.Reader
+}
+
+func startSCP(t *testing.T, rf *remoteFiles, args ...string) (*scpPeer, chan error) {
+	serverRead, clientWrite := io.Pipe()
+	clientRead, serverWrite := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := rf.serveSCP(pipeConn{serverRead, serverWrite}, args)
+		serverWrite.Close()
+		done <- err
+	}()
+	return &scpPeer{t: t, w: clientWrite, br: bufio.NewReader(clientRead)}, done
+}
+
+func (p *scpPeer) expectAck() {
+	p.t.Helper()
+	c, err := p.br.ReadByte()
+	if err != nil || c != 0 {
+		msg, _ := p.br.ReadString('\n')
+		p.t.Fatalf("expected ack, got %#x %q (%v)", c, msg, err)
+	}
+}
+
+func TestSCP_UploadAndDownload(t *testing.T) {
+	rf, _ := setupRemoteFiles(t, config.ServerConfig{})
+	rf.via = "SCP"
+
+	// scp LOCAL.TXT host:OPEN
+	up, done := startSCP(t, rf, "-t", "--", "OPEN")
+	up.expectAck()
+	body := "uploaded with scp"
+	fmt.Fprintf(up.w, "C0644 %d LOCAL.TXT\n", len(body))
+	up.expectAck()
+	io.WriteString(up.w, body)
+	up.w.Write([]byte{0})
+	up.expectAck()
+	up.w.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("scp -t: %v", err)
+	}
+
+	// scp host:/OPEN/LOCAL.TXT .
+	down, done := startSCP(t, rf, "-f", "/OPEN/LOCAL.TXT")
+	down.w.Write([]byte{0})
+	line, _ := down.br.ReadString('\n')
+	if want := fmt.Sprintf("C0644 %d LOCAL.TXT\n", len(body)); line != want {
+		t.Fatalf("header = %q, want %q", line, want)
+	}
+	down.w.Write([]byte{0})
+	got := make([]byte, len(body))
+	io.ReadFull(down.br, got)
+	down.expectAck()
+	down.w.Write([]byte{0})
+	down.w.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("scp -f: %v", err)
+	}
+	if !bytes.Equal(got, []byte(body)) {
+		t.Errorf("downloaded %q, want %q", got, body)
+	}
+	if u := rf.testUser(t); u.NumUploads != 1 || u.NumDownloads != 1 {
+		t.Errorf("uploads/downloads = %d/%d, want 1/1", u.NumUploads, u.NumDownloads)
+	}
+}
+
+func TestSCP_RefusesRecursiveAndUnknownFiles(t *testing.T) {
+	rf, _ := setupRemoteFiles(t, config.ServerConfig{})
+
+	p, done := startSCP(t, rf, "-r", "-t", "OPEN")
+	if c, _ := p.br.ReadByte(); c != 2 {
+		t.Errorf("recursive copy: got %#x, want fatal error", c)
+	}
+	if err := <-done; err == nil {
+		t.Error("recursive copy should fail")
+	}
+
+	p, done = startSCP(t, rf, "-f", "/OPEN/MISSING.ZIP")
+	p.w.Write([]byte{0})
+	if c, _ := p.br.ReadByte(); c != 1 {
+		t.Errorf("missing file: got %#x, want warning", c)
+	}
+	p.br.ReadString('\n')
+	if err := <-done; err == nil {
+		t.Error("missing file should fail the run")
+	}
+}
@@ -0,0 +1,215 @@
+package menu
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// errSCPIncomplete is returned when at least one file in an SCP run failed.
+var errSCPIncomplete = errors.New("scp: one or more files failed")
+
+// ServeSCP runs the server side of a legacy "scp -f" (download) or
+// "scp -t" (upload) command against the file areas. args is the command
+// line after "scp". Newer scp clients use SFTP instead and never get here.
+func (e *MenuExecutor) ServeSCP(s ssh.Session, userManager *user.UserMgr, u *user.User, args []string) error {
+	rf := e.newRemoteFiles(s, userManager, u, "SCP")
+	return rf.serveSCP(s, args)
+}
+
+func (rf *remoteFiles) serveSCP(rw io.ReadWriter, args []string) error {
+	var source, sink, times bool
+	var paths []string
+	for i, a := range args {
+		if a == "--" {
+			paths = append(paths, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(a, "-") {
+			paths = append(paths, a)
+			continue
+		}
+		for _, f := range a[1:] {
+			switch f {
+			case 'f':
+				source = true
+			case 't':
+				sink = true
+			case 'p':
+				times = true
+			case 'r':
+				fmt.Fprint(rw, "\x02scp: recursive copies are not supported\n")
+				return errors.New("scp: recursive copy refused")
+			}
+		}
+	}
+	if source == sink || len(paths) == 0 {
+		fmt.Fprint(rw, "\x02scp: unsupported command\n")
+		return fmt.Errorf("scp: unsupported arguments %q", args)
+	}
+
+	br := bufio.NewReader(rw)
+	if source {
+		return rf.scpSend(rw, br, paths, times)
+	}
+	return rf.scpReceive(rw, br, paths[len(paths)-1])
+}
+
+// scpAck reads the client's response: 0 for OK, or 1/2 and a message.
+func scpAck(br *bufio.Reader) error {
+	c, err := br.ReadByte()
+	if err != nil {
+		return err
+	}
+	if c == 0 {
+		return nil
+	}
+	msg, _ := br.ReadString('\n')
+	return fmt.Errorf("scp: client error: %s", strings.TrimSpace(msg))
+}
+
+// scpSend sends each path to the client ("scp -f").
+func (rf *remoteFiles) scpSend(w io.Writer, br *bufio.Reader, paths []string, times bool) error {
+	if err := scpAck(br); err != nil {
+		return err
+	}
+	failed := false
+	for _, p := range paths {
+		d, err := rf.openDownload(p)
+		if err != nil {
+			fmt.Fprintf(w, "\x01scp: %s: %s\n", p, err)
+			failed = true
+			continue
+		}
+		size := d.rec.Size
+		if fi, statErr := d.f.Stat(); statErr == nil {
+			size = fi.Size()
+		}
+		if times {
+			t := d.rec.UploadedAt.Unix()
+			fmt.Fprintf(w, "T%d 0 %d 0\n", t, t)
+			if err := scpAck(br); err != nil {
+				d.f.Close()
+				return err
+			}
+		}
+		fmt.Fprintf(w, "C0644 %d %s\n", size, d.rec.Filename)
+		if err := scpAck(br); err != nil {
+			d.f.Close()
+			return err
+		}
+		if err := d.copyTo(w, size); err != nil {
+			d.f.Close()
+			return err
+		}
+		if _, err := w.Write([]byte{0}); err != nil {
+			d.f.Close()
+			return err
+		}
+		err = scpAck(br)
+		d.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errSCPIncomplete
+	}
+	return nil
+}
+
+// scpReceive accepts files from the client ("scp -t"). target is an area
+// directory, or a file path inside one to upload under a different name.
+func (rf *remoteFiles) scpReceive(w io.Writer, br *bufio.Reader, target string) error {
+	tag, name, err := splitRemotePath(target)
+	if err != nil || tag == "" {
+		fmt.Fprintf(w, "\x02scp: %s: uploads go into an area directory\n", target)
+		return fmt.Errorf("scp: bad upload target %q", target)
+	}
+
+	if _, err := w.Write([]byte{0}); err != nil {
+		return err
+	}
+	failed := false
+	for {
+		line, err := br.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'T':
+			w.Write([]byte{0})
+			continue
+		case 'D', 'E':
+			fmt.Fprint(w, "\x02scp: directories are not supported\n")
+			return errors.New("scp: directory copy refused")
+		case '\x01', '\x02':
+			log.Printf("WARN: SCP: client reported: %s", strings.TrimSpace(line[1:]))
+			failed = true
+			continue
+		case 'C':
+		default:
+			fmt.Fprintf(w, "\x02scp: protocol error\n")
+			return fmt.Errorf("scp: unexpected line %q", line)
+		}
+
+		// C<mode> <size> <name>
+		fields := strings.SplitN(line[1:], " ", 3)
+		if len(fields) != 3 {
+			fmt.Fprint(w, "\x02scp: protocol error\n")
+			return fmt.Errorf("scp: bad file line %q", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || size < 0 {
+			fmt.Fprint(w, "\x02scp: protocol error\n")
+			return fmt.Errorf("scp: bad size in %q", line)
+		}
+		fileName := fields[2]
+		if name != "" {
+			fileName = name
+		}
+
+		up, err := rf.createUpload(path.Join("/", tag, fileName))
+		if err != nil {
+			// The client skips the data after a warning.
+			fmt.Fprintf(w, "\x01scp: %s: %s\n", fileName, err)
+			failed = true
+			continue
+		}
+		w.Write([]byte{0})
+		if _, err := io.CopyN(up, br, size); err != nil {
+			up.abort()
+			return err
+		}
+		if err := scpAck(br); err != nil {
+			up.abort()
+			return err
+		}
+		if err := up.Close(); err != nil {
+			fmt.Fprintf(w, "\x01scp: %s: %s\n", fileName, err)
+			failed = true
+			continue
+		}
+		w.Write([]byte{0})
+	}
+	if failed {
+		return errSCPIncomplete
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/pkg/sftp"
+
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// ServeSFTP serves the file areas to an authenticated user over an SFTP
+// subsystem channel until the client disconnects.
+func (e *MenuExecutor) ServeSFTP(s ssh.Session, userManager *user.UserMgr, u *user.User) error {
+	rf := e.newRemoteFiles(s, userManager, u, "SFTP")
+	log.Printf("INFO: SFTP: %s connected from %s", u.Handle, s.RemoteAddr())
+	err := rf.serveSFTP(s)
+	log.Printf("INFO: SFTP: %s disconnected", u.Handle)
+	return err
+}
+
+// serveSFTP runs the SFTP request server over rwc.
+func (rf *remoteFiles) serveSFTP(rwc io.ReadWriteCloser) error {
+	h := sftpHandlers{rf}
+	server := sftp.NewRequestServer(rwc, sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h})
+	defer server.Close()
+	if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// sftpHandlers maps SFTP requests onto the remote file view.
+type sftpHandlers struct {
+	rf *remoteFiles
+}
+
+func (h sftpHandlers) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	return h.rf.openDownload(r.Filepath)
+}
+
+func (h sftpHandlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return h.rf.createUpload(r.Filepath)
+}
+
+// Filecmd refuses everything that would change the areas behind the file
+// manager's back. Setstat is accepted and ignored so clients that preserve
+// timestamps don't fail the upload.
+func (h sftpHandlers) Filecmd(r *sftp.Request) error {
+	if r.Method == "Setstat" {
+		return nil
+	}
+	return errRemoteUnsupported
+}
+
+func (h sftpHandlers) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	u, err := h.rf.user()
+	if err != nil {
+		return nil, err
+	}
+	tag, name, err := splitRemotePath(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		if name != "" {
+			return nil, os.ErrNotExist
+		}
+		if tag == "" {
+			var dirs listerAt
+			for _, a := range h.rf.areas(u) {
+				dirs = append(dirs, h.rf.areaInfo(u, a))
+			}
+			return dirs, nil
+		}
+		area, ok := h.rf.area(u, tag)
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		var files listerAt
+		for _, rec := range h.rf.e.visibleFilesForArea(area.ID, u) {
+			files = append(files, recordInfo(rec))
+		}
+		return files, nil
+
+	case "Stat":
+		if tag == "" {
+			return listerAt{remoteInfo{name: "/", dir: true, mode: 0555, mod: time.Now()}}, nil
+		}
+		area, ok := h.rf.area(u, tag)
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		if name == "" {
+			return listerAt{h.rf.areaInfo(u, area)}, nil
+		}
+		rec, ok := h.rf.record(u, area, name)
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return listerAt{recordInfo(rec)}, nil
+	}
+	return nil, errRemoteUnsupported
+}
+
+// areaInfo describes an area directory. It is writable when u may upload.
+func (rf *remoteFiles) areaInfo(u *user.User, a file.FileArea) remoteInfo {
+	mode := os.FileMode(0555)
+	if rf.allowed(a.ACSUpload, u) {
+		mode = 0755
+	}
+	return remoteInfo{name: a.Tag, dir: true, mode: mode, mod: rf.started}
+}
+
+func recordInfo(rec file.FileRecord) remoteInfo {
+	return remoteInfo{name: rec.Filename, size: rec.Size, mode: 0444, mod: rec.UploadedAt}
+}
+
+// remoteInfo is the os.FileInfo for an area directory or file record.
+type remoteInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+	mod  time.Time
+	dir  bool
+}
+
+func (fi remoteInfo) Name() string       { return fi.name }
+func (fi remoteInfo) Size() int64        { return fi.size }
+func (fi remoteInfo) ModTime() time.Time { return fi.mod }
+func (fi remoteInfo) IsDir() bool        { return fi.dir }
+func (fi remoteInfo) Sys() interface{}   { return nil }
+
+func (fi remoteInfo) Mode() os.FileMode {
+	if fi.dir {
+		return fi.mode | os.ModeDir
+	}
+	return fi.mode
+}
+
+// listerAt serves a fixed listing to the SFTP server.
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(out []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(out, l[offset:])
+	if n < len(out) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// errRemoteUnsupported is returned for operations the file areas don't allow.
+var errRemoteUnsupported = fmt.Errorf("%w: not supported on the BBS file areas", sftp.ErrSSHFxPermissionDenied)
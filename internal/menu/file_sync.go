@@ -0,0 +1,266 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/ziplab"
+)
+
+// fileSyncSettle is how long a new file in a synced area must go unmodified
+// before it is added, so files still being copied in are left alone.
+const fileSyncSettle = 5 * time.Second
+
+var (
+	fileSyncMu sync.Mutex
+	// fileSyncRejected remembers files sync refused (duplicates, ZipLab
+	// failures, case collisions) so they are not retried or reported again
+	// until they change. Keyed by area ID and filename; entries for files no
+	// longer waiting to be added are dropped on each sync of the area.
+	fileSyncRejected = make(map[string]fileSyncStamp)
+)
+
+type fileSyncStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// SyncFileArea brings an area's records in step with its directory. New
+// files go through the same duplicate and ZipLab checks as an upload,
+// resized files get their size and hashes updated, removed files are
+// marked offline and returning ones brought back online. It returns true
+// if new files are still being written and the area should be scanned
+// again shortly.
+func (e *MenuExecutor) SyncFileArea(areaID int) (bool, error) {
+	fileSyncMu.Lock()
+	defer fileSyncMu.Unlock()
+
+	areaPtr, ok := e.FileMgr.GetAreaByID(areaID)
+	if !ok {
+		return false, fmt.Errorf("file area %d not found", areaID)
+	}
+	area := *areaPtr
+	changes, err := e.FileMgr.ScanAreaDir(areaID, fileSyncSettle)
+	if err != nil {
+		return false, err
+	}
+	pruneFileSyncRejected(areaID, changes)
+	if changes.Empty() {
+		return false, nil
+	}
+	dir, err := e.FileMgr.GetAreaUploadPath(areaID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, rec := range changes.Resized {
+		e.syncUpdateFile(area, dir, rec, "resized")
+	}
+	for _, rec := range changes.Back {
+		e.syncUpdateFile(area, dir, rec, "back online")
+	}
+
+	for _, name := range changes.Collisions {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		key := fileSyncKey(areaID, name)
+		stamp := fileSyncStamp{fi.Size(), fi.ModTime()}
+		if fileSyncRejected[key] == stamp {
+			continue
+		}
+		log.Printf("WARN: File sync %s: Not adding %s: another file's name differs only in case; rename one of them", area.Tag, name)
+		fileSyncRejected[key] = stamp
+	}
+
+	renamed := make(map[uuid.UUID]bool)
+	for _, name := range changes.New {
+		path := filepath.Join(dir, name)
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		key := fileSyncKey(areaID, name)
+		stamp := fileSyncStamp{fi.Size(), fi.ModTime()}
+		if fileSyncRejected[key] == stamp {
+			continue
+		}
+		id, wasRename, err := e.syncAddFile(area, dir, name)
+		if errors.Is(err, file.ErrFilenameTaken) {
+			// An upload added it since the scan
+			log.Printf("DEBUG: File sync %s: %s already has a record", area.Tag, name)
+			continue
+		}
+		if err != nil {
+			log.Printf("WARN: File sync %s: Not adding %s: %v", area.Tag, name, err)
+			fileSyncRejected[key] = stamp
+			continue
+		}
+		delete(fileSyncRejected, key)
+		if wasRename {
+			renamed[id] = true
+		}
+	}
+
+	for _, rec := range changes.Missing {
+		if renamed[rec.ID] {
+			continue
+		}
+		if err := e.FileMgr.SetFileOffline(rec.ID, true); err != nil {
+			log.Printf("ERROR: File sync %s: Failed to mark %s offline: %v", area.Tag, rec.Filename, err)
+			continue
+		}
+		log.Printf("INFO: File sync %s: %s is gone from disk, marked offline", area.Tag, rec.Filename)
+	}
+
+	return len(changes.Settling) > 0, nil
+}
+
+// fileSyncKey is the fileSyncRejected key for name in an area.
+func fileSyncKey(areaID int, name string) string {
+	return fmt.Sprintf("%d/%s", areaID, name)
+}
+
+// pruneFileSyncRejected forgets the area's rejected files that are no
+// longer waiting to be added, such as files since deleted or renamed.
+// Caller must hold fileSyncMu.
+func pruneFileSyncRejected(areaID int, changes file.AreaDirChanges) {
+	waiting := make(map[string]bool, len(changes.New)+len(changes.Collisions))
+	for _, name := range changes.New {
+		waiting[fileSyncKey(areaID, name)] = true
+	}
+	for _, name := range changes.Collisions {
+		waiting[fileSyncKey(areaID, name)] = true
+	}
+	prefix := fileSyncKey(areaID, "")
+	for key := range fileSyncRejected {
+		if strings.HasPrefix(key, prefix) && !waiting[key] {
+			delete(fileSyncRejected, key)
+		}
+	}
+}
+
+// syncUpdateFile records a synced file's new size and hashes and clears its
+// offline flag.
+func (e *MenuExecutor) syncUpdateFile(area file.FileArea, dir string, rec file.FileRecord, why string) {
+	hashes, err := file.HashFile(filepath.Join(dir, filepath.Base(rec.Filename)))
+	if err != nil {
+		log.Printf("WARN: File sync %s: Failed to hash %s: %v", area.Tag, rec.Filename, err)
+	}
+	err = e.FileMgr.UpdateFileRecord(rec.ID, func(r *file.FileRecord) {
+		r.Size = rec.Size
+		r.Offline = false
+		if hashes.SHA256 != "" {
+			r.SHA256, r.CRC32 = hashes.SHA256, hashes.CRC32
+		}
+	})
+	if err != nil {
+		log.Printf("ERROR: File sync %s: Failed to update %s: %v", area.Tag, rec.Filename, err)
+		return
+	}
+	log.Printf("INFO: File sync %s: %s %s (%d bytes)", area.Tag, rec.Filename, why, rec.Size)
+}
+
+// syncAddFile adds a file found in a synced area's directory. A file whose
+// contents match a record in the same area whose own file is gone is taken
+// to be that file renamed, and the record follows it. Returns the record ID
+// and whether it was a rename.
+func (e *MenuExecutor) syncAddFile(area file.FileArea, dir, name string) (uuid.UUID, bool, error) {
+	path := filepath.Join(dir, name)
+	hashes, err := file.HashFile(path)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if dup, found := e.FileMgr.FindFileBySHA256(hashes.SHA256); found {
+		if dup.AreaID == area.ID {
+			if _, statErr := os.Stat(filepath.Join(dir, filepath.Base(dup.Filename))); os.IsNotExist(statErr) {
+				oldName := dup.Filename
+				err := e.FileMgr.UpdateFileRecord(dup.ID, func(r *file.FileRecord) {
+					r.Filename = name
+					r.Offline = false
+				})
+				if err != nil {
+					return uuid.Nil, false, err
+				}
+				log.Printf("INFO: File sync %s: %s renamed to %s", area.Tag, oldName, name)
+				return dup.ID, true, nil
+			}
+		}
+		return uuid.Nil, false, fmt.Errorf("identical to %s", e.fileRecordLocation(dup))
+	}
+
+	var description, zipLabResults string
+	pipelineRan := false
+	zlCfg, zlErr := ziplab.LoadConfig(e.RootConfigPath)
+	if zlErr != nil {
+		log.Printf("WARN: File sync %s: Failed to load ZipLab config: %v", area.Tag, zlErr)
+	}
+	if zlErr == nil && zlCfg.Enabled && zlCfg.RunOnUpload && zlCfg.IsArchiveSupported(name) {
+		log.Printf("INFO: File sync %s: Running ZipLab pipeline on %s", area.Tag, name)
+		proc := ziplab.NewProcessor(zlCfg, filepath.Join(filepath.Dir(e.RootConfigPath), "ziplab"))
+		result := proc.RunPipeline(path, nil)
+		pipelineRan = true
+		zipLabResults = result.Summary()
+		if !result.Success {
+			return uuid.Nil, false, fmt.Errorf("rejected by ZipLab: %v", result.Error)
+		}
+		description = result.Description
+	} else if e.FileMgr.IsSupportedArchive(name) {
+		diz, dizErr := ziplab.ExtractDIZFromArchive(path, e.RootConfigPath)
+		if dizErr != nil {
+			log.Printf("WARN: File sync %s: DIZ extraction failed for %s: %v", area.Tag, name, dizErr)
+		}
+		description = diz
+	}
+	description = sanitizeControlChars(strings.TrimRight(description, " \t\r\n"))
+	if description == "" {
+		description = "No description"
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if pipelineRan {
+		// ZipLab may have rewritten the archive
+		if hashes, err = file.HashFile(path); err != nil {
+			return uuid.Nil, false, err
+		}
+		if dup, found := e.FileMgr.FindFileBySHA256(hashes.SHA256); found {
+			return uuid.Nil, false, fmt.Errorf("identical to %s after ZipLab", e.fileRecordLocation(dup))
+		}
+	}
+
+	uploader := e.GetServerConfig().SysOpName
+	if uploader == "" {
+		uploader = "SysOp"
+	}
+	record := file.FileRecord{
+		ID:            uuid.New(),
+		AreaID:        area.ID,
+		Filename:      name,
+		Description:   description,
+		Size:          fi.Size(),
+		UploadedAt:    time.Now(),
+		UploadedBy:    uploader,
+		SHA256:        hashes.SHA256,
+		CRC32:         hashes.CRC32,
+		ZipLabResults: zipLabResults,
+	}
+	if err := e.FileMgr.AddExistingFile(record); err != nil {
+		return uuid.Nil, false, err
+	}
+	log.Printf("INFO: File sync %s: Added %s (%d bytes)", area.Tag, name, record.Size)
+	e.fillWants(record, 0)
+	return record.ID, false, nil
+}
@@ -0,0 +1,130 @@
+package menu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/file"
+)
+
+func TestSyncFileArea(t *testing.T) {
+	fm, filesDir := setupTestFileManagerForViewer(t, []file.FileArea{
+		{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils", Sync: true},
+		{ID: 2, Tag: "GAMES", Name: "Games", Path: "games"},
+	})
+	e := &MenuExecutor{FileMgr: fm, ServerCfg: config.ServerConfig{SysOpName: "Sysop Sam"}, RootConfigPath: filepath.Join(t.TempDir(), "configs")}
+	dir := filepath.Join(filesDir, "utils")
+	old := time.Now().Add(-time.Hour)
+	write := func(area, name, contents string, mod time.Time) {
+		t.Helper()
+		p := filepath.Join(filesDir, area, name)
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	byName := func() map[string]file.FileRecord {
+		m := make(map[string]file.FileRecord)
+		for _, r := range fm.GetFilesForArea(1) {
+			m[r.Filename] = r
+		}
+		return m
+	}
+
+	// A file elsewhere on the board that a copy in UTILS duplicates.
+	write("games", "DOOM.ZIP", "doom shareware", old)
+	hashes, _ := file.HashFile(filepath.Join(filesDir, "games", "DOOM.ZIP"))
+	if err := fm.AddFileRecord(file.FileRecord{ID: uuid.New(), AreaID: 2, Filename: "DOOM.ZIP", Size: 14, SHA256: hashes.SHA256}); err != nil {
+		t.Fatal(err)
+	}
+
+	write("utils", "TOOL.TXT", "a handy tool", old)
+	write("utils", "DOOMCOPY.ZIP", "doom shareware", old)
+	write("utils", "WRITING.TXT", "still copying", time.Now())
+
+	pending, err := e.SyncFileArea(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pending {
+		t.Error("expected pending for a file still being written")
+	}
+	recs := byName()
+	tool, ok := recs["TOOL.TXT"]
+	if !ok || len(recs) != 1 {
+		t.Fatalf("records after first sync = %+v", recs)
+	}
+	if tool.UploadedBy != "Sysop Sam" || tool.Size != 12 || tool.SHA256 == "" || tool.Description != "No description" {
+		t.Errorf("added record = %+v", tool)
+	}
+
+	// Grow the file, rename another, delete one.
+	write("utils", "TOOL.TXT", "a handier tool!", old)
+	write("utils", "NOTES.TXT", "release notes", old)
+	if _, err := e.SyncFileArea(1); err != nil {
+		t.Fatal(err)
+	}
+	notes := byName()["NOTES.TXT"]
+	if err := os.Rename(filepath.Join(dir, "NOTES.TXT"), filepath.Join(dir, "README.TXT")); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(dir, "WRITING.TXT"))
+	os.Remove(filepath.Join(dir, "TOOL.TXT"))
+	if _, err := e.SyncFileArea(1); err != nil {
+		t.Fatal(err)
+	}
+
+	recs = byName()
+	if r, ok := recs["README.TXT"]; !ok || r.ID != notes.ID || r.Offline {
+		t.Errorf("rename not followed: %+v", recs)
+	}
+	if _, ok := recs["NOTES.TXT"]; ok {
+		t.Error("old name still has a record")
+	}
+	if r := recs["TOOL.TXT"]; !r.Offline {
+		t.Errorf("deleted file not marked offline: %+v", r)
+	}
+	if _, ok := recs["DOOMCOPY.ZIP"]; ok {
+		t.Error("duplicate of a file in another area was added")
+	}
+
+	// The file comes back, larger.
+	write("utils", "TOOL.TXT", "a handier tool, v2", old)
+	if _, err := e.SyncFileArea(1); err != nil {
+		t.Fatal(err)
+	}
+	if r := byName()["TOOL.TXT"]; r.Offline || r.Size != 18 {
+		t.Errorf("returned file = %+v", r)
+	}
+
+	// A name differing only in case is reported, not added; rejections
+	// are forgotten once their file is gone.
+	write("utils", "readme.txt", "another readme", old)
+	if _, err := e.SyncFileArea(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := byName()["readme.txt"]; ok {
+		t.Error("case collision was added")
+	}
+	if _, ok := fileSyncRejected[fileSyncKey(1, "readme.txt")]; !ok {
+		t.Error("case collision not remembered")
+	}
+	os.Remove(filepath.Join(dir, "readme.txt"))
+	os.Remove(filepath.Join(dir, "DOOMCOPY.ZIP"))
+	if _, err := e.SyncFileArea(1); err != nil {
+		t.Fatal(err)
+	}
+	for key := range fileSyncRejected {
+		if strings.HasPrefix(key, "1/") {
+			t.Errorf("stale rejection %s kept", key)
+		}
+	}
+}
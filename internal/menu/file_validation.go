@@ -0,0 +1,266 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// validateMaxDescLines is the number of DIZ lines shown per pending file.
+const validateMaxDescLines = 10
+
+// visibleFilesForArea returns the files in an area that u may see.
+// Unvalidated uploads are only listed for their uploader and sysops.
+func (e *MenuExecutor) visibleFilesForArea(areaID int, u *user.User) []file.FileRecord {
+	handle, privileged := e.fileViewer(u)
+	return file.FilterVisible(e.FileMgr.GetFilesForArea(areaID), handle, privileged)
+}
+
+// visibleFileCount returns the number of files in an area that u may see.
+func (e *MenuExecutor) visibleFileCount(areaID int, u *user.User) (int, error) {
+	handle, privileged := e.fileViewer(u)
+	return e.FileMgr.GetVisibleFileCountForArea(areaID, handle, privileged)
+}
+
+// fileViewer returns the handle and sysop flag used for file visibility.
+func (e *MenuExecutor) fileViewer(u *user.User) (string, bool) {
+	if u == nil {
+		return "", false
+	}
+	return u.Handle, e.isCoSysOpOrAbove(u)
+}
+
+// downloadHeldForValidation reports whether fileID is an unvalidated upload
+// that u may not download yet. Sysops can always download.
+func (e *MenuExecutor) downloadHeldForValidation(u *user.User, fileID uuid.UUID) bool {
+	rec, ok := e.FileMgr.GetFileRecord(fileID)
+	return ok && rec.Unvalidated && !e.isCoSysOpOrAbove(u)
+}
+
+// formatFilenameString substitutes |FN in a strings.json entry with name.
+func formatFilenameString(s, name string) string {
+	return strings.ReplaceAll(s, "|FN", name)
+}
+
+// runValidateFiles is the RunnableFunc for the sysop upload validation queue.
+// It steps through every unvalidated file, oldest first, showing its
+// FILE_ID.DIZ and ZipLab results so the sysop can approve, move, edit or
+// reject it.
+func runValidateFiles(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil || !e.isCoSysOpOrAbove(currentUser) {
+		return currentUser, "", nil
+	}
+
+	pending := e.FileMgr.ListUnvalidatedFiles()
+	if len(pending) == 0 {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|07No files are awaiting validation.\r\n")), outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+
+	ih := getSessionIH(s)
+	approved, rejected := 0, 0
+
+	for i := 0; i < len(pending); {
+		rec, ok := e.FileMgr.GetFileRecord(pending[i].ID)
+		if !ok || !rec.Unvalidated {
+			i++ // Deleted or validated elsewhere since the queue was built
+			continue
+		}
+		e.renderValidateFile(terminal, rec, i+1, len(pending), outputMode)
+
+		key, err := ih.ReadKey()
+		if err != nil {
+			if errors.Is(err, editor.ErrIdleTimeout) || errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", err
+		}
+		if key == editor.KeyEsc {
+			break
+		}
+		if key < 32 || key >= 127 {
+			continue
+		}
+
+		switch strings.ToLower(string(rune(key))) {
+		case "q":
+			i = len(pending)
+
+		case "a": // Approve
+			credit, apErr := e.approveUpload(userManager, currentUser, rec, nodeNumber)
+			if apErr != nil {
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Validation failed: %v|07", apErr), outputMode)
+				continue
+			}
+			msg := fmt.Sprintf("|10Validated %s.|07", rec.Filename)
+			if credit > 0 {
+				msg += fmt.Sprintf(" |07Granted |15%d|07 point(s) to %s.", credit, rec.UploadedBy)
+			}
+			e.fileEditNotice(terminal, msg, outputMode)
+			approved++
+			i++
+
+		case "m": // Move, then stay on the record so it can be approved
+			input, ok, promptErr := e.promptFileEditField(s, terminal, "Move to area (# or tag)", outputMode)
+			if promptErr != nil {
+				if errors.Is(promptErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				continue
+			}
+			if !ok {
+				continue
+			}
+			targetArea, found := e.resolveFileAreaInput(input)
+			if !found {
+				e.fileEditNotice(terminal, "|01Area not found.|07", outputMode)
+				continue
+			}
+			if mvErr := e.FileMgr.MoveFileRecord(rec.ID, targetArea.ID); mvErr != nil {
+				log.Printf("ERROR: Node %d: Failed to move file %s to area %d: %v", nodeNumber, rec.Filename, targetArea.ID, mvErr)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Move failed: %v|07", mvErr), outputMode)
+				continue
+			}
+			e.logFileAdminAction(userManager, currentUser, rec, "MOVE_FILE", "area", e.fileAreaTag(rec.AreaID), targetArea.Tag)
+
+		case "r": // Reject
+			tw, th := getTerminalSize(s)
+			proceed, promptErr := e.PromptYesNo(s, terminal, fmt.Sprintf("Reject and delete %s?", rec.Filename), outputMode, nodeNumber, tw, th, false)
+			if promptErr != nil {
+				if errors.Is(promptErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				continue
+			}
+			if !proceed {
+				continue
+			}
+			if delErr := e.FileMgr.DeleteFileRecord(rec.ID, true); delErr != nil {
+				log.Printf("ERROR: Node %d: Failed to delete rejected file %s: %v", nodeNumber, rec.Filename, delErr)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Delete failed: %v|07", delErr), outputMode)
+				continue
+			}
+			log.Printf("INFO: Node %d: %s rejected upload '%s' from %s.", nodeNumber, currentUser.Handle, rec.Filename, rec.UploadedBy)
+			e.logFileAdminActionNotes(userManager, currentUser, rec, "REJECT_FILE", "Uploaded by "+rec.UploadedBy)
+			rejected++
+			i++
+
+		case "e": // Full record editor
+			result, edErr := e.runFileRecordEditor(s, terminal, userManager, currentUser, rec.ID, outputMode, nodeNumber)
+			if edErr != nil {
+				if errors.Is(edErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				return currentUser, "", edErr
+			}
+			if result == fileEditDeleted {
+				i++
+			}
+
+		case "s", " ":
+			i++
+		}
+	}
+
+	summary := fmt.Sprintf("\r\n\r\n|15Validation done.|07 Approved: |10%d|07  Rejected: |09%d|07\r\n", approved, rejected)
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(summary)), outputMode)
+	time.Sleep(1 * time.Second)
+	return currentUser, "", nil
+}
+
+// renderValidateFile draws one pending upload with its DIZ and ZipLab results.
+func (e *MenuExecutor) renderValidateFile(terminal *term.Terminal, rec file.FileRecord, index, total int, outputMode ansi.OutputMode) {
+	var b strings.Builder
+	b.WriteString(ansi.ClearScreen())
+	b.WriteString(fmt.Sprintf("|09Validate Uploads |08- |15%d|08 of |15%d|07\r\n", index, total))
+	b.WriteString("|08" + strings.Repeat("-", 79) + "|07\r\n")
+
+	areaLabel := e.fileAreaTag(rec.AreaID)
+	if area, ok := e.FileMgr.GetAreaByID(rec.AreaID); ok {
+		areaLabel = fmt.Sprintf("%s |08(|07%s|08)", area.Tag, area.Name)
+	}
+	b.WriteString(fmt.Sprintf(" |11Area        |08: |15%s\r\n", areaLabel))
+	b.WriteString(fmt.Sprintf(" |11Filename    |08: |15%s\r\n", rec.Filename))
+	b.WriteString(fmt.Sprintf(" |11Size        |08: |07%d bytes\r\n", rec.Size))
+	b.WriteString(fmt.Sprintf(" |11Uploader    |08: |07%s\r\n", rec.UploadedBy))
+	b.WriteString(fmt.Sprintf(" |11Uploaded    |08: |07%s\r\n", rec.UploadedAt.Format("01/02/2006 15:04")))
+	if rec.CRC32 != "" {
+		b.WriteString(fmt.Sprintf(" |11CRC-32      |08: |07%s\r\n", rec.CRC32))
+	}
+
+	b.WriteString("\r\n |03Description:\r\n")
+	descLines := formatDIZLines(rec.Description, 60, validateMaxDescLines)
+	if len(descLines) == 0 {
+		b.WriteString("   |07(none)\r\n")
+	}
+	for _, line := range descLines {
+		b.WriteString("   |07" + line + "\r\n")
+	}
+
+	b.WriteString("\r\n |03ZipLab:\r\n")
+	if rec.ZipLabResults == "" {
+		b.WriteString("   |08(not run)\r\n")
+	}
+	for _, line := range strings.Split(rec.ZipLabResults, "\n") {
+		if line == "" {
+			continue
+		}
+		color := "|10"
+		if strings.Contains(line, ": FAIL") {
+			color = "|12"
+		}
+		b.WriteString("   " + color + line + "\r\n")
+	}
+
+	b.WriteString("\r\n |08[|14A|08]|07pprove  |08[|14M|08]|07ove  |08[|14E|08]|07dit  |08[|14R|08]|07eject  |08[|14S|08]|07kip  |08[|14Q|08]|07uit\r\n")
+	b.WriteString("\r\n|15Command: |07")
+
+	_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(b.String())), outputMode)
+}
+
+// approveUpload validates rec and gives its uploader the upload count and
+// file points that were held back at upload time. Returns the points granted.
+func (e *MenuExecutor) approveUpload(userManager *user.UserMgr, admin *user.User, rec file.FileRecord, nodeNumber int) (int, error) {
+	if err := e.FileMgr.UpdateFileRecord(rec.ID, func(r *file.FileRecord) { r.Unvalidated = false }); err != nil {
+		log.Printf("ERROR: Node %d: Failed to validate file %s: %v", nodeNumber, rec.Filename, err)
+		return 0, err
+	}
+	log.Printf("INFO: Node %d: %s validated upload '%s' from %s.", nodeNumber, admin.Handle, rec.Filename, rec.UploadedBy)
+	e.logFileAdminActionNotes(userManager, admin, rec, "VALIDATE_FILE", "Uploaded by "+rec.UploadedBy)
+	e.fillWants(rec, nodeNumber)
+	return e.creditUploader(userManager, rec, nodeNumber), nil
+}
+
+// creditUploader gives rec's uploader the upload count and file points for
+// a file that has just become available. Returns the points granted.
+func (e *MenuExecutor) creditUploader(userManager *user.UserMgr, rec file.FileRecord, nodeNumber int) int {
+	uploader, ok := userManager.GetUserByHandle(rec.UploadedBy)
+	if !ok {
+		log.Printf("WARN: Node %d: Uploader %q of %s not found; no credit granted", nodeNumber, rec.UploadedBy, rec.Filename)
+		return 0
+	}
+
+	credit := 0
+	uploader.NumUploads++
+	if area, found := e.FileMgr.GetAreaByID(rec.AreaID); found {
+		credit = e.applyUploadCredit(userManager, uploader, area.Tag, rec.Filename, area.UploadCreditFor(rec.Size, e.GetServerConfig().UploadKBPerPoint), nodeNumber)
+	}
+	if err := userManager.UpdateUser(uploader); err != nil {
+		log.Printf("ERROR: Node %d: Failed to credit uploader %s for %s: %v", nodeNumber, uploader.Handle, rec.Filename, err)
+	}
+	return credit
+}
@@ -18,7 +18,6 @@ import (
 	"github.com/stlalpha/vision3/internal/file"
 	"github.com/stlalpha/vision3/internal/terminalio"
 	"github.com/stlalpha/vision3/internal/user"
-	"github.com/stlalpha/vision3/internal/ziplab"
 )
 
 // findFileInArea searches for a file by name (case-insensitive) in the given area.
@@ -65,6 +64,9 @@ func promptAndResolveFile(e *MenuExecutor, s ssh.Session, terminal *term.Termina
 	}
 
 	record, err := findFileInArea(e.FileMgr, currentAreaID, filename)
+	if err == nil && !record.VisibleTo(e.fileViewer(currentUser)) {
+		err = fmt.Errorf("file not validated: %s", filename)
+	}
 	if err != nil {
 		msg := fmt.Sprintf(e.LoadedStrings.FileNotFoundFormat, filename)
 		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
@@ -94,9 +96,7 @@ func runViewFile(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userMa
 	}
 
 	if e.FileMgr.IsSupportedArchive(record.Filename) {
-		ctx, cancel := e.transferContext(s.Context())
-		defer cancel()
-		ziplab.RunZipLabView(ctx, s, terminal, filePath, record.Filename, outputMode)
+		e.browseArchive(s, terminal, userManager, currentUser, record, filePath, outputMode, nodeNumber)
 	} else {
 		if termHeight <= 0 {
 			_, termHeight = getTerminalSize(s)
@@ -130,8 +130,9 @@ func runTypeTextFile(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, us
 	return currentUser, "", nil
 }
 
-// viewFileByRecord displays a file given its record, used from the lightbar file list.
-func viewFileByRecord(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, record *file.FileRecord, outputMode ansi.OutputMode, termWidth int, termHeight int) {
+// viewFileByRecord displays a file given its record, used from the file lists.
+// Archives open the archive browser; anything else is paged as text.
+func viewFileByRecord(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, record *file.FileRecord, outputMode ansi.OutputMode, nodeNumber int, termWidth int, termHeight int) {
 	filePath, err := e.FileMgr.GetFilePath(record.ID)
 	if err != nil {
 		log.Printf("ERROR: Failed to get path for file %s: %v", record.ID, err)
@@ -141,9 +142,7 @@ func viewFileByRecord(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, r
 	}
 
 	if e.FileMgr.IsSupportedArchive(record.Filename) {
-		ctx, cancel := e.transferContext(s.Context())
-		defer cancel()
-		ziplab.RunZipLabView(ctx, s, terminal, filePath, record.Filename, outputMode)
+		e.browseArchive(s, terminal, userManager, currentUser, record, filePath, outputMode, nodeNumber)
 	} else {
 		if termHeight <= 0 {
 			_, termHeight = getTerminalSize(s)
@@ -0,0 +1,319 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// askInfoForm asks each question of f, offering the answers in prev as
+// defaults, and returns the answers by question ID. ok is false when the
+// caller abandoned the form with ESC.
+func (e *MenuExecutor) askInfoForm(s ssh.Session, terminal *term.Terminal, f config.InfoForm, prev map[string]string, outputMode ansi.OutputMode, nodeNumber, termWidth, termHeight int) (map[string]string, bool, error) {
+	wv(terminal, fmt.Sprintf("\r\n|15%s\r\n|08%s\r\n", f.Name, strings.Repeat("\xc4", max(len(f.Name), 20))), outputMode)
+	if f.Description != "" {
+		wv(terminal, "|07"+f.Description+"\r\n", outputMode)
+	}
+
+	answers := make(map[string]string, len(f.Questions))
+	for i, q := range f.Questions {
+		answer, ok, err := e.askInfoQuestion(s, terminal, i+1, q, prev[q.ID], outputMode, nodeNumber, termWidth, termHeight)
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+		answers[q.ID] = answer
+	}
+	return answers, true, nil
+}
+
+// askInfoQuestion asks q until it gets a valid answer. prev is offered as
+// the default.
+func (e *MenuExecutor) askInfoQuestion(s ssh.Session, terminal *term.Terminal, num int, q config.InfoQuestion, prev string, outputMode ansi.OutputMode, nodeNumber, termWidth, termHeight int) (string, bool, error) {
+	required := ""
+	if q.Required {
+		required = " |12*"
+	}
+
+	if q.Type == config.InfoQuestionYesNo {
+		yes, err := e.PromptYesNo(s, terminal, fmt.Sprintf("\r\n|09%d|01) |07%s%s|07 @", num, q.Text, required), outputMode, nodeNumber, termWidth, termHeight, prev == "Yes")
+		if err != nil {
+			return "", false, err
+		}
+		if yes {
+			return "Yes", true, nil
+		}
+		return "No", true, nil
+	}
+
+	def, maxLen := prev, q.MaxAnswerLength()
+	if q.Type == config.InfoQuestionChoice {
+		def, maxLen = "", len(strconv.Itoa(len(q.Choices)))
+		for i, c := range q.Choices {
+			if c == prev {
+				def = strconv.Itoa(i + 1)
+			}
+		}
+	}
+
+	for {
+		wv(terminal, fmt.Sprintf("\r\n|09%d|01) |07%s%s\r\n", num, q.Text, required), outputMode)
+		for i, c := range q.Choices {
+			wv(terminal, fmt.Sprintf("   |09%d|01) |07%s\r\n", i+1, c), outputMode)
+		}
+		wv(terminal, "|09: ", outputMode)
+
+		input, err := styledInput(terminal, s, outputMode, maxLen, def)
+		if err != nil {
+			if errors.Is(err, errInputAborted) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		answer, checkErr := q.CheckAnswer(input)
+		if checkErr != nil {
+			wv(terminal, "|12"+checkErr.Error()+"|07\r\n", outputMode)
+			def = input
+			continue
+		}
+		return answer, true, nil
+	}
+}
+
+// fillInfoForm asks f and stores the answers on u. The caller saves u.
+// Returns false when the form was abandoned.
+func (e *MenuExecutor) fillInfoForm(s ssh.Session, terminal *term.Terminal, u *user.User, f config.InfoForm, outputMode ansi.OutputMode, nodeNumber, termWidth, termHeight int) (bool, error) {
+	var prev map[string]string
+	if a, ok := u.InfoFormAnswered(f.ID); ok {
+		prev = a.Answers
+	}
+	answers, ok, err := e.askInfoForm(s, terminal, f, prev, outputMode, nodeNumber, termWidth, termHeight)
+	if err != nil || !ok {
+		return false, err
+	}
+	u.SetInfoFormAnswers(f.ID, user.InfoFormAnswers{Version: f.Version, Answered: time.Now(), Answers: answers})
+	log.Printf("INFO: Node %d: %s filled in infoform %d (%s)", nodeNumber, u.Handle, f.ID, f.Name)
+	return true, nil
+}
+
+// askNewUserInfoForms asks the forms marked newUser during signup and
+// returns the answers by form ID. A required form cannot be skipped; ESC
+// on one offers to leave signup, which returns io.EOF like the other
+// signup prompts.
+func (e *MenuExecutor) askNewUserInfoForms(s ssh.Session, terminal *term.Terminal, outputMode ansi.OutputMode, nodeNumber, termWidth, termHeight int) (map[int]user.InfoFormAnswers, error) {
+	results := make(map[int]user.InfoFormAnswers)
+	for _, f := range e.GetInfoForms() {
+		if !f.NewUser {
+			continue
+		}
+		for {
+			answers, ok, err := e.askInfoForm(s, terminal, f, nil, outputMode, nodeNumber, termWidth, termHeight)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				results[f.ID] = user.InfoFormAnswers{Version: f.Version, Answered: time.Now(), Answers: answers}
+				break
+			}
+			if !f.Required {
+				break
+			}
+			exit, err := e.confirmExitNewUser(s, terminal, outputMode, nodeNumber, termWidth, termHeight)
+			if err != nil {
+				return nil, err
+			}
+			if exit {
+				return nil, io.EOF
+			}
+		}
+	}
+	return results, nil
+}
+
+// infoFormStatus describes where u stands with f for the form list.
+func infoFormStatus(u *user.User, f config.InfoForm) string {
+	switch _, answered := u.InfoFormAnswered(f.ID); {
+	case u.InfoFormCurrent(f):
+		return "|10Answered"
+	case answered:
+		return "|14Changed - please update"
+	case f.Required:
+		return "|12Not answered (required)"
+	default:
+		return "|12Not answered"
+	}
+}
+
+// writeInfoFormAnswers shows a's answers to f's questions. Answers to
+// questions no longer on the form are not shown.
+func writeInfoFormAnswers(terminal *term.Terminal, f config.InfoForm, a user.InfoFormAnswers, outputMode ansi.OutputMode) {
+	wv(terminal, fmt.Sprintf("|15%s |08(%s)\r\n", f.Name, a.Answered.Format("01/02/2006")), outputMode)
+	for _, q := range f.Questions {
+		answer := a.Answers[q.ID]
+		if answer == "" {
+			answer = "|08(no answer)"
+		}
+		wv(terminal, fmt.Sprintf(" |03%s\r\n   |11%s\r\n", q.Text, answer), outputMode)
+	}
+}
+
+// runInfoForms lists the infoforms and lets the user fill one in, re-answer
+// it, or view their answers (V2 infoforms).
+func runInfoForms(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return nil, "", nil
+	}
+	forms := e.GetInfoForms()
+	if len(forms) == 0 {
+		wv(terminal, "\r\n|07There are no infoforms on this system.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+
+	for {
+		wv(terminal, "\x1b[2J\x1b[H", outputMode)
+		wv(terminal, "|15Infoforms\r\n", outputMode)
+		wv(terminal, fmt.Sprintf("|11%-4s%-36s%s\r\n", "#", "Form", "Status"), outputMode)
+		wv(terminal, "|08"+strings.Repeat("\xc4", 70)+"\r\n", outputMode)
+		for _, f := range forms {
+			wv(terminal, fmt.Sprintf("|09%-4d|07%-36s%s\r\n", f.ID, f.Name, infoFormStatus(currentUser, f)), outputMode)
+		}
+
+		prompt := e.LoadedStrings.InfoformPrompt
+		if prompt == "" {
+			prompt = "|07Infoforms (|15V|07)iew (|15Q|07)uit or |15#|07: "
+		}
+		wv(terminal, "\r\n"+prompt, outputMode)
+		input, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			return currentUser, "", err
+		}
+		input = strings.ToUpper(strings.TrimSpace(input))
+
+		switch input {
+		case "", "Q":
+			return currentUser, "", nil
+		case "V":
+			viewPrompt := e.LoadedStrings.ViewWhichForm
+			if viewPrompt == "" {
+				viewPrompt = "|07View which form? (#): "
+			}
+			wv(terminal, viewPrompt, outputMode)
+			which, err := readLineFromSessionIH(s, terminal)
+			if err != nil {
+				return currentUser, "", err
+			}
+			id, _ := strconv.Atoi(strings.TrimSpace(which))
+			f, ok := config.FindInfoForm(forms, id)
+			if !ok {
+				continue
+			}
+			wv(terminal, "\r\n", outputMode)
+			if a, answered := currentUser.InfoFormAnswered(f.ID); answered {
+				writeInfoFormAnswers(terminal, f, a, outputMode)
+			} else {
+				wv(terminal, "|07You have not filled in that form.\r\n", outputMode)
+			}
+			e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+		default:
+			id, _ := strconv.Atoi(input)
+			f, ok := config.FindInfoForm(forms, id)
+			if !ok {
+				continue
+			}
+			filled, err := e.fillInfoForm(s, terminal, currentUser, f, outputMode, nodeNumber, termWidth, termHeight)
+			if err != nil {
+				return currentUser, "", err
+			}
+			if !filled {
+				continue
+			}
+			if err := userManager.UpdateUser(currentUser); err != nil {
+				log.Printf("ERROR: Node %d: Failed to save infoform answers for %s: %v", nodeNumber, currentUser.Handle, err)
+				wv(terminal, "\r\n|12Error saving your answers.|07\r\n", outputMode)
+			} else {
+				wv(terminal, "\r\n|10Thanks! Your answers have been saved.|07\r\n", outputMode)
+			}
+			time.Sleep(1 * time.Second)
+		}
+	}
+}
+
+// runCheckInfoForms is the CHECKINFOFORMS login item. Required forms the
+// user has not answered, or answered before they last changed, are asked
+// now; users are told about optional forms that changed since they
+// answered them.
+func runCheckInfoForms(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return currentUser, "", nil
+	}
+
+	changed := false
+	for _, f := range e.GetInfoForms() {
+		if currentUser.InfoFormCurrent(f) {
+			continue
+		}
+		_, answered := currentUser.InfoFormAnswered(f.ID)
+		if !f.Required {
+			if answered {
+				wv(terminal, fmt.Sprintf("\r\n|14Infoform #%d (%s) has changed. You can update your answers from the infoforms menu.|07\r\n", f.ID, f.Name), outputMode)
+			}
+			continue
+		}
+
+		if answered {
+			wv(terminal, fmt.Sprintf("\r\n|14Infoform #%d (%s) has changed. Please answer it again.|07\r\n", f.ID, f.Name), outputMode)
+		} else {
+			wv(terminal, fmt.Sprintf("\r\n|14Please fill in infoform #%d (%s).|07\r\n", f.ID, f.Name), outputMode)
+		}
+		filled, err := e.fillInfoForm(s, terminal, currentUser, f, outputMode, nodeNumber, termWidth, termHeight)
+		if err != nil {
+			return currentUser, "", err
+		}
+		if !filled {
+			wv(terminal, "\r\n|07You will be asked again next time you call.\r\n", outputMode)
+			continue
+		}
+		changed = true
+	}
+
+	if changed {
+		if err := userManager.UpdateUser(currentUser); err != nil {
+			log.Printf("ERROR: Node %d: Failed to save infoform answers for %s: %v", nodeNumber, currentUser.Handle, err)
+		}
+	}
+	return currentUser, "", nil
+}
+
+// nuvShowInfoForms shows a NUV candidate's infoform answers to the voter.
+func nuvShowInfoForms(e *MenuExecutor, terminal *term.Terminal, userManager *user.UserMgr, handle string, outputMode ansi.OutputMode) {
+	u, ok := userManager.GetUserByHandle(handle)
+	if !ok {
+		return
+	}
+	shown := false
+	for _, f := range e.GetInfoForms() {
+		a, answered := u.InfoFormAnswered(f.ID)
+		if !answered {
+			continue
+		}
+		if !shown {
+			wv(terminal, fmt.Sprintf("|15Infoforms:\r\n|08%s\r\n", strings.Repeat("\xc4", 40)), outputMode)
+			shown = true
+		}
+		writeInfoFormAnswers(terminal, f, a, outputMode)
+	}
+	if shown {
+		wv(terminal, "\r\n", outputMode)
+	}
+}
@@ -0,0 +1,37 @@
+package menu
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestInfoFormStatus(t *testing.T) {
+	f := config.InfoForm{ID: 1, Version: 2}
+	answered := func(version int) *user.User {
+		u := &user.User{}
+		u.SetInfoFormAnswers(1, user.InfoFormAnswers{Version: version, Answered: time.Now()})
+		return u
+	}
+
+	tests := []struct {
+		name     string
+		u        *user.User
+		required bool
+		want     string
+	}{
+		{"current", answered(2), false, "Answered"},
+		{"old version", answered(1), false, "Changed"},
+		{"not answered", &user.User{}, false, "Not answered"},
+		{"required", &user.User{}, true, "(required)"},
+	}
+	for _, tt := range tests {
+		f.Required = tt.required
+		if got := infoFormStatus(tt.u, f); !strings.Contains(got, tt.want) {
+			t.Errorf("%s: infoFormStatus = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
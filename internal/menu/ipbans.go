@@ -0,0 +1,189 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/ban"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// runIPBans is the sysop IP ban manager. Changes take effect on the next
+// connection to either listener and are shared with `helper bans`.
+func runIPBans(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return nil, "", nil
+	}
+	if currentUser.AccessLevel < e.GetServerConfig().SysOpLevel {
+		wv(terminal, "\r\n|01Access denied.|07\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+	if e.Bans == nil {
+		wv(terminal, "\r\n|12IP bans are not available; see the server log.|07\r\n", outputMode)
+		time.Sleep(2 * time.Second)
+		return currentUser, "", nil
+	}
+
+	for {
+		bans := e.Bans.Bans()
+		e.listIPBans(s, terminal, bans, outputMode, termWidth, termHeight)
+
+		wv(terminal, "\r\n|07Bans (|15A|07)dd (|15R|07)emove (|15Q|07)uit: ", outputMode)
+		input, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", err
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(input)) {
+		case "Q":
+			return currentUser, "", nil
+		case "A":
+			err = e.addIPBan(s, terminal, currentUser, nodeNumber, outputMode, termWidth, termHeight)
+		case "R":
+			err = e.removeIPBan(s, terminal, currentUser, bans, nodeNumber, outputMode)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, "LOGOFF", io.EOF
+		}
+	}
+}
+
+// listIPBans shows the active bans, one per line.
+func (e *MenuExecutor) listIPBans(s ssh.Session, terminal *term.Terminal, bans []ban.Ban, outputMode ansi.OutputMode, termWidth, termHeight int) {
+	wv(terminal, ansi.ClearScreen(), outputMode)
+	wv(terminal, "|15IP Bans\r\n\r\n", outputMode)
+	if len(bans) == 0 {
+		wv(terminal, "|07No IP bans.\r\n", outputMode)
+		return
+	}
+	wv(terminal, fmt.Sprintf("|11%-5s%-20s%-16s%-12s%s\r\n", "#", "Address", "Expires", "Added By", "Reason"), outputMode)
+	wv(terminal, "|08"+strings.Repeat("\xc4", 75)+"\r\n", outputMode)
+	page := max(termHeight, 24) - 7
+	for i, b := range bans {
+		if i > 0 && i%page == 0 {
+			e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+			wv(terminal, "\r\n", outputMode)
+		}
+		expires := "Never"
+		if b.Expires != nil {
+			expires = b.Expires.Format("01/02/06 15:04")
+		}
+		wv(terminal, fmt.Sprintf("|09%-5d|15%-20s|03%-16s|07%-12s%s\r\n",
+			b.ID, adminTruncate(b.Target, 19), expires, adminTruncate(b.AddedBy, 11), adminTruncate(b.Reason, 22)), outputMode)
+	}
+}
+
+// addIPBan prompts for an address or range, a length and a reason, and
+// adds the ban.
+func (e *MenuExecutor) addIPBan(s ssh.Session, terminal *term.Terminal, sysop *user.User, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) error {
+	wv(terminal, "\r\n|07IP address or CIDR range: |15", outputMode)
+	target, err := styledInput(terminal, s, outputMode, 43, "")
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil
+	}
+	canon, network, err := ban.ParseTarget(target)
+	if err != nil {
+		wv(terminal, fmt.Sprintf("\r\n|12%v|07\r\n", err), outputMode)
+		time.Sleep(2 * time.Second)
+		return nil
+	}
+
+	if own := net.ParseIP(remoteIPFromSession(s)); own != nil && network.Contains(own) {
+		yes, err := e.PromptYesNo(s, terminal, "\r\n|12That ban covers your own address. Add it anyway? @", outputMode, nodeNumber, termWidth, termHeight, false)
+		if err != nil || !yes {
+			return err
+		}
+	}
+
+	var length time.Duration
+	for {
+		wv(terminal, "\r\n|07Ban for (e.g. 30m, 12h, 7d, 2w; Enter = permanent): |15", outputMode)
+		in, err := styledInput(terminal, s, outputMode, 10, "")
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return err
+			}
+			return nil
+		}
+		if length, err = ban.ParseDuration(in); err == nil {
+			break
+		}
+		wv(terminal, fmt.Sprintf("\r\n|12%v|07", err), outputMode)
+	}
+
+	wv(terminal, "\r\n|07Reason: |15", outputMode)
+	reason, err := styledInput(terminal, s, outputMode, 50, "")
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+
+	b, err := e.Bans.Add(canon, reason, sysop.Handle, length)
+	switch {
+	case errors.Is(err, ban.ErrExists):
+		wv(terminal, fmt.Sprintf("\r\n|12%s is already banned (#%d).|07\r\n", canon, b.ID), outputMode)
+	case err != nil:
+		log.Printf("ERROR: Node %d: Failed to ban %s: %v", nodeNumber, canon, err)
+		wv(terminal, "\r\n|12Error saving the ban!|07\r\n", outputMode)
+	default:
+		log.Printf("SECURITY: Node %d: %s banned %s (%s)", nodeNumber, sysop.Handle, b.Target, b.Reason)
+		wv(terminal, fmt.Sprintf("\r\n|10%s banned.|07\r\n", b.Target), outputMode)
+	}
+	time.Sleep(1 * time.Second)
+	return nil
+}
+
+// removeIPBan lifts a ban chosen by number.
+func (e *MenuExecutor) removeIPBan(s ssh.Session, terminal *term.Terminal, sysop *user.User, bans []ban.Ban, nodeNumber int, outputMode ansi.OutputMode) error {
+	if len(bans) == 0 {
+		return nil
+	}
+	wv(terminal, "\r\n|07Remove ban #: |15", outputMode)
+	in, err := styledInput(terminal, s, outputMode, 6, "")
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(in))
+	if err != nil {
+		return nil
+	}
+	b, err := e.Bans.Remove(id)
+	switch {
+	case errors.Is(err, ban.ErrNotFound):
+		wv(terminal, fmt.Sprintf("\r\n|12No ban #%d.|07\r\n", id), outputMode)
+	case err != nil:
+		log.Printf("ERROR: Node %d: Failed to remove ban #%d: %v", nodeNumber, id, err)
+		wv(terminal, "\r\n|12Error saving the ban list!|07\r\n", outputMode)
+	default:
+		log.Printf("SECURITY: Node %d: %s lifted ban #%d on %s", nodeNumber, sysop.Handle, b.ID, b.Target)
+		wv(terminal, fmt.Sprintf("\r\n|10Ban on %s lifted.|07\r\n", b.Target), outputMode)
+	}
+	time.Sleep(1 * time.Second)
+	return nil
+}
@@ -93,7 +93,7 @@ func (e *MenuExecutor) RunMatrixScreen(
 		key, err := sessionIH.ReadKey()
 		if err != nil {
 			if errors.Is(err, editor.ErrIdleTimeout) {
-				e.handleIdleTimeout(terminal, outputMode, nodeNumber, termHeight)
+				e.handleIdleTimeout(s, terminal, outputMode, nodeNumber, termHeight)
 				return "DISCONNECT", nil
 			}
 			if errors.Is(err, io.EOF) {
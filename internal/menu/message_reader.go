@@ -1053,6 +1053,7 @@ func handleReply(e *MenuExecutor, s ssh.Session, ih *editor.InputHandler, termin
 		if err := userManager.UpdateUser(currentUser); err != nil {
 			log.Printf("ERROR: Node %d: Failed to update MessagesPosted for user %s: %v", nodeNumber, currentUser.Handle, err)
 		}
+		e.logActivity(userManager, currentUser, nodeNumber, user.ActivityRecord{Kind: user.ActivityPost})
 		terminalio.WriteProcessedBytes(terminal, []byte(e.LoadedStrings.MsgReplySuccess), outputMode)
 		time.Sleep(1 * time.Second)
 		*totalMsgCount++
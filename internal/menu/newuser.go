@@ -156,7 +156,7 @@ func (e *MenuExecutor) handleNewUserApplication(
 	terminalio.WriteStringCP437(terminal, ansi.ReplacePipeCodes([]byte(userNumStr)), outputMode)
 
 	// 5. Password creation with confirmation
-	password, err := e.promptForPassword(s, terminal, nodeNumber, outputMode, termWidth, termHeight)
+	password, err := e.promptForPassword(s, terminal, userManager, nil, nodeNumber, outputMode, termWidth, termHeight)
 	if err != nil {
 		return err
 	}
@@ -185,7 +185,13 @@ func (e *MenuExecutor) handleNewUserApplication(
 		return err
 	}
 
-	// 9. Create account
+	// 9. New user infoforms
+	formAnswers, err := e.askNewUserInfoForms(s, terminal, outputMode, nodeNumber, termWidth, termHeight)
+	if err != nil {
+		return err
+	}
+
+	// 10. Create account
 	newUser, addErr := userManager.AddUser(
 		strings.ToLower(handle), // username = lowercase handle
 		password,
@@ -206,6 +212,16 @@ func (e *MenuExecutor) handleNewUserApplication(
 	newUser.PrivateNote = userNote
 	newUser.CreatedAt = time.Now()
 
+	for id, a := range formAnswers {
+		newUser.SetInfoFormAnswers(id, a)
+	}
+
+	// Start the account on the new user level's profile, from level 0 so
+	// its signup points are granted
+	startLevel := newUser.AccessLevel
+	newUser.AccessLevel = 0
+	e.setUserLevel(newUser, startLevel)
+
 	// Auto-join any message areas flagged with auto_join
 	if e.MessageMgr != nil {
 		var autoJoinTags []string
@@ -369,10 +385,14 @@ func (e *MenuExecutor) promptForHandle(
 	return "", nil
 }
 
-// promptForPassword prompts for password with confirmation.
+// promptForPassword prompts for password with confirmation. The password
+// must meet the password policy; u is the account whose password is being
+// replaced, for the reuse check, or nil at signup.
 func (e *MenuExecutor) promptForPassword(
 	s ssh.Session,
 	terminal *term.Terminal,
+	userManager *user.UserMgr,
+	u *user.User,
 	nodeNumber int,
 	outputMode ansi.OutputMode,
 	termWidth, termHeight int,
@@ -397,6 +417,9 @@ func (e *MenuExecutor) promptForPassword(
 				return "", io.EOF
 			}
 			if errors.Is(err, errInputAborted) {
+				if u != nil {
+					return "", nil // Changing a password: ESC cancels
+				}
 				exit, confirmErr := e.confirmExitNewUser(s, terminal, outputMode, nodeNumber, termWidth, termHeight)
 				if confirmErr != nil {
 					return "", confirmErr
@@ -410,6 +433,9 @@ func (e *MenuExecutor) promptForPassword(
 		}
 
 		if len(password) == 0 {
+			if u != nil {
+				return "", nil
+			}
 			retry, confirmErr := e.confirmCannotBeEmpty(s, terminal, outputMode, nodeNumber, termWidth, termHeight)
 			if confirmErr != nil {
 				return "", confirmErr
@@ -420,8 +446,15 @@ func (e *MenuExecutor) promptForPassword(
 			continue
 		}
 
-		if len(password) < 3 {
+		if policyErr := userManager.CheckNewPassword(u, password); policyErr != nil {
 			msg := e.LoadedStrings.NewUserPasswordTooShort
+			if errors.Is(policyErr, user.ErrPasswordReused) {
+				msg = e.LoadedStrings.PasswordReused
+				if msg == "" {
+					msg = "\r\n|09You have used that password recently. Choose another.|07\r\n"
+				}
+			}
+			msg = strings.ReplaceAll(msg, "|ML", strconv.Itoa(userManager.PasswordPolicy().MinLength))
 			terminalio.WriteStringCP437(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
 			time.Sleep(500 * time.Millisecond)
 			continue
@@ -435,6 +468,9 @@ func (e *MenuExecutor) promptForPassword(
 				return "", io.EOF
 			}
 			if errors.Is(err, errInputAborted) {
+				if u != nil {
+					return "", nil // Changing a password: ESC cancels
+				}
 				exit, confirmErr := e.confirmExitNewUser(s, terminal, outputMode, nodeNumber, termWidth, termHeight)
 				if confirmErr != nil {
 					return "", confirmErr
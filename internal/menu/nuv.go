@@ -157,7 +157,9 @@ func nuvApplyThresholds(e *MenuExecutor, nd *NUVData, idx int, userManager *user
 		if cfg.NUVValidate {
 			shouldRemove = false
 			if u, ok := userManager.GetUserByHandle(c.Handle); ok {
-				u.AccessLevel = cfg.NUVLevel
+				if u.AccessLevel != cfg.NUVLevel {
+					e.setUserLevel(u, cfg.NUVLevel)
+				}
 				u.Validated = true
 				if err := userManager.UpdateUser(u); err != nil {
 					log.Printf("ERROR: NUV: failed to validate user '%s': %v", c.Handle, err)
@@ -208,6 +210,7 @@ func nuvVoteOn(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 	ih := getSessionIH(s)
 	c := &nd.Candidates[idx]
 	nuvDisplayStats(terminal, c, idx+1, outputMode)
+	nuvShowInfoForms(e, terminal, userManager, c.Handle, outputMode)
 
 	voterIdx := nuvVoteIndex(c, currentUser.Handle)
 	if voterIdx >= 0 {
@@ -230,6 +233,7 @@ func nuvVoteOn(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
 			return false
 		case key == 'R' || key == 'r':
 			nuvDisplayStats(terminal, c, idx+1, outputMode)
+			nuvShowInfoForms(e, terminal, userManager, c.Handle, outputMode)
 			if voterIdx >= 0 {
 				vote := "|12No"
 				if c.Votes[voterIdx].Yes {
@@ -0,0 +1,231 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// requestOfflineFile queues an offline request by u for fileID. Returns the
+// filename and whether a new request was queued (false if u already had one).
+func (e *MenuExecutor) requestOfflineFile(u *user.User, fileID uuid.UUID, nodeNumber int) (string, bool, error) {
+	rec, ok := e.FileMgr.GetFileRecord(fileID)
+	if !ok {
+		return "", false, fmt.Errorf("file record %s not found", fileID)
+	}
+	_, queued, err := e.FileMgr.RequestOfflineFile(rec, u.ID, u.Handle)
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to queue offline request for %s by %s: %v", nodeNumber, rec.Filename, u.Handle, err)
+		return rec.Filename, false, err
+	}
+	if queued {
+		log.Printf("INFO: Node %d: %s requested offline file %s/%s", nodeNumber, u.Handle, e.fileAreaTag(rec.AreaID), rec.Filename)
+	}
+	return rec.Filename, queued, nil
+}
+
+// noticeOfflineDownload tells the user a file they tried to download is
+// offline and queues a request for it.
+func (e *MenuExecutor) noticeOfflineDownload(terminal *term.Terminal, u *user.User, fileID uuid.UUID, nodeNumber int, outputMode ansi.OutputMode) {
+	name, queued, err := e.requestOfflineFile(u, fileID, nodeNumber)
+	offlineMsg := e.LoadedStrings.FileIsOffline
+	if offlineMsg == "" {
+		offlineMsg = "|09That file is |05(|13Offline|05).."
+	}
+	msg := "\r\n" + offlineMsg + "|07\r\n"
+	switch {
+	case err != nil:
+		msg += "|01Could not queue a request for it.|07\r\n"
+	case queued:
+		msg += fmt.Sprintf("|07A request for |15%s|07 has been queued. You'll get mail when it is back online.\r\n", name)
+	default:
+		msg += fmt.Sprintf("|07You have already requested |15%s|07.\r\n", name)
+	}
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+}
+
+// runOfflineRequests is the RunnableFunc for the sysop offline request
+// queue. It steps through the requests, oldest first. Fulfilling one brings
+// the file back online and mails everyone who asked for it; denying one
+// drops it and tells the requester.
+func runOfflineRequests(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil || !e.isCoSysOpOrAbove(currentUser) {
+		return currentUser, "", nil
+	}
+
+	reqs := e.FileMgr.ListOfflineRequests()
+	if len(reqs) == 0 {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|07There are no offline requests.\r\n")), outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+
+	ih := getSessionIH(s)
+	fulfilled, denied := 0, 0
+	done := make(map[uuid.UUID]bool) // Requests already handled along with another for the same file
+
+	for i := 0; i < len(reqs); {
+		req := reqs[i]
+		if done[req.ID] {
+			i++
+			continue
+		}
+		e.renderOfflineRequest(terminal, req, reqs, i+1, outputMode)
+
+		key, err := ih.ReadKey()
+		if err != nil {
+			if errors.Is(err, editor.ErrIdleTimeout) || errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", err
+		}
+		if key == editor.KeyEsc {
+			break
+		}
+		if key < 32 || key >= 127 {
+			continue
+		}
+
+		switch strings.ToLower(string(rune(key))) {
+		case "q":
+			i = len(reqs)
+
+		case "f": // Fulfil: the file is back
+			if err := e.bringFileOnline(req.FileID); err != nil {
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Still offline: %v|07", err), outputMode)
+				continue
+			}
+			var ids []uuid.UUID
+			for _, r := range reqs {
+				if r.FileID != req.FileID || done[r.ID] {
+					continue
+				}
+				ids = append(ids, r.ID)
+				done[r.ID] = true
+				body := fmt.Sprintf("The file you requested, %s (%s), is back online and ready to download.\n", r.Filename, e.fileAreaTag(r.AreaID))
+				e.sendPrivateNotice("", r.Handle, "File online: "+r.Filename, body, nodeNumber)
+			}
+			if err := e.FileMgr.RemoveOfflineRequests(ids...); err != nil {
+				log.Printf("ERROR: Node %d: Failed to remove offline requests for %s: %v", nodeNumber, req.Filename, err)
+			}
+			log.Printf("INFO: Node %d: %s fulfilled %d offline request(s) for %s.", nodeNumber, currentUser.Handle, len(ids), req.Filename)
+			e.fileEditNotice(terminal, fmt.Sprintf("|10%s is online. Notified %d user(s).|07", req.Filename, len(ids)), outputMode)
+			fulfilled += len(ids)
+			i++
+
+		case "d": // Deny
+			tw, th := getTerminalSize(s)
+			proceed, promptErr := e.PromptYesNo(s, terminal, fmt.Sprintf("Deny %s's request for %s?", req.Handle, req.Filename), outputMode, nodeNumber, tw, th, false)
+			if promptErr != nil {
+				if errors.Is(promptErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				continue
+			}
+			if !proceed {
+				continue
+			}
+			if err := e.FileMgr.RemoveOfflineRequests(req.ID); err != nil {
+				log.Printf("ERROR: Node %d: Failed to remove offline request for %s: %v", nodeNumber, req.Filename, err)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Deny failed: %v|07", err), outputMode)
+				continue
+			}
+			body := fmt.Sprintf("Sorry, your request for %s (%s) could not be fulfilled.\n", req.Filename, e.fileAreaTag(req.AreaID))
+			e.sendPrivateNotice("", req.Handle, "File request: "+req.Filename, body, nodeNumber)
+			log.Printf("INFO: Node %d: %s denied %s's offline request for %s.", nodeNumber, currentUser.Handle, req.Handle, req.Filename)
+			done[req.ID] = true
+			denied++
+			i++
+
+		case "s", " ":
+			i++
+		}
+	}
+
+	summary := fmt.Sprintf("\r\n\r\n|15Offline requests done.|07 Fulfilled: |10%d|07  Denied: |09%d|07\r\n", fulfilled, denied)
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(summary)), outputMode)
+	time.Sleep(1 * time.Second)
+	return currentUser, "", nil
+}
+
+// bringFileOnline clears the record's offline flag if its file is on disk
+// again. The flag is left alone if the file still cannot be reached.
+func (e *MenuExecutor) bringFileOnline(fileID uuid.UUID) error {
+	rec, ok := e.FileMgr.GetFileRecord(fileID)
+	if !ok {
+		return fmt.Errorf("the file record no longer exists")
+	}
+	if rec.Offline {
+		if err := e.FileMgr.SetFileOffline(fileID, false); err != nil {
+			return err
+		}
+	}
+	path, err := e.FileMgr.GetFilePath(fileID)
+	if err == nil {
+		_, err = os.Stat(path)
+	}
+	if err != nil && rec.Offline {
+		_ = e.FileMgr.SetFileOffline(fileID, true)
+	}
+	return err
+}
+
+// renderOfflineRequest draws one offline request for the sysop queue.
+func (e *MenuExecutor) renderOfflineRequest(terminal *term.Terminal, req file.OfflineRequest, all []file.OfflineRequest, index int, outputMode ansi.OutputMode) {
+	var b strings.Builder
+	b.WriteString(ansi.ClearScreen())
+	b.WriteString(fmt.Sprintf("|09Offline Requests |08- |15%d|08 of |15%d|07\r\n", index, len(all)))
+	b.WriteString("|08" + strings.Repeat("-", 79) + "|07\r\n")
+
+	areaLabel := e.fileAreaTag(req.AreaID)
+	status := "|12Offline"
+	if area, ok := e.FileMgr.GetAreaByID(req.AreaID); ok {
+		areaLabel = fmt.Sprintf("%s |08(|07%s|08)", area.Tag, area.Name)
+		if area.Offline {
+			status = "|12Area offline"
+		}
+	}
+	if rec, ok := e.FileMgr.GetFileRecord(req.FileID); !ok {
+		status = "|12Record deleted"
+	} else if rec.Offline {
+		status = "|12Marked offline"
+	} else if _, err := e.FileMgr.GetFilePath(req.FileID); err == nil {
+		status = "|10Available"
+	}
+	others := 0
+	for _, r := range all {
+		if r.FileID == req.FileID && r.ID != req.ID {
+			others++
+		}
+	}
+
+	loc := config.LoadTimezone(e.ServerCfg.Timezone)
+	b.WriteString(fmt.Sprintf(" |11Area        |08: |15%s\r\n", areaLabel))
+	b.WriteString(fmt.Sprintf(" |11Filename    |08: |15%s\r\n", req.Filename))
+	b.WriteString(fmt.Sprintf(" |11Status      |08: %s\r\n", status))
+	b.WriteString(fmt.Sprintf(" |11Requested By|08: |07%s\r\n", req.Handle))
+	b.WriteString(fmt.Sprintf(" |11Requested   |08: |07%s\r\n", req.RequestedAt.In(loc).Format("01/02/2006 15:04")))
+	if others > 0 {
+		b.WriteString(fmt.Sprintf(" |11Also wanted |08: |07by %d other user(s)\r\n", others))
+	}
+
+	b.WriteString("\r\n |08[|14F|08]|07ulfil  |08[|14D|08]|07eny  |08[|14S|08]|07kip  |08[|14Q|08]|07uit\r\n")
+	b.WriteString("\r\n|15Command: |07")
+
+	_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(b.String())), outputMode)
+}
@@ -0,0 +1,242 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/transfer"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// formatPartialProgress describes how much of a partial upload arrived,
+// e.g. "120K of 480K, 25%".
+func formatPartialProgress(p file.PartialUpload) string {
+	if pct := p.Percent(); pct >= 0 {
+		return fmt.Sprintf("%s of %s, %d%%", formatTransferBytes(p.Size), formatTransferBytes(p.ExpectedSize), pct)
+	}
+	return formatTransferBytes(p.Size) + " received"
+}
+
+// keepPartialUpload moves an interrupted upload out of the incoming
+// directory into the uploader's partials so it can be resumed or promoted.
+// Empty files are not worth keeping. Returns the saved partial.
+func (e *MenuExecutor) keepPartialUpload(u *user.User, areaID int, proto *transfer.ProtocolConfig, path, name string, size, expected int64, nodeNumber int) (file.PartialUpload, bool) {
+	if size <= 0 {
+		return file.PartialUpload{}, false
+	}
+	p := file.PartialUpload{
+		UserID:       u.ID,
+		Handle:       u.Handle,
+		AreaID:       areaID,
+		Filename:     name,
+		Size:         size,
+		ExpectedSize: expected,
+		Protocol:     proto.Name,
+	}
+	if err := e.FileMgr.SavePartial(p, path); err != nil {
+		log.Printf("ERROR: Node %d: Failed to keep partial upload %s: %v", nodeNumber, name, err)
+		return file.PartialUpload{}, false
+	}
+	log.Printf("INFO: Node %d: Kept partial upload %s from %s (%s)", nodeNumber, name, u.Handle, formatPartialProgress(p))
+	return p, true
+}
+
+// offerPartialResumes asks the user about each unfinished upload to areaID
+// and returns the names they want to continue. Only protocols with crash
+// recovery are offered a resume.
+func (e *MenuExecutor) offerPartialResumes(s ssh.Session, terminal *term.Terminal, u *user.User, areaID int, proto *transfer.ProtocolConfig, outputMode ansi.OutputMode, nodeNumber int) ([]string, error) {
+	if !proto.CanResume() {
+		return nil, nil
+	}
+	var names []string
+	for _, p := range e.FileMgr.ListPartials(u.ID) {
+		if p.AreaID != areaID {
+			continue
+		}
+		msg := fmt.Sprintf("\r\n|15Unfinished upload: |14%s |08(|07%s|08)|07\r\n", p.Filename, formatPartialProgress(p))
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		tw, th := getTerminalSize(s)
+		resume, err := e.PromptYesNo(s, terminal, "|07Resume it with crash recovery?", outputMode, nodeNumber, tw, th, true)
+		if err != nil {
+			return nil, err
+		}
+		if resume {
+			names = append(names, p.Filename)
+		}
+	}
+	return names, nil
+}
+
+// runPartialUploads is the RunnableFunc for the sysop partial upload queue.
+// It steps through every user's interrupted uploads, oldest first, so the
+// sysop can promote one to a real file or delete it.
+func runPartialUploads(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil || !e.isCoSysOpOrAbove(currentUser) {
+		return currentUser, "", nil
+	}
+
+	partials := e.FileMgr.ListAllPartials()
+	if len(partials) == 0 {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|07There are no partial uploads.\r\n")), outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+
+	ih := getSessionIH(s)
+	promoted, deleted := 0, 0
+
+	for i := 0; i < len(partials); {
+		p := partials[i]
+		e.renderPartialUpload(terminal, p, i+1, len(partials), outputMode)
+
+		key, err := ih.ReadKey()
+		if err != nil {
+			if errors.Is(err, editor.ErrIdleTimeout) || errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", err
+		}
+		if key == editor.KeyEsc {
+			break
+		}
+		if key < 32 || key >= 127 {
+			continue
+		}
+
+		switch strings.ToLower(string(rune(key))) {
+		case "q":
+			i = len(partials)
+
+		case "p": // Promote to a real file
+			areaID := p.AreaID
+			input, ok, promptErr := e.promptFileEditField(s, terminal, fmt.Sprintf("Area (# or tag, Enter for %s)", e.fileAreaTag(p.AreaID)), outputMode)
+			if promptErr != nil {
+				if errors.Is(promptErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				continue
+			}
+			if ok {
+				area, found := e.resolveFileAreaInput(input)
+				if !found {
+					e.fileEditNotice(terminal, "|01Area not found.|07", outputMode)
+					continue
+				}
+				areaID = area.ID
+			}
+			desc, _, promptErr := e.promptFileEditField(s, terminal, "Description", outputMode)
+			if promptErr != nil {
+				if errors.Is(promptErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				continue
+			}
+			desc = sanitizeControlChars(desc)
+			if len([]rune(desc)) > 60 {
+				desc = string([]rune(desc)[:60])
+			}
+
+			rec, prErr := e.FileMgr.PromotePartial(p.UserID, p.Filename, areaID, desc)
+			if prErr != nil {
+				log.Printf("ERROR: Node %d: Failed to promote partial upload %s from %s: %v", nodeNumber, p.Filename, p.Handle, prErr)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Promote failed: %v|07", prErr), outputMode)
+				continue
+			}
+			log.Printf("INFO: Node %d: %s promoted partial upload '%s' from %s to %s.", nodeNumber, currentUser.Handle, rec.Filename, p.Handle, e.fileAreaTag(areaID))
+			e.logFileAdminActionNotes(userManager, currentUser, rec, "PROMOTE_PARTIAL", fmt.Sprintf("Uploaded by %s (%s)", p.Handle, formatPartialProgress(p)))
+			e.fillWants(rec, nodeNumber)
+			msg := fmt.Sprintf("|10Promoted %s.|07", rec.Filename)
+			if credit := e.creditUploader(userManager, rec, nodeNumber); credit > 0 {
+				msg += fmt.Sprintf(" |07Granted |15%d|07 point(s) to %s.", credit, rec.UploadedBy)
+			}
+			e.fileEditNotice(terminal, msg, outputMode)
+			promoted++
+			i++
+
+		case "d": // Delete
+			tw, th := getTerminalSize(s)
+			proceed, promptErr := e.PromptYesNo(s, terminal, fmt.Sprintf("Delete partial %s?", p.Filename), outputMode, nodeNumber, tw, th, false)
+			if promptErr != nil {
+				if errors.Is(promptErr, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				continue
+			}
+			if !proceed {
+				continue
+			}
+			if delErr := e.FileMgr.DeletePartial(p.UserID, p.Filename); delErr != nil && !errors.Is(delErr, file.ErrPartialNotFound) {
+				log.Printf("ERROR: Node %d: Failed to delete partial upload %s: %v", nodeNumber, p.Filename, delErr)
+				e.fileEditNotice(terminal, fmt.Sprintf("|01Delete failed: %v|07", delErr), outputMode)
+				continue
+			}
+			log.Printf("INFO: Node %d: %s deleted partial upload '%s' from %s.", nodeNumber, currentUser.Handle, p.Filename, p.Handle)
+			deleted++
+			i++
+
+		case "s", " ":
+			i++
+		}
+	}
+
+	summary := fmt.Sprintf("\r\n\r\n|15Partial uploads done.|07 Promoted: |10%d|07  Deleted: |09%d|07\r\n", promoted, deleted)
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(summary)), outputMode)
+	time.Sleep(1 * time.Second)
+	return currentUser, "", nil
+}
+
+// renderPartialUpload draws one partial upload for the sysop queue.
+func (e *MenuExecutor) renderPartialUpload(terminal *term.Terminal, p file.PartialUpload, index, total int, outputMode ansi.OutputMode) {
+	var b strings.Builder
+	b.WriteString(ansi.ClearScreen())
+	b.WriteString(fmt.Sprintf("|09Partial Uploads |08- |15%d|08 of |15%d|07\r\n", index, total))
+	b.WriteString("|08" + strings.Repeat("-", 79) + "|07\r\n")
+
+	areaLabel := e.fileAreaTag(p.AreaID)
+	if area, ok := e.FileMgr.GetAreaByID(p.AreaID); ok {
+		areaLabel = fmt.Sprintf("%s |08(|07%s|08)", area.Tag, area.Name)
+	}
+	loc := config.LoadTimezone(e.ServerCfg.Timezone)
+	b.WriteString(fmt.Sprintf(" |11Area        |08: |15%s\r\n", areaLabel))
+	b.WriteString(fmt.Sprintf(" |11Filename    |08: |15%s\r\n", p.Filename))
+	b.WriteString(fmt.Sprintf(" |11Received    |08: |07%s\r\n", formatPartialProgress(p)))
+	b.WriteString(fmt.Sprintf(" |11Uploader    |08: |07%s\r\n", p.Handle))
+	b.WriteString(fmt.Sprintf(" |11Protocol    |08: |07%s\r\n", p.Protocol))
+	b.WriteString(fmt.Sprintf(" |11Interrupted |08: |07%s\r\n", p.SavedAt.In(loc).Format("01/02/2006 15:04")))
+
+	b.WriteString("\r\n |08[|14P|08]|07romote  |08[|14D|08]|07elete  |08[|14S|08]|07kip  |08[|14Q|08]|07uit\r\n")
+	b.WriteString("\r\n|15Command: |07")
+
+	_ = terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(b.String())), outputMode)
+}
+
+// PurgePartials is the purge_partials event job. It deletes partial uploads
+// older than partialRetentionDays through the file manager, which holds the
+// lock the upload menus use, so no node loses a partial it is saving or
+// resuming.
+func (e *MenuExecutor) PurgePartials() (string, error) {
+	days := e.GetServerConfig().PartialRetentionDays
+	if days < 0 {
+		return "Retention days is -1 (never purge). Nothing to do.", nil
+	}
+	purged, err := e.FileMgr.PurgeStalePartials(time.Duration(days)*24*time.Hour, false)
+	if err != nil {
+		return "", fmt.Errorf("purge partial uploads: %w", err)
+	}
+	for _, p := range purged {
+		log.Printf("INFO: Purged partial upload %s of %s (saved %s)", p.Filename, p.Handle, p.SavedAt.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("Purged %d partial upload(s) (retention: %d days).", len(purged), days), nil
+}
@@ -0,0 +1,44 @@
+package menu
+
+import (
+	"log"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// requirePasswordChange makes a user who must change their password -
+// flagged by the sysop, logged in with a reset code, or past the maximum
+// password age - choose a new one before the login sequence runs. Returns
+// false when they give up, and the caller logs them off.
+func (e *MenuExecutor) requirePasswordChange(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, u *user.User, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) (bool, error) {
+	if !userManager.NeedsPasswordChange(u) {
+		return true, nil
+	}
+	log.Printf("INFO: Node %d: %s must change their password", nodeNumber, u.Handle)
+
+	msg := e.LoadedStrings.PasswordChangeRequired
+	if msg == "" {
+		msg = "\r\n|14You must choose a new password before continuing.|07\r\n"
+	}
+	wv(terminal, msg, outputMode)
+
+	newPw, err := e.promptForPassword(s, terminal, userManager, u, nodeNumber, outputMode, termWidth, termHeight)
+	if err != nil || newPw == "" {
+		return false, err
+	}
+	if err := userManager.SetPassword(u, newPw); err != nil {
+		log.Printf("ERROR: Node %d: Failed to save password for %s: %v", nodeNumber, u.Handle, err)
+		wv(terminal, "\r\n|12Error saving your password!|07\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return false, nil
+	}
+	log.Printf("SECURITY: Node %d: %s changed their password at login", nodeNumber, u.Handle)
+	wv(terminal, e.LoadedStrings.CfgPasswordChanged, outputMode)
+	time.Sleep(1 * time.Second)
+	return true, nil
+}
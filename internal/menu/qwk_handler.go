@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
 	"golang.org/x/term"
 
 	"github.com/stlalpha/vision3/internal/ansi"
@@ -200,9 +201,20 @@ func runQWKDownload(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, use
 	resetSessionIH(s)
 	ctx, cancel := e.transferContext(s.Context())
 	defer cancel()
+	started := time.Now()
 	sendErr := proto.ExecuteSend(ctx, s, qwkPath)
 	time.Sleep(250 * time.Millisecond)
 	getSessionIH(s)
+	if !errors.Is(sendErr, transfer.ErrBinaryNotFound) {
+		var files []user.TransferFile
+		if sendErr == nil {
+			files = []user.TransferFile{e.transferFileFor(qwkPath, uuid.Nil)}
+		}
+		e.recordTransfer(userManager, currentUser, nodeNumber, user.NewTransferRecord(user.TransferDownload, proto.Name, started, files, sendErr == nil))
+		if err := userManager.UpdateUser(currentUser); err != nil {
+			log.Printf("WARN: Node %d: QWK: failed to save transfer totals: %v", nodeNumber, err)
+		}
+	}
 
 	if sendErr != nil {
 		if errors.Is(sendErr, transfer.ErrBinaryNotFound) {
@@ -263,6 +275,7 @@ func runQWKUpload(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 	resetSessionIH(s)
 	ctx, cancel := e.transferContext(s.Context())
 	defer cancel()
+	started := time.Now()
 	recvErr := proto.ExecuteReceive(ctx, s, incomingDir)
 	time.Sleep(250 * time.Millisecond)
 	getSessionIH(s)
@@ -273,6 +286,18 @@ func runQWKUpload(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 
 	// Find the .REP file
 	repPath := findREPFile(incomingDir, bbsID)
+	var rec user.TransferRecord
+	if !errors.Is(recvErr, transfer.ErrBinaryNotFound) {
+		var files []user.TransferFile
+		if repPath != "" {
+			files = []user.TransferFile{e.transferFileFor(repPath, uuid.Nil)}
+		}
+		rec = user.NewTransferRecord(user.TransferUpload, proto.Name, started, files, recvErr == nil)
+		e.recordTransfer(userManager, currentUser, nodeNumber, rec)
+		if err := userManager.UpdateUser(currentUser); err != nil {
+			log.Printf("WARN: Node %d: QWK: failed to save transfer totals: %v", nodeNumber, err)
+		}
+	}
 	if repPath == "" {
 		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|01No REP packet received.|07\r\n")), outputMode)
 		time.Sleep(2 * time.Second)
@@ -343,6 +368,9 @@ func runQWKUpload(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userM
 		if updateErr := userManager.UpdateUser(currentUser); updateErr != nil {
 			log.Printf("ERROR: Node %d: QWK: failed to update user stats: %v", nodeNumber, updateErr)
 		}
+		e.logActivity(userManager, currentUser, nodeNumber, user.ActivityRecord{Kind: user.ActivityPost, Count: posted})
+		// Time is given back only for a packet that posted something.
+		e.creditUploadTime(s, terminal, nodeNumber, rec, outputMode)
 	}
 
 	statusMsg := strings.ReplaceAll(e.LoadedStrings.TotalQWKAdded, "|TO", fmt.Sprintf("%d", posted))
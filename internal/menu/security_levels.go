@@ -0,0 +1,81 @@
+package menu
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// setUserLevel moves u from its current level to level, applying the
+// level's profile from levels.json when one is defined (see
+// user.ApplySecurityLevel). The caller saves u.
+func (e *MenuExecutor) setUserLevel(u *user.User, level int) {
+	if p, ok := u.ApplySecurityLevel(e.GetSecurityLevels(), level); ok {
+		log.Printf("INFO: Applied security level %d (%s) to %s", p.Level, p.Name, u.Handle)
+	}
+}
+
+// downloadConfigFor returns the server config to check u's downloads
+// against, with the download ratio of u's level when its profile sets one.
+func (e *MenuExecutor) downloadConfigFor(u *user.User) config.ServerConfig {
+	cfg := e.GetServerConfig()
+	if u == nil {
+		return cfg
+	}
+	if p, ok := e.GetSecurityLevel(u.AccessLevel); ok && p.DownloadRatio > 0 {
+		cfg.DownloadRatio = p.DownloadRatio
+	}
+	return cfg
+}
+
+// AdmitDailyCall counts a logon against the user's calls for today and
+// reports whether it is allowed by the callsPerDay of their level. Callers
+// over the limit are told so; the session should then end. Users at
+// coSysOpLevel and above are not limited.
+func (e *MenuExecutor) AdmitDailyCall(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, u *user.User, nodeNumber int, outputMode ansi.OutputMode) bool {
+	today := e.timeDay(time.Now())
+	if p, ok := e.GetSecurityLevel(u.AccessLevel); ok && p.CallsPerDay > 0 && !e.isCoSysOpOrAbove(u) {
+		if u.CallsOn(today) >= p.CallsPerDay {
+			log.Printf("INFO: Node %d: %s has used all %d calls for today", nodeNumber, u.Handle, p.CallsPerDay)
+			msg := fmt.Sprintf("\r\n|12You have used all %d of your calls for today. Call back tomorrow!|07\r\n", p.CallsPerDay)
+			terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+			time.Sleep(2 * time.Second)
+			return false
+		}
+	}
+
+	calls, err := userManager.RecordDailyCall(u.Username, today)
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to count today's call for %s: %v", nodeNumber, u.Handle, err)
+		return true
+	}
+	// Keep the session's copy in step so later saves don't undo the count
+	u.CallsToday, u.CallsDate = calls, today
+	return true
+}
+
+// privateMailLeft returns how many more private mails u may send today and
+// whether their level limits it at all.
+func (e *MenuExecutor) privateMailLeft(u *user.User) (int, bool) {
+	p, ok := e.GetSecurityLevel(u.AccessLevel)
+	if !ok || p.MaxPrivateMail <= 0 || e.isCoSysOpOrAbove(u) {
+		return 0, false
+	}
+	return max(0, p.MaxPrivateMail-u.MailSentOn(e.timeDay(time.Now()))), true
+}
+
+// countPrivateMailSent adds one to u's private mails sent today. The caller
+// saves u.
+func (e *MenuExecutor) countPrivateMailSent(u *user.User) {
+	today := e.timeDay(time.Now())
+	u.MailSentToday = u.MailSentOn(today) + 1
+	u.MailSentDate = today
+}
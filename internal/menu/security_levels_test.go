@@ -0,0 +1,43 @@
+package menu
+
+import (
+	"testing"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestSecurityLevelProfiles(t *testing.T) {
+	e := &MenuExecutor{ServerCfg: config.ServerConfig{CoSysOpLevel: 250, DownloadRatio: 5, Timezone: "UTC"}}
+	e.SetSecurityLevels([]config.SecurityLevel{
+		{Level: 20, Name: "Regular", TimeLimit: 60, DownloadRatio: 3, SignupPoints: 100, MaxPrivateMail: 2, Flags: "R"},
+	})
+
+	u := &user.User{Handle: "Alice", AccessLevel: 10, TimeLimit: 30}
+	e.setUserLevel(u, 20)
+	if u.AccessLevel != 20 || u.TimeLimit != 60 || u.FilePoints != 100 || u.Flags != "R" {
+		t.Errorf("profile not applied: %+v", u)
+	}
+	if got := e.downloadConfigFor(u).DownloadRatio; got != 3 {
+		t.Errorf("ratio at level 20 = %d, want 3", got)
+	}
+
+	// Without a profile only the level changes
+	e.setUserLevel(u, 30)
+	if u.AccessLevel != 30 || u.TimeLimit != 60 {
+		t.Errorf("level 30: level %d, time %d", u.AccessLevel, u.TimeLimit)
+	}
+	if got := e.downloadConfigFor(u).DownloadRatio; got != 5 {
+		t.Errorf("ratio at level 30 = %d, want server default 5", got)
+	}
+
+	u.AccessLevel = 20
+	if left, limited := e.privateMailLeft(u); !limited || left != 2 {
+		t.Errorf("privateMailLeft = %d, %v; want 2, true", left, limited)
+	}
+	e.countPrivateMailSent(u)
+	e.countPrivateMailSent(u)
+	if left, _ := e.privateMailLeft(u); left != 0 {
+		t.Errorf("privateMailLeft after 2 = %d, want 0", left)
+	}
+}
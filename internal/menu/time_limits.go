@@ -0,0 +1,318 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/session"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// sessionClocks holds the time clock of each logged-in session, keyed by
+// ssh.Session like sessionInputHandlers.
+var sessionClocks sync.Map
+
+// timeWarnings are the amounts of time left at which callers are warned.
+var timeWarnings = []time.Duration{5 * time.Minute, time.Minute}
+
+// sessionClock returns the time clock for s, or nil before logon.
+func sessionClock(s ssh.Session) *session.TimeClock {
+	if v, ok := sessionClocks.Load(s); ok {
+		return v.(*session.TimeClock)
+	}
+	return nil
+}
+
+// minutesLeft returns the caller's time left in whole minutes and whether
+// the call has a time limit at all. Without a clock (before logon) it falls
+// back to the per-call limit counted from sessionStartTime.
+func minutesLeft(s ssh.Session, u *user.User, sessionStartTime time.Time) (int, bool) {
+	if c := sessionClock(s); c != nil {
+		if !c.Limited() {
+			return 0, false
+		}
+		return int(c.Left(time.Now()) / time.Minute), true
+	}
+	if u == nil || u.TimeLimit <= 0 {
+		return 0, false
+	}
+	left := time.Duration(u.TimeLimit)*time.Minute - time.Since(sessionStartTime)
+	return int(max(0, left) / time.Minute), true
+}
+
+// timeDay returns the BBS-local date daily time is counted against.
+func (e *MenuExecutor) timeDay(t time.Time) string {
+	return t.In(config.LoadTimezone(e.GetServerConfig().Timezone)).Format("2006-01-02")
+}
+
+// callAllowance returns how long u may stay on a call starting at now: the
+// smaller of the per-call limit and what is left of the daily limit, or 0
+// for no limit. The second result is false when today's time is used up.
+func (e *MenuExecutor) callAllowance(u *user.User, now time.Time) (time.Duration, bool) {
+	if e.isCoSysOpOrAbove(u) {
+		return 0, true
+	}
+	allowed := time.Duration(max(0, u.TimeLimit)) * time.Minute
+	if u.DailyTimeLimit > 0 {
+		left := u.DailyTimeLimit - u.TimeUsedOn(e.timeDay(now))
+		if left <= 0 {
+			return 0, false
+		}
+		if daily := time.Duration(left) * time.Minute; allowed == 0 || daily < allowed {
+			allowed = daily
+		}
+	}
+	return allowed, true
+}
+
+// StartSessionClock starts timing a logged-in call. Callers who have used
+// all of today's time are told so and false is returned; the session should
+// then end.
+func (e *MenuExecutor) StartSessionClock(s ssh.Session, terminal *term.Terminal, u *user.User, nodeNumber int, outputMode ansi.OutputMode) bool {
+	now := time.Now()
+	allowed, ok := e.callAllowance(u, now)
+	if !ok {
+		log.Printf("INFO: Node %d: %s has used all of today's time (%d minutes)", nodeNumber, u.Handle, u.DailyTimeLimit)
+		msg := fmt.Sprintf("\r\n|12You have used all %d minutes of your time for today. Call back tomorrow!|07\r\n", u.DailyTimeLimit)
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		time.Sleep(2 * time.Second)
+		return false
+	}
+
+	clock := session.NewTimeClock(now, allowed)
+	sessionClocks.Store(s, clock)
+	if v, ok := sessionInputHandlers.Load(s); ok {
+		v.(*editor.InputHandler).SetSessionDeadline(clock.Deadline())
+	}
+	if clock.Limited() {
+		log.Printf("INFO: Node %d: %s has %d minutes this call", nodeNumber, u.Handle, int(allowed/time.Minute))
+	}
+	return true
+}
+
+// EndSessionClock charges the call against the user's daily allowance and
+// forgets the clock. Call it once when a logged-in session ends.
+func (e *MenuExecutor) EndSessionClock(s ssh.Session, userManager *user.UserMgr, u *user.User, nodeNumber int) {
+	clock := sessionClock(s)
+	if clock == nil {
+		return
+	}
+	sessionClocks.Delete(s)
+
+	now := time.Now()
+	minutes := int((clock.Charged(now) + 30*time.Second) / time.Minute)
+	if minutes <= 0 || u == nil {
+		return
+	}
+	if err := userManager.AddTimeUsed(u.Username, e.timeDay(now), minutes); err != nil {
+		log.Printf("ERROR: Node %d: Failed to record %d minutes used by %s: %v", nodeNumber, minutes, u.Handle, err)
+	}
+}
+
+// AnnounceTimeUp tells the caller their time is up if that is why the
+// session is ending, and reports whether it was.
+func (e *MenuExecutor) AnnounceTimeUp(s ssh.Session, terminal *term.Terminal, nodeNumber int, outputMode ansi.OutputMode) bool {
+	clock := sessionClock(s)
+	if clock == nil || !clock.Expired(time.Now()) {
+		return false
+	}
+	log.Printf("INFO: Node %d: Time limit reached, logging off", nodeNumber)
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n\r\n|12Your time is up! Thanks for calling.|07\r\n")), outputMode)
+	time.Sleep(1 * time.Second)
+	return true
+}
+
+// adjustSessionTime adds d to the caller's time left (negative d takes time
+// away) and moves the input deadline to match.
+func adjustSessionTime(s ssh.Session, d time.Duration) {
+	clock := sessionClock(s)
+	if clock == nil {
+		return
+	}
+	clock.Adjust(d)
+	if v, ok := sessionInputHandlers.Load(s); ok {
+		v.(*editor.InputHandler).SetSessionDeadline(clock.Deadline())
+	}
+}
+
+// warnTimeLeft warns the caller once as their time left drops below each
+// of the timeWarnings. It is called before menu prompts.
+func (e *MenuExecutor) warnTimeLeft(s ssh.Session, terminal *term.Terminal, outputMode ansi.OutputMode) {
+	clock := sessionClock(s)
+	if clock == nil {
+		return
+	}
+	warning, ok := clock.Warning(time.Now(), timeWarnings...)
+	if !ok {
+		return
+	}
+	minutes := int(warning / time.Minute)
+	plural := "s"
+	if minutes == 1 {
+		plural = ""
+	}
+	msg := fmt.Sprintf("\r\n|12Warning: |15%d|12 minute%s left on this call!|07\r\n", minutes, plural)
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+}
+
+// creditUploadTime gives back uploadTimeCredit percent of the time a
+// successful upload took.
+func (e *MenuExecutor) creditUploadTime(s ssh.Session, terminal *term.Terminal, nodeNumber int, rec user.TransferRecord, outputMode ansi.OutputMode) {
+	clock := sessionClock(s)
+	percent := e.GetServerConfig().UploadTimeCredit
+	if clock == nil || !clock.Limited() || !rec.Success || percent <= 0 {
+		return
+	}
+	credit := rec.Duration * time.Duration(percent) / 100
+	if credit < time.Second {
+		return
+	}
+	adjustSessionTime(s, credit)
+	log.Printf("INFO: Node %d: Credited %s for upload by %s", nodeNumber, credit.Round(time.Second), rec.Handle)
+	if minutes := int(credit / time.Minute); minutes > 0 {
+		msg := fmt.Sprintf("\r\n|10You have been given |15%d|10 minute(s) back for your upload.|07\r\n", minutes)
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+}
+
+// runTimeBank lets callers save unused time for a later call and take it
+// back out. Deposits come out of the time left on this call; withdrawals
+// add to it. The balance is capped per level by timeBankLimits.
+func runTimeBank(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return nil, "", nil
+	}
+
+	wv := func(msg string) {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+
+	clock := sessionClock(s)
+	if clock == nil || !clock.Limited() {
+		wv("\r\n|07Your calls have no time limit, so there is nothing to bank.\r\n")
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+	limit := e.GetServerConfig().TimeBankMax(currentUser.AccessLevel)
+
+	for {
+		if fresh, ok := userManager.GetUser(currentUser.Username); ok {
+			currentUser.TimeBank = fresh.TimeBank
+		}
+		balance := currentUser.TimeBank
+		left := int(clock.Left(time.Now()) / time.Minute)
+
+		wv("\r\n|15Time Bank|07\r\n")
+		wv(fmt.Sprintf("|07Balance        : |15%d|07 minutes", balance))
+		if limit > 0 {
+			wv(fmt.Sprintf(" |08(limit %d)|07", limit))
+		}
+		wv(fmt.Sprintf("\r\n|07Left this call : |15%d|07 minutes\r\n\r\n", left))
+
+		if limit <= 0 && balance == 0 {
+			wv("|07The time bank is not available at your level.\r\n")
+			time.Sleep(1 * time.Second)
+			return currentUser, "", nil
+		}
+
+		if limit > 0 {
+			wv("|09D|07eposit  ")
+		}
+		wv("|09W|07ithdraw  |09Q|07uit : ")
+		input, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", err
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(input)) {
+		case "D":
+			if limit <= 0 {
+				continue
+			}
+			// Keep a minute back so a deposit cannot end the call on the spot
+			most := min(left-1, limit-balance)
+			if most <= 0 {
+				wv("|12You can't deposit any more time right now.|07\r\n")
+				continue
+			}
+			minutes, ok, err := promptMinutes(s, terminal, wv, fmt.Sprintf("Minutes to deposit (1-%d): ", most), most)
+			if err != nil {
+				return nil, "LOGOFF", err
+			}
+			if !ok {
+				continue
+			}
+			n, err := userManager.DepositTime(currentUser.Username, minutes, limit)
+			if err != nil {
+				log.Printf("WARN: Node %d: Time bank deposit by %s failed: %v", nodeNumber, currentUser.Handle, err)
+				wv("|12Deposit failed.|07\r\n")
+				continue
+			}
+			adjustSessionTime(s, -time.Duration(n)*time.Minute)
+			log.Printf("INFO: Node %d: %s deposited %d minutes in the time bank", nodeNumber, currentUser.Handle, n)
+			wv(fmt.Sprintf("|10Deposited %d minutes.|07\r\n", n))
+
+		case "W":
+			if balance <= 0 {
+				wv("|12Your time bank is empty.|07\r\n")
+				continue
+			}
+			minutes, ok, err := promptMinutes(s, terminal, wv, fmt.Sprintf("Minutes to withdraw (1-%d): ", balance), balance)
+			if err != nil {
+				return nil, "LOGOFF", err
+			}
+			if !ok {
+				continue
+			}
+			n, err := userManager.WithdrawTime(currentUser.Username, minutes)
+			if err != nil {
+				log.Printf("WARN: Node %d: Time bank withdrawal by %s failed: %v", nodeNumber, currentUser.Handle, err)
+				wv("|12Withdrawal failed.|07\r\n")
+				continue
+			}
+			adjustSessionTime(s, time.Duration(n)*time.Minute)
+			log.Printf("INFO: Node %d: %s withdrew %d minutes from the time bank", nodeNumber, currentUser.Handle, n)
+			wv(fmt.Sprintf("|10Withdrew %d minutes.|07\r\n", n))
+
+		default:
+			return currentUser, "", nil
+		}
+	}
+}
+
+// promptMinutes asks for a number of minutes from 1 to most. ok is false if
+// the caller entered nothing or an invalid amount.
+func promptMinutes(s ssh.Session, terminal *term.Terminal, wv func(string), prompt string, most int) (minutes int, ok bool, err error) {
+	wv("|07" + prompt + "|15")
+	input, err := readLineFromSessionIH(s, terminal)
+	wv("|07")
+	if err != nil {
+		return 0, false, err
+	}
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, false, nil
+	}
+	n, convErr := strconv.Atoi(input)
+	if convErr != nil || n < 1 || n > most {
+		wv(fmt.Sprintf("|12Enter a number from 1 to %d.|07\r\n", most))
+		return 0, false, nil
+	}
+	return n, true, nil
+}
@@ -0,0 +1,36 @@
+package menu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestCallAllowance(t *testing.T) {
+	e := &MenuExecutor{ServerCfg: config.ServerConfig{CoSysOpLevel: 250, Timezone: "UTC"}}
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	today := "2026-03-01"
+
+	tests := []struct {
+		name string
+		u    user.User
+		want time.Duration
+		ok   bool
+	}{
+		{"per call only", user.User{AccessLevel: 10, TimeLimit: 60}, 60 * time.Minute, true},
+		{"daily caps call", user.User{AccessLevel: 10, TimeLimit: 60, DailyTimeLimit: 90, TimeUsedToday: 50, TimeUsedDate: today}, 40 * time.Minute, true},
+		{"yesterday's use ignored", user.User{AccessLevel: 10, TimeLimit: 60, DailyTimeLimit: 90, TimeUsedToday: 90, TimeUsedDate: "2026-02-28"}, 60 * time.Minute, true},
+		{"daily only", user.User{AccessLevel: 10, DailyTimeLimit: 90, TimeUsedToday: 30, TimeUsedDate: today}, 60 * time.Minute, true},
+		{"daily used up", user.User{AccessLevel: 10, TimeLimit: 60, DailyTimeLimit: 90, TimeUsedToday: 90, TimeUsedDate: today}, 0, false},
+		{"cosysop exempt", user.User{AccessLevel: 250, TimeLimit: 60, DailyTimeLimit: 90, TimeUsedToday: 90, TimeUsedDate: today}, 0, true},
+		{"no limits", user.User{AccessLevel: 10}, 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := e.callAllowance(&tt.u, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: callAllowance = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package menu
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// topTenSize is the number of places in each ranking.
+const topTenSize = 10
+
+// Top Ten periods, as accepted in RUN:TOPTEN arguments.
+const (
+	topTenAllTime = "all"
+	topTenMonth   = "month"
+	topTenWeek    = "week"
+)
+
+// topTenCategory is one ranking on the Top Ten screens.
+type topTenCategory struct {
+	Key    string // RUN:TOPTEN argument
+	Title  string // Shown as @TITLE@
+	Label  string // Name column header, @LABEL@
+	Units  string // Value column header, @UNITS@
+	format func(int64) string
+}
+
+var topTenCategories = []topTenCategory{
+	{Key: "uploads", Title: "Uploaders", Label: "User", Units: "Files"},
+	{Key: "downloads", Title: "Downloaders", Label: "User", Units: "Files"},
+	{Key: "ulbytes", Title: "Upload Volume", Label: "User", Units: "Bytes", format: formatTransferBytes},
+	{Key: "dlbytes", Title: "Download Volume", Label: "User", Units: "Bytes", format: formatTransferBytes},
+	{Key: "posters", Title: "Posters", Label: "User", Units: "Messages"},
+	{Key: "callers", Title: "Callers", Label: "User", Units: "Calls"},
+	{Key: "online", Title: "Time Online", Label: "User", Units: "Hrs:Min", format: formatTopTenDuration},
+	{Key: "files", Title: "Downloaded Files", Label: "File", Units: "Downloads"},
+	{Key: "doors", Title: "Doors", Label: "Door", Units: "Plays"},
+}
+
+// topTenEntry is one place in a ranking.
+type topTenEntry struct {
+	Name  string
+	Value int64
+}
+
+// topTenData is everything the rankings are computed from.
+type topTenData struct {
+	users     []*user.User
+	transfers []user.TransferRecord
+	activity  []user.ActivityRecord
+	files     []file.FileRecord // File records in the listed areas, for all-time download counts
+	areaTags  map[int]string    // Tags of the areas the viewer may list; files elsewhere are left out
+}
+
+// formatTopTenDuration renders a duration stored as nanoseconds as h:mm.
+func formatTopTenDuration(n int64) string {
+	d := time.Duration(n)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// topTenPeriodName describes a period for @PERIOD@.
+func topTenPeriodName(period string) string {
+	switch period {
+	case topTenMonth:
+		return "This Month"
+	case topTenWeek:
+		return "This Week"
+	}
+	return "All Time"
+}
+
+// topTenPeriodStart returns when period began, in loc. All-time returns
+// the zero time. Weeks start on Sunday.
+func topTenPeriodStart(period string, now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	switch period {
+	case topTenMonth:
+		return midnight.AddDate(0, 0, 1-now.Day())
+	case topTenWeek:
+		return midnight.AddDate(0, 0, -int(now.Weekday()))
+	}
+	return time.Time{}
+}
+
+// loadTopTenData gathers the users, logs and file records for the rankings.
+// Only file areas canList accepts are included.
+func (e *MenuExecutor) loadTopTenData(userManager *user.UserMgr, canList func(file.FileArea) bool) topTenData {
+	d := topTenData{users: userManager.GetAllUsers(), areaTags: make(map[int]string)}
+	var err error
+	if d.transfers, err = userManager.TransferLog(); err != nil {
+		log.Printf("WARN: Failed to read transfer log for Top Ten: %v", err)
+	}
+	if d.activity, err = userManager.ActivityLog(); err != nil {
+		log.Printf("WARN: Failed to read activity log for Top Ten: %v", err)
+	}
+	if e.FileMgr != nil {
+		for _, area := range e.FileMgr.ListAreas() {
+			if !canList(area) {
+				continue
+			}
+			d.areaTags[area.ID] = area.Tag
+			d.files = append(d.files, e.FileMgr.GetFilesForArea(area.ID)...)
+		}
+	}
+	return d
+}
+
+// rankTopTen ranks category over activity since the given time; the zero
+// time means all time. Deleted users are left out, as are users whose last
+// call was an invisible logon and anything done while logged on invisibly.
+func rankTopTen(d topTenData, category string, since time.Time) []topTenEntry {
+	// A user is hidden if their most recent call was invisible.
+	hidden := make(map[int]bool)
+	for _, rec := range d.activity {
+		if rec.Kind == user.ActivityCall {
+			hidden[rec.UserID] = rec.Invisible
+		}
+	}
+	ranked := make(map[int]*user.User)
+	for _, u := range d.users {
+		if u != nil && !u.DeletedUser && !hidden[u.ID] {
+			ranked[u.ID] = u
+		}
+	}
+	inPeriod := func(t time.Time) bool { return since.IsZero() || !t.Before(since) }
+	allTime := since.IsZero()
+
+	totals := make(map[string]int64)
+	addUser := func(id int, n int64) {
+		if u, ok := ranked[id]; ok {
+			totals[u.Handle] += n
+		}
+	}
+
+	switch category {
+	case "uploads", "downloads", "ulbytes", "dlbytes":
+		direction := user.TransferDownload
+		if category == "uploads" || category == "ulbytes" {
+			direction = user.TransferUpload
+		}
+		if allTime {
+			for _, u := range ranked {
+				switch category {
+				case "uploads":
+					addUser(u.ID, int64(u.NumUploads))
+				case "downloads":
+					addUser(u.ID, int64(u.NumDownloads))
+				case "ulbytes":
+					addUser(u.ID, u.UploadedBytes)
+				case "dlbytes":
+					addUser(u.ID, u.DownloadedBytes)
+				}
+			}
+			break
+		}
+		for _, rec := range d.transfers {
+			if rec.Direction != direction || rec.Invisible || !inPeriod(rec.Timestamp) {
+				continue
+			}
+			if category == "uploads" || category == "downloads" {
+				addUser(rec.UserID, int64(len(rec.Files)))
+			} else {
+				addUser(rec.UserID, rec.Bytes)
+			}
+		}
+
+	case "posters", "callers":
+		if allTime {
+			for _, u := range ranked {
+				if category == "posters" {
+					addUser(u.ID, int64(u.MessagesPosted))
+				} else {
+					addUser(u.ID, int64(u.TimesCalled))
+				}
+			}
+			break
+		}
+		for _, rec := range d.activity {
+			if rec.Invisible || !inPeriod(rec.Timestamp) {
+				continue
+			}
+			if category == "posters" && rec.Kind == user.ActivityPost {
+				addUser(rec.UserID, int64(rec.Posts()))
+			} else if category == "callers" && rec.Kind == user.ActivityCall {
+				addUser(rec.UserID, 1)
+			}
+		}
+
+	case "online":
+		for _, rec := range d.activity {
+			if rec.Kind == user.ActivityCall && !rec.Invisible && inPeriod(rec.Timestamp) {
+				addUser(rec.UserID, int64(rec.Duration))
+			}
+		}
+
+	case "files":
+		fileName := func(areaID int, name string) string {
+			if tag := d.areaTags[areaID]; tag != "" {
+				return fmt.Sprintf("%s (%s)", name, tag)
+			}
+			return name
+		}
+		if allTime {
+			for _, rec := range d.files {
+				if !rec.Unvalidated {
+					totals[fileName(rec.AreaID, rec.Filename)] += int64(rec.DownloadCount)
+				}
+			}
+			break
+		}
+		for _, rec := range d.transfers {
+			if rec.Direction != user.TransferDownload || rec.Invisible || !inPeriod(rec.Timestamp) {
+				continue
+			}
+			for _, f := range rec.Files {
+				if _, listed := d.areaTags[f.AreaID]; listed {
+					totals[fileName(f.AreaID, f.Name)]++
+				}
+			}
+		}
+
+	case "doors":
+		for _, rec := range d.activity {
+			if rec.Kind == user.ActivityDoor && rec.Name != "" && !rec.Invisible && inPeriod(rec.Timestamp) {
+				totals[rec.Name]++
+			}
+		}
+	}
+
+	entries := make([]topTenEntry, 0, len(totals))
+	for name, value := range totals {
+		if value > 0 {
+			entries = append(entries, topTenEntry{Name: name, Value: value})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	if len(entries) > topTenSize {
+		entries = entries[:topTenSize]
+	}
+	return entries
+}
+
+// topTenTemplates holds the pipe-processed TOPTEN.TOP/MID/BOT templates.
+type topTenTemplates struct {
+	top, mid, bot string
+}
+
+func (e *MenuExecutor) loadTopTenTemplates() (topTenTemplates, error) {
+	var t topTenTemplates
+	for _, part := range []struct {
+		name string
+		dst  *string
+	}{{"TOPTEN.TOP", &t.top}, {"TOPTEN.MID", &t.mid}, {"TOPTEN.BOT", &t.bot}} {
+		data, err := readTemplateFile(filepath.Join(e.MenuSetPath, "templates", part.name))
+		if err != nil {
+			return t, fmt.Errorf("%s: %w", part.name, err)
+		}
+		data = normalizePipeCodeDelimiters(stripSauceMetadata(data))
+		*part.dst = string(ansi.ReplacePipeCodes(data))
+	}
+	return t, nil
+}
+
+// renderTopTen fills the templates for one ranking.
+func renderTopTen(t topTenTemplates, cat topTenCategory, period string, entries []topTenEntry) []byte {
+	header := func(s string) string {
+		s = replaceWhoOnlineToken(s, "TITLE", cat.Title)
+		s = replaceWhoOnlineToken(s, "PERIOD", topTenPeriodName(period))
+		s = replaceWhoOnlineToken(s, "LABEL", cat.Label)
+		return replaceWhoOnlineToken(s, "UNITS", cat.Units)
+	}
+	writeLine := func(buf *bytes.Buffer, line string) {
+		buf.WriteString(line)
+		if !strings.HasSuffix(line, "\r\n") && !strings.HasSuffix(line, "\n") {
+			buf.WriteString("\r\n")
+		}
+	}
+
+	var buf bytes.Buffer
+	writeLine(&buf, header(t.top))
+	if len(entries) == 0 {
+		writeLine(&buf, string(ansi.ReplacePipeCodes([]byte("|07  Nothing to rank yet."))))
+	}
+	for i, entry := range entries {
+		value := strconv.FormatInt(entry.Value, 10)
+		if cat.format != nil {
+			value = cat.format(entry.Value)
+		}
+		line := replaceWhoOnlineToken(t.mid, "RK", strconv.Itoa(i+1))
+		line = replaceWhoOnlineToken(line, "NA", entry.Name)
+		line = replaceWhoOnlineToken(line, "VA", value)
+		writeLine(&buf, line)
+	}
+	writeLine(&buf, header(t.bot))
+	return buf.Bytes()
+}
+
+// parseTopTenArgs reads an optional category and period from RUN:TOPTEN
+// arguments, e.g. "uploads month". Returns -1 when no category is given.
+func parseTopTenArgs(args string) (int, string) {
+	category, period := -1, topTenAllTime
+	for _, field := range strings.Fields(strings.ToLower(args)) {
+		switch field {
+		case topTenAllTime, topTenMonth, topTenWeek:
+			period = field
+			continue
+		}
+		for i, cat := range topTenCategories {
+			if cat.Key == field {
+				category = i
+			}
+		}
+	}
+	return category, period
+}
+
+// runTopTen is the RunnableFunc for the Top Ten screens. With a category
+// argument (RUN:TOPTEN uploads month) it shows that ranking and pauses;
+// otherwise the caller can flip between rankings and periods.
+func runTopTen(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	log.Printf("DEBUG: Node %d: Running TOPTEN", nodeNumber)
+
+	tmpl, err := e.loadTopTenTemplates()
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to load TOPTEN templates: %v", nodeNumber, err)
+		msg := "\r\n|01Error loading Top Ten templates.|07\r\n"
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+		time.Sleep(1 * time.Second)
+		return nil, "", fmt.Errorf("failed loading TOPTEN templates")
+	}
+
+	data := e.loadTopTenData(userManager, func(area file.FileArea) bool {
+		return checkACS(area.ACSList, currentUser, s, terminal, sessionStartTime)
+	})
+	loc := config.LoadTimezone(e.ServerCfg.Timezone)
+	category, period := parseTopTenArgs(args)
+	interactive := category < 0
+	if interactive {
+		category = 0
+	}
+
+	ih := getSessionIH(s)
+	for {
+		cat := topTenCategories[category]
+		entries := rankTopTen(data, cat.Key, topTenPeriodStart(period, time.Now(), loc))
+		terminalio.WriteProcessedBytes(terminal, []byte(ansi.ClearScreen()), outputMode)
+		terminalio.WriteProcessedBytes(terminal, renderTopTen(tmpl, cat, period, entries), outputMode)
+
+		if !interactive {
+			pausePrompt := e.LoadedStrings.PauseString
+			if pausePrompt == "" {
+				pausePrompt = "\r\n|07Press |15[ENTER]|07 to continue... "
+			}
+			if err := writeCenteredPausePrompt(s, terminal, pausePrompt, outputMode, termWidth, termHeight); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				return nil, "", err
+			}
+			return currentUser, "", nil
+		}
+
+		var menu strings.Builder
+		for i, c := range topTenCategories {
+			menu.WriteString(fmt.Sprintf("|08[|15%d|08]|07%-17s", i+1, c.Title))
+			if i%4 == 3 {
+				menu.WriteString("\r\n")
+			}
+		}
+		menu.WriteString("\r\n|08[|15A|08]|07ll Time  |08[|15M|08]|07onth  |08[|15W|08]|07eek  |08[|15Q|08]|07uit: ")
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n"+menu.String())), outputMode)
+
+		key, err := ih.ReadKey()
+		if err != nil {
+			if errors.Is(err, editor.ErrIdleTimeout) || errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", err
+		}
+		if key == editor.KeyEsc {
+			return currentUser, "", nil
+		}
+		switch k := strings.ToLower(string(rune(key))); {
+		case k >= "1" && k <= "9" && int(k[0]-'1') < len(topTenCategories):
+			category = int(k[0] - '1')
+		case k == " " || k == "n":
+			category = (category + 1) % len(topTenCategories)
+		case k == "a":
+			period = topTenAllTime
+		case k == "m":
+			period = topTenMonth
+		case k == "w":
+			period = topTenWeek
+		case k == "q":
+			return currentUser, "", nil
+		}
+	}
+}
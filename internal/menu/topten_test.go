@@ -0,0 +1,109 @@
+package menu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestRankTopTen_ExcludesDeletedAndInvisible(t *testing.T) {
+	now := time.Now()
+	d := topTenData{
+		users: []*user.User{
+			{ID: 1, Handle: "Alice", NumUploads: 3, TimesCalled: 9},
+			{ID: 2, Handle: "Bob", NumUploads: 5, TimesCalled: 9},
+			{ID: 3, Handle: "Gone", NumUploads: 50, DeletedUser: true},
+			{ID: 4, Handle: "Sysop", NumUploads: 40},
+		},
+		activity: []user.ActivityRecord{
+			{Timestamp: now, UserID: 4, Kind: user.ActivityCall, Invisible: true},
+			{Timestamp: now, UserID: 1, Kind: user.ActivityCall, Duration: 90 * time.Minute},
+			{Timestamp: now, UserID: 1, Kind: user.ActivityDoor, Name: "LORD"},
+			{Timestamp: now, UserID: 2, Kind: user.ActivityDoor, Name: "LORD"},
+			{Timestamp: now, UserID: 4, Kind: user.ActivityDoor, Name: "TW2002", Invisible: true},
+		},
+	}
+
+	got := rankTopTen(d, "uploads", time.Time{})
+	if len(got) != 2 || got[0] != (topTenEntry{"Bob", 5}) || got[1] != (topTenEntry{"Alice", 3}) {
+		t.Errorf("uploads = %+v", got)
+	}
+	// Ties are broken by name.
+	if got := rankTopTen(d, "callers", time.Time{}); len(got) != 2 || got[0].Name != "Alice" {
+		t.Errorf("callers = %+v", got)
+	}
+	if got := rankTopTen(d, "doors", time.Time{}); len(got) != 1 || got[0] != (topTenEntry{"LORD", 2}) {
+		t.Errorf("doors = %+v", got)
+	}
+	if got := rankTopTen(d, "online", time.Time{}); len(got) != 1 || formatTopTenDuration(got[0].Value) != "1:30" {
+		t.Errorf("online = %+v", got)
+	}
+}
+
+func TestRankTopTen_PeriodsUseLogs(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, -2, 0)
+	d := topTenData{
+		users: []*user.User{{ID: 1, Handle: "Alice", NumDownloads: 100}, {ID: 2, Handle: "Bob"}},
+		transfers: []user.TransferRecord{
+			{Timestamp: old, UserID: 1, Direction: user.TransferDownload, Files: []user.TransferFile{{Name: "OLD.ZIP", AreaID: 1}}},
+			{Timestamp: now, UserID: 2, Direction: user.TransferDownload, Files: []user.TransferFile{{Name: "NEW.ZIP", AreaID: 1}, {Name: "QWK.QWK"}}},
+			{Timestamp: now, UserID: 1, Direction: user.TransferDownload, Files: []user.TransferFile{{Name: "NEW.ZIP", AreaID: 1}}, Invisible: true},
+		},
+		activity: []user.ActivityRecord{
+			{Timestamp: now, UserID: 1, Kind: user.ActivityPost, Count: 4},
+			{Timestamp: old, UserID: 2, Kind: user.ActivityPost},
+		},
+		files:    []file.FileRecord{{AreaID: 1, Filename: "OLD.ZIP", DownloadCount: 7}, {AreaID: 1, Filename: "HIDDEN.ZIP", DownloadCount: 9, Unvalidated: true}},
+		areaTags: map[int]string{1: "UTILS"},
+	}
+	month := topTenPeriodStart(topTenMonth, now, time.Local)
+
+	if got := rankTopTen(d, "downloads", month); len(got) != 1 || got[0] != (topTenEntry{"Bob", 2}) {
+		t.Errorf("downloads this month = %+v", got)
+	}
+	if got := rankTopTen(d, "posters", month); len(got) != 1 || got[0] != (topTenEntry{"Alice", 4}) {
+		t.Errorf("posters this month = %+v", got)
+	}
+	if got := rankTopTen(d, "files", month); len(got) != 1 || got[0] != (topTenEntry{"NEW.ZIP (UTILS)", 1}) {
+		t.Errorf("files this month = %+v", got)
+	}
+	if got := rankTopTen(d, "files", time.Time{}); len(got) != 1 || got[0] != (topTenEntry{"OLD.ZIP (UTILS)", 7}) {
+		t.Errorf("files all time = %+v", got)
+	}
+}
+
+func TestRankTopTen_FilesOnlyFromListedAreas(t *testing.T) {
+	now := time.Now()
+	d := topTenData{
+		transfers: []user.TransferRecord{
+			{Timestamp: now, UserID: 1, Direction: user.TransferDownload, Files: []user.TransferFile{{Name: "PUBLIC.ZIP", AreaID: 1}, {Name: "SECRET.ZIP", AreaID: 2}}},
+		},
+		files:    []file.FileRecord{{AreaID: 1, Filename: "PUBLIC.ZIP", DownloadCount: 1}},
+		areaTags: map[int]string{1: "UTILS"}, // Area 2 is not listable
+	}
+	month := topTenPeriodStart(topTenMonth, now, time.Local)
+
+	if got := rankTopTen(d, "files", month); len(got) != 1 || got[0].Name != "PUBLIC.ZIP (UTILS)" {
+		t.Errorf("files this month = %+v", got)
+	}
+}
+
+func TestTopTenPeriodStart(t *testing.T) {
+	loc := time.UTC
+	now := time.Date(2024, time.May, 16, 15, 4, 5, 0, loc) // A Thursday
+	if got := topTenPeriodStart(topTenMonth, now, loc); !got.Equal(time.Date(2024, time.May, 1, 0, 0, 0, 0, loc)) {
+		t.Errorf("month start = %v", got)
+	}
+	if got := topTenPeriodStart(topTenWeek, now, loc); !got.Equal(time.Date(2024, time.May, 12, 0, 0, 0, 0, loc)) {
+		t.Errorf("week start = %v", got)
+	}
+	if got := topTenPeriodStart(topTenAllTime, now, loc); !got.IsZero() {
+		t.Errorf("all time start = %v", got)
+	}
+	if cat, period := parseTopTenArgs("Month posters"); topTenCategories[cat].Key != "posters" || period != topTenMonth {
+		t.Errorf("parseTopTenArgs = %d, %q", cat, period)
+	}
+}
@@ -0,0 +1,252 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/editor"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// recordTransfer logs a finished protocol transfer and adds it to the node's
+// session, the user's lifetime byte totals and the per-area stats. u is
+// updated in place; the caller saves it.
+func (e *MenuExecutor) recordTransfer(userManager *user.UserMgr, u *user.User, nodeNumber int, rec user.TransferRecord) {
+	if u != nil {
+		rec.UserID, rec.Handle = u.ID, u.Handle
+		switch rec.Direction {
+		case user.TransferUpload:
+			u.UploadedBytes += rec.Bytes
+		case user.TransferDownload:
+			u.DownloadedBytes += rec.Bytes
+		}
+	}
+	rec.NodeID = nodeNumber
+
+	if nodeNumber > 0 && e.SessionRegistry != nil {
+		if sess := e.SessionRegistry.Get(nodeNumber); sess != nil {
+			sess.Mutex.RLock()
+			rec.Invisible = sess.Invisible
+			sess.Mutex.RUnlock()
+			sess.AddTransfer(rec)
+		}
+	}
+	if e.FileMgr != nil {
+		for _, f := range rec.Files {
+			if f.AreaID <= 0 {
+				continue
+			}
+			if err := e.FileMgr.RecordAreaTransfer(f.AreaID, rec.Direction == user.TransferUpload, f.Size); err != nil {
+				log.Printf("WARN: Node %d: Failed to update stats for area %d: %v", nodeNumber, f.AreaID, err)
+			}
+		}
+	}
+	if userManager != nil {
+		if err := userManager.LogTransfer(rec); err != nil {
+			log.Printf("WARN: Node %d: Failed to write transfer log: %v", nodeNumber, err)
+		}
+	}
+	log.Printf("INFO: Node %d: %s %s via %s: %d file(s), %d bytes in %s (%d cps), success=%t",
+		nodeNumber, rec.Handle, transferDirectionName(rec.Direction), rec.Protocol, len(rec.Files), rec.Bytes, rec.Duration.Round(time.Second), rec.CPS, rec.Success)
+}
+
+// transferFileFor describes a sent file for the transfer log. The area comes
+// from the file record when there is one.
+func (e *MenuExecutor) transferFileFor(path string, id uuid.UUID) user.TransferFile {
+	f := user.TransferFile{Name: filepath.Base(path)}
+	if fi, err := os.Stat(path); err == nil {
+		f.Size = fi.Size()
+	}
+	if id != uuid.Nil {
+		if rec, ok := e.FileMgr.GetFileRecord(id); ok {
+			f.AreaID = rec.AreaID
+			if f.Size == 0 {
+				f.Size = rec.Size
+			}
+		}
+	}
+	return f
+}
+
+func transferDirectionName(direction string) string {
+	if direction == user.TransferUpload {
+		return "upload"
+	}
+	return "download"
+}
+
+// formatTransferBytes renders a byte count as B, K or M for narrow columns.
+func formatTransferBytes(n int64) string {
+	switch {
+	case n >= 10*1024*1024:
+		return fmt.Sprintf("%dM", n/(1024*1024))
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%dK", n/1024)
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// transferLogLine formats one transfer log entry for the sysop viewer.
+func (e *MenuExecutor) transferLogLine(rec user.TransferRecord, loc *time.Location) string {
+	status := "|10OK   "
+	if !rec.Success {
+		status = "|12ABORT"
+	}
+	dir := "|11DL"
+	if rec.Direction == user.TransferUpload {
+		dir = "|13UL"
+	}
+	node := "--"
+	if rec.NodeID > 0 {
+		node = fmt.Sprintf("%2d", rec.NodeID)
+	}
+	name := ""
+	if len(rec.Files) > 0 {
+		name = rec.Files[0].Name
+		if len(rec.Files) > 1 {
+			name = fmt.Sprintf("%s +%d", name, len(rec.Files)-1)
+		}
+	}
+	return fmt.Sprintf("|07%s |08%s |15%-12.12s %s |07%-9.9s |15%-7s |07%6d |08%s |07%-17.17s",
+		rec.Timestamp.In(loc).Format("01/02 15:04"), node, rec.Handle, dir, rec.Protocol,
+		formatTransferBytes(rec.Bytes), rec.CPS, status, name)
+}
+
+// runTransferLog is the RunnableFunc for the sysop transfer log viewer. It
+// pages through the transfer log newest first; A shows per-area totals.
+func runTransferLog(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil || !e.isCoSysOpOrAbove(currentUser) {
+		return currentUser, "", nil
+	}
+
+	entries, err := userManager.TransferLog()
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to read transfer log: %v", nodeNumber, err)
+	}
+	if len(entries) == 0 {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte("\r\n|07No transfers have been logged yet.\r\n")), outputMode)
+		time.Sleep(1 * time.Second)
+		return currentUser, "", nil
+	}
+	// Newest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	loc := config.LoadTimezone(e.ServerCfg.Timezone)
+	pageSize := termHeight - 6
+	if pageSize < 5 {
+		pageSize = 5
+	}
+	totalPages := (len(entries) + pageSize - 1) / pageSize
+	ih := getSessionIH(s)
+
+	for page := 0; ; {
+		var buf strings.Builder
+		buf.WriteString(ansi.ClearScreen())
+		buf.WriteString(fmt.Sprintf("|15Transfer Log|07  |08(page %d of %d, %d transfers)\r\n", page+1, totalPages, len(entries)))
+		buf.WriteString(fmt.Sprintf("|08%-11s %-2s %-12s %-2s %-9s %-7s %6s %-5s %s\r\n", "Date/Time", "Nd", "User", "Dr", "Protocol", "Bytes", "CPS", "Stat", "File"))
+		buf.WriteString("|08" + strings.Repeat("-", 79) + "\r\n")
+		start := page * pageSize
+		for i := start; i < start+pageSize && i < len(entries); i++ {
+			buf.WriteString(e.transferLogLine(entries[i], loc) + "\r\n")
+		}
+		buf.WriteString("\r\n|07[|15N|07]ext [|15P|07]rev [|15A|07]rea totals [|15Q|07]uit: ")
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(buf.String())), outputMode)
+
+		key, err := ih.ReadKey()
+		if err != nil {
+			if errors.Is(err, editor.ErrIdleTimeout) || errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", err
+		}
+		if key == editor.KeyEsc {
+			return currentUser, "", nil
+		}
+		switch strings.ToLower(string(rune(key))) {
+		case "n", " ":
+			if page < totalPages-1 {
+				page++
+			}
+		case "p":
+			if page > 0 {
+				page--
+			}
+		case "a":
+			if err := e.showAreaTransferStats(s, terminal, outputMode, termWidth, termHeight); err != nil {
+				return nil, "LOGOFF", err
+			}
+		case "q":
+			return currentUser, "", nil
+		}
+	}
+}
+
+// showAreaTransferStats lists upload and download totals for every file area.
+func (e *MenuExecutor) showAreaTransferStats(s ssh.Session, terminal *term.Terminal, outputMode ansi.OutputMode, termWidth, termHeight int) error {
+	areas := e.FileMgr.ListAreas()
+	sort.Slice(areas, func(i, j int) bool { return areas[i].ID < areas[j].ID })
+
+	var buf strings.Builder
+	buf.WriteString(ansi.ClearScreen())
+	buf.WriteString("|15File Area Transfer Totals\r\n")
+	buf.WriteString(fmt.Sprintf("|08%-12s %7s %8s  %9s %8s  %s\r\n", "Area", "Uploads", "Bytes", "Downloads", "Bytes", "Last Activity"))
+	buf.WriteString("|08" + strings.Repeat("-", 79) + "\r\n")
+	loc := config.LoadTimezone(e.ServerCfg.Timezone)
+	for _, area := range areas {
+		st := e.FileMgr.GetAreaStats(area.ID)
+		last := st.LastUpload
+		if st.LastDownload.After(last) {
+			last = st.LastDownload
+		}
+		lastStr := "never"
+		if !last.IsZero() {
+			lastStr = last.In(loc).Format("01/02/06 15:04")
+		}
+		buf.WriteString(fmt.Sprintf("|15%-12.12s |07%7d %8s  %9d %8s  |08%s\r\n",
+			area.Tag, st.Uploads, formatTransferBytes(st.UploadedBytes), st.Downloads, formatTransferBytes(st.DownloadedBytes), lastStr))
+	}
+	terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(buf.String())), outputMode)
+
+	pausePrompt := e.LoadedStrings.PauseString
+	if pausePrompt == "" {
+		pausePrompt = "\r\n|07Press |15[ENTER]|07 to continue... "
+	}
+	return writeCenteredPausePrompt(s, terminal, pausePrompt, outputMode, termWidth, termHeight)
+}
+
+// logActivity appends a call, post or door play to the activity log used by
+// the Top Ten rankings, flagging it if the node is logged on invisibly.
+func (e *MenuExecutor) logActivity(userManager *user.UserMgr, u *user.User, nodeNumber int, rec user.ActivityRecord) {
+	if userManager == nil || u == nil {
+		return
+	}
+	rec.UserID, rec.Handle = u.ID, u.Handle
+	if nodeNumber > 0 && e.SessionRegistry != nil {
+		if sess := e.SessionRegistry.Get(nodeNumber); sess != nil {
+			sess.Mutex.RLock()
+			rec.Invisible = sess.Invisible
+			sess.Mutex.RUnlock()
+		}
+	}
+	if err := userManager.LogActivity(rec); err != nil {
+		log.Printf("WARN: Node %d: Failed to write activity log: %v", nodeNumber, err)
+	}
+}
@@ -0,0 +1,61 @@
+package menu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/session"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+func TestRecordTransfer_UpdatesSessionUserAndAreaStats(t *testing.T) {
+	fm, _ := setupTestFileManagerForViewer(t, []file.FileArea{{ID: 1, Tag: "UTILS", Name: "Utilities", Path: "utils"}})
+	um, err := user.NewUserManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := um.GetUser("felonius")
+
+	registry := session.NewSessionRegistry()
+	registry.Register(&session.BbsSession{NodeID: 3, StartTime: time.Now()})
+	e := &MenuExecutor{FileMgr: fm, SessionRegistry: registry, ServerCfg: config.ServerConfig{}}
+
+	files := []user.TransferFile{{Name: "A.ZIP", AreaID: 1, Size: 2048}, {Name: "B.ZIP", AreaID: 1, Size: 1024}}
+	e.recordTransfer(um, u, 3, user.NewTransferRecord(user.TransferDownload, "Zmodem", time.Now(), files, true))
+	e.recordTransfer(um, u, 3, user.NewTransferRecord(user.TransferUpload, "Zmodem", time.Now(), nil, false))
+
+	if u.DownloadedBytes != 3072 || u.UploadedBytes != 0 {
+		t.Errorf("user bytes = %d down / %d up, want 3072 / 0", u.DownloadedBytes, u.UploadedBytes)
+	}
+	totals := registry.Get(3).TransferTotals()
+	if totals.Downloads != 2 || totals.DownloadedBytes != 3072 || totals.Actions() != "D" {
+		t.Errorf("session totals = %+v", totals)
+	}
+	if st := fm.GetAreaStats(1); st.Downloads != 2 || st.DownloadedBytes != 3072 {
+		t.Errorf("area stats = %+v", st)
+	}
+
+	entries, err := um.TransferLog()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("transfer log = %v, %v; want 2 entries", entries, err)
+	}
+	if entries[0].Handle != u.Handle || entries[0].NodeID != 3 || entries[1].Success {
+		t.Errorf("unexpected log entries: %+v", entries)
+	}
+}
+
+func TestFormatTransferBytes(t *testing.T) {
+	cases := map[int64]string{
+		512:                 "512B",
+		2048:                "2K",
+		3 * 1024 * 1024 / 2: "1.5M",
+		25 * 1024 * 1024:    "25M",
+	}
+	for n, want := range cases {
+		if got := formatTransferBytes(n); got != want {
+			t.Errorf("formatTransferBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+	"rsc.io/qr"
+
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/terminalio"
+	"github.com/stlalpha/vision3/internal/user"
+)
+
+// secondFactorTries is how many codes a caller may enter at login.
+const secondFactorTries = 3
+
+// qrBlockLines renders text as a QR code using half-block characters, two
+// modules per character row, with a two-module quiet zone. Light modules are
+// drawn as blocks so the code reads as dark-on-light on a black background.
+func qrBlockLines(text string) ([]string, error) {
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		return nil, err
+	}
+	const quiet = 2
+	light := func(x, y int) bool { return !code.Black(x, y) }
+
+	var lines []string
+	for y := -quiet; y < code.Size+quiet; y += 2 {
+		var b strings.Builder
+		for x := -quiet; x < code.Size+quiet; x++ {
+			top, bottom := light(x, y), light(x, y+1)
+			if y+1 >= code.Size+quiet {
+				bottom = false
+			}
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		lines = append(lines, b.String())
+	}
+	return lines, nil
+}
+
+// totpRequired reports whether config requires two-factor login for u.
+func (e *MenuExecutor) totpRequired(u *user.User) bool {
+	level := e.GetServerConfig().TOTPRequiredLevel
+	return level > 0 && u.AccessLevel >= level
+}
+
+// TOTPRequired reports whether u must set up two-factor login before an
+// automatic (SSH) login can be accepted.
+func (e *MenuExecutor) TOTPRequired(u *user.User) bool {
+	return e.totpRequired(u) && !u.TOTPEnabled()
+}
+
+// checkSecondFactor runs the two-factor step of a login for a user whose
+// password has just been checked. Users with 2FA on are asked for a code;
+// users whose level requires it but who have not set it up must enrol now.
+// Returns false if the login should be refused.
+func (e *MenuExecutor) checkSecondFactor(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, u *user.User, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) (bool, error) {
+	wv := func(msg string) {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+
+	if !u.TOTPEnabled() {
+		if !e.totpRequired(u) {
+			return true, nil
+		}
+		log.Printf("INFO: Node %d: %s must set up two-factor login", nodeNumber, u.Handle)
+		wv("\r\n|14Your account requires two-factor login. Set it up now to continue.|07\r\n")
+		time.Sleep(2 * time.Second)
+		return e.enrollTOTP(s, terminal, userManager, u, nodeNumber, outputMode, termWidth, termHeight)
+	}
+
+	for try := 1; try <= secondFactorTries; try++ {
+		wv("\r\n|07Authenticator code (or recovery code): |15")
+		code, err := readLineFromSessionIHAllowAbort(s, terminal)
+		wv("|07")
+		if err != nil {
+			if errors.Is(err, errInputAborted) {
+				return false, nil
+			}
+			return false, err
+		}
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		recovery, remaining, ok := userManager.CheckSecondFactor(u.Username, code)
+		if !ok {
+			log.Printf("WARN: Node %d: Wrong two-factor code for %s (try %d)", nodeNumber, u.Handle, try)
+			wv("|12Invalid code.|07\r\n")
+			continue
+		}
+		if recovery {
+			log.Printf("INFO: Node %d: %s logged in with a recovery code (%d left)", nodeNumber, u.Handle, remaining)
+			wv(fmt.Sprintf("|14Recovery code used. You have %d left; make new ones from the user config menu.|07\r\n", remaining))
+			time.Sleep(2 * time.Second)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// enrollTOTP walks the user through adding the BBS to an authenticator app:
+// a QR code, then the URI and secret for manual entry, a confirmation code
+// and finally the recovery codes. Returns true once 2FA is on and saved.
+func (e *MenuExecutor) enrollTOTP(s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, u *user.User, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) (bool, error) {
+	wv := func(msg string) {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+
+	secret, err := user.NewTOTPSecret()
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to generate TOTP secret: %v", nodeNumber, err)
+		return false, nil
+	}
+	issuer := e.GetServerConfig().BoardName
+	if issuer == "" {
+		issuer = "ViSiON/3 BBS"
+	}
+	uri := user.TOTPURI(secret, issuer, u.Handle)
+
+	// Screen 1: QR code on its own so it fits a 25-line terminal
+	if lines, qrErr := qrBlockLines(uri); qrErr == nil && len(lines) < termHeight {
+		wv(ansi.ClearScreen())
+		pad := strings.Repeat(" ", max(0, (termWidth-len([]rune(lines[0])))/2))
+		for _, line := range lines {
+			wv("|15|B0" + pad + line + "|07\r\n")
+		}
+		e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+	} else if qrErr != nil {
+		log.Printf("WARN: Node %d: Failed to render TOTP QR code: %v", nodeNumber, qrErr)
+	}
+
+	// Screen 2: manual entry and confirmation
+	wv(ansi.ClearScreen())
+	wv("|15Two-Factor Setup|07\r\n\r\n")
+	wv("|07Scan the QR code with an authenticator app, or add this URI:\r\n")
+	wv("|11" + uri + "|07\r\n\r\n")
+	wv("|07or enter the key by hand (time-based, 6 digits, 30 seconds):\r\n")
+	wv("|15" + groupSecret(secret) + "|07\r\n\r\n")
+
+	confirmed := false
+	var step int64
+	for try := 1; try <= secondFactorTries && !confirmed; try++ {
+		wv("|07Enter the code your app shows (blank to cancel): |15")
+		code, err := readLineFromSessionIH(s, terminal)
+		wv("|07")
+		if err != nil {
+			return false, err
+		}
+		if strings.TrimSpace(code) == "" {
+			wv("|03Two-factor setup cancelled.|07\r\n")
+			time.Sleep(1 * time.Second)
+			return false, nil
+		}
+		step, confirmed = user.ValidateTOTP(secret, code, time.Now(), 0)
+		if !confirmed {
+			wv("|12That code doesn't match. Check the time on your device.|07\r\n")
+		}
+	}
+	if !confirmed {
+		time.Sleep(1 * time.Second)
+		return false, nil
+	}
+
+	codes, hashes, err := user.NewRecoveryCodes()
+	if err != nil {
+		log.Printf("ERROR: Node %d: Failed to generate recovery codes: %v", nodeNumber, err)
+		return false, nil
+	}
+	// Work on a fresh copy so nothing else in the session's copy is written back
+	fresh, ok := userManager.GetUser(u.Username)
+	if !ok {
+		return false, nil
+	}
+	fresh.TOTPSecret = secret
+	fresh.TOTPRecoveryCodes = hashes
+	fresh.TOTPLastStep = step
+	if err := userManager.UpdateUser(fresh); err != nil {
+		log.Printf("ERROR: Node %d: Failed to save two-factor setup: %v", nodeNumber, err)
+		wv("|12Could not save two-factor setup.|07\r\n")
+		time.Sleep(1 * time.Second)
+		return false, nil
+	}
+	u.TOTPSecret, u.TOTPRecoveryCodes, u.TOTPLastStep = fresh.TOTPSecret, fresh.TOTPRecoveryCodes, fresh.TOTPLastStep
+	log.Printf("INFO: Node %d: %s turned on two-factor login", nodeNumber, u.Handle)
+
+	wv("\r\n|02Two-factor login is on.|07\r\n")
+	e.showRecoveryCodes(terminal, codes, outputMode)
+	e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+	return true, nil
+}
+
+// showRecoveryCodes lists newly issued recovery codes.
+func (e *MenuExecutor) showRecoveryCodes(terminal *term.Terminal, codes []string, outputMode ansi.OutputMode) {
+	wv := func(msg string) {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+	wv("\r\n|07Write down these recovery codes. Each one logs you in once if you\r\n")
+	wv("lose your authenticator. They will not be shown again.\r\n\r\n")
+	for i := 0; i < len(codes); i += 2 {
+		line := "   |15" + codes[i]
+		if i+1 < len(codes) {
+			line += "      " + codes[i+1]
+		}
+		wv(line + "|07\r\n")
+	}
+}
+
+// groupSecret splits a base32 secret into groups of four for reading aloud.
+func groupSecret(secret string) string {
+	var groups []string
+	for len(secret) > 4 {
+		groups = append(groups, secret[:4])
+		secret = secret[4:]
+	}
+	return strings.Join(append(groups, secret), " ")
+}
+
+// runCfgTwoFactor lets the user turn two-factor login on or off and make
+// new recovery codes.
+func runCfgTwoFactor(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return nil, "", nil
+	}
+
+	wv := func(msg string) {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+	readLine := func() (string, bool) {
+		line, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(line), true
+	}
+	// confirmCode asks for a current authenticator or recovery code before a
+	// change to the user's two-factor settings.
+	confirmCode := func() (bool, bool) {
+		wv("\r\n|07Current authenticator code: |15")
+		code, ok := readLine()
+		wv("|07")
+		if !ok {
+			return false, false
+		}
+		if _, _, valid := userManager.CheckSecondFactor(currentUser.Username, code); !valid {
+			wv("|12Invalid code.|07\r\n")
+			time.Sleep(1 * time.Second)
+			return false, true
+		}
+		if fresh, found := userManager.GetUser(currentUser.Username); found {
+			currentUser.TOTPRecoveryCodes, currentUser.TOTPLastStep = fresh.TOTPRecoveryCodes, fresh.TOTPLastStep
+		}
+		return true, true
+	}
+
+	for {
+		wv("\r\n|15Two-Factor Login|07\r\n")
+		wv("|08A code from an authenticator app is asked for after your password.|07\r\n\r\n")
+
+		if !currentUser.TOTPEnabled() {
+			wv("|03Two-factor login is off.|07\r\n\r\n")
+			wv("|09E|07nable  |09Q|07uit : ")
+			input, ok := readLine()
+			if !ok {
+				return nil, "LOGOFF", io.EOF
+			}
+			if !strings.EqualFold(input, "E") {
+				return currentUser, "", nil
+			}
+
+			// Setting up 2FA changes how the account logs in, so check the password
+			wv(e.LoadedStrings.CfgCurrentPwPrompt)
+			pw, err := readPasswordSecurely(s, terminal, outputMode)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				return currentUser, "", nil
+			}
+			if bcrypt.CompareHashAndPassword([]byte(currentUser.PasswordHash), []byte(pw)) != nil {
+				wv(e.LoadedStrings.CfgIncorrectPw)
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			if _, err := e.enrollTOTP(s, terminal, userManager, currentUser, nodeNumber, outputMode, termWidth, termHeight); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				return currentUser, "", nil
+			}
+			continue
+		}
+
+		wv("|02Two-factor login is on.|07\r\n")
+		wv(fmt.Sprintf("|07Unused recovery codes: |15%d|07\r\n\r\n", len(currentUser.TOTPRecoveryCodes)))
+		if e.totpRequired(currentUser) {
+			wv("|09R|07ecovery codes  |09Q|07uit : ")
+		} else {
+			wv("|09D|07isable  |09R|07ecovery codes  |09Q|07uit : ")
+		}
+		input, ok := readLine()
+		if !ok {
+			return nil, "LOGOFF", io.EOF
+		}
+
+		switch strings.ToUpper(input) {
+		case "D":
+			if e.totpRequired(currentUser) {
+				continue
+			}
+			valid, ok := confirmCode()
+			if !ok {
+				return nil, "LOGOFF", io.EOF
+			}
+			if !valid {
+				continue
+			}
+			currentUser.TOTPSecret = ""
+			currentUser.TOTPRecoveryCodes = nil
+			currentUser.TOTPLastStep = 0
+			if err := userManager.UpdateUser(currentUser); err != nil {
+				log.Printf("ERROR: Node %d: Failed to turn off two-factor login: %v", nodeNumber, err)
+				return currentUser, "", nil
+			}
+			log.Printf("INFO: Node %d: %s turned off two-factor login", nodeNumber, currentUser.Handle)
+			wv("\r\n|03Two-factor login is off.|07\r\n")
+			time.Sleep(1 * time.Second)
+
+		case "R":
+			valid, ok := confirmCode()
+			if !ok {
+				return nil, "LOGOFF", io.EOF
+			}
+			if !valid {
+				continue
+			}
+			codes, hashes, err := user.NewRecoveryCodes()
+			if err != nil {
+				log.Printf("ERROR: Node %d: Failed to generate recovery codes: %v", nodeNumber, err)
+				return currentUser, "", nil
+			}
+			currentUser.TOTPRecoveryCodes = hashes
+			if err := userManager.UpdateUser(currentUser); err != nil {
+				log.Printf("ERROR: Node %d: Failed to save recovery codes: %v", nodeNumber, err)
+				return currentUser, "", nil
+			}
+			log.Printf("INFO: Node %d: %s made new recovery codes", nodeNumber, currentUser.Handle)
+			wv("\r\n|02New recovery codes made; the old ones no longer work.|07\r\n")
+			e.showRecoveryCodes(terminal, codes, outputMode)
+			e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+
+		default:
+			return currentUser, "", nil
+		}
+	}
+}
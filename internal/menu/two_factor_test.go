@@ -0,0 +1,37 @@
+package menu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQRBlockLines(t *testing.T) {
+	lines, err := qrBlockLines("otpauth://totp/BBS:Felonius?issuer=BBS&secret=JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("qrBlockLines: %v", err)
+	}
+	width := len([]rune(lines[0]))
+	for i, line := range lines {
+		if n := len([]rune(line)); n != width {
+			t.Fatalf("line %d is %d wide, want %d", i, n, width)
+		}
+	}
+	// Two rows of modules per line, with the quiet zone around the code
+	if want := (width + 1) / 2; len(lines) != want {
+		t.Errorf("got %d lines, want %d", len(lines), want)
+	}
+	// The quiet zone is light, so the first line is solid
+	if strings.Trim(lines[0], "█") != "" {
+		t.Errorf("first line = %q, want all light", lines[0])
+	}
+	// Small enough for an 80x25 screen
+	if width > 80 || len(lines) > 24 {
+		t.Errorf("QR code is %dx%d", width, len(lines))
+	}
+}
+
+func TestGroupSecret(t *testing.T) {
+	if got := groupSecret("ABCDEFGHIJ"); got != "ABCD EFGH IJ" {
+		t.Errorf("groupSecret = %q", got)
+	}
+}
@@ -302,7 +302,7 @@ func runCfgPassword(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, use
 	}
 
 	// Prompt for new password using existing helper
-	newPw, err := e.promptForPassword(s, terminal, nodeNumber, outputMode, termWidth, termHeight)
+	newPw, err := e.promptForPassword(s, terminal, userManager, currentUser, nodeNumber, outputMode, termWidth, termHeight)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			return nil, "LOGOFF", io.EOF
@@ -314,14 +314,7 @@ func runCfgPassword(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, use
 	}
 
 	// Hash and save
-	hashed, err := bcrypt.GenerateFromPassword([]byte(newPw), bcrypt.DefaultCost)
-	if err != nil {
-		log.Printf("ERROR: Node %d: Failed to hash new password: %v", nodeNumber, err)
-		return currentUser, "", nil
-	}
-
-	currentUser.PasswordHash = string(hashed)
-	if err := userManager.UpdateUser(currentUser); err != nil {
+	if err := userManager.SetPassword(currentUser, newPw); err != nil {
 		log.Printf("ERROR: Node %d: Failed to save password: %v", nodeNumber, err)
 		return currentUser, "", nil
 	}
@@ -632,6 +625,138 @@ func runCfgAutoSig(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, user
 	}
 }
 
+// runCfgSSHKeys lets the user list, add and remove the SSH public keys that
+// log them in without the BBS password.
+func runCfgSSHKeys(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
+	if currentUser == nil {
+		return nil, "", nil
+	}
+
+	wv := func(msg string) {
+		terminalio.WriteProcessedBytes(terminal, ansi.ReplacePipeCodes([]byte(msg)), outputMode)
+	}
+
+	for {
+		wv("\r\n|15SSH Keys|07\r\n")
+		wv("|08Logging in over SSH with one of these keys skips the password prompt.|07\r\n\r\n")
+
+		if len(currentUser.SSHKeys) == 0 {
+			wv("|03You have no SSH keys registered.|07\r\n\r\n")
+		} else {
+			for i, k := range currentUser.SSHKeys {
+				keyType, _, _ := strings.Cut(k.Key, " ")
+				lastUsed := "never"
+				if !k.LastUsed.IsZero() {
+					lastUsed = k.LastUsed.Format("01/02/06")
+				}
+				wv(fmt.Sprintf("|15%d|07) |11%s |07%s\r\n", i+1, keyType, k.Fingerprint))
+				wv(fmt.Sprintf("   |08%s  added %s  last used %s|07\r\n", k.Comment, k.AddedAt.Format("01/02/06"), lastUsed))
+			}
+			wv("\r\n")
+		}
+
+		wv("|09A|07dd  |09D|07elete  |09Q|07uit : ")
+		input, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", nil
+		}
+
+		switch strings.TrimSpace(strings.ToUpper(input)) {
+		case "", "Q":
+			return currentUser, "", nil
+
+		case "A":
+			if len(currentUser.SSHKeys) >= user.MaxSSHKeys {
+				wv(fmt.Sprintf("\r\n|03You already have %d keys. Delete one first.|07\r\n", user.MaxSSHKeys))
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			wv("\r\n|07Paste your public key (the one line in |15id_ed25519.pub|07 or similar):\r\n")
+			line, err := readLineFromSessionIH(s, terminal)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				return currentUser, "", nil
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			key, err := user.ParseSSHKey(line)
+			if err != nil {
+				wv(fmt.Sprintf("\r\n|12%s.|07\r\n", err))
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			if owner, taken := userManager.SSHKeyOwner(key.Fingerprint); taken && !strings.EqualFold(owner, currentUser.Username) {
+				log.Printf("WARN: Node %d: %s tried to register SSH key %s already registered to another user", nodeNumber, currentUser.Handle, key.Fingerprint)
+				wv("\r\n|12That key is already in use.|07\r\n")
+				time.Sleep(1 * time.Second)
+				continue
+			}
+
+			// A key is as good as the password, so ask for it first
+			wv(e.LoadedStrings.CfgCurrentPwPrompt)
+			pw, err := readPasswordSecurely(s, terminal, outputMode)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				return currentUser, "", nil
+			}
+			if bcrypt.CompareHashAndPassword([]byte(currentUser.PasswordHash), []byte(pw)) != nil {
+				wv(e.LoadedStrings.CfgIncorrectPw)
+				time.Sleep(1 * time.Second)
+				continue
+			}
+
+			if err := currentUser.AddSSHKey(key); err != nil {
+				wv(fmt.Sprintf("\r\n|12%s.|07\r\n", err))
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			if err := userManager.UpdateUser(currentUser); err != nil {
+				log.Printf("ERROR: Node %d: Failed to save SSH key: %v", nodeNumber, err)
+				return currentUser, "", nil
+			}
+			log.Printf("INFO: Node %d: %s registered SSH key %s", nodeNumber, currentUser.Handle, key.Fingerprint)
+			wv("\r\n|02SSH key added!|07\r\n")
+			time.Sleep(500 * time.Millisecond)
+
+		case "D":
+			if len(currentUser.SSHKeys) == 0 {
+				wv("\r\n|03You don't have any SSH keys to delete!|07\r\n")
+				time.Sleep(500 * time.Millisecond)
+				continue
+			}
+			wv(fmt.Sprintf("\r\n|07Delete which key (|151-%d|07)? ", len(currentUser.SSHKeys)))
+			numStr, err := readLineFromSessionIH(s, terminal)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, "LOGOFF", io.EOF
+				}
+				return currentUser, "", nil
+			}
+			n, convErr := strconv.Atoi(strings.TrimSpace(numStr))
+			if convErr != nil || n < 1 || n > len(currentUser.SSHKeys) {
+				continue
+			}
+			fingerprint := currentUser.SSHKeys[n-1].Fingerprint
+			currentUser.RemoveSSHKey(fingerprint)
+			if err := userManager.UpdateUser(currentUser); err != nil {
+				log.Printf("ERROR: Node %d: Failed to delete SSH key: %v", nodeNumber, err)
+				return currentUser, "", nil
+			}
+			log.Printf("INFO: Node %d: %s removed SSH key %s", nodeNumber, currentUser.Handle, fingerprint)
+			wv("\r\n|03SSH key deleted.|07\r\n")
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+}
+
 func runCfgCustomPrompt(e *MenuExecutor, s ssh.Session, terminal *term.Terminal, userManager *user.UserMgr, currentUser *user.User, nodeNumber int, sessionStartTime time.Time, args string, outputMode ansi.OutputMode, termWidth int, termHeight int) (*user.User, string, error) {
 	if currentUser == nil {
 		return nil, "", nil
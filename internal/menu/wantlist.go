@@ -0,0 +1,643 @@
+package menu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/stlalpha/vision3/internal/ansi"
+	"github.com/stlalpha/vision3/internal/file"
+	"github.com/stlalpha/vision3/internal/user"
+	"golang.org/x/term"
+)
+
+// Want statuses.
+const (
+	WantOpen   = "open"
+	WantFilled = "filled"
+	WantClosed = "closed"
+)
+
+// Want urgencies.
+const (
+	WantLow    = 1
+	WantNormal = 2
+	WantUrgent = 3
+)
+
+// WantReply is a user saying they have a wanted file.
+type WantReply struct {
+	Handle    string    `json:"handle"`
+	Note      string    `json:"note,omitempty"` // Where or how they can get it
+	RepliedAt time.Time `json:"replied_at"`
+}
+
+// WantRecord is a request for a file posted to the want list.
+type WantRecord struct {
+	ID          int         `json:"id"`
+	Filename    string      `json:"filename"` // Name or wildcard pattern, e.g. DOOM*.ZIP
+	Description string      `json:"description"`
+	Urgency     int         `json:"urgency"` // WantLow, WantNormal or WantUrgent
+	RequestedBy string      `json:"requested_by"`
+	RequestedAt time.Time   `json:"requested_at"`
+	Status      string      `json:"status"` // WantOpen, WantFilled or WantClosed
+	Replies     []WantReply `json:"replies,omitempty"`
+	FilledBy    string      `json:"filled_by,omitempty"`   // Uploader of the matching file
+	FilledFile  string      `json:"filled_file,omitempty"` // "NAME (TAG)" of the matching file
+	ClosedBy    string      `json:"closed_by,omitempty"`
+	ResolvedAt  time.Time   `json:"resolved_at,omitempty"` // When filled or closed
+}
+
+// wantListData holds all wants with a NextID counter.
+type wantListData struct {
+	Wants  []WantRecord `json:"wants"`
+	NextID int          `json:"next_id"`
+}
+
+var wantListMu sync.Mutex
+
+func wantListFilePath(rootConfigPath string) string {
+	return filepath.Join(rootConfigPath, "..", "data", "wants.json")
+}
+
+func loadWantListData(rootConfigPath string) (*wantListData, error) {
+	data, err := os.ReadFile(wantListFilePath(rootConfigPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &wantListData{NextID: 1}, nil
+		}
+		return nil, fmt.Errorf("read wants.json: %w", err)
+	}
+	var wd wantListData
+	if err := json.Unmarshal(data, &wd); err != nil {
+		return nil, fmt.Errorf("parse wants.json: %w", err)
+	}
+	if wd.NextID < 1 {
+		maxID := 0
+		for _, w := range wd.Wants {
+			if w.ID > maxID {
+				maxID = w.ID
+			}
+		}
+		wd.NextID = maxID + 1
+	}
+	return &wd, nil
+}
+
+func saveWantListData(rootConfigPath string, wd *wantListData) error {
+	data, err := json.MarshalIndent(wd, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshal want list data: %w", err)
+	}
+	fp := wantListFilePath(rootConfigPath)
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		return fmt.Errorf("create want list data directory: %w", err)
+	}
+	return os.WriteFile(fp, data, 0644)
+}
+
+// wantSanitize replaces pipe characters in user-supplied strings to prevent
+// them from being interpreted as pipe color codes when displayed.
+func wantSanitize(s string) string {
+	return strings.ReplaceAll(sanitizeControlChars(strings.TrimSpace(s)), "|", "\xc2\xa6")
+}
+
+// wantUrgencyLabel returns a short colored label for an urgency.
+func wantUrgencyLabel(urgency int) string {
+	switch urgency {
+	case WantLow:
+		return "|08Low   "
+	case WantUrgent:
+		return "|12Urgent"
+	}
+	return "|07Normal"
+}
+
+// wantStatusLabel returns a short colored status for the list view.
+func wantStatusLabel(w *WantRecord) string {
+	switch w.Status {
+	case WantFilled:
+		return "|10Filled"
+	case WantClosed:
+		return "|08Closed"
+	}
+	if len(w.Replies) > 0 {
+		return fmt.Sprintf("|14Open (%d)", len(w.Replies))
+	}
+	return "|11Open"
+}
+
+// wantMatchesFile reports whether rec satisfies w. A wildcard pattern is
+// matched against the filename; a plain name matches the filename with or
+// without its extension, or appears as a word in the description, which
+// holds FILE_ID.DIZ text when the upload had one.
+func wantMatchesFile(w *WantRecord, rec file.FileRecord) bool {
+	want := strings.ToUpper(strings.TrimSpace(w.Filename))
+	name := strings.ToUpper(rec.Filename)
+	if want == "" {
+		return false
+	}
+	if strings.ContainsAny(want, "*?") {
+		ok, err := path.Match(want, name)
+		return err == nil && ok
+	}
+	if want == name {
+		return true
+	}
+	stem := strings.TrimSuffix(want, path.Ext(want))
+	if stem == strings.TrimSuffix(name, path.Ext(name)) {
+		return true
+	}
+	return len(stem) >= 4 && containsWord(strings.ToUpper(rec.Description), stem)
+}
+
+// containsWord reports whether word appears in text with no letter or digit
+// directly either side of it.
+func containsWord(text, word string) bool {
+	isWordByte := func(b byte) bool {
+		return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+	}
+	for start := 0; ; {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		if (i == 0 || !isWordByte(text[i-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+// fillWants marks open wants matched by a newly available file as filled
+// and sends each requester private mail. Called once rec can be downloaded:
+// straight after upload, or on validation for held uploads.
+func (e *MenuExecutor) fillWants(rec file.FileRecord, nodeNumber int) {
+	wantListMu.Lock()
+	wd, err := loadWantListData(e.RootConfigPath)
+	if err != nil {
+		wantListMu.Unlock()
+		log.Printf("WARN: Node %d: Failed to load want list for %s: %v", nodeNumber, rec.Filename, err)
+		return
+	}
+	location := rec.Filename
+	if tag := e.fileAreaTag(rec.AreaID); tag != "" {
+		location = fmt.Sprintf("%s (%s)", rec.Filename, tag)
+	}
+	var filled []WantRecord
+	for i := range wd.Wants {
+		w := &wd.Wants[i]
+		if w.Status != WantOpen || !wantMatchesFile(w, rec) {
+			continue
+		}
+		w.Status = WantFilled
+		w.FilledBy = rec.UploadedBy
+		w.FilledFile = location
+		w.ResolvedAt = time.Now().UTC()
+		filled = append(filled, *w)
+	}
+	if len(filled) > 0 {
+		if err := saveWantListData(e.RootConfigPath, wd); err != nil {
+			log.Printf("ERROR: Node %d: Failed to save want list: %v", nodeNumber, err)
+			filled = nil
+		}
+	}
+	wantListMu.Unlock()
+
+	for _, w := range filled {
+		log.Printf("INFO: Node %d: Want #%d (%s) from %s filled by %s", nodeNumber, w.ID, w.Filename, w.RequestedBy, location)
+		body := fmt.Sprintf("Good news! A file matching your want list request for %s has been uploaded.\n\nFile: %s\nUploaded by: %s\n", w.Filename, location, rec.UploadedBy)
+		e.sendPrivateNotice("", w.RequestedBy, "Want filled: "+w.Filename, body, nodeNumber)
+	}
+}
+
+// sendPrivateNotice sends private mail to a user on behalf of the system,
+// e.g. about a want or an offline request. An empty from sends it as the
+// SysOp.
+func (e *MenuExecutor) sendPrivateNotice(from, to, subject, body string, nodeNumber int) {
+	if e.MessageMgr == nil {
+		return
+	}
+	privmailArea, exists := e.MessageMgr.GetAreaByTag("PRIVMAIL")
+	if !exists {
+		log.Printf("WARN: Node %d: PRIVMAIL area not found; %s not notified: %s", nodeNumber, to, subject)
+		return
+	}
+	if from == "" {
+		if from = e.GetServerConfig().SysOpName; from == "" {
+			from = "SysOp"
+		}
+	}
+	if _, err := e.MessageMgr.AddPrivateMessage(privmailArea.ID, from, to, subject, body, ""); err != nil {
+		log.Printf("ERROR: Node %d: Failed to mail %s (%s): %v", nodeNumber, to, subject, err)
+	}
+}
+
+// wantListShow draws the want list: open wants first, then filled and
+// closed ones that have not been purged.
+func wantListShow(terminal *term.Terminal, wd *wantListData, outputMode ansi.OutputMode) {
+	wv(terminal, "\x1b[2J\x1b[H", outputMode)
+	wv(terminal, "|15Want List\r\n", outputMode)
+	wv(terminal, fmt.Sprintf("|11%-5s%-8s%-24s%-17s%-10s%s\r\n", "#", "Urgency", "File", "Requested By", "Date", "Status"), outputMode)
+	wv(terminal, "|08"+strings.Repeat("\xc4", 75)+"\r\n", outputMode)
+
+	if len(wd.Wants) == 0 {
+		wv(terminal, "|07Nobody wants anything yet.\r\n", outputMode)
+		return
+	}
+	for _, open := range []bool{true, false} {
+		for i := range wd.Wants {
+			w := &wd.Wants[i]
+			if (w.Status == WantOpen) != open {
+				continue
+			}
+			wv(terminal, fmt.Sprintf("|03%-5d%s  |15%-24s|07%-17s%-10s%s|07\r\n",
+				w.ID, wantUrgencyLabel(w.Urgency), truncateRunes(w.Filename, 23), truncateRunes(w.RequestedBy, 16),
+				w.RequestedAt.Format("01/02/06"), wantStatusLabel(w)), outputMode)
+		}
+	}
+}
+
+// findWant returns the index of the want with the given ID, or -1.
+func findWant(wd *wantListData, id int) int {
+	for i := range wd.Wants {
+		if wd.Wants[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// runWantList is the want list / file request board. Users post requests
+// for files and reply "I have it" to others' requests; the requester or a
+// SysOp can close a want, and SysOps purge filled and closed ones.
+func runWantList(e *MenuExecutor, s ssh.Session, terminal *term.Terminal,
+	userManager *user.UserMgr, currentUser *user.User, nodeNumber int,
+	sessionStartTime time.Time, args string, outputMode ansi.OutputMode,
+	termWidth int, termHeight int) (*user.User, string, error) {
+
+	if currentUser == nil {
+		return currentUser, "", nil
+	}
+
+	log.Printf("DEBUG: Node %d: Running WANTLIST for user %s", nodeNumber, currentUser.Handle)
+	isSysOp := e.isCoSysOpOrAbove(currentUser)
+
+	for {
+		wantListMu.Lock()
+		wd, err := loadWantListData(e.RootConfigPath)
+		wantListMu.Unlock()
+		if err != nil {
+			log.Printf("ERROR: Node %d: Failed to load want list: %v", nodeNumber, err)
+			wv(terminal, "\r\n|04Error loading want list.\r\n", outputMode)
+			e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+			return currentUser, "", nil
+		}
+		wantListShow(terminal, wd, outputMode)
+
+		prompt := "\r\n|15[A]|07dd  |15[V]|07iew #  |15[H]|07ave it #  |15[C]|07lose #  "
+		if isSysOp {
+			prompt += "|15[P]|07urge  "
+		}
+		prompt += "|15[Q]|07uit: "
+		wv(terminal, prompt, outputMode)
+
+		input, err := readLineFromSessionIH(s, terminal)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			return currentUser, "", nil
+		}
+		fields := strings.Fields(strings.ToUpper(input))
+		if len(fields) == 0 {
+			return currentUser, "", nil
+		}
+		cmd := fields[0]
+		id := 0
+		if len(fields) > 1 {
+			id, _ = strconv.Atoi(fields[1])
+		} else if len(cmd) > 1 {
+			// Allow "V3" as well as "V 3".
+			if n, nerr := strconv.Atoi(cmd[1:]); nerr == nil {
+				cmd, id = cmd[:1], n
+			}
+		}
+
+		var stepErr error
+		switch cmd {
+		case "Q":
+			return currentUser, "", nil
+		case "A":
+			stepErr = e.wantAdd(s, terminal, currentUser, nodeNumber, outputMode, termWidth, termHeight)
+		case "V", "H", "C":
+			if id == 0 {
+				wv(terminal, "|07Want number: ", outputMode)
+				numInput, rerr := readLineFromSessionIH(s, terminal)
+				if rerr != nil {
+					stepErr = rerr
+					break
+				}
+				id, _ = strconv.Atoi(strings.TrimSpace(numInput))
+			}
+			idx := findWant(wd, id)
+			if idx < 0 {
+				wv(terminal, "\r\n|04Want not found.\r\n", outputMode)
+				time.Sleep(1 * time.Second)
+				break
+			}
+			switch cmd {
+			case "V":
+				wantView(terminal, &wd.Wants[idx], outputMode)
+				e.holdScreen(s, terminal, outputMode, termWidth, termHeight)
+			case "H":
+				stepErr = e.wantReply(s, terminal, currentUser, id, nodeNumber, outputMode)
+			case "C":
+				stepErr = e.wantClose(s, terminal, currentUser, id, isSysOp, nodeNumber, outputMode, termWidth, termHeight)
+			}
+		case "P":
+			if isSysOp {
+				stepErr = e.wantPurge(s, terminal, currentUser, nodeNumber, outputMode, termWidth, termHeight)
+			}
+		}
+		if stepErr != nil {
+			if errors.Is(stepErr, io.EOF) {
+				return nil, "LOGOFF", io.EOF
+			}
+			log.Printf("ERROR: Node %d: Want list: %v", nodeNumber, stepErr)
+		}
+	}
+}
+
+// wantView shows one want in full.
+func wantView(terminal *term.Terminal, w *WantRecord, outputMode ansi.OutputMode) {
+	wv(terminal, fmt.Sprintf("\r\n|15Want #%d: |11%s\r\n", w.ID, w.Filename), outputMode)
+	wv(terminal, "|08"+strings.Repeat("\xc4", 75)+"\r\n", outputMode)
+	wv(terminal, fmt.Sprintf("|07Requested by |15%s|07 on %s   Urgency: %s|07\r\n", w.RequestedBy, w.RequestedAt.Format("01/02/06"), strings.TrimSpace(wantUrgencyLabel(w.Urgency))), outputMode)
+	if w.Description != "" {
+		wv(terminal, "|07"+w.Description+"\r\n", outputMode)
+	}
+	switch w.Status {
+	case WantFilled:
+		wv(terminal, fmt.Sprintf("|10Filled by %s uploading %s on %s.\r\n", w.FilledBy, w.FilledFile, w.ResolvedAt.Format("01/02/06")), outputMode)
+	case WantClosed:
+		wv(terminal, fmt.Sprintf("|08Closed by %s on %s.\r\n", w.ClosedBy, w.ResolvedAt.Format("01/02/06")), outputMode)
+	}
+	if len(w.Replies) > 0 {
+		wv(terminal, "\r\n|14Users who have it:\r\n", outputMode)
+		for _, r := range w.Replies {
+			line := fmt.Sprintf("|15%-16s |07%s", truncateRunes(r.Handle, 16), r.RepliedAt.Format("01/02/06"))
+			if r.Note != "" {
+				line += "  " + r.Note
+			}
+			wv(terminal, line+"\r\n", outputMode)
+		}
+	}
+}
+
+// findWantedFile returns an available file that already satisfies w, with
+// its area tag.
+func (e *MenuExecutor) findWantedFile(w *WantRecord) (file.FileRecord, string, bool) {
+	if e.FileMgr == nil {
+		return file.FileRecord{}, "", false
+	}
+	for _, area := range e.FileMgr.ListAreas() {
+		for _, rec := range e.FileMgr.GetFilesForArea(area.ID) {
+			if !rec.Unvalidated && wantMatchesFile(w, rec) {
+				return rec, area.Tag, true
+			}
+		}
+	}
+	return file.FileRecord{}, "", false
+}
+
+// wantAdd prompts for and posts a new want.
+func (e *MenuExecutor) wantAdd(s ssh.Session, terminal *term.Terminal, currentUser *user.User, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) error {
+	wv(terminal, "\r\n|07File name or pattern |08(e.g. |15DOOM*.ZIP|08)|07: ", outputMode)
+	name, err := readLineFromSessionIH(s, terminal)
+	if err != nil {
+		return err
+	}
+	name = wantSanitize(name)
+	if name == "" {
+		return nil
+	}
+	name = truncateRunes(name, 40)
+
+	wv(terminal, "|07Description: ", outputMode)
+	desc, err := readLineFromSessionIH(s, terminal)
+	if err != nil {
+		return err
+	}
+	desc = truncateRunes(wantSanitize(desc), 60)
+
+	wv(terminal, "|07Urgency |08[|15L|08]|07ow, |08[|15N|08]|07ormal, |08[|15U|08]|07rgent |08(|15Enter|08=Normal)|07: ", outputMode)
+	urgInput, err := readLineFromSessionIH(s, terminal)
+	if err != nil {
+		return err
+	}
+	urgency := WantNormal
+	switch strings.ToUpper(strings.TrimSpace(urgInput)) {
+	case "L":
+		urgency = WantLow
+	case "U":
+		urgency = WantUrgent
+	}
+
+	want := WantRecord{
+		Filename:    name,
+		Description: desc,
+		Urgency:     urgency,
+		RequestedBy: currentUser.Handle,
+		RequestedAt: time.Now().UTC(),
+		Status:      WantOpen,
+	}
+
+	// Nothing to wait for if it is already here.
+	if rec, tag, found := e.findWantedFile(&want); found {
+		wv(terminal, fmt.Sprintf("\r\n|14%s is already available in %s.|07\r\n", rec.Filename, tag), outputMode)
+		addAnyway, perr := e.PromptYesNo(s, terminal, "|09Post the want anyway? @", outputMode, nodeNumber, termWidth, termHeight, false)
+		if perr != nil || !addAnyway {
+			return perr
+		}
+	}
+
+	wantListMu.Lock()
+	wd, err := loadWantListData(e.RootConfigPath)
+	if err == nil {
+		want.ID = wd.NextID
+		wd.NextID++
+		wd.Wants = append(wd.Wants, want)
+		err = saveWantListData(e.RootConfigPath, wd)
+	}
+	wantListMu.Unlock()
+	if err != nil {
+		wv(terminal, "\r\n|04Error saving want.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return err
+	}
+
+	log.Printf("INFO: Node %d: %s added want #%d for %s", nodeNumber, currentUser.Handle, want.ID, want.Filename)
+	wv(terminal, "\r\n|10Want posted. You'll get mail when it arrives.\r\n", outputMode)
+	time.Sleep(1 * time.Second)
+	return nil
+}
+
+// wantReply records that the current user has a wanted file and lets the
+// requester know by private mail.
+func (e *MenuExecutor) wantReply(s ssh.Session, terminal *term.Terminal, currentUser *user.User, id, nodeNumber int, outputMode ansi.OutputMode) error {
+	wantListMu.Lock()
+	wd, err := loadWantListData(e.RootConfigPath)
+	wantListMu.Unlock()
+	if err != nil {
+		return err
+	}
+	idx := findWant(wd, id)
+	if idx < 0 {
+		return nil
+	}
+	w := wd.Wants[idx]
+	switch {
+	case w.Status != WantOpen:
+		wv(terminal, "\r\n|04That want is no longer open.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	case strings.EqualFold(w.RequestedBy, currentUser.Handle):
+		wv(terminal, "\r\n|04That's your own want!\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	}
+	for _, r := range w.Replies {
+		if strings.EqualFold(r.Handle, currentUser.Handle) {
+			wv(terminal, "\r\n|04You already said you have it.\r\n", outputMode)
+			time.Sleep(1 * time.Second)
+			return nil
+		}
+	}
+
+	wv(terminal, "\r\n|07Note for "+w.RequestedBy+" |08(optional)|07: ", outputMode)
+	note, err := readLineFromSessionIH(s, terminal)
+	if err != nil {
+		return err
+	}
+	reply := WantReply{Handle: currentUser.Handle, Note: truncateRunes(wantSanitize(note), 60), RepliedAt: time.Now().UTC()}
+
+	wantListMu.Lock()
+	wd, err = loadWantListData(e.RootConfigPath)
+	if err == nil {
+		if idx = findWant(wd, id); idx >= 0 && wd.Wants[idx].Status == WantOpen {
+			wd.Wants[idx].Replies = append(wd.Wants[idx].Replies, reply)
+			err = saveWantListData(e.RootConfigPath, wd)
+		}
+	}
+	wantListMu.Unlock()
+	if err != nil {
+		wv(terminal, "\r\n|04Error saving reply.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return err
+	}
+
+	body := fmt.Sprintf("%s has %s from your want list request.\n", currentUser.Handle, w.Filename)
+	if reply.Note != "" {
+		body += "\nNote: " + reply.Note + "\n"
+	}
+	e.sendPrivateNotice(currentUser.Handle, w.RequestedBy, "I have it: "+w.Filename, body, nodeNumber)
+	log.Printf("INFO: Node %d: %s replied to want #%d (%s)", nodeNumber, currentUser.Handle, id, w.Filename)
+	wv(terminal, fmt.Sprintf("\r\n|10%s has been told you have it.\r\n", w.RequestedBy), outputMode)
+	time.Sleep(1 * time.Second)
+	return nil
+}
+
+// wantClose closes an open want. Users may close their own; SysOps any.
+func (e *MenuExecutor) wantClose(s ssh.Session, terminal *term.Terminal, currentUser *user.User, id int, isSysOp bool, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) error {
+	wantListMu.Lock()
+	wd, err := loadWantListData(e.RootConfigPath)
+	wantListMu.Unlock()
+	if err != nil {
+		return err
+	}
+	idx := findWant(wd, id)
+	if idx < 0 {
+		return nil
+	}
+	w := wd.Wants[idx]
+	if !isSysOp && !strings.EqualFold(w.RequestedBy, currentUser.Handle) {
+		wv(terminal, "\r\n|04You didn't post that!\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	}
+	if w.Status != WantOpen {
+		wv(terminal, "\r\n|04That want is no longer open.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return nil
+	}
+	yes, err := e.PromptYesNo(s, terminal, fmt.Sprintf("|09Close want for %s? @", w.Filename), outputMode, nodeNumber, termWidth, termHeight, false)
+	if err != nil || !yes {
+		return err
+	}
+
+	wantListMu.Lock()
+	wd, err = loadWantListData(e.RootConfigPath)
+	if err == nil {
+		if idx = findWant(wd, id); idx >= 0 && wd.Wants[idx].Status == WantOpen {
+			wd.Wants[idx].Status = WantClosed
+			wd.Wants[idx].ClosedBy = currentUser.Handle
+			wd.Wants[idx].ResolvedAt = time.Now().UTC()
+			err = saveWantListData(e.RootConfigPath, wd)
+		}
+	}
+	wantListMu.Unlock()
+	if err != nil {
+		wv(terminal, "\r\n|04Error closing want.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return err
+	}
+	log.Printf("INFO: Node %d: %s closed want #%d (%s)", nodeNumber, currentUser.Handle, id, w.Filename)
+	return nil
+}
+
+// wantPurge permanently removes filled and closed wants.
+func (e *MenuExecutor) wantPurge(s ssh.Session, terminal *term.Terminal, currentUser *user.User, nodeNumber int, outputMode ansi.OutputMode, termWidth, termHeight int) error {
+	yes, err := e.PromptYesNo(s, terminal, "|09Purge all filled and closed wants? @", outputMode, nodeNumber, termWidth, termHeight, false)
+	if err != nil || !yes {
+		return err
+	}
+
+	wantListMu.Lock()
+	wd, err := loadWantListData(e.RootConfigPath)
+	purged := 0
+	if err == nil {
+		kept := wd.Wants[:0]
+		for _, w := range wd.Wants {
+			if w.Status == WantOpen {
+				kept = append(kept, w)
+			}
+		}
+		purged = len(wd.Wants) - len(kept)
+		wd.Wants = kept
+		err = saveWantListData(e.RootConfigPath, wd)
+	}
+	wantListMu.Unlock()
+	if err != nil {
+		wv(terminal, "\r\n|04Error purging wants.\r\n", outputMode)
+		time.Sleep(1 * time.Second)
+		return err
+	}
+
+	log.Printf("INFO: Node %d: %s purged %d want(s)", nodeNumber, currentUser.Handle, purged)
+	wv(terminal, fmt.Sprintf("\r\n|10Purged %d want(s).\r\n", purged), outputMode)
+	time.Sleep(1 * time.Second)
+	return nil
+}
@@ -0,0 +1,60 @@
+package menu
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stlalpha/vision3/internal/file"
+)
+
+func TestWantMatchesFile(t *testing.T) {
+	rec := file.FileRecord{Filename: "DOOM19S.ZIP", Description: "The Ultimate DOOM shareware v1.9 (QUAKE-free)"}
+	tests := []struct {
+		want string
+		ok   bool
+	}{
+		{"doom19s.zip", true},
+		{"DOOM19S", true},     // Extension ignored
+		{"DOOM19S.LZH", true}, // Same stem, different archiver
+		{"DOOM*.ZIP", true},
+		{"DOOM*.ARJ", false},
+		{"ULTIMATE", true}, // Word in the DIZ
+		{"QUAKE", true},
+		{"ULTIM", false}, // Not a whole word
+		{"DOS", false},   // Too short to match the DIZ
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := wantMatchesFile(&WantRecord{Filename: tt.want}, rec); got != tt.ok {
+			t.Errorf("wantMatchesFile(%q) = %v, want %v", tt.want, got, tt.ok)
+		}
+	}
+}
+
+func TestFillWants_MarksOpenMatchesFilled(t *testing.T) {
+	fm, _ := setupTestFileManagerForViewer(t, []file.FileArea{{ID: 1, Tag: "GAMES", Name: "Games", Path: "games"}})
+	root := filepath.Join(t.TempDir(), "configs")
+	e := &MenuExecutor{FileMgr: fm, RootConfigPath: root}
+
+	wd := &wantListData{NextID: 4, Wants: []WantRecord{
+		{ID: 1, Filename: "DOOM*.ZIP", RequestedBy: "Alice", Status: WantOpen},
+		{ID: 2, Filename: "DOOM19S.ZIP", RequestedBy: "Bob", Status: WantClosed},
+		{ID: 3, Filename: "QUAKE.ZIP", RequestedBy: "Carol", Status: WantOpen},
+	}}
+	if err := saveWantListData(root, wd); err != nil {
+		t.Fatal(err)
+	}
+
+	e.fillWants(file.FileRecord{AreaID: 1, Filename: "DOOM19S.ZIP", UploadedBy: "Dave"}, 1)
+
+	got, err := loadWantListData(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := got.Wants[0]; w.Status != WantFilled || w.FilledBy != "Dave" || w.FilledFile != "DOOM19S.ZIP (GAMES)" || w.ResolvedAt.IsZero() {
+		t.Errorf("want 1 = %+v", w)
+	}
+	if got.Wants[1].Status != WantClosed || got.Wants[2].Status != WantOpen {
+		t.Errorf("unexpected statuses: %+v", got.Wants)
+	}
+}
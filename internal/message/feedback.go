@@ -0,0 +1,228 @@
+package message
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/jam"
+)
+
+// Feedback to the sysop is kept in its own JAM base, apart from the message
+// areas, so it never shows up in area lists, scans or private mail.
+const (
+	feedbackBasePath = "msgbases/feedback"
+	FeedbackTo       = "SysOp" // To field of feedback left by users
+	feedbackMsgIDKey = "feedback"
+)
+
+// openFeedbackBase opens (creating if needed) the feedback base. The caller
+// must close it.
+func (mm *MessageManager) openFeedbackBase() (*jam.Base, error) {
+	b, err := jam.Open(filepath.Join(mm.dataPath, feedbackBasePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open feedback base: %w", err)
+	}
+	return b, nil
+}
+
+// writeFeedback writes one private message to the feedback base with a
+// MSGID so replies can be threaded to it.
+func writeFeedback(b *jam.Base, from, to, subject, body, replyToMsgID string, attr uint32) (int, error) {
+	msgID, err := b.GenerateMSGID(feedbackMsgIDKey)
+	if err != nil {
+		return 0, err
+	}
+	msg := jam.NewMessage()
+	msg.From = from
+	msg.To = to
+	msg.Subject = subject
+	msg.Text = body
+	msg.DateTime = time.Now()
+	msg.MsgID = msgID
+	msg.ReplyID = replyToMsgID
+	msg.Header = &jam.MessageHeader{Attribute: jam.MsgPrivate | jam.MsgLocal | attr}
+	return b.WriteMessage(msg)
+}
+
+// AddFeedback stores feedback from a user to the sysop. Returns the 1-based
+// feedback number.
+func (mm *MessageManager) AddFeedback(from, subject, body string) (int, error) {
+	b, err := mm.openFeedbackBase()
+	if err != nil {
+		return 0, err
+	}
+	defer b.Close()
+	return writeFeedback(b, from, FeedbackTo, subject, body, "", 0)
+}
+
+// AddFeedbackReply stores a sysop's reply to feedback number parent,
+// threaded to it. Replies are stored already read, so they never count as
+// waiting feedback. Returns the reply's number.
+func (mm *MessageManager) AddFeedbackReply(parent int, from, to, subject, body string) (int, error) {
+	b, err := mm.openFeedbackBase()
+	if err != nil {
+		return 0, err
+	}
+	defer b.Close()
+
+	orig, err := b.ReadMessage(parent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read feedback #%d: %w", parent, err)
+	}
+	num, err := writeFeedback(b, from, to, subject, body, orig.MsgID, jam.MsgRead)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := b.Link(); err != nil {
+		return num, fmt.Errorf("failed to link feedback reply: %w", err)
+	}
+	return num, nil
+}
+
+// ListFeedback returns every feedback message and reply that has not been
+// deleted, oldest first.
+func (mm *MessageManager) ListFeedback() ([]*DisplayMessage, error) {
+	b, err := mm.openFeedbackBase()
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	total, err := b.GetMessageCount()
+	if err != nil {
+		return nil, err
+	}
+	var list []*DisplayMessage
+	for n := 1; n <= total; n++ {
+		msg, err := b.ReadMessage(n)
+		if err != nil || msg.IsDeleted() {
+			continue
+		}
+		list = append(list, feedbackDisplay(n, msg))
+	}
+	return list, nil
+}
+
+// GetFeedback reads feedback number n.
+func (mm *MessageManager) GetFeedback(n int) (*DisplayMessage, error) {
+	b, err := mm.openFeedbackBase()
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	msg, err := b.ReadMessage(n)
+	if err != nil {
+		return nil, err
+	}
+	return feedbackDisplay(n, msg), nil
+}
+
+// FeedbackReplies returns the replies to feedback number n, oldest first.
+func (mm *MessageManager) FeedbackReplies(n int) ([]*DisplayMessage, error) {
+	list, err := mm.ListFeedback()
+	if err != nil {
+		return nil, err
+	}
+	var parent *DisplayMessage
+	for _, m := range list {
+		if m.MsgNum == n {
+			parent = m
+		}
+	}
+	if parent == nil || parent.MsgID == "" {
+		return nil, nil
+	}
+	var replies []*DisplayMessage
+	for _, m := range list {
+		if m.ReplyID == parent.MsgID {
+			replies = append(replies, m)
+		}
+	}
+	return replies, nil
+}
+
+// MarkFeedbackRead flags feedback number n as read by the sysop.
+func (mm *MessageManager) MarkFeedbackRead(n int) error {
+	b, err := mm.openFeedbackBase()
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	hdr, err := b.ReadMessageHeader(n)
+	if err != nil {
+		return err
+	}
+	if hdr.Attribute&jam.MsgRead != 0 {
+		return nil
+	}
+	hdr.Attribute |= jam.MsgRead
+	return b.UpdateMessageHeader(n, hdr)
+}
+
+// DeleteFeedback marks feedback number n and its replies as deleted.
+func (mm *MessageManager) DeleteFeedback(n int) error {
+	replies, err := mm.FeedbackReplies(n)
+	if err != nil {
+		return err
+	}
+	b, err := mm.openFeedbackBase()
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	if err := b.DeleteMessage(n); err != nil {
+		return err
+	}
+	for _, r := range replies {
+		if err := b.DeleteMessage(r.MsgNum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FeedbackWaiting returns how many feedback messages the sysop has not
+// read yet.
+func (mm *MessageManager) FeedbackWaiting() (int, error) {
+	list, err := mm.ListFeedback()
+	if err != nil {
+		return 0, err
+	}
+	waiting := 0
+	for _, m := range list {
+		if m.Attributes&jam.MsgRead == 0 {
+			waiting++
+		}
+	}
+	return waiting, nil
+}
+
+// IsFeedbackReply reports whether m is a sysop reply rather than feedback
+// from a user.
+func IsFeedbackReply(m *DisplayMessage) bool {
+	return m.ReplyID != ""
+}
+
+func feedbackDisplay(n int, msg *jam.Message) *DisplayMessage {
+	replyToNum := 0
+	if msg.Header != nil {
+		replyToNum = int(msg.Header.ReplyTo)
+	}
+	return &DisplayMessage{
+		MsgNum:     n,
+		From:       msg.From,
+		To:         msg.To,
+		Subject:    msg.Subject,
+		DateTime:   msg.DateTime,
+		Body:       normalizeLineEndings(msg.Text),
+		MsgID:      msg.MsgID,
+		ReplyID:    msg.ReplyID,
+		ReplyToNum: replyToNum,
+		Attributes: msg.GetAttribute(),
+		IsPrivate:  msg.IsPrivate(),
+		IsDeleted:  msg.IsDeleted(),
+	}
+}
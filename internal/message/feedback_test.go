@@ -0,0 +1,94 @@
+package message
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newFeedbackTestManager(t *testing.T) *MessageManager {
+	t.Helper()
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, "config")
+	os.MkdirAll(configDir, 0755)
+	os.WriteFile(filepath.Join(configDir, "message_areas.json"), []byte("[]"), 0644)
+
+	mm, err := NewMessageManager(tmpDir, configDir, "TestBBS", nil)
+	if err != nil {
+		t.Fatalf("failed to create message manager: %v", err)
+	}
+	return mm
+}
+
+func TestFeedback_WaitingAndRead(t *testing.T) {
+	mm := newFeedbackTestManager(t)
+
+	if n, err := mm.FeedbackWaiting(); err != nil || n != 0 {
+		t.Fatalf("empty base: waiting = %d, %v", n, err)
+	}
+
+	first, err := mm.AddFeedback("Alice", "Access", "Please validate me")
+	if err != nil {
+		t.Fatalf("AddFeedback: %v", err)
+	}
+	if _, err := mm.AddFeedback("Bob", "Hi", "Nice board"); err != nil {
+		t.Fatalf("AddFeedback: %v", err)
+	}
+	if n, _ := mm.FeedbackWaiting(); n != 2 {
+		t.Fatalf("waiting = %d, want 2", n)
+	}
+
+	fb, err := mm.GetFeedback(first)
+	if err != nil {
+		t.Fatalf("GetFeedback: %v", err)
+	}
+	if fb.From != "Alice" || fb.To != FeedbackTo || !fb.IsPrivate || fb.Body != "Please validate me" {
+		t.Errorf("GetFeedback = %+v", fb)
+	}
+
+	if err := mm.MarkFeedbackRead(first); err != nil {
+		t.Fatalf("MarkFeedbackRead: %v", err)
+	}
+	if n, _ := mm.FeedbackWaiting(); n != 1 {
+		t.Errorf("waiting after read = %d, want 1", n)
+	}
+}
+
+func TestFeedback_ReplyThreading(t *testing.T) {
+	mm := newFeedbackTestManager(t)
+
+	parent, _ := mm.AddFeedback("Alice", "Access", "Please validate me")
+	other, _ := mm.AddFeedback("Bob", "Hi", "Nice board")
+	reply, err := mm.AddFeedbackReply(parent, "Sysop", "Alice", "Re: Access", "Done!")
+	if err != nil {
+		t.Fatalf("AddFeedbackReply: %v", err)
+	}
+
+	replies, err := mm.FeedbackReplies(parent)
+	if err != nil {
+		t.Fatalf("FeedbackReplies: %v", err)
+	}
+	if len(replies) != 1 || replies[0].MsgNum != reply || replies[0].To != "Alice" {
+		t.Fatalf("replies = %+v", replies)
+	}
+	if !IsFeedbackReply(replies[0]) || replies[0].ReplyToNum != parent {
+		t.Errorf("reply not linked to #%d: %+v", parent, replies[0])
+	}
+	if r, _ := mm.FeedbackReplies(other); len(r) != 0 {
+		t.Errorf("unrelated feedback has %d replies", len(r))
+	}
+
+	// Replies never count as waiting feedback.
+	if n, _ := mm.FeedbackWaiting(); n != 2 {
+		t.Errorf("waiting = %d, want 2", n)
+	}
+
+	// Deleting feedback takes its replies with it.
+	if err := mm.DeleteFeedback(parent); err != nil {
+		t.Fatalf("DeleteFeedback: %v", err)
+	}
+	list, _ := mm.ListFeedback()
+	if len(list) != 1 || list[0].MsgNum != other {
+		t.Errorf("after delete ListFeedback = %+v", list)
+	}
+}
@@ -23,6 +23,10 @@ func (s *Scheduler) executeEvent(ctx context.Context, event config.EventConfig)
 
 	log.Printf("INFO: Event '%s' (%s) started", event.ID, event.Name)
 
+	if event.Internal != "" {
+		return s.executeInternal(ctx, event, result)
+	}
+
 	// Build substitutions for placeholders
 	substitutions := s.buildSubstitutions(event)
 
@@ -129,6 +133,46 @@ func (s *Scheduler) executeEvent(ctx context.Context, event config.EventConfig)
 	return result
 }
 
+// executeInternal runs a built-in job registered with RegisterInternal
+func (s *Scheduler) executeInternal(ctx context.Context, event config.EventConfig, result EventResult) EventResult {
+	s.mu.RLock()
+	fn, ok := s.internal[event.Internal]
+	s.mu.RUnlock()
+	if !ok {
+		result.EndTime = time.Now()
+		result.ExitCode = -1
+		result.Error = fmt.Errorf("unknown internal job %q", event.Internal)
+		log.Printf("ERROR: Event '%s' (%s) failed to start: %v", event.ID, event.Name, result.Error)
+		return result
+	}
+
+	jobCtx := ctx
+	if event.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, time.Duration(event.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	output, err := fn(jobCtx)
+	result.EndTime = time.Now()
+	result.Output = output
+	if err != nil {
+		result.Error = err
+		result.ExitCode = -1
+		result.ErrorOutput = err.Error()
+		log.Printf("ERROR: Event '%s' (%s) failed: %v", event.ID, event.Name, err)
+		return result
+	}
+
+	result.Success = true
+	duration := result.EndTime.Sub(result.StartTime)
+	log.Printf("INFO: Event '%s' (%s) completed in %.3fs", event.ID, event.Name, duration.Seconds())
+	if result.Output != "" {
+		log.Printf("DEBUG: Event '%s' output: %s", event.ID, result.Output)
+	}
+	return result
+}
+
 // buildSubstitutions creates a map of placeholder substitutions for an event
 func (s *Scheduler) buildSubstitutions(event config.EventConfig) map[string]string {
 	now := time.Now()
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"os/exec"
 	"strings"
 	"testing"
@@ -210,3 +211,31 @@ func TestPlaceholderSubstitutionInCommand(t *testing.T) {
 		t.Errorf("Expected output to contain 'placeholder_test_ok', got: %s", result2.Output)
 	}
 }
+
+func TestExecuteEvent_Internal(t *testing.T) {
+	s := NewScheduler(config.EventsConfig{}, t.TempDir()+"/history.json")
+
+	calls := 0
+	s.RegisterInternal("count", func(ctx context.Context) (string, error) {
+		calls++
+		return "counted", nil
+	})
+	s.RegisterInternal("broken", func(ctx context.Context) (string, error) {
+		return "", errors.New("save failed")
+	})
+
+	result := s.executeEvent(context.Background(), config.EventConfig{ID: "ok", Internal: "count"})
+	if !result.Success || calls != 1 || result.Output != "counted" {
+		t.Errorf("internal job: success=%v calls=%d output=%q", result.Success, calls, result.Output)
+	}
+
+	result = s.executeEvent(context.Background(), config.EventConfig{ID: "bad", Internal: "broken"})
+	if result.Success || result.ExitCode != -1 || result.Error == nil {
+		t.Errorf("failing job reported success=%v exit=%d err=%v", result.Success, result.ExitCode, result.Error)
+	}
+
+	result = s.executeEvent(context.Background(), config.EventConfig{ID: "missing", Internal: "nope"})
+	if result.Success || result.Error == nil {
+		t.Error("unknown internal job should fail")
+	}
+}
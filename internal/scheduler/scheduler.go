@@ -21,8 +21,14 @@ type Scheduler struct {
 	startupWg      sync.WaitGroup
 	ctx            context.Context
 	cancel         context.CancelFunc
+	internal       map[string]InternalFunc
 }
 
+// InternalFunc is a built-in job the BBS runs in its own process for
+// events that set Internal instead of Command. The returned string is
+// recorded as the event's output.
+type InternalFunc func(ctx context.Context) (string, error)
+
 // NewScheduler creates a new event scheduler
 func NewScheduler(cfg config.EventsConfig, historyPath string) *Scheduler {
 	// Set default max concurrent events if not specified
@@ -43,9 +49,18 @@ func NewScheduler(cfg config.EventsConfig, historyPath string) *Scheduler {
 		historyPath:    historyPath,
 		runningEvents:  make(map[string]bool),
 		concurrencySem: make(chan struct{}, cfg.MaxConcurrentEvents),
+		internal:       make(map[string]InternalFunc),
 	}
 }
 
+// RegisterInternal makes a built-in job available to events whose
+// Internal field names it. Call it before Start.
+func (s *Scheduler) RegisterInternal(name string, fn InternalFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.internal[name] = fn
+}
+
 // Start begins the scheduler with the given context
 func (s *Scheduler) Start(ctx context.Context) {
 	s.ctx, s.cancel = context.WithCancel(ctx)
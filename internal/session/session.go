@@ -37,6 +37,25 @@ type BbsSession struct {
 	LastActivity time.Time            // Tracks last user input for idle calculation
 	PendingPages []string             // Queued page messages for delivery at next prompt
 	Invisible    bool                 // True if user logged in invisibly (SysOp/CoSysOp only)
+	Transfers    []user.TransferRecord // File transfers made during this session
+}
+
+// AddTransfer records a file transfer made during the session.
+func (s *BbsSession) AddTransfer(rec user.TransferRecord) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+	s.Transfers = append(s.Transfers, rec)
+}
+
+// TransferTotals sums the session's file transfers.
+func (s *BbsSession) TransferTotals() user.TransferTotals {
+	s.Mutex.RLock()
+	defer s.Mutex.RUnlock()
+	var totals user.TransferTotals
+	for _, rec := range s.Transfers {
+		totals.Add(rec)
+	}
+	return totals
 }
 
 // AddPage queues a page message for delivery at the user's next menu prompt.
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// TimeClock tracks how much time a logged-in caller has left on this call.
+// The allowance is fixed at logon from the per-call and per-day limits;
+// credits (upload time, time bank withdrawals) and debits (deposits) adjust
+// it during the call. Time in doors and transfers is wall-clock time, so it
+// is debited like any other.
+type TimeClock struct {
+	mu      sync.Mutex
+	start   time.Time
+	allowed time.Duration // 0 = unlimited
+	credit  time.Duration // Net time added during the call
+	warned  time.Duration // Smallest warning threshold already given
+}
+
+// NewTimeClock starts a clock at start. An allowance of 0 means the call has
+// no time limit.
+func NewTimeClock(start time.Time, allowed time.Duration) *TimeClock {
+	return &TimeClock{start: start, allowed: allowed}
+}
+
+// Limited reports whether the call has a time limit.
+func (c *TimeClock) Limited() bool {
+	return c.allowed > 0
+}
+
+// Adjust adds d to the time left; a negative d takes time away.
+func (c *TimeClock) Adjust(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credit += d
+}
+
+// Charged returns the time to count against the caller's daily allowance:
+// time online less any credit.
+func (c *TimeClock) Charged(now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return max(0, now.Sub(c.start)-c.credit)
+}
+
+// Left returns the time remaining at now, never below zero. It is zero for
+// unlimited calls; check Limited first.
+func (c *TimeClock) Left(now time.Time) time.Duration {
+	if !c.Limited() {
+		return 0
+	}
+	return max(0, c.Deadline().Sub(now))
+}
+
+// Deadline returns when the call's time runs out, or the zero time for an
+// unlimited call.
+func (c *TimeClock) Deadline() time.Time {
+	if !c.Limited() {
+		return time.Time{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.start.Add(c.allowed + c.credit)
+}
+
+// Expired reports whether a limited call has run out of time.
+func (c *TimeClock) Expired(now time.Time) bool {
+	return c.Limited() && !now.Before(c.Deadline())
+}
+
+// Warning returns the smallest threshold the time left has dropped to that
+// has not been warned about yet, and records it. Thresholds must be given
+// largest first. A credit that lifts the time left back above a threshold
+// lets it be warned about again.
+func (c *TimeClock) Warning(now time.Time, thresholds ...time.Duration) (time.Duration, bool) {
+	if !c.Limited() {
+		return 0, false
+	}
+	left := c.Left(now)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.warned > 0 && left > c.warned {
+		c.warned = 0
+	}
+	var due time.Duration
+	for _, t := range thresholds {
+		if left <= t && (c.warned == 0 || t < c.warned) {
+			due = t
+		}
+	}
+	if due == 0 {
+		return 0, false
+	}
+	c.warned = due
+	return due, true
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeClockLeftAndCredit(t *testing.T) {
+	start := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	c := NewTimeClock(start, 30*time.Minute)
+
+	if got := c.Left(start.Add(10 * time.Minute)); got != 20*time.Minute {
+		t.Errorf("Left = %v, want 20m", got)
+	}
+	c.Adjust(5 * time.Minute) // Upload credit
+	if got := c.Left(start.Add(10 * time.Minute)); got != 25*time.Minute {
+		t.Errorf("Left after credit = %v, want 25m", got)
+	}
+	if got := c.Charged(start.Add(10 * time.Minute)); got != 5*time.Minute {
+		t.Errorf("Charged = %v, want 5m", got)
+	}
+	if c.Expired(start.Add(34 * time.Minute)) {
+		t.Error("expired before deadline")
+	}
+	if !c.Expired(start.Add(35 * time.Minute)) {
+		t.Error("not expired at deadline")
+	}
+	if got := c.Left(start.Add(40 * time.Minute)); got != 0 {
+		t.Errorf("Left past deadline = %v, want 0", got)
+	}
+}
+
+func TestTimeClockUnlimited(t *testing.T) {
+	start := time.Now()
+	c := NewTimeClock(start, 0)
+	if c.Limited() || c.Expired(start.Add(24*time.Hour)) || !c.Deadline().IsZero() {
+		t.Error("unlimited clock has a limit")
+	}
+	if got := c.Charged(start.Add(time.Hour)); got != time.Hour {
+		t.Errorf("Charged = %v, want 1h", got)
+	}
+	if _, ok := c.Warning(start.Add(time.Hour), 5*time.Minute, time.Minute); ok {
+		t.Error("warning on an unlimited call")
+	}
+}
+
+func TestTimeClockWarning(t *testing.T) {
+	start := time.Now()
+	c := NewTimeClock(start, 10*time.Minute)
+	thresholds := []time.Duration{5 * time.Minute, time.Minute}
+
+	if _, ok := c.Warning(start.Add(4*time.Minute), thresholds...); ok {
+		t.Error("warned with 6 minutes left")
+	}
+	if w, ok := c.Warning(start.Add(6*time.Minute), thresholds...); !ok || w != 5*time.Minute {
+		t.Errorf("Warning = %v, %v; want 5m", w, ok)
+	}
+	if _, ok := c.Warning(start.Add(7*time.Minute), thresholds...); ok {
+		t.Error("5 minute warning given twice")
+	}
+	// Skipping straight past both thresholds gives only the smaller one
+	if w, ok := c.Warning(start.Add(9*time.Minute+30*time.Second), thresholds...); !ok || w != time.Minute {
+		t.Errorf("Warning = %v, %v; want 1m", w, ok)
+	}
+
+	// A credit resets the warnings
+	c.Adjust(10 * time.Minute)
+	if _, ok := c.Warning(start.Add(10*time.Minute), thresholds...); ok {
+		t.Error("warned with 10 minutes left")
+	}
+	if w, ok := c.Warning(start.Add(16*time.Minute), thresholds...); !ok || w != 5*time.Minute {
+		t.Errorf("Warning after credit = %v, %v; want 5m", w, ok)
+	}
+}
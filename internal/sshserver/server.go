@@ -29,7 +29,10 @@ type Config struct {
 	SessionHandler             func(ssh.Session)
 	PasswordHandler            func(ctx ssh.Context, password string) bool
 	KeyboardInteractiveHandler func(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool
-	Version                    string // SSH server banner version (default: "Vision3")
+	PublicKeyHandler           func(ctx ssh.Context, key ssh.PublicKey) bool
+	SubsystemHandlers          map[string]ssh.SubsystemHandler // e.g. "sftp"; other subsystems are refused
+	Version                    string                          // SSH server banner version (default: "Vision3")
+	AllowConn                  func(remote net.Addr) bool      // if set, asked before the handshake; false closes the connection
 }
 
 // Server wraps a gliderlabs/ssh server.
@@ -65,6 +68,20 @@ func NewServer(cfg Config) (*Server, error) {
 	if cfg.KeyboardInteractiveHandler != nil {
 		srv.KeyboardInteractiveHandler = cfg.KeyboardInteractiveHandler
 	}
+	if cfg.PublicKeyHandler != nil {
+		srv.PublicKeyHandler = cfg.PublicKeyHandler
+	}
+	if len(cfg.SubsystemHandlers) > 0 {
+		srv.SubsystemHandlers = cfg.SubsystemHandlers
+	}
+	if allow := cfg.AllowConn; allow != nil {
+		srv.ConnCallback = func(ctx ssh.Context, conn net.Conn) net.Conn {
+			if !allow(conn.RemoteAddr()) {
+				return nil // gliderlabs closes the connection
+			}
+			return conn
+		}
+	}
 
 	// Configure algorithm suites via ServerConfigCallback.
 	// When LegacySSHAlgorithms is enabled, include older algorithms
@@ -94,6 +94,7 @@ func StringEntries() []StringEntry {
 		{Label: "Show Rumors?", Key: "wantRumors", Description: "Displayed when asking if user wished to see rumors"},
 		{Label: "Unknown User, Apply?", Key: "userNotFound", Description: "Asks unknown users if they wish to apply.."},
 		{Label: "Infoforms Prompt", Key: "infoformPrompt", Description: "Infoforms Prompt (V)iew, (Q)uit or (#)"},
+		{Label: "Feedback Reader Prompt", Key: "feedbackPrompt", Description: "Feedback reader (A)gain, (R)eply, (F)orward, (D)elete, (I)nfoform, (L)ist, (Q)uit or (#)"},
 		{Label: "NewUser Infoforms Prompt", Key: "newInfoFormPrompt", Description: "NewUser Infoforms Prompt, (Q)uit or (#)"},
 		{Label: "View which Infoform", Key: "viewWhichForm", Description: "Displayed when a user (V)iews his infoform"},
 		{Label: "Checking Local Ratio", Key: "checkingPhoneNum", Description: "Displayed when checking local ratio"},
@@ -247,8 +248,10 @@ func StringEntries() []StringEntry {
 		// New User (V3)
 		{Label: "New Users Closed", Key: "newUsersClosedStr", Description: "Shown when new user registration is disabled"},
 		{Label: "New User: Location", Key: "newUserLocationPrompt", Description: "Prompt for new user's location/group"},
-		{Label: "New User: PW Too Short", Key: "newUserPasswordTooShort", Description: "Shown when new user password is too short"},
+		{Label: "New User: PW Too Short", Key: "newUserPasswordTooShort", Description: "Shown when a new password is too short (|ML = minimum length)"},
 		{Label: "New User: PW Mismatch", Key: "newUserPasswordMismatch", Description: "Shown when password confirmation doesn't match"},
+		{Label: "Password Reused", Key: "passwordReused", Description: "Shown when a new password is one of the user's recent passwords"},
+		{Label: "Password Change Required", Key: "passwordChangeRequired", Description: "Shown at login when the user must choose a new password"},
 		{Label: "New User: Invalid Name", Key: "newUserInvalidRealName", Description: "Shown when real name format is invalid"},
 		{Label: "New User: Too Many Try", Key: "newUserTooManyAttempts", Description: "Shown after too many invalid attempts"},
 		{Label: "New User: Created", Key: "newUserAccountCreated", Description: "Shown after account creation (pending validation)"},
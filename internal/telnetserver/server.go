@@ -15,6 +15,15 @@ type Config struct {
 	Port           int
 	Host           string
 	SessionHandler SessionHandler
+
+	// AllowConn, if set, is asked about each new connection before telnet
+	// negotiation. Returning false closes the connection at once.
+	AllowConn func(remote net.Addr) bool
+
+	// CheckNegotiation, if set, is told how many bytes of non-telnet
+	// garbage the client sent during negotiation. Returning false closes
+	// the connection.
+	CheckNegotiation func(remote net.Addr, garbage int) bool
 }
 
 // Server is a telnet server that listens for TCP connections
@@ -75,6 +84,10 @@ func (s *Server) ListenAndServe() error {
 // handleConnection processes a new telnet connection.
 func (s *Server) handleConnection(conn net.Conn) {
 	remoteAddr := conn.RemoteAddr().String()
+	if s.config.AllowConn != nil && !s.config.AllowConn(conn.RemoteAddr()) {
+		conn.Close()
+		return
+	}
 	log.Printf("INFO: Telnet connection from %s", remoteAddr)
 
 	defer func() {
@@ -93,6 +106,9 @@ func (s *Server) handleConnection(conn net.Conn) {
 		log.Printf("ERROR: Telnet negotiation failed for %s: %v", remoteAddr, err)
 		return
 	}
+	if s.config.CheckNegotiation != nil && !s.config.CheckNegotiation(conn.RemoteAddr(), tc.NegotiationGarbage()) {
+		return
+	}
 
 	// Detect actual usable terminal size via ANSI CPR (primary), NAWS (fallback), defaults
 	// CPR detects status bars (e.g., SyncTerm reports 25 via NAWS but only 24 rows usable)
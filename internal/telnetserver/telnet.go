@@ -69,6 +69,10 @@ type TelnetConn struct {
 	sbOption byte   // option byte for current subnegotiation
 	sbData   []byte // accumulated subnegotiation data
 
+	// Bytes seen during negotiation that are not telnet protocol, such as an
+	// HTTP request or an SSH banner sent to the telnet port.
+	garbage int
+
 	closed int32 // atomic flag
 
 	// TERM_TYPE negotiation (RFC 1091)
@@ -172,8 +176,9 @@ func (tc *TelnetConn) processNegotiationBytes(data []byte) {
 		case stateData:
 			if b == IAC {
 				tc.state = stateIAC
+			} else if b != '\r' && b != '\n' && b != 0 {
+				tc.garbage++ // Ignore non-IAC data during negotiation drain; line endings from an early Enter don't count
 			}
-			// Ignore non-IAC data during negotiation drain
 
 		case stateIAC:
 			switch b {
@@ -192,6 +197,9 @@ func (tc *TelnetConn) processNegotiationBytes(data []byte) {
 			case SB:
 				tc.state = stateSB
 			default:
+				if b < SE {
+					tc.garbage++ // Not a telnet command
+				}
 				tc.state = stateData // Unknown command, consume
 			}
 
@@ -227,12 +235,19 @@ func (tc *TelnetConn) processNegotiationBytes(data []byte) {
 				tc.state = stateSBData
 			} else {
 				// Unexpected, treat as end of subnegotiation
+				tc.garbage++
 				tc.state = stateData
 			}
 		}
 	}
 }
 
+// NegotiationGarbage returns how many bytes the client sent during
+// Negotiate that were not valid telnet protocol.
+func (tc *TelnetConn) NegotiationGarbage() int {
+	return tc.garbage
+}
+
 // handleSubnegotiation processes a completed subnegotiation.
 func (tc *TelnetConn) handleSubnegotiation() {
 	switch tc.sbOption {
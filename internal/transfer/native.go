@@ -0,0 +1,217 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+)
+
+// Built-in protocol engines, selected with ProtocolConfig.Builtin. They run
+// in-process over the session instead of launching an external program.
+const (
+	BuiltinZmodem   = "zmodem"    // ZMODEM-8k with CRC-32, batch and crash recovery
+	BuiltinXmodem   = "xmodem"    // XMODEM, 128-byte blocks, CRC-16 or checksum
+	BuiltinXmodem1K = "xmodem-1k" // XMODEM-1K, 1024-byte blocks
+	BuiltinYmodem   = "ymodem"    // YMODEM batch with file name and size
+	BuiltinYmodemG  = "ymodem-g"  // Streaming YMODEM for error-free links
+)
+
+// ErrRemoteCancelled is returned by the built-in protocols when the remote
+// end aborts the transfer with a CAN sequence.
+var ErrRemoteCancelled = errors.New("transfer cancelled by remote")
+
+// errTimeout is returned when the remote end goes quiet for too long.
+var errTimeout = errors.New("timed out waiting for remote")
+
+// byteStream gives the protocol engines timed, byte-at-a-time reads from a
+// blocking reader. A goroutine copies chunks from the reader into a channel
+// so a read can give up after a timeout without losing data.
+type byteStream struct {
+	w      io.Writer
+	chunks chan []byte
+	buf    []byte
+	err    error // Read error; valid once chunks is closed
+	stop   chan struct{}
+	exited chan struct{}
+}
+
+func newByteStream(r io.Reader, w io.Writer) *byteStream {
+	st := &byteStream{
+		w:      w,
+		chunks: make(chan []byte, 64),
+		stop:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go st.readLoop(r)
+	return st
+}
+
+func (st *byteStream) readLoop(r io.Reader) {
+	defer close(st.exited)
+	for {
+		b := make([]byte, 4096)
+		n, err := r.Read(b)
+		if n > 0 {
+			select {
+			case st.chunks <- b[:n]:
+			case <-st.stop:
+				return
+			}
+		}
+		if err != nil {
+			st.err = err
+			close(st.chunks)
+			return
+		}
+	}
+}
+
+// readByte returns the next byte, waiting at most timeout for it.
+func (st *byteStream) readByte(ctx context.Context, timeout time.Duration) (byte, error) {
+	if len(st.buf) == 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case chunk, ok := <-st.chunks:
+			if !ok {
+				return 0, st.err
+			}
+			st.buf = chunk
+		case <-timer.C:
+			return 0, errTimeout
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	c := st.buf[0]
+	st.buf = st.buf[1:]
+	return c, nil
+}
+
+// buffered reports whether a byte can be read without waiting.
+func (st *byteStream) buffered() bool {
+	if len(st.buf) > 0 {
+		return true
+	}
+	select {
+	case chunk, ok := <-st.chunks:
+		if !ok {
+			return false
+		}
+		st.buf = chunk
+		return true
+	default:
+		return false
+	}
+}
+
+// unread pushes c back so the next readByte returns it.
+func (st *byteStream) unread(c byte) {
+	st.buf = append([]byte{c}, st.buf...)
+}
+
+func (st *byteStream) write(p []byte) error {
+	_, err := st.w.Write(p)
+	return err
+}
+
+// drain discards input until the remote end has been quiet for quiet, so
+// trailing protocol bytes don't reach the next prompt. It gives up after
+// four times quiet regardless.
+func (st *byteStream) drain(quiet time.Duration) {
+	end := time.Now().Add(4 * quiet)
+	n := 0
+	for time.Now().Before(end) {
+		if _, err := st.readByte(context.Background(), quiet); err != nil {
+			break
+		}
+		n++
+	}
+	if n > 0 {
+		log.Printf("DEBUG: Drained %d leftover bytes from session after transfer", n)
+	}
+}
+
+// close stops the reader goroutine once its pending Read returns.
+func (st *byteStream) close() {
+	close(st.stop)
+}
+
+// runNativeTransfer runs a built-in protocol over the session. Writes use
+// RawWrite when available so binary frames skip CRLF conversion. A read
+// interrupt is registered before the first Read so the reader goroutine can
+// be released afterwards without stealing the user's next keypress.
+func runNativeTransfer(ctx context.Context, s ssh.Session, fn func(*byteStream) error) error {
+	w := io.Writer(s)
+	if rw, ok := s.(rawBinaryWriter); ok {
+		w = writeFunc(rw.RawWrite)
+	}
+	ri, canInterrupt := s.(readInterrupter)
+	interrupt := make(chan struct{})
+	if canInterrupt {
+		ri.SetReadInterrupt(interrupt)
+	}
+
+	st := newByteStream(s, w)
+	err := fn(st)
+
+	st.drain(500 * time.Millisecond)
+	st.close()
+	close(interrupt)
+	select {
+	case <-st.exited:
+	case <-time.After(2 * time.Second):
+		log.Printf("WARN: transfer reader did not stop within 2s, proceeding")
+	}
+	if canInterrupt {
+		ri.SetReadInterrupt(nil)
+	}
+	return err
+}
+
+// sendBuiltin runs the built-in engine named by p.Builtin to send files.
+func (p *ProtocolConfig) sendBuiltin(ctx context.Context, s ssh.Session, filePaths []string) error {
+	switch strings.ToLower(p.Builtin) {
+	case BuiltinZmodem:
+		log.Printf("INFO: Protocol %q send (built-in %s): %v", p.Name, p.Builtin, filePaths)
+		return runNativeTransfer(ctx, s, func(st *byteStream) error {
+			return zmodemSend(ctx, st, filePaths)
+		})
+	case BuiltinXmodem, BuiltinXmodem1K, BuiltinYmodem, BuiltinYmodemG:
+		variant := strings.ToLower(p.Builtin)
+		log.Printf("INFO: Protocol %q send (built-in %s): %v", p.Name, variant, filePaths)
+		return runNativeTransfer(ctx, s, func(st *byteStream) error {
+			return xmodemSend(ctx, st, variant, filePaths)
+		})
+	}
+	return fmt.Errorf("unknown built-in protocol %q for %q", p.Builtin, p.Name)
+}
+
+// receiveBuiltin runs the built-in engine named by p.Builtin to receive
+// files into targetDir.
+func (p *ProtocolConfig) receiveBuiltin(ctx context.Context, s ssh.Session, targetDir string) error {
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create target directory %q: %w", targetDir, err)
+	}
+	switch strings.ToLower(p.Builtin) {
+	case BuiltinZmodem:
+		log.Printf("INFO: Protocol %q receive in %s (built-in %s)", p.Name, targetDir, p.Builtin)
+		return runNativeTransfer(ctx, s, func(st *byteStream) error {
+			return zmodemReceive(ctx, st, targetDir)
+		})
+	case BuiltinXmodem, BuiltinXmodem1K, BuiltinYmodem, BuiltinYmodemG:
+		variant := strings.ToLower(p.Builtin)
+		log.Printf("INFO: Protocol %q receive in %s (built-in %s)", p.Name, targetDir, variant)
+		return runNativeTransfer(ctx, s, func(st *byteStream) error {
+			return xmodemReceive(ctx, st, variant, targetDir)
+		})
+	}
+	return fmt.Errorf("unknown built-in protocol %q for %q", p.Builtin, p.Name)
+}
@@ -0,0 +1,41 @@
+package transfer
+
+import "context"
+
+// Progress describes the state of one file in a built-in protocol transfer.
+// A callback registered with WithProgress receives one report when a file
+// starts, further reports as data moves, and a final report with Done or
+// Skipped set.
+type Progress struct {
+	File    string // Base name of the file
+	Path    string // Local path: the source for sends, the destination for receives
+	Bytes   int64  // Bytes transferred so far, including any resumed offset
+	Size    int64  // Total file size, or -1 when the sender did not say
+	Resumed int64  // Offset the transfer started from after a crash recovery
+	Done    bool   // The file finished successfully
+	Skipped bool   // The remote end declined the file
+}
+
+// ProgressFunc receives progress reports. It is called on the goroutine
+// running the transfer, so it must not block.
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress returns a context that carries fn to ExecuteSend,
+// ExecuteReceive and the native protocol functions. External programs
+// cannot report progress, so fn is only called for built-in protocols.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressFrom returns the callback stored in ctx, or a no-op.
+func progressFrom(ctx context.Context) ProgressFunc {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		return fn
+	}
+	return func(Progress) {}
+}
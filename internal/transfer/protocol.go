@@ -29,7 +29,9 @@ const (
 )
 
 // ProtocolConfig defines a user-visible file transfer protocol.
-// The send/receive commands are external programs (e.g., lrzsz, sexyz).
+// The send/receive commands are external programs (e.g., lrzsz, sexyz),
+// unless Builtin names one of the in-process engines (e.g. "zmodem"), in
+// which case the command fields are ignored.
 //
 // Argument placeholders:
 //
@@ -40,25 +42,28 @@ const (
 //
 // If {filePath} is absent from send_args, file paths are appended at the end.
 type ProtocolConfig struct {
-	Key            string   `json:"key"`             // Selection key shown to users (e.g. "Z", "ZST")
-	Name           string   `json:"name"`            // Display name shown to users
-	Description    string   `json:"description"`     // Short description for help text
-	SendCmd        string   `json:"send_cmd"`        // Executable for sending (download to user)
-	SendArgs       []string `json:"send_args"`       // Arguments for send command
-	RecvCmd        string   `json:"recv_cmd"`        // Executable for receiving (upload from user)
-	RecvArgs       []string `json:"recv_args"`       // Arguments for receive command
-	BatchSend      bool     `json:"batch_send"`      // True if the protocol supports multi-file batch sends
-	UsePTY         bool     `json:"use_pty"`         // True if the command requires a PTY
-	Default        bool     `json:"default"`         // True if this is the default protocol when none is selected
-	ConnectionType string   `json:"connection_type"` // "" = any, "ssh" = SSH only, "telnet" = telnet only
+	Key            string   `json:"key"`               // Selection key shown to users (e.g. "Z", "ZST")
+	Name           string   `json:"name"`              // Display name shown to users
+	Description    string   `json:"description"`       // Short description for help text
+	SendCmd        string   `json:"send_cmd"`          // Executable for sending (download to user)
+	SendArgs       []string `json:"send_args"`         // Arguments for send command
+	RecvCmd        string   `json:"recv_cmd"`          // Executable for receiving (upload from user)
+	RecvArgs       []string `json:"recv_args"`         // Arguments for receive command
+	BatchSend      bool     `json:"batch_send"`        // True if the protocol supports multi-file batch sends
+	UsePTY         bool     `json:"use_pty"`           // True if the command requires a PTY
+	Default        bool     `json:"default"`           // True if this is the default protocol when none is selected
+	ConnectionType string   `json:"connection_type"`   // "" = any, "ssh" = SSH only, "telnet" = telnet only
+	Builtin        string   `json:"builtin,omitempty"` // Built-in engine to use instead of the commands (e.g. "zmodem")
 }
 
 // defaultProtocols returns built-in defaults.
 func defaultProtocols() []ProtocolConfig {
 	return []ProtocolConfig{
-		{Key: "Z", Name: "Zmodem", Description: "Zmodem (lrzsz)", SendCmd: "sz", SendArgs: []string{"-b", "-e"}, RecvCmd: "rz", RecvArgs: []string{"-b", "-r"}, BatchSend: true, UsePTY: false, Default: true},
-		{Key: "Y", Name: "Ymodem", Description: "Ymodem batch transfer", SendCmd: "sb", SendArgs: []string{"{filePath}"}, RecvCmd: "rb", RecvArgs: nil, BatchSend: true, UsePTY: false},
-		{Key: "X", Name: "Xmodem", Description: "Xmodem single-file transfer", SendCmd: "sx", SendArgs: []string{"{filePath}"}, RecvCmd: "rx", RecvArgs: nil, BatchSend: false, UsePTY: false},
+		{Key: "Z", Name: "Zmodem", Description: "ZModem-8k batch transfer (built-in)", BatchSend: true, Default: true, Builtin: BuiltinZmodem},
+		{Key: "Y", Name: "Ymodem", Description: "Ymodem batch transfer (built-in)", BatchSend: true, Builtin: BuiltinYmodem},
+		{Key: "G", Name: "Ymodem-G", Description: "Streaming Ymodem for error-free links (built-in)", BatchSend: true, Builtin: BuiltinYmodemG},
+		{Key: "1", Name: "Xmodem-1K", Description: "Xmodem-1K single-file transfer (built-in)", Builtin: BuiltinXmodem1K},
+		{Key: "X", Name: "Xmodem", Description: "Xmodem single-file transfer (built-in)", Builtin: BuiltinXmodem},
 	}
 }
 
@@ -105,6 +110,25 @@ func DefaultProtocol(protocols []ProtocolConfig) (ProtocolConfig, bool) {
 	return protocols[0], true
 }
 
+// NeedsReceiveName reports whether the protocol leaves the uploaded file
+// unnamed, so the caller has to ask for a name and pass it with
+// WithReceiveName. This is true of the built-in XMODEM variants.
+func (p *ProtocolConfig) NeedsReceiveName() bool {
+	switch strings.ToLower(p.Builtin) {
+	case BuiltinXmodem, BuiltinXmodem1K:
+		return true
+	}
+	return false
+}
+
+// CanResume reports whether a receive continues a partial file already in
+// the target directory instead of starting it over. Only the built-in
+// ZMODEM engine's crash recovery does; external receivers are left alone
+// because their flags decide what happens to an existing file.
+func (p *ProtocolConfig) CanResume() bool {
+	return strings.EqualFold(p.Builtin, BuiltinZmodem)
+}
+
 // ExecuteSend runs this protocol's send command to transfer files to the user.
 // filePaths must be absolute paths to the files being sent.
 // ctx controls cancellation and timeout; when ctx.Done() fires, the transfer is aborted.
@@ -122,6 +146,12 @@ func (p *ProtocolConfig) ExecuteSend(ctx context.Context, s ssh.Session, filePat
 			return fmt.Errorf("send path must be absolute, got %q", fp)
 		}
 	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p.Builtin != "" {
+		return p.sendBuiltin(ctx, s, filePaths)
+	}
 
 	cmdPath, err := exec.LookPath(p.SendCmd)
 	if err != nil {
@@ -139,9 +169,6 @@ func (p *ProtocolConfig) ExecuteSend(ctx context.Context, s ssh.Session, filePat
 	if listFile != "" {
 		defer os.Remove(listFile)
 	}
-	if ctx == nil {
-		ctx = context.Background()
-	}
 	cmd := exec.CommandContext(ctx, cmdPath, args...)
 
 	log.Printf("INFO: Protocol %q send: %s %v (pty=%v)", p.Name, cmdPath, args, p.UsePTY)
@@ -161,6 +188,12 @@ func (p *ProtocolConfig) ExecuteReceive(ctx context.Context, s ssh.Session, targ
 	if !filepath.IsAbs(targetDir) {
 		return fmt.Errorf("target directory must be absolute, got %q", targetDir)
 	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p.Builtin != "" {
+		return p.receiveBuiltin(ctx, s, targetDir)
+	}
 
 	cmdPath, err := exec.LookPath(p.RecvCmd)
 	if err != nil {
@@ -178,9 +211,6 @@ func (p *ProtocolConfig) ExecuteReceive(ctx context.Context, s ssh.Session, targ
 	if listFile != "" {
 		defer os.Remove(listFile)
 	}
-	if ctx == nil {
-		ctx = context.Background()
-	}
 	cmd := exec.CommandContext(ctx, cmdPath, args...)
 	cmd.Dir = targetDir
 
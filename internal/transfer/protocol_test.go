@@ -142,24 +142,22 @@ func TestLoadProtocols_missing_file_returns_defaults(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error for missing file, got: %v", err)
 	}
-	if len(loaded) != 3 {
-		t.Fatalf("expected 3 built-in defaults (Z/Y/X), got %d", len(loaded))
+	if len(loaded) != 5 {
+		t.Fatalf("expected 5 built-in defaults (Z/Y/G/1/X), got %d", len(loaded))
 	}
 	def, ok := DefaultProtocol(loaded)
-	if !ok || def.SendCmd == "" {
-		t.Errorf("built-in default has no SendCmd: %+v", def)
+	if !ok || (def.SendCmd == "" && def.Builtin == "") {
+		t.Errorf("built-in default has no SendCmd or Builtin: %+v", def)
 	}
 	if def.Key != "Z" {
 		t.Errorf("expected default key Z, got %q", def.Key)
 	}
-	// Verify YMODEM and XMODEM are present
-	_, yFound := FindProtocol(loaded, "Y")
-	if !yFound {
-		t.Error("expected Ymodem protocol in defaults")
-	}
-	_, xFound := FindProtocol(loaded, "X")
-	if !xFound {
-		t.Error("expected Xmodem protocol in defaults")
+	// Verify the XMODEM family is present and built in
+	for key, builtin := range map[string]string{"Y": BuiltinYmodem, "G": BuiltinYmodemG, "1": BuiltinXmodem1K, "X": BuiltinXmodem} {
+		p, found := FindProtocol(loaded, key)
+		if !found || p.Builtin != builtin {
+			t.Errorf("expected protocol %s with builtin %q in defaults, got %+v", key, builtin, p)
+		}
 	}
 }
 
@@ -0,0 +1,644 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XMODEM/YMODEM control characters.
+const (
+	xSOH = 0x01 // 128-byte block
+	xSTX = 0x02 // 1024-byte block
+	xEOT = 0x04
+	xACK = 0x06
+	xNAK = 0x15
+	xCAN = 0x18
+	xSUB = 0x1a // Padding for the last block
+)
+
+const (
+	xDefaultTimeout = 10 * time.Second
+	xMaxErrors      = 10
+)
+
+var errXBlock = errors.New("xmodem: bad block")
+
+// xmodemVariant describes one member of the XMODEM family.
+type xmodemVariant struct {
+	blockSize int  // Preferred block size: 128 or 1024
+	batch     bool // YMODEM: block 0 carries the name and size, several files per session
+	streaming bool // YMODEM-G: no per-block ACKs, any error aborts
+}
+
+// xmodemVariants maps the Builtin names to their variants.
+var xmodemVariants = map[string]xmodemVariant{
+	BuiltinXmodem:   {blockSize: 128},
+	BuiltinXmodem1K: {blockSize: 1024},
+	BuiltinYmodem:   {blockSize: 1024, batch: true},
+	BuiltinYmodemG:  {blockSize: 1024, batch: true, streaming: true},
+}
+
+type receiveNameKey struct{}
+
+// WithReceiveName returns a context that names the file written by an
+// XMODEM or XMODEM-1K receive. Those protocols don't send a filename, so
+// without one the file is saved as UPLOAD.BIN.
+func WithReceiveName(ctx context.Context, name string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, receiveNameKey{}, name)
+}
+
+// XmodemSend sends files over rw with the built-in XMODEM family engine.
+// variant is one of BuiltinXmodem, BuiltinXmodem1K, BuiltinYmodem or
+// BuiltinYmodemG; only the YMODEM variants accept more than one file.
+func XmodemSend(ctx context.Context, rw io.ReadWriter, variant string, filePaths ...string) error {
+	st := newByteStream(rw, rw)
+	defer st.close()
+	return xmodemSend(ctx, st, variant, filePaths)
+}
+
+// XmodemReceive receives files into targetDir with the built-in XMODEM
+// family engine. See XmodemSend for the variants.
+func XmodemReceive(ctx context.Context, rw io.ReadWriter, variant, targetDir string) error {
+	st := newByteStream(rw, rw)
+	defer st.close()
+	return xmodemReceive(ctx, st, variant, targetDir)
+}
+
+// xsession holds the state for one side of an XMODEM family transfer.
+type xsession struct {
+	ctx      context.Context
+	st       *byteStream
+	name     string // Variant name, for logging
+	v        xmodemVariant
+	crc      bool // CRC-16 rather than the 8-bit checksum
+	timeout  time.Duration
+	progress ProgressFunc
+}
+
+func newXSession(ctx context.Context, st *byteStream, variant string) (*xsession, error) {
+	v, ok := xmodemVariants[variant]
+	if !ok {
+		return nil, fmt.Errorf("unknown xmodem variant %q", variant)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &xsession{ctx: ctx, st: st, name: variant, v: v, crc: true, timeout: xDefaultTimeout, progress: progressFrom(ctx)}, nil
+}
+
+func (x *xsession) readByte(timeout time.Duration) (byte, error) {
+	return x.st.readByte(x.ctx, timeout)
+}
+
+// readCancel confirms a CAN: two in a row cancel the transfer.
+func (x *xsession) readCancel() bool {
+	c, err := x.readByte(time.Second)
+	return err == nil && c == xCAN
+}
+
+// abort tells the remote end to give up.
+func (x *xsession) abort() {
+	_ = x.st.write(append(bytes.Repeat([]byte{xCAN}, 8), bytes.Repeat([]byte{0x08}, 8)...))
+}
+
+// purge discards input until the line has been quiet for a second, so a
+// NAK lands after the remote end has finished sending the bad block.
+func (x *xsession) purge() {
+	for {
+		if _, err := x.readByte(time.Second); err != nil {
+			return
+		}
+	}
+}
+
+// packet builds one block. data must already be padded to 128 or 1024.
+func (x *xsession) packet(num byte, data []byte) []byte {
+	head := byte(xSOH)
+	if len(data) == 1024 {
+		head = xSTX
+	}
+	pkt := make([]byte, 0, len(data)+5)
+	pkt = append(pkt, head, num, ^num)
+	pkt = append(pkt, data...)
+	if x.crc {
+		crc := crc16Update(0, data)
+		return append(pkt, byte(crc>>8), byte(crc))
+	}
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return append(pkt, sum)
+}
+
+// readPacket reads the rest of a block whose header byte was first.
+func (x *xsession) readPacket(first byte) (byte, []byte, error) {
+	size := 128
+	if first == xSTX {
+		size = 1024
+	}
+	n := 2 + size + 1
+	if x.crc {
+		n++
+	}
+	buf := make([]byte, n)
+	for i := range buf {
+		c, err := x.readByte(time.Second)
+		if err != nil {
+			if errors.Is(err, errTimeout) {
+				return 0, nil, errXBlock
+			}
+			return 0, nil, err
+		}
+		buf[i] = c
+	}
+	if buf[0] != ^buf[1] {
+		return 0, nil, errXBlock
+	}
+	data := buf[2 : 2+size]
+	if x.crc {
+		if crc16Update(0, data) != uint16(buf[n-2])<<8|uint16(buf[n-1]) {
+			return 0, nil, errXBlock
+		}
+	} else {
+		var sum byte
+		for _, b := range data {
+			sum += b
+		}
+		if sum != buf[n-1] {
+			return 0, nil, errXBlock
+		}
+	}
+	return buf[0], data, nil
+}
+
+// --- Sending ---
+
+func xmodemSend(ctx context.Context, st *byteStream, variant string, filePaths []string) error {
+	x, err := newXSession(ctx, st, variant)
+	if err != nil {
+		return err
+	}
+	if len(filePaths) == 0 {
+		return fmt.Errorf("no files provided for %s send", variant)
+	}
+	if !x.v.batch && len(filePaths) > 1 {
+		return fmt.Errorf("%s sends one file at a time (got %d)", variant, len(filePaths))
+	}
+
+	err = x.sendAll(filePaths)
+	if err != nil && !errors.Is(err, ErrRemoteCancelled) {
+		x.abort()
+	}
+	return err
+}
+
+func (x *xsession) sendAll(filePaths []string) error {
+	for _, p := range filePaths {
+		if err := x.awaitStart(); err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		err = x.sendFile(f, p)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(p), err)
+		}
+	}
+	if x.v.batch {
+		// An empty block 0 ends the batch.
+		if err := x.awaitStart(); err != nil {
+			return err
+		}
+		if err := x.sendBlock(0, make([]byte, 128)); err != nil && !errors.Is(err, errTimeout) {
+			return err
+		}
+	}
+	return nil
+}
+
+// awaitStart waits for the receiver to ask for data: 'C' for CRC, NAK for
+// checksums, or 'G' for YMODEM-G streaming.
+func (x *xsession) awaitStart() error {
+	for tries := 0; tries < xMaxErrors; tries++ {
+		c, err := x.readByte(x.timeout)
+		if errors.Is(err, errTimeout) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		switch c {
+		case 'C':
+			x.crc = true
+			return nil
+		case 'G':
+			if x.v.batch {
+				x.crc, x.v.streaming = true, true
+				return nil
+			}
+		case xNAK:
+			x.crc = false
+			return nil
+		case xCAN:
+			if x.readCancel() {
+				return ErrRemoteCancelled
+			}
+		}
+	}
+	return fmt.Errorf("receiver never started: %w", errTimeout)
+}
+
+// sendBlock sends one block and, unless streaming, waits for its ACK.
+func (x *xsession) sendBlock(num byte, data []byte) error {
+	pkt := x.packet(num, data)
+	for tries := 0; tries < xMaxErrors; tries++ {
+		if err := x.st.write(pkt); err != nil {
+			return err
+		}
+		if x.v.streaming {
+			// Nothing comes back while streaming unless the receiver gives up.
+			if x.st.buffered() {
+				c, _ := x.readByte(0)
+				if c != xCAN {
+					x.st.unread(c)
+				} else if x.readCancel() {
+					return ErrRemoteCancelled
+				}
+			}
+			return nil
+		}
+		c, err := x.readByte(x.timeout)
+		if err != nil && !errors.Is(err, errTimeout) {
+			return err
+		}
+		switch {
+		case err != nil:
+			continue
+		case c == xACK:
+			return nil
+		case c == xCAN:
+			if x.readCancel() {
+				return ErrRemoteCancelled
+			}
+		}
+		// NAK, a stray 'C' or noise: send the block again.
+	}
+	return fmt.Errorf("block %d not acknowledged: %w", num, errTimeout)
+}
+
+func (x *xsession) sendFile(f *os.File, path string) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+	size := fi.Size()
+
+	if x.v.batch {
+		header := make([]byte, 128)
+		info := fmt.Sprintf("%s\x00%d %o %o", name, size, fi.ModTime().Unix(), 0644)
+		if len(info) > len(header) {
+			header = make([]byte, 1024)
+		}
+		copy(header, info)
+		if err := x.sendBlock(0, header); err != nil {
+			return err
+		}
+		// The receiver asks again before the data starts.
+		if err := x.awaitStart(); err != nil {
+			return err
+		}
+	}
+
+	x.progress(Progress{File: name, Path: path, Size: size})
+	buf := make([]byte, x.v.blockSize)
+	num := byte(1)
+	var sent int64
+	for {
+		n, rerr := io.ReadFull(f, buf)
+		if n > 0 {
+			blockLen := x.v.blockSize
+			if blockLen == 1024 && n <= 128 {
+				blockLen = 128
+			}
+			block := make([]byte, blockLen)
+			copy(block, buf[:n])
+			for i := n; i < blockLen; i++ {
+				block[i] = xSUB
+			}
+			if err := x.sendBlock(num, block); err != nil {
+				return err
+			}
+			num++
+			sent += int64(n)
+			x.progress(Progress{File: name, Path: path, Bytes: sent, Size: size})
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+
+	for tries := 0; tries < xMaxErrors; tries++ {
+		if err := x.st.write([]byte{xEOT}); err != nil {
+			return err
+		}
+		c, err := x.readByte(x.timeout)
+		if err != nil && !errors.Is(err, errTimeout) {
+			return err
+		}
+		if err == nil && c == xACK {
+			x.progress(Progress{File: name, Path: path, Bytes: sent, Size: size, Done: true})
+			return nil
+		}
+		// YMODEM receivers NAK the first EOT to be sure it wasn't noise.
+	}
+	return fmt.Errorf("EOT not acknowledged: %w", errTimeout)
+}
+
+// --- Receiving ---
+
+func xmodemReceive(ctx context.Context, st *byteStream, variant, targetDir string) error {
+	x, err := newXSession(ctx, st, variant)
+	if err != nil {
+		return err
+	}
+	if x.v.batch {
+		err = x.receiveBatch(targetDir)
+	} else {
+		name, _ := x.ctx.Value(receiveNameKey{}).(string)
+		if name = zSafeName(name); name == "" {
+			name = "UPLOAD.BIN"
+		}
+		err = x.receiveFile(filepath.Join(targetDir, name), -1, time.Time{})
+	}
+	if err != nil && !errors.Is(err, ErrRemoteCancelled) {
+		x.abort()
+	}
+	return err
+}
+
+// startChar is what the receiver sends to ask for data.
+func (x *xsession) startChar() byte {
+	switch {
+	case x.v.streaming:
+		return 'G'
+	case x.crc:
+		return 'C'
+	}
+	return xNAK
+}
+
+func (x *xsession) receiveBatch(targetDir string) error {
+	for {
+		num, data, err := x.receiveFirstBlock()
+		if err != nil {
+			return err
+		}
+		if num != 0 {
+			return fmt.Errorf("expected YMODEM header block, got block %d", num)
+		}
+		if data[0] == 0 {
+			// Empty header: end of batch.
+			if !x.v.streaming {
+				_ = x.st.write([]byte{xACK})
+			}
+			return nil
+		}
+		if !x.v.streaming {
+			if err := x.st.write([]byte{xACK}); err != nil {
+				return err
+			}
+		}
+
+		parts := bytes.SplitN(data, []byte{0}, 2)
+		name := zSafeName(string(parts[0]))
+		if name == "" {
+			return fmt.Errorf("unusable filename %q", parts[0])
+		}
+		size := int64(-1)
+		var modTime time.Time
+		if len(parts) > 1 {
+			fields := strings.Fields(string(bytes.TrimRight(parts[1], "\x00")))
+			if len(fields) > 0 {
+				if n, err := strconv.ParseInt(fields[0], 10, 64); err == nil && n >= 0 {
+					size = n
+				}
+			}
+			if len(fields) > 1 {
+				if t, err := strconv.ParseInt(fields[1], 8, 64); err == nil && t > 0 {
+					modTime = time.Unix(t, 0)
+				}
+			}
+		}
+		if err := x.receiveFile(filepath.Join(targetDir, name), size, modTime); err != nil {
+			return err
+		}
+	}
+}
+
+// receiveFirstBlock asks for data until the first good block arrives. A
+// plain XMODEM receiver falls back to checksums if 'C' goes unanswered.
+func (x *xsession) receiveFirstBlock() (byte, []byte, error) {
+	for tries := 0; tries < xMaxErrors; tries++ {
+		if !x.v.batch && x.v.blockSize == 128 && tries == 3 {
+			x.crc = false
+		}
+		if err := x.st.write([]byte{x.startChar()}); err != nil {
+			return 0, nil, err
+		}
+		c, err := x.readByte(x.timeout)
+		if errors.Is(err, errTimeout) {
+			continue
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		switch c {
+		case xSOH, xSTX:
+			num, data, err := x.readPacket(c)
+			if err == nil {
+				return num, data, nil
+			}
+			if !errors.Is(err, errXBlock) {
+				return 0, nil, err
+			}
+			x.purge()
+		case xCAN:
+			if x.readCancel() {
+				return 0, nil, ErrRemoteCancelled
+			}
+		case xEOT:
+			// An empty XMODEM file.
+			_ = x.st.write([]byte{xACK})
+			return 0, nil, io.EOF
+		}
+	}
+	return 0, nil, fmt.Errorf("sender never started: %w", errTimeout)
+}
+
+// receiveFile writes blocks to path until EOT. With a known size (YMODEM)
+// the padding is cut off exactly; otherwise trailing SUB padding is
+// removed from the last block.
+func (x *xsession) receiveFile(path string, size int64, modTime time.Time) error {
+	name := filepath.Base(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	x.progress(Progress{File: name, Path: path, Size: size})
+
+	var written int64
+	var pending []byte // Last block, held back until we know it isn't padded
+	flush := func(last bool) error {
+		data := pending
+		pending = nil
+		if size >= 0 && written+int64(len(data)) > size {
+			data = data[:size-written]
+		} else if last && size < 0 {
+			data = bytes.TrimRight(data, "\x1a")
+		}
+		if _, err := f.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written += int64(len(data))
+		return nil
+	}
+	finish := func() error {
+		if err := flush(true); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if !modTime.IsZero() {
+			_ = os.Chtimes(path, modTime, modTime)
+		}
+		log.Printf("INFO: %s: received %s (%d bytes)", x.name, name, written)
+		x.progress(Progress{File: name, Path: path, Bytes: written, Size: size, Done: true})
+		return nil
+	}
+
+	expect := byte(1)
+	started := false
+	sawEOT := false
+	errs := 0
+	if x.v.batch {
+		// YMODEM asks again after the header block.
+		if err := x.st.write([]byte{x.startChar()}); err != nil {
+			return err
+		}
+		started = true
+	} else {
+		num, data, err := x.receiveFirstBlock()
+		if errors.Is(err, io.EOF) {
+			return finish()
+		}
+		if err != nil {
+			return err
+		}
+		if num != 1 {
+			return fmt.Errorf("expected block 1, got block %d", num)
+		}
+		pending = append([]byte(nil), data...)
+		expect = 2
+		if err := x.st.write([]byte{xACK}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		c, err := x.readByte(x.timeout)
+		if err != nil {
+			if !errors.Is(err, errTimeout) || x.v.streaming {
+				return err
+			}
+			if errs++; errs > xMaxErrors {
+				return fmt.Errorf("sender stopped responding: %w", err)
+			}
+			reply := byte(xNAK)
+			if started && expect == 1 {
+				reply = x.startChar()
+			}
+			if err := x.st.write([]byte{reply}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch c {
+		case xSOH, xSTX:
+			num, data, err := x.readPacket(c)
+			if err != nil {
+				if !errors.Is(err, errXBlock) || x.v.streaming {
+					return err
+				}
+				if errs++; errs > xMaxErrors {
+					return fmt.Errorf("too many bad blocks: %w", err)
+				}
+				x.purge()
+				if err := x.st.write([]byte{xNAK}); err != nil {
+					return err
+				}
+				continue
+			}
+			switch num {
+			case expect:
+				if pending != nil {
+					if err := flush(false); err != nil {
+						return err
+					}
+				}
+				pending = append([]byte(nil), data...)
+				expect++
+				errs = 0
+				x.progress(Progress{File: name, Path: path, Bytes: written + int64(len(pending)), Size: size})
+			case expect - 1:
+				// A repeat of a block whose ACK was lost.
+			default:
+				return fmt.Errorf("block %d out of sequence (expected %d)", num, expect)
+			}
+			if !x.v.streaming {
+				if err := x.st.write([]byte{xACK}); err != nil {
+					return err
+				}
+			}
+		case xEOT:
+			if x.v.batch && !x.v.streaming && !sawEOT {
+				// NAK the first EOT in case it was line noise.
+				sawEOT = true
+				if err := x.st.write([]byte{xNAK}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := x.st.write([]byte{xACK}); err != nil {
+				return err
+			}
+			return finish()
+		case xCAN:
+			if x.readCancel() {
+				return ErrRemoteCancelled
+			}
+		}
+	}
+}
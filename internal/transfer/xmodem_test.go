@@ -0,0 +1,214 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// runXmodemTransfer is runTransfer for the XMODEM family engines.
+func runXmodemTransfer(t *testing.T, variant string, senderOut func(io.Writer) io.Writer, dstDir string, files ...string) (sendErr, recvErr error, sent, received *progressLog) {
+	t.Helper()
+	a, b, closeLink := newLink()
+	defer closeLink()
+	if senderOut != nil {
+		a = duplex{a, senderOut(a)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	sent, received = &progressLog{}, &progressLog{}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- XmodemSend(WithProgress(ctx, sent.add), a, variant, files...)
+	}()
+	recvCtx := WithReceiveName(WithProgress(ctx, received.add), "UPLOAD.ZIP")
+	recvErr = XmodemReceive(recvCtx, b, variant, dstDir)
+	if recvErr != nil {
+		// Hang up so a streaming sender isn't left blocked on a write.
+		closeLink()
+	}
+	sendErr = <-done
+	return sendErr, recvErr, sent, received
+}
+
+func TestXmodem_SingleFile(t *testing.T) {
+	for _, variant := range []string{BuiltinXmodem, BuiltinXmodem1K} {
+		t.Run(variant, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			want := randomBytes(10000, 10)
+			want[len(want)-1] = 'x' // XMODEM can't tell trailing SUBs from padding
+			file := writeTestFile(t, src, "DATA.BIN", want)
+
+			sendErr, recvErr, sent, received := runXmodemTransfer(t, variant, nil, dst, file)
+			if sendErr != nil || recvErr != nil {
+				t.Fatalf("transfer failed: send=%v recv=%v", sendErr, recvErr)
+			}
+			got, err := os.ReadFile(filepath.Join(dst, "UPLOAD.ZIP"))
+			if err != nil {
+				t.Fatalf("file not saved under the receive name: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("received %d bytes, content mismatch (want %d)", len(got), len(want))
+			}
+			if p, ok := sent.final("DATA.BIN"); !ok || p.Bytes != int64(len(want)) {
+				t.Errorf("sender final progress = %+v", p)
+			}
+			if p, ok := received.final("UPLOAD.ZIP"); !ok || p.Bytes != int64(len(want)) {
+				t.Errorf("receiver final progress = %+v", p)
+			}
+		})
+	}
+}
+
+func TestXmodem_RejectsBatch(t *testing.T) {
+	src := t.TempDir()
+	a := writeTestFile(t, src, "A.TXT", []byte("a"))
+	b := writeTestFile(t, src, "B.TXT", []byte("b"))
+	if err := XmodemSend(context.Background(), duplex{bytes.NewReader(nil), io.Discard}, BuiltinXmodem, a, b); err == nil {
+		t.Fatal("expected XMODEM to refuse a batch")
+	}
+}
+
+func TestXmodem_ChecksumFallback(t *testing.T) {
+	a, b, closeLink := newLink()
+	defer closeLink()
+	want := []byte("checksum mode still works")
+	file := writeTestFile(t, t.TempDir(), "OLD.TXT", want)
+
+	done := make(chan error, 1)
+	go func() { done <- XmodemSend(context.Background(), a, BuiltinXmodem, file) }()
+
+	// Play an old receiver that only knows checksums and starts with NAK.
+	st := newByteStream(b, b)
+	defer st.close()
+	x, _ := newXSession(context.Background(), st, BuiltinXmodem)
+	x.crc = false
+	var got []byte
+	st.write([]byte{xNAK})
+	for {
+		c, err := x.readByte(5 * time.Second)
+		if err != nil {
+			t.Fatalf("reading from sender: %v", err)
+		}
+		if c == xEOT {
+			st.write([]byte{xACK})
+			break
+		}
+		if c != xSOH {
+			t.Fatalf("unexpected byte %#x from sender", c)
+		}
+		_, data, err := x.readPacket(c)
+		if err != nil {
+			t.Fatalf("bad checksum block: %v", err)
+		}
+		got = append(got, data...)
+		st.write([]byte{xACK})
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if got = bytes.TrimRight(got, "\x1a"); !bytes.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestYmodem_BatchTransfer(t *testing.T) {
+	for _, variant := range []string{BuiltinYmodem, BuiltinYmodemG} {
+		t.Run(variant, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			contents := map[string][]byte{
+				"RANDOM.BIN": randomBytes(50000, 11),
+				"EXACT.BIN":  randomBytes(4096, 12),
+				"SMALL.TXT":  []byte("short\x1a\x1a"),
+				"EMPTY.TXT":  {},
+			}
+			var files []string
+			for _, name := range []string{"RANDOM.BIN", "EXACT.BIN", "SMALL.TXT", "EMPTY.TXT"} {
+				files = append(files, writeTestFile(t, src, name, contents[name]))
+			}
+
+			sendErr, recvErr, sent, received := runXmodemTransfer(t, variant, nil, dst, files...)
+			if sendErr != nil || recvErr != nil {
+				t.Fatalf("transfer failed: send=%v recv=%v", sendErr, recvErr)
+			}
+			for name, want := range contents {
+				got, err := os.ReadFile(filepath.Join(dst, name))
+				if err != nil {
+					t.Errorf("%s not received: %v", name, err)
+					continue
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s: received %d bytes, content mismatch (want %d)", name, len(got), len(want))
+				}
+				for side, log := range map[string]*progressLog{"sender": sent, "receiver": received} {
+					if p, ok := log.final(name); !ok || p.Bytes != int64(len(want)) {
+						t.Errorf("%s: %s final progress = %+v, want Done with %d bytes", name, side, p, len(want))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestXmodem_RecoversFromLineNoise(t *testing.T) {
+	for _, variant := range []string{BuiltinXmodem, BuiltinXmodem1K, BuiltinYmodem} {
+		t.Run(variant, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			want := randomBytes(30000, 13)
+			want[len(want)-1] = 'x'
+			file := writeTestFile(t, src, "UPLOAD.ZIP", want)
+
+			corrupt := func(w io.Writer) io.Writer { return &corruptWriter{w: w, at: 20000} }
+			sendErr, recvErr, _, _ := runXmodemTransfer(t, variant, corrupt, dst, file)
+			if sendErr != nil || recvErr != nil {
+				t.Fatalf("transfer failed: send=%v recv=%v", sendErr, recvErr)
+			}
+			got, _ := os.ReadFile(filepath.Join(dst, "UPLOAD.ZIP"))
+			if !bytes.Equal(got, want) {
+				t.Fatalf("received %d bytes, content mismatch", len(got))
+			}
+		})
+	}
+}
+
+func TestYmodemG_AbortsOnLineNoise(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	file := writeTestFile(t, src, "NOISY.BIN", randomBytes(30000, 14))
+
+	corrupt := func(w io.Writer) io.Writer { return &corruptWriter{w: w, at: 20000} }
+	_, recvErr, _, received := runXmodemTransfer(t, BuiltinYmodemG, corrupt, dst, file)
+	if recvErr == nil {
+		t.Fatal("expected YMODEM-G to fail on a damaged block")
+	}
+	if _, ok := received.final("NOISY.BIN"); ok {
+		t.Error("damaged file was reported complete")
+	}
+}
+
+func TestXmodem_RemoteCancel(t *testing.T) {
+	a, b, closeLink := newLink()
+	defer closeLink()
+	file := writeTestFile(t, t.TempDir(), "A.TXT", []byte("hello"))
+
+	done := make(chan error, 1)
+	go func() { done <- XmodemSend(context.Background(), a, BuiltinYmodem, file) }()
+
+	go io.Copy(io.Discard, b)
+	b.Write([]byte{xCAN, xCAN})
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrRemoteCancelled) {
+			t.Fatalf("expected ErrRemoteCancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sender did not notice the cancel")
+	}
+}
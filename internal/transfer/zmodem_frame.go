@@ -0,0 +1,461 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+)
+
+// ZMODEM framing characters.
+const (
+	zPAD   = '*'  // Padding before a header
+	zDLE   = 0x18 // Escape character (same value as CAN)
+	zBIN   = 'A'  // Binary header, CRC-16
+	zHEX   = 'B'  // Hex header, CRC-16
+	zBIN32 = 'C'  // Binary header, CRC-32
+
+	zCRCE = 'h' // Subpacket ends the frame, no response expected
+	zCRCG = 'i' // Subpacket continues the frame, no response expected
+	zCRCQ = 'j' // Subpacket continues the frame, ZACK expected
+	zCRCW = 'k' // Subpacket ends the frame, ZACK expected
+	zRUB0 = 'l' // Escaped 0x7f
+	zRUB1 = 'm' // Escaped 0xff
+
+	xON  = 0x11
+	xOFF = 0x13
+)
+
+// ZMODEM frame types.
+const (
+	zRQINIT    = 0
+	zRINIT     = 1
+	zSINIT     = 2
+	zACK       = 3
+	zFILE      = 4
+	zSKIP      = 5
+	zNAK       = 6
+	zABORT     = 7
+	zFIN       = 8
+	zRPOS      = 9
+	zDATA      = 10
+	zEOF       = 11
+	zFERR      = 12
+	zCRC       = 13
+	zCHALLENGE = 14
+	zCOMPL     = 15
+	zCAN       = 16
+	zFREECNT   = 17
+	zCOMMAND   = 18
+	zSTDERR    = 19
+)
+
+// ZRINIT capability flags (ZF0).
+const (
+	canFDX  = 0x01 // Full duplex
+	canOVIO = 0x02 // Can receive data during disk I/O
+	canFC32 = 0x20 // Can use CRC-32
+	escCTL  = 0x40 // Wants all control characters escaped
+)
+
+// ZFILE conversion options (ZF0).
+const (
+	zCBIN   = 1 // Binary transfer
+	zCRESUM = 3 // Resume an interrupted transfer
+)
+
+const (
+	zDefaultTimeout = 10 * time.Second
+	zMaxBlock       = 8192 // ZMODEM-8k subpacket size
+	zMaxGarbage     = 16 * 1024
+)
+
+var (
+	errZCRC     = errors.New("zmodem: CRC error")
+	errZGarbage = errors.New("zmodem: no header found")
+	errZLong    = errors.New("zmodem: subpacket too long")
+	errZEscape  = errors.New("zmodem: bad escape sequence")
+)
+
+// zRecoverable reports whether err is line noise or a quiet spell that the
+// protocol recovers from by resending, rather than a dead connection.
+func zRecoverable(err error) bool {
+	return errors.Is(err, errTimeout) || errors.Is(err, errZCRC) || errors.Is(err, errZGarbage) ||
+		errors.Is(err, errZLong) || errors.Is(err, errZEscape)
+}
+
+// crc16Table is the CRC-16/XMODEM (CCITT, polynomial 0x1021) lookup table
+// shared by ZMODEM and XMODEM.
+var crc16Table = func() [256]uint16 {
+	var t [256]uint16
+	for i := range t {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}()
+
+func crc16Update(crc uint16, p []byte) uint16 {
+	for _, b := range p {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^b]
+	}
+	return crc
+}
+
+// zheader is a frame type plus its four data bytes. The bytes hold either a
+// little-endian file position (ZP0..ZP3) or flags, with ZF0 in the last byte.
+type zheader struct {
+	typ  byte
+	data [4]byte
+}
+
+func posHeader(typ byte, pos int64) zheader {
+	h := zheader{typ: typ}
+	binary.LittleEndian.PutUint32(h.data[:], uint32(pos))
+	return h
+}
+
+func flagsHeader(typ, zf0 byte) zheader {
+	return zheader{typ: typ, data: [4]byte{0, 0, 0, zf0}}
+}
+
+func (h zheader) pos() int64 { return int64(binary.LittleEndian.Uint32(h.data[:])) }
+func (h zheader) zf0() byte  { return h.data[3] }
+
+// zsession holds the framing state for one side of a ZMODEM transfer.
+type zsession struct {
+	ctx      context.Context
+	st       *byteStream
+	timeout  time.Duration
+	txCRC32  bool // Send binary headers and subpackets with CRC-32
+	rxCRC32  bool // The last binary header (and its subpackets) used CRC-32
+	escCtl   bool // Escape every control character
+	lastSent byte
+	out      bytes.Buffer
+}
+
+func newZSession(ctx context.Context, st *byteStream) *zsession {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &zsession{ctx: ctx, st: st, timeout: zDefaultTimeout}
+}
+
+func (z *zsession) flush() error {
+	err := z.st.write(z.out.Bytes())
+	z.out.Reset()
+	return err
+}
+
+// putEscaped appends c to the output, ZDLE-escaping the characters that
+// flow control, telnet or modem escapes could eat.
+func (z *zsession) putEscaped(c byte) {
+	switch c {
+	case zDLE, zDLE | 0x80, 0x10, 0x90, xON, xON | 0x80, xOFF, xOFF | 0x80:
+		z.out.WriteByte(zDLE)
+		c ^= 0x40
+	case '\r', '\r' | 0x80:
+		if z.escCtl || z.lastSent&0x7f == '@' {
+			z.out.WriteByte(zDLE)
+			c ^= 0x40
+		}
+	default:
+		if z.escCtl && c&0x60 == 0 {
+			z.out.WriteByte(zDLE)
+			c ^= 0x40
+		}
+	}
+	z.out.WriteByte(c)
+	z.lastSent = c
+}
+
+func (z *zsession) putEscapedAll(p []byte) {
+	for _, c := range p {
+		z.putEscaped(c)
+	}
+}
+
+// sendHexHeader writes a hex header. Hex headers are used for frames that
+// are not followed by data subpackets.
+func (z *zsession) sendHexHeader(h zheader) error {
+	raw := append([]byte{h.typ}, h.data[:]...)
+	crc := crc16Update(0, raw)
+	raw = append(raw, byte(crc>>8), byte(crc))
+	z.out.Write([]byte{zPAD, zPAD, zDLE, zHEX})
+	z.out.WriteString(fmt.Sprintf("%x", raw))
+	z.out.Write([]byte{'\r', '\n' | 0x80})
+	if h.typ != zFIN && h.typ != zACK {
+		z.out.WriteByte(xON)
+	}
+	z.lastSent = 0
+	return z.flush()
+}
+
+// sendBinHeader writes a binary header using CRC-32 when negotiated.
+func (z *zsession) sendBinHeader(h zheader) error {
+	raw := append([]byte{h.typ}, h.data[:]...)
+	if z.txCRC32 {
+		z.out.Write([]byte{zPAD, zDLE, zBIN32})
+		z.putEscapedAll(raw)
+		var sum [4]byte
+		binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(raw))
+		z.putEscapedAll(sum[:])
+	} else {
+		z.out.Write([]byte{zPAD, zDLE, zBIN})
+		z.putEscapedAll(raw)
+		crc := crc16Update(0, raw)
+		z.putEscapedAll([]byte{byte(crc >> 8), byte(crc)})
+	}
+	return z.flush()
+}
+
+// sendData writes one data subpacket terminated by end (ZCRCE, ZCRCG, ZCRCQ
+// or ZCRCW).
+func (z *zsession) sendData(p []byte, end byte) error {
+	z.putEscapedAll(p)
+	z.out.Write([]byte{zDLE, end})
+	if z.txCRC32 {
+		crc := crc32.Update(crc32.ChecksumIEEE(p), crc32.IEEETable, []byte{end})
+		var sum [4]byte
+		binary.LittleEndian.PutUint32(sum[:], crc)
+		z.putEscapedAll(sum[:])
+	} else {
+		crc := crc16Update(crc16Update(0, p), []byte{end})
+		z.putEscapedAll([]byte{byte(crc >> 8), byte(crc)})
+	}
+	if end == zCRCW {
+		z.out.WriteByte(xON)
+	}
+	return z.flush()
+}
+
+// abort sends the CAN sequence that tells the remote end to give up.
+func (z *zsession) abort() {
+	z.out.Reset()
+	_ = z.st.write(append(bytes.Repeat([]byte{zDLE}, 8), bytes.Repeat([]byte{0x08}, 8)...))
+}
+
+// readRaw returns the next byte, skipping XON/XOFF flow control.
+func (z *zsession) readRaw() (byte, error) {
+	for {
+		c, err := z.st.readByte(z.ctx, z.timeout)
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case xON, xON | 0x80, xOFF, xOFF | 0x80:
+			continue
+		}
+		return c, nil
+	}
+}
+
+// readEscaped returns the next decoded byte. Subpacket terminators are
+// returned with bit 8 set.
+func (z *zsession) readEscaped() (int, error) {
+	c, err := z.readRaw()
+	if err != nil {
+		return 0, err
+	}
+	if c != zDLE {
+		return int(c), nil
+	}
+	cans := 1
+	for {
+		c, err = z.readRaw()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case c == zDLE:
+			cans++
+			if cans >= 5 {
+				return 0, ErrRemoteCancelled
+			}
+			continue
+		case c == zCRCE || c == zCRCG || c == zCRCQ || c == zCRCW:
+			return int(c) | 0x100, nil
+		case c == zRUB0:
+			return 0x7f, nil
+		case c == zRUB1:
+			return 0xff, nil
+		case c&0x60 == 0x40:
+			return int(c ^ 0x40), nil
+		}
+		return 0, errZEscape
+	}
+}
+
+// readEscapedBytes fills p with decoded bytes; a terminator is an error.
+func (z *zsession) readEscapedBytes(p []byte) error {
+	for i := range p {
+		v, err := z.readEscaped()
+		if err != nil {
+			return err
+		}
+		if v > 0xff {
+			return errZEscape
+		}
+		p[i] = byte(v)
+	}
+	return nil
+}
+
+// readHeader skips noise until a valid header arrives. Five CANs in a row
+// return ErrRemoteCancelled.
+func (z *zsession) readHeader() (zheader, error) {
+	garbage := 0
+	cans := 0
+	for {
+		c, err := z.readRaw()
+		if err != nil {
+			return zheader{}, err
+		}
+		if c == zDLE {
+			if cans++; cans >= 5 {
+				return zheader{}, ErrRemoteCancelled
+			}
+		} else {
+			cans = 0
+		}
+		if c != zPAD {
+			if garbage++; garbage > zMaxGarbage {
+				return zheader{}, errZGarbage
+			}
+			continue
+		}
+		for c == zPAD {
+			if c, err = z.readRaw(); err != nil {
+				return zheader{}, err
+			}
+		}
+		if c != zDLE {
+			continue
+		}
+		if c, err = z.readRaw(); err != nil {
+			return zheader{}, err
+		}
+		switch c {
+		case zBIN, zBIN32:
+			return z.readBinHeader(c == zBIN32)
+		case zHEX:
+			return z.readHexHeader()
+		}
+	}
+}
+
+func (z *zsession) readBinHeader(crc32Header bool) (zheader, error) {
+	var raw [5]byte
+	if err := z.readEscapedBytes(raw[:]); err != nil {
+		return zheader{}, err
+	}
+	if crc32Header {
+		var sum [4]byte
+		if err := z.readEscapedBytes(sum[:]); err != nil {
+			return zheader{}, err
+		}
+		if binary.LittleEndian.Uint32(sum[:]) != crc32.ChecksumIEEE(raw[:]) {
+			return zheader{}, errZCRC
+		}
+	} else {
+		var sum [2]byte
+		if err := z.readEscapedBytes(sum[:]); err != nil {
+			return zheader{}, err
+		}
+		if binary.BigEndian.Uint16(sum[:]) != crc16Update(0, raw[:]) {
+			return zheader{}, errZCRC
+		}
+	}
+	z.rxCRC32 = crc32Header
+	h := zheader{typ: raw[0]}
+	copy(h.data[:], raw[1:])
+	return h, nil
+}
+
+func (z *zsession) readHexHeader() (zheader, error) {
+	var raw [7]byte
+	for i := range raw {
+		var v byte
+		for j := 0; j < 2; j++ {
+			c, err := z.readRaw()
+			if err != nil {
+				return zheader{}, err
+			}
+			switch {
+			case c >= '0' && c <= '9':
+				v = v<<4 | (c - '0')
+			case c >= 'a' && c <= 'f':
+				v = v<<4 | (c - 'a' + 10)
+			default:
+				return zheader{}, errZCRC
+			}
+		}
+		raw[i] = v
+	}
+	if binary.BigEndian.Uint16(raw[5:]) != crc16Update(0, raw[:5]) {
+		return zheader{}, errZCRC
+	}
+	// Swallow the trailing CR/LF so it isn't mistaken for subpacket data.
+	for i := 0; i < 2; i++ {
+		c, err := z.st.readByte(z.ctx, 100*time.Millisecond)
+		if err != nil {
+			break
+		}
+		if c&0x7f != '\r' && c&0x7f != '\n' {
+			z.st.unread(c)
+			break
+		}
+	}
+	h := zheader{typ: raw[0]}
+	copy(h.data[:], raw[1:5])
+	return h, nil
+}
+
+// readData reads one data subpacket of at most max bytes, checked with the
+// CRC of the header that preceded it.
+func (z *zsession) readData(max int) ([]byte, byte, error) {
+	data := make([]byte, 0, 1024)
+	for {
+		v, err := z.readEscaped()
+		if err != nil {
+			return nil, 0, err
+		}
+		if v <= 0xff {
+			if len(data) >= max {
+				return nil, 0, errZLong
+			}
+			data = append(data, byte(v))
+			continue
+		}
+		end := byte(v)
+		if z.rxCRC32 {
+			var sum [4]byte
+			if err := z.readEscapedBytes(sum[:]); err != nil {
+				return nil, 0, err
+			}
+			crc := crc32.Update(crc32.ChecksumIEEE(data), crc32.IEEETable, []byte{end})
+			if binary.LittleEndian.Uint32(sum[:]) != crc {
+				return nil, 0, errZCRC
+			}
+		} else {
+			var sum [2]byte
+			if err := z.readEscapedBytes(sum[:]); err != nil {
+				return nil, 0, err
+			}
+			if binary.BigEndian.Uint16(sum[:]) != crc16Update(crc16Update(0, data), []byte{end}) {
+				return nil, 0, errZCRC
+			}
+		}
+		return data, end, nil
+	}
+}
@@ -0,0 +1,248 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// duplex joins the two halves of an in-process link.
+type duplex struct {
+	io.Reader
+	io.Writer
+}
+
+// newLink returns the two ends of a full-duplex in-process connection and
+// a function that tears it down.
+func newLink() (io.ReadWriter, io.ReadWriter, func()) {
+	ar, bw := io.Pipe()
+	br, aw := io.Pipe()
+	closeAll := func() {
+		ar.Close()
+		br.Close()
+		aw.Close()
+		bw.Close()
+	}
+	return duplex{ar, aw}, duplex{br, bw}, closeAll
+}
+
+// corruptWriter flips one byte at offset at, once, to simulate line noise.
+type corruptWriter struct {
+	w       io.Writer
+	at      int
+	written int
+}
+
+func (c *corruptWriter) Write(p []byte) (int, error) {
+	if c.at >= c.written && c.at < c.written+len(p) {
+		q := append([]byte(nil), p...)
+		q[c.at-c.written] ^= 0x5a
+		p = q
+	}
+	c.written += len(p)
+	return c.w.Write(p)
+}
+
+// progressLog collects progress reports from both ends of a transfer.
+type progressLog struct {
+	mu      sync.Mutex
+	reports []Progress
+}
+
+func (l *progressLog) add(p Progress) {
+	l.mu.Lock()
+	l.reports = append(l.reports, p)
+	l.mu.Unlock()
+}
+
+func (l *progressLog) final(name string) (Progress, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := len(l.reports) - 1; i >= 0; i-- {
+		if l.reports[i].File == name && (l.reports[i].Done || l.reports[i].Skipped) {
+			return l.reports[i], true
+		}
+	}
+	return Progress{}, false
+}
+
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// randomBytes returns n bytes covering every byte value, so escaping of
+// ZDLE, XON/XOFF and CR is exercised.
+func randomBytes(n int, seed int64) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(b)
+	return b
+}
+
+// runTransfer sends files from the sender end to a receiver writing into
+// dstDir and returns both errors.
+func runTransfer(t *testing.T, senderOut func(io.Writer) io.Writer, dstDir string, files ...string) (sendErr, recvErr error, sent, received *progressLog) {
+	t.Helper()
+	a, b, closeLink := newLink()
+	defer closeLink()
+	if senderOut != nil {
+		a = duplex{a, senderOut(a)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	sent, received = &progressLog{}, &progressLog{}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ZmodemSend(WithProgress(ctx, sent.add), a, files...)
+	}()
+	recvErr = ZmodemReceive(WithProgress(ctx, received.add), b, dstDir)
+	sendErr = <-done
+	return sendErr, recvErr, sent, received
+}
+
+func TestZmodem_BatchTransfer(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	contents := map[string][]byte{
+		"RANDOM.BIN": randomBytes(50000, 1),
+		"EXACT.BIN":  randomBytes(2*zMaxBlock, 2),
+		"EMPTY.TXT":  {},
+		"ESCAPES.BIN": bytes.Repeat([]byte{
+			zDLE, zDLE | 0x80, xON, xOFF, 0x90, '@', '\r', zPAD, 0xff, 0x7f,
+		}, 500),
+	}
+	var files []string
+	for _, name := range []string{"RANDOM.BIN", "EXACT.BIN", "EMPTY.TXT", "ESCAPES.BIN"} {
+		files = append(files, writeTestFile(t, src, name, contents[name]))
+	}
+
+	sendErr, recvErr, sent, received := runTransfer(t, nil, dst, files...)
+	if sendErr != nil || recvErr != nil {
+		t.Fatalf("transfer failed: send=%v recv=%v", sendErr, recvErr)
+	}
+
+	for name, want := range contents {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Errorf("%s not received: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: received %d bytes, content mismatch (want %d bytes)", name, len(got), len(want))
+		}
+		for side, log := range map[string]*progressLog{"sender": sent, "receiver": received} {
+			p, ok := log.final(name)
+			if !ok || !p.Done || p.Bytes != int64(len(want)) {
+				t.Errorf("%s: %s final progress = %+v, want Done with %d bytes", name, side, p, len(want))
+			}
+		}
+	}
+}
+
+func TestZmodem_RecoversFromLineNoise(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	want := randomBytes(40000, 3)
+	file := writeTestFile(t, src, "NOISY.BIN", want)
+
+	corrupt := func(w io.Writer) io.Writer { return &corruptWriter{w: w, at: 20000} }
+	sendErr, recvErr, _, _ := runTransfer(t, corrupt, dst, file)
+	if sendErr != nil || recvErr != nil {
+		t.Fatalf("transfer failed: send=%v recv=%v", sendErr, recvErr)
+	}
+	got, _ := os.ReadFile(filepath.Join(dst, "NOISY.BIN"))
+	if !bytes.Equal(got, want) {
+		t.Fatalf("received %d bytes, content mismatch", len(got))
+	}
+}
+
+func TestZmodem_ResumesPartialFile(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	want := randomBytes(30000, 4)
+	file := writeTestFile(t, src, "CRASHED.ZIP", want)
+	writeTestFile(t, dst, "CRASHED.ZIP", want[:12345])
+
+	sendErr, recvErr, sent, received := runTransfer(t, nil, dst, file)
+	if sendErr != nil || recvErr != nil {
+		t.Fatalf("transfer failed: send=%v recv=%v", sendErr, recvErr)
+	}
+	got, _ := os.ReadFile(filepath.Join(dst, "CRASHED.ZIP"))
+	if !bytes.Equal(got, want) {
+		t.Fatalf("resumed file is %d bytes, content mismatch", len(got))
+	}
+	for side, log := range map[string]*progressLog{"sender": sent, "receiver": received} {
+		p, _ := log.final("CRASHED.ZIP")
+		if !p.Done || p.Resumed != 12345 {
+			t.Errorf("%s final progress = %+v, want Done resumed at 12345", side, p)
+		}
+	}
+}
+
+func TestZmodem_SkipsFileAlreadyReceived(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	data := randomBytes(1000, 5)
+	file := writeTestFile(t, src, "HAVE.TXT", data)
+	writeTestFile(t, dst, "HAVE.TXT", data)
+
+	sendErr, recvErr, sent, _ := runTransfer(t, nil, dst, file)
+	if sendErr != nil || recvErr != nil {
+		t.Fatalf("transfer failed: send=%v recv=%v", sendErr, recvErr)
+	}
+	if p, _ := sent.final("HAVE.TXT"); !p.Skipped {
+		t.Errorf("sender final progress = %+v, want Skipped", p)
+	}
+}
+
+func TestZmodem_RefusesPathTraversal(t *testing.T) {
+	for in, want := range map[string]string{
+		"../../etc/passwd":   "passwd",
+		`..\..\WIN.INI`:      "WIN.INI",
+		"/abs/path/FILE.ZIP": "FILE.ZIP",
+		"..":                 "",
+		"bad\x01name.txt":    "badname.txt",
+	} {
+		if got := zSafeName(in); got != want {
+			t.Errorf("zSafeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestZmodem_RemoteCancel(t *testing.T) {
+	a, b, closeLink := newLink()
+	defer closeLink()
+	file := writeTestFile(t, t.TempDir(), "A.TXT", []byte("hello"))
+
+	done := make(chan error, 1)
+	go func() { done <- ZmodemSend(context.Background(), a, file) }()
+
+	// Swallow the sender's output and answer with a CAN abort.
+	go io.Copy(io.Discard, b)
+	b.Write(bytes.Repeat([]byte{zDLE}, 8))
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrRemoteCancelled) {
+			t.Fatalf("expected ErrRemoteCancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sender did not notice the cancel")
+	}
+}
+
+func TestCRC16_XMODEM(t *testing.T) {
+	// Standard check value for CRC-16/XMODEM.
+	if got := crc16Update(0, []byte("123456789")); got != 0x31c3 {
+		t.Errorf("crc16(123456789) = %#04x, want 0x31c3", got)
+	}
+}
@@ -0,0 +1,309 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZmodemReceive receives files into targetDir with the built-in ZMODEM
+// engine. A partial file already in targetDir is resumed rather than
+// restarted. Progress is reported to any callback registered on ctx with
+// WithProgress; only files reported Done are complete.
+func ZmodemReceive(ctx context.Context, rw io.ReadWriter, targetDir string) error {
+	st := newByteStream(rw, rw)
+	defer st.close()
+	return zmodemReceive(ctx, st, targetDir)
+}
+
+// zreceiver is the receiving side of a ZMODEM session.
+type zreceiver struct {
+	*zsession
+	progress ProgressFunc
+	dir      string
+	cur      *zrecvFile
+}
+
+// zrecvFile is the file currently being received.
+type zrecvFile struct {
+	f       *os.File
+	name    string
+	path    string
+	size    int64 // -1 when the sender did not say
+	modTime time.Time
+	pos     int64
+	resumed int64
+}
+
+// errZSkip tells the sender we don't want the offered file.
+var errZSkip = errors.New("zmodem: skip file")
+
+func zmodemReceive(ctx context.Context, st *byteStream, targetDir string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	zr := &zreceiver{zsession: newZSession(ctx, st), progress: progressFrom(ctx), dir: targetDir}
+	err := zr.run()
+	if zr.cur != nil {
+		// Keep the partial file so a later transfer can resume it.
+		log.Printf("INFO: zmodem: %s incomplete at %d bytes", zr.cur.name, zr.cur.pos)
+		zr.cur.f.Close()
+		zr.cur = nil
+	}
+	if err != nil && !errors.Is(err, ErrRemoteCancelled) {
+		zr.abort()
+	}
+	return err
+}
+
+func (zr *zreceiver) sendInit() error {
+	return zr.sendHexHeader(flagsHeader(zRINIT, canFDX|canOVIO|canFC32))
+}
+
+// nudge prompts a quiet or confused sender: ZRPOS mid-file, else ZRINIT.
+func (zr *zreceiver) nudge() error {
+	if zr.cur != nil {
+		return zr.sendHexHeader(posHeader(zRPOS, zr.cur.pos))
+	}
+	return zr.sendInit()
+}
+
+func (zr *zreceiver) run() error {
+	if err := zr.sendInit(); err != nil {
+		return err
+	}
+	timeouts := 0
+	for {
+		h, err := zr.readHeader()
+		if err != nil {
+			if !zRecoverable(err) {
+				return err
+			}
+			if errors.Is(err, errTimeout) {
+				if timeouts++; timeouts > zMaxRetries {
+					return fmt.Errorf("sender stopped responding: %w", err)
+				}
+			}
+			if err := zr.nudge(); err != nil {
+				return err
+			}
+			continue
+		}
+		timeouts = 0
+
+		switch h.typ {
+		case zRQINIT:
+			err = zr.sendInit()
+		case zSINIT:
+			if _, _, dErr := zr.readData(zMaxBlock); dErr != nil {
+				err = zr.sendHexHeader(posHeader(zNAK, 0))
+				break
+			}
+			if h.zf0()&escCTL != 0 {
+				zr.escCtl = true
+			}
+			err = zr.sendHexHeader(posHeader(zACK, 1))
+		case zFILE:
+			info, _, dErr := zr.readData(zMaxBlock)
+			if dErr != nil {
+				err = zr.sendHexHeader(posHeader(zNAK, 0))
+				break
+			}
+			if zr.cur != nil {
+				if zr.cur.name == zSafeName(string(bytes.SplitN(info, []byte{0}, 2)[0])) {
+					// The sender missed our ZRPOS and offered the file again.
+					err = zr.sendHexHeader(posHeader(zRPOS, zr.cur.pos))
+					break
+				}
+				// A new file before ZEOF: the sender gave up on the old one.
+				zr.cur.f.Close()
+				zr.cur = nil
+			}
+			switch oErr := zr.openFile(info); {
+			case errors.Is(oErr, errZSkip):
+				err = zr.sendHexHeader(posHeader(zSKIP, 0))
+			case oErr != nil:
+				return oErr
+			default:
+				err = zr.sendHexHeader(posHeader(zRPOS, zr.cur.pos))
+			}
+		case zDATA:
+			switch {
+			case zr.cur == nil:
+				err = zr.sendInit()
+			case h.pos() != zr.cur.pos:
+				err = zr.sendHexHeader(posHeader(zRPOS, zr.cur.pos))
+			default:
+				err = zr.receiveData()
+			}
+		case zEOF:
+			switch {
+			case zr.cur == nil:
+				err = zr.sendInit()
+			case h.pos() == zr.cur.pos:
+				zr.finishFile()
+				err = zr.sendInit()
+			}
+			// A ZEOF at the wrong offset is ignored; the ZRPOS we sent
+			// for the missing data is already on its way.
+		case zFIN:
+			if err := zr.sendHexHeader(posHeader(zFIN, 0)); err != nil {
+				return err
+			}
+			// The sender signs off with "OO".
+			for i := 0; i < 2; i++ {
+				if _, rErr := zr.st.readByte(zr.ctx, time.Second); rErr != nil {
+					break
+				}
+			}
+			return nil
+		case zFREECNT:
+			err = zr.sendHexHeader(posHeader(zACK, 0))
+		case zCOMMAND:
+			// Remote commands are never run.
+			if _, _, dErr := zr.readData(zMaxBlock); dErr == nil {
+				err = zr.sendHexHeader(posHeader(zCOMPL, 1))
+			}
+		case zABORT, zFERR, zCAN:
+			return ErrRemoteCancelled
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// openFile parses a ZFILE subpacket and opens the destination. A smaller
+// file of the same name is treated as an interrupted earlier attempt and
+// resumed; one of the same size is skipped as already received.
+func (zr *zreceiver) openFile(info []byte) error {
+	parts := bytes.SplitN(info, []byte{0}, 2)
+	name := zSafeName(string(parts[0]))
+	if name == "" {
+		log.Printf("WARN: zmodem: refusing file with unusable name %q", parts[0])
+		return errZSkip
+	}
+
+	size := int64(-1)
+	var modTime time.Time
+	if len(parts) > 1 {
+		fields := strings.Fields(string(bytes.TrimRight(parts[1], "\x00")))
+		if len(fields) > 0 {
+			if n, err := strconv.ParseInt(fields[0], 10, 64); err == nil && n >= 0 {
+				size = n
+			}
+		}
+		if len(fields) > 1 {
+			if t, err := strconv.ParseInt(fields[1], 8, 64); err == nil && t > 0 {
+				modTime = time.Unix(t, 0)
+			}
+		}
+	}
+
+	dest := filepath.Join(zr.dir, name)
+	var pos int64
+	if fi, err := os.Stat(dest); err == nil && fi.Mode().IsRegular() && size >= 0 {
+		switch {
+		case fi.Size() == size:
+			log.Printf("INFO: zmodem: %s already received, skipping", name)
+			zr.progress(Progress{File: name, Path: dest, Bytes: size, Size: size, Skipped: true})
+			return errZSkip
+		case fi.Size() < size:
+			pos = fi.Size()
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if pos == 0 {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(dest, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", dest, err)
+	}
+	if _, err := f.Seek(pos, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	if pos > 0 {
+		log.Printf("INFO: zmodem: resuming %s at %d of %d bytes", name, pos, size)
+	}
+	zr.cur = &zrecvFile{f: f, name: name, path: dest, size: size, modTime: modTime, pos: pos, resumed: pos}
+	zr.report()
+	return nil
+}
+
+// receiveData writes subpackets until the frame ends. Damaged data is
+// answered with ZRPOS so the sender goes back to the last good byte.
+func (zr *zreceiver) receiveData() error {
+	for {
+		data, end, err := zr.readData(zMaxBlock)
+		if err != nil {
+			if !zRecoverable(err) {
+				return err
+			}
+			log.Printf("DEBUG: %v at %d in %s, requesting resend", err, zr.cur.pos, zr.cur.name)
+			return zr.sendHexHeader(posHeader(zRPOS, zr.cur.pos))
+		}
+		if _, err := zr.cur.f.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", zr.cur.path, err)
+		}
+		zr.cur.pos += int64(len(data))
+		zr.report()
+
+		switch end {
+		case zCRCW:
+			return zr.sendHexHeader(posHeader(zACK, zr.cur.pos))
+		case zCRCQ:
+			if err := zr.sendHexHeader(posHeader(zACK, zr.cur.pos)); err != nil {
+				return err
+			}
+		case zCRCE:
+			return nil
+		}
+	}
+}
+
+// finishFile closes the current file after ZEOF.
+func (zr *zreceiver) finishFile() {
+	c := zr.cur
+	zr.cur = nil
+	if err := c.f.Close(); err != nil {
+		log.Printf("WARN: zmodem: closing %s: %v", c.path, err)
+	}
+	if !c.modTime.IsZero() {
+		_ = os.Chtimes(c.path, c.modTime, c.modTime)
+	}
+	log.Printf("INFO: zmodem: received %s (%d bytes)", c.name, c.pos)
+	zr.progress(Progress{File: c.name, Path: c.path, Bytes: c.pos, Size: c.size, Resumed: c.resumed, Done: true})
+}
+
+func (zr *zreceiver) report() {
+	c := zr.cur
+	zr.progress(Progress{File: c.name, Path: c.path, Bytes: c.pos, Size: c.size, Resumed: c.resumed})
+}
+
+// zSafeName reduces a sender-supplied path to a plain file name so nothing
+// can be written outside the target directory.
+func zSafeName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return name
+}
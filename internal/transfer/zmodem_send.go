@@ -0,0 +1,409 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const zMaxRetries = 10
+
+// ZmodemSend sends files over rw with the built-in ZMODEM engine. Progress
+// is reported to any callback registered on ctx with WithProgress.
+func ZmodemSend(ctx context.Context, rw io.ReadWriter, filePaths ...string) error {
+	st := newByteStream(rw, rw)
+	defer st.close()
+	return zmodemSend(ctx, st, filePaths)
+}
+
+// zsender is the sending side of a ZMODEM session.
+type zsender struct {
+	*zsession
+	progress  ProgressFunc
+	rxFlags   byte // Receiver capabilities from ZRINIT
+	rxBufSize int  // Receiver buffer size; 0 means it can stream
+	blockSize int
+}
+
+func zmodemSend(ctx context.Context, st *byteStream, filePaths []string) error {
+	if len(filePaths) == 0 {
+		return fmt.Errorf("no files provided for zmodem send")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	zs := &zsender{zsession: newZSession(ctx, st), progress: progressFrom(ctx)}
+	err := zs.run(filePaths)
+	if err != nil && !errors.Is(err, ErrRemoteCancelled) {
+		zs.abort()
+	}
+	return err
+}
+
+func (zs *zsender) run(filePaths []string) error {
+	var totalLeft int64
+	for _, p := range filePaths {
+		if fi, err := os.Stat(p); err == nil {
+			totalLeft += fi.Size()
+		}
+	}
+
+	if err := zs.st.write([]byte("rz\r")); err != nil {
+		return err
+	}
+	if err := zs.sendHexHeader(posHeader(zRQINIT, 0)); err != nil {
+		return err
+	}
+	if err := zs.awaitReceiverInit(); err != nil {
+		return err
+	}
+
+	for i, p := range filePaths {
+		size, err := zs.sendFile(p, len(filePaths)-i, totalLeft)
+		if err != nil {
+			return fmt.Errorf("zmodem send %s: %w", filepath.Base(p), err)
+		}
+		totalLeft -= size
+	}
+	return zs.finish()
+}
+
+// awaitReceiverInit waits for ZRINIT and records the receiver's abilities.
+func (zs *zsender) awaitReceiverInit() error {
+	for tries := 0; tries < zMaxRetries; tries++ {
+		h, err := zs.readHeader()
+		switch {
+		case zRecoverable(err):
+			if err := zs.sendHexHeader(posHeader(zRQINIT, 0)); err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+		switch h.typ {
+		case zRINIT:
+			zs.rxFlags = h.zf0()
+			zs.rxBufSize = int(h.data[0]) | int(h.data[1])<<8
+			zs.txCRC32 = zs.rxFlags&canFC32 != 0
+			zs.escCtl = zs.rxFlags&escCTL != 0
+			zs.blockSize = zMaxBlock
+			if zs.rxBufSize > 0 && zs.rxBufSize < zs.blockSize {
+				zs.blockSize = zs.rxBufSize
+			}
+			return nil
+		case zCHALLENGE:
+			if err := zs.sendHexHeader(zheader{typ: zACK, data: h.data}); err != nil {
+				return err
+			}
+		case zNAK:
+			if err := zs.sendHexHeader(posHeader(zRQINIT, 0)); err != nil {
+				return err
+			}
+		case zABORT, zFERR, zCAN:
+			return ErrRemoteCancelled
+		}
+	}
+	return fmt.Errorf("no ZRINIT from receiver: %w", errTimeout)
+}
+
+// sendFile offers one file and sends it from whatever offset the receiver
+// asks for. It returns the file's size.
+func (zs *zsender) sendFile(path string, filesLeft int, bytesLeft int64) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+	name := filepath.Base(path)
+
+	info := fmt.Sprintf("%s\x00%d %o %o 0 %d %d\x00", name, size, fi.ModTime().Unix(), 0644, filesLeft, bytesLeft)
+
+	var start int64
+	for tries := 0; ; tries++ {
+		if tries >= zMaxRetries {
+			return size, fmt.Errorf("receiver did not accept ZFILE: %w", errTimeout)
+		}
+		// ZCRESUM lets the receiver continue a partial copy it already has.
+		if err := zs.sendBinHeader(flagsHeader(zFILE, zCRESUM)); err != nil {
+			return size, err
+		}
+		if err := zs.sendData([]byte(info), zCRCW); err != nil {
+			return size, err
+		}
+		h, err := zs.awaitFileResponse(f)
+		if err != nil {
+			return size, err
+		}
+		if h.typ == zSKIP {
+			log.Printf("INFO: zmodem: receiver skipped %s", name)
+			zs.progress(Progress{File: name, Path: path, Size: size, Skipped: true})
+			return size, nil
+		}
+		if h.typ == zRPOS {
+			start = h.pos()
+			break
+		}
+	}
+
+	if start > size {
+		start = size
+	}
+	zs.progress(Progress{File: name, Path: path, Bytes: start, Size: size, Resumed: start})
+	return size, zs.sendFileData(f, name, path, start, size)
+}
+
+// awaitFileResponse waits for the receiver's answer to ZFILE, answering any
+// ZCRC requests along the way. ZNAK is returned when ZFILE should be resent.
+func (zs *zsender) awaitFileResponse(f *os.File) (zheader, error) {
+	for {
+		h, err := zs.readHeader()
+		switch {
+		case zRecoverable(err):
+			return zheader{typ: zNAK}, nil
+		case err != nil:
+			return zheader{}, err
+		}
+		switch h.typ {
+		case zCRC:
+			// The receiver wants a CRC of the first n bytes (0 = all) to
+			// decide whether its partial copy matches ours.
+			sum, err := fileCRC32(f, h.pos())
+			if err != nil {
+				return zheader{}, err
+			}
+			if err := zs.sendHexHeader(posHeader(zCRC, int64(sum))); err != nil {
+				return zheader{}, err
+			}
+		case zRINIT:
+			// Often a stale reply to our ZRQINIT; only resend ZFILE if
+			// nothing else follows it.
+			if c, err := zs.st.readByte(zs.ctx, 500*time.Millisecond); err == nil {
+				zs.st.unread(c)
+				continue
+			}
+			return zheader{typ: zNAK}, nil
+		case zRPOS, zSKIP, zNAK:
+			return h, nil
+		case zABORT, zFERR, zCAN:
+			return zheader{}, ErrRemoteCancelled
+		}
+	}
+}
+
+// sendFileData sends the file from pos and completes it with ZEOF, going
+// back whenever the receiver reports an error with ZRPOS. Receivers that
+// cannot stream get one ZCRCW subpacket per frame and must ZACK each.
+func (zs *zsender) sendFileData(f *os.File, name, path string, pos, size int64) error {
+	streaming := zs.rxFlags&canFDX != 0 && zs.rxFlags&canOVIO != 0 && zs.rxBufSize == 0
+	buf := make([]byte, zs.blockSize)
+	resumed := pos
+	skip := func() error {
+		zs.progress(Progress{File: name, Path: path, Bytes: pos, Size: size, Resumed: resumed, Skipped: true})
+		return nil
+	}
+
+	for {
+		if _, err := f.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+		if err := zs.sendBinHeader(posHeader(zDATA, pos)); err != nil {
+			return err
+		}
+
+		next := int64(-1)
+		atEOF := false
+		for next < 0 && !atEOF {
+			n, rerr := io.ReadFull(f, buf)
+			if rerr != nil && rerr != io.EOF && rerr != io.ErrUnexpectedEOF {
+				return rerr
+			}
+			atEOF = rerr != nil || pos+int64(n) >= size
+
+			end := byte(zCRCG)
+			switch {
+			case atEOF:
+				end = zCRCE
+			case !streaming:
+				end = zCRCW
+			}
+			if err := zs.sendData(buf[:n], end); err != nil {
+				return err
+			}
+			packetStart := pos
+			pos += int64(n)
+			zs.progress(Progress{File: name, Path: path, Bytes: pos, Size: size, Resumed: resumed})
+
+			var skipped bool
+			var err error
+			switch {
+			case end == zCRCW:
+				next, skipped, err = zs.awaitAck(packetStart, pos)
+			case !atEOF:
+				// Only look at the back channel when something has
+				// arrived, so a quiet receiver costs nothing.
+				next, skipped, err = zs.checkBackChannel()
+			}
+			if err != nil {
+				return err
+			}
+			if skipped {
+				return skip()
+			}
+		}
+		if next >= 0 {
+			pos = next
+			continue
+		}
+
+		next, done, err := zs.sendEOF(pos)
+		if err != nil {
+			return err
+		}
+		if done {
+			zs.progress(Progress{File: name, Path: path, Bytes: pos, Size: size, Resumed: resumed, Done: true})
+			return nil
+		}
+		if next < 0 {
+			return skip()
+		}
+		pos = next
+	}
+}
+
+// awaitAck waits for the ZACK to a ZCRCW subpacket and returns where the
+// next frame starts. A timeout resends the subpacket.
+func (zs *zsender) awaitAck(packetStart, pos int64) (int64, bool, error) {
+	for tries := 0; tries < zMaxRetries; tries++ {
+		h, err := zs.readHeader()
+		switch {
+		case zRecoverable(err):
+			return packetStart, false, nil
+		case err != nil:
+			return -1, false, err
+		}
+		switch h.typ {
+		case zACK:
+			return pos, false, nil
+		case zRPOS:
+			return h.pos(), false, nil
+		case zSKIP:
+			return -1, true, nil
+		case zABORT, zFERR, zCAN:
+			return -1, false, ErrRemoteCancelled
+		}
+	}
+	return -1, false, fmt.Errorf("no ZACK from receiver: %w", errTimeout)
+}
+
+// checkBackChannel reads any headers the receiver sent while we were
+// streaming. It returns the position to go back to, or -1.
+func (zs *zsender) checkBackChannel() (int64, bool, error) {
+	rewind := int64(-1)
+	for zs.st.buffered() {
+		c, err := zs.st.readByte(zs.ctx, zs.timeout)
+		if err != nil {
+			return rewind, false, err
+		}
+		if c != zPAD && c != zDLE {
+			continue
+		}
+		zs.st.unread(c)
+		h, err := zs.readHeader()
+		switch {
+		case zRecoverable(err):
+			continue
+		case err != nil:
+			return rewind, false, err
+		}
+		switch h.typ {
+		case zRPOS:
+			rewind = h.pos()
+			log.Printf("DEBUG: zmodem: receiver asked to resend from %d", rewind)
+		case zSKIP:
+			return rewind, true, nil
+		case zABORT, zFERR, zCAN:
+			return rewind, false, ErrRemoteCancelled
+		}
+	}
+	return rewind, false, nil
+}
+
+// sendEOF sends ZEOF until the receiver confirms the file with ZRINIT. If
+// the receiver asks for data again it returns that position; a skip
+// returns -1 and false.
+func (zs *zsender) sendEOF(pos int64) (int64, bool, error) {
+	for tries := 0; tries < zMaxRetries; tries++ {
+		if err := zs.sendBinHeader(posHeader(zEOF, pos)); err != nil {
+			return -1, false, err
+		}
+		h, err := zs.readHeader()
+		switch {
+		case zRecoverable(err):
+			continue
+		case err != nil:
+			return -1, false, err
+		}
+		switch h.typ {
+		case zRINIT:
+			return pos, true, nil
+		case zRPOS:
+			return h.pos(), false, nil
+		case zSKIP:
+			return -1, false, nil
+		case zABORT, zFERR, zCAN:
+			return -1, false, ErrRemoteCancelled
+		}
+	}
+	return -1, false, fmt.Errorf("no response to ZEOF: %w", errTimeout)
+}
+
+// finish ends the session: ZFIN, wait for the receiver's ZFIN, then "OO".
+func (zs *zsender) finish() error {
+	for tries := 0; tries < 3; tries++ {
+		if err := zs.sendHexHeader(posHeader(zFIN, 0)); err != nil {
+			return err
+		}
+		h, err := zs.readHeader()
+		if err != nil {
+			if zRecoverable(err) {
+				continue
+			}
+			return err
+		}
+		if h.typ == zFIN {
+			return zs.st.write([]byte("OO"))
+		}
+	}
+	// Every file was acknowledged, so a missing ZFIN is not a failure.
+	log.Printf("WARN: zmodem: receiver did not answer ZFIN")
+	return nil
+}
+
+// fileCRC32 returns the CRC-32 of the first n bytes of f, or all of it
+// when n is 0.
+func fileCRC32(f *os.File, n int64) (uint32, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var r io.Reader = f
+	if n > 0 {
+		r = io.LimitReader(f, n)
+	}
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, r); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
@@ -0,0 +1,96 @@
+package user
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const activityLogFile = "activity.jsonl" // Append-only log of calls, posts and door plays
+
+// Activity kinds.
+const (
+	ActivityCall = "call"
+	ActivityPost = "post"
+	ActivityDoor = "door"
+)
+
+// ActivityRecord is one logged call, message post or door play.
+type ActivityRecord struct {
+	Timestamp time.Time     `json:"timestamp"` // When the activity ended
+	UserID    int           `json:"userId"`
+	Handle    string        `json:"handle"`
+	Kind      string        `json:"kind"`               // ActivityCall, ActivityPost or ActivityDoor
+	Name      string        `json:"name,omitempty"`     // Door name
+	Count     int           `json:"count,omitempty"`    // Messages posted at once (QWK); 0 means 1
+	Duration  time.Duration `json:"duration,omitempty"` // Length of a call or door session
+	Invisible bool          `json:"invisible,omitempty"`
+}
+
+// Posts returns the number of messages a post record stands for.
+func (r ActivityRecord) Posts() int {
+	if r.Count > 0 {
+		return r.Count
+	}
+	return 1
+}
+
+// LogActivity appends rec to the activity log.
+func (um *UserMgr) LogActivity(rec ActivityRecord) error {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now()
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity record: %w", err)
+	}
+
+	logPath := filepath.Join(um.dataPath, activityLogFile)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open activity log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write activity log: %w", err)
+	}
+	return f.Close()
+}
+
+// ActivityLog reads the activity log, oldest first.
+func (um *UserMgr) ActivityLog() ([]ActivityRecord, error) {
+	um.mu.RLock()
+	defer um.mu.RUnlock()
+	return LoadActivityLog(um.dataPath)
+}
+
+// LoadActivityLog reads the activity log from dataPath, oldest first.
+// Malformed lines are skipped. A missing log returns no entries.
+func LoadActivityLog(dataPath string) ([]ActivityRecord, error) {
+	f, err := os.Open(filepath.Join(dataPath, activityLogFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ActivityRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec ActivityRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		entries = append(entries, rec)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogActivity_AppendsAndLoads(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "users.json"), []byte("[]"), 0644)
+
+	um, err := NewUserManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewUserManager: %v", err)
+	}
+
+	um.LogActivity(ActivityRecord{UserID: 2, Handle: "Bob", Kind: ActivityCall, Duration: time.Minute})
+	um.LogActivity(ActivityRecord{UserID: 2, Handle: "Bob", Kind: ActivityPost, Count: 3})
+
+	entries, err := um.ActivityLog()
+	if err != nil {
+		t.Fatalf("ActivityLog: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Duration != time.Minute || entries[0].Posts() != 1 || entries[1].Posts() != 3 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if entries[0].Timestamp.IsZero() {
+		t.Error("expected timestamp to be filled in")
+	}
+}
@@ -12,15 +12,16 @@ import "time"
 type AdminActivityLog struct {
 	ID            int       `json:"id"`
 	Timestamp     time.Time `json:"timestamp"`
-	AdminUsername string    `json:"adminUsername"` // Admin who made the change
-	AdminID       int       `json:"adminId"`       // Admin user ID
-	TargetUserID  int       `json:"targetUserId"`  // User being modified
-	TargetHandle  string    `json:"targetHandle"`  // Handle of user being modified
-	Action        string    `json:"action"`        // Type of action (e.g., "EDIT_USER", "BAN_USER", "DELETE_USER")
-	FieldName     string    `json:"fieldName"`     // Field that was changed (for edits)
-	OldValue      string    `json:"oldValue"`      // Previous value - may contain PII
-	NewValue      string    `json:"newValue"`      // New value - may contain PII
-	Notes         string    `json:"notes"`         // Optional notes/reason
+	AdminUsername string    `json:"adminUsername"`        // Admin who made the change
+	AdminID       int       `json:"adminId"`              // Admin user ID
+	TargetUserID  int       `json:"targetUserId"`         // User being modified
+	TargetHandle  string    `json:"targetHandle"`         // Handle of user being modified
+	TargetFile    string    `json:"targetFile,omitempty"` // "AREA/FILENAME" for file record actions
+	Action        string    `json:"action"`               // Type of action (e.g., "EDIT_USER", "BAN_USER", "EDIT_FILE")
+	FieldName     string    `json:"fieldName"`            // Field that was changed (for edits)
+	OldValue      string    `json:"oldValue"`             // Previous value - may contain PII
+	NewValue      string    `json:"newValue"`             // New value - may contain PII
+	Notes         string    `json:"notes"`                // Optional notes/reason
 }
 
 // AdminActivityLogEntry creates a formatted log entry for a single field change
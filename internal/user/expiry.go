@@ -0,0 +1,146 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/stlalpha/vision3/internal/config"
+)
+
+// ExpiryResult holds information about an account moved down on expiry,
+// for reporting and notices.
+type ExpiryResult struct {
+	ID        int
+	Username  string
+	Handle    string
+	FromLevel int
+	ToLevel   int
+	ExpiredAt time.Time
+}
+
+// Expired reports whether u's account has reached its expiry at now.
+func (u *User) Expired(now time.Time) bool {
+	return u.ExpiresAt != nil && !now.Before(*u.ExpiresAt)
+}
+
+// DaysUntilExpiry returns the whole days left before u's account expires,
+// rounded up, and false when it never expires. An expired account has 0.
+func (u *User) DaysUntilExpiry(now time.Time) (int, bool) {
+	if u.ExpiresAt == nil {
+		return 0, false
+	}
+	left := u.ExpiresAt.Sub(now)
+	if left <= 0 {
+		return 0, true
+	}
+	return int((left + 24*time.Hour - 1) / (24 * time.Hour)), true
+}
+
+// ExpireAccount moves u down to ExpireToLevel with ApplySecurityLevel: the
+// flags of the old level's profile are taken away, the new level's time
+// limits and flags are applied when levels.json defines it, and no signup
+// points are granted for a level below one already held. The expiry is
+// cleared. The caller saves u.
+func (u *User) ExpireAccount(levels []config.SecurityLevel) ExpiryResult {
+	res := ExpiryResult{
+		ID:        u.ID,
+		Username:  u.Username,
+		Handle:    u.Handle,
+		FromLevel: u.AccessLevel,
+		ToLevel:   u.ExpireToLevel,
+	}
+	if u.ExpiresAt != nil {
+		res.ExpiredAt = *u.ExpiresAt
+	}
+
+	u.ApplySecurityLevel(levels, u.ExpireToLevel)
+	u.ExpiresAt = nil
+	u.ExpireToLevel = 0
+	return res
+}
+
+// Notice returns the subject and body of the private mail telling the user
+// their account has expired. Levels are named from their profiles in levels;
+// the expiry date is shown in loc.
+func (r ExpiryResult) Notice(levels []config.SecurityLevel, loc *time.Location) (subject, body string) {
+	subject = "Your account has expired"
+	body = fmt.Sprintf("Your %s access expired on %s and your account is now %s.\n\n"+
+		"Contact the SysOp to renew it.\n",
+		levelName(levels, r.FromLevel), r.ExpiredAt.In(loc).Format("2006-01-02"), levelName(levels, r.ToLevel))
+	return subject, body
+}
+
+// levelName returns the name of level's profile, or "level N" when it has
+// none.
+func levelName(levels []config.SecurityLevel, level int) string {
+	if p, ok := config.FindSecurityLevel(levels, level); ok && p.Name != "" {
+		return p.Name
+	}
+	return fmt.Sprintf("level %d", level)
+}
+
+// removeFlags returns flags without any of the letters in remove
+// (case-insensitive).
+func removeFlags(flags, remove string) string {
+	remove = strings.ToUpper(remove)
+	var b strings.Builder
+	for _, f := range flags {
+		if !strings.ContainsRune(remove, unicode.ToUpper(f)) {
+			b.WriteRune(f)
+		}
+	}
+	return b.String()
+}
+
+// ExpireAccounts moves every account past its expiry at now down to its
+// ExpireToLevel (see ExpireAccount). Soft-deleted accounts are skipped.
+// Each account is saved on its own. Returns the expired accounts ordered
+// by user ID; accounts that could not be saved are left out and the first
+// such error is returned alongside the rest.
+func (um *UserMgr) ExpireAccounts(levels []config.SecurityLevel, now time.Time) ([]ExpiryResult, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	users, err := um.store.LoadUsers()
+	if err != nil {
+		return nil, fmt.Errorf("expire: failed to load users: %w", err)
+	}
+
+	var expired []ExpiryResult
+	var firstErr error
+	for _, u := range users {
+		if u.DeletedUser || !u.Expired(now) {
+			continue
+		}
+		var res ExpiryResult
+		_, err := um.store.ModifyUser(u.Username, func(u *User) error {
+			// Checked again in case it changed since the list was read.
+			if u.DeletedUser || !u.Expired(now) {
+				return errNoChange
+			}
+			res = u.ExpireAccount(levels)
+			return nil
+		})
+		switch {
+		case err == nil:
+			expired = append(expired, res)
+		case errors.Is(err, errNoChange), errors.Is(err, ErrUserNotFound):
+		default:
+			log.Printf("ERROR: Failed to save expired account %s: %v", u.Username, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("expire: failed to save %s: %w", u.Username, err)
+			}
+		}
+	}
+
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+	if len(expired) > 0 {
+		log.Printf("INFO: Expired %d user account(s)", len(expired))
+	}
+	return expired, firstErr
+}
@@ -0,0 +1,127 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+)
+
+func TestDaysUntilExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { ts := now.Add(d); return &ts }
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		wantDays  int
+		wantOK    bool
+		expired   bool
+	}{
+		{"never", nil, 0, false, false},
+		{"past", at(-time.Hour), 0, true, true},
+		{"exactly now", at(0), 0, true, true},
+		{"later today", at(2 * time.Hour), 1, true, false},
+		{"three days", at(72 * time.Hour), 3, true, false},
+		{"just over three days", at(73 * time.Hour), 4, true, false},
+	}
+	for _, tc := range tests {
+		u := User{ExpiresAt: tc.expiresAt}
+		days, ok := u.DaysUntilExpiry(now)
+		if days != tc.wantDays || ok != tc.wantOK {
+			t.Errorf("%s: DaysUntilExpiry = %d, %v; want %d, %v", tc.name, days, ok, tc.wantDays, tc.wantOK)
+		}
+		if got := u.Expired(now); got != tc.expired {
+			t.Errorf("%s: Expired = %v, want %v", tc.name, got, tc.expired)
+		}
+	}
+}
+
+func TestExpireAccount(t *testing.T) {
+	levels := []config.SecurityLevel{
+		{Level: 20, Name: "Validated", TimeLimit: 60, DailyTime: 90, SignupPoints: 25, Flags: "V"},
+		{Level: 50, Name: "Donor", TimeLimit: 120, DailyTime: 240, Flags: "DX"},
+	}
+	when := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	u := User{ID: 7, Handle: "Donor", AccessLevel: 50, TimeLimit: 120, Flags: "VDXA", FilePoints: 10,
+		ExpiresAt: &when, ExpireToLevel: 20}
+
+	res := u.ExpireAccount(levels)
+
+	if res.FromLevel != 50 || res.ToLevel != 20 || !res.ExpiredAt.Equal(when) {
+		t.Errorf("result = %+v", res)
+	}
+	if u.AccessLevel != 20 || u.TimeLimit != 60 || u.DailyTimeLimit != 90 {
+		t.Errorf("level %d, time %d/%d; want 20, 60/90", u.AccessLevel, u.TimeLimit, u.DailyTimeLimit)
+	}
+	if u.Flags != "VA" {
+		t.Errorf("Flags = %q, want VA", u.Flags)
+	}
+	if u.FilePoints != 10 {
+		t.Errorf("FilePoints = %d, want 10 (no signup points on expiry)", u.FilePoints)
+	}
+	if u.ExpiresAt != nil || u.ExpireToLevel != 0 {
+		t.Errorf("expiry not cleared: %v, %d", u.ExpiresAt, u.ExpireToLevel)
+	}
+}
+
+func TestExpireAccountWithoutProfiles(t *testing.T) {
+	when := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	u := User{AccessLevel: 50, TimeLimit: 120, Flags: "D", ExpiresAt: &when, ExpireToLevel: 10}
+
+	u.ExpireAccount(nil)
+
+	if u.AccessLevel != 10 || u.TimeLimit != 120 || u.Flags != "D" {
+		t.Errorf("level %d, time %d, flags %q; want 10, 120, D", u.AccessLevel, u.TimeLimit, u.Flags)
+	}
+}
+
+func TestExpireAccounts(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.AddDate(0, 0, 10)
+	um := newTestManager(t, []User{
+		{ID: 1, Username: "expired", Handle: "Expired", AccessLevel: 50, ExpiresAt: &past, ExpireToLevel: 20},
+		{ID: 2, Username: "current", Handle: "Current", AccessLevel: 50, ExpiresAt: &future, ExpireToLevel: 20},
+		{ID: 3, Username: "deleted", Handle: "Deleted", AccessLevel: 50, ExpiresAt: &past, ExpireToLevel: 20, DeletedUser: true},
+		{ID: 4, Username: "forever", Handle: "Forever", AccessLevel: 50},
+	})
+
+	expired, err := um.ExpireAccounts(nil, now)
+	if err != nil {
+		t.Fatalf("ExpireAccounts: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Handle != "Expired" {
+		t.Fatalf("expired = %+v, want only Expired", expired)
+	}
+
+	u, _ := um.GetUser("expired")
+	if u.AccessLevel != 20 || u.ExpiresAt != nil {
+		t.Errorf("expired user level %d, expiresAt %v; want 20, nil", u.AccessLevel, u.ExpiresAt)
+	}
+	for _, name := range []string{"current", "deleted", "forever"} {
+		if u, _ := um.GetUser(name); u.AccessLevel != 50 {
+			t.Errorf("%s level = %d, want 50", name, u.AccessLevel)
+		}
+	}
+
+	// A second run finds nothing left to expire.
+	if again, err := um.ExpireAccounts(nil, now); err != nil || len(again) != 0 {
+		t.Errorf("second ExpireAccounts = %v, %v; want none", again, err)
+	}
+}
+
+func TestExpiryNotice(t *testing.T) {
+	levels := []config.SecurityLevel{{Level: 50, Name: "Donor"}}
+	res := ExpiryResult{FromLevel: 50, ToLevel: 20, ExpiredAt: time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)}
+
+	subject, body := res.Notice(levels, time.UTC)
+	if subject == "" {
+		t.Error("empty subject")
+	}
+	want := "Your Donor access expired on 2026-03-01 and your account is now level 20."
+	if !strings.HasPrefix(body, want) {
+		t.Errorf("body = %q, want prefix %q", body, want)
+	}
+}
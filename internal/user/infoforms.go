@@ -0,0 +1,37 @@
+package user
+
+import (
+	"maps"
+	"time"
+
+	"github.com/stlalpha/vision3/internal/config"
+)
+
+// InfoFormAnswers is a user's answers to one infoform.
+type InfoFormAnswers struct {
+	Version  int               `json:"version"`  // form version the answers were given for
+	Answered time.Time         `json:"answered"` // when the form was last filled in
+	Answers  map[string]string `json:"answers"`  // question ID -> answer
+}
+
+// InfoFormAnswered returns u's answers to form id, if they have any.
+func (u *User) InfoFormAnswered(id int) (InfoFormAnswers, bool) {
+	a, ok := u.InfoForms[id]
+	return a, ok
+}
+
+// InfoFormCurrent reports whether u has answered the current version of f.
+func (u *User) InfoFormCurrent(f config.InfoForm) bool {
+	a, ok := u.InfoForms[f.ID]
+	return ok && a.Version >= f.Version
+}
+
+// SetInfoFormAnswers stores u's answers to form id. The map is copied so
+// other copies of the user (the manager's, a session's) are not changed
+// under them. The caller saves u.
+func (u *User) SetInfoFormAnswers(id int, a InfoFormAnswers) {
+	forms := make(map[int]InfoFormAnswers, len(u.InfoForms)+1)
+	maps.Copy(forms, u.InfoForms)
+	forms[id] = a
+	u.InfoForms = forms
+}
@@ -0,0 +1,32 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stlalpha/vision3/internal/config"
+)
+
+func TestInfoFormAnswers(t *testing.T) {
+	form := config.InfoForm{ID: 1, Version: 2}
+	u := User{}
+	if u.InfoFormCurrent(form) {
+		t.Error("unanswered form reported current")
+	}
+
+	u.SetInfoFormAnswers(1, InfoFormAnswers{Version: 1, Answers: map[string]string{"age": "42"}})
+	if u.InfoFormCurrent(form) {
+		t.Error("answers to version 1 reported current for version 2")
+	}
+
+	shared := u // another copy sharing the same map
+	u.SetInfoFormAnswers(1, InfoFormAnswers{Version: 2, Answers: map[string]string{"age": "43"}})
+	if !u.InfoFormCurrent(form) {
+		t.Error("answers to version 2 not current")
+	}
+	if a, _ := shared.InfoFormAnswered(1); a.Version != 1 {
+		t.Errorf("other copy changed: version %d, want 1", a.Version)
+	}
+	if a, ok := u.InfoFormAnswered(1); !ok || a.Answers["age"] != "43" {
+		t.Errorf("InfoFormAnswered = %+v, %v", a, ok)
+	}
+}
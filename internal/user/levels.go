@@ -0,0 +1,72 @@
+package user
+
+import (
+	"strings"
+
+	"github.com/stlalpha/vision3/internal/config"
+)
+
+// ApplySecurityLevel moves u to level. The default flags of the old
+// level's profile are taken away; flags the user was given some other way
+// are kept. When levels defines the new level, its time limits are copied
+// and its default flags added. Its signup points are granted only when the
+// user rises above every level they have held, so moving a user down and
+// back up does not grant them again. Returns the new level's profile, if
+// any. The caller saves u.
+func (u *User) ApplySecurityLevel(levels []config.SecurityLevel, level int) (config.SecurityLevel, bool) {
+	if old, ok := config.FindSecurityLevel(levels, u.AccessLevel); ok {
+		u.Flags = removeFlags(u.Flags, old.Flags)
+	}
+	highest := max(u.HighestLevel, u.AccessLevel)
+	u.AccessLevel = level
+	u.HighestLevel = max(highest, level)
+
+	p, ok := config.FindSecurityLevel(levels, level)
+	if !ok {
+		return p, false
+	}
+	u.TimeLimit = p.TimeLimit
+	u.DailyTimeLimit = p.DailyTime
+	if level > highest {
+		u.FilePoints += p.SignupPoints
+	}
+	for _, f := range strings.ToUpper(p.Flags) {
+		if !strings.ContainsRune(strings.ToUpper(u.Flags), f) {
+			u.Flags += string(f)
+		}
+	}
+	return p, true
+}
+
+// CallsOn returns the user's logons on day (YYYY-MM-DD).
+func (u *User) CallsOn(day string) int {
+	if u.CallsDate != day {
+		return 0
+	}
+	return u.CallsToday
+}
+
+// MailSentOn returns the private mails the user has sent on day (YYYY-MM-DD).
+func (u *User) MailSentOn(day string) int {
+	if u.MailSentDate != day {
+		return 0
+	}
+	return u.MailSentToday
+}
+
+// RecordDailyCall counts a logon on day, starting a new count when the day
+// has changed. Returns the user's calls on day including this one.
+func (um *UserMgr) RecordDailyCall(username, day string) (int, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	u, err := um.store.ModifyUser(username, func(u *User) error {
+		u.CallsToday = u.CallsOn(day) + 1
+		u.CallsDate = day
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return u.CallsToday, nil
+}
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stlalpha/vision3/internal/config"
+)
+
+func TestApplySecurityLevel(t *testing.T) {
+	levels := []config.SecurityLevel{
+		{Level: 10, TimeLimit: 30, Flags: "A"},
+		{Level: 20, TimeLimit: 60, DailyTime: 120, SignupPoints: 50, Flags: "bcd"},
+	}
+	u := User{AccessLevel: 10, TimeLimit: 30, Flags: "AX", FilePoints: 5}
+	if _, ok := u.ApplySecurityLevel(levels, 20); !ok {
+		t.Fatal("level 20 profile not found")
+	}
+
+	if u.AccessLevel != 20 || u.TimeLimit != 60 || u.DailyTimeLimit != 120 {
+		t.Errorf("level %d, time %d/%d; want 20, 60/120", u.AccessLevel, u.TimeLimit, u.DailyTimeLimit)
+	}
+	if u.FilePoints != 55 {
+		t.Errorf("FilePoints = %d, want 55", u.FilePoints)
+	}
+	if u.Flags != "XBCD" {
+		t.Errorf("Flags = %q, want XBCD (level 10's A taken away)", u.Flags)
+	}
+
+	// Down and back up: the level 20 flags go and return, the points do not.
+	u.ApplySecurityLevel(levels, 10)
+	if u.Flags != "XA" || u.TimeLimit != 30 {
+		t.Errorf("after demotion flags %q time %d; want XA, 30", u.Flags, u.TimeLimit)
+	}
+	u.ApplySecurityLevel(levels, 20)
+	if u.FilePoints != 55 {
+		t.Errorf("FilePoints = %d after re-promotion, want 55", u.FilePoints)
+	}
+
+	// A level without a profile only changes the level.
+	if _, ok := u.ApplySecurityLevel(levels, 30); ok || u.AccessLevel != 30 || u.HighestLevel != 30 {
+		t.Errorf("level 30: ok=%v level %d highest %d", ok, u.AccessLevel, u.HighestLevel)
+	}
+}
+
+func TestRecordDailyCall(t *testing.T) {
+	um := newTestManager(t, []User{{ID: 1, Username: "alice", Handle: "Alice", CallsToday: 2, CallsDate: "2026-03-01"}})
+
+	n, err := um.RecordDailyCall("alice", "2026-03-01")
+	if err != nil || n != 3 {
+		t.Errorf("RecordDailyCall = %d, %v; want 3", n, err)
+	}
+	n, err = um.RecordDailyCall("alice", "2026-03-02")
+	if err != nil || n != 1 {
+		t.Errorf("RecordDailyCall next day = %d, %v; want 1", n, err)
+	}
+
+	u, _ := um.GetUser("alice")
+	if got := u.CallsOn("2026-03-02"); got != 1 {
+		t.Errorf("CallsOn = %d, want 1", got)
+	}
+	if got := u.CallsOn("2026-03-01"); got != 0 {
+		t.Errorf("CallsOn earlier day = %d, want 0", got)
+	}
+
+	if _, err := um.RecordDailyCall("nobody", "2026-03-02"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("missing user: err = %v", err)
+	}
+}
@@ -19,6 +19,10 @@ var (
 	ErrUserNotFound = errors.New("user not found")
 	ErrUserExists   = errors.New("username already exists")
 	ErrHandleExists = errors.New("handle already exists")
+
+	// errNoChange is returned by a ModifyUser function to leave the user
+	// as it was.
+	errNoChange = errors.New("no change")
 	// MaxLastLogins   = 10 // Removed MaxLastLogins constant
 )
 
@@ -40,51 +44,54 @@ func StripUTF8BOM(data []byte) []byte {
 	return data
 }
 
-// UserMgr manages user data (Renamed from UserManager)
+// UserMgr manages user data (Renamed from UserManager). Users are read from
+// the store on every lookup rather than held in memory.
 type UserMgr struct {
-	users          map[string]*User
 	mu             sync.RWMutex
-	path           string // Path to users.json
-	dataPath       string // Path to the data directory (for callhistory.json etc)
+	store          Store  // Where users and call history are persisted
+	path           string // Path to the user file (users.json)
+	dataPath       string // Path to the data directory (for the activity and admin logs)
 	newUserLevel   int    // Access level assigned to new signups (from config)
-	nextUserID     int    // Added to track the next available user ID
+	policy         PasswordPolicy // Password rules and bcrypt cost (from config)
+	totpRequiredLevel int         // Level at which two-factor login is required, 0 = never (from config)
 	callHistory    []CallRecord    // Added slice for call history
 	nextCallNumber uint64          // Added counter for overall calls
 	activeUserIDs  map[int32]bool  // Track which user IDs are currently online
 }
 
-// NewUserManager creates and initializes a new user manager
+// NewUserManager creates and initializes a new user manager backed by the
+// JSON files in dataPath.
 func NewUserManager(dataPath string) (*UserMgr, error) { // Return renamed type
+	return NewUserManagerWithStore(dataPath, NewJSONStore(dataPath))
+}
+
+// NewUserManagerWithStore creates and initializes a user manager that loads
+// from and saves to store. dataPath holds the logs kept outside the store.
+func NewUserManagerWithStore(dataPath string, store Store) (*UserMgr, error) {
 	um := &UserMgr{ // Use renamed type
-		users:        make(map[string]*User),
-		path:         filepath.Join(dataPath, userFile), // userFile path uses dataPath now
+		store:        store,
+		path:         store.Path(),
 		newUserLevel: 1,                                 // Default to 1, will be overridden by SetNewUserLevel
+		policy:       DefaultPasswordPolicy(),           // Overridden by SetPasswordPolicy
 		dataPath: dataPath,                          // Store the data path
 		// LastLogins:  make([]LoginEvent, 0, MaxLastLogins), // Removed LastLogins initialization
 		callHistory:    make([]CallRecord, 0, callHistoryLimit), // Initialize call history
-		nextUserID:     1,                                       // Start user IDs from 1
 		nextCallNumber: 1,                                       // Start call numbers from 1
 		activeUserIDs:  make(map[int32]bool),                    // Initialize online user tracking
 	}
 
 	// Removed call to loadLastLogins
 
-	// Load call history using the stored dataPath
+	// Load call history and the next call number
 	if err := um.loadCallHistory(); err != nil {
 		// Log warning but continue
 		log.Printf("WARN: Failed to load call history: %v", err)
 	}
 
-	// Load the next call number
-	if err := um.loadNextCallNumber(); err != nil {
-		// Log warning but continue, using the default start value of 1
-		log.Printf("WARN: Failed to load next call number: %v", err)
-	}
-
 	if err := um.loadUsers(); err != nil {
 		// If loading fails (e.g., file not found), create default felonius user
-		if os.IsNotExist(err) {
-			log.Println("INFO: users.json not found, creating default felonius user.")
+		if errors.Is(err, os.ErrNotExist) {
+			log.Printf("INFO: No users in %s, creating default felonius user.", um.path)
 			// AddUser will handle ID assignment and initialization
 			// Using "password" as default password
 			defaultUser, addErr := um.AddUser("felonius", "password", "Felonius", "Felonius", "", "FAiRLiGHT/PC")
@@ -92,251 +99,85 @@ func NewUserManager(dataPath string) (*UserMgr, error) { // Return renamed type
 				return nil, fmt.Errorf("failed to create default felonius user: %w", addErr)
 			}
 			// Update felonius user fields after AddUser returns it
-			um.mu.Lock()                 // Lock necessary for direct modification
-			defaultUser.AccessLevel = 10 // Default user level
-			defaultUser.Validated = true // Default user is validated
-			// Ensure we update the map entry after modifying
-			um.users[strings.ToLower(defaultUser.Username)] = defaultUser
+			um.mu.Lock()
+			_, saveErr := um.store.ModifyUser(defaultUser.Username, func(u *User) error {
+				u.AccessLevel = 10 // Default user level
+				u.Validated = true // Default user is validated
+				return nil
+			})
 			um.mu.Unlock()
 
-			// Save again to ensure level/validation is persisted
-			if saveErr := um.SaveUsers(); saveErr != nil {
+			// Check the second save so level/validation is persisted
+			if saveErr != nil {
 				return nil, fmt.Errorf("failed to save default felonius user details: %w", saveErr)
 			}
 			log.Println("INFO: Default felonius user created (felonius/password).")
-			// Determine next user ID after creating the default user
-			um.determineNextUserID()
 			return um, nil // Return successfully after creating default
 		} else {
 			// Other load error
 			return nil, fmt.Errorf("failed to load users: %w", err)
 		}
 	}
-	// If load was successful, determine nextUserID
-	um.determineNextUserID()
 	return um, nil
 }
 
-// loadUsers loads user data from the JSON file.
-func (um *UserMgr) loadUsers() error { // Receiver uses renamed type
-	data, err := os.ReadFile(um.path)
+// loadUsers checks that the store can be read. The error wraps
+// os.ErrNotExist when no users have ever been stored.
+func (um *UserMgr) loadUsers() error {
+	n, err := um.store.CountUsers()
 	if err != nil {
-		return err // Return error to NewUserManager to handle
-	}
-	data = StripUTF8BOM(data)
-
-	// Temporary slice to hold users from JSON array
-	// We load into a slice because the JSON is an array.
-	var usersList []*User // Load into a slice of pointers to handle omitempty correctly
-	if err := json.Unmarshal(data, &usersList); err != nil {
-		return fmt.Errorf("failed to unmarshal users array: %w", err)
+		return err
 	}
-
-	um.mu.Lock()
-	defer um.mu.Unlock()
-	// Ensure map is initialized
-	if um.users == nil {
-		um.users = make(map[string]*User)
-	}
-
-	// Populate the map from the slice
-	for _, user := range usersList { // Iterate directly over the slice of pointers
-		if user == nil { // Safety check for nil entries in JSON array
-			continue
-		}
-		lowerUsername := strings.ToLower(user.Username)
-		if _, exists := um.users[lowerUsername]; exists {
-			log.Printf("WARN: Duplicate username found in users.json: %s. Skipping subsequent entry.", user.Username)
-			continue
+	if n == 0 {
+		if _, err := um.store.LoadUsers(); err != nil {
+			return err
 		}
-		um.users[lowerUsername] = user
-		log.Printf("TRACE: Loaded user %s (Handle: %s, Group: %s) from JSON.", user.Username, user.Handle, user.GroupLocation)
 	}
-
-	// Note: determineNextUserID should be called *after* successful load
-	// but *outside* the lock (or re-acquire read lock if needed) if called from NewUserManager.
-	// It's called from NewUserManager after this returns.
+	log.Printf("DEBUG: %d user(s) in %s", n, filepath.Base(um.path))
 	return nil
 }
 
-// determineNextUserID finds the max existing ID and sets nextUserID appropriately.
-// Should be called after users are loaded.
-func (um *UserMgr) determineNextUserID() { // Receiver uses renamed type
-	um.mu.RLock() // Use read lock
-	maxID := 0
-	for _, u := range um.users {
-		if u.ID > maxID {
-			maxID = u.ID
-		}
-	}
-	um.mu.RUnlock()
-
-	um.mu.Lock() // Need write lock to set nextUserID
-	um.nextUserID = maxID + 1
-	log.Printf("DEBUG: Determined next User ID to be %d", um.nextUserID)
-	um.mu.Unlock()
-}
-
-// loadCallHistory loads the call history events from JSON.
-// Now uses um.dataPath internally.
+// loadCallHistory loads the recent call history and the next call number
+// from the store.
 func (um *UserMgr) loadCallHistory() error {
-	filePath := filepath.Join(um.dataPath, callHistoryFile) // Use stored dataPath
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("INFO: %s not found, starting with empty call history list.", callHistoryFile)
-			return nil // Not an error if the file doesn't exist yet
-		}
-		return fmt.Errorf("failed to read %s: %w", callHistoryFile, err)
-	}
-
-	data = StripUTF8BOM(data)
-	if len(data) == 0 {
-		return nil // Empty file is okay
-	}
+	calls, next, err := um.store.LoadCallHistory(callHistoryLimit)
 
-	um.mu.Lock() // Lock before modifying internal slice
+	um.mu.Lock() // Lock before modifying internal state
 	defer um.mu.Unlock()
-	// Ensure slice exists
-	if um.callHistory == nil {
-		um.callHistory = make([]CallRecord, 0, callHistoryLimit)
+	if next > 0 {
+		um.nextCallNumber = next
 	}
-	if err := json.Unmarshal(data, &um.callHistory); err != nil {
-		return fmt.Errorf("failed to unmarshal %s: %w", callHistoryFile, err)
-	}
-	// Ensure capacity and length limits are respected after loading
-	if len(um.callHistory) > callHistoryLimit {
-		startIdx := len(um.callHistory) - callHistoryLimit
-		um.callHistory = um.callHistory[startIdx:]
-	}
-	log.Printf("DEBUG: Loaded %d call history records from %s", len(um.callHistory), callHistoryFile)
-	return nil
-}
-
-// loadNextCallNumber loads the next call number from its dedicated JSON file.
-func (um *UserMgr) loadNextCallNumber() error {
-	filePath := filepath.Join(um.dataPath, callNumberFile)
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("INFO: %s not found, starting call numbers from 1.", callNumberFile)
-			// Keep the default um.nextCallNumber = 1
-			return nil // Not an error if the file doesn't exist
-		}
-		return fmt.Errorf("failed to read %s: %w", callNumberFile, err)
-	}
-
-	data = StripUTF8BOM(data)
-	if len(data) == 0 {
-		log.Printf("WARN: %s is empty, starting call numbers from 1.", callNumberFile)
-		return nil // Empty file, use default
-	}
-
-	um.mu.Lock() // Lock before modifying
-	defer um.mu.Unlock()
-	if err := json.Unmarshal(data, &um.nextCallNumber); err != nil {
-		// If unmarshal fails, log and keep the default
-		log.Printf("WARN: Failed to unmarshal %s: %v. Starting call numbers from 1.", callNumberFile, err)
-		um.nextCallNumber = 1
-		return nil // Don't return error, just use default
-	}
-
-	log.Printf("DEBUG: Loaded next call number %d from %s", um.nextCallNumber, callNumberFile)
-	return nil
-}
-
-// saveCallHistoryLocked saves the current callHistory slice to JSON (assumes lock is held).
-// Now uses um.dataPath internally.
-func (um *UserMgr) saveCallHistoryLocked() error {
-	if um.callHistory == nil {
-		// Avoid marshaling nil slice, treat as empty
-		um.callHistory = make([]CallRecord, 0)
-	}
-	data, err := json.MarshalIndent(um.callHistory, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal call history: %w", err)
-	}
-
-	filePath := filepath.Join(um.dataPath, callHistoryFile) // Use stored dataPath
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write %s: %w", callHistoryFile, err)
-	}
-
-	// Also save the next call number (atomically with history? separate file is simpler for now)
-	if err := um.saveNextCallNumberLocked(); err != nil {
-		// Log error but don't fail the history save if number save fails
-		log.Printf("ERROR: Failed to save next call number: %v", err)
-	}
-
-	return nil
-}
-
-// saveNextCallNumberLocked saves the current nextCallNumber to its JSON file (assumes lock is held).
-func (um *UserMgr) saveNextCallNumberLocked() error {
-	data, err := json.Marshal(um.nextCallNumber) // Simple marshal, no indent needed
 	if err != nil {
-		return fmt.Errorf("failed to marshal next call number: %w", err)
-	}
-
-	filePath := filepath.Join(um.dataPath, callNumberFile)
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write %s: %w", callNumberFile, err)
+		return err
 	}
+	um.callHistory = append(make([]CallRecord, 0, callHistoryLimit), calls...)
 	return nil
 }
 
-// saveUsersLocked performs the actual saving without acquiring locks.
-// Uses um.path (which should point to data/users.json)
-func (um *UserMgr) saveUsersLocked() error { // Receiver uses renamed type
-	// Convert map back to slice for saving as JSON array
-	// We now store pointers in the map, so create a slice of pointers
-	usersList := make([]*User, 0, len(um.users))
-	for _, user := range um.users {
-		usersList = append(usersList, user) // Append pointers directly
-	}
 
-	data, err := json.MarshalIndent(usersList, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal users slice: %w", err)
-	}
-
-	// Ensure the directory exists before writing the file
-	dir := filepath.Dir(um.path)
-	if err := os.MkdirAll(dir, 0750); err != nil { // Use 0750 for permissions
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
-	}
-
-	// WriteFile ensures atomic write (usually via temp file)
-	if err = os.WriteFile(um.path, data, 0600); err != nil {
-		return fmt.Errorf("failed to write users file %s: %w", um.path, err) // Include path in error
-	}
-	return nil
-}
-
-// SaveUsers saves the current user data to the JSON file (acquires lock).
-func (um *UserMgr) SaveUsers() error { // Receiver uses renamed type
+// Close closes the user store. The manager must not be used afterwards.
+func (um *UserMgr) Close() error {
 	um.mu.Lock()
 	defer um.mu.Unlock()
-	return um.saveUsersLocked()
+	return um.store.Close()
 }
 
-// UpdateUser copies the modified user back into the internal map and saves to disk.
-// Use this instead of SaveUsers when you have modified a user copy obtained from
-// GetUser or Authenticate and need those changes persisted.
+// UpdateUser saves a modified copy of a user obtained from GetUser or
+// Authenticate, replacing the whole stored record.
 func (um *UserMgr) UpdateUser(u *User) error {
 	if u == nil {
 		return fmt.Errorf("cannot update nil user")
 	}
 	um.mu.Lock()
 	defer um.mu.Unlock()
-	lowerUsername := strings.ToLower(u.Username)
-	if _, exists := um.users[lowerUsername]; !exists {
-		return ErrUserNotFound
-	}
-	// Create a defensive copy to prevent external mutations from bypassing locks
+	// Replace the record in one step; the copy keeps later changes by the
+	// caller from being picked up
 	userCopy := *u
-	um.users[lowerUsername] = &userCopy
-	return um.saveUsersLocked()
+	_, err := um.store.ModifyUser(u.Username, func(stored *User) error {
+		*stored = userCopy
+		return nil
+	})
+	return err
 }
 
 // LogAdminActivity logs an administrative action to the activity log file
@@ -345,7 +186,7 @@ func (um *UserMgr) LogAdminActivity(logEntry AdminActivityLog) error {
 	defer um.mu.Unlock()
 
 	// Load existing logs
-	logPath := filepath.Join(filepath.Dir(um.path), adminLogFile)
+	logPath := filepath.Join(um.dataPath, adminLogFile)
 	var logs []AdminActivityLog
 
 	// Try to load existing logs
@@ -377,139 +218,123 @@ func (um *UserMgr) LogAdminActivity(logEntry AdminActivityLog) error {
 	return nil
 }
 
-// Authenticate checks username and compares password hash.
+// Authenticate checks username and compares password hash, then records
+// the login. It has no second-factor step, so accounts with two-factor login
+// on, or whose level requires it (see SetTOTPRequiredLevel), are refused;
+// the BBS login uses CheckPassword and RecordLogin instead. Reset codes are
+// not accepted.
 // Returns: (user, success)
 func (um *UserMgr) Authenticate(username, password string) (*User, bool) { // Receiver uses renamed type
-	lowerUsername := strings.ToLower(username)
-
-	um.mu.RLock()
-	user, exists := um.users[lowerUsername]
-	if !exists {
-		um.mu.RUnlock()
-		return nil, false
-	}
-	// Deny login if user is deleted
-	if user.DeletedUser {
-		um.mu.RUnlock()
+	// Deleted accounts never match; a low-cost hash is upgraded.
+	u, ok := um.verifyPassword(strings.ToLower(username), password)
+	if !ok {
 		return nil, false
 	}
-	// Copy the password hash while holding the read lock
-	passwordHash := user.PasswordHash
+	um.mu.RLock()
+	totpLevel := um.totpRequiredLevel
 	um.mu.RUnlock()
-
-	// Compare hashed password outside any lock (bcrypt is CPU-intensive)
-	err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
-	if err != nil {
+	if u.TOTPEnabled() || (totpLevel > 0 && u.AccessLevel >= totpLevel) {
+		log.Printf("WARN: Refused login for %s without a second factor", u.Username)
 		return nil, false
 	}
 
 	// Authentication successful - update LastLogin and TimesCalled
+	return um.RecordLogin(username)
+}
+
+// RecordLogin updates LastLogin and TimesCalled for a user who has passed
+// every login check, such as CheckPassword followed by a two-factor code.
+// Returns a copy of the updated user.
+func (um *UserMgr) RecordLogin(username string) (*User, bool) {
 	um.mu.Lock()
-	user = um.users[lowerUsername] // Re-fetch under write lock
-	if user == nil {
-		um.mu.Unlock()
+	defer um.mu.Unlock()
+	user, err := um.store.ModifyUser(username, func(u *User) error {
+		u.LastLogin = time.Now()
+		u.TimesCalled++
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			log.Printf("ERROR: Failed to save user data after successful login for %s: %v", username, err)
+		}
 		return nil, false
 	}
-	user.LastLogin = time.Now()
-	user.TimesCalled++
-	um.mu.Unlock()
-
-	// Save outside the write lock to avoid blocking other user operations
-	if err := um.SaveUsers(); err != nil {
-		log.Printf("ERROR: Failed to save user data after successful login for %s: %v", username, err)
-	}
+	return user, true
+}
 
-	// Return a copy
-	um.mu.RLock()
-	userCopy := *um.users[lowerUsername]
-	um.mu.RUnlock()
-	return &userCopy, true
+// CheckPassword verifies a user's password without recording a login. It is
+// for services such as SFTP that authenticate outside the BBS login flow, and
+// for logins that still have a two-factor step before RecordLogin.
+// Deleted accounts never match, and reset codes are not accepted (see
+// RedeemResetCode). Returns a copy of the user on success.
+func (um *UserMgr) CheckPassword(username, password string) (*User, bool) {
+	return um.verifyPassword(username, password)
 }
 
 // GetUser retrieves a user by username.
-// Returns a copy to prevent callers from mutating internal state without the lock.
+// Returns a copy; save changes with UpdateUser.
 func (um *UserMgr) GetUser(username string) (*User, bool) { // Receiver uses renamed type
-	um.mu.RLock()
-	defer um.mu.RUnlock()
+	return um.found(um.store.UserByName(username))
+}
 
-	user, exists := um.users[strings.ToLower(username)] // Use lower case for lookup
-	if !exists {
+// found turns a store lookup into the (user, ok) form UserMgr returns,
+// logging errors other than ErrUserNotFound.
+func (um *UserMgr) found(u *User, err error) (*User, bool) {
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			log.Printf("ERROR: Failed to look up user in %s: %v", um.path, err)
+		}
 		return nil, false
 	}
-	userCopy := *user
-	return &userCopy, true
+	return u, true
 }
 
 // GetUserByHandle retrieves a user by their handle (case-insensitive search).
 func (um *UserMgr) GetUserByHandle(handle string) (*User, bool) { // Receiver uses renamed type
-	um.mu.RLock()
-	defer um.mu.RUnlock()
-
-	lowerHandle := strings.ToLower(handle)
-	for _, user := range um.users {
-		if strings.ToLower(user.Handle) == lowerHandle {
-			// Return a copy to prevent modification of the internal user data
-			userCopy := *user
-			return &userCopy, true
-		}
-	}
-	return nil, false
+	return um.found(um.store.UserByHandle(handle))
 }
 
 // GetUserByID returns a user by their ID (for optimistic locking checks)
 func (um *UserMgr) GetUserByID(id int) (*User, bool) {
-	um.mu.RLock()
-	defer um.mu.RUnlock()
-
-	for _, user := range um.users {
-		if user.ID == id {
-			// Return a copy to prevent modification of the internal user data
-			userCopy := *user
-			return &userCopy, true
-		}
-	}
-	return nil, false
+	return um.found(um.store.UserByID(id))
 }
 
 // NextUserID returns the ID that will be assigned to the next new user.
 func (um *UserMgr) NextUserID() int {
-	um.mu.RLock()
-	defer um.mu.RUnlock()
-	return um.nextUserID
+	next, err := um.store.NextUserID()
+	if err != nil {
+		log.Printf("ERROR: Failed to read the next user ID from %s: %v", um.path, err)
+	}
+	return next
 }
 
 // AddUser creates a new user, hashes the password, assigns an ID, and saves.
 // Added GroupLocation parameter.
 func (um *UserMgr) AddUser(username, password, handle, realName, phoneNum, groupLocation string) (*User, error) { // Receiver uses renamed type
-	lowerUsername := strings.ToLower(username)
-	lowerHandle := strings.ToLower(handle)
-
 	um.mu.Lock()
 	defer um.mu.Unlock()
 
-	// Check if username already exists
-	if _, exists := um.users[lowerUsername]; exists {
+	// Check if username or handle already exists before paying for bcrypt;
+	// the store checks again when adding
+	if _, err := um.store.UserByName(username); err == nil {
 		return nil, ErrUserExists
 	}
-
-	// Check if handle already exists
-	for _, u := range um.users {
-		if strings.ToLower(u.Handle) == lowerHandle {
-			return nil, ErrHandleExists
-		}
+	if _, err := um.store.UserByHandle(handle); err == nil {
+		return nil, ErrHandleExists
 	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), um.policy.HashCost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
+	now := time.Now()
 
 	// Create new user
 	newUser := &User{
-		ID:            um.nextUserID,    // Assign the next available ID
 		Username:      username,
 		PasswordHash:  string(hashedPassword),
+		PasswordChangedAt: &now,
 		Handle:        handle,
 		RealName:      realName,
 		PhoneNumber:   phoneNum,
@@ -521,17 +346,11 @@ func (um *UserMgr) AddUser(username, password, handle, realName, phoneNum, group
 		// Initialize other fields as needed
 	}
 
-	// Add to map and increment nextUserID
-	um.users[lowerUsername] = newUser
-	um.nextUserID++
-
-	// Save the updated user list *while still holding the lock*
-	if err := um.saveUsersLocked(); err != nil {
-		log.Printf("ERROR: Failed to save users after adding %s: %v", username, err)
-		// If save fails, should we attempt to roll back the in-memory add?
-		// For now, return the error, the user exists in memory but not saved.
-		delete(um.users, lowerUsername) // Rollback in-memory add on save failure
-		um.nextUserID--                 // Rollback ID increment
+	// The store assigns the next available ID
+	if err := um.store.AddUser(newUser); err != nil {
+		if !errors.Is(err, ErrUserExists) && !errors.Is(err, ErrHandleExists) {
+			log.Printf("ERROR: Failed to save users after adding %s: %v", username, err)
+		}
 		return nil, err
 	}
 
@@ -562,8 +381,8 @@ func (um *UserMgr) AddCallRecord(record CallRecord) {
 		um.callHistory = um.callHistory[1:]
 	}
 
-	// Save the updated history *while still holding the lock*
-	if err := um.saveCallHistoryLocked(); err != nil {
+	// Save the record and call number *while still holding the lock*
+	if err := um.store.AddCallRecords([]CallRecord{record}, um.nextCallNumber, callHistoryLimit); err != nil {
 		log.Printf("ERROR: Failed to save call history after adding record for user %d: %v", record.UserID, err)
 		// Maybe try to rollback the append? Less critical than user add.
 	}
@@ -580,25 +399,26 @@ func (um *UserMgr) GetLastCallers() []CallRecord {
 	return historyCopy
 }
 
-// GetAllUsers returns a slice containing copies of all user records.
-// Returns copies to prevent callers from mutating internal state.
+// GetAllUsers returns a slice containing copies of all user records, read
+// from the store.
 func (um *UserMgr) GetAllUsers() []*User {
-	um.mu.RLock()
-	defer um.mu.RUnlock()
-
-	usersSlice := make([]*User, 0, len(um.users))
-	for _, user := range um.users {
-		userCopy := *user
-		usersSlice = append(usersSlice, &userCopy)
+	users, err := um.store.LoadUsers()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("ERROR: Failed to load users from %s: %v", um.path, err)
+		}
+		return []*User{}
 	}
-	return usersSlice
+	return users
 }
 
 // GetUserCount returns the total number of registered users.
 func (um *UserMgr) GetUserCount() int {
-	um.mu.RLock()
-	defer um.mu.RUnlock()
-	return len(um.users)
+	n, err := um.store.CountUsers()
+	if err != nil {
+		log.Printf("ERROR: Failed to count users in %s: %v", um.path, err)
+	}
+	return n
 }
 
 // GetTotalCalls returns the total number of calls (logins) recorded.
@@ -675,21 +495,24 @@ func (um *UserMgr) PurgeDeletedUsers(retentionDays int) ([]PurgeResult, error) {
 	um.mu.Lock()
 	defer um.mu.Unlock()
 
-	// Phase 1: identify eligible users without modifying the map yet.
+	users, err := um.store.LoadUsers()
+	if err != nil {
+		return nil, fmt.Errorf("purge: failed to load users: %w", err)
+	}
+
+	// Phase 1: identify eligible users.
 	type candidate struct {
-		key    string
 		user   *User
 		result PurgeResult
 	}
 	var candidates []candidate
-	for key, u := range um.users {
+	for _, u := range users {
 		if !u.DeletedUser {
 			continue
 		}
 		if u.DeletedAt == nil {
 			// Deleted but no timestamp: treat as immediately eligible.
 			candidates = append(candidates, candidate{
-				key:  key,
 				user: u,
 				result: PurgeResult{
 					ID:       u.ID,
@@ -699,7 +522,6 @@ func (um *UserMgr) PurgeDeletedUsers(retentionDays int) ([]PurgeResult, error) {
 			})
 		} else if u.DeletedAt.Before(cutoff) {
 			candidates = append(candidates, candidate{
-				key:  key,
 				user: u,
 				result: PurgeResult{
 					ID:        u.ID,
@@ -715,15 +537,12 @@ func (um *UserMgr) PurgeDeletedUsers(retentionDays int) ([]PurgeResult, error) {
 		return nil, nil
 	}
 
-	// Phase 2: remove from in-memory store, then persist.
-	// Roll back in-memory changes if save fails so the store stays consistent.
-	for _, c := range candidates {
-		delete(um.users, c.key)
+	// Phase 2: remove them all in one write.
+	deleted := make([]*User, len(candidates))
+	for i, c := range candidates {
+		deleted[i] = c.user
 	}
-	if err := um.saveUsersLocked(); err != nil {
-		for _, c := range candidates {
-			um.users[c.key] = c.user
-		}
+	if err := um.store.DeleteUsers(deleted...); err != nil {
 		return nil, fmt.Errorf("purge: failed to save users: %w", err)
 	}
 
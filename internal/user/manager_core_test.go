@@ -422,6 +422,26 @@ func TestAuthenticate_UpdatesLastLoginAndTimesCalled(t *testing.T) {
 	}
 }
 
+func TestAuthenticate_RefusesSecondFactorAccounts(t *testing.T) {
+	pw := "pass"
+	seed := []User{
+		{ID: 1, Username: "alice", Handle: "Alice", PasswordHash: hashPassword(t, pw), AccessLevel: 5, TOTPSecret: "JBSWY3DPEHPK3PXP"},
+		{ID: 2, Username: "sysop", Handle: "Sysop", PasswordHash: hashPassword(t, pw), AccessLevel: 255},
+	}
+	um := newTestManager(t, seed)
+	um.SetTOTPRequiredLevel(100)
+
+	if _, ok := um.Authenticate("alice", pw); ok {
+		t.Error("expected authentication to fail for an account with two-factor login on")
+	}
+	if _, ok := um.Authenticate("sysop", pw); ok {
+		t.Error("expected authentication to fail for a level that requires two-factor login")
+	}
+	if u, _ := um.GetUser("alice"); u.TimesCalled != 0 {
+		t.Errorf("refused login was recorded: TimesCalled=%d", u.TimesCalled)
+	}
+}
+
 // --- UpdateUser ---
 
 func TestUpdateUser_Success(t *testing.T) {
@@ -0,0 +1,345 @@
+package user
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stlalpha/vision3/internal/config"
+)
+
+// MinPasswordLength is the shortest password any policy accepts.
+const MinPasswordLength = 3
+
+var (
+	ErrPasswordTooShort = errors.New("password is too short")
+	ErrPasswordReused   = errors.New("password was used recently")
+)
+
+// PasswordPolicy controls what passwords are accepted and how they are
+// stored. It is applied at signup and whenever a password is changed;
+// existing passwords are not checked against it.
+type PasswordPolicy struct {
+	MinLength  int // Shortest password accepted
+	History    int // Recent passwords, the current one included, that may not be reused (0 = no check)
+	MaxAgeDays int // Days before a password must be changed (0 = never)
+	HashCost   int // bcrypt cost for new hashes; lower-cost hashes are upgraded at login
+	ResetHours int // How long a sysop-issued reset code stays valid
+}
+
+// DefaultPasswordPolicy is the policy used until SetPasswordPolicy is called.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:  6,
+		History:    3,
+		HashCost:   bcrypt.DefaultCost,
+		ResetHours: 24,
+	}
+}
+
+// PasswordPolicyFromConfig returns the policy set in cfg, clamped as by
+// SetPasswordPolicy.
+func PasswordPolicyFromConfig(cfg config.ServerConfig) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:  cfg.PasswordMinLength,
+		History:    cfg.PasswordHistory,
+		MaxAgeDays: cfg.PasswordMaxAgeDays,
+		HashCost:   cfg.PasswordHashCost,
+		ResetHours: cfg.PasswordResetHours,
+	}.clamped()
+}
+
+// clamped returns p with values out of range replaced, as for SetNewUserLevel.
+func (p PasswordPolicy) clamped() PasswordPolicy {
+	if p.MinLength < MinPasswordLength {
+		p.MinLength = MinPasswordLength
+	}
+	if p.History < 0 {
+		p.History = 0
+	}
+	if p.MaxAgeDays < 0 {
+		p.MaxAgeDays = 0
+	}
+	if p.HashCost < bcrypt.MinCost || p.HashCost > bcrypt.MaxCost {
+		log.Printf("WARN: invalid passwordHashCost %d; using %d", p.HashCost, bcrypt.DefaultCost)
+		p.HashCost = bcrypt.DefaultCost
+	}
+	if p.ResetHours <= 0 {
+		p.ResetHours = DefaultPasswordPolicy().ResetHours
+	}
+	return p
+}
+
+// SetPasswordPolicy sets the policy for new and changed passwords. Values
+// out of range are clamped, as for SetNewUserLevel.
+func (um *UserMgr) SetPasswordPolicy(p PasswordPolicy) {
+	p = p.clamped()
+
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	um.policy = p
+}
+
+// PasswordPolicy returns the policy in force.
+func (um *UserMgr) PasswordPolicy() PasswordPolicy {
+	um.mu.RLock()
+	defer um.mu.RUnlock()
+	return um.policy
+}
+
+// Hash hashes password at the policy's bcrypt cost.
+func (p PasswordPolicy) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.HashCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Check reports whether password may replace u's current one:
+// ErrPasswordTooShort or ErrPasswordReused when the policy refuses it. u may
+// be nil for a new account, in which case only the length is checked.
+func (p PasswordPolicy) Check(u *User, password string) error {
+	if len(password) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	if u == nil || p.History == 0 {
+		return nil
+	}
+	recent := append([]string{u.PasswordHash}, u.PasswordHistory...)
+	if len(recent) > p.History {
+		recent = recent[:p.History]
+	}
+	for _, h := range recent {
+		if h != "" && bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// SetPassword checks password against the policy, then hashes it into u. The
+// old hash is kept for the reuse check, the password age starts again, and
+// any forced change or reset code is cleared. u is not saved.
+func (p PasswordPolicy) SetPassword(u *User, password string) error {
+	if err := p.Check(u, password); err != nil {
+		return err
+	}
+	hash, err := p.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if keep := p.History - 1; keep > 0 && u.PasswordHash != "" {
+		u.PasswordHistory = append([]string{u.PasswordHash}, u.PasswordHistory...)
+		if len(u.PasswordHistory) > keep {
+			u.PasswordHistory = u.PasswordHistory[:keep]
+		}
+	} else {
+		u.PasswordHistory = nil
+	}
+	now := time.Now()
+	u.PasswordHash = hash
+	u.PasswordChangedAt = &now
+	u.MustChangePassword = false
+	u.ResetCodeHash = ""
+	u.ResetCodeExpires = nil
+	return nil
+}
+
+// HashPassword hashes password at the policy's bcrypt cost.
+func (um *UserMgr) HashPassword(password string) (string, error) {
+	return um.PasswordPolicy().Hash(password)
+}
+
+// CheckNewPassword reports whether password may replace u's current one
+// under the policy in force. See PasswordPolicy.Check.
+func (um *UserMgr) CheckNewPassword(u *User, password string) error {
+	return um.PasswordPolicy().Check(u, password)
+}
+
+// SetPassword sets u's password under the policy in force and saves u.
+// See PasswordPolicy.SetPassword.
+func (um *UserMgr) SetPassword(u *User, password string) error {
+	if err := um.PasswordPolicy().SetPassword(u, password); err != nil {
+		return err
+	}
+	return um.UpdateUser(u)
+}
+
+// PasswordExpired reports whether u's password is older than maxAgeDays at
+// now. A password with no recorded change date never expires; its age is
+// counted from the next login.
+func (u *User) PasswordExpired(maxAgeDays int, now time.Time) bool {
+	if maxAgeDays <= 0 || u.PasswordChangedAt == nil {
+		return false
+	}
+	return !now.Before(u.PasswordChangedAt.AddDate(0, 0, maxAgeDays))
+}
+
+// NeedsPasswordChange reports whether u must choose a new password before
+// going on: the sysop asked for it, a reset code was used, or the password
+// is past the policy's maximum age.
+func (um *UserMgr) NeedsPasswordChange(u *User) bool {
+	return u.MustChangePassword || u.PasswordExpired(um.PasswordPolicy().MaxAgeDays, time.Now())
+}
+
+// SetMustChangePassword flags username to change their password at the next
+// login, or clears the flag.
+func (um *UserMgr) SetMustChangePassword(username string, must bool) error {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	_, err := um.store.ModifyUser(username, func(u *User) error {
+		u.MustChangePassword = must
+		return nil
+	})
+	return err
+}
+
+// IssueResetCode gives u a one-time code to log in with in place of their
+// password, valid for the policy's ResetHours. Logging in with it forces a
+// password change. A new code replaces any earlier one. u is not saved.
+func (p PasswordPolicy) IssueResetCode(u *User) (code string, expires time.Time, err error) {
+	code, err = newOneTimeCode()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires = time.Now().Add(time.Duration(p.ResetHours) * time.Hour)
+	u.ResetCodeHash = hashRecoveryCode(code)
+	u.ResetCodeExpires = &expires
+	return code, expires, nil
+}
+
+// IssueResetCode gives username a reset code and saves it.
+// See PasswordPolicy.IssueResetCode.
+func (um *UserMgr) IssueResetCode(username string) (code string, expires time.Time, err error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	u, err := um.store.ModifyUser(username, func(u *User) error {
+		if u.DeletedUser {
+			return ErrUserNotFound
+		}
+		code, expires, err = um.policy.IssueResetCode(u)
+		return err
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	log.Printf("SECURITY: Issued a password reset code for %s (expires %s)", u.Username, expires.Format(time.RFC3339))
+	return code, expires, nil
+}
+
+// resetCodeMatches reports whether code is u's unexpired reset code.
+func resetCodeMatches(u *User, code string, now time.Time) bool {
+	if u.DeletedUser || u.ResetCodeHash == "" || u.ResetCodeExpires == nil {
+		return false
+	}
+	return now.Before(*u.ResetCodeExpires) &&
+		hmac.Equal([]byte(u.ResetCodeHash), []byte(hashRecoveryCode(code)))
+}
+
+// CheckResetCode checks code against username's unexpired reset code
+// without using it up, so a login can finish its other checks first; call
+// RedeemResetCode once they pass. Returns a copy of the user on success.
+func (um *UserMgr) CheckResetCode(username, code string) (*User, bool) {
+	u, ok := um.GetUser(username)
+	if !ok || !resetCodeMatches(u, code, time.Now()) {
+		return nil, false
+	}
+	return u, true
+}
+
+// RedeemResetCode checks code against username's unexpired reset code. A
+// match uses the code up and flags the account to change its password.
+// Returns a copy of the user on success.
+func (um *UserMgr) RedeemResetCode(username, code string) (*User, bool) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	u, err := um.store.ModifyUser(username, func(u *User) error {
+		if !resetCodeMatches(u, code, time.Now()) {
+			return errNoChange
+		}
+		u.ResetCodeHash = ""
+		u.ResetCodeExpires = nil
+		u.MustChangePassword = true
+		return nil
+	})
+	if err != nil {
+		// The code must be used up, so it is not accepted unsaved.
+		if !errors.Is(err, errNoChange) && !errors.Is(err, ErrUserNotFound) {
+			log.Printf("ERROR: Failed to save user data after reset code login for %s: %v", username, err)
+		}
+		return nil, false
+	}
+	log.Printf("SECURITY: %s logged in with a password reset code", u.Username)
+	return u, true
+}
+
+// verifyPassword compares password with username's stored hash outside the
+// lock (bcrypt is CPU-intensive). A hash below the policy's cost is rehashed
+// at that cost while the plaintext is at hand, and an account with no
+// password date starts its age now. Returns a copy of the user on success.
+func (um *UserMgr) verifyPassword(username, password string) (*User, bool) {
+	u, ok := um.GetUser(username)
+	if !ok || u.DeletedUser {
+		return nil, false
+	}
+	passwordHash := u.PasswordHash
+	um.mu.RLock()
+	cost := um.policy.HashCost
+	um.mu.RUnlock()
+
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) != nil {
+		return nil, false
+	}
+	var upgraded string
+	if current, err := bcrypt.Cost([]byte(passwordHash)); err == nil && current < cost {
+		if h, err := bcrypt.GenerateFromPassword([]byte(password), cost); err == nil {
+			upgraded = string(h)
+		} else {
+			log.Printf("WARN: Failed to rehash password for %s: %v", username, err)
+		}
+	}
+
+	if upgraded == "" && u.PasswordChangedAt != nil {
+		return u, true
+	}
+
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	saved, err := um.store.ModifyUser(username, func(u *User) error {
+		if u.PasswordHash != passwordHash {
+			// Rehashed or changed while we were comparing; leave it be.
+			upgraded = ""
+		}
+		if upgraded == "" && u.PasswordChangedAt != nil {
+			return errNoChange
+		}
+		if upgraded != "" {
+			u.PasswordHash = upgraded
+			log.Printf("INFO: Upgraded password hash for %s to bcrypt cost %d", u.Username, cost)
+		}
+		if u.PasswordChangedAt == nil {
+			now := time.Now()
+			u.PasswordChangedAt = &now
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errNoChange):
+		return u, true
+	case errors.Is(err, ErrUserNotFound):
+		return nil, false
+	case err != nil:
+		log.Printf("ERROR: Failed to save user data after password check for %s: %v", username, err)
+		return u, true
+	}
+	return saved, true
+}
@@ -0,0 +1,142 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSetPassword_Policy(t *testing.T) {
+	um := newTestManager(t, []User{{ID: 1, Username: "alice", Handle: "Alice", PasswordHash: hashPassword(t, "first-pw")}})
+	um.SetPasswordPolicy(PasswordPolicy{MinLength: 6, History: 2, HashCost: bcrypt.MinCost})
+	u, _ := um.GetUser("alice")
+
+	if err := um.SetPassword(u, "short"); !errors.Is(err, ErrPasswordTooShort) {
+		t.Errorf("short password: got %v, want ErrPasswordTooShort", err)
+	}
+	if err := um.SetPassword(u, "first-pw"); !errors.Is(err, ErrPasswordReused) {
+		t.Errorf("current password: got %v, want ErrPasswordReused", err)
+	}
+	if err := um.SetPassword(u, "second-pw"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if err := um.SetPassword(u, "first-pw"); !errors.Is(err, ErrPasswordReused) {
+		t.Errorf("previous password: got %v, want ErrPasswordReused", err)
+	}
+	if err := um.SetPassword(u, "third-pw"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	// With a history of 2, the password before last may be used again.
+	if err := um.CheckNewPassword(u, "first-pw"); err != nil {
+		t.Errorf("password outside the history refused: %v", err)
+	}
+
+	stored, _ := um.GetUser("alice")
+	if stored.PasswordChangedAt == nil || len(stored.PasswordHistory) != 1 {
+		t.Errorf("changedAt = %v, history = %d; want a date and 1 entry", stored.PasswordChangedAt, len(stored.PasswordHistory))
+	}
+	if _, ok := um.CheckPassword("alice", "third-pw"); !ok {
+		t.Error("new password does not log in")
+	}
+}
+
+func TestCheckPassword_UpgradesHashCost(t *testing.T) {
+	um := newTestManager(t, []User{{ID: 1, Username: "alice", Handle: "Alice", PasswordHash: hashPassword(t, "pw-alice")}})
+	um.SetPasswordPolicy(PasswordPolicy{MinLength: 3, HashCost: bcrypt.MinCost + 1})
+
+	if _, ok := um.CheckPassword("alice", "wrong"); ok {
+		t.Fatal("wrong password accepted")
+	}
+	u, _ := um.GetUser("alice")
+	if cost, _ := bcrypt.Cost([]byte(u.PasswordHash)); cost != bcrypt.MinCost {
+		t.Fatalf("hash changed after a failed check (cost %d)", cost)
+	}
+
+	if _, ok := um.CheckPassword("alice", "pw-alice"); !ok {
+		t.Fatal("right password refused")
+	}
+	u, _ = um.GetUser("alice")
+	if cost, _ := bcrypt.Cost([]byte(u.PasswordHash)); cost != bcrypt.MinCost+1 {
+		t.Errorf("cost = %d after login, want %d", cost, bcrypt.MinCost+1)
+	}
+	if u.PasswordChangedAt == nil {
+		t.Error("password age not started at login")
+	}
+	if _, ok := um.CheckPassword("alice", "pw-alice"); !ok {
+		t.Error("upgraded hash refuses the password")
+	}
+}
+
+func TestResetCode(t *testing.T) {
+	um := newTestManager(t, []User{{ID: 1, Username: "alice", Handle: "Alice", PasswordHash: hashPassword(t, "pw-alice")}})
+
+	code, expires, err := um.IssueResetCode("alice")
+	if err != nil {
+		t.Fatalf("IssueResetCode: %v", err)
+	}
+	if time.Until(expires) < 23*time.Hour {
+		t.Errorf("expires = %v, want about 24 hours from now", expires)
+	}
+	if _, ok := um.CheckPassword("alice", code); ok {
+		t.Error("CheckPassword accepted a reset code")
+	}
+
+	if _, ok := um.Authenticate("alice", code); ok {
+		t.Error("Authenticate accepted a reset code")
+	}
+
+	// Checking the code leaves it usable and the account unflagged, so a
+	// login refused later on does not use it up.
+	u, ok := um.CheckResetCode("alice", code)
+	if !ok || u.MustChangePassword {
+		t.Fatalf("CheckResetCode = %+v, %v", u, ok)
+	}
+	if _, ok := um.CheckResetCode("alice", code); !ok {
+		t.Fatal("reset code used up by CheckResetCode")
+	}
+
+	u, ok = um.RedeemResetCode("alice", code)
+	if !ok {
+		t.Fatal("reset code refused")
+	}
+	if !u.MustChangePassword || !um.NeedsPasswordChange(u) {
+		t.Error("reset code login did not force a password change")
+	}
+	if _, ok := um.CheckResetCode("alice", code); ok {
+		t.Error("reset code accepted after it was used")
+	}
+	if _, ok := um.RedeemResetCode("alice", code); ok {
+		t.Error("reset code accepted twice")
+	}
+	if _, ok := um.Authenticate("alice", "pw-alice"); !ok {
+		t.Error("old password stopped working")
+	}
+
+	// Expired codes are refused.
+	code, _, _ = um.IssueResetCode("alice")
+	u, _ = um.GetUser("alice")
+	past := time.Now().Add(-time.Minute)
+	u.ResetCodeExpires = &past
+	um.UpdateUser(u)
+	if _, ok := um.RedeemResetCode("alice", code); ok {
+		t.Error("expired reset code accepted")
+	}
+}
+
+func TestPasswordExpired(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, 0, -31)
+	u := &User{PasswordChangedAt: &old}
+
+	if !u.PasswordExpired(30, now) {
+		t.Error("31-day-old password not expired at 30 days")
+	}
+	if u.PasswordExpired(0, now) {
+		t.Error("password expired with no maximum age")
+	}
+	if (&User{}).PasswordExpired(30, now) {
+		t.Error("password with no change date expired")
+	}
+}
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const filePointsLogFile = "file_points.jsonl" // Append-only ledger of file point changes
+
+// File point ledger reasons.
+const (
+	PointsReasonDownload = "DOWNLOAD"
+	PointsReasonUpload   = "UPLOAD"
+	PointsReasonAdmin    = "ADMIN"
+)
+
+// FilePointsLog records a single change to a user's file point balance.
+// Entries are appended one JSON object per line and never trimmed so the
+// sysop has a complete audit trail of charges and credits.
+type FilePointsLog struct {
+	Timestamp time.Time `json:"timestamp"`
+	UserID    int       `json:"userId"`
+	Handle    string    `json:"handle"`
+	Reason    string    `json:"reason"`             // DOWNLOAD, UPLOAD, ADMIN
+	Delta     int       `json:"delta"`              // Negative for charges, positive for credits
+	Balance   int       `json:"balance"`            // Balance after the change
+	AreaTag   string    `json:"areaTag,omitempty"`  // File area involved, if any
+	Filename  string    `json:"filename,omitempty"` // File involved, if any
+	Notes     string    `json:"notes,omitempty"`
+}
+
+// LogFilePoints appends an entry to the file point ledger.
+func (um *UserMgr) LogFilePoints(entry FilePointsLog) error {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal file points entry: %w", err)
+	}
+
+	logPath := filepath.Join(um.dataPath, filePointsLogFile)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file points log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write file points log: %w", err)
+	}
+	return f.Close()
+}
+
+// LoadFilePointsLog reads the file point ledger from dataPath, oldest first.
+// Malformed lines are skipped. A missing ledger returns no entries.
+func LoadFilePointsLog(dataPath string) ([]FilePointsLog, error) {
+	f, err := os.Open(filepath.Join(dataPath, filePointsLogFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []FilePointsLog
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry FilePointsLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
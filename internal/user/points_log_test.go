@@ -0,0 +1,52 @@
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogFilePoints_AppendsAndLoads(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "users.json"), []byte("[]"), 0644)
+
+	um, err := NewUserManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewUserManager: %v", err)
+	}
+
+	um.LogFilePoints(FilePointsLog{UserID: 2, Handle: "Bob", Reason: PointsReasonUpload, Delta: 5, Balance: 5})
+	um.LogFilePoints(FilePointsLog{UserID: 2, Handle: "Bob", Reason: PointsReasonDownload, Delta: -3, Balance: 2, AreaTag: "UTILS", Filename: "PKZ204G.EXE"})
+
+	entries, err := LoadFilePointsLog(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFilePointsLog: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[1].Delta != -3 || entries[1].Filename != "PKZ204G.EXE" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+	if entries[0].Timestamp.IsZero() {
+		t.Error("expected timestamp to be filled in")
+	}
+}
+
+func TestLoadFilePointsLog_MissingAndCorrupt(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	entries, err := LoadFilePointsLog(tmpDir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty ledger, got %v, %v", entries, err)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, filePointsLogFile), []byte("not json\n{\"handle\":\"Bob\",\"delta\":1}\n"), 0600)
+	entries, err = LoadFilePointsLog(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFilePointsLog: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Handle != "Bob" {
+		t.Errorf("expected the valid line only, got %+v", entries)
+	}
+}
@@ -0,0 +1,143 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// MaxSSHKeys is the most public keys a user may register.
+const MaxSSHKeys = 8
+
+var (
+	ErrSSHKeyLimit     = fmt.Errorf("no more than %d SSH keys per user", MaxSSHKeys)
+	ErrSSHKeyDuplicate = errors.New("key is already registered")
+)
+
+// SSHKey is a public key a user has registered for SSH login.
+type SSHKey struct {
+	Key         string    `json:"key"`               // Authorized_keys form: type and base64 blob
+	Fingerprint string    `json:"fingerprint"`       // SHA256 fingerprint, as shown by ssh-keygen -l
+	Comment     string    `json:"comment,omitempty"` // Comment from the pasted line (e.g. user@host)
+	AddedAt     time.Time `json:"addedAt"`           // When the key was registered
+	LastUsed    time.Time `json:"lastUsed"`          // Last successful login with the key
+}
+
+// ParseSSHKey parses a single line in authorized_keys format, such as the
+// contents of id_ed25519.pub. Options before the key type are not allowed.
+func ParseSSHKey(line string) (SSHKey, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return SSHKey{}, errors.New("empty key")
+	}
+	pub, comment, options, _, err := gossh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return SSHKey{}, fmt.Errorf("not a valid public key: %w", err)
+	}
+	if len(options) > 0 {
+		return SSHKey{}, errors.New("key options are not supported")
+	}
+	return SSHKey{
+		Key:         strings.TrimSpace(string(gossh.MarshalAuthorizedKey(pub))),
+		Fingerprint: gossh.FingerprintSHA256(pub),
+		Comment:     strings.TrimSpace(comment),
+		AddedAt:     time.Now(),
+	}, nil
+}
+
+// AddSSHKey registers a key on the user.
+func (u *User) AddSSHKey(key SSHKey) error {
+	for _, k := range u.SSHKeys {
+		if k.Fingerprint == key.Fingerprint {
+			return ErrSSHKeyDuplicate
+		}
+	}
+	if len(u.SSHKeys) >= MaxSSHKeys {
+		return ErrSSHKeyLimit
+	}
+	// Build a new slice: copies of the user share the old one.
+	keys := make([]SSHKey, 0, len(u.SSHKeys)+1)
+	u.SSHKeys = append(append(keys, u.SSHKeys...), key)
+	return nil
+}
+
+// RemoveSSHKey removes the key with the given fingerprint, reporting whether
+// it was registered.
+func (u *User) RemoveSSHKey(fingerprint string) bool {
+	keys := make([]SSHKey, 0, len(u.SSHKeys))
+	for _, k := range u.SSHKeys {
+		if k.Fingerprint != fingerprint {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == len(u.SSHKeys) {
+		return false
+	}
+	if len(keys) == 0 {
+		keys = nil
+	}
+	u.SSHKeys = keys
+	return true
+}
+
+// SSHKeyOwner returns the username a key fingerprint is registered to, so a
+// key cannot be bound to two accounts.
+func (um *UserMgr) SSHKeyOwner(fingerprint string) (string, bool) {
+	for _, u := range um.GetAllUsers() {
+		for _, k := range u.SSHKeys {
+			if k.Fingerprint == fingerprint {
+				return u.Username, true
+			}
+		}
+	}
+	return "", false
+}
+
+// CheckSSHKey reports whether pub is registered to username. Deleted
+// accounts never match. Like CheckPassword it does not record a login;
+// callers must also have the client prove it holds the private key, which
+// the SSH server does when it verifies the signature.
+func (um *UserMgr) CheckSSHKey(username string, pub gossh.PublicKey) (*User, bool) {
+	u, ok := um.GetUser(username)
+	if !ok || u.DeletedUser {
+		return nil, false
+	}
+	fingerprint := gossh.FingerprintSHA256(pub)
+	for _, k := range u.SSHKeys {
+		if k.Fingerprint == fingerprint {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// TouchSSHKey records a successful login with the key that has the given
+// fingerprint.
+func (um *UserMgr) TouchSSHKey(username, fingerprint string) error {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	_, err := um.store.ModifyUser(username, func(u *User) error {
+		keys := make([]SSHKey, len(u.SSHKeys))
+		copy(keys, u.SSHKeys)
+		found := false
+		for i := range keys {
+			if keys[i].Fingerprint == fingerprint {
+				keys[i].LastUsed = time.Now()
+				found = true
+			}
+		}
+		if !found {
+			return errNoChange
+		}
+		u.SSHKeys = keys
+		return nil
+	})
+	if errors.Is(err, errNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package user
+
+// Store persists user records and the call history behind UserMgr, which
+// reads and writes users through the store on every call rather than
+// keeping its own copy. UserMgr serialises its own writes; ModifyUser and
+// AddUser are atomic in the store itself.
+type Store interface {
+	// LoadUsers returns every stored user. The error wraps os.ErrNotExist
+	// when nothing has ever been stored.
+	LoadUsers() ([]*User, error)
+	// UserByName, UserByHandle and UserByID look up one user, ignoring
+	// case for names. They return ErrUserNotFound if there is none.
+	UserByName(username string) (*User, error)
+	UserByHandle(handle string) (*User, error)
+	UserByID(id int) (*User, error)
+	// CountUsers returns the number of stored users.
+	CountUsers() (int, error)
+	// NextUserID returns the ID AddUser would give the next user.
+	NextUserID() (int, error)
+	// AddUser stores u as a new user with the next free ID, which it sets
+	// on u. It fails with ErrUserExists or ErrHandleExists if the username
+	// or handle is taken.
+	AddUser(u *User) error
+	// ModifyUser reads username, applies fn and saves the result in one
+	// step, so concurrent changes to other fields are not lost. An error
+	// from fn saves nothing. Returns the saved user.
+	ModifyUser(username string, fn func(u *User) error) (*User, error)
+	// SaveUsers inserts or replaces the given users in one write.
+	SaveUsers(users ...*User) error
+	// DeleteUsers permanently removes the given users in one write.
+	DeleteUsers(users ...*User) error
+	// LoadCallHistory returns the last limit call records, oldest first, and
+	// the next call number to assign.
+	LoadCallHistory(limit int) ([]CallRecord, uint64, error)
+	// AddCallRecords appends records, keeps only the last limit records,
+	// and stores next as the next call number to assign, in one write.
+	AddCallRecords(records []CallRecord, next uint64, limit int) error
+	// Path returns the file the users are stored in.
+	Path() string
+	// Close releases the store.
+	Close() error
+}
@@ -0,0 +1,390 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// jsonStore keeps users in users.json and the call history in
+// callhistory.json and callnumber.json. users.json is read once and every
+// write rewrites the whole file, so the store holds every user in memory and
+// does not see changes other programs make to the file.
+type jsonStore struct {
+	mu       sync.Mutex
+	dataPath string
+	loaded   bool             // users.json has been read
+	users    map[string]*User // lower-case username -> user
+	calls    []CallRecord
+}
+
+// NewJSONStore returns the JSON file store in dataPath.
+func NewJSONStore(dataPath string) Store {
+	return &jsonStore{dataPath: dataPath, users: make(map[string]*User)}
+}
+
+func (s *jsonStore) Path() string { return filepath.Join(s.dataPath, userFile) }
+
+func (s *jsonStore) Close() error { return nil }
+
+// loadLocked reads users.json the first time it is called. Entries with a
+// username already seen are skipped. A missing file is an empty store; the
+// error then wraps os.ErrNotExist.
+func (s *jsonStore) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.Path())
+	if errors.Is(err, os.ErrNotExist) {
+		s.loaded = true
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	data = StripUTF8BOM(data)
+
+	// Load into a slice of pointers to handle omitempty correctly
+	var usersList []*User
+	if err := json.Unmarshal(data, &usersList); err != nil {
+		return fmt.Errorf("failed to unmarshal users array: %w", err)
+	}
+
+	s.users = make(map[string]*User, len(usersList))
+	for _, u := range usersList {
+		if u == nil { // Safety check for nil entries in JSON array
+			continue
+		}
+		key := strings.ToLower(u.Username)
+		if _, exists := s.users[key]; exists {
+			log.Printf("WARN: Duplicate username found in users.json: %s. Skipping subsequent entry.", u.Username)
+			continue
+		}
+		s.users[key] = u
+	}
+	s.loaded = true
+	return nil
+}
+
+// readyLocked loads users.json if needed; a missing file is not an error.
+func (s *jsonStore) readyLocked() error {
+	if err := s.loadLocked(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// LoadUsers returns copies of every user, ordered by ID.
+func (s *jsonStore) LoadUsers() ([]*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	if len(s.users) == 0 {
+		if _, err := os.Stat(s.Path()); err != nil {
+			return nil, err
+		}
+	}
+	loaded := make([]*User, 0, len(s.users))
+	for _, u := range s.users {
+		userCopy := *u
+		loaded = append(loaded, &userCopy)
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].ID < loaded[j].ID })
+	return loaded, nil
+}
+
+// findLocked returns the first user match accepts.
+func (s *jsonStore) findLocked(match func(u *User) bool) (*User, error) {
+	if err := s.readyLocked(); err != nil {
+		return nil, err
+	}
+	for _, u := range s.users {
+		if match(u) {
+			userCopy := *u
+			return &userCopy, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s *jsonStore) UserByName(username string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readyLocked(); err != nil {
+		return nil, err
+	}
+	u, ok := s.users[strings.ToLower(username)]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	userCopy := *u
+	return &userCopy, nil
+}
+
+func (s *jsonStore) UserByHandle(handle string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findLocked(func(u *User) bool { return strings.EqualFold(u.Handle, handle) })
+}
+
+func (s *jsonStore) UserByID(id int) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findLocked(func(u *User) bool { return u.ID == id })
+}
+
+func (s *jsonStore) CountUsers() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readyLocked(); err != nil {
+		return 0, err
+	}
+	return len(s.users), nil
+}
+
+func (s *jsonStore) NextUserID() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readyLocked(); err != nil {
+		return 0, err
+	}
+	return s.nextIDLocked(), nil
+}
+
+func (s *jsonStore) nextIDLocked() int {
+	maxID := 0
+	for _, u := range s.users {
+		maxID = max(maxID, u.ID)
+	}
+	return maxID + 1
+}
+
+func (s *jsonStore) AddUser(u *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readyLocked(); err != nil {
+		return err
+	}
+	key := strings.ToLower(u.Username)
+	if _, exists := s.users[key]; exists {
+		return ErrUserExists
+	}
+	for _, other := range s.users {
+		if strings.EqualFold(other.Handle, u.Handle) {
+			return ErrHandleExists
+		}
+	}
+	u.ID = s.nextIDLocked()
+	userCopy := *u
+	s.users[key] = &userCopy
+	if err := s.writeUsersLocked(); err != nil {
+		delete(s.users, key)
+		return err
+	}
+	return nil
+}
+
+func (s *jsonStore) ModifyUser(username string, fn func(u *User) error) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readyLocked(); err != nil {
+		return nil, err
+	}
+	key := strings.ToLower(username)
+	old, ok := s.users[key]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	userCopy := *old
+	if err := fn(&userCopy); err != nil {
+		return nil, err
+	}
+	s.users[key] = &userCopy
+	if err := s.writeUsersLocked(); err != nil {
+		s.users[key] = old
+		return nil, err
+	}
+	result := userCopy
+	return &result, nil
+}
+
+func (s *jsonStore) SaveUsers(users ...*User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readyLocked(); err != nil {
+		return err
+	}
+	for _, u := range users {
+		userCopy := *u
+		s.users[strings.ToLower(u.Username)] = &userCopy
+	}
+	return s.writeUsersLocked()
+}
+
+func (s *jsonStore) DeleteUsers(users ...*User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readyLocked(); err != nil {
+		return err
+	}
+	for _, u := range users {
+		delete(s.users, strings.ToLower(u.Username))
+	}
+	return s.writeUsersLocked()
+}
+
+// writeUsersLocked writes every user to users.json, sorted by ID, through
+// a temp file so a crash mid-write leaves the old file intact.
+func (s *jsonStore) writeUsersLocked() error {
+	usersList := make([]*User, 0, len(s.users))
+	for _, u := range s.users {
+		usersList = append(usersList, u)
+	}
+	sort.Slice(usersList, func(i, j int) bool { return usersList[i].ID < usersList[j].ID })
+
+	data, err := json.MarshalIndent(usersList, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal users slice: %w", err)
+	}
+	if err := os.MkdirAll(s.dataPath, 0750); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", s.dataPath, err)
+	}
+	if err := writeFileAtomic(s.Path(), data, 0600); err != nil {
+		return fmt.Errorf("failed to write users file %s: %w", s.Path(), err)
+	}
+	return nil
+}
+
+// LoadCallHistory reads callhistory.json and callnumber.json. Missing,
+// empty or unreadable call number files start numbering at 1.
+func (s *jsonStore) LoadCallHistory(limit int) ([]CallRecord, uint64, error) {
+	next := s.loadNextCallNumber()
+
+	filePath := filepath.Join(s.dataPath, callHistoryFile)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("INFO: %s not found, starting with empty call history list.", callHistoryFile)
+			return nil, next, nil
+		}
+		return nil, next, fmt.Errorf("failed to read %s: %w", callHistoryFile, err)
+	}
+	data = StripUTF8BOM(data)
+	if len(data) == 0 {
+		return nil, next, nil // Empty file is okay
+	}
+
+	var calls []CallRecord
+	if err := json.Unmarshal(data, &calls); err != nil {
+		return nil, next, fmt.Errorf("failed to unmarshal %s: %w", callHistoryFile, err)
+	}
+	if len(calls) > limit {
+		calls = calls[len(calls)-limit:]
+	}
+
+	s.mu.Lock()
+	s.calls = append([]CallRecord(nil), calls...)
+	s.mu.Unlock()
+
+	log.Printf("DEBUG: Loaded %d call history records from %s", len(calls), callHistoryFile)
+	return calls, next, nil
+}
+
+// loadNextCallNumber reads callnumber.json, defaulting to 1.
+func (s *jsonStore) loadNextCallNumber() uint64 {
+	filePath := filepath.Join(s.dataPath, callNumberFile)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("INFO: %s not found, starting call numbers from 1.", callNumberFile)
+		} else {
+			log.Printf("WARN: Failed to read %s: %v. Starting call numbers from 1.", callNumberFile, err)
+		}
+		return 1
+	}
+	data = StripUTF8BOM(data)
+	if len(data) == 0 {
+		log.Printf("WARN: %s is empty, starting call numbers from 1.", callNumberFile)
+		return 1
+	}
+
+	var next uint64
+	if err := json.Unmarshal(data, &next); err != nil {
+		log.Printf("WARN: Failed to unmarshal %s: %v. Starting call numbers from 1.", callNumberFile, err)
+		return 1
+	}
+	log.Printf("DEBUG: Loaded next call number %d from %s", next, callNumberFile)
+	return next
+}
+
+func (s *jsonStore) AddCallRecords(records []CallRecord, next uint64, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, records...)
+	if len(s.calls) > limit {
+		s.calls = s.calls[len(s.calls)-limit:]
+	}
+
+	data, err := json.MarshalIndent(s.calls, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal call history: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dataPath, callHistoryFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", callHistoryFile, err)
+	}
+
+	// The call number lives in its own file; a failure here is logged but
+	// does not fail the history save.
+	if err := s.writeNextCallNumber(next); err != nil {
+		log.Printf("ERROR: Failed to save next call number: %v", err)
+	}
+	return nil
+}
+
+func (s *jsonStore) writeNextCallNumber(next uint64) error {
+	data, err := json.Marshal(next)
+	if err != nil {
+		return fmt.Errorf("failed to marshal next call number: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dataPath, callNumberFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", callNumberFile, err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// into place.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package user
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestJSONStore_SaveLoadDelete(t *testing.T) {
+	s := NewJSONStore(t.TempDir())
+
+	if _, err := s.LoadUsers(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadUsers on empty store: got %v, want os.ErrNotExist", err)
+	}
+
+	alice := &User{ID: 1, Username: "alice", Handle: "Alice", AccessLevel: 10}
+	bob := &User{ID: 2, Username: "bob", Handle: "Bob", AccessLevel: 20}
+	if err := s.SaveUsers(alice, bob); err != nil {
+		t.Fatalf("SaveUsers: %v", err)
+	}
+
+	users, err := s.LoadUsers()
+	if err != nil {
+		t.Fatalf("LoadUsers: %v", err)
+	}
+	if len(users) != 2 || users[0].Username != "alice" || users[1].AccessLevel != 20 {
+		t.Fatalf("LoadUsers = %+v", users)
+	}
+
+	if err := s.DeleteUsers(alice); err != nil {
+		t.Fatalf("DeleteUsers: %v", err)
+	}
+	users, _ = s.LoadUsers()
+	if len(users) != 1 || users[0].Username != "bob" {
+		t.Fatalf("after delete LoadUsers = %+v", users)
+	}
+}
+
+func TestJSONStore_Lookups(t *testing.T) {
+	s := NewJSONStore(t.TempDir())
+	if n, _ := s.NextUserID(); n != 1 {
+		t.Errorf("NextUserID on empty store = %d, want 1", n)
+	}
+	alice := &User{Username: "alice", Handle: "Wonder"}
+	if err := s.AddUser(alice); err != nil || alice.ID != 1 {
+		t.Fatalf("AddUser = %v, ID %d", err, alice.ID)
+	}
+	if err := s.AddUser(&User{Username: "ALICE", Handle: "Other"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("duplicate username: got %v, want ErrUserExists", err)
+	}
+	if err := s.AddUser(&User{Username: "bob", Handle: "WONDER"}); !errors.Is(err, ErrHandleExists) {
+		t.Errorf("duplicate handle: got %v, want ErrHandleExists", err)
+	}
+
+	for name, lookup := range map[string]func() (*User, error){
+		"name":   func() (*User, error) { return s.UserByName("Alice") },
+		"handle": func() (*User, error) { return s.UserByHandle("wonder") },
+		"id":     func() (*User, error) { return s.UserByID(1) },
+	} {
+		if u, err := lookup(); err != nil || u.Username != "alice" {
+			t.Errorf("by %s = %+v, %v", name, u, err)
+		}
+	}
+	if _, err := s.UserByHandle("nobody"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("missing handle: got %v, want ErrUserNotFound", err)
+	}
+
+	u, err := s.ModifyUser("alice", func(u *User) error {
+		u.Handle = "Rabbit"
+		return nil
+	})
+	if err != nil || u.Handle != "Rabbit" {
+		t.Fatalf("ModifyUser = %+v, %v", u, err)
+	}
+	if _, err := s.UserByHandle("wonder"); !errors.Is(err, ErrUserNotFound) {
+		t.Error("old handle still found after ModifyUser")
+	}
+
+	// An error from fn saves nothing.
+	if _, err := s.ModifyUser("alice", func(u *User) error {
+		u.Handle = "Lost"
+		return errNoChange
+	}); !errors.Is(err, errNoChange) {
+		t.Fatalf("ModifyUser error = %v, want errNoChange", err)
+	}
+	if u, _ := s.UserByName("alice"); u.Handle != "Rabbit" {
+		t.Errorf("handle after failed ModifyUser = %q, want Rabbit", u.Handle)
+	}
+	if n, _ := s.CountUsers(); n != 1 {
+		t.Errorf("CountUsers = %d, want 1", n)
+	}
+}
+
+func TestJSONStore_CallHistory(t *testing.T) {
+	dir := t.TempDir()
+	s := NewJSONStore(dir)
+
+	calls, next, err := s.LoadCallHistory(callHistoryLimit)
+	if err != nil || len(calls) != 0 || next != 1 {
+		t.Fatalf("empty LoadCallHistory = %v, %d, %v", calls, next, err)
+	}
+
+	const limit = 3
+	for i := uint64(1); i <= 3; i++ {
+		if err := s.AddCallRecords([]CallRecord{{UserID: 1, CallNumber: i}}, i+1, limit); err != nil {
+			t.Fatalf("AddCallRecords %d: %v", i, err)
+		}
+	}
+	// A batch is trimmed to the limit as a whole.
+	if err := s.AddCallRecords([]CallRecord{{UserID: 1, CallNumber: 4}, {UserID: 1, CallNumber: 5}}, 6, limit); err != nil {
+		t.Fatalf("AddCallRecords batch: %v", err)
+	}
+
+	// A fresh store reads back what was written.
+	calls, next, err = NewJSONStore(dir).LoadCallHistory(callHistoryLimit)
+	if err != nil {
+		t.Fatalf("LoadCallHistory: %v", err)
+	}
+	if next != 6 {
+		t.Errorf("next call number = %d, want 6", next)
+	}
+	if len(calls) != limit {
+		t.Fatalf("got %d call records, want %d", len(calls), limit)
+	}
+	for i, c := range calls {
+		if want := uint64(3 + i); c.CallNumber != want {
+			t.Errorf("calls[%d].CallNumber = %d, want %d", i, c.CallNumber, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"errors"
+)
+
+var (
+	ErrTimeBankFull  = errors.New("time bank is full")
+	ErrTimeBankEmpty = errors.New("time bank is empty")
+)
+
+// TimeUsedOn returns the minutes the user has been charged on day
+// (YYYY-MM-DD); a count kept for an earlier day does not apply.
+func (u *User) TimeUsedOn(day string) int {
+	if u.TimeUsedDate != day {
+		return 0
+	}
+	return u.TimeUsedToday
+}
+
+// AddTimeUsed charges minutes of a finished call against the user's daily
+// allowance for day, starting a new count when the day has changed.
+func (um *UserMgr) AddTimeUsed(username, day string, minutes int) error {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	_, err := um.store.ModifyUser(username, func(u *User) error {
+		u.TimeUsedToday = u.TimeUsedOn(day) + minutes
+		u.TimeUsedDate = day
+		return nil
+	})
+	return err
+}
+
+// DepositTime moves up to minutes into the user's time bank without taking
+// the balance past limit. Returns the minutes deposited.
+func (um *UserMgr) DepositTime(username string, minutes, limit int) (int, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	var amount int
+	_, err := um.store.ModifyUser(username, func(u *User) error {
+		amount = min(minutes, limit-u.TimeBank)
+		if amount <= 0 {
+			return ErrTimeBankFull
+		}
+		u.TimeBank += amount
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
+
+// WithdrawTime takes up to minutes out of the user's time bank. Returns the
+// minutes withdrawn.
+func (um *UserMgr) WithdrawTime(username string, minutes int) (int, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	var amount int
+	_, err := um.store.ModifyUser(username, func(u *User) error {
+		amount = min(minutes, u.TimeBank)
+		if amount <= 0 {
+			return ErrTimeBankEmpty
+		}
+		u.TimeBank -= amount
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
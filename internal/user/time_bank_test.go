@@ -0,0 +1,61 @@
+package user
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddTimeUsed(t *testing.T) {
+	um := newTestManager(t, []User{{ID: 1, Username: "alice", Handle: "Alice", TimeUsedToday: 40, TimeUsedDate: "2026-03-01"}})
+
+	if err := um.AddTimeUsed("alice", "2026-03-01", 15); err != nil {
+		t.Fatalf("AddTimeUsed: %v", err)
+	}
+	u, _ := um.GetUser("alice")
+	if got := u.TimeUsedOn("2026-03-01"); got != 55 {
+		t.Errorf("used = %d, want 55", got)
+	}
+	if got := u.TimeUsedOn("2026-03-02"); got != 0 {
+		t.Errorf("used next day = %d, want 0", got)
+	}
+
+	// A new day starts a new count
+	if err := um.AddTimeUsed("alice", "2026-03-02", 10); err != nil {
+		t.Fatalf("AddTimeUsed: %v", err)
+	}
+	u, _ = um.GetUser("alice")
+	if u.TimeUsedToday != 10 || u.TimeUsedDate != "2026-03-02" {
+		t.Errorf("used = %d on %s, want 10 on 2026-03-02", u.TimeUsedToday, u.TimeUsedDate)
+	}
+
+	if err := um.AddTimeUsed("nobody", "2026-03-02", 1); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("missing user: err = %v", err)
+	}
+}
+
+func TestTimeBank(t *testing.T) {
+	um := newTestManager(t, []User{{ID: 1, Username: "alice", Handle: "Alice", TimeBank: 20}})
+
+	n, err := um.DepositTime("alice", 50, 60)
+	if err != nil || n != 40 {
+		t.Fatalf("DepositTime = %d, %v; want 40 (capped)", n, err)
+	}
+	if _, err := um.DepositTime("alice", 5, 60); !errors.Is(err, ErrTimeBankFull) {
+		t.Errorf("deposit into full bank: err = %v", err)
+	}
+
+	n, err = um.WithdrawTime("alice", 15)
+	if err != nil || n != 15 {
+		t.Fatalf("WithdrawTime = %d, %v; want 15", n, err)
+	}
+	n, err = um.WithdrawTime("alice", 100)
+	if err != nil || n != 45 {
+		t.Fatalf("WithdrawTime = %d, %v; want 45 (balance)", n, err)
+	}
+	if _, err := um.WithdrawTime("alice", 1); !errors.Is(err, ErrTimeBankEmpty) {
+		t.Errorf("withdraw from empty bank: err = %v", err)
+	}
+	if u, _ := um.GetUser("alice"); u.TimeBank != 0 {
+		t.Errorf("balance = %d, want 0", u.TimeBank)
+	}
+}
@@ -0,0 +1,189 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 defaults, which every authenticator app supports).
+const (
+	totpPeriod = 30 // Seconds per code
+	totpDigits = 6
+	totpSkew   = 1 // Codes accepted either side of the current step, for clock drift
+
+	// RecoveryCodeCount is how many one-time recovery codes enrolment issues.
+	RecoveryCodeCount = 8
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPEnabled reports whether the user has two-factor authentication on.
+func (u *User) TOTPEnabled() bool {
+	return u.TOTPSecret != ""
+}
+
+// SetTOTPRequiredLevel sets the access level at or above which accounts
+// must use two-factor login (0 = never). Authenticate refuses them.
+func (um *UserMgr) SetTOTPRequiredLevel(level int) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	um.totpRequiredLevel = level
+}
+
+// NewTOTPSecret returns a random 160-bit secret, base32-encoded as
+// authenticator apps expect.
+func NewTOTPSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// TOTPURI returns the otpauth:// URI an authenticator app scans to add the
+// account. The algorithm, digits and period are the defaults and left out
+// to keep the QR code small.
+func TOTPURI(secret, issuer, account string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// TOTPCode returns the code for secret at time t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	return totpCodeAt(key, t.Unix()/totpPeriod), nil
+}
+
+// totpCodeAt is the HOTP value (RFC 4226) for a time step.
+func totpCodeAt(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// ValidateTOTP checks code against secret at time t, allowing for clock
+// drift. It returns the matching time step; codes from a step at or before
+// lastStep are refused so a code cannot be replayed.
+func ValidateTOTP(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+	now := t.Unix() / totpPeriod
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if hmac.Equal([]byte(totpCodeAt(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// NewRecoveryCodes returns RecoveryCodeCount one-time codes to show the user
+// and their hashes to store.
+func NewRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, err := newOneTimeCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// newOneTimeCode returns a random code in the form "abcde-fghij", as used
+// for recovery and password reset codes.
+func newOneTimeCode() (string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567" // RFC 4648 base32, lowercase
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for j := range buf {
+		buf[j] = alphabet[buf[j]&31]
+	}
+	return string(buf[:5]) + "-" + string(buf[5:]), nil
+}
+
+// hashRecoveryCode hashes a recovery code for storage. The codes are random
+// and long enough that a plain SHA-256 is sufficient.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if !strings.Contains(code, "-") && len(code) == 10 {
+		code = code[:5] + "-" + code[5:]
+	}
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckSecondFactor verifies a TOTP code or unused recovery code for a user
+// with two-factor authentication on. A TOTP code is good once; a recovery
+// code is removed when used. Returns whether a recovery code was used and
+// how many remain.
+func (um *UserMgr) CheckSecondFactor(username, code string) (recovery bool, remaining int, ok bool) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	saved, err := um.store.ModifyUser(username, func(u *User) error {
+		if u.DeletedUser || !u.TOTPEnabled() {
+			return errNoChange
+		}
+		if step, valid := ValidateTOTP(u.TOTPSecret, code, time.Now(), u.TOTPLastStep); valid {
+			u.TOTPLastStep = step
+			return nil
+		}
+		hash := hashRecoveryCode(code)
+		codes := make([]string, 0, len(u.TOTPRecoveryCodes))
+		matched := false
+		for _, h := range u.TOTPRecoveryCodes {
+			if !matched && hmac.Equal([]byte(h), []byte(hash)) {
+				matched = true
+				continue
+			}
+			codes = append(codes, h)
+		}
+		if !matched {
+			return errNoChange
+		}
+		recovery = true
+		u.TOTPRecoveryCodes = codes
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errNoChange) && !errors.Is(err, ErrUserNotFound) {
+			log.Printf("ERROR: Failed to save user data after second factor check for %s: %v", username, err)
+		}
+		return false, 0, false
+	}
+	return recovery, len(saved.TOTPRecoveryCodes), true
+}
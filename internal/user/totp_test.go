@@ -0,0 +1,101 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 test key from RFC 6238 appendix B,
+// "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range tests {
+		got, err := TOTPCode(rfcSecret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", unix, err)
+		}
+		if got != want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	code, _ := TOTPCode(rfcSecret, now)
+
+	step, ok := ValidateTOTP(rfcSecret, code, now, 0)
+	if !ok || step != now.Unix()/totpPeriod {
+		t.Fatalf("ValidateTOTP = %d, %v", step, ok)
+	}
+	if _, ok := ValidateTOTP(rfcSecret, code, now, step); ok {
+		t.Error("code accepted twice")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, code, now.Add(totpPeriod*time.Second), 0); !ok {
+		t.Error("code from the previous step refused")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, code, now.Add(3*totpPeriod*time.Second), 0); ok {
+		t.Error("stale code accepted")
+	}
+	if _, ok := ValidateTOTP(rfcSecret, "12345", now, 0); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("ABC", "My BBS", "Felonius")
+	if !strings.HasPrefix(uri, "otpauth://totp/My%20BBS:Felonius?") {
+		t.Errorf("uri = %s", uri)
+	}
+	if !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=My+BBS") {
+		t.Errorf("uri = %s", uri)
+	}
+}
+
+func TestCheckSecondFactor(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatalf("NewRecoveryCodes: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes, %d hashes", len(codes), len(hashes))
+	}
+	secret, _ := NewTOTPSecret()
+	um := newTestManager(t, []User{
+		{ID: 1, Username: "alice", Handle: "Alice", TOTPSecret: secret, TOTPRecoveryCodes: hashes},
+		{ID: 2, Username: "bob", Handle: "Bob"},
+	})
+
+	code, _ := TOTPCode(secret, time.Now())
+	if recovery, _, ok := um.CheckSecondFactor("alice", code); !ok || recovery {
+		t.Fatalf("TOTP code: recovery=%v ok=%v", recovery, ok)
+	}
+	if _, _, ok := um.CheckSecondFactor("alice", code); ok {
+		t.Error("TOTP code accepted twice")
+	}
+
+	// Recovery codes are case and dash insensitive, and work once
+	entered := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	recovery, remaining, ok := um.CheckSecondFactor("alice", entered)
+	if !ok || !recovery || remaining != RecoveryCodeCount-1 {
+		t.Fatalf("recovery code: recovery=%v remaining=%d ok=%v", recovery, remaining, ok)
+	}
+	if _, _, ok := um.CheckSecondFactor("alice", codes[0]); ok {
+		t.Error("recovery code accepted twice")
+	}
+	if u, _ := um.GetUser("alice"); len(u.TOTPRecoveryCodes) != RecoveryCodeCount-1 {
+		t.Errorf("stored %d recovery codes", len(u.TOTPRecoveryCodes))
+	}
+
+	if _, _, ok := um.CheckSecondFactor("bob", code); ok {
+		t.Error("code accepted for a user without 2FA")
+	}
+}
@@ -0,0 +1,158 @@
+package user
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const transferLogFile = "transfers.jsonl" // Append-only log of file transfers
+
+// Transfer directions.
+const (
+	TransferUpload   = "U"
+	TransferDownload = "D"
+)
+
+// TransferFile is one file that was transferred whole.
+type TransferFile struct {
+	Name   string `json:"name"`
+	AreaID int    `json:"areaId,omitempty"` // 0 for files outside the file areas (QWK packets)
+	Size   int64  `json:"size"`
+}
+
+// TransferRecord describes one protocol transfer, successful or not. Files
+// lists only what arrived whole; an aborted batch keeps the files that
+// completed before the abort.
+type TransferRecord struct {
+	Timestamp time.Time      `json:"timestamp"` // When the transfer ended
+	UserID    int            `json:"userId"`
+	Handle    string         `json:"handle"`
+	NodeID    int            `json:"nodeId,omitempty"` // 0 for SFTP/scp sessions
+	Direction string         `json:"direction"`        // TransferUpload or TransferDownload
+	Protocol  string         `json:"protocol"`
+	Files     []TransferFile `json:"files,omitempty"`
+	Bytes     int64          `json:"bytes"`
+	Duration  time.Duration  `json:"duration"`
+	CPS       int64          `json:"cps"`
+	Success   bool           `json:"success"`
+	Invisible bool           `json:"invisible,omitempty"` // Made during an invisible logon
+}
+
+// NewTransferRecord builds a record for a transfer that ran from started
+// until now, totalling the bytes and throughput of files.
+func NewTransferRecord(direction, protocol string, started time.Time, files []TransferFile, success bool) TransferRecord {
+	rec := TransferRecord{
+		Timestamp: time.Now(),
+		Direction: direction,
+		Protocol:  protocol,
+		Files:     files,
+		Duration:  time.Since(started),
+		Success:   success,
+	}
+	for _, f := range files {
+		rec.Bytes += f.Size
+	}
+	if secs := rec.Duration.Seconds(); secs > 0 {
+		rec.CPS = int64(float64(rec.Bytes) / secs)
+	}
+	return rec
+}
+
+// TransferTotals sums the transfers of a session or a log.
+type TransferTotals struct {
+	Uploads         int
+	UploadedBytes   int64
+	Downloads       int
+	DownloadedBytes int64
+}
+
+// Add counts rec's files and bytes in t.
+func (t *TransferTotals) Add(rec TransferRecord) {
+	switch rec.Direction {
+	case TransferUpload:
+		t.Uploads += len(rec.Files)
+		t.UploadedBytes += rec.Bytes
+	case TransferDownload:
+		t.Downloads += len(rec.Files)
+		t.DownloadedBytes += rec.Bytes
+	}
+}
+
+// Actions returns the call record action letters for t, e.g. "D,U".
+func (t TransferTotals) Actions() string {
+	var actions []string
+	if t.Downloads > 0 {
+		actions = append(actions, "D")
+	}
+	if t.Uploads > 0 {
+		actions = append(actions, "U")
+	}
+	return strings.Join(actions, ",")
+}
+
+// BytesToMB converts a byte count to megabytes for call records.
+func BytesToMB(n int64) float64 {
+	return float64(n) / (1024 * 1024)
+}
+
+// LogTransfer appends rec to the transfer log.
+func (um *UserMgr) LogTransfer(rec TransferRecord) error {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now()
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transfer record: %w", err)
+	}
+
+	logPath := filepath.Join(um.dataPath, transferLogFile)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open transfer log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write transfer log: %w", err)
+	}
+	return f.Close()
+}
+
+// TransferLog reads the transfer log, oldest first.
+func (um *UserMgr) TransferLog() ([]TransferRecord, error) {
+	um.mu.RLock()
+	defer um.mu.RUnlock()
+	return LoadTransferLog(um.dataPath)
+}
+
+// LoadTransferLog reads the transfer log from dataPath, oldest first.
+// Malformed lines are skipped. A missing log returns no entries.
+func LoadTransferLog(dataPath string) ([]TransferRecord, error) {
+	f, err := os.Open(filepath.Join(dataPath, transferLogFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []TransferRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec TransferRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		entries = append(entries, rec)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTransferRecord_TotalsAndCPS(t *testing.T) {
+	files := []TransferFile{{Name: "A.ZIP", AreaID: 1, Size: 3000}, {Name: "B.ZIP", AreaID: 2, Size: 1000}}
+	rec := NewTransferRecord(TransferDownload, "Zmodem", time.Now().Add(-2*time.Second), files, true)
+
+	if rec.Bytes != 4000 {
+		t.Errorf("Bytes = %d, want 4000", rec.Bytes)
+	}
+	if rec.CPS < 1500 || rec.CPS > 2000 {
+		t.Errorf("CPS = %d, want about 2000", rec.CPS)
+	}
+	if !rec.Success || rec.Timestamp.IsZero() {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestTransferTotals(t *testing.T) {
+	var totals TransferTotals
+	if totals.Actions() != "" {
+		t.Errorf("empty totals should have no actions, got %q", totals.Actions())
+	}
+	totals.Add(TransferRecord{Direction: TransferUpload, Files: []TransferFile{{Size: 10}}, Bytes: 10})
+	if totals.Actions() != "U" {
+		t.Errorf("Actions = %q, want U", totals.Actions())
+	}
+	totals.Add(TransferRecord{Direction: TransferDownload, Files: []TransferFile{{Size: 5}, {Size: 7}}, Bytes: 12})
+	totals.Add(TransferRecord{Direction: TransferDownload, Success: false}) // aborted, nothing delivered
+
+	if totals.Uploads != 1 || totals.UploadedBytes != 10 || totals.Downloads != 2 || totals.DownloadedBytes != 12 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+	if totals.Actions() != "D,U" {
+		t.Errorf("Actions = %q, want D,U", totals.Actions())
+	}
+}
+
+func TestLogTransfer_AppendsAndLoads(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "users.json"), []byte("[]"), 0644)
+
+	um, err := NewUserManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewUserManager: %v", err)
+	}
+
+	um.LogTransfer(TransferRecord{UserID: 2, Handle: "Bob", Direction: TransferUpload, Protocol: "Zmodem", Bytes: 100, Success: true})
+	um.LogTransfer(TransferRecord{UserID: 2, Handle: "Bob", Direction: TransferDownload, Protocol: "SFTP"})
+
+	entries, err := um.TransferLog()
+	if err != nil {
+		t.Fatalf("TransferLog: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Protocol != "Zmodem" || entries[1].Success {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if entries[1].Timestamp.IsZero() {
+		t.Error("expected timestamp to be filled in")
+	}
+
+	missing, err := LoadTransferLog(t.TempDir())
+	if err != nil || len(missing) != 0 {
+		t.Errorf("missing log: got %v, %v", missing, err)
+	}
+}
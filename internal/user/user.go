@@ -34,9 +34,20 @@ type User struct {
 	FilePoints       int       `json:"filePoints"`    // Added for P
 	NumUploads       int       `json:"numUploads"`    // Added for E
 	NumDownloads     int       `json:"numDownloads,omitempty"` // Download count for ACS 'B' ratio
+	UploadedBytes    int64     `json:"uploadedBytes,omitempty"`   // Lifetime bytes received from this user
+	DownloadedBytes  int64     `json:"downloadedBytes,omitempty"` // Lifetime bytes sent to this user
+	RatioExempt      bool      `json:"ratioExempt,omitempty"`  // Skip file point charges and download ratio checks
 	MessagesPosted   int       `json:"messagesPosted,omitempty"` // Number of messages posted by user
 	// NumLogons is TimesCalled
 	TimeLimit   int    `json:"timeLimit"`   // Added for T (in minutes)
+	DailyTimeLimit int    `json:"dailyTimeLimit,omitempty"` // Minutes allowed per day across all calls (0 = no daily limit)
+	TimeUsedToday  int    `json:"timeUsedToday,omitempty"`  // Minutes charged on TimeUsedDate
+	TimeUsedDate   string `json:"timeUsedDate,omitempty"`   // Day of TimeUsedToday (YYYY-MM-DD, BBS time zone)
+	TimeBank       int    `json:"timeBank,omitempty"`       // Minutes saved in the time bank
+	CallsToday     int    `json:"callsToday,omitempty"`     // Logons on CallsDate
+	CallsDate      string `json:"callsDate,omitempty"`      // Day of CallsToday (YYYY-MM-DD, BBS time zone)
+	MailSentToday  int    `json:"mailSentToday,omitempty"`  // Private mails sent on MailSentDate
+	MailSentDate   string `json:"mailSentDate,omitempty"`   // Day of MailSentToday (YYYY-MM-DD, BBS time zone)
 	PrivateNote string `json:"privateNote"` // Added for Z
 	// Conference tracking for ACS codes C (message conference) and X (file conference)
 	CurrentMsgConferenceID   int    `json:"current_msg_conference_id,omitempty"`
@@ -71,6 +82,32 @@ type User struct {
 	AutoSignature   string `json:"autoSignature,omitempty"`   // Auto-signature appended to messages (max 5 lines)
 	Colors           [7]int `json:"colors,omitempty"`
 
+	// SSH Public Keys (a registered key logs in over SSH without the BBS password)
+	SSHKeys []SSHKey `json:"sshKeys,omitempty"`
+
+	// Two-Factor Authentication (RFC 6238 TOTP)
+	TOTPSecret        string   `json:"totpSecret,omitempty"`        // Base32 secret; empty = 2FA off
+	TOTPRecoveryCodes []string `json:"totpRecoveryCodes,omitempty"` // SHA-256 hashes of unused recovery codes
+	TOTPLastStep      int64    `json:"totpLastStep,omitempty"`      // Time step of the last accepted code (replay guard)
+
+	// Password policy (see password.go)
+	PasswordChangedAt  *time.Time `json:"passwordChangedAt,omitempty"`  // When the password was last set (nil = not yet recorded)
+	PasswordHistory    []string   `json:"passwordHistory,omitempty"`    // Hashes of recent earlier passwords, newest first
+	MustChangePassword bool       `json:"mustChangePassword,omitempty"` // Ask for a new password at the next login
+	ResetCodeHash      string     `json:"resetCodeHash,omitempty"`      // SHA-256 of a sysop-issued one-time reset code
+	ResetCodeExpires   *time.Time `json:"resetCodeExpires,omitempty"`   // When the reset code stops working
+
+	// Infoform answers, keyed by form ID (see infoforms.json)
+	InfoForms map[int]InfoFormAnswers `json:"infoForms,omitempty"`
+
+	// Highest access level the user has held, so a level's signup points are
+	// granted only once (see ApplySecurityLevel)
+	HighestLevel int `json:"highestLevel,omitempty"`
+
+	// Account Expiration (e.g. a paid or donor subscription)
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`     // When the account drops to ExpireToLevel (nil = never)
+	ExpireToLevel int        `json:"expireToLevel,omitempty"` // Access level the account is moved to on expiry
+
 	// Soft Delete (user marked as deleted but data preserved)
 	DeletedUser bool       `json:"deletedUser,omitempty"` // True if user is soft-deleted
 	DeletedAt   *time.Time `json:"deletedAt,omitempty"`   // Timestamp when user was deleted (nil if not deleted)
@@ -85,9 +122,11 @@ type CallRecord struct {
 	ConnectTime    time.Time     `json:"connectTime"`
 	DisconnectTime time.Time     `json:"disconnectTime"`
 	Duration       time.Duration `json:"duration"`
-	UploadedMB     float64       `json:"uploadedMB"`           // Placeholder for now
-	DownloadedMB   float64       `json:"downloadedMB"`         // Placeholder for now
-	Actions        string        `json:"actions"`              // Placeholder for now (e.g., "D,U,M")
+	UploadedMB     float64       `json:"uploadedMB"`
+	DownloadedMB   float64       `json:"downloadedMB"`
+	Uploads        int           `json:"uploads,omitempty"`    // Files uploaded during the call
+	Downloads      int           `json:"downloads,omitempty"`  // Files downloaded during the call
+	Actions        string        `json:"actions"`              // Activity letters (e.g., "D,U")
 	BaudRate       string        `json:"baudRate"`             // Static value for now
 	CallNumber     uint64        `json:"callNumber,omitempty"` // Overall call number
 	Invisible      bool          `json:"invisible,omitempty"`  // True if user was logged in invisibly
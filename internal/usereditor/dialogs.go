@@ -1,7 +1,11 @@
 package usereditor
 
 import (
+	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/stlalpha/vision3/internal/config"
 )
 
 // overlayConfirmDialog renders a confirmation dialog centered over the background.
@@ -139,3 +143,157 @@ func (m Model) overlayHelpScreen(background string) string {
 	return strings.Join(lines, "\n")
 }
 
+// overlaySSHKeysDialog renders the user's registered SSH keys with the
+// selected one highlighted and its details below the list.
+func (m Model) overlaySSHKeysDialog(background string, u *userType) string {
+	lines := strings.Split(background, "\n")
+
+	dialogW := 72
+	innerW := dialogW - 2
+	startCol := (m.width - dialogW) / 2
+	if startCol < 0 {
+		startCol = 0
+	}
+
+	border := dialogBorderStyle.Render("╔" + strings.Repeat("═", innerW) + "╗")
+	borderBot := dialogBorderStyle.Render("╚" + strings.Repeat("═", innerW) + "╝")
+	side := dialogBorderStyle.Render("║")
+	textLine := func(text string) string {
+		return side + dialogTextStyle.Render(padRight(" "+text, innerW)) + side
+	}
+
+	dialogLines := []string{
+		border,
+		side + dialogTitleStyle.Render(centerText("SSH Keys for "+u.Handle, innerW)) + side,
+		textLine(""),
+	}
+	if len(u.SSHKeys) == 0 {
+		dialogLines = append(dialogLines, textLine("No SSH keys registered."))
+	}
+	for i, k := range u.SSHKeys {
+		entry := padRight(" "+k.Fingerprint+"  "+k.Comment, innerW)
+		if i == m.keyCursor {
+			dialogLines = append(dialogLines, side+buttonActiveStyle.Render(entry)+side)
+		} else {
+			dialogLines = append(dialogLines, side+dialogTextStyle.Render(entry)+side)
+		}
+	}
+	dialogLines = append(dialogLines, textLine(""))
+	if m.keyCursor >= 0 && m.keyCursor < len(u.SSHKeys) {
+		k := u.SSHKeys[m.keyCursor]
+		keyType, _, _ := strings.Cut(k.Key, " ")
+		dialogLines = append(dialogLines,
+			textLine(fmt.Sprintf("%s  Added %s  Last used %s", keyType, formatTime(k.AddedAt), formatTime(k.LastUsed))),
+			textLine(""))
+	}
+	dialogLines = append(dialogLines,
+		side+dialogTitleStyle.Render(centerText("D - Revoke Key  ESC - Done", innerW))+side,
+		borderBot)
+
+	startRow := (m.height - len(dialogLines)) / 2
+	tailW := max(0, m.width-startCol-dialogW)
+	tail := bgFillStyle.Render(strings.Repeat("░", tailW))
+	for i, dl := range dialogLines {
+		row := startRow + i
+		if row >= 0 && row < len(lines) {
+			lines[row] = padToCol(lines[row], startCol) + dl + tail
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// answeredFormIDs returns the IDs of the infoforms u has answered, in order.
+func answeredFormIDs(u *userType) []int {
+	ids := make([]int, 0, len(u.InfoForms))
+	for id := range u.InfoForms {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// overlayInfoFormsDialog renders the user's answers to one infoform at a
+// time. Questions come from infoforms.json when the form is still defined;
+// otherwise answers are listed by question ID.
+func (m Model) overlayInfoFormsDialog(background string, u *userType) string {
+	lines := strings.Split(background, "\n")
+
+	dialogW := 72
+	innerW := dialogW - 2
+	startCol := (m.width - dialogW) / 2
+	if startCol < 0 {
+		startCol = 0
+	}
+
+	border := dialogBorderStyle.Render("╔" + strings.Repeat("═", innerW) + "╗")
+	borderBot := dialogBorderStyle.Render("╚" + strings.Repeat("═", innerW) + "╝")
+	side := dialogBorderStyle.Render("║")
+	textLine := func(text string) string {
+		return side + dialogTextStyle.Render(padRight(" "+text, innerW)) + side
+	}
+
+	dialogLines := []string{
+		border,
+		side + dialogTitleStyle.Render(centerText("Infoforms for "+u.Handle, innerW)) + side,
+		textLine(""),
+	}
+
+	ids := answeredFormIDs(u)
+	if len(ids) == 0 {
+		dialogLines = append(dialogLines, textLine("No infoforms answered."))
+	} else {
+		idx := min(max(m.formIndex, 0), len(ids)-1)
+		id := ids[idx]
+		a := u.InfoForms[id]
+		f, defined := config.FindInfoForm(m.infoForms, id)
+		name := fmt.Sprintf("Form %d", id)
+		if defined {
+			name = fmt.Sprintf("Form %d: %s", id, f.Name)
+		}
+		header := fmt.Sprintf("%s  (v%d, %s)", name, a.Version, formatTime(a.Answered))
+		if defined && a.Version < f.Version {
+			header += "  OUT OF DATE"
+		}
+		dialogLines = append(dialogLines,
+			side+buttonActiveStyle.Render(padRight(" "+header, innerW))+side,
+			textLine(""))
+
+		if defined {
+			for _, q := range f.Questions {
+				dialogLines = append(dialogLines, textLine(q.Text), textLine("  "+a.Answers[q.ID]))
+			}
+		} else {
+			qids := make([]string, 0, len(a.Answers))
+			for qid := range a.Answers {
+				qids = append(qids, qid)
+			}
+			sort.Strings(qids)
+			for _, qid := range qids {
+				dialogLines = append(dialogLines, textLine(qid+": "+a.Answers[qid]))
+			}
+		}
+		dialogLines = append(dialogLines, textLine(""),
+			textLine(fmt.Sprintf("Form %d of %d", idx+1, len(ids))))
+	}
+
+	// Keep the dialog on screen for long forms
+	if maxLines := m.height - 2; maxLines > 4 && len(dialogLines) > maxLines {
+		dialogLines = dialogLines[:maxLines]
+	}
+	dialogLines = append(dialogLines,
+		side+dialogTitleStyle.Render(centerText("←/→ - Other Forms  ESC - Done", innerW))+side,
+		borderBot)
+
+	startRow := (m.height - len(dialogLines)) / 2
+	tailW := max(0, m.width-startCol-dialogW)
+	tail := bgFillStyle.Render(strings.Repeat("░", tailW))
+	for i, dl := range dialogLines {
+		row := startRow + i
+		if row >= 0 && row < len(lines) {
+			lines[row] = padToCol(lines[row], startCol) + dl + tail
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
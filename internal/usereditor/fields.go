@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/stlalpha/vision3/internal/config"
 	"github.com/stlalpha/vision3/internal/user"
 )
 
@@ -33,11 +34,12 @@ type fieldDef struct {
 	Set     func(u *user.User, val string) error
 }
 
-// editFields returns the ordered list of editable fields.
+// editFields returns the ordered list of editable fields. Changing the access
+// level applies that level's profile from levels.
 // Layout matches UE.PAS v1.3 Proc_Entry: left column (x=3) and right column (x=50).
-func editFields() []fieldDef {
+func editFields(levels []config.SecurityLevel) []fieldDef {
 	return []fieldDef{
-		// Left column (x=3, rows 4-18)
+		// Left column (x=3, rows 4-16, and Must Chg PW on row 22)
 		{
 			Label: "Handle", Type: ftString, Col: 3, Row: 4, Width: 22,
 			Get: func(u *user.User) string { return u.Handle },
@@ -66,7 +68,10 @@ func editFields() []fieldDef {
 				if err != nil {
 					return err
 				}
-				u.AccessLevel = n
+				if n == u.AccessLevel {
+					return nil
+				}
+				u.ApplySecurityLevel(levels, n)
 				return nil
 			},
 		},
@@ -135,6 +140,11 @@ func editFields() []fieldDef {
 				return "(set)"
 			},
 		},
+		{
+			Label: "Must Chg PW", Type: ftYesNo, Col: 3, Row: 22, Width: 1,
+			Get: func(u *user.User) string { return boolToYN(u.MustChangePassword) },
+			Set: func(u *user.User, val string) error { u.MustChangePassword = ynToBool(val); return nil },
+		},
 
 		// Right column (x=50, rows 4-16)
 		{
@@ -222,6 +232,48 @@ func editFields() []fieldDef {
 				return nil
 			},
 		},
+		{
+			Label: "Ratio Exempt", Type: ftYesNo, Col: 50, Row: 13, Width: 1,
+			Get: func(u *user.User) string { return boolToYN(u.RatioExempt) },
+			Set: func(u *user.User, val string) error { u.RatioExempt = ynToBool(val); return nil },
+		},
+		{
+			Label: "SSH Keys", Type: ftAction, Col: 50, Row: 14, Width: 10,
+			Get: func(u *user.User) string {
+				switch len(u.SSHKeys) {
+				case 0:
+					return "(none)"
+				case 1:
+					return "1 key"
+				default:
+					return fmt.Sprintf("%d keys", len(u.SSHKeys))
+				}
+			},
+		},
+		{
+			Label: "Daily Time", Type: ftInteger, Col: 50, Row: 15, Width: 6, Min: 0, Max: 1440,
+			Get: func(u *user.User) string { return strconv.Itoa(u.DailyTimeLimit) },
+			Set: func(u *user.User, val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				u.DailyTimeLimit = n
+				return nil
+			},
+		},
+		{
+			Label: "Time Bank", Type: ftInteger, Col: 50, Row: 16, Width: 6, Min: 0, Max: 99999,
+			Get: func(u *user.User) string { return strconv.Itoa(u.TimeBank) },
+			Set: func(u *user.User, val string) error {
+				n, err := strconv.Atoi(val)
+				if err != nil {
+					return err
+				}
+				u.TimeBank = n
+				return nil
+			},
+		},
 
 		// Row 17: separator rendered by view_edit.go
 
@@ -235,6 +287,14 @@ func editFields() []fieldDef {
 			Label: "Msgs Posted", Type: ftDisplay, Col: 3, Row: 19, Width: 6,
 			Get: func(u *user.User) string { return strconv.Itoa(u.MessagesPosted) },
 		},
+		{
+			Label: "Num Downloads", Type: ftDisplay, Col: 3, Row: 20, Width: 6,
+			Get: func(u *user.User) string { return strconv.Itoa(u.NumDownloads) },
+		},
+		{
+			Label: "Expires", Type: ftDisplay, Col: 3, Row: 21, Width: 16,
+			Get: func(u *user.User) string { return formatExpiry(u) },
+		},
 		// Right column display fields
 		{
 			Label: "Created", Type: ftDisplay, Col: 50, Row: 18, Width: 16,
@@ -276,6 +336,15 @@ func formatTime(t time.Time) string {
 	return t.Format("01/02/06 3:04PM")
 }
 
+// formatExpiry formats when u's account expires and the level it then
+// drops to.
+func formatExpiry(u *user.User) string {
+	if u.ExpiresAt == nil {
+		return "Never"
+	}
+	return fmt.Sprintf("%s to %d", formatDate(*u.ExpiresAt), u.ExpireToLevel)
+}
+
 // formatDate formats a time as date only.
 func formatDate(t time.Time) string {
 	if t.IsZero() {
@@ -118,6 +118,16 @@ func CloneUser(u *user.User) *user.User {
 		c.TaggedMessageAreaTags = make([]string, len(u.TaggedMessageAreaTags))
 		copy(c.TaggedMessageAreaTags, u.TaggedMessageAreaTags)
 	}
+	if u.SSHKeys != nil {
+		c.SSHKeys = make([]user.SSHKey, len(u.SSHKeys))
+		copy(c.SSHKeys, u.SSHKeys)
+	}
+	if u.InfoForms != nil {
+		c.InfoForms = make(map[int]user.InfoFormAnswers, len(u.InfoForms))
+		for id, a := range u.InfoForms {
+			c.InfoForms[id] = a
+		}
+	}
 	if u.DeletedAt != nil {
 		t := *u.DeletedAt
 		c.DeletedAt = &t
@@ -1,6 +1,7 @@
 package usereditor
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -8,8 +9,8 @@ import (
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/stlalpha/vision3/internal/config"
 	"github.com/stlalpha/vision3/internal/user"
 )
 
@@ -36,6 +37,8 @@ const (
 	modeExitConfirm                    // Unsaved changes exit confirm
 	modePasswordEntry                  // Password entry for reset
 	modeSaveConfirm                    // Confirm save before exit
+	modeSSHKeys                        // SSH key list for revoking keys
+	modeInfoForms                      // Infoform answers (read-only)
 )
 
 // Model is the BubbleTea model for the user editor TUI.
@@ -46,11 +49,14 @@ type Model struct {
 	filePath  string
 	fileMtime time.Time // mtime at load for optimistic concurrency
 	dirty     bool
+	levels    []config.SecurityLevel // Security level profiles from levels.json
+	infoForms []config.InfoForm      // Infoforms from infoforms.json (for question text)
+	policy    user.PasswordPolicy    // Password policy from config.json
 
 	// List mode state
 	cursor       int          // Current position in user list (0-based)
 	scrollOffset int          // First visible row in the list
-	listType     int          // Column view mode (1-5)
+	listType     int          // Column view mode (1-6)
 	listAlpha    bool         // Alphabetical sort active
 	tagged       map[int]bool // Tagged user indices (0-based)
 
@@ -58,6 +64,8 @@ type Model struct {
 	editIndex int        // Index into users slice being edited
 	editField int        // Current field index (0-based)
 	fields    []fieldDef // Field definitions
+	keyCursor int        // Selected key in the SSH key list
+	formIndex int        // Form shown in the infoform answers dialog
 
 	// Text input (shared for editing fields, search, password)
 	textInput textinput.Model
@@ -75,8 +83,11 @@ type Model struct {
 	message string // Flash message
 }
 
-// New creates a new user editor model.
-func New(filePath string) (Model, error) {
+// New creates a new user editor model. levels are the security level
+// profiles applied when a user's access level is changed; nil means none.
+// forms supply the question text when viewing infoform answers. Passwords
+// set in the editor follow policy.
+func New(filePath string, levels []config.SecurityLevel, forms []config.InfoForm, policy user.PasswordPolicy) (Model, error) {
 	users, mtime, err := LoadUsers(filePath)
 	if err != nil {
 		return Model{}, fmt.Errorf("loading users: %w", err)
@@ -106,7 +117,10 @@ func New(filePath string) (Model, error) {
 		cursor:    0,
 		listType:  1,
 		tagged:    make(map[int]bool),
-		fields:    editFields(),
+		fields:    editFields(levels),
+		levels:    levels,
+		infoForms: forms,
+		policy:    policy,
 		textInput: ti,
 		searchInput: si,
 		width:     minWidth,
@@ -151,6 +165,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateHelp(msg)
 		case modePasswordEntry:
 			return m.updatePassword(msg)
+		case modeSSHKeys:
+			return m.updateSSHKeys(msg)
+		case modeInfoForms:
+			return m.updateInfoForms(msg)
 		}
 	}
 	return m, nil
@@ -238,7 +256,7 @@ func (m Model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.listType--
 			}
 		case "right":
-			if m.listType < 5 {
+			if m.listType < 6 {
 				m.listType++
 			}
 		case "shift+f2":
@@ -280,6 +298,11 @@ func (m Model) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
 	case tea.KeyTab, tea.KeyEnter:
 		f := m.fields[m.editField]
+		if f.Type == ftAction && f.Label == "SSH Keys" {
+			m.mode = modeSSHKeys
+			m.keyCursor = 0
+			return m, nil
+		}
 		if f.Type == ftAction {
 			// Password field - enter password entry mode
 			m.mode = modePasswordEntry
@@ -341,6 +364,17 @@ func (m Model) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.confirmYes = false
 		return m, nil
 
+	case tea.KeyF6:
+		// View infoform answers
+		m.mode = modeInfoForms
+		m.formIndex = 0
+		return m, nil
+
+	case tea.KeyF7:
+		// Issue a password reset code
+		m.issueResetCode()
+		return m, nil
+
 	case tea.KeyF10:
 		// Abort - discard changes for this user
 		m.mode = modeList
@@ -566,12 +600,19 @@ func (m Model) updatePassword(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case tea.KeyEnter:
 		pw := m.textInput.Value()
 		if pw != "" {
-			hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
-			if err != nil {
+			// A password set by the sysop is temporary: the user must
+			// change it at their next login unless Must Chg PW is cleared.
+			u := m.users[m.editIndex]
+			switch err := m.policy.SetPassword(u, pw); {
+			case errors.Is(err, user.ErrPasswordTooShort):
+				m.message = fmt.Sprintf("Password must be at least %d characters", m.policy.MinLength)
+			case errors.Is(err, user.ErrPasswordReused):
+				m.message = "Password was used recently"
+			case err != nil:
 				m.message = fmt.Sprintf("Hash error: %v", err)
-			} else {
-				m.users[m.editIndex].PasswordHash = string(hash)
-				m.users[m.editIndex].UpdatedAt = time.Now()
+			default:
+				u.MustChangePassword = true
+				u.UpdatedAt = time.Now()
 				m.dirty = true
 				m.message = "Password updated"
 			}
@@ -594,6 +635,80 @@ func (m Model) updatePassword(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// issueResetCode gives the user being edited a one-time password reset
+// code and shows it. The code is kept only if the user is saved.
+func (m *Model) issueResetCode() {
+	u := m.users[m.editIndex]
+	code, expires, err := m.policy.IssueResetCode(u)
+	if err != nil {
+		m.message = fmt.Sprintf("Reset code error: %v", err)
+		return
+	}
+	u.UpdatedAt = time.Now()
+	m.dirty = true
+	m.message = fmt.Sprintf("Reset code %s valid until %s - save to keep it", code, formatTime(expires))
+}
+
+// --- SSH Keys ---
+
+func (m Model) updateSSHKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	u := m.users[m.editIndex]
+	switch msg.Type {
+	case tea.KeyUp:
+		if m.keyCursor > 0 {
+			m.keyCursor--
+		}
+	case tea.KeyDown:
+		if m.keyCursor < len(u.SSHKeys)-1 {
+			m.keyCursor++
+		}
+	case tea.KeyDelete:
+		m.revokeSSHKey()
+	case tea.KeyEscape, tea.KeyEnter:
+		m.mode = modeEdit
+	default:
+		switch msg.String() {
+		case "d", "D":
+			m.revokeSSHKey()
+		}
+	}
+	return m, nil
+}
+
+// --- Infoforms ---
+
+func (m Model) updateInfoForms(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(answeredFormIDs(m.users[m.editIndex]))
+	switch msg.Type {
+	case tea.KeyLeft, tea.KeyUp, tea.KeyPgUp:
+		if m.formIndex > 0 {
+			m.formIndex--
+		}
+	case tea.KeyRight, tea.KeyDown, tea.KeyPgDown:
+		if m.formIndex < n-1 {
+			m.formIndex++
+		}
+	case tea.KeyEscape, tea.KeyEnter, tea.KeyF6:
+		m.mode = modeEdit
+	}
+	return m, nil
+}
+
+// revokeSSHKey removes the selected key from the user being edited.
+func (m *Model) revokeSSHKey() {
+	u := m.users[m.editIndex]
+	if m.keyCursor < 0 || m.keyCursor >= len(u.SSHKeys) {
+		return
+	}
+	u.RemoveSSHKey(u.SSHKeys[m.keyCursor].Fingerprint)
+	u.UpdatedAt = time.Now()
+	m.dirty = true
+	m.message = "SSH key revoked"
+	if m.keyCursor >= len(u.SSHKeys) {
+		m.keyCursor = max(0, len(u.SSHKeys)-1)
+	}
+}
+
 // --- Confirm Dialog ---
 
 func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -732,10 +847,16 @@ func (m *Model) autoValidateUser(idx int) {
 		return
 	}
 	u := m.users[idx]
-	u.AccessLevel = 10
+	if _, ok := config.FindSecurityLevel(m.levels, 10); ok {
+		if u.AccessLevel != 10 {
+			u.ApplySecurityLevel(m.levels, 10)
+		}
+	} else {
+		u.AccessLevel = 10
+		u.FilePoints = 100
+		u.TimeLimit = 60
+	}
 	u.Validated = true
-	u.FilePoints = 100
-	u.TimeLimit = 60
 	u.UpdatedAt = time.Now()
 	m.dirty = true
 	m.message = fmt.Sprintf("Validated: %s", u.Handle)
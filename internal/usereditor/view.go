@@ -3,6 +3,7 @@ package usereditor
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -10,7 +11,7 @@ import (
 // View implements tea.Model.
 func (m Model) View() string {
 	switch m.mode {
-	case modeEdit, modeEditField, modePasswordEntry:
+	case modeEdit, modeEditField, modePasswordEntry, modeSSHKeys, modeInfoForms:
 		return m.viewEditScreen()
 	default:
 		return m.viewListScreen()
@@ -194,6 +195,8 @@ func (m Model) renderColumnTitle(width int) string {
 		cols = "Posts    Valid"
 	case 5:
 		cols = "Last Date Online"
+	case 6:
+		cols = "Expires   To   Days"
 	}
 	full := nameStr + cols
 	if len(full) > width {
@@ -244,6 +247,18 @@ func (m Model) renderUserRow(idx int, isHighlight bool, boxW int) string {
 	case 5:
 		dataCols = padRight(formatDate(u.LastLogin), 11) +
 			padRight(formatTimeOnly(u.LastLogin), 8)
+	case 6:
+		if u.ExpiresAt == nil {
+			dataCols = padRight("Never", 19)
+		} else {
+			days := "Expired"
+			if n, _ := u.DaysUntilExpiry(time.Now()); n > 0 {
+				days = fmt.Sprintf("%d", n)
+			}
+			dataCols = padRight(formatDate(*u.ExpiresAt), 10) +
+				padRight(fmt.Sprintf("%d", u.ExpireToLevel), 5) +
+				padRight(days, 7)
+		}
 	}
 
 	// Build the full row content
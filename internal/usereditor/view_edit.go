@@ -77,13 +77,17 @@ func (m Model) viewEditScreen() string {
 
 	// === Bottom help bar ===
 	// UE.PAS: 'F2 - Delete  F5 - Set Defaults  F10 - Aborts  ESC - Save Changes'
-	helpText := centerText("F2 - Delete  F5 - Set Defaults  F10 - Aborts  ESC - Save Changes", m.width)
+	helpText := centerText("F2 - Delete  F5 - Defaults  F6 - Forms  F7 - Reset Code  F10 - Abort  ESC - Save", m.width)
 	b.WriteString(helpBarStyle.Render(helpText))
 
 	// Overlay for password entry
 	result := b.String()
 	if m.mode == modePasswordEntry {
 		result = m.overlayPasswordDialog(result)
+	} else if m.mode == modeSSHKeys {
+		result = m.overlaySSHKeysDialog(result, u)
+	} else if m.mode == modeInfoForms {
+		result = m.overlayInfoFormsDialog(result, u)
 	} else if m.mode == modeDeleteConfirm {
 		result = m.overlayConfirmDialog(result, "-- Delete User --",
 			fmt.Sprintf("Delete %s? ", u.Handle))
@@ -126,7 +130,8 @@ func (m Model) renderEditRow(row int, u *userType, boxW int) string {
 			separatorStyle.Render(strings.Repeat("─", max(0, boxW-sepPad-len(sepText))))
 	}
 
-	// Special row: User Number display (row 22 in the box)
+	// Special row: User Number display (row 22 in the box), to the right
+	// of the Must Chg PW field
 	if row == 22 {
 		infoText := fmt.Sprintf("User Number: %d of %d", m.editIndex+1, len(m.users))
 		infoRendered := editInfoLabelStyle.Render("User Number: ") +
@@ -135,7 +140,11 @@ func (m Model) renderEditRow(row int, u *userType, boxW int) string {
 			editInfoValueStyle.Render(fmt.Sprintf("%d", len(m.users)))
 		leftPad := 40
 		rawLen := len(infoText)
-		return fieldDisplayStyle.Render(strings.Repeat(" ", leftPad)) + infoRendered +
+		left := fieldDisplayStyle.Render(strings.Repeat(" ", leftPad))
+		if leftField != "" {
+			left = leftField + fieldDisplayStyle.Render(strings.Repeat(" ", max(0, leftPad-leftRawW)))
+		}
+		return left + infoRendered +
 			fieldDisplayStyle.Render(strings.Repeat(" ", max(0, boxW-leftPad-rawLen)))
 	}
 
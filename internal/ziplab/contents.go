@@ -0,0 +1,184 @@
+package ziplab
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxMemberExtractBytes caps how much of a single member is written when
+// extracting it for viewing or download, guarding against decompression bombs.
+const maxMemberExtractBytes = 256 * 1024 * 1024 // 256 MB
+
+// ArchiveMember describes one file stored inside an archive.
+type ArchiveMember struct {
+	Name     string // Path inside the archive, sanitized for display
+	Size     int64  // Uncompressed size in bytes
+	Modified time.Time
+
+	zipIndex int    // Index into zip.Reader.File (native ZIP only)
+	path     string // Location in the unpack directory (external formats only)
+}
+
+// ArchiveContents is an opened archive whose members can be listed and
+// extracted one at a time. Native ZIPs are read in place; other formats are
+// unpacked with the archivers.json unpack command into a temp directory
+// that lives until Close is called.
+type ArchiveContents struct {
+	Members []ArchiveMember
+
+	archivePath string
+	native      bool
+	workDir     string
+}
+
+// OpenArchiveContents lists the members of archivePath. Directories are not
+// included. The caller must call Close when done.
+func OpenArchiveContents(archivePath string, cfg Config) (*ArchiveContents, error) {
+	at, ok := cfg.GetArchiveType(archivePath)
+	if !ok {
+		return nil, fmt.Errorf("unsupported archive type: %s", filepath.Ext(archivePath))
+	}
+
+	c := &ArchiveContents{archivePath: archivePath, native: at.Native}
+	if at.Native {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer r.Close()
+		for i, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			c.Members = append(c.Members, ArchiveMember{
+				Name:     sanitizeEntryName(f.Name),
+				Size:     int64(f.UncompressedSize64),
+				Modified: f.Modified,
+				zipIndex: i,
+			})
+		}
+		return c, nil
+	}
+
+	if at.ExtractCommand == "" {
+		return nil, fmt.Errorf("no unpack command configured for %s", filepath.Ext(archivePath))
+	}
+	workDir, err := os.MkdirTemp("", "ziplab-browse-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	c.workDir = workDir
+
+	p := NewProcessor(cfg, filepath.Dir(archivePath))
+	if err := p.runExternalCommand(at.ExtractCommand, at.ExtractArgs, archivePath, workDir, 0); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	err = filepath.WalkDir(workDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || !d.Type().IsRegular() {
+			return nil // Skip directories, symlinks and unreadable entries
+		}
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(workDir, path)
+		c.Members = append(c.Members, ArchiveMember{
+			Name:     sanitizeEntryName(filepath.ToSlash(rel)),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			path:     path,
+		})
+		return nil
+	})
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to read unpacked archive: %w", err)
+	}
+	sort.Slice(c.Members, func(i, j int) bool {
+		return strings.ToLower(c.Members[i].Name) < strings.ToLower(c.Members[j].Name)
+	})
+	return c, nil
+}
+
+// ExtractMember writes member n (1-based) to a new temp directory and
+// returns its path and a cleanup function that removes the directory. The
+// extracted file keeps the member's base name so it can be sent as-is.
+func (c *ArchiveContents) ExtractMember(n int) (string, func(), error) {
+	noop := func() {}
+	if n < 1 || n > len(c.Members) {
+		return "", noop, fmt.Errorf("member %d out of range (archive has %d members)", n, len(c.Members))
+	}
+	m := c.Members[n-1]
+
+	tmpDir, err := os.MkdirTemp("", "ziplab-member-*")
+	if err != nil {
+		return "", noop, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	// Base name only, so a crafted member path cannot escape tmpDir.
+	destPath := filepath.Join(tmpDir, filepath.Base(filepath.FromSlash(m.Name)))
+
+	var src io.ReadCloser
+	if c.native {
+		r, err := zip.OpenReader(c.archivePath)
+		if err != nil {
+			cleanup()
+			return "", noop, fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer r.Close()
+		if m.zipIndex >= len(r.File) {
+			cleanup()
+			return "", noop, fmt.Errorf("archive changed since it was opened")
+		}
+		src, err = r.File[m.zipIndex].Open()
+		if err != nil {
+			cleanup()
+			return "", noop, fmt.Errorf("failed to open member: %w", err)
+		}
+	} else {
+		src, err = os.Open(m.path)
+		if err != nil {
+			cleanup()
+			return "", noop, fmt.Errorf("failed to open member: %w", err)
+		}
+	}
+	defer src.Close()
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("failed to create output file: %w", err)
+	}
+	written, copyErr := io.Copy(out, io.LimitReader(src, maxMemberExtractBytes+1))
+	closeErr := out.Close()
+	switch {
+	case copyErr != nil:
+		cleanup()
+		return "", noop, fmt.Errorf("failed to extract member: %w", copyErr)
+	case closeErr != nil:
+		cleanup()
+		return "", noop, fmt.Errorf("failed to write member: %w", closeErr)
+	case written > maxMemberExtractBytes:
+		cleanup()
+		return "", noop, fmt.Errorf("member exceeds %d byte extraction limit", maxMemberExtractBytes)
+	}
+	return destPath, cleanup, nil
+}
+
+// Close removes any temp files created for an external archive.
+func (c *ArchiveContents) Close() {
+	if c.workDir != "" {
+		os.RemoveAll(c.workDir)
+		c.workDir = ""
+	}
+}
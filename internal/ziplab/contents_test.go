@@ -0,0 +1,108 @@
+package ziplab
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenArchiveContents_NativeZip(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "test.zip")
+	createTestZip(t, zipPath, map[string]string{
+		"docs/readme.txt": "hello world",
+		"FILE_ID.DIZ":     "Test archive",
+	})
+
+	c, err := OpenArchiveContents(zipPath, DefaultConfig())
+	if err != nil {
+		t.Fatalf("OpenArchiveContents: %v", err)
+	}
+	defer c.Close()
+
+	if len(c.Members) != 2 {
+		t.Fatalf("got %d members, want 2: %+v", len(c.Members), c.Members)
+	}
+
+	n := 0
+	for i, m := range c.Members {
+		if m.Name == "docs/readme.txt" {
+			n = i + 1
+		}
+	}
+	if n == 0 {
+		t.Fatalf("docs/readme.txt not listed: %+v", c.Members)
+	}
+
+	path, cleanup, err := c.ExtractMember(n)
+	if err != nil {
+		t.Fatalf("ExtractMember: %v", err)
+	}
+	defer cleanup()
+	if filepath.Base(path) != "readme.txt" {
+		t.Errorf("extracted name = %s, want readme.txt", filepath.Base(path))
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "hello world" {
+		t.Errorf("extracted content = %q", data)
+	}
+
+	cleanup()
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Error("cleanup should remove the temp directory")
+	}
+
+	if _, _, err := c.ExtractMember(3); err == nil {
+		t.Error("expected error for out-of-range member")
+	}
+}
+
+func TestOpenArchiveContents_ExternalCommand(t *testing.T) {
+	cpPath, err := exec.LookPath("cp")
+	if err != nil {
+		t.Skip("cp not available")
+	}
+
+	// A stand-in "archiver" whose unpack step copies the archive into the
+	// output directory, so the archive itself becomes the only member.
+	cfg := DefaultConfig()
+	cfg.ArchiveTypes = []ArchiveType{{
+		Extension:      ".tst",
+		ExtractCommand: cpPath,
+		ExtractArgs:    []string{"{ARCHIVE}", "{OUTDIR}"},
+	}}
+
+	dir := t.TempDir()
+	arcPath := filepath.Join(dir, "DATA.TST")
+	os.WriteFile(arcPath, []byte("payload"), 0644)
+
+	c, err := OpenArchiveContents(arcPath, cfg)
+	if err != nil {
+		t.Fatalf("OpenArchiveContents: %v", err)
+	}
+	if len(c.Members) != 1 || c.Members[0].Name != "DATA.TST" || c.Members[0].Size != 7 {
+		t.Fatalf("unexpected members: %+v", c.Members)
+	}
+
+	path, cleanup, err := c.ExtractMember(1)
+	if err != nil {
+		t.Fatalf("ExtractMember: %v", err)
+	}
+	defer cleanup()
+	if data, _ := os.ReadFile(path); string(data) != "payload" {
+		t.Errorf("extracted content = %q", data)
+	}
+
+	workDir := c.workDir
+	c.Close()
+	if _, err := os.Stat(workDir); !os.IsNotExist(err) {
+		t.Error("Close should remove the unpack directory")
+	}
+}
+
+func TestOpenArchiveContents_Unsupported(t *testing.T) {
+	if _, err := OpenArchiveContents("FILE.XYZ", DefaultConfig()); err == nil {
+		t.Error("expected error for unsupported archive type")
+	}
+}
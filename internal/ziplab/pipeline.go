@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -38,6 +39,24 @@ type StepResult struct {
 	Error   error
 }
 
+// Summary returns one line per step that ran ("Test Integrity: PASS"),
+// with the error appended to failed steps. It is stored with uploads so a
+// sysop validating the file later can see how it fared.
+func (r PipelineResult) Summary() string {
+	lines := make([]string, 0, len(r.StepResults))
+	for _, sr := range r.StepResults {
+		switch {
+		case sr.Status == StatusFail && sr.Error != nil:
+			lines = append(lines, fmt.Sprintf("%s: FAIL (%v)", sr.Name, sr.Error))
+		case sr.Status == StatusFail:
+			lines = append(lines, sr.Name+": FAIL")
+		default:
+			lines = append(lines, sr.Name+": PASS")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // StatusCallback is called when a step's status changes, allowing
 // the caller to update the ANSI display in real time.
 type StatusCallback func(step StepNumber, status Status)
@@ -2,6 +2,7 @@ package ziplab
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -125,6 +126,20 @@ func TestRunPipeline_StepTimingRecorded(t *testing.T) {
 	}
 }
 
+func TestPipelineResult_Summary(t *testing.T) {
+	result := PipelineResult{StepResults: []StepResult{
+		{Step: StepIntegrity, Name: "Test Integrity", Status: StatusPass},
+		{Step: StepVirusScan, Name: "Virus Scan", Status: StatusFail, Error: errors.New("infected")},
+	}}
+	want := "Test Integrity: PASS\nVirus Scan: FAIL (infected)"
+	if got := result.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if got := (PipelineResult{}).Summary(); got != "" {
+		t.Errorf("empty Summary() = %q, want empty", got)
+	}
+}
+
 func TestDisplayPipeline_WritesANSIAndStatus(t *testing.T) {
 	tmpDir := t.TempDir()
 	zipPath := filepath.Join(tmpDir, "test.zip")
@@ -520,7 +520,9 @@ func (p *Processor) addFileToZip(zipPath, name string, data []byte) (retErr erro
 }
 
 // runExternalCommand runs an external command with placeholder substitution.
-// timeoutSeconds of 0 uses the default (60s).
+// Both the ZipLab placeholders ({FILE}, {WORKDIR}) and the archivers.json
+// ones ({ARCHIVE}, {OUTDIR}) are expanded. timeoutSeconds of 0 uses the
+// default (60s).
 func (p *Processor) runExternalCommand(command string, args []string, archivePath, workDir string, timeoutSeconds int) error {
 	if command == "" {
 		return fmt.Errorf("no command configured")
@@ -529,7 +531,9 @@ func (p *Processor) runExternalCommand(command string, args []string, archivePat
 	expandedArgs := make([]string, len(args))
 	for i, arg := range args {
 		arg = strings.ReplaceAll(arg, "{FILE}", archivePath)
+		arg = strings.ReplaceAll(arg, "{ARCHIVE}", archivePath)
 		arg = strings.ReplaceAll(arg, "{WORKDIR}", workDir)
+		arg = strings.ReplaceAll(arg, "{OUTDIR}", workDir)
 		expandedArgs[i] = arg
 	}
 